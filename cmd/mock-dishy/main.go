@@ -0,0 +1,281 @@
+// Command mock-dishy simulates the SpaceX.API.Device.Device/Handle
+// get_status surface for development: point a collector (or a whole
+// daemon, via starfail.starlink.addr) at it and exercise dish behaviours
+// that are awkward to produce with hardware — a tree line sliding into
+// view, a reboot, a summer afternoon of thermal throttling. The wire
+// format mirrors the hand-rolled h2c client in
+// pkg/collector/starlink/pool.go.
+//
+// Usage:
+//
+//	mock-dishy -addr :9200 -scenario obstruction-ramp -period 10m
+//
+// Scenarios: steady, obstruction-ramp, reboot, thermal.
+package main
+
+import (
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net"
+	"os"
+	"time"
+)
+
+const (
+	frameData     = 0x0
+	frameHeaders  = 0x1
+	frameSettings = 0x4
+
+	flagEndStream  = 0x1
+	flagEndHeaders = 0x4
+)
+
+var h2Preface = []byte("PRI * HTTP/2.0\r\n\r\nSM\r\n\r\n")
+
+// dish is one point-in-time dish state to serialize.
+type dish struct {
+	uptimeS        int64
+	latencyMS      float64
+	downMbps       float64
+	upMbps         float64
+	snr            float64
+	obstructionPct float64
+	alerts         []int // DishAlerts field numbers (see pkg/collector/starlink/alerts.go)
+	drop           bool  // hang up instead of answering (mid-reboot)
+}
+
+// healthy is the baseline every scenario starts from.
+func healthy(elapsed time.Duration) dish {
+	return dish{
+		uptimeS:        int64(elapsed.Seconds()) + 3600,
+		latencyMS:      35,
+		downMbps:       150,
+		upMbps:         20,
+		snr:            9,
+		obstructionPct: 0.5,
+	}
+}
+
+// scenario maps elapsed run time to dish state.
+type scenario func(elapsed time.Duration) dish
+
+// scenarios are all cyclic over -period so a long-running collector sees
+// the behaviour repeatedly.
+var scenarios = map[string]func(period time.Duration) scenario{
+	"steady": func(time.Duration) scenario {
+		return healthy
+	},
+	// A tree line (or a parked truck) slides into the field of view:
+	// obstruction climbs from clear to 40% over the period while latency
+	// and loss of SNR follow, then the view clears again.
+	"obstruction-ramp": func(period time.Duration) scenario {
+		return func(elapsed time.Duration) dish {
+			d := healthy(elapsed)
+			frac := cyclePos(elapsed, period)
+			d.obstructionPct = 40 * frac
+			d.latencyMS += 60 * frac
+			d.snr -= 5 * frac
+			return d
+		}
+	},
+	// The dish reboots once per period: 30 seconds of dead air, then it
+	// comes back with its uptime counter restarted.
+	"reboot": func(period time.Duration) scenario {
+		return func(elapsed time.Duration) dish {
+			into := elapsed % period
+			d := healthy(elapsed)
+			if into < 30*time.Second {
+				d.drop = true
+				return d
+			}
+			d.uptimeS = int64((into - 30*time.Second).Seconds())
+			return d
+		}
+	},
+	// Afternoon heat: the middle half of each period runs thermally
+	// throttled at half throughput, with a short thermal_shutdown spike
+	// at the peak.
+	"thermal": func(period time.Duration) scenario {
+		return func(elapsed time.Duration) dish {
+			d := healthy(elapsed)
+			frac := cyclePos(elapsed, period)
+			if frac < 0.25 || frac > 0.75 {
+				return d
+			}
+			d.alerts = []int{3} // thermal_throttle
+			d.downMbps /= 2
+			d.upMbps /= 2
+			if frac > 0.45 && frac < 0.55 {
+				d.alerts = []int{2} // thermal_shutdown
+				d.latencyMS += 400
+				d.downMbps = 1
+			}
+			return d
+		}
+	},
+}
+
+// cyclePos is the position inside the current period as a 0..1..0
+// triangle, so ramps rise and fall instead of sawtoothing.
+func cyclePos(elapsed, period time.Duration) float64 {
+	frac := float64(elapsed%period) / float64(period)
+	return 1 - math.Abs(2*frac-1)
+}
+
+func main() {
+	addr := flag.String("addr", ":9200", "listen address")
+	name := flag.String("scenario", "steady", "scenario to play")
+	period := flag.Duration("period", 10*time.Minute, "scenario cycle length")
+	flag.Parse()
+
+	mk, ok := scenarios[*name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "mock-dishy: unknown scenario %q; have:", *name)
+		for n := range scenarios {
+			fmt.Fprintf(os.Stderr, " %s", n)
+		}
+		fmt.Fprintln(os.Stderr)
+		os.Exit(1)
+	}
+	scen := mk(*period)
+	start := time.Now()
+
+	ln, err := net.Listen("tcp", *addr)
+	if err != nil {
+		log.Fatalf("mock-dishy: %v", err)
+	}
+	fmt.Printf("mock-dishy: %s scenario on %s (period %s)\n", *name, ln.Addr(), *period)
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			log.Fatalf("mock-dishy: accept: %v", err)
+		}
+		go serve(conn, func() dish { return scen(time.Since(start)) })
+	}
+}
+
+// serve handles one pooled connection: preface, then request/response
+// pairs until the client hangs up or the scenario drops the link.
+func serve(conn net.Conn, state func() dish) {
+	defer conn.Close()
+	preface := make([]byte, len(h2Preface))
+	if _, err := io.ReadFull(conn, preface); err != nil || string(preface) != string(h2Preface) {
+		return
+	}
+	if _, err := conn.Write(appendFrame(nil, frameSettings, 0, 0, nil)); err != nil {
+		return
+	}
+
+	hdr := make([]byte, 9)
+	stream := uint32(1)
+	for {
+		if _, err := io.ReadFull(conn, hdr); err != nil {
+			return
+		}
+		length := int(hdr[0])<<16 | int(hdr[1])<<8 | int(hdr[2])
+		ftype, flags := hdr[3], hdr[4]
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(conn, payload); err != nil {
+			return
+		}
+		switch ftype {
+		case frameHeaders:
+			stream = binary.BigEndian.Uint32(hdr[5:]) &^ (1 << 31)
+		case frameData:
+			if flags&flagEndStream == 0 {
+				continue
+			}
+			d := state()
+			if d.drop {
+				return
+			}
+			if err := respond(conn, stream, encodeStatus(d)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// respond sends HEADERS (:status 200) then the length-prefixed message
+// with END_STREAM.
+func respond(conn net.Conn, stream uint32, msg []byte) error {
+	var buf []byte
+	buf = appendFrame(buf, frameHeaders, flagEndHeaders, stream, []byte{0x88})
+	body := make([]byte, 5+len(msg))
+	binary.BigEndian.PutUint32(body[1:5], uint32(len(msg)))
+	copy(body[5:], msg)
+	buf = appendFrame(buf, frameData, flagEndStream, stream, body)
+	_, err := conn.Write(buf)
+	return err
+}
+
+// encodeStatus serializes Response{dish_get_status{...}} with the field
+// numbers the collector parses.
+func encodeStatus(d dish) []byte {
+	var inner []byte
+	inner = appendMsg(inner, 1, appendVarintField(nil, 1, uint64(d.uptimeS))) // device_state{uptime_s}
+	inner = appendFloat32Field(inner, 9, d.latencyMS)                         // pop_ping_latency_ms
+	inner = appendFloat32Field(inner, 10, d.downMbps*1e6)                     // downlink_throughput_bps
+	inner = appendFloat32Field(inner, 11, d.upMbps*1e6)                       // uplink_throughput_bps
+	inner = appendDoubleField(inner, 12, d.snr)                               // snr
+
+	var obs []byte
+	obs = appendFloat32Field(obs, 1, d.obstructionPct/100) // fraction_obstructed
+	obs = appendFloat32Field(obs, 5, 600)                  // valid_s
+	inner = appendMsg(inner, 7, obs)
+
+	if len(d.alerts) > 0 {
+		var alerts []byte
+		for _, num := range d.alerts {
+			alerts = appendVarintField(alerts, num, 1)
+		}
+		inner = appendMsg(inner, 8, alerts)
+	}
+	return appendMsg(nil, 1004, inner) // Response.dish_get_status
+}
+
+// Minimal protobuf writers; wire types 0 (varint), 5 (32-bit), 1
+// (64-bit) and 2 (length-delimited).
+
+func appendVarint(buf []byte, v uint64) []byte {
+	for v >= 0x80 {
+		buf = append(buf, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(buf, byte(v))
+}
+
+func appendVarintField(buf []byte, num int, v uint64) []byte {
+	buf = appendVarint(buf, uint64(num)<<3|0)
+	return appendVarint(buf, v)
+}
+
+func appendFloat32Field(buf []byte, num int, v float64) []byte {
+	buf = appendVarint(buf, uint64(num)<<3|5)
+	return binary.LittleEndian.AppendUint32(buf, math.Float32bits(float32(v)))
+}
+
+func appendDoubleField(buf []byte, num int, v float64) []byte {
+	buf = appendVarint(buf, uint64(num)<<3|1)
+	return binary.LittleEndian.AppendUint64(buf, math.Float64bits(v))
+}
+
+func appendMsg(buf []byte, num int, payload []byte) []byte {
+	buf = appendVarint(buf, uint64(num)<<3|2)
+	buf = appendVarint(buf, uint64(len(payload)))
+	return append(buf, payload...)
+}
+
+// appendFrame mirrors the client's framing: 24-bit length, type, flags,
+// 31-bit stream id.
+func appendFrame(buf []byte, ftype, flags byte, stream uint32, payload []byte) []byte {
+	buf = append(buf,
+		byte(len(payload)>>16), byte(len(payload)>>8), byte(len(payload)),
+		ftype, flags,
+		byte(stream>>24), byte(stream>>16), byte(stream>>8), byte(stream))
+	return append(buf, payload...)
+}
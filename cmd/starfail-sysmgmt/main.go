@@ -0,0 +1,47 @@
+// Command starfail-sysmgmt is the companion system-management daemon: it
+// watches router health (disk, memory, services) and applies automated
+// fixes so the failover daemon itself stays alive unattended.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/sysmgmt"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/webhook"
+)
+
+var version = "dev"
+
+func main() {
+	var (
+		check       = flag.Bool("check", false, "run checks once, print issues as JSON, and exit")
+		showVersion = flag.Bool("version", false, "print version and exit")
+	)
+	flag.Parse()
+	if *showVersion {
+		fmt.Println("starfail-sysmgmt", version)
+		return
+	}
+
+	cfg, err := sysmgmt.LoadConfig()
+	if err != nil {
+		log.Fatalf("load config: %v", err)
+	}
+	mgr := sysmgmt.NewManager(cfg)
+	mgr.SetReporter(webhook.NewFromUCI())
+
+	if *check {
+		issues := mgr.CheckHealth()
+		b, _ := json.MarshalIndent(issues, "", "  ")
+		fmt.Println(string(b))
+		return
+	}
+	if !cfg.Enabled {
+		log.Println("sysmgmt disabled in UCI; exiting")
+		return
+	}
+	mgr.Run()
+}
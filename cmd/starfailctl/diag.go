@@ -0,0 +1,131 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/collector/starlink"
+)
+
+// secretOption matches UCI option lines whose values must not leave the
+// router: credentials, API tokens, wifi keys.
+var secretOption = regexp.MustCompile(`(?i)^(\s*option\s+'?\w*(?:token|secret|password|key|psk|user)\w*'?\s+)'.*'`)
+
+// diagCmd assembles a support bundle: daemon state, redacted config, dish
+// status, mwan3 status and logs in one tar.gz for attaching to issues.
+func diagCmd(args []string) {
+	fs := flag.NewFlagSet("diag", flag.ExitOnError)
+	out := fs.String("o", "", "output path (default /tmp/starfail-diag-<timestamp>.tar.gz)")
+	_ = fs.Parse(args)
+
+	path := *out
+	if path == "" {
+		path = fmt.Sprintf("/tmp/starfail-diag-%s.tar.gz", time.Now().Format("20060102-150405"))
+	}
+
+	entries := []struct {
+		name    string
+		collect func() ([]byte, error)
+	}{
+		{"status.json", func() ([]byte, error) { return daemonJSON("status") }},
+		{"members.json", func() ([]byte, error) { return daemonJSON("members") }},
+		{"events.json", func() ([]byte, error) { return daemonJSON("events") }},
+		{"heatmap.json", func() ([]byte, error) { return daemonJSON("heatmap") }},
+		{"config.txt", redactedConfig},
+		{"dish_status.json", dishStatus},
+		{"mwan3_status.json", func() ([]byte, error) {
+			return exec.Command("ubus", "call", "mwan3", "status").Output()
+		}},
+		{"logread.txt", func() ([]byte, error) {
+			return exec.Command("logread", "-e", "starfail").Output()
+		}},
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "create %s: %v\n", path, err)
+		os.Exit(1)
+	}
+	defer f.Close()
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+
+	now := time.Now()
+	for _, e := range entries {
+		name, body := e.name, []byte(nil)
+		if b, err := e.collect(); err != nil {
+			// A failing source must not sink the bundle; record the error
+			// in its place so support can see what was unavailable.
+			name += ".err"
+			body = []byte(err.Error() + "\n")
+		} else {
+			body = b
+		}
+		hdr := &tar.Header{Name: name, Mode: 0o644, Size: int64(len(body)), ModTime: now}
+		if err := tw.WriteHeader(hdr); err != nil {
+			fmt.Fprintf(os.Stderr, "write bundle: %v\n", err)
+			os.Exit(1)
+		}
+		if _, err := tw.Write(body); err != nil {
+			fmt.Fprintf(os.Stderr, "write bundle: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "write bundle: %v\n", err)
+		os.Exit(1)
+	}
+	if err := gz.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "write bundle: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(path)
+}
+
+// daemonJSON fetches one method from the daemon socket, pretty-printed.
+func daemonJSON(method string) ([]byte, error) {
+	raw, err := call(method, nil)
+	if err != nil {
+		return nil, err
+	}
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return raw, nil
+	}
+	return json.MarshalIndent(v, "", "  ")
+}
+
+// redactedConfig exports the starfail UCI package with secret option
+// values replaced, so bundles are safe to attach to public issues.
+func redactedConfig() ([]byte, error) {
+	out, err := exec.Command("uci", "export", "starfail").Output()
+	if err != nil {
+		return nil, fmt.Errorf("uci export: %w", err)
+	}
+	lines := strings.Split(string(out), "\n")
+	for i, line := range lines {
+		lines[i] = secretOption.ReplaceAllString(line, "${1}'REDACTED'")
+	}
+	return []byte(strings.Join(lines, "\n")), nil
+}
+
+// dishStatus queries the dish directly so the bundle is useful even when
+// the daemon is down.
+func dishStatus() ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	st, err := starlink.New("").GetStarlinkInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(st, "", "  ")
+}
@@ -0,0 +1,314 @@
+// Command starfailctl is the operator CLI for starfaild. It talks to the
+// daemon's control socket for live data and works standalone for offline
+// tasks such as previewing notification templates.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/metrics"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/notifications"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/secrets"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/ubus"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/uci"
+)
+
+var version = "dev"
+
+func usage() {
+	fmt.Fprintf(os.Stderr, `usage: starfailctl <command> [flags]
+
+commands:
+  status            show daemon status
+  members           list members with scores
+  events            show recent events
+  overview          status, members, scores and recent events in one call
+  explain <member>  break down a member's score
+  ack <id>          acknowledge an escalating notification
+  live <member>     follow live samples for a member (see live -h)
+  mapping           trace interface -> device -> mwan3 -> member chains
+  config docs       print the canonical UCI option reference (-json for machines)
+  config check      validate /etc/config/starfail against the reference
+  troubleshoot      run a whitelisted read-only diagnostic (no action lists them)
+  stow | unstow     park or deploy the Starlink dish (traffic moves away first)
+  notify            render or send a notification (see notify -h)
+  diag              write a support bundle tar.gz (see diag -h)
+  secret encrypt    encrypt a credential for storing in UCI
+  version           print version
+`)
+	os.Exit(2)
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	switch os.Args[1] {
+	case "status":
+		callAndPrint("status", nil)
+	case "members":
+		callAndPrint("members", nil)
+	case "events":
+		callAndPrint("events", nil)
+	case "overview":
+		callAndPrint("overview", nil)
+	case "explain":
+		if len(os.Args) < 3 {
+			fmt.Fprintln(os.Stderr, "usage: starfailctl explain <member>")
+			os.Exit(2)
+		}
+		callAndPrint("explain", map[string]any{"member": os.Args[2]})
+	case "ack":
+		if len(os.Args) < 3 {
+			fmt.Fprintln(os.Stderr, "usage: starfailctl ack <id>")
+			os.Exit(2)
+		}
+		callAndPrint("ack", map[string]any{"id": os.Args[2]})
+	case "live":
+		liveCmd(os.Args[2:])
+	case "mapping":
+		callAndPrint("mapping", nil)
+	case "config":
+		configCmd(os.Args[2:])
+	case "troubleshoot":
+		if len(os.Args) < 3 {
+			callAndPrint("troubleshoot", nil)
+		} else {
+			callAndPrint("troubleshoot", map[string]any{"action": os.Args[2]})
+		}
+	case "stow":
+		callAndPrint("stow", nil)
+	case "unstow":
+		callAndPrint("stow", map[string]any{"unstow": true})
+	case "notify":
+		notifyCmd(os.Args[2:])
+	case "diag":
+		diagCmd(os.Args[2:])
+	case "secret":
+		secretCmd(os.Args[2:])
+	case "version":
+		fmt.Println("starfailctl", version)
+	default:
+		usage()
+	}
+}
+
+// notifyCmd renders a template with sample or provided data; with
+// --preview the result is printed instead of sent.
+func notifyCmd(args []string) {
+	fs := flag.NewFlagSet("notify", flag.ExitOnError)
+	var (
+		preview = fs.Bool("preview", false, "render the template without sending")
+		event   = fs.String("event", "failover", "event type to render")
+		channel = fs.String("channel", "pushover", "channel whose template to use")
+		member  = fs.String("member", "starlink", "member name")
+		from    = fs.String("from", "cellular", "previous member")
+		reason  = fs.String("reason", "better score", "event reason")
+		score   = fs.Float64("score", 82.5, "member score")
+	)
+	_ = fs.Parse(args)
+
+	data := notifications.EventData{
+		Event:         *event,
+		Member:        *member,
+		From:          *from,
+		Reason:        *reason,
+		Score:         *score,
+		Metrics:       sampleMetrics(),
+		CorrelationID: notifications.NewCorrelationID(),
+		Timestamp:     time.Now(),
+	}
+	title, body, err := notifications.RenderTemplate(*channel, *event, data)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "render: %v\n", err)
+		os.Exit(1)
+	}
+	if *preview {
+		fmt.Printf("--- %s/%s ---\n%s\n\n%s\n", *channel, *event, title, body)
+		return
+	}
+	n := notifications.NewNotifier()
+	n.SendNotification(title, body, notifications.PriorityNormal)
+	fmt.Println("queued")
+}
+
+// configCmd works standalone against the local uci binary: "docs" emits
+// the canonical option reference derived from the pkg/uci struct tags,
+// "check" validates the live config against it.
+func configCmd(args []string) {
+	if len(args) < 1 {
+		fmt.Fprintln(os.Stderr, "usage: starfailctl config <docs|check> [flags]")
+		os.Exit(2)
+	}
+	switch args[0] {
+	case "docs":
+		fs := flag.NewFlagSet("config docs", flag.ExitOnError)
+		jsonOut := fs.Bool("json", false, "emit JSON instead of Markdown")
+		_ = fs.Parse(args[1:])
+		if *jsonOut {
+			_, _ = os.Stdout.Write(uci.OptionsJSON())
+			return
+		}
+		fmt.Print(uci.OptionsMarkdown())
+	case "check":
+		problems, err := uci.CheckConfig()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "read config: %v\n", err)
+			os.Exit(1)
+		}
+		if len(problems) == 0 {
+			fmt.Println("configuration ok")
+			return
+		}
+		for _, p := range problems {
+			fmt.Fprintln(os.Stderr, p)
+		}
+		os.Exit(1)
+	default:
+		fmt.Fprintln(os.Stderr, "usage: starfailctl config <docs|check> [flags]")
+		os.Exit(2)
+	}
+}
+
+// secretCmd encrypts a credential with the device key so the UCI config
+// holds no plaintext; paste the output as the option value.
+func secretCmd(args []string) {
+	if len(args) != 2 || args[0] != "encrypt" {
+		fmt.Fprintln(os.Stderr, "usage: starfailctl secret encrypt <value>")
+		os.Exit(2)
+	}
+	enc, err := secrets.Encrypt(args[1])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "encrypt: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(enc)
+}
+
+// sampleMetrics gives templates something realistic to interpolate during
+// previews.
+func sampleMetrics() *metrics.Metrics {
+	return &metrics.Metrics{
+		Timestamp:   time.Now(),
+		DataSource:  metrics.SourceGRPC,
+		LatencyMS:   metrics.Milliseconds(47).Ptr(),
+		LossPercent: metrics.Percent(0.5).Ptr(),
+	}
+}
+
+// liveCmd follows the metrics_live stream, printing one JSON line per
+// sample. The daemon decimates long windows, so the printed rate may be
+// lower than one line per second.
+func liveCmd(args []string) {
+	fs := flag.NewFlagSet("live", flag.ExitOnError)
+	var (
+		seconds  = fs.Int("seconds", 30, "how long to follow")
+		interval = fs.Int("interval", 1, "seconds between samples")
+	)
+	_ = fs.Parse(args)
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: starfailctl live [-seconds N] [-interval N] <member>")
+		os.Exit(2)
+	}
+	err := callStream("metrics_live", map[string]any{
+		"member": fs.Arg(0), "seconds": *seconds, "interval": *interval,
+	}, func(chunk json.RawMessage) {
+		fmt.Println(string(chunk))
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// call sends one request to the daemon socket and returns the raw result.
+func call(method string, args map[string]any) (json.RawMessage, error) {
+	conn, err := net.DialTimeout("unix", ubus.DefaultSocket, 3*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("connect to starfaild: %w", err)
+	}
+	defer conn.Close()
+	req := map[string]any{"method": method}
+	if args != nil {
+		req["args"] = args
+	}
+	b, _ := json.Marshal(req)
+	if _, err := conn.Write(append(b, '\n')); err != nil {
+		return nil, fmt.Errorf("write: %w", err)
+	}
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	if err != nil {
+		return nil, fmt.Errorf("read: %w", err)
+	}
+	var resp struct {
+		Result json.RawMessage `json:"result"`
+		Error  string          `json:"error"`
+	}
+	if err := json.Unmarshal(line, &resp); err != nil {
+		return nil, fmt.Errorf("bad reply: %w", err)
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("%s", resp.Error)
+	}
+	return resp.Result, nil
+}
+
+// callStream sends one request and invokes fn per streamed chunk until the
+// daemon sends the final (non-"more") response line.
+func callStream(method string, args map[string]any, fn func(json.RawMessage)) error {
+	conn, err := net.DialTimeout("unix", ubus.DefaultSocket, 3*time.Second)
+	if err != nil {
+		return fmt.Errorf("connect to starfaild: %w", err)
+	}
+	defer conn.Close()
+	req := map[string]any{"method": method}
+	if args != nil {
+		req["args"] = args
+	}
+	b, _ := json.Marshal(req)
+	if _, err := conn.Write(append(b, '\n')); err != nil {
+		return fmt.Errorf("write: %w", err)
+	}
+	r := bufio.NewReader(conn)
+	for {
+		line, err := r.ReadBytes('\n')
+		if err != nil {
+			return fmt.Errorf("read: %w", err)
+		}
+		var resp struct {
+			Result json.RawMessage `json:"result"`
+			Error  string          `json:"error"`
+			More   bool            `json:"more"`
+		}
+		if err := json.Unmarshal(line, &resp); err != nil {
+			return fmt.Errorf("bad reply: %w", err)
+		}
+		if resp.Error != "" {
+			return fmt.Errorf("%s", resp.Error)
+		}
+		if !resp.More {
+			return nil
+		}
+		fn(resp.Result)
+	}
+}
+
+// callAndPrint sends one request to the daemon socket and pretty-prints
+// the JSON reply.
+func callAndPrint(method string, args map[string]any) {
+	result, err := call(method, args)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	var pretty any
+	_ = json.Unmarshal(result, &pretty)
+	out, _ := json.MarshalIndent(pretty, "", "  ")
+	fmt.Println(string(out))
+}
@@ -0,0 +1,1027 @@
+// Command starfaild is the Go failover daemon for RUTOS routers: it scores
+// every mwan3 WAN member from class-specific collectors (Starlink dish API,
+// gsmctl, ping probes) and steers mwan3 toward the best link, replacing the
+// cron-driven starlink_monitor shell scripts.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/archive"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/capture"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/collector"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/collector/cellular"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/collector/generic"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/collector/starlink"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/controller"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/databalance"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/diag"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/engine"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/features"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/flowstats"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/gps"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/heartbeat"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/indicator"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/monitoring"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/mqtt"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/notifications"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/predictive"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/probe"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/report"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/roam"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/secrets"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/simulate"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/sla"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/smscmd"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/telemetry"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/ubus"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/uci"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/webhook"
+)
+
+var version = "dev" // set via -ldflags at release build
+
+func main() {
+	var (
+		socket       = flag.String("socket", ubus.DefaultSocket, "control socket path")
+		simulateArg  = flag.String("simulate", "", "run against a scenario file instead of real collectors")
+		showVersion  = flag.Bool("version", false, "print version and exit")
+		showFeatures = flag.Bool("features", false, "with -version, also print the compiled feature matrix")
+	)
+	flag.Parse()
+	if *showVersion || *showFeatures {
+		fmt.Println("starfaild", version)
+		if *showFeatures {
+			fmt.Println(features.String())
+		}
+		return
+	}
+
+	// Scrub registered secrets from everything the daemon logs.
+	log.SetOutput(secrets.NewWriter(os.Stderr))
+
+	if applied, err := uci.Migrate(); err != nil {
+		log.Printf("config migration: %v", err)
+	} else {
+		for _, step := range applied {
+			log.Printf("config migrated: %s", step)
+		}
+	}
+
+	cfg, err := uci.LoadConfig()
+	if err != nil {
+		log.Fatalf("load config: %v", err)
+	}
+	if pkgs := uci.Commits.Recover(); len(pkgs) > 0 {
+		log.Printf("uci: completed interrupted commit of %s", strings.Join(pkgs, ", "))
+	}
+
+	var scenario *simulate.Scenario
+	if *simulateArg != "" {
+		scenario, err = simulate.LoadScenario(*simulateArg)
+		if err != nil {
+			log.Fatalf("load scenario: %v", err)
+		}
+		// Scenario members replace whatever UCI (if any) declared.
+		cfg.Enabled = true
+		cfg.Members = cfg.Members[:0]
+		for _, m := range scenario.Members {
+			cfg.Members = append(cfg.Members, uci.MemberConfig{
+				Name: m.Name, Iface: m.Name, Class: m.Class, Weight: 50,
+			})
+		}
+		log.Printf("simulate: running scenario %q with %d members", scenario.Name, len(scenario.Members))
+	}
+
+	if !cfg.Enabled {
+		log.Println("starfail disabled in UCI; idling")
+		select {}
+	}
+
+	probe.SetProfiles(probeProfiles(cfg))
+	probe.SetDSCP(cfg.ProbeDSCP)
+	probe.SetDailyBudget(int64(cfg.ProbeBudgetMB * 1e6))
+
+	store := telemetry.NewStore("")
+	if err := store.LoadEvents(); err != nil {
+		log.Printf("load events: %v", err)
+	}
+
+	// Crash forensics: recover the previous run's blackbox ring before
+	// reusing the file, then mirror samples, events and log output into
+	// the fresh mapping.
+	var bb *telemetry.Blackbox
+	if scenario == nil {
+		if lines, clean := telemetry.RecoverBlackbox(""); !clean && len(lines) > 0 {
+			if path, err := telemetry.WriteCrashReport("", lines); err != nil {
+				log.Printf("blackbox: write crash report: %v", err)
+			} else {
+				log.Printf("blackbox: previous run exited uncleanly; crash report at %s", path)
+				store.AddEvent(telemetry.Event{
+					Timestamp: time.Now(),
+					Type:      "crash_report",
+					Reason:    "previous run exited uncleanly",
+					Details:   map[string]any{"path": path, "lines": len(lines)},
+				})
+			}
+		}
+		var err error
+		if bb, err = telemetry.OpenBlackbox(""); err != nil {
+			log.Printf("blackbox: %v", err)
+		} else {
+			store.SetBlackbox(bb)
+			log.SetOutput(io.MultiWriter(os.Stderr, bb))
+		}
+	}
+
+	if scenario == nil && uci.GetBool("starfail.main.mwan3_hygiene", false) {
+		fixes, err := controller.Mwan3Hygiene(true, "/etc/starfail")
+		if err != nil {
+			log.Printf("mwan3 hygiene: %v", err)
+		}
+		for _, f := range fixes {
+			for _, n := range f.Notes {
+				log.Printf("mwan3 hygiene: %s: %s", f.Iface, n)
+			}
+			store.AddEvent(telemetry.Event{
+				Type: "mwan3_hygiene", Member: f.Iface,
+				Reason:  "track_ip audit",
+				Details: map[string]any{"removed": f.Removed, "added": f.Added, "interval": f.Interval, "notes": f.Notes},
+			})
+		}
+	}
+	if err := store.LoadHeatmap(); err != nil {
+		log.Printf("load heatmap: %v", err)
+	}
+
+	reg := collector.NewRegistry()
+	var sl *starlink.Collector
+	if scenario != nil {
+		for _, c := range simulate.NewCollectors(scenario) {
+			reg.Register(c)
+		}
+	} else {
+		dishAddr, _ := uci.Get("starfail.starlink.addr")
+		sl = starlink.New(dishAddr)
+		sl.AllowHeuristic = uci.GetBool("starfail.starlink.allow_heuristic", false)
+		if addr, ok := uci.Get("starfail.starlink.router_addr"); ok && addr != "" {
+			sl.Router = starlink.NewRouterClient(addr)
+		}
+		for _, mc := range cfg.Members {
+			if mc.Class == "starlink" && mc.VRF != "" {
+				sl.SetDevice(mc.VRF)
+			}
+		}
+		reg.Register(sl)
+		reg.Register(cellular.New())
+		reg.Register(generic.New())
+	}
+
+	ctl := controller.New(nil)
+	ctl.Noop = scenario != nil
+	// A crash mid-switch leaves a persisted intent; finish it before the
+	// engine starts making its own decisions on a half-modified mwan3.
+	if target, err := ctl.Recover(); target != "" {
+		if err != nil {
+			log.Printf("controller: recover interrupted switch to %s: %v", target, err)
+		} else {
+			store.AddEvent(telemetry.Event{
+				Timestamp: time.Now(), Type: "switch_recovered", Member: target,
+				Reason: "completed a switch interrupted by a crash",
+			})
+		}
+	}
+	notifier := notifications.NewNotifier()
+	eng := engine.New(cfg, reg, ctl, store, notifier)
+	// The engine's roster is the one member registry; the controller reads
+	// member names from it live (constructed earlier, so wired here).
+	ctl.SetMemberSource(eng.Roster().Names)
+	if cfg.DryRun {
+		log.Println("dry-run mode: recording would_failover events, routing untouched")
+	}
+
+	// Runtime feature toggles (ubus set_feature): each compiled-in
+	// subsystem registers start/stop hooks so it can be switched off on a
+	// live router without a restart.
+	rt := features.NewRuntime()
+	if features.Predictive {
+		rt.Register("predictive",
+			func() { eng.SetAssessor(predictive.FromUCI()) },
+			func() { eng.SetAssessor(predictive.Disabled{}) })
+		_ = rt.Set("predictive", true)
+	}
+	if features.GPS {
+		// GPS has no goroutine of its own; call sites consult the toggle.
+		rt.Register("gps", func() {}, func() {})
+		_ = rt.Set("gps", true)
+	}
+
+	slaTracker := sla.NewTracker(sla.Objectives(cfg), notifier, "")
+	eng.SetSLA(slaTracker)
+
+	mon := monitoring.NewServer(eng)
+	mon.Starlink = sl
+	mon.SLA = slaTracker
+	mon.Store = store
+	if fs := flowstats.NewFromUCI(); fs != nil && scenario == nil {
+		devs := make([]string, 0, len(eng.Members()))
+		for _, m := range eng.Members() {
+			devs = append(devs, m.BindIface())
+		}
+		if err := fs.Attach(devs...); err != nil {
+			log.Printf("flowstats: %v (continuing without eBPF stats)", err)
+		} else {
+			mon.Flow = fs
+		}
+	}
+	if features.Monitoring {
+		rt.Register("monitoring", mon.Start, mon.Stop)
+		_ = rt.Set("monitoring", true)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	notifier.Start(ctx)
+
+	tap := telemetry.NewTap()
+	store.SetTap(tap)
+	go func() {
+		if err := tap.Serve(ctx, telemetry.DefaultTapSocket); err != nil && ctx.Err() == nil {
+			log.Printf("telemetry tap: %v", err)
+		}
+	}()
+
+	balances := databalance.NewScheduler(store, notifier)
+	if sims := databalance.LoadSIMs(); len(sims) > 0 {
+		go balances.Run(ctx, sims)
+	}
+
+	reports := report.NewGenerator(cfg, eng, store, notifier, "")
+	go reports.Run(ctx)
+
+	if wifiNets := controller.LoadWifiNetworks(); len(wifiNets) > 0 && scenario == nil {
+		onboarder := controller.NewWifiOnboarder(ctl, wifiNets)
+		onboarder.OnJoin = func(name, iface string) {
+			eng.AddMember(&engine.Member{
+				Name: name, Iface: iface, Class: "wifi", Weight: 50,
+				Lifecycle: engine.Lifecycle{State: engine.StateEligible},
+			})
+			store.AddEvent(telemetry.Event{Type: "wifi_joined", Member: name})
+		}
+		onboarder.OnLeave = func(name string) {
+			eng.RemoveMember(name)
+			store.AddEvent(telemetry.Event{Type: "wifi_left", Member: name})
+		}
+		go onboarder.Run(ctx)
+	}
+
+	capturer := capture.NewFromUCI()
+	if capturer != nil && scenario == nil {
+		eng.SetFailoverHook(func(_, iface, corr string) string {
+			return capturer.Start(ctx, iface, corr)
+		})
+	}
+
+	if wgr := controller.NewWireGuardRefresher(); wgr != nil && scenario == nil {
+		eng.OnSwitch(func(string) { wgr.Refresh() })
+	}
+	if voip := controller.NewVoipHooks(); voip != nil && scenario == nil {
+		eng.OnSwitch(voip.Run)
+	}
+	if in := controller.NewInboundFromUCI(); in != nil && scenario == nil {
+		eng.OnSwitch(in.Apply)
+	}
+	if dnsm := controller.NewDNSFromUCI(); dnsm != nil && scenario == nil {
+		eng.OnSwitch(func(member string) {
+			servers := dnsm.Apply(member)
+			if len(servers) == 0 {
+				return
+			}
+			store.AddEvent(telemetry.Event{
+				Timestamp: time.Now(),
+				Type:      "dns_reordered",
+				Member:    member,
+				Reason:    "dnsmasq upstreams follow active WAN",
+				Details:   map[string]any{"servers": servers},
+			})
+		})
+		go dnsm.Run(ctx)
+	}
+	if up := controller.NewUPnPFromUCI(); up != nil && scenario == nil {
+		eng.OnSwitch(func(member string) {
+			leases := up.Migrate(member)
+			if len(leases) == 0 {
+				return
+			}
+			services := make([]string, 0, len(leases))
+			for _, l := range leases {
+				desc := l.Desc
+				if desc == "" {
+					desc = l.Proto + "/" + l.ExtPort
+				}
+				services = append(services, desc)
+			}
+			store.AddEvent(telemetry.Event{
+				Timestamp: time.Now(),
+				Type:      "upnp_migrated",
+				Member:    member,
+				Reason:    "leases re-announced on new WAN",
+				Details:   map[string]any{"services": services},
+			})
+			notifier.SendNotification("UPnP leases migrated",
+				fmt.Sprintf("Re-announced on %s; may need reconnection: %s",
+					member, strings.Join(services, ", ")),
+				notifications.PriorityLow)
+		})
+	}
+	if sv := controller.NewShadowVerifier(); sv != nil && scenario == nil {
+		eng.SetVerifier(sv)
+	}
+	if pm := probe.NewPassiveFromUCI(); pm != nil && scenario == nil {
+		pm.OnBurst(func(count int) {
+			store.AddEvent(telemetry.Event{
+				Timestamp: time.Now(),
+				Type:      "passive_burst",
+				Reason:    "connection failure burst on active member",
+				Details:   map[string]any{"failures": count},
+			})
+			eng.Poke()
+		})
+		go pm.Run(ctx)
+	}
+	if refl := probe.NewReflectorFromUCI(); refl != nil && scenario == nil {
+		lastDirection := make(map[string]string)
+		go refl.Run(ctx, func() map[string]string {
+			ifaces := make(map[string]string)
+			for _, m := range eng.Members() {
+				ifaces[m.Name] = m.BindIface()
+			}
+			return ifaces
+		}, func(results map[string]probe.Result) {
+			details := make(map[string]any, len(results))
+			for member, r := range results {
+				details[member] = r
+			}
+			store.AddEvent(telemetry.Event{Type: "reflector_compare", Details: details})
+			// One event per direction change, not per round: a rain fade
+			// that stays uplink-dominant for an hour is one event.
+			for member, r := range results {
+				dir := probe.FailureDirection(r)
+				if dir == lastDirection[member] {
+					continue
+				}
+				lastDirection[member] = dir
+				if dir == "" {
+					continue
+				}
+				store.AddEvent(telemetry.Event{
+					Timestamp: time.Now(),
+					Type:      "asymmetric_degradation",
+					Member:    member,
+					Reason:    dir + " degraded",
+					Details:   map[string]any{"uplink_ms": r.UplinkMS, "downlink_ms": r.DownlinkMS},
+				})
+			}
+		})
+	}
+	qtn := controller.NewQuarantineFromUCI()
+	if tp := probe.NewTLSProbeFromUCI(); tp != nil && scenario == nil {
+		go tp.Run(ctx, func() map[string]string {
+			ifaces := make(map[string]string)
+			for _, m := range eng.Members() {
+				ifaces[m.Name] = m.BindIface()
+			}
+			return ifaces
+		}, func(results map[string]probe.TLSResult) {
+			devs := make(map[string]string)
+			for _, m := range eng.Members() {
+				devs[m.Name] = m.BindIface()
+			}
+			for member, r := range results {
+				eng.SetTLSFiltered(member, r.Filtered, r.Issuer)
+				if r.Filtered {
+					store.AddEvent(telemetry.Event{
+						Type: "tls_filtered", Member: member,
+						Reason:  "TLS interception detected",
+						Details: map[string]any{"host": r.Host, "issuer": r.Issuer, "error": r.Error},
+					})
+					if applied, err := qtn.Apply(member, devs[member]); err != nil {
+						log.Printf("quarantine %s: %v", member, err)
+					} else if applied {
+						store.AddEvent(telemetry.Event{
+							Type: "member_quarantined", Member: member,
+							Reason: "TLS interception detected",
+						})
+					}
+				} else if qtn.Release(member) {
+					store.AddEvent(telemetry.Event{
+						Type: "quarantine_lifted", Member: member,
+						Reason: "TLS probe clean",
+					})
+				}
+			}
+		})
+	}
+	if pub := mqtt.NewFromUCI(); pub != nil && scenario == nil {
+		snapshot := func() []mqtt.MemberState {
+			var out []mqtt.MemberState
+			for _, m := range eng.Members() {
+				ms := mqtt.MemberState{
+					Name:   m.Name,
+					Class:  m.Class,
+					State:  string(m.State),
+					Score:  m.Final,
+					Active: m.Name == eng.Active(),
+				}
+				if s := m.LastSample; s != nil {
+					if s.LatencyMS != nil {
+						ms.LatencyMS = float64(*s.LatencyMS)
+					}
+					if s.LossPercent != nil {
+						ms.LossPct = float64(*s.LossPercent)
+					}
+				}
+				out = append(out, ms)
+			}
+			return out
+		}
+		var mqttCancel context.CancelFunc
+		rt.Register("mqtt", func() {
+			mctx, mcancel := context.WithCancel(ctx)
+			mqttCancel = mcancel
+			go pub.Run(mctx, snapshot)
+		}, func() { mqttCancel() })
+		_ = rt.Set("mqtt", true)
+	}
+	if wh := webhook.NewFromUCI(); wh != nil && scenario == nil {
+		eng.SetProblemReporter(func(check, subject, severity, detail string) {
+			wh.Report("starfaild", check, subject, severity, detail)
+		})
+	}
+	if arc := archive.NewFromUCI(); arc != nil && scenario == nil {
+		go arc.Run(ctx, func() (any, error) {
+			samples := map[string][]telemetry.Sample{}
+			for _, m := range eng.Members() {
+				samples[m.Name] = store.Samples(m.Name, 0)
+			}
+			return map[string]any{
+				"events":  store.Events(0),
+				"samples": samples,
+			}, nil
+		})
+	}
+	if rw := roam.NewFromUCI(); rw != nil && scenario == nil {
+		rw.OnRescan = func(reason string) {
+			store.AddEvent(telemetry.Event{
+				Timestamp: time.Now(),
+				Type:      "operator_rescan",
+				Reason:    reason,
+			})
+		}
+		go rw.Run(ctx)
+	}
+	if sc := smscmd.NewFromUCI(); sc != nil && scenario == nil {
+		sc.Status = func() string {
+			parts := []string{"active: " + eng.Active()}
+			for _, m := range eng.Members() {
+				parts = append(parts, fmt.Sprintf("%s %.0f %s", m.Name, m.Final, m.State))
+			}
+			return strings.Join(parts, "; ")
+		}
+		sc.Failover = func(member string) error { return eng.Force(member, 0) }
+		sc.Unforce = eng.Unforce
+		sc.RebootDish = func(rctx context.Context) error {
+			if sl == nil {
+				return fmt.Errorf("no starlink collector")
+			}
+			return sl.Reboot(rctx)
+		}
+		sc.OnCommand = func(from, command, result string) {
+			store.AddEvent(telemetry.Event{
+				Timestamp: time.Now(),
+				Type:      "sms_command",
+				Reason:    command,
+				Details:   map[string]any{"from": from, "result": result},
+			})
+		}
+		go sc.Run(ctx)
+	}
+	if ind := indicator.NewFromUCI(); ind != nil && scenario == nil {
+		go ind.Run(ctx, func() indicator.Status {
+			members := eng.Members()
+			s := indicator.Status{Active: eng.Active(), Total: len(members)}
+			for i, m := range members {
+				if m.State == engine.StateEligible {
+					s.Healthy++
+				}
+				if m.Name == s.Active {
+					s.Score = m.Final
+					s.Failover = i > 0
+				}
+			}
+			return s
+		})
+	}
+	if scenario == nil {
+		go superviseSysmgmt(ctx, store)
+	}
+
+	busmon := ubus.NewMonitor("starfail")
+	go busmon.Run(ctx)
+	mon.Ubus = busmon
+
+	srv := ubus.NewServer()
+	registerUbus(srv, eng, ctl, store, busmon, rt, notifier)
+	srv.Register("balances", func(json.RawMessage) (any, error) {
+		return map[string]any{"balances": balances.Latest()}, nil
+	})
+	srv.Register("heatmap", func(json.RawMessage) (any, error) {
+		return map[string]any{"members": store.Heatmap()}, nil
+	})
+	srv.Register("pcap", func(args json.RawMessage) (any, error) {
+		if capturer == nil {
+			return nil, fmt.Errorf("captures disabled (starfail.capture.enabled)")
+		}
+		var p struct {
+			CorrelationID string `json:"correlation_id"`
+		}
+		_ = json.Unmarshal(args, &p)
+		if p.CorrelationID != "" {
+			info, err := capturer.Find(p.CorrelationID)
+			if err != nil {
+				return nil, err
+			}
+			return info, nil
+		}
+		return map[string]any{"captures": capturer.List()}, nil
+	})
+	srv.Register("mapping", func(json.RawMessage) (any, error) {
+		return map[string]any{"mappings": controller.Mappings(cfg)}, nil
+	})
+	dr := diag.New()
+	if sl != nil {
+		dr.Dish = func(dctx context.Context) (any, error) { return sl.GetStarlinkInfo(dctx) }
+	}
+	srv.Register("troubleshoot", func(args json.RawMessage) (any, error) {
+		var p struct {
+			Action string `json:"action"`
+		}
+		_ = json.Unmarshal(args, &p)
+		if p.Action == "" {
+			return map[string]any{"actions": dr.Actions()}, nil
+		}
+		dctx, dcancel := context.WithTimeout(ctx, 10*time.Second)
+		defer dcancel()
+		return dr.Run(dctx, p.Action)
+	})
+	srv.Register("alignment", func(json.RawMessage) (any, error) {
+		if sl == nil {
+			return nil, fmt.Errorf("no starlink collector (simulate mode)")
+		}
+		actx, acancel := context.WithTimeout(ctx, 10*time.Second)
+		defer acancel()
+		st, err := sl.GetStarlinkInfo(actx)
+		if err != nil {
+			return nil, err
+		}
+		var heading float64
+		headingValid := false
+		if features.GPS && rt.Enabled("gps") {
+			if fix, err := gps.Current(actx); err == nil && fix.HeadingValid {
+				heading, headingValid = fix.HeadingDeg, true
+			}
+		}
+		out := map[string]any{
+			"boresight_azimuth_deg": st.BoresightAzimuthDeg,
+			"obstruction_pct":       st.ObstructionPct,
+		}
+		if s := starlink.SuggestHeading(st, heading, headingValid); s != nil {
+			out["suggestion"] = s
+		} else {
+			out["suggestion"] = nil
+			out["note"] = "no better orientation found"
+		}
+		return out, nil
+	})
+	srv.Register("stow", func(args json.RawMessage) (any, error) {
+		if sl == nil {
+			return nil, fmt.Errorf("no starlink collector (simulate mode)")
+		}
+		var p struct {
+			Unstow bool `json:"unstow"`
+		}
+		_ = json.Unmarshal(args, &p)
+		stowed := !p.Unstow
+		// Mark the member out of rotation first so traffic moves away
+		// before the dish drops the link, then command the dish.
+		var marked []string
+		for _, mc := range cfg.Members {
+			if mc.Class == "starlink" {
+				if err := eng.SetStowed(mc.Name, stowed); err == nil {
+					marked = append(marked, mc.Name)
+				}
+			}
+		}
+		sctx, scancel := context.WithTimeout(ctx, 10*time.Second)
+		defer scancel()
+		if err := sl.Stow(sctx, p.Unstow); err != nil {
+			// The dish refused; undo the administrative mark (the next
+			// tick would also reconcile it from dish status).
+			for _, name := range marked {
+				_ = eng.SetStowed(name, !stowed)
+			}
+			return nil, err
+		}
+		return map[string]any{"stowed": stowed, "members": marked}, nil
+	})
+	srv.Register("sla", func(json.RawMessage) (any, error) {
+		return map[string]any{"sla": slaTracker.Compliance(time.Now())}, nil
+	})
+	srv.Register("report", func(args json.RawMessage) (any, error) {
+		var p struct {
+			Period string `json:"period"`
+		}
+		_ = json.Unmarshal(args, &p)
+		if p.Period == "" {
+			p.Period = "daily"
+		}
+		if p.Period != "daily" && p.Period != "weekly" {
+			return nil, fmt.Errorf("period must be daily or weekly")
+		}
+		// Prefer the stored scheduled report; fall back to generating
+		// one on the fly from current history.
+		if r, err := reports.Load(p.Period); err == nil {
+			return r, nil
+		}
+		return reports.Generate(p.Period), nil
+	})
+	go func() {
+		if err := srv.Serve(*socket); err != nil {
+			log.Printf("ubus server: %v", err)
+		}
+	}()
+
+	eng.RestoreState()
+	eng.FastStart(ctx)
+	go eng.Run(ctx)
+
+	// Reload config on SIGHUP, exit cleanly on TERM/INT.
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGHUP, syscall.SIGTERM, syscall.SIGINT)
+	for s := range sig {
+		if s == syscall.SIGHUP {
+			newCfg, err := uci.LoadConfig()
+			if err != nil {
+				log.Printf("reload config: %v", err)
+				continue
+			}
+			*cfg = *newCfg
+			// One roster update; the controller and ubus handlers read it live.
+			var members []*engine.Member
+			for _, mc := range cfg.Members {
+				members = append(members, &engine.Member{
+					Name: mc.Name, Iface: mc.Iface, Class: mc.Class,
+					Weight: mc.Weight, Lifecycle: engine.Lifecycle{State: engine.StateEligible},
+				})
+			}
+			eng.SetMembers(members)
+			probe.SetProfiles(probeProfiles(cfg))
+			probe.SetDSCP(cfg.ProbeDSCP)
+			probe.SetDailyBudget(int64(cfg.ProbeBudgetMB * 1e6))
+			notifications.ReloadLocale()
+			log.Println("configuration reloaded")
+			continue
+		}
+		break
+	}
+	cancel()
+	eng.SaveState()
+	uci.Commits.Flush()
+	if err := store.Flush(); err != nil {
+		log.Printf("flush telemetry: %v", err)
+	}
+	bb.Close()
+	_ = srv.Close()
+}
+
+// probeProfiles converts the UCI target lists into probe profiles.
+func probeProfiles(cfg *uci.Config) map[string]probe.Profile {
+	out := make(map[string]probe.Profile, len(cfg.ProbeProfiles))
+	for class, targets := range cfg.ProbeProfiles {
+		var p probe.Profile
+		for _, t := range targets {
+			p = append(p, probe.ParseTarget(t))
+		}
+		out[class] = p
+	}
+	return out
+}
+
+// Peer supervision: starfaild beats its own heartbeat every
+// heartbeatInterval and watches the system manager's. sysmgmt beats once
+// per check round (5 minutes by default), so sysmgmtStaleAfter allows a
+// couple of missed rounds before acting.
+const (
+	heartbeatInterval  = 30 * time.Second
+	sysmgmtStaleAfter  = 15 * time.Minute
+	peerRestartHoldoff = 10 * time.Minute
+)
+
+// superviseSysmgmt runs until ctx is cancelled, raising a peer_stale event
+// (and, unless starfail.main.restart_peer is off, a procd restart) when the
+// system manager stops beating while configured to run.
+func superviseSysmgmt(ctx context.Context, store *telemetry.Store) {
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+	var lastRestart time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := heartbeat.Beat("starfaild"); err != nil {
+			log.Printf("heartbeat: %v", err)
+		}
+		if !uci.GetBool("starfail.sysmgmt.enabled", true) {
+			continue
+		}
+		age, ok := heartbeat.Age("starfail-sysmgmt")
+		if !ok || age < sysmgmtStaleAfter {
+			continue
+		}
+		store.AddEvent(telemetry.Event{
+			Type: "peer_stale", Member: "starfail-sysmgmt",
+			Reason: fmt.Sprintf("no heartbeat for %s", age.Round(time.Second)),
+		})
+		if !uci.GetBool("starfail.main.restart_peer", true) || time.Since(lastRestart) < peerRestartHoldoff {
+			continue
+		}
+		lastRestart = time.Now()
+		log.Printf("supervise: restarting stale starfail-sysmgmt (no heartbeat for %s)", age.Round(time.Second))
+		if out, err := exec.Command("/etc/init.d/starfail-sysmgmt", "restart").CombinedOutput(); err != nil {
+			log.Printf("supervise: restart starfail-sysmgmt: %v: %s", err, out)
+		}
+	}
+}
+
+// metrics_live bounds: streams are capped to maxLiveSeconds and decimated
+// down to maxLivePoints chunks so a LuCI graph left open cannot hold RAM
+// or CPU hostage.
+const (
+	maxLiveSeconds = 300
+	maxLivePoints  = 60
+)
+
+// registerUbus wires the daemon's ubus object methods.
+func registerUbus(srv *ubus.Server, eng *engine.Engine, ctl *controller.Controller, store *telemetry.Store, busmon *ubus.Monitor, rt *features.Runtime, notifier *notifications.Notifier) {
+	statusPayload := func() map[string]any {
+		out := map[string]any{
+			"version": version,
+			"active":  eng.Active(),
+			"members": len(eng.Members()),
+			"ubus":    busmon.Status(),
+		}
+		if b := eng.Budget(); b != nil {
+			out["budget"] = b
+		}
+		if eng.DryRun() {
+			out["dry_run"] = true
+		}
+		return out
+	}
+	membersPayload := func() []map[string]any {
+		out := make([]map[string]any, 0, len(eng.Members()))
+		for _, m := range eng.Members() {
+			entry := map[string]any{
+				"name":   m.Name,
+				"class":  m.Class,
+				"status": m.State,
+				"score":  m.Final,
+			}
+			if m.LastSample != nil {
+				entry["data_source"] = m.LastSample.DataSource
+			}
+			if m.Bloat.Grade != "" {
+				entry["bloat_grade"] = m.Bloat.Grade
+				entry["saturated"] = m.Bloat.Saturated
+			}
+			if fc := eng.FailbackConfidence(m.Name); fc != nil {
+				entry["failback_confidence"] = fc
+			}
+			out = append(out, entry)
+		}
+		return out
+	}
+	srv.Register("status", func(json.RawMessage) (any, error) {
+		return statusPayload(), nil
+	})
+	srv.Register("set_feature", func(args json.RawMessage) (any, error) {
+		var p struct {
+			Name    string `json:"name"`
+			Enabled *bool  `json:"enabled"`
+		}
+		if err := json.Unmarshal(args, &p); err != nil || p.Name == "" || p.Enabled == nil {
+			return nil, fmt.Errorf("name and enabled required")
+		}
+		if err := rt.Set(p.Name, *p.Enabled); err != nil {
+			return nil, err
+		}
+		store.AddEvent(telemetry.Event{
+			Type: "feature_toggled", Member: p.Name,
+			Details: map[string]any{"enabled": *p.Enabled},
+		})
+		return map[string]any{"features": rt.States()}, nil
+	})
+	srv.Register("features", func(json.RawMessage) (any, error) {
+		return map[string]any{"features": rt.States()}, nil
+	})
+	srv.Register("members", func(json.RawMessage) (any, error) {
+		return map[string]any{"members": membersPayload()}, nil
+	})
+	// overview bundles what a dashboard needs into one round trip; the
+	// LuCI page otherwise issues status, members, events and scores as
+	// separate calls, each paying the rpcd hop on an already-slow router.
+	srv.Register("overview", func(json.RawMessage) (any, error) {
+		scores := make(map[string]float64, len(eng.Members()))
+		for _, m := range eng.Members() {
+			scores[m.Name] = m.Final
+		}
+		return map[string]any{
+			"status":  statusPayload(),
+			"members": membersPayload(),
+			"events":  store.Events(10),
+			"scores":  scores,
+		}, nil
+	})
+	srv.Register("forecast", func(args json.RawMessage) (any, error) {
+		var p struct {
+			Minutes int `json:"minutes"`
+		}
+		_ = json.Unmarshal(args, &p)
+		if p.Minutes <= 0 {
+			p.Minutes = 60
+		}
+		members := eng.Forecast(p.Minutes)
+		if members == nil {
+			return nil, fmt.Errorf("predictive engine disabled")
+		}
+		return map[string]any{"horizon_min": p.Minutes, "members": members}, nil
+	})
+	srv.Register("probe_budget", func(json.RawMessage) (any, error) {
+		return map[string]any{"members": probe.BudgetCounters()}, nil
+	})
+	srv.Register("ack", func(args json.RawMessage) (any, error) {
+		var p struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal(args, &p); err != nil || p.ID == "" {
+			return nil, fmt.Errorf("id argument required")
+		}
+		if !notifier.Ack(p.ID) {
+			return nil, fmt.Errorf("no escalating notification with id %q", p.ID)
+		}
+		store.AddEvent(telemetry.Event{
+			Timestamp: time.Now(), Type: "notification_acked",
+			Details: map[string]any{"id": p.ID},
+		})
+		return map[string]any{"acked": p.ID}, nil
+	})
+	srv.Register("events", func(args json.RawMessage) (any, error) {
+		var p struct {
+			Limit int `json:"limit"`
+		}
+		_ = json.Unmarshal(args, &p)
+		if p.Limit == 0 {
+			p.Limit = 20
+		}
+		return map[string]any{"events": store.Events(p.Limit)}, nil
+	})
+	srv.Register("explain", func(args json.RawMessage) (any, error) {
+		var p struct {
+			Member string `json:"member"`
+		}
+		if err := json.Unmarshal(args, &p); err != nil || p.Member == "" {
+			return nil, fmt.Errorf("member argument required")
+		}
+		return eng.Explain(p.Member)
+	})
+	srv.RegisterStream("metrics_live", func(args json.RawMessage, emit func(any) error) error {
+		var p struct {
+			Member   string `json:"member"`
+			Seconds  int    `json:"seconds"`
+			Interval int    `json:"interval"`
+		}
+		if err := json.Unmarshal(args, &p); err != nil || p.Member == "" {
+			return fmt.Errorf("member argument required")
+		}
+		if p.Seconds <= 0 {
+			p.Seconds = 10
+		}
+		if p.Seconds > maxLiveSeconds {
+			p.Seconds = maxLiveSeconds
+		}
+		if p.Interval < 1 {
+			p.Interval = 1
+		}
+		// Decimate server-side: long windows get a wider interval so one
+		// stream never emits more than maxLivePoints chunks.
+		if p.Seconds/p.Interval > maxLivePoints {
+			p.Interval = (p.Seconds + maxLivePoints - 1) / maxLivePoints
+		}
+		deadline := time.Now().Add(time.Duration(p.Seconds) * time.Second)
+		ticker := time.NewTicker(time.Duration(p.Interval) * time.Second)
+		defer ticker.Stop()
+		for {
+			var target *engine.Member
+			for _, m := range eng.Members() {
+				if m.Name == p.Member {
+					target = m
+					break
+				}
+			}
+			if target == nil {
+				return fmt.Errorf("unknown member %q", p.Member)
+			}
+			point := map[string]any{
+				"ts":     time.Now().Unix(),
+				"member": target.Name,
+				"score":  target.Final,
+				"status": target.State,
+			}
+			if s := target.LastSample; s != nil {
+				if s.LatencyMS != nil {
+					point["latency_ms"] = *s.LatencyMS
+				}
+				if s.LossPercent != nil {
+					point["loss_pct"] = *s.LossPercent
+				}
+			}
+			if err := emit(point); err != nil {
+				return nil // client went away
+			}
+			if time.Now().After(deadline) {
+				return nil
+			}
+			<-ticker.C
+		}
+	})
+	srv.Register("pause", func(args json.RawMessage) (any, error) {
+		var p struct {
+			Reason   string `json:"reason"`
+			Duration int    `json:"duration"` // seconds; 0 means the maximum
+		}
+		_ = json.Unmarshal(args, &p)
+		if p.Reason == "" {
+			p.Reason = "ubus request"
+		}
+		until := eng.Pause(p.Reason, time.Duration(p.Duration)*time.Second)
+		eng.SaveState()
+		return map[string]any{"paused": true, "until": until.Format(time.RFC3339)}, nil
+	})
+	srv.Register("resume", func(args json.RawMessage) (any, error) {
+		var p struct {
+			Reason string `json:"reason"`
+		}
+		_ = json.Unmarshal(args, &p)
+		if p.Reason == "" {
+			p.Reason = "ubus request"
+		}
+		eng.Resume(p.Reason)
+		eng.SaveState()
+		return map[string]any{"paused": false}, nil
+	})
+	srv.Register("failover", func(args json.RawMessage) (any, error) {
+		var p struct {
+			Member   string `json:"member"`
+			Duration int    `json:"duration"` // seconds; 0 pins until the next failover call
+		}
+		if err := json.Unmarshal(args, &p); err != nil || p.Member == "" {
+			return nil, fmt.Errorf("member argument required")
+		}
+		if p.Member == "auto" {
+			eng.Unforce()
+			store.AddEvent(telemetry.Event{Type: "manual_revert", Reason: "ubus request"})
+			return map[string]any{"mode": "auto"}, nil
+		}
+		if err := eng.Force(p.Member, time.Duration(p.Duration)*time.Second); err != nil {
+			return nil, err
+		}
+		store.AddEvent(telemetry.Event{Type: "manual_failover", Member: p.Member, Reason: "ubus request"})
+		result := map[string]any{"switched_to": p.Member}
+		if _, until := eng.Forced(); !until.IsZero() {
+			result["reverts_at"] = until.Format(time.RFC3339)
+		}
+		return result, nil
+	})
+}
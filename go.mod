@@ -0,0 +1,3 @@
+module github.com/markus-lassfolk/rutos-starlink-failover
+
+go 1.22
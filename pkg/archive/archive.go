@@ -0,0 +1,362 @@
+// Package archive pushes compressed telemetry snapshots to an
+// S3-compatible bucket (MinIO, Backblaze, ...) for long-term retention
+// that the router's tmpfs cannot provide. Objects are named by date so
+// bucket lifecycle rules can expire them; large snapshots go up as
+// resumable multipart uploads so a flaky uplink can finish a transfer
+// across several cycles, and an optional bandwidth cap keeps the sync
+// from starving interactive traffic.
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/secrets"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/uci"
+)
+
+// partSize is the S3 minimum part size; snapshots below it use a single
+// PUT. A variable so tests can exercise multipart without 5 MiB payloads.
+var partSize = 5 << 20
+
+// stateDir holds the pending-upload spool; a variable for tests.
+var stateDir = "/tmp/starfail"
+
+// Config is the starfail.archive section.
+type Config struct {
+	Endpoint      string // e.g. https://minio.example:9000
+	Bucket        string
+	Region        string
+	Prefix        string
+	AccessKey     string
+	SecretKey     string
+	IntervalH     int
+	BandwidthKbps int // 0 = unthrottled
+}
+
+// Uploader periodically snapshots and uploads telemetry.
+type Uploader struct {
+	cfg    Config
+	client *http.Client
+	now    func() time.Time
+}
+
+// NewFromUCI returns an Uploader when starfail.archive.endpoint and
+// bucket are configured, nil otherwise. Credentials come through the
+// secrets layer so they can be stored encrypted.
+func NewFromUCI() *Uploader {
+	cfg := Config{
+		Region:        "us-east-1",
+		IntervalH:     uci.GetInt("starfail.archive.interval_h", 6),
+		BandwidthKbps: uci.GetInt("starfail.archive.bandwidth_kbps", 0),
+	}
+	cfg.Endpoint, _ = uci.Get("starfail.archive.endpoint")
+	cfg.Bucket, _ = uci.Get("starfail.archive.bucket")
+	if cfg.Endpoint == "" || cfg.Bucket == "" {
+		return nil
+	}
+	if v, ok := uci.Get("starfail.archive.region"); ok && v != "" {
+		cfg.Region = v
+	}
+	cfg.Prefix, _ = uci.Get("starfail.archive.prefix")
+	cfg.AccessKey, _ = secrets.Get("starfail.archive.access_key")
+	cfg.SecretKey, _ = secrets.Get("starfail.archive.secret_key")
+	return New(cfg)
+}
+
+// New returns an Uploader for cfg.
+func New(cfg Config) *Uploader {
+	return &Uploader{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 5 * time.Minute},
+		now:    time.Now,
+	}
+}
+
+// Run uploads a snapshot every interval until ctx is cancelled. A cycle
+// first finishes any spooled upload left over from a failed previous
+// attempt, so flaky links make progress instead of starting over.
+func (u *Uploader) Run(ctx context.Context, snapshot func() (any, error)) {
+	interval := time.Duration(u.cfg.IntervalH) * time.Hour
+	if interval <= 0 {
+		interval = 6 * time.Hour
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := u.cycle(ctx, snapshot); err != nil {
+				log.Printf("archive: %v (will resume next cycle)", err)
+			}
+		}
+	}
+}
+
+// cycle resumes a pending upload if one is spooled, otherwise takes and
+// uploads a fresh snapshot.
+func (u *Uploader) cycle(ctx context.Context, snapshot func() (any, error)) error {
+	if st := u.loadPending(); st != nil {
+		data, err := os.ReadFile(u.spoolPath())
+		if err == nil {
+			if err := u.upload(ctx, st.Key, data, st); err != nil {
+				return err
+			}
+			u.clearPending()
+			return nil
+		}
+		u.clearPending() // spool unreadable; fall through to a fresh snapshot
+	}
+	v, err := snapshot()
+	if err != nil {
+		return err
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, _ = zw.Write(raw)
+	_ = zw.Close()
+	key := u.objectKey(u.now())
+	if err := u.upload(ctx, key, buf.Bytes(), nil); err != nil {
+		return err
+	}
+	log.Printf("archive: uploaded %s (%d bytes)", key, buf.Len())
+	return nil
+}
+
+// objectKey names snapshots by date so lifecycle rules can expire whole
+// prefixes: <prefix>/2026/08/31/telemetry-20260831T120000Z.json.gz
+func (u *Uploader) objectKey(now time.Time) string {
+	now = now.UTC()
+	key := fmt.Sprintf("%s/telemetry-%s.json.gz",
+		now.Format("2006/01/02"), now.Format("20060102T150405Z"))
+	if u.cfg.Prefix != "" {
+		key = u.cfg.Prefix + "/" + key
+	}
+	return key
+}
+
+// uploadState tracks a multipart upload across attempts: which parts
+// already made it and under which upload ID.
+type uploadState struct {
+	Key      string   `json:"key"`
+	UploadID string   `json:"upload_id"`
+	ETags    []string `json:"etags"` // indexed by part number - 1; "" = outstanding
+}
+
+// upload sends data to key, as one PUT when small enough, otherwise as a
+// resumable multipart upload; st carries progress from a previous attempt.
+func (u *Uploader) upload(ctx context.Context, key string, data []byte, st *uploadState) error {
+	if len(data) <= partSize {
+		return u.put(ctx, key, data)
+	}
+	nparts := (len(data) + partSize - 1) / partSize
+	if st == nil || st.UploadID == "" {
+		id, err := u.initiate(ctx, key)
+		if err != nil {
+			return err
+		}
+		st = &uploadState{Key: key, UploadID: id, ETags: make([]string, nparts)}
+	}
+	for i := 0; i < nparts; i++ {
+		if st.ETags[i] != "" {
+			continue
+		}
+		end := (i + 1) * partSize
+		if end > len(data) {
+			end = len(data)
+		}
+		etag, err := u.putPart(ctx, key, st.UploadID, i+1, data[i*partSize:end])
+		if err != nil {
+			u.spool(st, data)
+			return fmt.Errorf("part %d/%d: %w", i+1, nparts, err)
+		}
+		st.ETags[i] = etag
+		u.spool(st, data) // progress survives a crash mid-upload too
+	}
+	return u.complete(ctx, key, st)
+}
+
+// do signs and sends one request, expecting a 2xx.
+func (u *Uploader) do(req *http.Request, payloadHash string) (*http.Response, error) {
+	signV4(req, u.cfg.AccessKey, u.cfg.SecretKey, u.cfg.Region, payloadHash, u.now())
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s %s: %s: %s", req.Method, req.URL.Path, resp.Status, body)
+	}
+	return resp, nil
+}
+
+func (u *Uploader) objectURL(key string) string {
+	return u.cfg.Endpoint + "/" + u.cfg.Bucket + "/" + key
+}
+
+func (u *Uploader) put(ctx context.Context, key string, data []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u.objectURL(key), u.throttled(data))
+	if err != nil {
+		return err
+	}
+	req.ContentLength = int64(len(data))
+	resp, err := u.do(req, hexSHA256(data))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (u *Uploader) initiate(ctx context.Context, key string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.objectURL(key)+"?uploads=", nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := u.do(req, hexSHA256(nil))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	var out struct {
+		UploadID string `xml:"UploadId"`
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return "", fmt.Errorf("parse initiate response: %w", err)
+	}
+	return out.UploadID, nil
+}
+
+func (u *Uploader) putPart(ctx context.Context, key, uploadID string, n int, data []byte) (string, error) {
+	url := fmt.Sprintf("%s?partNumber=%d&uploadId=%s", u.objectURL(key), n, uploadID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, url, u.throttled(data))
+	if err != nil {
+		return "", err
+	}
+	req.ContentLength = int64(len(data))
+	resp, err := u.do(req, hexSHA256(data))
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	etag := resp.Header.Get("ETag")
+	if etag == "" {
+		return "", fmt.Errorf("part %d: no ETag in response", n)
+	}
+	return etag, nil
+}
+
+func (u *Uploader) complete(ctx context.Context, key string, st *uploadState) error {
+	type part struct {
+		PartNumber int    `xml:"PartNumber"`
+		ETag       string `xml:"ETag"`
+	}
+	var body struct {
+		XMLName struct{} `xml:"CompleteMultipartUpload"`
+		Parts   []part   `xml:"Part"`
+	}
+	for i, etag := range st.ETags {
+		body.Parts = append(body.Parts, part{PartNumber: i + 1, ETag: etag})
+	}
+	raw, err := xml.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		u.objectURL(key)+"?uploadId="+st.UploadID, bytes.NewReader(raw))
+	if err != nil {
+		return err
+	}
+	resp, err := u.do(req, hexSHA256(raw))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// throttled wraps data in a rate-limited reader when a cap is configured.
+func (u *Uploader) throttled(data []byte) io.Reader {
+	r := bytes.NewReader(data)
+	if u.cfg.BandwidthKbps <= 0 {
+		return r
+	}
+	return &throttledReader{
+		r:     r,
+		bps:   float64(u.cfg.BandwidthKbps) * 1000 / 8,
+		start: u.now(),
+		sleep: time.Sleep,
+	}
+}
+
+// throttledReader paces reads so the average rate stays at bps.
+type throttledReader struct {
+	r     io.Reader
+	bps   float64
+	start time.Time
+	sent  int64
+	sleep func(time.Duration)
+}
+
+func (t *throttledReader) Read(p []byte) (int, error) {
+	n, err := t.r.Read(p)
+	t.sent += int64(n)
+	if n > 0 && t.bps > 0 {
+		due := time.Duration(float64(t.sent) / t.bps * float64(time.Second))
+		if elapsed := time.Since(t.start); due > elapsed {
+			t.sleep(due - elapsed)
+		}
+	}
+	return n, err
+}
+
+// Pending-upload spool: the gzipped payload plus progress state, kept on
+// tmpfs so an interrupted transfer resumes with the same bytes and key.
+
+func (u *Uploader) spoolPath() string { return filepath.Join(stateDir, "archive-pending.gz") }
+func (u *Uploader) statePath() string { return filepath.Join(stateDir, "archive-pending.json") }
+
+func (u *Uploader) spool(st *uploadState, data []byte) {
+	if err := os.MkdirAll(stateDir, 0o755); err != nil {
+		return
+	}
+	if _, err := os.Stat(u.spoolPath()); err != nil {
+		_ = os.WriteFile(u.spoolPath(), data, 0o600)
+	}
+	b, _ := json.Marshal(st)
+	_ = os.WriteFile(u.statePath(), b, 0o600)
+}
+
+func (u *Uploader) loadPending() *uploadState {
+	raw, err := os.ReadFile(u.statePath())
+	if err != nil {
+		return nil
+	}
+	var st uploadState
+	if json.Unmarshal(raw, &st) != nil || st.Key == "" {
+		return nil
+	}
+	return &st
+}
+
+func (u *Uploader) clearPending() {
+	_ = os.Remove(u.statePath())
+	_ = os.Remove(u.spoolPath())
+}
@@ -0,0 +1,146 @@
+// Package capture grabs short packet-header captures around failover
+// events so a post-mortem can tell upstream loss from local trouble. It
+// shells out to tcpdump (present on RUTOS), writes size-capped files into
+// a small ring on tmpfs, and is entirely optional — nothing runs unless
+// starfail.capture.enabled is set.
+package capture
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/uci"
+)
+
+// DefaultDir is the capture ring location; tmpfs, gone after reboot.
+const DefaultDir = "/tmp/starfail/pcap"
+
+// Defaults for the knobs under starfail.capture.
+const (
+	defaultDurationS = 10
+	defaultMaxFiles  = 5
+	defaultSnapLen   = 96   // headers only
+	defaultMaxPkts   = 2000 // hard size cap regardless of duration
+)
+
+// runTcpdump is a variable so tests can stub the binary away.
+var runTcpdump = func(ctx context.Context, args ...string) error {
+	return exec.CommandContext(ctx, "tcpdump", args...).Run()
+}
+
+// Capturer manages the capture ring.
+type Capturer struct {
+	Dir       string
+	DurationS int
+	MaxFiles  int
+
+	mu sync.Mutex // serializes pruning
+}
+
+// NewFromUCI returns a Capturer per the starfail.capture section, or nil
+// when captures are disabled.
+func NewFromUCI() *Capturer {
+	if !uci.GetBool("starfail.capture.enabled", false) {
+		return nil
+	}
+	c := &Capturer{
+		Dir:       DefaultDir,
+		DurationS: uci.GetInt("starfail.capture.duration_s", defaultDurationS),
+		MaxFiles:  uci.GetInt("starfail.capture.max_files", defaultMaxFiles),
+	}
+	if d, ok := uci.Get("starfail.capture.dir"); ok && d != "" {
+		c.Dir = d
+	}
+	return c
+}
+
+// PathFor is the deterministic file location for a correlation id, so the
+// failover event can reference the capture before it finishes.
+func (c *Capturer) PathFor(correlationID string) string {
+	return filepath.Join(c.Dir, "failover-"+correlationID+".pcap")
+}
+
+// Start kicks off a capture on iface in the background and returns the
+// file path the event should reference. The capture runs for DurationS
+// seconds or defaultMaxPkts packets, whichever comes first.
+func (c *Capturer) Start(ctx context.Context, iface, correlationID string) string {
+	if err := os.MkdirAll(c.Dir, 0o755); err != nil {
+		log.Printf("capture: %v", err)
+		return ""
+	}
+	path := c.PathFor(correlationID)
+	go func() {
+		cctx, cancel := context.WithTimeout(ctx, time.Duration(c.DurationS+5)*time.Second)
+		defer cancel()
+		err := runTcpdump(cctx,
+			"-i", iface,
+			"-s", strconv.Itoa(defaultSnapLen),
+			"-c", strconv.Itoa(defaultMaxPkts),
+			"-G", strconv.Itoa(c.DurationS),
+			"-W", "1",
+			"-w", path,
+		)
+		if err != nil && cctx.Err() == nil {
+			log.Printf("capture: tcpdump on %s: %v", iface, err)
+		}
+		c.prune()
+	}()
+	return path
+}
+
+// Info describes one capture file for the ubus listing.
+type Info struct {
+	File      string    `json:"file"`
+	SizeBytes int64     `json:"size_bytes"`
+	Captured  time.Time `json:"captured"`
+}
+
+// List returns the ring contents, newest first.
+func (c *Capturer) List() []Info {
+	entries, err := os.ReadDir(c.Dir)
+	if err != nil {
+		return nil
+	}
+	var out []Info
+	for _, e := range entries {
+		fi, err := e.Info()
+		if err != nil || e.IsDir() {
+			continue
+		}
+		out = append(out, Info{
+			File:      filepath.Join(c.Dir, e.Name()),
+			SizeBytes: fi.Size(),
+			Captured:  fi.ModTime(),
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Captured.After(out[j].Captured) })
+	return out
+}
+
+// Find returns the capture for a correlation id, if it exists.
+func (c *Capturer) Find(correlationID string) (Info, error) {
+	path := c.PathFor(correlationID)
+	fi, err := os.Stat(path)
+	if err != nil {
+		return Info{}, fmt.Errorf("no capture for %s", correlationID)
+	}
+	return Info{File: path, SizeBytes: fi.Size(), Captured: fi.ModTime()}, nil
+}
+
+// prune drops the oldest files beyond MaxFiles.
+func (c *Capturer) prune() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	files := c.List()
+	for i := c.MaxFiles; i < len(files); i++ {
+		_ = os.Remove(files[i].File)
+	}
+}
@@ -0,0 +1,74 @@
+package capture
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+)
+
+// installFakeTcpdump replaces runTcpdump with a stub that writes an empty
+// capture file to the -w argument.
+func installFakeTcpdump(t *testing.T) {
+	t.Helper()
+	orig := runTcpdump
+	runTcpdump = func(_ context.Context, args ...string) error {
+		for i, a := range args {
+			if a == "-w" && i+1 < len(args) {
+				return os.WriteFile(args[i+1], []byte("pcap"), 0o644)
+			}
+		}
+		t.Error("no -w argument passed to tcpdump")
+		return nil
+	}
+	t.Cleanup(func() { runTcpdump = orig })
+}
+
+func TestStartAndFind(t *testing.T) {
+	installFakeTcpdump(t)
+	c := &Capturer{Dir: t.TempDir(), DurationS: 1, MaxFiles: 5}
+
+	path := c.Start(context.Background(), "wan", "abc123")
+	if path != c.PathFor("abc123") {
+		t.Fatalf("path = %q, want %q", path, c.PathFor("abc123"))
+	}
+	waitForFile(t, path)
+
+	info, err := c.Find("abc123")
+	if err != nil {
+		t.Fatalf("Find: %v", err)
+	}
+	if info.File != path || info.SizeBytes == 0 {
+		t.Errorf("info = %+v", info)
+	}
+	if _, err := c.Find("nope"); err == nil {
+		t.Error("Find of unknown correlation id succeeded")
+	}
+}
+
+func TestPrune(t *testing.T) {
+	installFakeTcpdump(t)
+	c := &Capturer{Dir: t.TempDir(), DurationS: 1, MaxFiles: 2}
+
+	for _, corr := range []string{"a", "b", "c", "d"} {
+		waitForFile(t, c.Start(context.Background(), "wan", corr))
+	}
+	if got := len(c.List()); got != 2 {
+		t.Errorf("ring holds %d files, want 2", got)
+	}
+}
+
+// waitForFile waits for the background capture goroutine to write path.
+func waitForFile(t *testing.T, path string) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if _, err := os.Stat(path); err == nil {
+			// Give the goroutine a moment to finish its prune pass too.
+			time.Sleep(10 * time.Millisecond)
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("capture file %s never appeared", path)
+}
@@ -0,0 +1,64 @@
+package cellular
+
+import (
+	"context"
+	"log"
+	"os/exec"
+	"sync"
+)
+
+// signalData is the backend-independent signal snapshot.
+type signalData struct {
+	RSRP, RSRQ, SINR, RSSI *float64
+	Network                string
+	Operator               string
+	Roaming                bool
+}
+
+// backend abstracts where cellular signal data comes from. gsmctl output
+// formats drift between RUTOS releases, so structured sources (uqmi JSON,
+// ModemManager) are preferred when their tools are present.
+type backend interface {
+	name() string
+	available() bool
+	signal(ctx context.Context) (*signalData, error)
+}
+
+var (
+	backendMu sync.Mutex
+	selected  backend
+)
+
+// selectBackend picks the first available backend in preference order and
+// caches the choice. A failed collection clears the cache via resetBackend
+// so a hot-unplugged modem triggers re-detection.
+func selectBackend() backend {
+	backendMu.Lock()
+	defer backendMu.Unlock()
+	if selected != nil {
+		return selected
+	}
+	for _, b := range []backend{&uqmiBackend{}, &mmBackend{}, &gsmctlBackend{}} {
+		if b.available() {
+			log.Printf("cellular: using %s backend", b.name())
+			selected = b
+			return b
+		}
+	}
+	// gsmctl is the fallback even when not detected; it logs its own errors.
+	selected = &gsmctlBackend{}
+	return selected
+}
+
+// resetBackend forces re-detection on the next collection.
+func resetBackend() {
+	backendMu.Lock()
+	selected = nil
+	backendMu.Unlock()
+}
+
+// haveBinary reports whether a tool exists in PATH.
+func haveBinary(name string) bool {
+	_, err := exec.LookPath(name)
+	return err == nil
+}
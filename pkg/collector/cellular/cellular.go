@@ -0,0 +1,69 @@
+// Package cellular collects modem signal metrics. Signal data comes from
+// the best available backend (uqmi, ModemManager, or gsmctl as the
+// fallback) because gsmctl output formats vary across RUTOS versions;
+// reachability comes from the shared probe layer.
+package cellular
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/metrics"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/probe"
+)
+
+// Collector gathers cellular signal plus generic reachability metrics.
+type Collector struct{}
+
+// New returns a cellular Collector.
+func New() *Collector { return &Collector{} }
+
+// Class implements collector.Collector.
+func (c *Collector) Class() string { return "cellular" }
+
+// Collect implements collector.Collector.
+func (c *Collector) Collect(ctx context.Context, iface string) (*metrics.Metrics, error) {
+	start := time.Now()
+	m := &metrics.Metrics{Timestamp: time.Now(), DataSource: metrics.SourceProbe}
+
+	b := selectBackend()
+	sd, err := b.signal(ctx)
+	if err != nil {
+		log.Printf("cellular: %s backend: %v", b.name(), err)
+		resetBackend()
+	} else {
+		if sd.RSRP != nil {
+			m.RSRP = metrics.DBm(*sd.RSRP).Ptr()
+		}
+		if sd.RSRQ != nil {
+			m.RSRQ = metrics.DB(*sd.RSRQ).Ptr()
+		}
+		if sd.SINR != nil {
+			m.SINR = metrics.DB(*sd.SINR).Ptr()
+		}
+		if sd.RSSI != nil {
+			m.RSSI = metrics.DBm(*sd.RSSI).Ptr()
+		}
+		m.Network = sd.Network
+		m.Operator = sd.Operator
+		m.Roaming = sd.Roaming
+	}
+
+	// Reachability through the interface, using the cellular probe
+	// profile (typically the carrier DNS plus one anycast target).
+	agg, perTarget := probe.Run(ctx, iface, probe.ProfileFor(c.Class()), probe.CountFor(iface, 5))
+	m.LatencyMS = metrics.Milliseconds(agg.LatencyMS).Ptr()
+	m.LossPercent = metrics.Percent(agg.LossPercent).Ptr()
+	m.JitterMS = metrics.Milliseconds(agg.JitterMS).Ptr()
+	for _, r := range perTarget {
+		m.ProbeResults = append(m.ProbeResults, metrics.ProbeResult(r))
+	}
+	if probe.Metered() {
+		if rtt, _, ok := probe.PassiveRTT(); ok {
+			m.PassiveRTTMS = metrics.Milliseconds(rtt).Ptr()
+		}
+	}
+	m.CollectDurationMS = time.Since(start).Milliseconds()
+	return m, nil
+}
@@ -0,0 +1,96 @@
+package cellular
+
+import (
+	"context"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// Exec runs gsmctl; a variable so tests can stub modem output.
+var Exec = func(ctx context.Context, args ...string) (string, error) {
+	out, err := exec.CommandContext(ctx, "gsmctl", args...).Output()
+	return strings.TrimSpace(string(out)), err
+}
+
+// gsmctlBackend is the historical AT-command source, used when neither
+// QMI nor ModemManager is present.
+type gsmctlBackend struct{}
+
+func (b *gsmctlBackend) name() string { return "gsmctl" }
+
+func (b *gsmctlBackend) available() bool { return haveBinary("gsmctl") }
+
+func (b *gsmctlBackend) signal(ctx context.Context) (*signalData, error) {
+	sd := &signalData{}
+	if v, ok := gsmFloat(ctx, "-W"); ok { // RSRP
+		sd.RSRP = &v
+	}
+	if v, ok := gsmFloat(ctx, "-M"); ok { // RSRQ
+		sd.RSRQ = &v
+	}
+	if v, ok := gsmFloat(ctx, "-Z"); ok { // SINR
+		sd.SINR = &v
+	}
+	if v, ok := gsmFloat(ctx, "-q"); ok { // RSSI
+		sd.RSSI = &v
+	}
+	if s, err := Exec(ctx, "-t"); err == nil { // network type
+		sd.Network = s
+	}
+	if s, err := Exec(ctx, "-o"); err == nil { // operator
+		sd.Operator = s
+	}
+	return sd, nil
+}
+
+// SignalSnapshot returns the current modem readings as a flat map for the
+// diagnostic API; queries that fail are simply absent from the result.
+func SignalSnapshot(ctx context.Context) map[string]any {
+	b := &gsmctlBackend{}
+	sd, _ := b.signal(ctx)
+	out := map[string]any{}
+	if sd.RSRP != nil {
+		out["rsrp_dbm"] = *sd.RSRP
+	}
+	if sd.RSRQ != nil {
+		out["rsrq_db"] = *sd.RSRQ
+	}
+	if sd.SINR != nil {
+		out["sinr_db"] = *sd.SINR
+	}
+	if sd.RSSI != nil {
+		out["rssi_dbm"] = *sd.RSSI
+	}
+	if sd.Network != "" {
+		out["network"] = sd.Network
+	}
+	if sd.Operator != "" {
+		out["operator"] = sd.Operator
+	}
+	return out
+}
+
+// gsmFloat runs a gsmctl query expected to print one number.
+func gsmFloat(ctx context.Context, flag string) (float64, bool) {
+	out, err := Exec(ctx, flag)
+	if err != nil {
+		return 0, false
+	}
+	return parseGSMValue(out)
+}
+
+// parseGSMValue extracts a number from gsmctl output. RUTOS versions differ
+// in suffixing units ("-89 dBm") and some error paths print text, so only
+// the leading token is parsed and NaN/Inf are rejected.
+func parseGSMValue(out string) (float64, bool) {
+	fields := strings.Fields(out)
+	if len(fields) == 0 {
+		return 0, false
+	}
+	v, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil || v != v || v > 1e6 || v < -1e6 {
+		return 0, false
+	}
+	return v, true
+}
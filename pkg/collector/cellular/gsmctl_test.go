@@ -0,0 +1,34 @@
+package cellular
+
+import "testing"
+
+func TestParseGSMValue(t *testing.T) {
+	tests := []struct {
+		out    string
+		want   float64
+		wantOK bool
+	}{
+		{"-89", -89, true},
+		{"-89 dBm", -89, true},
+		{"13.5", 13.5, true},
+		{"", 0, false},
+		{"ERROR", 0, false},
+		{"N/A", 0, false},
+		{"NaN", 0, false},
+		{"1e300", 0, false},
+	}
+	for _, tt := range tests {
+		v, ok := parseGSMValue(tt.out)
+		if ok != tt.wantOK || (ok && v != tt.want) {
+			t.Errorf("parseGSMValue(%q) = %v, %v; want %v, %v", tt.out, v, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func FuzzParseGSMValue(f *testing.F) {
+	f.Add("-89 dBm")
+	f.Add("ERROR\nread failed")
+	f.Fuzz(func(t *testing.T, out string) {
+		_, _ = parseGSMValue(out)
+	})
+}
@@ -0,0 +1,99 @@
+package cellular
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strconv"
+)
+
+// mmBackend uses ModemManager's mmcli JSON output, present on some newer
+// RUTOS builds and on generic OpenWrt with the modemmanager package.
+type mmBackend struct{}
+
+func (b *mmBackend) name() string { return "modemmanager" }
+
+func (b *mmBackend) available() bool {
+	if !haveBinary("mmcli") {
+		return false
+	}
+	// A listed modem is required; mmcli exits 0 with an empty list.
+	out, err := exec.Command("mmcli", "-L", "-J").Output()
+	if err != nil {
+		return false
+	}
+	var list struct {
+		ModemList []string `json:"modem-list"`
+	}
+	return json.Unmarshal(out, &list) == nil && len(list.ModemList) > 0
+}
+
+func (b *mmBackend) signal(ctx context.Context) (*signalData, error) {
+	out, err := exec.CommandContext(ctx, "mmcli", "-m", "any", "--signal-get", "-J").Output()
+	if err != nil {
+		return nil, fmt.Errorf("mmcli signal: %w", err)
+	}
+	// mmcli encodes all numbers as strings ("--" when absent).
+	var raw struct {
+		Modem struct {
+			Signal struct {
+				LTE struct {
+					RSRP string `json:"rsrp"`
+					RSRQ string `json:"rsrq"`
+					RSSI string `json:"rssi"`
+					SNR  string `json:"snr"`
+				} `json:"lte"`
+				NR5G struct {
+					RSRP string `json:"rsrp"`
+					RSRQ string `json:"rsrq"`
+					SNR  string `json:"snr"`
+				} `json:"5g"`
+			} `json:"signal"`
+		} `json:"modem"`
+	}
+	if err := json.Unmarshal(out, &raw); err != nil {
+		return nil, fmt.Errorf("mmcli signal parse: %w", err)
+	}
+	sd := &signalData{}
+	lte := raw.Modem.Signal.LTE
+	nr := raw.Modem.Signal.NR5G
+	if v, ok := mmFloat(nr.RSRP); ok {
+		sd.RSRP, sd.Network = v, "5G"
+		if v, ok := mmFloat(nr.RSRQ); ok {
+			sd.RSRQ = v
+		}
+		if v, ok := mmFloat(nr.SNR); ok {
+			sd.SINR = v
+		}
+		return sd, nil
+	}
+	if v, ok := mmFloat(lte.RSRP); ok {
+		sd.RSRP, sd.Network = v, "LTE"
+	}
+	if v, ok := mmFloat(lte.RSRQ); ok {
+		sd.RSRQ = v
+	}
+	if v, ok := mmFloat(lte.RSSI); ok {
+		sd.RSSI = v
+	}
+	if v, ok := mmFloat(lte.SNR); ok {
+		sd.SINR = v
+	}
+	if sd.RSRP == nil && sd.RSSI == nil {
+		return nil, fmt.Errorf("mmcli reported no signal values")
+	}
+	return sd, nil
+}
+
+// mmFloat parses mmcli's stringly-typed numbers, treating "--" as absent.
+func mmFloat(s string) (*float64, bool) {
+	if s == "" || s == "--" {
+		return nil, false
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil, false
+	}
+	return &v, true
+}
@@ -0,0 +1,73 @@
+package cellular
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+)
+
+// uqmiBackend reads signal data over QMI, which returns stable JSON across
+// RUTOS versions (the modem speaks QMI directly, no AT parsing involved).
+type uqmiBackend struct {
+	device string
+}
+
+func (b *uqmiBackend) name() string { return "uqmi" }
+
+func (b *uqmiBackend) available() bool {
+	if !haveBinary("uqmi") {
+		return false
+	}
+	devs, _ := filepath.Glob("/dev/cdc-wdm*")
+	if len(devs) == 0 {
+		return false
+	}
+	b.device = devs[0]
+	return true
+}
+
+func (b *uqmiBackend) signal(ctx context.Context) (*signalData, error) {
+	out, err := exec.CommandContext(ctx, "uqmi", "-d", b.device, "--get-signal-info").Output()
+	if err != nil {
+		return nil, fmt.Errorf("uqmi signal: %w", err)
+	}
+	// Example: {"type":"lte","rssi":-61,"rsrq":-9,"rsrp":-90,"snr":13.2}
+	var raw struct {
+		Type string   `json:"type"`
+		RSSI *float64 `json:"rssi"`
+		RSRQ *float64 `json:"rsrq"`
+		RSRP *float64 `json:"rsrp"`
+		SNR  *float64 `json:"snr"`
+	}
+	if err := json.Unmarshal(out, &raw); err != nil {
+		return nil, fmt.Errorf("uqmi signal parse: %w", err)
+	}
+	sd := &signalData{
+		RSSI: raw.RSSI,
+		RSRQ: raw.RSRQ,
+		RSRP: raw.RSRP,
+		SINR: raw.SNR,
+	}
+	switch raw.Type {
+	case "lte":
+		sd.Network = "LTE"
+	case "5gnr", "nr5g":
+		sd.Network = "5G"
+	default:
+		sd.Network = raw.Type
+	}
+	// Serving system gives operator and roaming status.
+	if out, err := exec.CommandContext(ctx, "uqmi", "-d", b.device, "--get-serving-system").Output(); err == nil {
+		var srv struct {
+			PLMNDescription string `json:"plmn_description"`
+			Roaming         bool   `json:"roaming"`
+		}
+		if json.Unmarshal(out, &srv) == nil {
+			sd.Operator = srv.PLMNDescription
+			sd.Roaming = srv.Roaming
+		}
+	}
+	return sd, nil
+}
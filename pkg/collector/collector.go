@@ -0,0 +1,42 @@
+// Package collector defines the interface every metrics source implements
+// and a registry keyed by member class.
+package collector
+
+import (
+	"context"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/metrics"
+)
+
+// Collector produces one sample for one member per decision tick.
+type Collector interface {
+	// Class identifies the member class this collector serves
+	// (starlink, cellular, wifi, lan, vpn, generic).
+	Class() string
+	// Collect gathers a sample. Implementations must honor ctx.
+	Collect(ctx context.Context, iface string) (*metrics.Metrics, error)
+}
+
+// Registry maps member classes to collectors.
+type Registry struct {
+	byClass map[string]Collector
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{byClass: make(map[string]Collector)}
+}
+
+// Register adds c for its class, replacing any previous collector.
+func (r *Registry) Register(c Collector) {
+	r.byClass[c.Class()] = c
+}
+
+// ForClass returns the collector for class, falling back to "generic".
+func (r *Registry) ForClass(class string) (Collector, bool) {
+	if c, ok := r.byClass[class]; ok {
+		return c, true
+	}
+	c, ok := r.byClass["generic"]
+	return c, ok
+}
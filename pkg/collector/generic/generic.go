@@ -0,0 +1,48 @@
+// Package generic is the fallback collector for member classes without a
+// dedicated source (wifi uplinks, wired WAN, VPN tunnels): reachability
+// probing only.
+package generic
+
+import (
+	"context"
+	"time"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/metrics"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/probe"
+)
+
+// Collector probes the default targets through the member interface.
+type Collector struct{}
+
+// New returns a generic Collector.
+func New() *Collector { return &Collector{} }
+
+// Class implements collector.Collector.
+func (c *Collector) Class() string { return "generic" }
+
+// Collect implements collector.Collector.
+func (c *Collector) Collect(ctx context.Context, iface string) (*metrics.Metrics, error) {
+	start := time.Now()
+	m := &metrics.Metrics{Timestamp: time.Now(), DataSource: metrics.SourceProbe}
+	agg, perTarget := probe.Run(ctx, iface, probe.ProfileFor(c.Class()), probe.CountFor(iface, 3))
+	m.LatencyMS = metrics.Milliseconds(agg.LatencyMS).Ptr()
+	m.LossPercent = metrics.Percent(agg.LossPercent).Ptr()
+	m.JitterMS = metrics.Milliseconds(agg.JitterMS).Ptr()
+	m.ProbeResults = probeResults(perTarget)
+	if probe.Metered() {
+		if rtt, _, ok := probe.PassiveRTT(); ok {
+			m.PassiveRTTMS = metrics.Milliseconds(rtt).Ptr()
+		}
+	}
+	m.CollectDurationMS = time.Since(start).Milliseconds()
+	return m, nil
+}
+
+// probeResults converts probe results to the metrics-local mirror type.
+func probeResults(in []probe.Result) []metrics.ProbeResult {
+	out := make([]metrics.ProbeResult, 0, len(in))
+	for _, r := range in {
+		out = append(out, metrics.ProbeResult(r))
+	}
+	return out
+}
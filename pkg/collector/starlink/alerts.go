@@ -0,0 +1,33 @@
+package starlink
+
+// DishAlerts field numbers (SpaceX.API.Device.DishAlerts). Every field is a
+// bool on the wire; the names below are the proto field names and double as
+// telemetry event reasons.
+var alertNames = map[int]string{
+	1: "motors_stuck",
+	2: "thermal_shutdown",
+	3: "thermal_throttle",
+	4: "unexpected_location",
+	5: "mast_not_near_vertical",
+	6: "slow_ethernet_speeds",
+	7: "roaming",
+}
+
+// decodeAlerts returns the names of the alert bits set in a parsed
+// DishAlerts submessage. A set bit with an unrecognised field number (new
+// firmware) comes back as "unknown" so it still raises an alert event
+// rather than vanishing.
+func decodeAlerts(fields []field) []string {
+	var out []string
+	for _, f := range fields {
+		if f.wire != wireVarint || f.vint == 0 {
+			continue
+		}
+		name, ok := alertNames[f.num]
+		if !ok {
+			name = "unknown"
+		}
+		out = append(out, name)
+	}
+	return out
+}
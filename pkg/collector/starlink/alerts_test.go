@@ -0,0 +1,45 @@
+package starlink
+
+import "testing"
+
+func TestDecodeAlerts(t *testing.T) {
+	// alerts(8){ motors_stuck(1)=1, thermal_shutdown(2)=0,
+	// unexpected_location(4)=1, field 40 (unknown)=1 }
+	inner := []byte{
+		0x42, 0x09, // alerts submessage, len 9
+		0x08, 0x01, // motors_stuck = true
+		0x10, 0x00, // thermal_shutdown = false
+		0x20, 0x01, // unexpected_location = true
+		0xc0, 0x02, 0x01, // field 40 = true (future firmware alert)
+	}
+	raw := append([]byte{0xe2, 0x3e, byte(len(inner))}, inner...)
+	st, err := parseStatus(raw)
+	if err != nil {
+		t.Fatalf("parseStatus: %v", err)
+	}
+	want := []string{"motors_stuck", "unexpected_location", "unknown"}
+	if len(st.Alerts) != len(want) {
+		t.Fatalf("Alerts = %v, want %v", st.Alerts, want)
+	}
+	for i := range want {
+		if st.Alerts[i] != want[i] {
+			t.Errorf("Alerts[%d] = %q, want %q", i, st.Alerts[i], want[i])
+		}
+	}
+	if !st.SystemAlertsActive {
+		t.Error("SystemAlertsActive = false with alerts set")
+	}
+}
+
+func TestDecodeAlertsAllClear(t *testing.T) {
+	// alerts(8){ thermal_throttle(3)=0 }: bits present but none set.
+	inner := []byte{0x42, 0x02, 0x18, 0x00}
+	raw := append([]byte{0xe2, 0x3e, byte(len(inner))}, inner...)
+	st, err := parseStatus(raw)
+	if err != nil {
+		t.Fatalf("parseStatus: %v", err)
+	}
+	if len(st.Alerts) != 0 || st.SystemAlertsActive {
+		t.Errorf("Alerts = %v, SystemAlertsActive = %v; want none", st.Alerts, st.SystemAlertsActive)
+	}
+}
@@ -0,0 +1,84 @@
+package starlink
+
+import (
+	"fmt"
+	"math"
+)
+
+// wedgeCount / wedgeWidthDeg describe the dish obstruction map: twelve
+// 30-degree wedges clockwise from the boresight azimuth.
+const (
+	wedgeCount    = 12
+	wedgeWidthDeg = 360.0 / wedgeCount
+)
+
+// HeadingSuggestion is a recommended vehicle rotation that points the
+// clearest sector of sky at the satellite boresight.
+type HeadingSuggestion struct {
+	// RotateDeg is the recommended rotation in (-180, 180]; positive is
+	// clockwise.
+	RotateDeg float64 `json:"rotate_deg"`
+	// TargetHeadingDeg is the absolute vehicle heading after rotating,
+	// only present when a GPS heading was available.
+	TargetHeadingDeg *float64 `json:"target_heading_deg,omitempty"`
+	// BoresightObstructedPct is how obstructed the wedge the dish is
+	// currently looking through is.
+	BoresightObstructedPct float64 `json:"boresight_obstructed_pct"`
+	// ClearestObstructedPct is the same for the wedge the rotation would
+	// bring to boresight.
+	ClearestObstructedPct float64 `json:"clearest_obstructed_pct"`
+	// Text is a human-readable one-liner for the alignment assistant.
+	Text string `json:"text"`
+}
+
+// SuggestHeading derives a rotation from the wedge obstruction map.
+// vehicleHeadingDeg is the GPS course over ground; pass headingValid false
+// when no heading is known (parked, no RMC course). Returns nil when the
+// dish reported no usable wedge data or the current pointing is already
+// the clearest option.
+func SuggestHeading(st *Status, vehicleHeadingDeg float64, headingValid bool) *HeadingSuggestion {
+	if st == nil || len(st.WedgeFractionObstructed) != wedgeCount {
+		return nil
+	}
+	// Score each wedge with its neighbours weighted in, so we aim at the
+	// middle of a clear arc rather than a lone clear wedge between trees.
+	best, bestScore := 0, math.MaxFloat64
+	for i := 0; i < wedgeCount; i++ {
+		prev := st.WedgeFractionObstructed[(i+wedgeCount-1)%wedgeCount]
+		next := st.WedgeFractionObstructed[(i+1)%wedgeCount]
+		score := st.WedgeFractionObstructed[i] + 0.5*(prev+next)
+		if score < bestScore {
+			best, bestScore = i, score
+		}
+	}
+	current := st.WedgeFractionObstructed[0] // wedge 0 straddles boresight
+	clearest := st.WedgeFractionObstructed[best]
+	if best == 0 || clearest >= current {
+		return nil // already pointing at the clearest sky
+	}
+	// Rotating the vehicle by the wedge's offset from boresight swings
+	// that wedge's sky into the dish's view.
+	rotate := float64(best) * wedgeWidthDeg
+	if rotate > 180 {
+		rotate -= 360
+	}
+	s := &HeadingSuggestion{
+		RotateDeg:              rotate,
+		BoresightObstructedPct: current * 100,
+		ClearestObstructedPct:  clearest * 100,
+	}
+	if headingValid {
+		target := math.Mod(vehicleHeadingDeg+rotate+360, 360)
+		s.TargetHeadingDeg = &target
+	}
+	dir := "clockwise"
+	if rotate < 0 {
+		dir = "counterclockwise"
+	}
+	s.Text = fmt.Sprintf("rotate ~%.0f° %s to clear obstructions (%.1f%% -> %.1f%% obstructed)",
+		math.Abs(rotate), dir, s.BoresightObstructedPct, s.ClearestObstructedPct)
+	if s.TargetHeadingDeg != nil {
+		s.Text += fmt.Sprintf(", new heading %.0f°", *s.TargetHeadingDeg)
+	}
+	return s
+}
@@ -0,0 +1,49 @@
+package starlink
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestSuggestHeading(t *testing.T) {
+	wedges := make([]float64, wedgeCount)
+	wedges[0] = 0.40 // boresight blocked by the tree line
+	wedges[1] = 0.35
+	wedges[11] = 0.30
+	wedges[3] = 0.05
+	wedges[7] = 0.05
+	// wedges 4-6 are clear; wedge 5 sits in the middle of the arc.
+	st := &Status{WedgeFractionObstructed: wedges}
+
+	s := SuggestHeading(st, 90, true)
+	if s == nil {
+		t.Fatal("expected a suggestion")
+	}
+	if s.RotateDeg != 150 {
+		t.Fatalf("rotate = %v, want 150 (wedge 5)", s.RotateDeg)
+	}
+	if s.TargetHeadingDeg == nil || math.Abs(*s.TargetHeadingDeg-240) > 0.01 {
+		t.Fatalf("target heading = %v, want 240", s.TargetHeadingDeg)
+	}
+	if !strings.Contains(s.Text, "clockwise") {
+		t.Fatalf("text = %q, want a clockwise recommendation", s.Text)
+	}
+}
+
+func TestSuggestHeadingAlreadyClear(t *testing.T) {
+	wedges := make([]float64, wedgeCount)
+	wedges[6] = 0.8 // obstruction behind the dish only
+	if s := SuggestHeading(&Status{WedgeFractionObstructed: wedges}, 0, false); s != nil {
+		t.Fatalf("expected nil for an already-clear boresight, got %+v", s)
+	}
+}
+
+func TestSuggestHeadingNoWedges(t *testing.T) {
+	if s := SuggestHeading(&Status{}, 0, false); s != nil {
+		t.Fatalf("expected nil without wedge data, got %+v", s)
+	}
+	if s := SuggestHeading(nil, 0, false); s != nil {
+		t.Fatalf("expected nil for nil status, got %+v", s)
+	}
+}
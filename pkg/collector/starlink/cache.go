@@ -0,0 +1,71 @@
+package starlink
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultCacheTTL is how long one dish status reply stays reusable. The
+// engine tick, health checks, and ubus queries land within the same few
+// seconds, and the dish only refreshes its internal stats about once per
+// second, so a short TTL removes redundant API calls without staleness.
+const DefaultCacheTTL = 3 * time.Second
+
+// statusCache memoizes the last status reply for a bounded time.
+type statusCache struct {
+	mu      sync.Mutex
+	status  *Status
+	fetched time.Time
+	ttl     time.Duration
+	hits    atomic.Uint64
+	misses  atomic.Uint64
+}
+
+// get returns the cached status when fresh, or fetches via fn and caches
+// the result. cached reports whether the value was served from cache.
+func (c *statusCache) get(ctx context.Context, fn func(context.Context) (*Status, error)) (st *Status, cached bool, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ttl := c.ttl
+	if ttl == 0 {
+		ttl = DefaultCacheTTL
+	}
+	if c.status != nil && time.Since(c.fetched) < ttl {
+		c.hits.Add(1)
+		return c.status, true, nil
+	}
+	c.misses.Add(1)
+	st, err = fn(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	c.status = st
+	c.fetched = time.Now()
+	return st, false, nil
+}
+
+// invalidate drops the cached status, used after commands that change dish
+// state (stow/unstow) so the next query reflects the new reality.
+func (c *statusCache) invalidate() {
+	c.mu.Lock()
+	c.status = nil
+	c.mu.Unlock()
+}
+
+// CacheStats is a point-in-time view of cache effectiveness.
+type CacheStats struct {
+	Hits   uint64 `json:"hits"`
+	Misses uint64 `json:"misses"`
+}
+
+// Stats returns the cache hit/miss counters.
+func (c *Collector) Stats() CacheStats {
+	return CacheStats{Hits: c.cache.hits.Load(), Misses: c.cache.misses.Load()}
+}
+
+// PoolStats returns the dish connection pool counters.
+func (c *Collector) PoolStats() PoolStats {
+	return c.pool.stats()
+}
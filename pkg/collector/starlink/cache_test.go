@@ -0,0 +1,59 @@
+package starlink
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestStatusCache(t *testing.T) {
+	calls := 0
+	fetch := func(context.Context) (*Status, error) {
+		calls++
+		return &Status{PopPingLatencyMS: 40}, nil
+	}
+	c := &statusCache{ttl: 50 * time.Millisecond}
+
+	st, cached, err := c.get(context.Background(), fetch)
+	if err != nil || cached || st == nil {
+		t.Fatalf("first get: st=%v cached=%v err=%v", st, cached, err)
+	}
+	_, cached, err = c.get(context.Background(), fetch)
+	if err != nil || !cached {
+		t.Fatalf("second get not cached: cached=%v err=%v", cached, err)
+	}
+	if calls != 1 {
+		t.Fatalf("fetch called %d times, want 1", calls)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	_, cached, err = c.get(context.Background(), fetch)
+	if err != nil || cached {
+		t.Fatalf("get after TTL served from cache")
+	}
+	if calls != 2 {
+		t.Fatalf("fetch called %d times after expiry, want 2", calls)
+	}
+	if c.hits.Load() != 1 || c.misses.Load() != 2 {
+		t.Errorf("counters = %d hits / %d misses, want 1/2", c.hits.Load(), c.misses.Load())
+	}
+}
+
+func TestStatusCacheError(t *testing.T) {
+	c := &statusCache{}
+	wantErr := errors.New("dish down")
+	_, _, err := c.get(context.Background(), func(context.Context) (*Status, error) {
+		return nil, wantErr
+	})
+	if !errors.Is(err, wantErr) {
+		t.Fatalf("err = %v, want %v", err, wantErr)
+	}
+	// A failed fetch must not be cached.
+	st, cached, err := c.get(context.Background(), func(context.Context) (*Status, error) {
+		return &Status{}, nil
+	})
+	if err != nil || cached || st == nil {
+		t.Fatalf("recovery get: st=%v cached=%v err=%v", st, cached, err)
+	}
+}
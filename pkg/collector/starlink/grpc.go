@@ -0,0 +1,91 @@
+// Package starlink talks to the dish's local gRPC endpoint
+// (192.168.100.1:9200) without linking the full gRPC stack.
+//
+// The dish only ever serves the SpaceX.API.Device.Device/Handle method over
+// cleartext HTTP/2, so the client below hand-rolls just enough of h2c and
+// gRPC framing for that one unary call: connection preface, a SETTINGS
+// frame, one HEADERS frame encoded with HPACK literals, one DATA frame with
+// the 5-byte gRPC length prefix, then it reads DATA frames back until
+// END_STREAM. This keeps the binary small enough for RUTX-class flash.
+// Connection lifecycle (reuse, keepalive, re-dial) lives in pool.go.
+package starlink
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// DefaultAddr is the dish API endpoint in bypass-mode installs.
+const DefaultAddr = "192.168.100.1:9200"
+
+const grpcPath = "/SpaceX.API.Device.Device/Handle"
+
+const h2Preface = "PRI * HTTP/2.0\r\n\r\nSM\r\n\r\n"
+
+// frame types we care about.
+const (
+	frameData     = 0x0
+	frameHeaders  = 0x1
+	frameSettings = 0x4
+	frameGoAway   = 0x7
+	frameWindow   = 0x8
+)
+
+const (
+	flagEndStream  = 0x1
+	flagEndHeaders = 0x4
+	flagAck        = 0x1
+)
+
+func stripGRPCPrefix(data []byte) ([]byte, error) {
+	if len(data) < 5 {
+		return nil, fmt.Errorf("short gRPC message (%d bytes)", len(data))
+	}
+	n := binary.BigEndian.Uint32(data[1:5])
+	if int(n) > len(data)-5 {
+		return nil, fmt.Errorf("truncated gRPC message")
+	}
+	return data[5 : 5+n], nil
+}
+
+func appendFrame(buf []byte, ftype, flags byte, stream uint32, payload []byte) []byte {
+	l := len(payload)
+	buf = append(buf, byte(l>>16), byte(l>>8), byte(l))
+	buf = append(buf, ftype, flags)
+	buf = append(buf, byte(stream>>24), byte(stream>>16), byte(stream>>8), byte(stream))
+	return append(buf, payload...)
+}
+
+// hpackRequest builds the request header block using only literal header
+// fields with incremental indexing off, which every HPACK decoder accepts.
+func hpackRequest(authority string) []byte {
+	var b []byte
+	add := func(name, value string) {
+		b = append(b, 0x00) // literal, not indexed
+		b = appendHpackString(b, name)
+		b = appendHpackString(b, value)
+	}
+	add(":method", "POST")
+	add(":scheme", "http")
+	add(":path", grpcPath)
+	add(":authority", authority)
+	add("content-type", "application/grpc")
+	add("te", "trailers")
+	return b
+}
+
+func appendHpackString(b []byte, s string) []byte {
+	// Huffman off; 7-bit length is plenty for our short strings.
+	if len(s) < 127 {
+		b = append(b, byte(len(s)))
+	} else {
+		b = append(b, 127)
+		n := len(s) - 127
+		for n >= 128 {
+			b = append(b, byte(n)|0x80)
+			n >>= 7
+		}
+		b = append(b, byte(n))
+	}
+	return append(b, s...)
+}
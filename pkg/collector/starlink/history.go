@@ -0,0 +1,176 @@
+package starlink
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Field numbers inside the get_history exchange (DishGetHistoryResponse).
+// Like get_status these have been stable across firmware for years.
+const (
+	fReqGetHistory      = 1006 // Request.get_history
+	fRespDishGetHistory = 1006 // Response.dish_get_history
+	fHistoryCurrent     = 1    // samples written since boot (uint64)
+	fHistoryPopDropRate = 1001 // ring buffer of per-second pop ping drop rates
+)
+
+// getHistoryRequest is the serialized Request{get_history{}} message:
+// field 1006, wire type 2, empty payload.
+func getHistoryRequest() []byte {
+	// tag = 1006<<3 | 2 = 8050 -> varint f2 3e, then length 0
+	return []byte{0xf2, 0x3e, 0x00}
+}
+
+// History is the subset of the dish history the forecast uses: per-second
+// pop ping drop rates in chronological order, oldest first.
+type History struct {
+	DropRate []float64
+	Current  uint64
+}
+
+// parseHistory decodes a get_history reply and unrolls its ring buffer.
+func parseHistory(raw []byte) (*History, error) {
+	top, err := parseFields(raw)
+	if err != nil {
+		return nil, err
+	}
+	resp, ok := sub(top, fRespDishGetHistory)
+	if !ok {
+		resp = top // some firmwares omit the wrapper
+	}
+	h := &History{}
+	h.Current, _ = varintAt(resp, fHistoryCurrent)
+	h.DropRate = orderRing(packedFloats(resp, fHistoryPopDropRate), h.Current)
+	return h, nil
+}
+
+// orderRing rewinds the dish's ring buffer into chronological order. The
+// dish writes one sample per second at index current % len(ring), so the
+// oldest sample sits at that index and the newest just before it; until
+// the ring has wrapped only the first current entries hold data.
+func orderRing(ring []float64, current uint64) []float64 {
+	n := len(ring)
+	if n == 0 {
+		return nil
+	}
+	if current < uint64(n) {
+		return append([]float64(nil), ring[:current]...)
+	}
+	at := int(current % uint64(n))
+	out := make([]float64, 0, n)
+	out = append(out, ring[at:]...)
+	return append(out, ring[:at]...)
+}
+
+// outageDropThreshold is the per-second drop rate at or above which a
+// history sample counts as an outage second: the dish reports 1.0 during a
+// true outage and small fractions during ordinary packet loss.
+const outageDropThreshold = 0.9
+
+// forecastHorizonS is how far ahead the forecast looks — ten minutes, the
+// window over which "expect micro-outages" is actionable for a failover
+// decision.
+const forecastHorizonS = 600
+
+// minOutageGaps is how many inter-outage gaps the empirical distribution
+// needs before the forecast says anything; with fewer, one coincidence
+// would dominate the probability.
+const minOutageGaps = 3
+
+// Forecast is the history-derived outage outlook for one dish.
+type Forecast struct {
+	// ProbabilityPct is the empirical probability (0-100) of another
+	// outage starting within the next forecastHorizonS seconds.
+	ProbabilityPct float64 `json:"probability_pct"`
+	// Outages counts the outage episodes seen in the history buffer.
+	Outages int `json:"outages"`
+	// SinceLastS is seconds since the last outage ended, -1 when the
+	// buffer holds none.
+	SinceLastS int `json:"since_last_s"`
+}
+
+// outageForecast derives the outlook from a chronological drop-rate series.
+// Consecutive outage seconds collapse into episodes; the gaps between
+// episode starts form an empirical inter-outage distribution, and the
+// probability is the renewal-process conditional: of the recorded gaps
+// longer than the current quiet spell, the fraction that ended within the
+// horizon. Micro-outage clusters (short gaps) therefore push the
+// probability up sharply while a long-quiet dish forecasts nothing.
+func outageForecast(drops []float64) Forecast {
+	f := Forecast{SinceLastS: -1}
+	var starts []int
+	inOutage := false
+	lastEnd := -1
+	for i, v := range drops {
+		if v >= outageDropThreshold {
+			if !inOutage {
+				starts = append(starts, i)
+				inOutage = true
+			}
+			lastEnd = i
+		} else {
+			inOutage = false
+		}
+	}
+	f.Outages = len(starts)
+	if lastEnd >= 0 {
+		f.SinceLastS = len(drops) - 1 - lastEnd
+	}
+	if len(starts) < minOutageGaps+1 {
+		return f
+	}
+	gaps := make([]int, 0, len(starts)-1)
+	for i := 1; i < len(starts); i++ {
+		gaps = append(gaps, starts[i]-starts[i-1])
+	}
+	elapsed := len(drops) - starts[len(starts)-1]
+	within, longer := 0, 0
+	for _, g := range gaps {
+		if g <= elapsed {
+			continue
+		}
+		longer++
+		if g <= elapsed+forecastHorizonS {
+			within++
+		}
+	}
+	if longer == 0 {
+		// The quiet spell has outlasted every recorded gap, so the
+		// distribution has nothing to say about the next ten minutes.
+		return f
+	}
+	f.ProbabilityPct = 100 * float64(within) / float64(longer)
+	return f
+}
+
+// historyRefresh is how often the forecast re-fetches dish history. The
+// forecast moves slowly and the history reply is by far the largest dish
+// message, so it is polled far less often than status.
+const historyRefresh = time.Minute
+
+// Forecast returns the latest history-derived outage forecast, re-fetching
+// when stale. A failed fetch degrades to the previous forecast — a dish
+// that stops answering get_history is already being penalized through its
+// status metrics.
+func (c *Collector) Forecast(ctx context.Context) (Forecast, bool) {
+	c.histMu.Lock()
+	defer c.histMu.Unlock()
+	if time.Since(c.histFetched) < historyRefresh {
+		return c.histForecast, c.histOK
+	}
+	c.histFetched = time.Now()
+	raw, err := c.pool.call(ctx, getHistoryRequest())
+	if err != nil {
+		log.Printf("starlink: get_history: %v", err)
+		return c.histForecast, c.histOK
+	}
+	h, err := parseHistory(raw)
+	if err != nil {
+		log.Printf("starlink: parse history: %v", err)
+		return c.histForecast, c.histOK
+	}
+	c.histForecast = outageForecast(h.DropRate)
+	c.histOK = true
+	return c.histForecast, true
+}
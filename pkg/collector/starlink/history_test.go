@@ -0,0 +1,122 @@
+package starlink
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+// buildHistoryResponse serializes a dish_get_history reply:
+// Response{ dish_get_history{ current(1), pop_ping_drop_rate(1001) packed } }.
+func buildHistoryResponse(current uint64, ring []float64) []byte {
+	var inner []byte
+	inner = append(inner, 0x08) // current: field 1, wire 0
+	inner = binary.AppendUvarint(inner, current)
+	// pop_ping_drop_rate: field 1001, wire 2 -> tag (1001<<3|2)=8010 = 0xca 0x3e
+	inner = append(inner, 0xca, 0x3e)
+	inner = binary.AppendUvarint(inner, uint64(4*len(ring)))
+	for _, v := range ring {
+		inner = binary.LittleEndian.AppendUint32(inner, math.Float32bits(float32(v)))
+	}
+	// wrapper: field 1006, wire 2 -> tag (1006<<3|2)=8050 = 0xf2 0x3e
+	out := []byte{0xf2, 0x3e}
+	out = binary.AppendUvarint(out, uint64(len(inner)))
+	return append(out, inner...)
+}
+
+func TestParseHistoryOrdersRing(t *testing.T) {
+	// current=6 over a 4-slot ring: next write lands at index 2, so
+	// chronological order starts there.
+	raw := buildHistoryResponse(6, []float64{0.25, 0.5, 0.75, 1.0})
+	h, err := parseHistory(raw)
+	if err != nil {
+		t.Fatalf("parseHistory: %v", err)
+	}
+	if h.Current != 6 {
+		t.Errorf("Current = %d, want 6", h.Current)
+	}
+	want := []float64{0.75, 1.0, 0.25, 0.5}
+	if len(h.DropRate) != len(want) {
+		t.Fatalf("DropRate = %v, want %v", h.DropRate, want)
+	}
+	for i, v := range want {
+		if h.DropRate[i] != v {
+			t.Errorf("DropRate[%d] = %v, want %v", i, h.DropRate[i], v)
+		}
+	}
+}
+
+func TestParseHistoryPartialRing(t *testing.T) {
+	// Before the ring wraps only the first current entries hold data.
+	raw := buildHistoryResponse(2, []float64{0.25, 0.5, 0, 0})
+	h, err := parseHistory(raw)
+	if err != nil {
+		t.Fatalf("parseHistory: %v", err)
+	}
+	if len(h.DropRate) != 2 || h.DropRate[0] != 0.25 || h.DropRate[1] != 0.5 {
+		t.Errorf("DropRate = %v, want [0.25 0.5]", h.DropRate)
+	}
+}
+
+// dropSeries builds a clean series of length n with single-second outages
+// at the given indices.
+func dropSeries(n int, outageAt ...int) []float64 {
+	drops := make([]float64, n)
+	for _, i := range outageAt {
+		drops[i] = 1.0
+	}
+	return drops
+}
+
+func TestOutageForecastPeriodicOutages(t *testing.T) {
+	// Outages every 300s, last one 100s ago: every recorded gap longer
+	// than the quiet spell ends well inside the 600s horizon.
+	f := outageForecast(dropSeries(2200, 0, 300, 600, 900, 1200, 1500, 1800, 2100))
+	if f.Outages != 8 {
+		t.Errorf("Outages = %d, want 8", f.Outages)
+	}
+	if f.SinceLastS != 99 {
+		t.Errorf("SinceLastS = %d, want 99", f.SinceLastS)
+	}
+	if f.ProbabilityPct != 100 {
+		t.Errorf("ProbabilityPct = %v, want 100", f.ProbabilityPct)
+	}
+}
+
+func TestOutageForecastCleanHistory(t *testing.T) {
+	f := outageForecast(dropSeries(900))
+	if f.Outages != 0 || f.ProbabilityPct != 0 {
+		t.Errorf("clean history forecast = %+v, want zero", f)
+	}
+	if f.SinceLastS != -1 {
+		t.Errorf("SinceLastS = %d, want -1", f.SinceLastS)
+	}
+}
+
+func TestOutageForecastTooFewGaps(t *testing.T) {
+	// Two episodes give one gap: not enough distribution to forecast.
+	f := outageForecast(dropSeries(900, 100, 400))
+	if f.Outages != 2 {
+		t.Errorf("Outages = %d, want 2", f.Outages)
+	}
+	if f.ProbabilityPct != 0 {
+		t.Errorf("ProbabilityPct = %v, want 0", f.ProbabilityPct)
+	}
+}
+
+func TestOutageForecastQuietSpellOutlastsGaps(t *testing.T) {
+	// A burst of micro-outages followed by a long quiet spell: the spell
+	// exceeds every recorded gap, so the forecast stays silent.
+	f := outageForecast(dropSeries(5000, 0, 10, 20, 30))
+	if f.ProbabilityPct != 0 {
+		t.Errorf("ProbabilityPct = %v, want 0", f.ProbabilityPct)
+	}
+}
+
+func TestOutageForecastCollapsesEpisodes(t *testing.T) {
+	// Three consecutive outage seconds are one episode, not three.
+	drops := dropSeries(600, 100, 101, 102)
+	if f := outageForecast(drops); f.Outages != 1 {
+		t.Errorf("Outages = %d, want 1", f.Outages)
+	}
+}
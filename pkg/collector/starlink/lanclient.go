@@ -0,0 +1,110 @@
+package starlink
+
+// Reduced-fidelity fallback for installs where the dish sits behind its
+// own Starlink router (no bypass mode). From the WAN side of such a
+// setup the dish gRPC API never answers, and until now every tick fell
+// back to mock values. The Starlink router's LAN address still serves
+// its local status endpoint, which recovers a little truth: the router
+// is reachable, how fast it answers, its uptime and software version,
+// and whether it reports the dish as connected. Samples built this way
+// carry SourceHTTP so scoring and the UI can see the reduced fidelity.
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/metrics"
+)
+
+// DefaultRouterAddr is the Starlink router's LAN gateway address.
+const DefaultRouterAddr = "192.168.1.1"
+
+// RouterClient scrapes the Starlink router's local status endpoint.
+type RouterClient struct {
+	Addr string
+
+	client *http.Client
+}
+
+// NewRouterClient returns a client for the router at addr ("" for the
+// default gateway address).
+func NewRouterClient(addr string) *RouterClient {
+	if addr == "" {
+		addr = DefaultRouterAddr
+	}
+	return &RouterClient{
+		Addr:   addr,
+		client: &http.Client{Timeout: 3 * time.Second},
+	}
+}
+
+// RouterStatus is the subset the scrape recovers — far less than the
+// dish API, by design of the setup.
+type RouterStatus struct {
+	LatencyMS       float64 `json:"latency_ms"` // HTTP round trip to the router
+	UptimeS         int64   `json:"uptime_s,omitempty"`
+	SoftwareVersion string  `json:"software_version,omitempty"`
+	DishConnected   bool    `json:"dish_connected"`
+}
+
+// Status fetches the router's status. Firmware that serves JSON on
+// /api/status contributes uptime and dish connectivity; anything that
+// answers at all still contributes reachability and round-trip time.
+func (r *RouterClient) Status(ctx context.Context) (*RouterStatus, error) {
+	start := time.Now()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+r.Addr+"/api/status", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("starlink router %s: %w", r.Addr, err)
+	}
+	defer resp.Body.Close()
+
+	st := &RouterStatus{LatencyMS: float64(time.Since(start).Microseconds()) / 1000}
+	var body struct {
+		UptimeS         int64  `json:"uptime_s"`
+		SoftwareVersion string `json:"software_version"`
+		DishConnected   *bool  `json:"dish_connected"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err == nil {
+		st.UptimeS = body.UptimeS
+		st.SoftwareVersion = body.SoftwareVersion
+		if body.DishConnected != nil {
+			st.DishConnected = *body.DishConnected
+		} else {
+			st.DishConnected = true
+		}
+		return st, nil
+	}
+	// Older firmware serves only the HTML app; reachability and timing
+	// are all we get, and a page served means the router is up.
+	if resp.StatusCode >= 500 {
+		return nil, fmt.Errorf("starlink router %s: status %d", r.Addr, resp.StatusCode)
+	}
+	st.DishConnected = true
+	return st, nil
+}
+
+// routerMetrics converts a scrape into a reduced sample: only the fields
+// the scrape actually recovered are set, so the scorer treats the rest
+// as missing rather than perfect.
+func routerMetrics(st *RouterStatus, start time.Time) *metrics.Metrics {
+	m := &metrics.Metrics{
+		Timestamp:  time.Now(),
+		DataSource: metrics.SourceHTTP,
+		LatencyMS:  metrics.Milliseconds(st.LatencyMS).Ptr(),
+		UptimeS:    metrics.Int64(st.UptimeS),
+	}
+	if !st.DishConnected {
+		// The router answers but reports no dish: that is an outage,
+		// and pretending otherwise would mask it.
+		m.LossPercent = metrics.Percent(100).Ptr()
+	}
+	m.CollectDurationMS = time.Since(start).Milliseconds()
+	return m
+}
@@ -0,0 +1,106 @@
+package starlink
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/metrics"
+)
+
+func routerServer(t *testing.T, handler http.HandlerFunc) *RouterClient {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return NewRouterClient(strings.TrimPrefix(srv.URL, "http://"))
+}
+
+func TestRouterStatusJSON(t *testing.T) {
+	r := routerServer(t, func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/api/status" {
+			http.NotFound(w, req)
+			return
+		}
+		_, _ = w.Write([]byte(`{"uptime_s": 7200, "software_version": "2026.08.1", "dish_connected": true}`))
+	})
+	st, err := r.Status(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.UptimeS != 7200 || st.SoftwareVersion != "2026.08.1" || !st.DishConnected {
+		t.Errorf("status = %+v", st)
+	}
+	if st.LatencyMS <= 0 {
+		t.Error("no round-trip latency recorded")
+	}
+}
+
+func TestRouterStatusHTMLOnlyFirmware(t *testing.T) {
+	r := routerServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("<html>Starlink</html>"))
+	})
+	st, err := r.Status(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.UptimeS != 0 || !st.DishConnected {
+		t.Errorf("status = %+v, want reachability only", st)
+	}
+}
+
+func TestRouterStatusUnreachable(t *testing.T) {
+	r := NewRouterClient("127.0.0.1:1")
+	if _, err := r.Status(context.Background()); err == nil {
+		t.Fatal("dead router accepted")
+	}
+}
+
+func TestCollectFallsBackToRouterScrape(t *testing.T) {
+	c := New("127.0.0.1:1") // dish API never answers
+	c.Router = routerServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"uptime_s": 600, "dish_connected": true}`))
+	})
+	m, err := c.Collect(context.Background(), "wan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.DataSource != metrics.SourceHTTP {
+		t.Fatalf("data source = %s, want %s", m.DataSource, metrics.SourceHTTP)
+	}
+	if m.LatencyMS == nil || *m.LatencyMS <= 0 {
+		t.Error("no latency in the reduced sample")
+	}
+	// Reduced fidelity means absent, not fabricated: no SNR, throughput
+	// or obstruction readings.
+	if m.SNR != nil || m.DownlinkMbps != nil || m.ObstructionPct != nil {
+		t.Errorf("reduced sample fabricated dish readings: %+v", m)
+	}
+}
+
+func TestCollectRouterReportsDishDown(t *testing.T) {
+	c := New("127.0.0.1:1")
+	c.Router = routerServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"dish_connected": false}`))
+	})
+	m, err := c.Collect(context.Background(), "wan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.LossPercent == nil || *m.LossPercent != 100 {
+		t.Errorf("disconnected dish sample = %+v, want 100%% loss", m)
+	}
+}
+
+func TestCollectRouterDeadFallsBackToMock(t *testing.T) {
+	c := New("127.0.0.1:1")
+	c.Router = NewRouterClient("127.0.0.1:1")
+	m, err := c.Collect(context.Background(), "wan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.DataSource != metrics.SourceMock {
+		t.Errorf("data source = %s, want %s", m.DataSource, metrics.SourceMock)
+	}
+}
@@ -0,0 +1,179 @@
+package starlink
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// The dish response is protobuf but we deliberately avoid generated code:
+// the SpaceX proto definitions are unversioned and field numbers have been
+// stable for years, so a small wire-format walker keyed on field numbers is
+// both smaller and more tolerant of firmware drift than regenerating stubs.
+
+// wire types
+const (
+	wireVarint = 0
+	wire64     = 1
+	wireBytes  = 2
+	wire32     = 5
+)
+
+// field holds one decoded wire-format field.
+type field struct {
+	num  int
+	wire int
+	vint uint64 // varint / fixed values
+	raw  []byte // bytes fields
+}
+
+// maxFields bounds how many fields one message level may contain, so a
+// malformed reply cannot make the daemon allocate without limit.
+const maxFields = 4096
+
+// parseFields walks one message level and returns its fields in order.
+func parseFields(data []byte) ([]field, error) {
+	var fields []field
+	for len(data) > 0 {
+		if len(fields) >= maxFields {
+			return nil, fmt.Errorf("message has more than %d fields", maxFields)
+		}
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return nil, fmt.Errorf("bad field key")
+		}
+		data = data[n:]
+		f := field{num: int(key >> 3), wire: int(key & 7)}
+		if f.num <= 0 || f.num > 1<<28 {
+			return nil, fmt.Errorf("invalid field number %d", f.num)
+		}
+		switch f.wire {
+		case wireVarint:
+			v, m := binary.Uvarint(data)
+			if m <= 0 {
+				return nil, fmt.Errorf("bad varint in field %d", f.num)
+			}
+			f.vint = v
+			data = data[m:]
+		case wire64:
+			if len(data) < 8 {
+				return nil, fmt.Errorf("short fixed64 in field %d", f.num)
+			}
+			f.vint = binary.LittleEndian.Uint64(data)
+			f.raw = data[:8]
+			data = data[8:]
+		case wire32:
+			if len(data) < 4 {
+				return nil, fmt.Errorf("short fixed32 in field %d", f.num)
+			}
+			f.vint = uint64(binary.LittleEndian.Uint32(data))
+			f.raw = data[:4]
+			data = data[4:]
+		case wireBytes:
+			l, m := binary.Uvarint(data)
+			if m <= 0 || uint64(len(data)-m) < l {
+				return nil, fmt.Errorf("bad length in field %d", f.num)
+			}
+			f.raw = data[m : m+int(l)]
+			data = data[m+int(l):]
+		default:
+			return nil, fmt.Errorf("unsupported wire type %d", f.wire)
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// sub returns the first embedded message under field num, parsed.
+func sub(fields []field, num int) ([]field, bool) {
+	for _, f := range fields {
+		if f.num == num && f.wire == wireBytes {
+			inner, err := parseFields(f.raw)
+			if err != nil {
+				return nil, false
+			}
+			return inner, true
+		}
+	}
+	return nil, false
+}
+
+// floatAt returns the float value of field num, handling both float and
+// double encodings.
+func floatAt(fields []field, num int) (float64, bool) {
+	for _, f := range fields {
+		if f.num != num {
+			continue
+		}
+		switch f.wire {
+		case wire32:
+			return float64(bytesToFloat32(f.raw)), true
+		case wire64:
+			return bytesToFloat64(f.raw), true
+		}
+	}
+	return 0, false
+}
+
+// packedFloats returns the values of a repeated float field, handling both
+// packed (one length-delimited run of 4-byte floats) and unpacked
+// (repeated fixed32) encodings.
+func packedFloats(fields []field, num int) []float64 {
+	var out []float64
+	for _, f := range fields {
+		if f.num != num {
+			continue
+		}
+		switch f.wire {
+		case wire32:
+			out = append(out, float64(bytesToFloat32(f.raw)))
+		case wireBytes:
+			for i := 0; i+4 <= len(f.raw); i += 4 {
+				out = append(out, float64(bytesToFloat32(f.raw[i:i+4])))
+			}
+		}
+	}
+	return out
+}
+
+// varintAt returns the varint value of field num.
+func varintAt(fields []field, num int) (uint64, bool) {
+	for _, f := range fields {
+		if f.num == num && f.wire == wireVarint {
+			return f.vint, true
+		}
+	}
+	return 0, false
+}
+
+// bytesToFloat64 decodes 8 little-endian bytes as a float64.
+func bytesToFloat64(b []byte) float64 {
+	if len(b) < 8 {
+		return 0
+	}
+	return math.Float64frombits(binary.LittleEndian.Uint64(b))
+}
+
+// bytesToFloat32 decodes 4 little-endian bytes as a float32.
+func bytesToFloat32(b []byte) float32 {
+	if len(b) < 4 {
+		return 0
+	}
+	return math.Float32frombits(binary.LittleEndian.Uint32(b))
+}
+
+// scanFloats heuristically extracts every plausible 32-bit float in the
+// message. It is only consulted when the operator explicitly enables
+// heuristic extraction (starfail.starlink.allow_heuristic) and any sample
+// built from it is flagged as heuristic, never silently mixed with
+// field-addressed values.
+func scanFloats(data []byte) []float64 {
+	var out []float64
+	for i := 0; i+4 <= len(data); i++ {
+		v := bytesToFloat32(data[i : i+4])
+		if v == v && v > -1000 && v < 100000 { // NaN and range filter
+			out = append(out, float64(v))
+		}
+	}
+	return out
+}
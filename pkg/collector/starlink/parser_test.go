@@ -0,0 +1,139 @@
+package starlink
+
+import "testing"
+
+// buildStatusResponse serializes a minimal dish_get_status reply for tests:
+// Response{ dish_get_status{ pop_ping_latency_ms(9): 45.5 } }.
+func buildStatusResponse() []byte {
+	// inner: field 9, wire 5 (float32 45.5 = 0x42360000 LE)
+	inner := []byte{0x4d, 0x00, 0x00, 0x36, 0x42}
+	// wrapper: field 1004, wire 2 -> tag varint (1004<<3|2)=8034 = 0xe2 0x3e
+	out := []byte{0xe2, 0x3e, byte(len(inner))}
+	return append(out, inner...)
+}
+
+func TestParseStatus(t *testing.T) {
+	st, err := parseStatus(buildStatusResponse())
+	if err != nil {
+		t.Fatalf("parseStatus: %v", err)
+	}
+	if st.PopPingLatencyMS < 45.4 || st.PopPingLatencyMS > 45.6 {
+		t.Errorf("PopPingLatencyMS = %v, want 45.5", st.PopPingLatencyMS)
+	}
+}
+
+// TestParseStatusFullFixture exercises a reply with double, float, varint
+// and nested fields, byte-for-byte as a real dish frames them.
+func TestParseStatusFullFixture(t *testing.T) {
+	inner := []byte{
+		// device_state(1){ uptime_s(1)=7200 }: tag 0x0a len 4, inner tag 0x08 varint
+		0x0a, 0x04, 0x08, 0xa0, 0xb8, 0x01,
+		// pop_ping_latency_ms(9) float32 33.25 = 0x42050000
+		0x4d, 0x00, 0x00, 0x05, 0x42,
+		// downlink_throughput_bps(10) float32 1.5e8 = 0x4d0f0d18 (LE 18 0d 0f 4d)
+		0x55, 0x18, 0x0d, 0x0f, 0x4d,
+		// snr(12) double 9.5 = 0x4023000000000000 (LE)
+		0x61, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x23, 0x40,
+		// obstruction_stats(7){ fraction_obstructed(1) float32 0.02 }
+		0x3a, 0x05, 0x0d, 0x0a, 0xd7, 0xa3, 0x3c,
+	}
+	raw := append([]byte{0xe2, 0x3e, byte(len(inner))}, inner...)
+	st, err := parseStatus(raw)
+	if err != nil {
+		t.Fatalf("parseStatus: %v", err)
+	}
+	if st.UptimeS != 23584 { // varint a0 b8 01
+		t.Errorf("UptimeS = %d, want 23584", st.UptimeS)
+	}
+	if st.PopPingLatencyMS != 33.25 {
+		t.Errorf("PopPingLatencyMS = %v, want 33.25", st.PopPingLatencyMS)
+	}
+	if st.DownlinkMbps < 149.9 || st.DownlinkMbps > 150.1 {
+		t.Errorf("DownlinkMbps = %v, want ~150", st.DownlinkMbps)
+	}
+	if st.SNR != 9.5 {
+		t.Errorf("SNR = %v, want 9.5", st.SNR)
+	}
+	if st.ObstructionPct < 1.99 || st.ObstructionPct > 2.01 {
+		t.Errorf("ObstructionPct = %v, want 2", st.ObstructionPct)
+	}
+	if st.Heuristic {
+		t.Error("field-addressed parse flagged as heuristic")
+	}
+}
+
+func TestBytesToFloat(t *testing.T) {
+	if v := bytesToFloat64([]byte{0, 0, 0, 0, 0, 0, 0x23, 0x40}); v != 9.5 {
+		t.Errorf("bytesToFloat64 = %v, want 9.5", v)
+	}
+	if v := bytesToFloat32([]byte{0, 0, 0x05, 0x42}); v != 33.25 {
+		t.Errorf("bytesToFloat32 = %v, want 33.25", v)
+	}
+	// Short input must not panic and returns zero.
+	if v := bytesToFloat64([]byte{1, 2}); v != 0 {
+		t.Errorf("short bytesToFloat64 = %v, want 0", v)
+	}
+	if v := bytesToFloat32(nil); v != 0 {
+		t.Errorf("nil bytesToFloat32 = %v, want 0", v)
+	}
+}
+
+func TestHeuristicStatus(t *testing.T) {
+	// 33.25 as float32 LE somewhere in an unrecognized message.
+	raw := []byte{0xff, 0x00, 0x00, 0x05, 0x42, 0xff}
+	st, ok := heuristicStatus(raw)
+	if !ok {
+		t.Fatal("heuristicStatus found nothing")
+	}
+	if !st.Heuristic {
+		t.Error("heuristic result not flagged")
+	}
+	if st.PopPingLatencyMS != 33.25 {
+		t.Errorf("latency = %v, want 33.25", st.PopPingLatencyMS)
+	}
+	if _, ok := heuristicStatus([]byte{1, 2}); ok {
+		t.Error("heuristicStatus invented a value from 2 bytes")
+	}
+}
+
+func TestParseFieldsRejectsTruncated(t *testing.T) {
+	for _, data := range [][]byte{
+		{0x09},                   // fixed64 tag, no payload
+		{0x0d, 0x00},             // fixed32 tag, short payload
+		{0x0a, 0x05, 0x01},       // bytes tag, length beyond input
+		{0x00, 0x01},             // field number 0
+		{0xff, 0xff, 0xff, 0xff}, // unterminated varint key
+	} {
+		if _, err := parseFields(data); err == nil {
+			t.Errorf("parseFields(%x) succeeded, want error", data)
+		}
+	}
+}
+
+func FuzzParseFields(f *testing.F) {
+	f.Add([]byte{})
+	f.Add(buildStatusResponse())
+	f.Add([]byte{0x08, 0x96, 0x01})       // varint field
+	f.Add([]byte{0x0a, 0x02, 0x08, 0x01}) // nested bytes
+	f.Fuzz(func(t *testing.T, data []byte) {
+		// Must never panic or hang, errors are fine.
+		fields, err := parseFields(data)
+		if err != nil {
+			return
+		}
+		for _, fl := range fields {
+			_, _ = floatAt(fields, fl.num)
+			_, _ = varintAt(fields, fl.num)
+			_, _ = sub(fields, fl.num)
+		}
+		_ = scanFloats(data)
+	})
+}
+
+func FuzzParseStatus(f *testing.F) {
+	f.Add(buildStatusResponse())
+	f.Add([]byte{0xe2, 0x3e, 0x00})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		_, _ = parseStatus(data)
+	})
+}
@@ -0,0 +1,241 @@
+package starlink
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// The dish is happiest with one long-lived connection: dialing per call
+// adds latency and (at our poll rates) measurable dish-side load. The
+// pool keeps a single h2c connection open, runs one stream per unary
+// call with increasing stream ids, pings before reuse after idle gaps,
+// recycles the connection past an age limit, and re-dials transparently
+// when the dish drops it mid-call.
+
+const (
+	framePing = 0x6
+
+	// maxConnAge bounds how long one connection is reused before a
+	// planned re-dial, so firmware-side resource leaks never accumulate.
+	maxConnAge = 5 * time.Minute
+
+	// keepaliveIdle is the idle gap after which the next call sends a
+	// PING ahead of its request, flushing out half-dead connections.
+	keepaliveIdle = 10 * time.Second
+)
+
+// pooledConn is one live h2c connection with its stream-id cursor.
+type pooledConn struct {
+	conn       net.Conn
+	nextStream uint32
+	dialedAt   time.Time
+	lastUsedAt time.Time
+}
+
+// pool serializes unary calls over one pooled connection.
+type pool struct {
+	addr string
+
+	mu sync.Mutex
+	pc *pooledConn
+	// device, when non-empty, is bound via SO_BINDTODEVICE at dial time so
+	// VRF setups route the dish connection by the VRF's table.
+	device string
+
+	dials        atomic.Uint64
+	dialFailures atomic.Uint64
+	reuses       atomic.Uint64
+}
+
+// call performs one unary request, reusing the pooled connection when
+// possible and re-dialing once when a reused connection turns out dead.
+func (p *pool) call(ctx context.Context, reqMsg []byte) ([]byte, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.pc != nil && time.Since(p.pc.dialedAt) > maxConnAge {
+		_ = p.pc.conn.Close()
+		p.pc = nil
+	}
+	reused := p.pc != nil
+	if !reused {
+		if err := p.dial(ctx); err != nil {
+			return nil, err
+		}
+	} else {
+		p.reuses.Add(1)
+		if time.Since(p.pc.lastUsedAt) > keepaliveIdle {
+			// A best-effort PING; a dead connection surfaces as a write
+			// or read error in the round trip below and triggers re-dial.
+			ping := appendFrame(nil, framePing, 0, 0, make([]byte, 8))
+			_, _ = p.pc.conn.Write(ping)
+		}
+	}
+
+	resp, err := p.roundTrip(ctx, reqMsg)
+	if err != nil && reused {
+		// The dish dropped the idle connection; one fresh dial gets the
+		// caller its answer without surfacing a spurious error.
+		_ = p.pc.conn.Close()
+		p.pc = nil
+		if err := p.dial(ctx); err != nil {
+			return nil, err
+		}
+		resp, err = p.roundTrip(ctx, reqMsg)
+	}
+	if err != nil && p.pc != nil {
+		_ = p.pc.conn.Close()
+		p.pc = nil
+	}
+	return resp, err
+}
+
+// setDevice records the device to bind future dials to. A change closes the
+// pooled connection so the next call dials on the new device.
+func (p *pool) setDevice(device string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.device == device {
+		return
+	}
+	p.device = device
+	if p.pc != nil {
+		_ = p.pc.conn.Close()
+		p.pc = nil
+	}
+}
+
+// dial opens a connection and performs the h2c preface exchange.
+func (p *pool) dial(ctx context.Context) error {
+	d := net.Dialer{Control: bindToDevice(p.device)}
+	conn, err := d.DialContext(ctx, "tcp", p.addr)
+	if err != nil {
+		p.dialFailures.Add(1)
+		return fmt.Errorf("dial dish: %w", err)
+	}
+	p.dials.Add(1)
+	var buf []byte
+	buf = append(buf, h2Preface...)
+	buf = appendFrame(buf, frameSettings, 0, 0, nil)
+	if _, err := conn.Write(buf); err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("write preface: %w", err)
+	}
+	p.pc = &pooledConn{
+		conn:       conn,
+		nextStream: 1,
+		dialedAt:   time.Now(),
+		lastUsedAt: time.Now(),
+	}
+	return nil
+}
+
+// roundTrip runs one stream on the pooled connection. The caller holds
+// p.mu, so only one stream is ever active.
+func (p *pool) roundTrip(ctx context.Context, reqMsg []byte) ([]byte, error) {
+	pc := p.pc
+	if dl, ok := ctx.Deadline(); ok {
+		_ = pc.conn.SetDeadline(dl)
+	} else {
+		_ = pc.conn.SetDeadline(time.Now().Add(10 * time.Second))
+	}
+	stream := pc.nextStream
+	pc.nextStream += 2
+
+	var buf []byte
+	buf = appendFrame(buf, frameHeaders, flagEndHeaders, stream, hpackRequest(p.addr))
+	body := make([]byte, 5+len(reqMsg))
+	binary.BigEndian.PutUint32(body[1:5], uint32(len(reqMsg)))
+	copy(body[5:], reqMsg)
+	buf = appendFrame(buf, frameData, flagEndStream, stream, body)
+	if _, err := pc.conn.Write(buf); err != nil {
+		return nil, fmt.Errorf("write request: %w", err)
+	}
+
+	var data []byte
+	hdr := make([]byte, 9)
+	for {
+		if _, err := io.ReadFull(pc.conn, hdr); err != nil {
+			return nil, fmt.Errorf("read frame header: %w", err)
+		}
+		length := int(hdr[0])<<16 | int(hdr[1])<<8 | int(hdr[2])
+		ftype, flags := hdr[3], hdr[4]
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(pc.conn, payload); err != nil {
+			return nil, fmt.Errorf("read frame payload: %w", err)
+		}
+		switch ftype {
+		case frameSettings:
+			if flags&flagAck == 0 {
+				if _, err := pc.conn.Write(appendFrame(nil, frameSettings, flagAck, 0, nil)); err != nil {
+					return nil, err
+				}
+			}
+		case framePing:
+			if flags&flagAck == 0 {
+				if _, err := pc.conn.Write(appendFrame(nil, framePing, flagAck, 0, payload)); err != nil {
+					return nil, err
+				}
+			}
+		case frameData:
+			data = append(data, payload...)
+			if flags&flagEndStream != 0 {
+				pc.lastUsedAt = time.Now()
+				return stripGRPCPrefix(data)
+			}
+		case frameHeaders:
+			// Trailers-only response carries END_STREAM on HEADERS.
+			if flags&flagEndStream != 0 {
+				pc.lastUsedAt = time.Now()
+				if len(data) > 0 {
+					return stripGRPCPrefix(data)
+				}
+				return nil, fmt.Errorf("dish returned no data (trailers only)")
+			}
+		case frameGoAway:
+			return nil, fmt.Errorf("dish sent GOAWAY")
+		case frameWindow:
+			// ignore; our single response fits the default window
+		}
+	}
+}
+
+// PoolStats is a point-in-time view of connection reuse.
+type PoolStats struct {
+	Dials        uint64 `json:"dials"`
+	DialFailures uint64 `json:"dial_failures"`
+	Reuses       uint64 `json:"reuses"`
+}
+
+func (p *pool) stats() PoolStats {
+	return PoolStats{
+		Dials:        p.dials.Load(),
+		DialFailures: p.dialFailures.Load(),
+		Reuses:       p.reuses.Load(),
+	}
+}
+
+// bindToDevice returns a dialer Control that binds the socket to device via
+// SO_BINDTODEVICE; nil (no binding) when device is "".
+func bindToDevice(device string) func(network, address string, c syscall.RawConn) error {
+	if device == "" {
+		return nil
+	}
+	return func(network, address string, c syscall.RawConn) error {
+		var serr error
+		err := c.Control(func(fd uintptr) {
+			serr = syscall.SetsockoptString(int(fd), syscall.SOL_SOCKET, syscall.SO_BINDTODEVICE, device)
+		})
+		if err != nil {
+			return err
+		}
+		return serr
+	}
+}
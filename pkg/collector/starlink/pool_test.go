@@ -0,0 +1,116 @@
+package starlink
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+)
+
+// startFakeDish speaks just enough h2c to answer pooled unary calls: it
+// consumes the preface, and for every DATA frame with END_STREAM replies
+// with a gRPC-prefixed copy of msg on the same stream.
+func startFakeDish(t *testing.T, msg []byte) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveDishConn(conn, msg)
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func serveDishConn(conn net.Conn, msg []byte) {
+	defer conn.Close()
+	preface := make([]byte, len(h2Preface))
+	if _, err := io.ReadFull(conn, preface); err != nil {
+		return
+	}
+	hdr := make([]byte, 9)
+	for {
+		if _, err := io.ReadFull(conn, hdr); err != nil {
+			return
+		}
+		length := int(hdr[0])<<16 | int(hdr[1])<<8 | int(hdr[2])
+		ftype, flags := hdr[3], hdr[4]
+		stream := binary.BigEndian.Uint32(hdr[5:9]) & 0x7fffffff
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(conn, payload); err != nil {
+			return
+		}
+		switch ftype {
+		case framePing:
+			if flags&flagAck == 0 {
+				if _, err := conn.Write(appendFrame(nil, framePing, flagAck, 0, payload)); err != nil {
+					return
+				}
+			}
+		case frameData:
+			if flags&flagEndStream == 0 {
+				continue
+			}
+			body := make([]byte, 5+len(msg))
+			binary.BigEndian.PutUint32(body[1:5], uint32(len(msg)))
+			copy(body[5:], msg)
+			if _, err := conn.Write(appendFrame(nil, frameData, flagEndStream, stream, body)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func TestPoolReusesConnection(t *testing.T) {
+	msg := []byte{0x08, 0x01} // any protobuf bytes
+	addr := startFakeDish(t, msg)
+	p := &pool{addr: addr}
+
+	for i := 0; i < 3; i++ {
+		got, err := p.call(context.Background(), getStatusRequest())
+		if err != nil {
+			t.Fatalf("call %d: %v", i, err)
+		}
+		if len(got) != len(msg) || got[0] != msg[0] {
+			t.Fatalf("call %d returned %x, want %x", i, got, msg)
+		}
+	}
+	st := p.stats()
+	if st.Dials != 1 {
+		t.Errorf("dials = %d, want 1 (pooled)", st.Dials)
+	}
+	if st.Reuses != 2 {
+		t.Errorf("reuses = %d, want 2", st.Reuses)
+	}
+}
+
+func TestPoolRedialsAfterDrop(t *testing.T) {
+	msg := []byte{0x08, 0x02}
+	addr := startFakeDish(t, msg)
+	p := &pool{addr: addr}
+
+	if _, err := p.call(context.Background(), getStatusRequest()); err != nil {
+		t.Fatalf("first call: %v", err)
+	}
+	// Simulate the dish dropping the idle connection.
+	_ = p.pc.conn.Close()
+
+	if _, err := p.call(context.Background(), getStatusRequest()); err != nil {
+		t.Fatalf("call after drop: %v", err)
+	}
+	st := p.stats()
+	if st.Dials != 2 {
+		t.Errorf("dials = %d, want 2 (one re-dial)", st.Dials)
+	}
+	if st.DialFailures != 0 {
+		t.Errorf("dial failures = %d, want 0", st.DialFailures)
+	}
+}
@@ -0,0 +1,30 @@
+package starlink
+
+// Dish reboot. A wedged dish (stuck firmware update, thermal shutdown that
+// never recovered) sometimes only comes back after a power cycle, and on a
+// remote installation nobody is there to pull the plug; the API reboot is
+// the next best thing.
+
+import "context"
+
+// Request field number for the reboot command.
+const fReqReboot = 1001 // Request.reboot
+
+// rebootRequest serializes Request{reboot{}}: field 1001, wire type 2,
+// with an empty RebootRequest body.
+func rebootRequest() []byte {
+	// tag = 1001<<3 | 2 = 8010 -> varint ca 3e
+	return []byte{0xca, 0x3e, 0x00}
+}
+
+// Reboot power-cycles the dish through its API. The link is gone for the
+// several minutes the dish takes to boot and reacquire satellites, so
+// callers should fail over first; the status cache is dropped so the next
+// query reflects the restart rather than the pre-reboot state.
+func (c *Collector) Reboot(ctx context.Context) error {
+	if _, err := c.pool.call(ctx, rebootRequest()); err != nil {
+		return err
+	}
+	c.cache.invalidate()
+	return nil
+}
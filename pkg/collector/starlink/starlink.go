@@ -0,0 +1,268 @@
+package starlink
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/metrics"
+)
+
+// Field numbers inside the get_status response (DishGetStatusResponse),
+// stable across dish firmware since 2022.
+const (
+	fReqGetStatus      = 1004 // Request.get_status
+	fRespDishGetStatus = 1004 // Response.dish_get_status
+	fDeviceState       = 1    // device_state { uptime_s=1 }
+	fObstructionStats  = 7    // obstruction_stats
+	fPopPingLatency    = 9
+	fDownlinkTput      = 10
+	fUplinkTput        = 11
+	fSNR               = 12
+	fStowRequested     = 13 // stow_requested bool
+	fAlerts            = 8
+	fFractionObstructed = 1
+	fWedgeFraction      = 2 // wedge_abs_fraction_obstructed, 12 x 30 degrees
+	fValidS            = 5
+	fBoresightAzimuth  = 22 // boresight_azimuth_deg
+	fBoresightElevation = 23
+)
+
+// Collector polls the dish for status and converts it to a Metrics sample.
+type Collector struct {
+	Addr string
+	// AllowHeuristic enables last-resort float scanning when the known
+	// field numbers yield nothing (firmware drift). Samples built that
+	// way carry Status.Heuristic / Metrics.HeuristicFloats.
+	AllowHeuristic bool
+	// Router, when set, is the no-bypass fallback: with the dish API
+	// unreachable, a scrape of the Starlink router's LAN status endpoint
+	// yields a reduced sample instead of mock values. See lanclient.go.
+	Router *RouterClient
+
+	cache statusCache
+	pool  pool
+
+	// Outage-forecast state; see history.go.
+	histMu       sync.Mutex
+	histFetched  time.Time
+	histForecast Forecast
+	histOK       bool
+}
+
+// New returns a Collector for the given dish address ("" for the default).
+func New(addr string) *Collector {
+	if addr == "" {
+		addr = DefaultAddr
+	}
+	return &Collector{Addr: addr, pool: pool{addr: addr}}
+}
+
+// Class implements collector.Collector.
+func (c *Collector) Class() string { return "starlink" }
+
+// SetDevice binds future dish connections to a device (the member's VRF
+// device in VRF setups, so the kernel routes them by the VRF's table). A
+// change drops the pooled connection; "" removes the binding.
+func (c *Collector) SetDevice(device string) { c.pool.setDevice(device) }
+
+// getStatusRequest is the serialized Request{get_status{}} message:
+// field 1004, wire type 2, empty payload.
+func getStatusRequest() []byte {
+	// tag = 1004<<3 | 2 = 8034 -> varint e2 3e, then length 0
+	return []byte{0xe2, 0x3e, 0x00}
+}
+
+// Status is the subset of dish state the daemon uses.
+type Status struct {
+	UptimeS            int64   `json:"uptime_s"`
+	PopPingLatencyMS   float64 `json:"pop_ping_latency_ms"`
+	DownlinkMbps       float64 `json:"downlink_mbps"`
+	UplinkMbps         float64 `json:"uplink_mbps"`
+	SNR                float64 `json:"snr"`
+	ObstructionPct     float64 `json:"obstruction_pct"`
+	ObstructionValid   bool    `json:"obstruction_valid"`
+	SystemAlertsActive bool    `json:"system_alerts_active"`
+	// StowRequested is set while the dish is stowed or stowing; a stowed
+	// dish has no link, by design. MotorsStuck mirrors the motors_stuck
+	// alert bit for quick attitude checks. See stow.go.
+	StowRequested bool `json:"stow_requested,omitempty"`
+	MotorsStuck   bool `json:"motors_stuck,omitempty"`
+	// Alerts are the decoded DishAlerts names behind SystemAlertsActive;
+	// see alerts.go.
+	Alerts []string `json:"alerts,omitempty"`
+	// Alignment data for the heading assistant; wedges are 12 x 30 degree
+	// obstruction fractions clockwise from the dish boresight azimuth.
+	BoresightAzimuthDeg   float64   `json:"boresight_azimuth_deg,omitempty"`
+	BoresightElevationDeg float64   `json:"boresight_elevation_deg,omitempty"`
+	WedgeFractionObstructed []float64 `json:"wedge_fraction_obstructed,omitempty"`
+	// Heuristic is set when values came from scanFloats rather than
+	// known field numbers.
+	Heuristic bool `json:"heuristic,omitempty"`
+}
+
+// GetStarlinkInfo returns the dish status, served from the short-TTL cache
+// when a fresh reply exists so callers within the same tick share one API
+// round trip.
+func (c *Collector) GetStarlinkInfo(ctx context.Context) (*Status, error) {
+	st, _, err := c.cache.get(ctx, c.fetchStatus)
+	return st, err
+}
+
+// fetchStatus performs one get_status round trip and parses the reply.
+func (c *Collector) fetchStatus(ctx context.Context) (*Status, error) {
+	raw, err := c.pool.call(ctx, getStatusRequest())
+	if err != nil {
+		return nil, err
+	}
+	st, err := parseStatus(raw)
+	if err != nil {
+		return nil, err
+	}
+	if st.PopPingLatencyMS == 0 && st.SNR == 0 && c.AllowHeuristic {
+		if h, ok := heuristicStatus(raw); ok {
+			return h, nil
+		}
+	}
+	return st, nil
+}
+
+func parseStatus(raw []byte) (*Status, error) {
+	top, err := parseFields(raw)
+	if err != nil {
+		return nil, err
+	}
+	resp, ok := sub(top, fRespDishGetStatus)
+	if !ok {
+		resp = top // some firmwares omit the wrapper
+	}
+	st := &Status{}
+	if dev, ok := sub(resp, fDeviceState); ok {
+		if v, ok := varintAt(dev, 1); ok {
+			st.UptimeS = int64(v)
+		}
+	}
+	if v, ok := floatAt(resp, fPopPingLatency); ok {
+		st.PopPingLatencyMS = v
+	}
+	if v, ok := floatAt(resp, fDownlinkTput); ok {
+		st.DownlinkMbps = v / 1e6
+	}
+	if v, ok := floatAt(resp, fUplinkTput); ok {
+		st.UplinkMbps = v / 1e6
+	}
+	if v, ok := floatAt(resp, fSNR); ok {
+		st.SNR = v
+	}
+	if obs, ok := sub(resp, fObstructionStats); ok {
+		if v, ok := floatAt(obs, fFractionObstructed); ok {
+			st.ObstructionPct = v * 100
+		}
+		if v, ok := floatAt(obs, fValidS); ok {
+			st.ObstructionValid = v > 0
+		}
+		st.WedgeFractionObstructed = packedFloats(obs, fWedgeFraction)
+	}
+	if v, ok := floatAt(resp, fBoresightAzimuth); ok {
+		st.BoresightAzimuthDeg = v
+	}
+	if v, ok := floatAt(resp, fBoresightElevation); ok {
+		st.BoresightElevationDeg = v
+	}
+	if alerts, ok := sub(resp, fAlerts); ok {
+		st.Alerts = decodeAlerts(alerts)
+		st.SystemAlertsActive = len(st.Alerts) > 0
+		for _, a := range st.Alerts {
+			if a == "motors_stuck" {
+				st.MotorsStuck = true
+			}
+		}
+	}
+	if v, ok := varintAt(resp, fStowRequested); ok {
+		st.StowRequested = v != 0
+	}
+	return st, nil
+}
+
+// heuristicStatus builds a reduced Status from scanned floats: the only
+// value recovered with any confidence is a latency-like reading in the
+// 10-2000ms range. Anything else stays zero.
+func heuristicStatus(raw []byte) (*Status, bool) {
+	for _, v := range scanFloats(raw) {
+		if v >= 10 && v <= 2000 {
+			return &Status{PopPingLatencyMS: v, Heuristic: true}, true
+		}
+	}
+	return nil, false
+}
+
+// CheckHardwareHealth does a quick status call and reports whether the dish
+// is reachable and alert-free.
+func (c *Collector) CheckHardwareHealth(ctx context.Context) (bool, error) {
+	st, err := c.GetStarlinkInfo(ctx)
+	if err != nil {
+		return false, err
+	}
+	return !st.SystemAlertsActive, nil
+}
+
+// Collect implements collector.Collector. On dish API failure it tries the
+// Starlink router's LAN status endpoint when one is configured (no-bypass
+// setups), and failing that falls back to plausible mock values so the
+// scoring pipeline keeps moving; the failure is logged but the mock sample
+// is otherwise indistinguishable.
+func (c *Collector) Collect(ctx context.Context, iface string) (*metrics.Metrics, error) {
+	start := time.Now()
+	source := metrics.SourceGRPC
+	st, cached, err := c.cache.get(ctx, c.fetchStatus)
+	if err != nil {
+		if c.Router != nil {
+			if rs, rerr := c.Router.Status(ctx); rerr == nil {
+				log.Printf("starlink: dish API unavailable (%v), using router scrape", err)
+				return routerMetrics(rs, start), nil
+			}
+		}
+		log.Printf("starlink: dish API unavailable (%v), using mock sample", err)
+		st = mockStatus()
+		source = metrics.SourceMock
+	} else if cached {
+		source = metrics.SourceCache
+	}
+	m := &metrics.Metrics{
+		Timestamp:          time.Now(),
+		DataSource:         source,
+		LatencyMS:          metrics.Milliseconds(st.PopPingLatencyMS).Ptr(),
+		PopPingLatencyMS:   metrics.Milliseconds(st.PopPingLatencyMS).Ptr(),
+		SNR:                metrics.DB(st.SNR).Ptr(),
+		DownlinkMbps:       metrics.Mbps(st.DownlinkMbps).Ptr(),
+		UplinkMbps:         metrics.Mbps(st.UplinkMbps).Ptr(),
+		ObstructionPct:     metrics.Percent(st.ObstructionPct).Ptr(),
+		ObstructionValid:   st.ObstructionValid,
+		SystemAlertsActive: st.SystemAlertsActive,
+		ActiveAlerts:       st.Alerts,
+		Stowed:             st.StowRequested,
+		UptimeS:            metrics.Int64(st.UptimeS),
+		HeuristicFloats:    st.Heuristic,
+	}
+	if source != metrics.SourceMock {
+		if f, ok := c.Forecast(ctx); ok {
+			m.OutageProbPct = metrics.Percent(f.ProbabilityPct).Ptr()
+		}
+	}
+	m.CollectDurationMS = time.Since(start).Milliseconds()
+	return m, nil
+}
+
+// mockStatus returns typical clear-sky values.
+func mockStatus() *Status {
+	return &Status{
+		UptimeS:          3600,
+		PopPingLatencyMS: 45,
+		DownlinkMbps:     120,
+		UplinkMbps:       15,
+		SNR:              9,
+		ObstructionPct:   0.5,
+		ObstructionValid: true,
+	}
+}
@@ -0,0 +1,35 @@
+package starlink
+
+// Dish stow control. Motorhome users park the dish flat before driving and
+// deploy it again on site; while stowed the dish has no link by design, so
+// the engine treats a stowed member as administratively out of rotation
+// rather than failed (see the engine's stow handling).
+
+import "context"
+
+// Request field numbers for the stow command.
+const (
+	fReqDishStow = 1005 // Request.dish_stow
+	fStowUnstow  = 1    // DishStowRequest.unstow bool
+)
+
+// dishStowRequest serializes Request{dish_stow{unstow}}: field 1005, wire
+// type 2; unstow is bool field 1, omitted when false as protobuf does.
+func dishStowRequest(unstow bool) []byte {
+	// tag = 1005<<3 | 2 = 8042 -> varint ea 3e
+	if unstow {
+		return []byte{0xea, 0x3e, 0x02, fStowUnstow<<3 | wireVarint, 0x01}
+	}
+	return []byte{0xea, 0x3e, 0x00}
+}
+
+// Stow parks the dish flat (unstow false) or deploys it again (unstow
+// true). The reply carries no data we use; the status cache is dropped so
+// the next query reflects the new state.
+func (c *Collector) Stow(ctx context.Context, unstow bool) error {
+	if _, err := c.pool.call(ctx, dishStowRequest(unstow)); err != nil {
+		return err
+	}
+	c.cache.invalidate()
+	return nil
+}
@@ -0,0 +1,45 @@
+package starlink
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDishStowRequestBytes(t *testing.T) {
+	// Stow: Request{dish_stow{}} with unstow omitted (proto default false).
+	if got := dishStowRequest(false); !bytes.Equal(got, []byte{0xea, 0x3e, 0x00}) {
+		t.Errorf("stow request = %x", got)
+	}
+	// Unstow: DishStowRequest{unstow: true} = field 1 varint 1.
+	if got := dishStowRequest(true); !bytes.Equal(got, []byte{0xea, 0x3e, 0x02, 0x08, 0x01}) {
+		t.Errorf("unstow request = %x", got)
+	}
+}
+
+func TestParseStatusStowed(t *testing.T) {
+	inner := []byte{
+		// stow_requested(13) varint 1: tag 13<<3|0 = 0x68
+		0x68, 0x01,
+		// alerts(8){ motors_stuck(1)=1 }: tag 0x42 len 2, inner tag 0x08
+		0x42, 0x02, 0x08, 0x01,
+	}
+	raw := append([]byte{0xe2, 0x3e, byte(len(inner))}, inner...)
+	st, err := parseStatus(raw)
+	if err != nil {
+		t.Fatalf("parseStatus: %v", err)
+	}
+	if !st.StowRequested {
+		t.Error("StowRequested not parsed")
+	}
+	if !st.MotorsStuck {
+		t.Error("MotorsStuck not derived from the alert bit")
+	}
+	// Absent field means deployed.
+	st, err = parseStatus(buildStatusResponse())
+	if err != nil {
+		t.Fatalf("parseStatus: %v", err)
+	}
+	if st.StowRequested || st.MotorsStuck {
+		t.Errorf("stow state invented from a reply without it: %+v", st)
+	}
+}
@@ -0,0 +1,138 @@
+// Package controller applies routing decisions by adjusting mwan3 member
+// metrics through UCI and reloading mwan3, mirroring what the shell
+// failover scripts have always done.
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/uci"
+)
+
+// Metric values written to mwan3: lower wins.
+const (
+	metricActive  = 1
+	metricStandby = 20
+)
+
+// Controller serializes routing changes.
+type Controller struct {
+	// Noop makes Switch log instead of touching mwan3; used by the
+	// development simulator where there is no router to actuate.
+	Noop bool
+
+	mu      sync.Mutex
+	members func() []string
+}
+
+// New returns a Controller. members supplies the live mwan3 member names
+// on every switch — main wires it to the engine's roster, so reloads and
+// runtime additions (wifi onboarding) are visible here without any
+// re-registration. A nil source leaves the controller unable to switch,
+// which only the Noop configurations used in tests do.
+func New(members func() []string) *Controller {
+	return &Controller{members: members}
+}
+
+// SetMemberSource installs the live member-name source. It exists because
+// the controller is constructed before the engine that owns the roster;
+// main wires it immediately after engine.New.
+func (c *Controller) SetMemberSource(members func() []string) {
+	c.mu.Lock()
+	c.members = members
+	c.mu.Unlock()
+}
+
+// Switch promotes name to the active member and demotes all others. The
+// whole operation is transactional: the intent is persisted first, and a
+// failure rolls mwan3 back to the previously active member (see txn.go).
+func (c *Controller) Switch(name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.Noop {
+		log.Printf("controller: simulate: would switch active member to %s", name)
+		return nil
+	}
+	var members []string
+	if c.members != nil {
+		members = c.members()
+	}
+	found := false
+	for _, m := range members {
+		if m == name {
+			found = true
+		}
+	}
+	if !found {
+		return fmt.Errorf("unknown member %q", name)
+	}
+	t := &txn{Target: name, Previous: activeMember(members), Members: members, Started: time.Now()}
+	writeIntent(t)
+	if err := apply(members, name); err != nil {
+		// Roll back so mwan3 is never left half-modified. If the rollback
+		// also fails, the intent stays on disk — retargeted at the
+		// previous member — for startup recovery to finish.
+		if t.Previous != "" {
+			if rbErr := apply(members, t.Previous); rbErr == nil {
+				clearIntent()
+				return err
+			}
+			t.Target = t.Previous
+			writeIntent(t)
+		}
+		return err
+	}
+	clearIntent()
+	return nil
+}
+
+// apply sets every member's metric absolutely, queues the commit and
+// restarts mwan3. Being absolute makes replay idempotent, which is what
+// lets recovery and rollback reuse it.
+func apply(members []string, name string) error {
+	for _, m := range members {
+		metric := metricStandby
+		if m == name {
+			metric = metricActive
+		}
+		if err := uci.Set(fmt.Sprintf("mwan3.%s.metric", m), fmt.Sprintf("%d", metric)); err != nil {
+			return fmt.Errorf("set metric for %s: %w", m, err)
+		}
+	}
+	// The restart reads the staged deltas; the flash commit itself is
+	// write-behind (see uci.CommitQueue).
+	uci.Commits.Request("mwan3")
+	return mwan3Restart()
+}
+
+// mwan3Restart is split out for stubbing in tests.
+var mwan3Restart = func() error {
+	if out, err := exec.Command("mwan3", "restart").CombinedOutput(); err != nil {
+		log.Printf("controller: mwan3 restart: %v: %s", err, out)
+		return fmt.Errorf("mwan3 restart: %w", err)
+	}
+	return nil
+}
+
+// Status returns the mwan3 interface status JSON via ubus.
+func (c *Controller) Status() (map[string]any, error) {
+	var out map[string]any
+	if err := ubusStatus(&out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ubusStatus is split out for stubbing in tests.
+var ubusStatus = func(out *map[string]any) error {
+	raw, err := exec.Command("ubus", "call", "mwan3", "status").Output()
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, out)
+}
@@ -0,0 +1,329 @@
+package controller
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"log"
+	"net"
+	"os/exec"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/ubus"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/uci"
+)
+
+// LAN clients keep resolving through whatever upstreams dnsmasq was
+// started with, so after a failover DNS traffic still heads for the old
+// WAN's resolvers — slow at best, black-holed at worst. This integration
+// keeps per-resolver quality stats (query latency, failure rate), and on
+// each switch rewrites dnsmasq's upstream server list to the new active
+// member's resolvers ordered best-first: the Starlink-provided servers
+// while Starlink carries traffic, the carrier's while cellular does.
+// Management is on by default once the daemon runs but a deployment that
+// hand-tunes /etc/config/dhcp can opt out with starfail.dns.enabled=0.
+
+// dnsmasqServerKey is the upstream list dnsmasq reads; OpenWrt keeps a
+// single anonymous dnsmasq section in the dhcp package.
+const dnsmasqServerKey = "dhcp.@dnsmasq[0].server"
+
+// dnsmasqReload applies a rewritten server list; a variable for tests.
+var dnsmasqReload = func() error {
+	out, err := exec.Command("/etc/init.d/dnsmasq", "reload").CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("dnsmasq reload: %v: %s", err, out)
+	}
+	return nil
+}
+
+// memberDNSServers resolves the resolvers a member's WAN learned from
+// DHCP/PPP through netifd; a variable for tests.
+var memberDNSServers = func(member string) ([]string, error) {
+	iface := member
+	if v, ok := uci.Get("starfail." + member + ".iface"); ok && v != "" {
+		iface = v
+	}
+	var st struct {
+		DNS []string `json:"dns-server"`
+	}
+	if err := ubus.Call("network.interface."+iface, "status", nil, &st); err != nil {
+		return nil, err
+	}
+	return st.DNS, nil
+}
+
+// dnsQuery sends one A-record query for probeName to server:53 and
+// returns the round-trip time; a variable for tests. Any well-formed
+// response with a matching ID counts — we measure the resolver, not the
+// answer.
+var dnsQuery = func(server string) (time.Duration, error) {
+	conn, err := net.DialTimeout("udp", net.JoinHostPort(server, "53"), 2*time.Second)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+	id := uint16(time.Now().UnixNano())
+	msg := encodeDNSQuery(id, probeName)
+	start := time.Now()
+	conn.SetDeadline(start.Add(2 * time.Second))
+	if _, err := conn.Write(msg); err != nil {
+		return 0, err
+	}
+	buf := make([]byte, 512)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return 0, err
+	}
+	if n < 2 || binary.BigEndian.Uint16(buf) != id {
+		return 0, fmt.Errorf("malformed response from %s", server)
+	}
+	return time.Since(start), nil
+}
+
+// probeName is resolved when probing; a stable, never-disappearing name
+// so failures indict the resolver rather than the zone.
+const probeName = "example.com"
+
+// dnsStat is one resolver's running quality, EWMA-smoothed so a single
+// slow answer does not reshuffle the LAN's resolver order.
+type dnsStat struct {
+	latencyMS float64
+	failRate  float64
+	probes    int
+}
+
+// dnsStatAlpha smooths the per-resolver stats.
+const dnsStatAlpha = 0.3
+
+// DNSManager reorders dnsmasq upstreams to follow the active member.
+type DNSManager struct {
+	// Overrides maps member name to an explicit resolver list
+	// (starfail.<member>.dns_servers), used instead of the netifd-learned
+	// servers when set.
+	Overrides map[string][]string
+
+	// Fallbacks are appended after the member's resolvers so the LAN
+	// still resolves if the carrier's servers all die.
+	Fallbacks []string
+
+	// IntervalS is how often Run re-probes the current upstreams.
+	IntervalS int
+
+	mu     sync.Mutex
+	stats  map[string]*dnsStat
+	active string
+}
+
+// NewDNSFromUCI returns the dnsmasq integration, or nil when the
+// deployment opted out with starfail.dns.enabled=0.
+func NewDNSFromUCI() *DNSManager {
+	if !uci.GetBool("starfail.dns.enabled", true) {
+		return nil
+	}
+	m := &DNSManager{
+		Overrides: make(map[string][]string),
+		Fallbacks: uci.GetList("starfail.dns.fallbacks"),
+		IntervalS: uci.GetInt("starfail.dns.interval_s", 300),
+		stats:     make(map[string]*dnsStat),
+	}
+	if len(m.Fallbacks) == 0 {
+		m.Fallbacks = []string{"1.1.1.1", "8.8.8.8"}
+	}
+	for _, name := range uci.Sections("starfail", "member") {
+		if list := uci.GetList("starfail." + name + ".dns_servers"); len(list) > 0 {
+			m.Overrides[name] = list
+		}
+	}
+	return m
+}
+
+// Apply rewrites the dnsmasq upstream list for the newly active member
+// and returns the ordered servers, or nil when nothing changed or the
+// member's resolvers could not be determined. It runs as an OnSwitch
+// hook; failures are logged, never fatal.
+func (m *DNSManager) Apply(member string) []string {
+	servers := m.Overrides[member]
+	if len(servers) == 0 {
+		var err error
+		if servers, err = memberDNSServers(member); err != nil {
+			log.Printf("controller: dns: resolve %s servers: %v", member, err)
+		}
+	}
+	m.mu.Lock()
+	m.active = member
+	m.mu.Unlock()
+	ordered := m.order(servers)
+	if len(ordered) == 0 {
+		return nil
+	}
+	if !m.install(ordered) {
+		return nil
+	}
+	return ordered
+}
+
+// Run periodically re-probes the active member's upstreams so a resolver
+// that degrades between switches is demoted without waiting for one.
+func (m *DNSManager) Run(ctx context.Context) {
+	interval := time.Duration(m.IntervalS) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Minute
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		m.mu.Lock()
+		member := m.active
+		m.mu.Unlock()
+		if member == "" {
+			continue
+		}
+		if ordered := m.Apply(member); len(ordered) > 0 {
+			log.Printf("controller: dns: upstreams reordered: %v", ordered)
+		}
+	}
+}
+
+// order probes the candidate servers plus fallbacks, updates their
+// stats and returns them best-first: member resolvers ahead of
+// fallbacks, then by failure rate, then by latency.
+func (m *DNSManager) order(memberServers []string) []string {
+	type cand struct {
+		server   string
+		fallback bool
+	}
+	var cands []cand
+	seen := make(map[string]bool)
+	for _, s := range memberServers {
+		if s != "" && !seen[s] {
+			seen[s] = true
+			cands = append(cands, cand{server: s})
+		}
+	}
+	for _, s := range m.Fallbacks {
+		if s != "" && !seen[s] {
+			seen[s] = true
+			cands = append(cands, cand{server: s, fallback: true})
+		}
+	}
+	for _, c := range cands {
+		m.probe(c.server)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	sort.SliceStable(cands, func(i, j int) bool {
+		a, b := cands[i], cands[j]
+		if a.fallback != b.fallback {
+			return !a.fallback
+		}
+		sa, sb := m.stats[a.server], m.stats[b.server]
+		if sa.failRate != sb.failRate {
+			return sa.failRate < sb.failRate
+		}
+		return sa.latencyMS < sb.latencyMS
+	})
+	out := make([]string, len(cands))
+	for i, c := range cands {
+		out[i] = c.server
+	}
+	return out
+}
+
+// probe runs one query against server and folds the outcome into its
+// stats.
+func (m *DNSManager) probe(server string) {
+	rtt, err := dnsQuery(server)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	st := m.stats[server]
+	if st == nil {
+		st = &dnsStat{}
+		m.stats[server] = st
+	}
+	fail, lat := 0.0, st.latencyMS
+	if err != nil {
+		fail = 1
+	} else {
+		lat = float64(rtt) / float64(time.Millisecond)
+	}
+	if st.probes == 0 {
+		st.failRate, st.latencyMS = fail, lat
+	} else {
+		st.failRate += dnsStatAlpha * (fail - st.failRate)
+		st.latencyMS += dnsStatAlpha * (lat - st.latencyMS)
+	}
+	st.probes++
+}
+
+// install writes the ordered list to dnsmasq's UCI section and reloads
+// it, skipping the reload when the list already matches. Returns whether
+// anything changed.
+func (m *DNSManager) install(servers []string) bool {
+	cur := uci.GetList(dnsmasqServerKey)
+	if len(cur) == len(servers) {
+		same := true
+		for i := range cur {
+			if cur[i] != servers[i] {
+				same = false
+				break
+			}
+		}
+		if same {
+			return false
+		}
+	}
+	if _, err := uci.Exec("-q", "delete", dnsmasqServerKey); err != nil {
+		log.Printf("controller: dns: clear server list: %v", err)
+	}
+	for _, s := range servers {
+		if _, err := uci.Exec("add_list", dnsmasqServerKey+"="+s); err != nil {
+			log.Printf("controller: dns: add server %s: %v", s, err)
+			return false
+		}
+	}
+	uci.Commits.Request("dhcp")
+	if err := dnsmasqReload(); err != nil {
+		log.Printf("controller: dns: %v", err)
+		return false
+	}
+	return true
+}
+
+// encodeDNSQuery builds a minimal A-record query: header with RD set,
+// one question, no EDNS.
+func encodeDNSQuery(id uint16, name string) []byte {
+	msg := make([]byte, 12, 12+len(name)+6)
+	binary.BigEndian.PutUint16(msg, id)
+	msg[2] = 0x01 // RD
+	msg[5] = 0x01 // QDCOUNT=1
+	start := 0
+	for i := 0; i <= len(name); i++ {
+		if i == len(name) || name[i] == '.' {
+			msg = append(msg, byte(i-start))
+			msg = append(msg, name[start:i]...)
+			start = i + 1
+		}
+	}
+	msg = append(msg, 0x00)       // root label
+	msg = append(msg, 0x00, 0x01) // QTYPE A
+	msg = append(msg, 0x00, 0x01) // QCLASS IN
+	return msg
+}
+
+// statsSnapshot returns a copy of the resolver stats for diagnostics.
+func (m *DNSManager) statsSnapshot() map[string]dnsStat {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make(map[string]dnsStat, len(m.stats))
+	for s, st := range m.stats {
+		out[s] = *st
+	}
+	return out
+}
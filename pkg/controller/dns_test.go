@@ -0,0 +1,166 @@
+package controller
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/uci"
+)
+
+// installDNSStubs replaces the dnsmasq integration's externals: latencies
+// maps server -> query RTT in ms (negative means the query fails) and
+// netifd hands every member the given servers.
+func installDNSStubs(t *testing.T, servers []string, latencies map[string]float64) (uciOps *[]string, reloads *int) {
+	t.Helper()
+	var ops []string
+	var rel int
+	origQueue := uci.Commits
+	uci.Commits = uci.NewCommitQueue(filepath.Join(t.TempDir(), "journal.json"), time.Hour)
+	origExec, origReload, origServers, origQuery := uci.Exec, dnsmasqReload, memberDNSServers, dnsQuery
+	uci.Exec = func(args ...string) (string, error) {
+		ops = append(ops, strings.Join(args, " "))
+		// No server list exists yet: reads and deletes miss, writes work.
+		if args[0] == "-q" || args[0] == "delete" {
+			return "", fmt.Errorf("not found")
+		}
+		return "", nil
+	}
+	dnsmasqReload = func() error { rel++; return nil }
+	memberDNSServers = func(string) ([]string, error) { return servers, nil }
+	dnsQuery = func(server string) (time.Duration, error) {
+		ms, ok := latencies[server]
+		if !ok || ms < 0 {
+			return 0, fmt.Errorf("timeout")
+		}
+		return time.Duration(ms * float64(time.Millisecond)), nil
+	}
+	t.Cleanup(func() {
+		uci.Commits = origQueue
+		uci.Exec, dnsmasqReload, memberDNSServers, dnsQuery = origExec, origReload, origServers, origQuery
+	})
+	return &ops, &rel
+}
+
+func newTestDNSManager() *DNSManager {
+	return &DNSManager{
+		Overrides: make(map[string][]string),
+		Fallbacks: []string{"1.1.1.1"},
+		stats:     make(map[string]*dnsStat),
+	}
+}
+
+func TestDNSApplyOrdersByQuality(t *testing.T) {
+	ops, reloads := installDNSStubs(t, []string{"10.0.0.1", "10.0.0.2"}, map[string]float64{
+		"10.0.0.1": 80,
+		"10.0.0.2": 15,
+		"1.1.1.1":  5,
+	})
+	m := newTestDNSManager()
+	got := m.Apply("mob1s1a1")
+	// The faster carrier server wins, but fallbacks stay behind the
+	// member's own resolvers even when faster.
+	want := []string{"10.0.0.2", "10.0.0.1", "1.1.1.1"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Fatalf("Apply order = %v, want %v", got, want)
+	}
+	uci.Commits.Flush()
+	joined := strings.Join(*ops, "\n")
+	for _, op := range []string{
+		"add_list dhcp.@dnsmasq[0].server=10.0.0.2",
+		"add_list dhcp.@dnsmasq[0].server=1.1.1.1",
+		"commit dhcp",
+	} {
+		if !strings.Contains(joined, op) {
+			t.Errorf("missing uci op %q in:\n%s", op, joined)
+		}
+	}
+	if *reloads != 1 {
+		t.Errorf("dnsmasq reloads = %d, want 1", *reloads)
+	}
+}
+
+func TestDNSFailingResolverDemoted(t *testing.T) {
+	installDNSStubs(t, []string{"10.0.0.1", "10.0.0.2"}, map[string]float64{
+		"10.0.0.1": -1, // dead
+		"10.0.0.2": 90,
+		"1.1.1.1":  5,
+	})
+	m := newTestDNSManager()
+	got := m.Apply("wan")
+	if len(got) == 0 || got[0] != "10.0.0.2" {
+		t.Errorf("Apply order = %v, want the answering resolver first", got)
+	}
+}
+
+func TestDNSOverrideBeatsNetifd(t *testing.T) {
+	installDNSStubs(t, []string{"10.0.0.1"}, map[string]float64{
+		"192.168.100.1": 20,
+		"1.1.1.1":       5,
+	})
+	m := newTestDNSManager()
+	m.Overrides["starlink"] = []string{"192.168.100.1"}
+	got := m.Apply("starlink")
+	if len(got) == 0 || got[0] != "192.168.100.1" {
+		t.Errorf("Apply order = %v, want the override resolver first", got)
+	}
+}
+
+func TestDNSInstallSkipsWhenCurrent(t *testing.T) {
+	_, reloads := installDNSStubs(t, []string{"10.0.0.1"}, map[string]float64{
+		"10.0.0.1": 10,
+		"1.1.1.1":  5,
+	})
+	// uci get returns the list the manager would write: nothing to do.
+	uci.Exec = func(args ...string) (string, error) {
+		if len(args) > 1 && args[1] == "get" {
+			return "10.0.0.1 1.1.1.1", nil
+		}
+		return "", nil
+	}
+	m := newTestDNSManager()
+	if got := m.Apply("wan"); got != nil {
+		t.Errorf("Apply = %v, want nil for an unchanged list", got)
+	}
+	if *reloads != 0 {
+		t.Errorf("dnsmasq reloaded %d times for a no-op", *reloads)
+	}
+}
+
+func TestDNSStatsSmoothing(t *testing.T) {
+	installDNSStubs(t, nil, map[string]float64{"10.0.0.1": 10})
+	m := newTestDNSManager()
+	m.probe("10.0.0.1")
+	dnsQuery = func(string) (time.Duration, error) { return 0, fmt.Errorf("timeout") }
+	m.probe("10.0.0.1")
+	st := m.statsSnapshot()["10.0.0.1"]
+	if st.probes != 2 {
+		t.Fatalf("probes = %d, want 2", st.probes)
+	}
+	// One failure out of two EWMA-smoothed probes: rate moves toward 1
+	// by alpha, not all the way.
+	if st.failRate <= 0 || st.failRate >= 1 {
+		t.Errorf("failRate = %v, want smoothed between 0 and 1", st.failRate)
+	}
+}
+
+func TestEncodeDNSQuery(t *testing.T) {
+	msg := encodeDNSQuery(0xbeef, "example.com")
+	if len(msg) != 12+1+7+1+3+1+4 {
+		t.Fatalf("query length = %d", len(msg))
+	}
+	if msg[0] != 0xbe || msg[1] != 0xef {
+		t.Errorf("id bytes = %x %x", msg[0], msg[1])
+	}
+	if msg[12] != 7 || string(msg[13:20]) != "example" {
+		t.Errorf("first label wrong: %q", msg[12:20])
+	}
+	if msg[20] != 3 || string(msg[21:24]) != "com" {
+		t.Errorf("second label wrong: %q", msg[20:24])
+	}
+	if msg[24] != 0 {
+		t.Errorf("missing root label terminator")
+	}
+}
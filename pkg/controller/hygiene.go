@@ -0,0 +1,220 @@
+package controller
+
+// mwan3 track_ip hygiene. A large share of "failover is broken" reports
+// trace back to mwan3 tracking configs, not to this daemon: dead track_ip
+// targets, every target behind the same anycast provider (one outage fails
+// every member at once), or intervals aggressive enough to flap metered
+// links. The audit runs at startup when starfail.main.mwan3_hygiene is set,
+// exports a backup before touching anything, and reports every change.
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/uci"
+)
+
+// Track intervals outside this range are corrected: faster flaps members
+// and burns metered data, slower leaves outages undetected for minutes.
+const (
+	minTrackInterval = 5
+	maxTrackInterval = 60
+)
+
+// anycastProvider maps well-known resolver targets to their operator, the
+// diversity unit for track_ip: two targets of one provider share fate.
+var anycastProvider = map[string]string{
+	"1.1.1.1":         "cloudflare",
+	"1.0.0.1":         "cloudflare",
+	"8.8.8.8":         "google",
+	"8.8.4.4":         "google",
+	"9.9.9.9":         "quad9",
+	"149.112.112.112": "quad9",
+	"208.67.222.222":  "opendns",
+	"208.67.220.220":  "opendns",
+}
+
+// diversityPool is where replacement targets come from, ordered by
+// preference; pickDiverse walks it for a provider not already in use.
+var diversityPool = []string{"1.1.1.1", "8.8.8.8", "9.9.9.9", "208.67.222.222"}
+
+// TrackIPFix is the audit outcome for one mwan3 interface section.
+type TrackIPFix struct {
+	Iface    string   `json:"iface"`
+	Removed  []string `json:"removed,omitempty"`  // dead targets dropped
+	Added    []string `json:"added,omitempty"`    // targets added for diversity or floor
+	Interval int      `json:"interval,omitempty"` // corrected interval, 0 when untouched
+	Notes    []string `json:"notes,omitempty"`
+}
+
+// changed reports whether the audit wants to touch this section.
+func (f *TrackIPFix) changed() bool {
+	return len(f.Removed) > 0 || len(f.Added) > 0 || f.Interval != 0
+}
+
+// Mwan3Hygiene audits every mwan3 interface section's tracking config and,
+// when apply is set, stages and commits the fixes after exporting a backup
+// to backupDir. Sections without track_ip are left alone — some members
+// legitimately track nothing.
+func Mwan3Hygiene(apply bool, backupDir string) ([]TrackIPFix, error) {
+	var fixes []TrackIPFix
+	for _, name := range uci.Sections("mwan3", "interface") {
+		targets := uci.GetList("mwan3." + name + ".track_ip")
+		if len(targets) == 0 {
+			continue
+		}
+		fix := auditTrackIPs(name, targets)
+		if fix.changed() {
+			fixes = append(fixes, fix)
+		}
+	}
+	if !apply || len(fixes) == 0 {
+		return fixes, nil
+	}
+	if err := backupMwan3(backupDir); err != nil {
+		return fixes, fmt.Errorf("refusing to fix without backup: %w", err)
+	}
+	for _, fix := range fixes {
+		if err := applyTrackIPFix(fix); err != nil {
+			return fixes, err
+		}
+	}
+	uci.Commits.Request("mwan3")
+	return fixes, nil
+}
+
+// auditTrackIPs computes the fix for one section without touching UCI.
+func auditTrackIPs(iface string, targets []string) TrackIPFix {
+	fix := TrackIPFix{Iface: iface}
+
+	var alive []string
+	for _, t := range targets {
+		if targetAlive(t) {
+			alive = append(alive, t)
+		} else {
+			fix.Removed = append(fix.Removed, t)
+			fix.Notes = append(fix.Notes, fmt.Sprintf("%s does not answer pings from here", t))
+		}
+	}
+
+	// Keep at least two targets so one provider hiccup cannot fail the
+	// member; refill from the pool before worrying about diversity.
+	for len(alive)+len(fix.Added) < 2 {
+		next := pickDiverse(append(alive, fix.Added...))
+		if next == "" {
+			break
+		}
+		fix.Added = append(fix.Added, next)
+		fix.Notes = append(fix.Notes, fmt.Sprintf("added %s to keep two live targets", next))
+	}
+
+	// All targets behind one provider means its outage fails the member.
+	if len(fix.Added) == 0 && singleProvider(alive) {
+		if next := pickDiverse(alive); next != "" {
+			fix.Added = append(fix.Added, next)
+			fix.Notes = append(fix.Notes, fmt.Sprintf(
+				"all targets belong to %s; added %s for provider diversity",
+				anycastProvider[alive[0]], next))
+		}
+	}
+
+	if iv := uci.GetInt("mwan3."+iface+".interval", 0); iv != 0 {
+		switch {
+		case iv < minTrackInterval:
+			fix.Interval = minTrackInterval
+			fix.Notes = append(fix.Notes, fmt.Sprintf("interval %d too aggressive, raised to %d", iv, minTrackInterval))
+		case iv > maxTrackInterval:
+			fix.Interval = maxTrackInterval
+			fix.Notes = append(fix.Notes, fmt.Sprintf("interval %d too slow, lowered to %d", iv, maxTrackInterval))
+		}
+	}
+	return fix
+}
+
+// singleProvider reports whether every known target shares one provider;
+// unknown targets count as their own provider.
+func singleProvider(targets []string) bool {
+	if len(targets) < 2 {
+		return false
+	}
+	first := providerOf(targets[0])
+	for _, t := range targets[1:] {
+		if providerOf(t) != first {
+			return false
+		}
+	}
+	return true
+}
+
+func providerOf(target string) string {
+	if p, ok := anycastProvider[target]; ok {
+		return p
+	}
+	return target // unknown targets are their own fate-sharing unit
+}
+
+// pickDiverse returns the first pool target whose provider is not already
+// among current, "" when every provider is taken.
+func pickDiverse(current []string) string {
+	used := make(map[string]bool, len(current))
+	for _, t := range current {
+		used[providerOf(t)] = true
+	}
+	for _, t := range diversityPool {
+		if !used[providerOf(t)] {
+			return t
+		}
+	}
+	return ""
+}
+
+// applyTrackIPFix stages one section's changes; the caller commits.
+func applyTrackIPFix(fix TrackIPFix) error {
+	if len(fix.Removed) > 0 || len(fix.Added) > 0 {
+		keep := make(map[string]bool, len(fix.Removed))
+		for _, t := range fix.Removed {
+			keep[t] = true
+		}
+		var next []string
+		for _, t := range uci.GetList("mwan3." + fix.Iface + ".track_ip") {
+			if !keep[t] {
+				next = append(next, t)
+			}
+		}
+		next = append(next, fix.Added...)
+		if err := uci.Delete("mwan3." + fix.Iface + ".track_ip"); err != nil {
+			return err
+		}
+		for _, t := range next {
+			if _, err := uci.Exec("add_list", fmt.Sprintf("mwan3.%s.track_ip=%s", fix.Iface, t)); err != nil {
+				return err
+			}
+		}
+	}
+	if fix.Interval != 0 {
+		if err := uci.Set(fmt.Sprintf("mwan3.%s.interval", fix.Iface), fmt.Sprintf("%d", fix.Interval)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// backupMwan3 exports the pre-fix mwan3 config, mirroring the starfail
+// schema-migration backup.
+func backupMwan3(dir string) error {
+	out, err := uci.Exec("export", "mwan3")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, "mwan3.bak"), []byte(out+"\n"), 0o600)
+}
+
+// targetAlive is a var so tests can decide which targets answer.
+var targetAlive = func(target string) bool {
+	return exec.Command("ping", "-c", "1", "-W", "2", target).Run() == nil
+}
@@ -0,0 +1,181 @@
+package controller
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/uci"
+)
+
+// fakeMwan3 emulates the uci CLI over a map, like the pkg/uci migration
+// tests do, plus the show/add_list verbs the hygiene audit needs.
+type fakeMwan3 struct {
+	values  map[string]string
+	commits int
+}
+
+func installFakeMwan3(t *testing.T, initial map[string]string) *fakeMwan3 {
+	t.Helper()
+	f := &fakeMwan3{values: initial}
+	origQueue := uci.Commits
+	uci.Commits = uci.NewCommitQueue(filepath.Join(t.TempDir(), "journal.json"), time.Hour)
+	t.Cleanup(func() { uci.Commits = origQueue })
+	orig := uci.Exec
+	uci.Exec = func(args ...string) (string, error) {
+		if len(args) > 0 && args[0] == "-q" {
+			args = args[1:]
+		}
+		switch args[0] {
+		case "get":
+			if v, ok := f.values[args[1]]; ok {
+				return v, nil
+			}
+			return "", fmt.Errorf("uci: entry not found")
+		case "set":
+			kv := strings.SplitN(args[1], "=", 2)
+			f.values[kv[0]] = kv[1]
+			return "", nil
+		case "add_list":
+			kv := strings.SplitN(args[1], "=", 2)
+			if cur := f.values[kv[0]]; cur != "" {
+				f.values[kv[0]] = cur + " " + kv[1]
+			} else {
+				f.values[kv[0]] = kv[1]
+			}
+			return "", nil
+		case "delete":
+			delete(f.values, args[1])
+			return "", nil
+		case "commit":
+			f.commits++
+			return "", nil
+		case "show", "export":
+			keys := make([]string, 0, len(f.values))
+			for k := range f.values {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			var sb strings.Builder
+			for _, k := range keys {
+				fmt.Fprintf(&sb, "%s=%s\n", k, f.values[k])
+			}
+			return sb.String(), nil
+		}
+		return "", fmt.Errorf("fake uci: unhandled %v", args)
+	}
+	t.Cleanup(func() { uci.Exec = orig })
+	return f
+}
+
+// allAlive stubs targetAlive with an explicit dead set.
+func allAlive(t *testing.T, dead ...string) {
+	t.Helper()
+	orig := targetAlive
+	targetAlive = func(target string) bool {
+		for _, d := range dead {
+			if target == d {
+				return false
+			}
+		}
+		return true
+	}
+	t.Cleanup(func() { targetAlive = orig })
+}
+
+func TestHygieneRemovesDeadTargets(t *testing.T) {
+	f := installFakeMwan3(t, map[string]string{
+		"mwan3.wan":          "interface",
+		"mwan3.wan.track_ip": "1.1.1.1 1.0.0.1",
+	})
+	allAlive(t, "1.0.0.1")
+
+	dir := t.TempDir()
+	fixes, err := Mwan3Hygiene(true, dir)
+	if err != nil {
+		t.Fatalf("hygiene: %v", err)
+	}
+	if len(fixes) != 1 || len(fixes[0].Removed) != 1 || fixes[0].Removed[0] != "1.0.0.1" {
+		t.Fatalf("fixes = %+v", fixes)
+	}
+	// The dead target goes, and a second live one from another provider
+	// comes in so the member never tracks a single address.
+	if got := f.values["mwan3.wan.track_ip"]; got != "1.1.1.1 8.8.8.8" {
+		t.Errorf("track_ip = %q", got)
+	}
+	uci.Commits.Flush()
+	if f.commits != 1 {
+		t.Errorf("commits = %d", f.commits)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "mwan3.bak")); err != nil {
+		t.Errorf("no backup written: %v", err)
+	}
+}
+
+func TestHygieneAddsProviderDiversity(t *testing.T) {
+	f := installFakeMwan3(t, map[string]string{
+		"mwan3.wan":          "interface",
+		"mwan3.wan.track_ip": "8.8.8.8 8.8.4.4",
+	})
+	allAlive(t)
+
+	fixes, err := Mwan3Hygiene(true, t.TempDir())
+	if err != nil {
+		t.Fatalf("hygiene: %v", err)
+	}
+	if len(fixes) != 1 || len(fixes[0].Added) != 1 {
+		t.Fatalf("fixes = %+v", fixes)
+	}
+	if got := f.values["mwan3.wan.track_ip"]; got != "8.8.8.8 8.8.4.4 1.1.1.1" {
+		t.Errorf("track_ip = %q", got)
+	}
+}
+
+func TestHygieneCorrectsInterval(t *testing.T) {
+	f := installFakeMwan3(t, map[string]string{
+		"mwan3.wan":          "interface",
+		"mwan3.wan.track_ip": "1.1.1.1 8.8.8.8",
+		"mwan3.wan.interval": "1",
+	})
+	allAlive(t)
+
+	fixes, err := Mwan3Hygiene(true, t.TempDir())
+	if err != nil {
+		t.Fatalf("hygiene: %v", err)
+	}
+	if len(fixes) != 1 || fixes[0].Interval != minTrackInterval {
+		t.Fatalf("fixes = %+v", fixes)
+	}
+	if got := f.values["mwan3.wan.interval"]; got != "5" {
+		t.Errorf("interval = %q", got)
+	}
+	// Targets were fine and must be untouched.
+	if got := f.values["mwan3.wan.track_ip"]; got != "1.1.1.1 8.8.8.8" {
+		t.Errorf("track_ip = %q", got)
+	}
+}
+
+func TestHygieneLeavesHealthyConfigAlone(t *testing.T) {
+	f := installFakeMwan3(t, map[string]string{
+		"mwan3.wan":          "interface",
+		"mwan3.wan.track_ip": "1.1.1.1 8.8.8.8",
+		"mwan3.wan.interval": "10",
+	})
+	allAlive(t)
+
+	fixes, err := Mwan3Hygiene(true, t.TempDir())
+	if err != nil {
+		t.Fatalf("hygiene: %v", err)
+	}
+	if len(fixes) != 0 {
+		t.Errorf("unexpected fixes %+v", fixes)
+	}
+	uci.Commits.Flush()
+	if f.commits != 0 {
+		t.Errorf("healthy config committed %d times", f.commits)
+	}
+}
@@ -0,0 +1,184 @@
+package controller
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/ubus"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/uci"
+)
+
+// Inbound traffic is the quiet casualty of a WAN switch: port forwards
+// whose src_dip pins the old WAN address stop matching, and DDNS records
+// keep resolving to it until their TTL runs out. This integration runs
+// right after a switch, re-points the listed firewall redirects at the
+// new active member's address and pushes it to the configured DDNS
+// providers, so inbound services recover in seconds rather than hours.
+
+// fwReload reloads the firewall after redirect changes; a variable so
+// tests can stub it.
+var fwReload = func() error {
+	out, err := exec.Command("fw3", "reload").CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("fw3 reload: %v: %s", err, out)
+	}
+	return nil
+}
+
+// ddnsGet performs one provider update request; a variable for tests.
+var ddnsGet = func(url string) error {
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("status %s", resp.Status)
+	}
+	return nil
+}
+
+// memberAddress resolves a member's current IPv4 WAN address through
+// netifd; a variable for tests.
+var memberAddress = func(member string) (string, error) {
+	iface := member
+	if v, ok := uci.Get("starfail." + member + ".iface"); ok && v != "" {
+		iface = v
+	}
+	var st struct {
+		Up   bool `json:"up"`
+		IPv4 []struct {
+			Address string `json:"address"`
+		} `json:"ipv4-address"`
+	}
+	if err := ubus.Call("network.interface."+iface, "status", nil, &st); err != nil {
+		return "", err
+	}
+	if !st.Up || len(st.IPv4) == 0 {
+		return "", fmt.Errorf("interface %s has no IPv4 address yet", iface)
+	}
+	return st.IPv4[0].Address, nil
+}
+
+// inboundRetryDelay spaces the address-resolution retries; shrunk in
+// tests. A member that just became active may take a few seconds to get
+// its address from netifd.
+var inboundRetryDelay = 2 * time.Second
+
+const inboundRetries = 5
+
+// DDNSProvider is one "config ddns" section of the starfail package.
+// The url plugin covers every GET-style provider (DuckDNS, No-IP,
+// dyn.com, ...); the script plugin hands the address to a user script for
+// anything fancier.
+type DDNSProvider struct {
+	Name     string
+	Plugin   string // url|script
+	URL      string // template; {ip} and {hostname} are substituted
+	Hostname string
+	Script   string // script plugin: run with the new address as argument
+}
+
+// Inbound re-establishes inbound reachability after a switch.
+type Inbound struct {
+	// Redirects lists firewall redirect section names whose src_dip is
+	// re-pointed at the new WAN address.
+	Redirects []string
+
+	Providers []DDNSProvider
+}
+
+// NewInboundFromUCI returns the configured integration, or nil when
+// neither redirects nor DDNS providers are set up.
+func NewInboundFromUCI() *Inbound {
+	in := &Inbound{Redirects: uci.GetList("starfail.inbound.redirects")}
+	for _, name := range uci.Sections("starfail", "ddns") {
+		p := DDNSProvider{Name: name}
+		p.Plugin, _ = uci.Get("starfail." + name + ".plugin")
+		if p.Plugin == "" {
+			p.Plugin = "url"
+		}
+		p.URL, _ = uci.Get("starfail." + name + ".url")
+		p.Hostname, _ = uci.Get("starfail." + name + ".hostname")
+		p.Script, _ = uci.Get("starfail." + name + ".script")
+		if p.URL == "" && p.Script == "" {
+			continue
+		}
+		in.Providers = append(in.Providers, p)
+	}
+	if len(in.Redirects) == 0 && len(in.Providers) == 0 {
+		return nil
+	}
+	return in
+}
+
+// Apply re-points redirects and DDNS at member's address. It runs as an
+// OnSwitch hook in the background, so it may wait for netifd to hand the
+// member an address; failures are logged, never fatal.
+func (in *Inbound) Apply(member string) {
+	var ip string
+	var err error
+	for attempt := 0; attempt < inboundRetries; attempt++ {
+		if ip, err = memberAddress(member); err == nil {
+			break
+		}
+		time.Sleep(inboundRetryDelay)
+	}
+	if err != nil {
+		log.Printf("controller: inbound: resolve %s address: %v", member, err)
+		return
+	}
+	in.applyRedirects(ip)
+	in.updateDDNS(ip)
+}
+
+// applyRedirects rewrites src_dip on the managed firewall redirects and
+// reloads the firewall once if anything changed.
+func (in *Inbound) applyRedirects(ip string) {
+	changed := false
+	for _, sec := range in.Redirects {
+		key := "firewall." + sec + ".src_dip"
+		if cur, ok := uci.Get(key); ok && cur == ip {
+			continue
+		}
+		if err := uci.Set(key, ip); err != nil {
+			log.Printf("controller: inbound: redirect %s: %v", sec, err)
+			continue
+		}
+		changed = true
+	}
+	if !changed {
+		return
+	}
+	uci.Commits.Request("firewall")
+	if err := fwReload(); err != nil {
+		log.Printf("controller: inbound: %v", err)
+		return
+	}
+	log.Printf("controller: inbound: redirects re-pointed at %s", ip)
+}
+
+// updateDDNS pushes the new address to every provider.
+func (in *Inbound) updateDDNS(ip string) {
+	for _, p := range in.Providers {
+		var err error
+		switch p.Plugin {
+		case "script":
+			err = scriptExec(p.Script, ip)
+		default:
+			url := strings.ReplaceAll(p.URL, "{ip}", ip)
+			url = strings.ReplaceAll(url, "{hostname}", p.Hostname)
+			err = ddnsGet(url)
+		}
+		if err != nil {
+			log.Printf("controller: inbound: ddns %s: %v", p.Name, err)
+			continue
+		}
+		log.Printf("controller: inbound: ddns %s updated to %s", p.Name, ip)
+	}
+}
@@ -0,0 +1,112 @@
+package controller
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/uci"
+)
+
+func installInboundStubs(t *testing.T, addr string, addrErr error) (uciOps *[]string, reloads *int, urls *[]string) {
+	t.Helper()
+	var ops []string
+	var rel int
+	var got []string
+	origQueue := uci.Commits
+	uci.Commits = uci.NewCommitQueue(filepath.Join(t.TempDir(), "journal.json"), time.Hour)
+	origExec, origFw, origGet, origAddr, origDelay := uci.Exec, fwReload, ddnsGet, memberAddress, inboundRetryDelay
+	uci.Exec = func(args ...string) (string, error) {
+		ops = append(ops, strings.Join(args, " "))
+		// src_dip is unset at first: reads miss, writes work.
+		if args[0] == "-q" {
+			return "", fmt.Errorf("not found")
+		}
+		return "", nil
+	}
+	fwReload = func() error { rel++; return nil }
+	ddnsGet = func(url string) error { got = append(got, url); return nil }
+	memberAddress = func(string) (string, error) { return addr, addrErr }
+	inboundRetryDelay = time.Millisecond
+	t.Cleanup(func() {
+		uci.Commits = origQueue
+		uci.Exec, fwReload, ddnsGet, memberAddress, inboundRetryDelay = origExec, origFw, origGet, origAddr, origDelay
+	})
+	return &ops, &rel, &got
+}
+
+func TestInboundApplyRedirects(t *testing.T) {
+	ops, reloads, _ := installInboundStubs(t, "203.0.113.7", nil)
+	in := &Inbound{Redirects: []string{"fwd_http", "fwd_sip"}}
+	in.Apply("starlink")
+	uci.Commits.Flush()
+
+	joined := strings.Join(*ops, "\n")
+	for _, want := range []string{
+		"set firewall.fwd_http.src_dip=203.0.113.7",
+		"set firewall.fwd_sip.src_dip=203.0.113.7",
+		"commit firewall",
+	} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("missing uci op %q in:\n%s", want, joined)
+		}
+	}
+	if *reloads != 1 {
+		t.Errorf("firewall reloads = %d, want 1", *reloads)
+	}
+}
+
+func TestInboundRedirectsAlreadyCurrent(t *testing.T) {
+	_, reloads, _ := installInboundStubs(t, "203.0.113.7", nil)
+	// Get returns the target address for every key: nothing to change.
+	uci.Exec = func(args ...string) (string, error) { return "203.0.113.7", nil }
+	in := &Inbound{Redirects: []string{"fwd_http"}}
+	in.Apply("starlink")
+	if *reloads != 0 {
+		t.Errorf("firewall reloaded %d times for a no-op", *reloads)
+	}
+}
+
+func TestInboundDDNS(t *testing.T) {
+	_, _, urls := installInboundStubs(t, "203.0.113.7", nil)
+	var scriptRuns []string
+	origScript := scriptExec
+	scriptExec = func(script, arg string) error {
+		scriptRuns = append(scriptRuns, script+" "+arg)
+		return nil
+	}
+	t.Cleanup(func() { scriptExec = origScript })
+
+	in := &Inbound{Providers: []DDNSProvider{
+		{Name: "duck", Plugin: "url", URL: "https://duckdns.example/update?domains={hostname}&ip={ip}", Hostname: "rv"},
+		{Name: "custom", Plugin: "script", Script: "/etc/starfail/ddns.sh"},
+	}}
+	in.Apply("starlink")
+
+	if len(*urls) != 1 || (*urls)[0] != "https://duckdns.example/update?domains=rv&ip=203.0.113.7" {
+		t.Errorf("ddns urls = %v", *urls)
+	}
+	if len(scriptRuns) != 1 || scriptRuns[0] != "/etc/starfail/ddns.sh 203.0.113.7" {
+		t.Errorf("script runs = %v", scriptRuns)
+	}
+}
+
+func TestInboundAddressUnresolvable(t *testing.T) {
+	_, reloads, urls := installInboundStubs(t, "", fmt.Errorf("no address yet"))
+	in := &Inbound{Redirects: []string{"fwd_http"}, Providers: []DDNSProvider{{Name: "d", URL: "https://x/{ip}"}}}
+	in.Apply("starlink")
+	if *reloads != 0 || len(*urls) != 0 {
+		t.Errorf("acted without an address: reloads=%d urls=%v", *reloads, *urls)
+	}
+}
+
+func TestNewInboundFromUCIUnconfigured(t *testing.T) {
+	orig := uci.Exec
+	uci.Exec = func(args ...string) (string, error) { return "", fmt.Errorf("not found") }
+	t.Cleanup(func() { uci.Exec = orig })
+	if in := NewInboundFromUCI(); in != nil {
+		t.Errorf("NewInboundFromUCI() = %+v, want nil", in)
+	}
+}
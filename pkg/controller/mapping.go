@@ -0,0 +1,124 @@
+package controller
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/ubus"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/uci"
+)
+
+// Mapping traces one starfail member through the stack: the netifd
+// interface it claims, the device behind it, and the mwan3 member that
+// actually steers its traffic. Issues lists every mismatch found, which
+// is how "Starlink metrics attached to the wrong WAN" gets diagnosed
+// without reading four config files by hand.
+type Mapping struct {
+	Member      string   `json:"member"`
+	Class       string   `json:"class"`
+	ConfigIface string   `json:"config_iface"`          // starfail.<member>.iface
+	Mwan3Iface  string   `json:"mwan3_iface,omitempty"` // mwan3.<member>.interface
+	Device      string   `json:"device,omitempty"`      // netifd l3_device
+	VRF         string   `json:"vrf,omitempty"`         // starfail.<member>.vrf
+	Up          bool     `json:"up"`
+	Issues      []string `json:"issues,omitempty"`
+}
+
+// cellularDevicePrefixes mark devices that belong to a modem; a starlink
+// member pointing at one of these is the classic misconfiguration.
+var cellularDevicePrefixes = []string{"mob", "wwan", "qmi", "rmnet"}
+
+// Mappings resolves the full chain for every configured member.
+func Mappings(cfg *uci.Config) []Mapping {
+	out := make([]Mapping, 0, len(cfg.Members))
+	for _, mc := range cfg.Members {
+		m := Mapping{Member: mc.Name, Class: mc.Class, ConfigIface: mc.Iface}
+
+		if iface, ok := uci.Get("mwan3." + mc.Name + ".interface"); ok && iface != "" {
+			m.Mwan3Iface = iface
+			if iface != mc.Iface {
+				m.Issues = append(m.Issues, fmt.Sprintf(
+					"mwan3 member steers interface %q but starfail samples %q", iface, mc.Iface))
+			}
+		} else {
+			m.Issues = append(m.Issues, "no mwan3 member section; Switch cannot steer this member")
+		}
+
+		var st struct {
+			Up       bool   `json:"up"`
+			L3Device string `json:"l3_device"`
+			Device   string `json:"device"`
+		}
+		if err := ubus.Call("network.interface."+mc.Iface, "status", nil, &st); err != nil {
+			m.Issues = append(m.Issues, fmt.Sprintf("netifd knows no interface %q", mc.Iface))
+		} else {
+			m.Up = st.Up
+			m.Device = st.L3Device
+			if m.Device == "" {
+				m.Device = st.Device
+			}
+			if !st.Up {
+				m.Issues = append(m.Issues, "interface is down")
+			}
+			if issue := classDeviceMismatch(mc.Class, m.Device); issue != "" {
+				m.Issues = append(m.Issues, issue)
+			}
+		}
+		if mc.VRF != "" {
+			m.VRF = mc.VRF
+			if issue := vrfMismatch(m.Device, mc.VRF); issue != "" {
+				m.Issues = append(m.Issues, issue)
+			}
+		}
+		out = append(out, m)
+	}
+	return out
+}
+
+// vrfMismatch verifies that a member's device is actually enslaved to the
+// VRF it claims. Probes bound to the VRF device would otherwise silently
+// route by the main table — the path looks fine while measuring the wrong
+// one.
+func vrfMismatch(device, vrf string) string {
+	if device == "" {
+		return ""
+	}
+	out, err := ipLinkShow(device)
+	if err != nil {
+		return fmt.Sprintf("cannot inspect device %q for VRF %q: %v", device, vrf, err)
+	}
+	if !strings.Contains(out, " master "+vrf+" ") {
+		return fmt.Sprintf("member binds VRF %q but device %q is not enslaved to it; probes would route by the main table", vrf, device)
+	}
+	return ""
+}
+
+// ipLinkShow is a var so tests can fake the ip output.
+var ipLinkShow = func(device string) (string, error) {
+	out, err := exec.Command("ip", "-o", "link", "show", "dev", device).Output()
+	return string(out), err
+}
+
+// classDeviceMismatch flags member classes that plainly do not match the
+// device type behind the interface. It only reports certain mismatches;
+// a generic member may legitimately sit on anything.
+func classDeviceMismatch(class, device string) string {
+	if device == "" {
+		return ""
+	}
+	cellular := false
+	for _, p := range cellularDevicePrefixes {
+		if strings.HasPrefix(device, p) {
+			cellular = true
+			break
+		}
+	}
+	switch {
+	case class == "starlink" && cellular:
+		return fmt.Sprintf("class starlink but device %q looks like a modem; dish metrics will score the wrong WAN", device)
+	case class == "cellular" && !cellular && strings.HasPrefix(device, "eth"):
+		return fmt.Sprintf("class cellular but device %q is ethernet", device)
+	}
+	return ""
+}
@@ -0,0 +1,61 @@
+package controller
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestClassDeviceMismatch(t *testing.T) {
+	cases := []struct {
+		class, device string
+		wantIssue     bool
+	}{
+		{"starlink", "eth1", false},
+		{"starlink", "mob1s1a1", true},
+		{"starlink", "wwan0", true},
+		{"cellular", "qmi0", false},
+		{"cellular", "eth1", true},
+		{"generic", "eth0", false},
+		{"generic", "mob1s1a1", false},
+		{"starlink", "", false},
+	}
+	for _, tc := range cases {
+		issue := classDeviceMismatch(tc.class, tc.device)
+		if tc.wantIssue && issue == "" {
+			t.Errorf("%s on %s: expected an issue", tc.class, tc.device)
+		}
+		if !tc.wantIssue && issue != "" {
+			t.Errorf("%s on %s: unexpected issue %q", tc.class, tc.device, issue)
+		}
+		if issue != "" && !strings.Contains(issue, tc.device) {
+			t.Errorf("issue %q does not name the device", issue)
+		}
+	}
+}
+
+func TestVRFMismatch(t *testing.T) {
+	orig := ipLinkShow
+	t.Cleanup(func() { ipLinkShow = orig })
+
+	ipLinkShow = func(device string) (string, error) {
+		return "2: eth1: <BROADCAST,MULTICAST,UP> mtu 1500 master vrf_mgmt state UP \n", nil
+	}
+	if issue := vrfMismatch("eth1", "vrf_mgmt"); issue != "" {
+		t.Errorf("enslaved device flagged: %q", issue)
+	}
+	if issue := vrfMismatch("eth1", "vrf_other"); issue == "" {
+		t.Error("device in the wrong VRF not flagged")
+	} else if !strings.Contains(issue, "vrf_other") {
+		t.Errorf("issue %q does not name the VRF", issue)
+	}
+
+	ipLinkShow = func(device string) (string, error) {
+		return "3: eth2: <BROADCAST,MULTICAST,UP> mtu 1500 state UP \n", nil
+	}
+	if issue := vrfMismatch("eth2", "vrf_mgmt"); issue == "" {
+		t.Error("unenslaved device not flagged")
+	}
+	if issue := vrfMismatch("", "vrf_mgmt"); issue != "" {
+		t.Errorf("unknown device should be skipped, got %q", issue)
+	}
+}
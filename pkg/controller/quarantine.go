@@ -0,0 +1,137 @@
+package controller
+
+import (
+	"fmt"
+	"log"
+	"os/exec"
+	"sort"
+	"sync"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/uci"
+)
+
+// A member that hijacks DNS or answers TLS with its own certificate is
+// worse than a dead one: failing over away from it still leaves LAN
+// clients free to route odd flows through it. Quarantine optionally turns
+// such a detection into firewall state — forwarded traffic may no longer
+// leave through the member's device except for the flows a captive-portal
+// re-auth needs (DNS and HTTP/HTTPS) — and lifts itself as soon as the
+// member probes clean again.
+
+// quarantineChain is the iptables chain holding the allow/reject policy;
+// per-member jump rules in FORWARD point at it.
+const quarantineChain = "starfail_qtn"
+
+// iptablesExec runs iptables; a variable so tests can stub it.
+var iptablesExec = func(args ...string) error {
+	out, err := exec.Command("iptables", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("iptables %v: %v: %s", args, err, out)
+	}
+	return nil
+}
+
+// Quarantine tracks which member devices are currently fenced off.
+type Quarantine struct {
+	mu     sync.Mutex
+	active map[string]string // member -> device carrying the jump rule
+	ready  bool              // policy chain created and populated
+}
+
+// NewQuarantineFromUCI returns a Quarantine when
+// starfail.quarantine.enabled is set, nil otherwise; a nil Quarantine is
+// safe to call and does nothing.
+func NewQuarantineFromUCI() *Quarantine {
+	if !uci.GetBool("starfail.quarantine.enabled", false) {
+		return nil
+	}
+	return &Quarantine{active: make(map[string]string)}
+}
+
+// ensureChain creates and populates the policy chain on first use. The
+// allow list is exactly what a captive-portal login needs — DNS plus
+// HTTP/HTTPS to reach and complete the re-auth flow; everything else is
+// rejected so clients fail fast instead of timing out.
+func (q *Quarantine) ensureChain() error {
+	if q.ready {
+		return nil
+	}
+	if err := iptablesExec("-N", quarantineChain); err != nil {
+		// The chain can survive a daemon restart; start from a clean one.
+		if err := iptablesExec("-F", quarantineChain); err != nil {
+			return err
+		}
+	}
+	for _, rule := range [][]string{
+		{"-p", "udp", "--dport", "53", "-j", "RETURN"},
+		{"-p", "tcp", "--dport", "53", "-j", "RETURN"},
+		{"-p", "tcp", "--dport", "80", "-j", "RETURN"},
+		{"-p", "tcp", "--dport", "443", "-j", "RETURN"},
+		{"-j", "REJECT"},
+	} {
+		if err := iptablesExec(append([]string{"-A", quarantineChain}, rule...)...); err != nil {
+			return err
+		}
+	}
+	q.ready = true
+	return nil
+}
+
+// Apply fences off a member's device and reports whether it newly did so:
+// a member already quarantined on the same device is left alone.
+func (q *Quarantine) Apply(member, device string) (bool, error) {
+	if q == nil || device == "" {
+		return false, nil
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.active[member] == device {
+		return false, nil
+	}
+	if err := q.ensureChain(); err != nil {
+		return false, err
+	}
+	if old := q.active[member]; old != "" {
+		_ = iptablesExec("-D", "FORWARD", "-o", old, "-j", quarantineChain)
+	}
+	if err := iptablesExec("-I", "FORWARD", "-o", device, "-j", quarantineChain); err != nil {
+		return false, err
+	}
+	q.active[member] = device
+	log.Printf("controller: quarantined %s (%s): re-auth flows only", member, device)
+	return true, nil
+}
+
+// Release lifts a member's quarantine and reports whether one was active.
+func (q *Quarantine) Release(member string) bool {
+	if q == nil {
+		return false
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	device, ok := q.active[member]
+	if !ok {
+		return false
+	}
+	if err := iptablesExec("-D", "FORWARD", "-o", device, "-j", quarantineChain); err != nil {
+		log.Printf("controller: release quarantine for %s: %v", member, err)
+	}
+	delete(q.active, member)
+	log.Printf("controller: quarantine lifted for %s (%s)", member, device)
+	return true
+}
+
+// Active returns the currently quarantined member names, sorted.
+func (q *Quarantine) Active() []string {
+	if q == nil {
+		return nil
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	out := make([]string, 0, len(q.active))
+	for m := range q.active {
+		out = append(out, m)
+	}
+	sort.Strings(out)
+	return out
+}
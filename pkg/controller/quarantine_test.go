@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"strings"
+	"testing"
+)
+
+// captureIptables stubs iptablesExec, recording each invocation as one
+// space-joined line.
+func captureIptables(t *testing.T) *[]string {
+	t.Helper()
+	var calls []string
+	orig := iptablesExec
+	iptablesExec = func(args ...string) error {
+		calls = append(calls, strings.Join(args, " "))
+		return nil
+	}
+	t.Cleanup(func() { iptablesExec = orig })
+	return &calls
+}
+
+func TestQuarantineApplyAndRelease(t *testing.T) {
+	calls := captureIptables(t)
+	q := &Quarantine{active: make(map[string]string)}
+
+	applied, err := q.Apply("wan", "eth1")
+	if err != nil || !applied {
+		t.Fatalf("Apply = %v, %v, want true, nil", applied, err)
+	}
+	joined := strings.Join(*calls, "\n")
+	for _, want := range []string{
+		"-N " + quarantineChain,
+		"--dport 53",
+		"--dport 80",
+		"--dport 443",
+		"-A " + quarantineChain + " -j REJECT",
+		"-I FORWARD -o eth1 -j " + quarantineChain,
+	} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("iptables calls missing %q:\n%s", want, joined)
+		}
+	}
+
+	// Re-applying the same quarantine is a no-op.
+	n := len(*calls)
+	if applied, _ := q.Apply("wan", "eth1"); applied || len(*calls) != n {
+		t.Error("second Apply was not a no-op")
+	}
+
+	if !q.Release("wan") {
+		t.Fatal("Release returned false for an active quarantine")
+	}
+	if got := (*calls)[len(*calls)-1]; got != "-D FORWARD -o eth1 -j "+quarantineChain {
+		t.Errorf("release rule = %q", got)
+	}
+	if q.Release("wan") {
+		t.Error("second Release reported an active quarantine")
+	}
+}
+
+func TestQuarantineChainBuiltOnce(t *testing.T) {
+	calls := captureIptables(t)
+	q := &Quarantine{active: make(map[string]string)}
+	_, _ = q.Apply("wan", "eth1")
+	_, _ = q.Apply("wwan", "wwan0")
+	creates := 0
+	for _, c := range *calls {
+		if strings.HasPrefix(c, "-N ") {
+			creates++
+		}
+	}
+	if creates != 1 {
+		t.Errorf("chain created %d times, want 1", creates)
+	}
+	if got := q.Active(); len(got) != 2 || got[0] != "wan" || got[1] != "wwan" {
+		t.Errorf("Active() = %v", got)
+	}
+}
+
+func TestQuarantineNilReceiver(t *testing.T) {
+	var q *Quarantine
+	if applied, err := q.Apply("wan", "eth1"); applied || err != nil {
+		t.Error("nil Apply did something")
+	}
+	if q.Release("wan") || q.Active() != nil {
+		t.Error("nil Release/Active did something")
+	}
+}
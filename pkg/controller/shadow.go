@@ -0,0 +1,113 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os/exec"
+	"time"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/probe"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/uci"
+)
+
+// ShadowVerifier proves a recovery before failback: it installs a policy
+// route that steers only interface-bound traffic (the daemon's probes)
+// through the candidate member, probes through it for a verification
+// window, and tears the route down again. Regular traffic stays on the
+// current member the whole time.
+
+// shadowTable is a routing table well away from mwan3's (1-250 range).
+const shadowTable = "900"
+
+// Defaults for the starfail.verify section.
+const (
+	defaultVerifyWindowS  = 15
+	defaultVerifyMaxLoss  = 10.0  // percent
+	defaultVerifyMaxLatMS = 800.0 // covers Starlink worst cases
+)
+
+// ipExec runs the ip binary; a variable so tests can stub it.
+var ipExec = func(args ...string) error {
+	out, err := exec.Command("ip", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("ip %v: %v: %s", args, err, out)
+	}
+	return nil
+}
+
+// shadowProbe is probe.Run, split out for stubbing in tests.
+var shadowProbe = probe.Run
+
+// ShadowVerifier holds the verification thresholds.
+type ShadowVerifier struct {
+	WindowS      int
+	MaxLossPct   float64
+	MaxLatencyMS float64
+}
+
+// NewShadowVerifier returns the verifier configured under starfail.verify,
+// or nil when disabled.
+func NewShadowVerifier() *ShadowVerifier {
+	if !uci.GetBool("starfail.verify.enabled", false) {
+		return nil
+	}
+	return &ShadowVerifier{
+		WindowS:      uci.GetInt("starfail.verify.window_s", defaultVerifyWindowS),
+		MaxLossPct:   uci.GetFloat("starfail.verify.max_loss_pct", defaultVerifyMaxLoss),
+		MaxLatencyMS: uci.GetFloat("starfail.verify.max_latency_ms", defaultVerifyMaxLatMS),
+	}
+}
+
+// Verify implements engine.Verifier. Every probe round inside the window
+// must pass; one bad round fails the candidate.
+func (v *ShadowVerifier) Verify(ctx context.Context, member, iface string) bool {
+	if err := v.setupRoute(iface); err != nil {
+		log.Printf("controller: shadow route for %s: %v", member, err)
+		return false
+	}
+	defer v.teardownRoute(iface)
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(v.WindowS+10)*time.Second)
+	defer cancel()
+	deadline := time.Now().Add(time.Duration(v.WindowS) * time.Second)
+	rounds := 0
+	for time.Now().Before(deadline) {
+		agg, _ := shadowProbe(ctx, iface, probe.ProfileFor("generic"), 3)
+		if agg.LossPercent > v.MaxLossPct || agg.LatencyMS > v.MaxLatencyMS {
+			log.Printf("controller: shadow verify %s: loss=%.0f%% latency=%.0fms, failing",
+				member, agg.LossPercent, agg.LatencyMS)
+			return false
+		}
+		rounds++
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(3 * time.Second):
+		}
+	}
+	return rounds > 0
+}
+
+// setupRoute policy-routes traffic bound to iface through the shadow
+// table, keeping the daemon's probes off the active member's default
+// route without touching what mwan3 installed for everyone else.
+func (v *ShadowVerifier) setupRoute(iface string) error {
+	if err := ipExec("route", "replace", "default", "dev", iface, "table", shadowTable); err != nil {
+		return err
+	}
+	if err := ipExec("rule", "add", "oif", iface, "lookup", shadowTable, "prio", shadowTable); err != nil {
+		_ = ipExec("route", "flush", "table", shadowTable)
+		return err
+	}
+	return nil
+}
+
+func (v *ShadowVerifier) teardownRoute(iface string) {
+	if err := ipExec("rule", "del", "oif", iface, "lookup", shadowTable, "prio", shadowTable); err != nil {
+		log.Printf("controller: shadow teardown: %v", err)
+	}
+	if err := ipExec("route", "flush", "table", shadowTable); err != nil {
+		log.Printf("controller: shadow teardown: %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+package controller
+
+// Switch transactions. A switch is several uci writes, a commit and an
+// mwan3 restart; a daemon crash in the middle leaves mwan3 half-modified
+// with no one coming back to finish the job. So every switch first
+// persists its intent, applies, then confirms by removing the intent.
+// Replay is idempotent — applying a switch sets every member's metric
+// absolutely — so startup recovery simply completes whatever target the
+// surviving intent names, and a failed apply at runtime rolls back to the
+// previously active member the same way.
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/uci"
+)
+
+// txnPath is where switch intents persist across a daemon crash. /tmp
+// survives a crash within the same boot, and after a reboot mwan3 re-reads
+// committed config anyway, so tmpfs is exactly durable enough. A variable
+// for tests.
+var txnPath = "/tmp/starfail/controller-txn.json"
+
+// txn is one recorded switch intent.
+type txn struct {
+	Target   string    `json:"target"`
+	Previous string    `json:"previous,omitempty"`
+	Members  []string  `json:"members"`
+	Started  time.Time `json:"started"`
+}
+
+// writeIntent persists t before any mwan3 state changes.
+func writeIntent(t *txn) {
+	if err := os.MkdirAll(filepath.Dir(txnPath), 0o755); err != nil {
+		return
+	}
+	b, _ := json.Marshal(t)
+	if err := os.WriteFile(txnPath, b, 0o600); err != nil {
+		log.Printf("controller: persist switch intent: %v", err)
+	}
+}
+
+// loadIntent returns the surviving intent, nil when there is none.
+func loadIntent() *txn {
+	raw, err := os.ReadFile(txnPath)
+	if err != nil {
+		return nil
+	}
+	var t txn
+	if json.Unmarshal(raw, &t) != nil || t.Target == "" {
+		return nil
+	}
+	return &t
+}
+
+// clearIntent confirms the transaction.
+func clearIntent() { _ = os.Remove(txnPath) }
+
+// activeMember returns the member currently holding the active metric, ""
+// when none does (fresh install, foreign edits).
+func activeMember(members []string) string {
+	for _, m := range members {
+		if v, ok := uci.Get(fmt.Sprintf("mwan3.%s.metric", m)); ok && v == fmt.Sprintf("%d", metricActive) {
+			return m
+		}
+	}
+	return ""
+}
+
+// Recover completes a switch interrupted by a crash. It returns the
+// recovered target ("" when no transaction was pending) so the caller can
+// record the event; on failure the intent stays on disk for the next
+// attempt.
+func (c *Controller) Recover() (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := loadIntent()
+	if t == nil {
+		return "", nil
+	}
+	if c.Noop {
+		clearIntent()
+		return t.Target, nil
+	}
+	log.Printf("controller: completing switch to %s interrupted %s ago",
+		t.Target, time.Since(t.Started).Round(time.Second))
+	if err := apply(t.Members, t.Target); err != nil {
+		return t.Target, err
+	}
+	clearIntent()
+	return t.Target, nil
+}
@@ -0,0 +1,151 @@
+package controller
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// txnTestSetup points the intent file at a temp dir and stubs the mwan3
+// restart, returning the fake uci store and a pointer to the restart
+// error the stub will report.
+func txnTestSetup(t *testing.T, initial map[string]string) (*fakeMwan3, *error) {
+	t.Helper()
+	f := installFakeMwan3(t, initial)
+
+	origPath := txnPath
+	txnPath = filepath.Join(t.TempDir(), "txn.json")
+	t.Cleanup(func() { txnPath = origPath })
+
+	var restartErr error
+	origRestart := mwan3Restart
+	mwan3Restart = func() error { return restartErr }
+	t.Cleanup(func() { mwan3Restart = origRestart })
+	return f, &restartErr
+}
+
+// staticMembers is a fixed member source for tests.
+func staticMembers(names ...string) func() []string {
+	return func() []string { return names }
+}
+
+func intentOnDisk(t *testing.T) *txn {
+	t.Helper()
+	if _, err := os.Stat(txnPath); err != nil {
+		return nil
+	}
+	return loadIntent()
+}
+
+func TestSwitchAppliesAndConfirms(t *testing.T) {
+	f, _ := txnTestSetup(t, map[string]string{
+		"mwan3.wan.metric":  "1",
+		"mwan3.cell.metric": "20",
+	})
+	c := New(staticMembers("wan", "cell"))
+	if err := c.Switch("cell"); err != nil {
+		t.Fatalf("Switch: %v", err)
+	}
+	if f.values["mwan3.cell.metric"] != "1" || f.values["mwan3.wan.metric"] != "20" {
+		t.Errorf("metrics after switch: %v", f.values)
+	}
+	if intentOnDisk(t) != nil {
+		t.Error("confirmed switch left its intent on disk")
+	}
+}
+
+func TestSwitchRollsBackOnRestartFailure(t *testing.T) {
+	f, restartErr := txnTestSetup(t, map[string]string{
+		"mwan3.wan.metric":  "1",
+		"mwan3.cell.metric": "20",
+	})
+	c := New(staticMembers("wan", "cell"))
+
+	// First restart (the switch) fails, the second (the rollback) works.
+	calls := 0
+	mwan3Restart = func() error {
+		calls++
+		if calls == 1 {
+			return errors.New("restart: boom")
+		}
+		return *restartErr
+	}
+	if err := c.Switch("cell"); err == nil {
+		t.Fatal("Switch did not report the restart failure")
+	}
+	if f.values["mwan3.wan.metric"] != "1" || f.values["mwan3.cell.metric"] != "20" {
+		t.Errorf("rollback did not restore metrics: %v", f.values)
+	}
+	if intentOnDisk(t) != nil {
+		t.Error("rolled-back switch left its intent on disk")
+	}
+}
+
+func TestSwitchKeepsIntentWhenRollbackFails(t *testing.T) {
+	_, _ = txnTestSetup(t, map[string]string{
+		"mwan3.wan.metric":  "1",
+		"mwan3.cell.metric": "20",
+	})
+	c := New(staticMembers("wan", "cell"))
+	mwan3Restart = func() error { return errors.New("restart: boom") }
+
+	if err := c.Switch("cell"); err == nil {
+		t.Fatal("Switch did not report the restart failure")
+	}
+	in := intentOnDisk(t)
+	if in == nil {
+		t.Fatal("no surviving intent for startup recovery")
+	}
+	// Retargeted at the previously active member so Recover restores it.
+	if in.Target != "wan" {
+		t.Errorf("surviving intent targets %q, want wan", in.Target)
+	}
+}
+
+func TestRecoverCompletesPendingSwitch(t *testing.T) {
+	f, _ := txnTestSetup(t, map[string]string{
+		"mwan3.wan.metric":  "1",
+		"mwan3.cell.metric": "20",
+	})
+	writeIntent(&txn{Target: "cell", Previous: "wan", Members: []string{"wan", "cell"}})
+
+	c := New(staticMembers("wan", "cell"))
+	target, err := c.Recover()
+	if err != nil {
+		t.Fatalf("Recover: %v", err)
+	}
+	if target != "cell" {
+		t.Errorf("recovered target = %q, want cell", target)
+	}
+	if f.values["mwan3.cell.metric"] != "1" || f.values["mwan3.wan.metric"] != "20" {
+		t.Errorf("metrics after recovery: %v", f.values)
+	}
+	if intentOnDisk(t) != nil {
+		t.Error("completed recovery left its intent on disk")
+	}
+}
+
+func TestRecoverWithNothingPending(t *testing.T) {
+	_, _ = txnTestSetup(t, map[string]string{})
+	c := New(staticMembers("wan"))
+	if target, err := c.Recover(); target != "" || err != nil {
+		t.Errorf("Recover = (%q, %v), want empty", target, err)
+	}
+}
+
+func TestSwitchUnknownMemberTouchesNothing(t *testing.T) {
+	f, _ := txnTestSetup(t, map[string]string{
+		"mwan3.wan.metric": "1",
+	})
+	c := New(staticMembers("wan"))
+	if err := c.Switch("ghost"); err == nil {
+		t.Fatal("switching to an unknown member did not error")
+	}
+	if f.commits != 0 {
+		t.Errorf("unknown member caused %d commits", f.commits)
+	}
+	if intentOnDisk(t) != nil {
+		t.Error("unknown member wrote an intent")
+	}
+}
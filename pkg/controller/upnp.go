@@ -0,0 +1,90 @@
+package controller
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/uci"
+)
+
+// UPnP/NAT-PMP leases are handed out by miniupnpd against the WAN address
+// that was active when a console or P2P app asked for them; after a
+// switch they keep pointing at the old one. Restarting miniupnpd expires
+// every lease and sends fresh SSDP announcements, so well-behaved clients
+// re-map against the new WAN within their next renewal instead of waiting
+// for the old lease to time out. The lease table is snapshotted first so
+// the caller can tell the user which services were affected.
+
+// upnpLeasesPath is miniupnpd's lease file; a variable for tests.
+var upnpLeasesPath = "/var/upnp.leases"
+
+// initdExec runs an init.d service action; a variable so tests can stub it.
+var initdExec = func(service, action string) error {
+	out, err := exec.Command("/etc/init.d/"+service, action).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("/etc/init.d/%s %s: %v: %s", service, action, err, out)
+	}
+	return nil
+}
+
+// UPnPLease is one entry of the miniupnpd lease file
+// (proto:extport:intaddr:intport:expiry:description).
+type UPnPLease struct {
+	Proto   string `json:"proto"`
+	ExtPort string `json:"ext_port"`
+	IntAddr string `json:"int_addr"`
+	IntPort string `json:"int_port"`
+	Desc    string `json:"desc,omitempty"`
+}
+
+// UPnPMigrator expires and re-announces UPnP/NAT-PMP leases after a
+// switch.
+type UPnPMigrator struct{}
+
+// NewUPnPFromUCI returns the migrator when starfail.upnp.migrate_leases
+// is on, nil otherwise.
+func NewUPnPFromUCI() *UPnPMigrator {
+	if !uci.GetBool("starfail.upnp.migrate_leases", false) {
+		return nil
+	}
+	return &UPnPMigrator{}
+}
+
+// Migrate snapshots the current leases, then restarts miniupnpd so they
+// are expired and re-announced on the new WAN. The snapshot is returned
+// so the caller can surface which services need reconnection; failures
+// are logged, never fatal.
+func (u *UPnPMigrator) Migrate(member string) []UPnPLease {
+	leases := readUPnPLeases()
+	if err := initdExec("miniupnpd", "restart"); err != nil {
+		log.Printf("controller: upnp: %v", err)
+		return nil
+	}
+	log.Printf("controller: upnp: re-announced %d lease(s) after switch to %s", len(leases), member)
+	return leases
+}
+
+// readUPnPLeases parses the miniupnpd lease file; a missing file (UPnP
+// idle or disabled) yields no leases.
+func readUPnPLeases() []UPnPLease {
+	raw, err := os.ReadFile(upnpLeasesPath)
+	if err != nil {
+		return nil
+	}
+	var out []UPnPLease
+	for _, line := range strings.Split(string(raw), "\n") {
+		parts := strings.SplitN(strings.TrimSpace(line), ":", 6)
+		if len(parts) < 5 || parts[0] == "" {
+			continue
+		}
+		l := UPnPLease{Proto: parts[0], ExtPort: parts[1], IntAddr: parts[2], IntPort: parts[3]}
+		if len(parts) == 6 {
+			l.Desc = parts[5]
+		}
+		out = append(out, l)
+	}
+	return out
+}
@@ -0,0 +1,56 @@
+package controller
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUPnPMigrate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "upnp.leases")
+	content := "TCP:25565:192.168.1.50:25565:1767225600:minecraft\n" +
+		"UDP:3074:192.168.1.60:3074:1767225600:xbox live\n" +
+		"garbage line\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var restarts []string
+	origPath, origInitd := upnpLeasesPath, initdExec
+	upnpLeasesPath = path
+	initdExec = func(service, action string) error {
+		restarts = append(restarts, service+" "+action)
+		return nil
+	}
+	t.Cleanup(func() { upnpLeasesPath, initdExec = origPath, origInitd })
+
+	leases := (&UPnPMigrator{}).Migrate("cellular")
+	if len(restarts) != 1 || restarts[0] != "miniupnpd restart" {
+		t.Errorf("restarts = %v", restarts)
+	}
+	if len(leases) != 2 {
+		t.Fatalf("leases = %+v, want 2", leases)
+	}
+	if leases[0].Proto != "TCP" || leases[0].ExtPort != "25565" || leases[0].Desc != "minecraft" {
+		t.Errorf("lease[0] = %+v", leases[0])
+	}
+	if leases[1].IntAddr != "192.168.1.60" || leases[1].Desc != "xbox live" {
+		t.Errorf("lease[1] = %+v", leases[1])
+	}
+}
+
+func TestUPnPMigrateNoLeaseFile(t *testing.T) {
+	restarted := 0
+	origPath, origInitd := upnpLeasesPath, initdExec
+	upnpLeasesPath = filepath.Join(t.TempDir(), "missing")
+	initdExec = func(string, string) error { restarted++; return nil }
+	t.Cleanup(func() { upnpLeasesPath, initdExec = origPath, origInitd })
+
+	if leases := (&UPnPMigrator{}).Migrate("cellular"); len(leases) != 0 {
+		t.Errorf("leases = %+v, want none", leases)
+	}
+	// miniupnpd is still restarted: clients may hold leases the file
+	// no longer shows.
+	if restarted != 1 {
+		t.Errorf("restarts = %d, want 1", restarted)
+	}
+}
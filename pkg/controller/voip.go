@@ -0,0 +1,73 @@
+package controller
+
+import (
+	"fmt"
+	"log"
+	"os/exec"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/uci"
+)
+
+// VoIP is the most common user-visible casualty of a WAN switch: SIP
+// registrations and RTP conntrack entries keep pointing at the old WAN
+// address until they expire. These hooks nudge things along right after a
+// switch — flushing the conntrack table so NAT entries rebuild on the new
+// WAN, and running a user-provided script that re-registers SIP accounts.
+
+// conntrackExec runs the conntrack binary; a variable so tests can stub it.
+var conntrackExec = func(args ...string) error {
+	out, err := exec.Command("conntrack", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("conntrack %v: %v: %s", args, err, out)
+	}
+	return nil
+}
+
+// scriptExec runs a user script through the shell; a variable for tests.
+var scriptExec = func(script, member string) error {
+	out, err := exec.Command("/bin/sh", script, member).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s: %v: %s", script, err, out)
+	}
+	return nil
+}
+
+// VoipHooks holds the configured post-switch VoIP actions.
+type VoipHooks struct {
+	// FlushConntrack drops the conntrack table after a switch so UDP
+	// sessions (SIP, RTP) rebuild against the new WAN immediately.
+	FlushConntrack bool
+
+	// Script, when set, runs after a switch with the new active member as
+	// its argument (e.g. a script that re-registers asterisk SIP peers).
+	Script string
+}
+
+// NewVoipHooks returns the hooks configured under starfail.voip, or nil
+// when none are enabled.
+func NewVoipHooks() *VoipHooks {
+	h := &VoipHooks{
+		FlushConntrack: uci.GetBool("starfail.voip.flush_conntrack", false),
+	}
+	h.Script, _ = uci.Get("starfail.voip.script")
+	if !h.FlushConntrack && h.Script == "" {
+		return nil
+	}
+	return h
+}
+
+// Run executes the configured actions; failures are logged, never fatal.
+func (h *VoipHooks) Run(member string) {
+	if h.FlushConntrack {
+		if err := conntrackExec("-F"); err != nil {
+			log.Printf("controller: voip conntrack flush: %v", err)
+		} else {
+			log.Printf("controller: voip: flushed conntrack after switch to %s", member)
+		}
+	}
+	if h.Script != "" {
+		if err := scriptExec(h.Script, member); err != nil {
+			log.Printf("controller: voip script: %v", err)
+		}
+	}
+}
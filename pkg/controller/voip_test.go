@@ -0,0 +1,39 @@
+package controller
+
+import (
+	"testing"
+)
+
+func TestVoipHooksRun(t *testing.T) {
+	var flushed int
+	var scriptRuns []string
+	origCT, origScript := conntrackExec, scriptExec
+	conntrackExec = func(args ...string) error {
+		if len(args) == 1 && args[0] == "-F" {
+			flushed++
+		}
+		return nil
+	}
+	scriptExec = func(script, member string) error {
+		scriptRuns = append(scriptRuns, script+" "+member)
+		return nil
+	}
+	t.Cleanup(func() { conntrackExec, scriptExec = origCT, origScript })
+
+	h := &VoipHooks{FlushConntrack: true, Script: "/etc/starfail/sip-rekick.sh"}
+	h.Run("starlink")
+
+	if flushed != 1 {
+		t.Errorf("conntrack flushes = %d, want 1", flushed)
+	}
+	if len(scriptRuns) != 1 || scriptRuns[0] != "/etc/starfail/sip-rekick.sh starlink" {
+		t.Errorf("script runs = %v", scriptRuns)
+	}
+
+	// Neither action configured: Run does nothing.
+	flushed, scriptRuns = 0, nil
+	(&VoipHooks{}).Run("starlink")
+	if flushed != 0 || len(scriptRuns) != 0 {
+		t.Errorf("unconfigured hooks ran: flushes=%d scripts=%v", flushed, scriptRuns)
+	}
+}
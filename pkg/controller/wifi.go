@@ -0,0 +1,207 @@
+package controller
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/secrets"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/ubus"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/uci"
+)
+
+// wifiScanInterval is how often the onboarder looks for known SSIDs.
+const wifiScanInterval = 60 * time.Second
+
+// wifiMissLimit is how many consecutive scans a joined SSID may be absent
+// before the member is torn down; single missed beacons must not flap.
+const wifiMissLimit = 3
+
+// WifiNetwork is one known wifi-as-WAN network (campground, marina, ...)
+// declared as a "config wifi_network" section in the starfail UCI package:
+//
+//	config wifi_network 'harbor'
+//	        option ssid 'Marina-Guest'
+//	        option key 'secret'
+type WifiNetwork struct {
+	Name string // UCI section name, also used as the member name
+	SSID string
+	Key  string
+}
+
+// LoadWifiNetworks reads the configured wifi_network sections.
+func LoadWifiNetworks() []WifiNetwork {
+	var out []WifiNetwork
+	for _, sec := range uci.Sections("starfail", "wifi_network") {
+		ssid, ok := uci.Get("starfail." + sec + ".ssid")
+		if !ok || ssid == "" {
+			continue
+		}
+		key, _ := secrets.Get("starfail." + sec + ".key")
+		out = append(out, WifiNetwork{Name: sec, SSID: ssid, Key: key})
+	}
+	return out
+}
+
+// WifiOnboarder joins known SSIDs when their beacons appear in scan
+// results and tears the connection down again when they stay gone.
+type WifiOnboarder struct {
+	ctl      *Controller
+	networks []WifiNetwork
+	device   string // radio device for iwinfo scans, e.g. wlan0
+	iface    string // logical interface the sta config uses
+
+	// OnJoin and OnLeave let main add/remove the engine member; the
+	// controller cannot import the engine package directly.
+	OnJoin  func(name, iface string)
+	OnLeave func(name string)
+
+	joined string // section name of the currently joined network
+	missed int    // consecutive scans without the joined SSID
+}
+
+// NewWifiOnboarder returns an onboarder for the configured networks.
+// Device and interface come from starfail.wifi.device / starfail.wifi.iface
+// and default to wlan0 / wwan.
+func NewWifiOnboarder(ctl *Controller, networks []WifiNetwork) *WifiOnboarder {
+	device, ok := uci.Get("starfail.wifi.device")
+	if !ok || device == "" {
+		device = "wlan0"
+	}
+	iface, ok := uci.Get("starfail.wifi.iface")
+	if !ok || iface == "" {
+		iface = "wwan"
+	}
+	return &WifiOnboarder{ctl: ctl, networks: networks, device: device, iface: iface}
+}
+
+// Run scans periodically until ctx is cancelled.
+func (o *WifiOnboarder) Run(ctx context.Context) {
+	ticker := time.NewTicker(wifiScanInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			o.tick()
+		}
+	}
+}
+
+// tick runs one scan-and-decide round.
+func (o *WifiOnboarder) tick() {
+	visible, err := o.scan()
+	if err != nil {
+		log.Printf("wifi: scan: %v", err)
+		return
+	}
+	if o.joined != "" {
+		if net := o.network(o.joined); net != nil && visible[net.SSID] {
+			o.missed = 0
+			return
+		}
+		o.missed++
+		if o.missed >= wifiMissLimit {
+			o.leave()
+		}
+		return
+	}
+	for _, net := range o.networks {
+		if visible[net.SSID] {
+			o.join(net)
+			return
+		}
+	}
+}
+
+// scan returns the set of SSIDs currently in range.
+func (o *WifiOnboarder) scan() (map[string]bool, error) {
+	var resp struct {
+		Results []struct {
+			SSID string `json:"ssid"`
+		} `json:"results"`
+	}
+	if err := ubus.Call("iwinfo", "scan", map[string]any{"device": o.device}, &resp); err != nil {
+		return nil, err
+	}
+	out := make(map[string]bool, len(resp.Results))
+	for _, r := range resp.Results {
+		out[r.SSID] = true
+	}
+	return out, nil
+}
+
+// join writes the sta wireless config, brings it up, and hands the new
+// member to OnJoin for roster registration.
+func (o *WifiOnboarder) join(net WifiNetwork) {
+	log.Printf("wifi: joining %q (%s)", net.SSID, net.Name)
+	sec := "wireless.starfail_sta"
+	settings := map[string]string{
+		sec:               "wifi-iface",
+		sec + ".device":   "radio0",
+		sec + ".mode":     "sta",
+		sec + ".network":  o.iface,
+		sec + ".ssid":     net.SSID,
+		sec + ".key":      net.Key,
+		sec + ".disabled": "0",
+	}
+	if net.Key == "" {
+		settings[sec+".encryption"] = "none"
+		delete(settings, sec+".key")
+	} else {
+		settings[sec+".encryption"] = "psk2"
+	}
+	for k, v := range settings {
+		if err := uci.Set(k, v); err != nil {
+			log.Printf("wifi: set %s: %v", k, err)
+			return
+		}
+	}
+	uci.Commits.Request("wireless")
+	if err := reloadWireless(); err != nil {
+		log.Printf("wifi: reload: %v", err)
+		return
+	}
+	o.joined = net.Name
+	o.missed = 0
+	// OnJoin adds the member to the engine's roster, which is also the
+	// controller's live member source — no separate registration here.
+	if o.OnJoin != nil {
+		o.OnJoin(net.Name, o.iface)
+	}
+}
+
+// leave disables the sta config and deregisters the member.
+func (o *WifiOnboarder) leave() {
+	name := o.joined
+	log.Printf("wifi: %s out of range, tearing down", name)
+	if err := uci.Set("wireless.starfail_sta.disabled", "1"); err != nil {
+		log.Printf("wifi: disable sta: %v", err)
+	} else {
+		uci.Commits.Request("wireless")
+		if err := reloadWireless(); err != nil {
+			log.Printf("wifi: reload: %v", err)
+		}
+	}
+	o.joined = ""
+	o.missed = 0
+	if o.OnLeave != nil {
+		o.OnLeave(name)
+	}
+}
+
+// network looks up a known network by section name.
+func (o *WifiOnboarder) network(name string) *WifiNetwork {
+	for i := range o.networks {
+		if o.networks[i].Name == name {
+			return &o.networks[i]
+		}
+	}
+	return nil
+}
+
+// reloadWireless applies committed wireless changes.
+func reloadWireless() error {
+	return ubus.Call("network.wireless", "reload", nil, nil)
+}
@@ -0,0 +1,81 @@
+package controller
+
+import (
+	"fmt"
+	"log"
+	"os/exec"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/uci"
+)
+
+// After a switch the WAN source address changes, but long-lived WireGuard
+// tunnels keep sending from the old one until a keepalive times out. The
+// refresher re-sets each configured peer's endpoint, which makes wg
+// re-resolve the hostname and re-bind the tunnel immediately.
+
+// wgExec runs the wg binary; a variable so tests can stub it.
+var wgExec = func(args ...string) error {
+	out, err := exec.Command("wg", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("wg %v: %v: %s", args, err, out)
+	}
+	return nil
+}
+
+// WireGuardRefresher re-kicks a fixed set of WireGuard interfaces.
+type WireGuardRefresher struct {
+	Ifaces []string
+}
+
+// NewWireGuardRefresher returns a refresher for the interfaces listed in
+// starfail.wireguard.ifaces, or nil when none are configured.
+func NewWireGuardRefresher() *WireGuardRefresher {
+	ifaces := uci.GetList("starfail.wireguard.ifaces")
+	if len(ifaces) == 0 {
+		return nil
+	}
+	return &WireGuardRefresher{Ifaces: ifaces}
+}
+
+// wgPeer is one peer read from the netifd wireguard config.
+type wgPeer struct {
+	publicKey    string
+	endpointHost string
+	endpointPort string
+}
+
+// peers reads the peers of one wg interface from UCI (netifd stores them
+// as wireguard_<iface> sections in the network package).
+func peers(iface string) []wgPeer {
+	var out []wgPeer
+	for _, sec := range uci.Sections("network", "wireguard_"+iface) {
+		p := wgPeer{}
+		p.publicKey, _ = uci.Get("network." + sec + ".public_key")
+		p.endpointHost, _ = uci.Get("network." + sec + ".endpoint_host")
+		p.endpointPort, _ = uci.Get("network." + sec + ".endpoint_port")
+		if p.publicKey == "" || p.endpointHost == "" {
+			continue // peers without a fixed endpoint cannot be re-kicked
+		}
+		if p.endpointPort == "" {
+			p.endpointPort = "51820"
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+// Refresh re-sets every configured peer endpoint. Setting the endpoint
+// makes wg resolve the hostname again and start using the current source
+// address, so tunnels recover in seconds instead of keepalive timeouts.
+func (r *WireGuardRefresher) Refresh() {
+	for _, iface := range r.Ifaces {
+		for _, p := range peers(iface) {
+			endpoint := p.endpointHost + ":" + p.endpointPort
+			if err := wgExec("set", iface, "peer", p.publicKey, "endpoint", endpoint); err != nil {
+				log.Printf("controller: wireguard refresh %s: %v", iface, err)
+				continue
+			}
+			log.Printf("controller: wireguard %s: re-resolved endpoint %s", iface, endpoint)
+		}
+	}
+}
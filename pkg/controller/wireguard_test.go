@@ -0,0 +1,51 @@
+package controller
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/uci"
+)
+
+func TestWireGuardRefresh(t *testing.T) {
+	values := map[string]string{
+		"network.peer1.public_key":    "PUBKEY1",
+		"network.peer1.endpoint_host": "vpn.example.com",
+		"network.peer1.endpoint_port": "51821",
+		"network.peer2.public_key":    "PUBKEY2", // no endpoint_host: skipped
+	}
+	origUCI := uci.Exec
+	uci.Exec = func(args ...string) (string, error) {
+		if len(args) > 0 && args[0] == "-q" {
+			args = args[1:]
+		}
+		switch args[0] {
+		case "get":
+			if v, ok := values[args[1]]; ok {
+				return v, nil
+			}
+			return "", fmt.Errorf("uci: entry not found")
+		case "show":
+			return "network.peer1=wireguard_wg0\nnetwork.peer2=wireguard_wg0\n", nil
+		}
+		return "", fmt.Errorf("unhandled %v", args)
+	}
+	t.Cleanup(func() { uci.Exec = origUCI })
+
+	var calls []string
+	origWG := wgExec
+	wgExec = func(args ...string) error {
+		calls = append(calls, strings.Join(args, " "))
+		return nil
+	}
+	t.Cleanup(func() { wgExec = origWG })
+
+	r := &WireGuardRefresher{Ifaces: []string{"wg0"}}
+	r.Refresh()
+
+	want := []string{"set wg0 peer PUBKEY1 endpoint vpn.example.com:51821"}
+	if len(calls) != len(want) || calls[0] != want[0] {
+		t.Errorf("wg calls = %v, want %v", calls, want)
+	}
+}
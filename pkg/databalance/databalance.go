@@ -0,0 +1,173 @@
+// Package databalance periodically queries how much data allowance remains
+// on each SIM, through pluggable sources: USSD codes via gsmctl for
+// carriers that support them, or carrier REST APIs. Balances feed
+// telemetry and low-balance notifications, and later the data-cap budget
+// logic in the decision engine.
+package databalance
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/notifications"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/telemetry"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/uci"
+)
+
+// Balance is one data-balance reading.
+type Balance struct {
+	SIM         string    `json:"sim"`
+	RemainingMB float64   `json:"remaining_mb"`
+	Raw         string    `json:"raw,omitempty"` // original carrier text for debugging
+	QueriedAt   time.Time `json:"queried_at"`
+}
+
+// Plugin queries one kind of balance source.
+type Plugin interface {
+	// Name is the value matched against the sim section's plugin option.
+	Name() string
+	// Query fetches the balance for one SIM.
+	Query(ctx context.Context, sim SIMConfig) (*Balance, error)
+}
+
+// SIMConfig is one "config sim" UCI section.
+type SIMConfig struct {
+	Name           string  // section name
+	Plugin         string  // ussd | http
+	USSDCode       string  // e.g. *123#
+	APIURL         string  // carrier REST endpoint returning JSON
+	IntervalS      int     // query interval
+	LowWatermarkMB float64 // notify when the balance drops below this
+}
+
+// LoadSIMs reads the sim sections of the starfail package.
+func LoadSIMs() []SIMConfig {
+	var sims []SIMConfig
+	for _, name := range uci.Sections("starfail", "sim") {
+		get := func(opt, def string) string {
+			if v, ok := uci.Get("starfail." + name + "." + opt); ok {
+				return v
+			}
+			return def
+		}
+		sims = append(sims, SIMConfig{
+			Name:           name,
+			Plugin:         get("plugin", "ussd"),
+			USSDCode:       get("ussd_code", ""),
+			APIURL:         get("api_url", ""),
+			IntervalS:      uci.GetInt("starfail."+name+".interval", 3600),
+			LowWatermarkMB: uci.GetFloat("starfail."+name+".low_watermark_mb", 500),
+		})
+	}
+	return sims
+}
+
+// Scheduler runs balance queries for every configured SIM.
+type Scheduler struct {
+	mu       sync.RWMutex
+	plugins  map[string]Plugin
+	store    *telemetry.Store
+	notifier *notifications.Notifier
+	latest   map[string]Balance
+	notified map[string]bool // low-balance notification latch per SIM
+}
+
+// NewScheduler registers the built-in plugins.
+func NewScheduler(store *telemetry.Store, notifier *notifications.Notifier) *Scheduler {
+	s := &Scheduler{
+		plugins:  make(map[string]Plugin),
+		store:    store,
+		notifier: notifier,
+		latest:   make(map[string]Balance),
+		notified: make(map[string]bool),
+	}
+	s.RegisterPlugin(&USSDPlugin{})
+	s.RegisterPlugin(&HTTPPlugin{})
+	return s
+}
+
+// RegisterPlugin adds a balance source; external packages can add carrier
+// specific plugins before Run.
+func (s *Scheduler) RegisterPlugin(p Plugin) {
+	s.mu.Lock()
+	s.plugins[p.Name()] = p
+	s.mu.Unlock()
+}
+
+// Latest returns the most recent balance per SIM.
+func (s *Scheduler) Latest() map[string]Balance {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make(map[string]Balance, len(s.latest))
+	for k, v := range s.latest {
+		out[k] = v
+	}
+	return out
+}
+
+// Run starts one query loop per SIM and blocks until ctx is cancelled.
+func (s *Scheduler) Run(ctx context.Context, sims []SIMConfig) {
+	var wg sync.WaitGroup
+	for _, sim := range sims {
+		sim := sim
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.loop(ctx, sim)
+		}()
+	}
+	wg.Wait()
+}
+
+func (s *Scheduler) loop(ctx context.Context, sim SIMConfig) {
+	ticker := time.NewTicker(time.Duration(sim.IntervalS) * time.Second)
+	defer ticker.Stop()
+	s.query(ctx, sim) // once at startup
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.query(ctx, sim)
+		}
+	}
+}
+
+func (s *Scheduler) query(ctx context.Context, sim SIMConfig) {
+	s.mu.RLock()
+	p, ok := s.plugins[sim.Plugin]
+	s.mu.RUnlock()
+	if !ok {
+		log.Printf("databalance: sim %s: unknown plugin %q", sim.Name, sim.Plugin)
+		return
+	}
+	qctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	bal, err := p.Query(qctx, sim)
+	if err != nil {
+		log.Printf("databalance: sim %s: %v", sim.Name, err)
+		return
+	}
+	bal.SIM = sim.Name
+	bal.QueriedAt = time.Now()
+
+	s.mu.Lock()
+	s.latest[sim.Name] = *bal
+	low := bal.RemainingMB < sim.LowWatermarkMB
+	shouldNotify := low && !s.notified[sim.Name]
+	s.notified[sim.Name] = low
+	s.mu.Unlock()
+
+	s.store.AddEvent(telemetry.Event{
+		Timestamp: bal.QueriedAt, Type: "data_balance", Member: sim.Name,
+		Details: map[string]any{"remaining_mb": bal.RemainingMB},
+	})
+	if shouldNotify && s.notifier != nil {
+		s.notifier.SendNotification("Data balance low",
+			fmt.Sprintf("SIM %s has %.0f MB remaining", sim.Name, bal.RemainingMB),
+			notifications.PriorityNormal)
+	}
+}
@@ -0,0 +1,107 @@
+package databalance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/collector/cellular"
+)
+
+// USSDPlugin sends a carrier USSD code through the modem and extracts the
+// first data quantity from the free-text reply.
+type USSDPlugin struct{}
+
+// Name implements Plugin.
+func (p *USSDPlugin) Name() string { return "ussd" }
+
+// ussdAmountRe matches "5.2 GB", "850MB", "1,5 GB" and similar.
+var ussdAmountRe = regexp.MustCompile(`([0-9]+(?:[.,][0-9]+)?)\s*(GB|MB)`)
+
+// Query implements Plugin.
+func (p *USSDPlugin) Query(ctx context.Context, sim SIMConfig) (*Balance, error) {
+	if sim.USSDCode == "" {
+		return nil, fmt.Errorf("sim %s: ussd_code not set", sim.Name)
+	}
+	out, err := cellular.Exec(ctx, "-U", sim.USSDCode)
+	if err != nil {
+		return nil, fmt.Errorf("ussd %s: %w", sim.USSDCode, err)
+	}
+	mb, ok := ParseUSSDBalance(out)
+	if !ok {
+		return nil, fmt.Errorf("no data amount found in USSD reply %q", out)
+	}
+	return &Balance{RemainingMB: mb, Raw: out}, nil
+}
+
+// ParseUSSDBalance extracts the first MB/GB quantity from carrier text,
+// tolerating decimal commas. Exported for carrier-specific reply tests.
+func ParseUSSDBalance(text string) (float64, bool) {
+	m := ussdAmountRe.FindStringSubmatch(text)
+	if m == nil {
+		return 0, false
+	}
+	v, err := strconv.ParseFloat(strings.ReplaceAll(m[1], ",", "."), 64)
+	if err != nil {
+		return 0, false
+	}
+	if m[2] == "GB" {
+		v *= 1024
+	}
+	return v, true
+}
+
+// HTTPPlugin fetches a carrier REST endpoint expected to return JSON with
+// either "remaining_mb" or "remaining_bytes".
+type HTTPPlugin struct {
+	Client *http.Client
+}
+
+// Name implements Plugin.
+func (p *HTTPPlugin) Name() string { return "http" }
+
+// Query implements Plugin.
+func (p *HTTPPlugin) Query(ctx context.Context, sim SIMConfig) (*Balance, error) {
+	if sim.APIURL == "" {
+		return nil, fmt.Errorf("sim %s: api_url not set", sim.Name)
+	}
+	client := p.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, sim.APIURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("carrier API returned %s", resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil {
+		return nil, err
+	}
+	var raw struct {
+		RemainingMB    *float64 `json:"remaining_mb"`
+		RemainingBytes *float64 `json:"remaining_bytes"`
+	}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, fmt.Errorf("carrier API parse: %w", err)
+	}
+	switch {
+	case raw.RemainingMB != nil:
+		return &Balance{RemainingMB: *raw.RemainingMB, Raw: string(body)}, nil
+	case raw.RemainingBytes != nil:
+		return &Balance{RemainingMB: *raw.RemainingBytes / (1024 * 1024), Raw: string(body)}, nil
+	}
+	return nil, fmt.Errorf("carrier API reply has no remaining_mb/remaining_bytes")
+}
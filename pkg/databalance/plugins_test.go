@@ -0,0 +1,27 @@
+package databalance
+
+import "testing"
+
+func TestParseUSSDBalance(t *testing.T) {
+	tests := []struct {
+		reply  string
+		wantMB float64
+		wantOK bool
+	}{
+		{"Du har 5.2 GB kvar att surfa for.", 5324.8, true},
+		{"Remaining data: 850MB valid until 2026-09-30", 850, true},
+		{"Sie haben noch 1,5 GB Datenvolumen.", 1536, true},
+		{"Your balance is 12.50 EUR", 0, false},
+		{"", 0, false},
+	}
+	for _, tt := range tests {
+		mb, ok := ParseUSSDBalance(tt.reply)
+		if ok != tt.wantOK {
+			t.Errorf("ParseUSSDBalance(%q) ok = %v, want %v", tt.reply, ok, tt.wantOK)
+			continue
+		}
+		if ok && (mb < tt.wantMB-0.01 || mb > tt.wantMB+0.01) {
+			t.Errorf("ParseUSSDBalance(%q) = %v MB, want %v", tt.reply, mb, tt.wantMB)
+		}
+	}
+}
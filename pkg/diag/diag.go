@@ -0,0 +1,97 @@
+// Package diag exposes a small whitelist of read-only troubleshooting
+// actions over ubus, so remote dashboards can gather the usual first-look
+// data (mwan3 state, modem signal, dish status) without anyone handing
+// out SSH access. Actions are enumerated, not parameterized: there is no
+// way to run an arbitrary command through this package.
+package diag
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/collector/cellular"
+)
+
+// mwan3Exec runs `mwan3 status`; a variable so tests can stub it.
+var mwan3Exec = func(ctx context.Context) (string, error) {
+	out, err := exec.CommandContext(ctx, "mwan3", "status").Output()
+	return string(out), err
+}
+
+// Runner dispatches whitelisted diagnostic actions.
+type Runner struct {
+	// Dish, when set, backs the dish_status action (wired from the
+	// Starlink collector when one is configured).
+	Dish func(ctx context.Context) (any, error)
+}
+
+// New returns an empty Runner; optional actions are attached by the
+// caller setting the corresponding hook.
+func New() *Runner { return &Runner{} }
+
+// Actions lists the currently available action names, sorted.
+func (r *Runner) Actions() []string {
+	actions := []string{"cell_signal", "mwan3_status"}
+	if r.Dish != nil {
+		actions = append(actions, "dish_status")
+	}
+	sort.Strings(actions)
+	return actions
+}
+
+// Run executes one whitelisted action and returns its structured result.
+func (r *Runner) Run(ctx context.Context, action string) (any, error) {
+	switch action {
+	case "mwan3_status":
+		out, err := mwan3Exec(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("mwan3 status: %w", err)
+		}
+		return map[string]any{"interfaces": parseMwan3Status(out)}, nil
+	case "cell_signal":
+		return cellular.SignalSnapshot(ctx), nil
+	case "dish_status":
+		if r.Dish == nil {
+			return nil, fmt.Errorf("no starlink collector configured")
+		}
+		return r.Dish(ctx)
+	default:
+		return nil, fmt.Errorf("unknown action %q (available: %s)",
+			action, strings.Join(r.Actions(), ", "))
+	}
+}
+
+// Mwan3Interface is one interface line from `mwan3 status`.
+type Mwan3Interface struct {
+	Name     string `json:"name"`
+	Status   string `json:"status"`             // online, offline, disabled, ...
+	Tracking string `json:"tracking,omitempty"` // active, down, paused, ...
+}
+
+// parseMwan3Status extracts the per-interface lines; mwan3 versions vary
+// in the surrounding sections (policies, rules) so everything else is
+// ignored. Lines look like:
+//
+//	interface wan is online 00h:12m:34s, uptime 01h:02m:03s and tracking is active
+//	interface mob1s1a1 is offline and tracking is down
+func parseMwan3Status(out string) []Mwan3Interface {
+	var ifaces []Mwan3Interface
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 || fields[0] != "interface" || fields[2] != "is" {
+			continue
+		}
+		entry := Mwan3Interface{Name: fields[1], Status: strings.TrimSuffix(fields[3], ",")}
+		for i := 0; i+2 < len(fields); i++ {
+			if fields[i] == "tracking" && fields[i+1] == "is" {
+				entry.Tracking = fields[i+2]
+				break
+			}
+		}
+		ifaces = append(ifaces, entry)
+	}
+	return ifaces
+}
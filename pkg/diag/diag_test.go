@@ -0,0 +1,105 @@
+package diag
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/collector/cellular"
+)
+
+func TestRunMwan3Status(t *testing.T) {
+	old := mwan3Exec
+	defer func() { mwan3Exec = old }()
+	mwan3Exec = func(context.Context) (string, error) {
+		return "Interface status:\n" +
+			" interface wan is online 00h:12m:34s, uptime 01h:02m:03s and tracking is active\n" +
+			" interface mob1s1a1 is offline and tracking is down\n" +
+			" interface wg0 is disabled\n" +
+			"Current ipv4 policies:\n" +
+			"default: wan (100%)\n", nil
+	}
+	out, err := New().Run(context.Background(), "mwan3_status")
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	ifaces := out.(map[string]any)["interfaces"].([]Mwan3Interface)
+	want := []Mwan3Interface{
+		{Name: "wan", Status: "online", Tracking: "active"},
+		{Name: "mob1s1a1", Status: "offline", Tracking: "down"},
+		{Name: "wg0", Status: "disabled"},
+	}
+	if len(ifaces) != len(want) {
+		t.Fatalf("interfaces = %+v, want %d entries", ifaces, len(want))
+	}
+	for i := range want {
+		if ifaces[i] != want[i] {
+			t.Errorf("interface %d = %+v, want %+v", i, ifaces[i], want[i])
+		}
+	}
+}
+
+func TestRunCellSignal(t *testing.T) {
+	old := cellular.Exec
+	defer func() { cellular.Exec = old }()
+	cellular.Exec = func(_ context.Context, args ...string) (string, error) {
+		switch args[0] {
+		case "-W":
+			return "-95 dBm", nil
+		case "-t":
+			return "LTE", nil
+		default:
+			return "", fmt.Errorf("no modem")
+		}
+	}
+	out, err := New().Run(context.Background(), "cell_signal")
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	m := out.(map[string]any)
+	if m["rsrp_dbm"] != -95.0 || m["network"] != "LTE" {
+		t.Fatalf("signal = %v", m)
+	}
+	if _, ok := m["rsrq_db"]; ok {
+		t.Fatalf("failed query leaked into result: %v", m)
+	}
+}
+
+func TestRunDishStatus(t *testing.T) {
+	r := New()
+	if _, err := r.Run(context.Background(), "dish_status"); err == nil {
+		t.Fatalf("dish_status without a collector should fail")
+	}
+	r.Dish = func(context.Context) (any, error) {
+		return map[string]any{"uptime_s": 42}, nil
+	}
+	out, err := r.Run(context.Background(), "dish_status")
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if out.(map[string]any)["uptime_s"] != 42 {
+		t.Fatalf("dish status = %v", out)
+	}
+}
+
+func TestRunUnknownActionListsWhitelist(t *testing.T) {
+	_, err := New().Run(context.Background(), "rm -rf /")
+	if err == nil {
+		t.Fatalf("unknown action accepted")
+	}
+	if !strings.Contains(err.Error(), "cell_signal, mwan3_status") {
+		t.Fatalf("error should list available actions, got: %v", err)
+	}
+}
+
+func TestActionsIncludeDishOnlyWhenWired(t *testing.T) {
+	r := New()
+	if got := strings.Join(r.Actions(), ","); got != "cell_signal,mwan3_status" {
+		t.Fatalf("Actions = %s", got)
+	}
+	r.Dish = func(context.Context) (any, error) { return nil, nil }
+	if got := strings.Join(r.Actions(), ","); got != "cell_signal,dish_status,mwan3_status" {
+		t.Fatalf("Actions = %s", got)
+	}
+}
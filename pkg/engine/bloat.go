@@ -0,0 +1,119 @@
+package engine
+
+import (
+	"sort"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/metrics"
+)
+
+// Bufferbloat is inferred from latency under load: probes run every tick
+// regardless of what the user's traffic is doing, so the recent window
+// contains both idle and loaded readings. The 5th percentile of the window
+// is the closest thing to the path's idle RTT a live router sees; how far
+// the current reading sits above that floor is the bloat delta. A large
+// delta on an otherwise lossless link is the signature of a saturated
+// uplink queue — the usual way Starlink video calls die.
+
+// bloatMinSamples is how much window history the floor estimate needs
+// before grading starts.
+const bloatMinSamples = 10
+
+// bloatSaturatedMS is the delta past which the path counts as saturated.
+const bloatSaturatedMS = 150.0
+
+// bloatMaxPenalty caps the score impact of bufferbloat.
+const bloatMaxPenalty = 15.0
+
+// bloatDampMaxLossPct separates local saturation from WAN failure: a
+// saturated local queue inflates latency but still delivers packets,
+// while a failing WAN drops them. Loss past this bound means the spike
+// is not (only) the user's upload, and damping must not mask it.
+const bloatDampMaxLossPct = 2.0
+
+// bloatDampFactor is how far a saturation-caused score drop is pulled
+// back toward the smoothed score.
+const bloatDampFactor = 0.6
+
+// BloatInfo is one member's current bufferbloat assessment.
+type BloatInfo struct {
+	FloorMS   float64 `json:"floor_ms"`         // estimated idle RTT
+	DeltaMS   float64 `json:"delta_ms"`         // current latency above the floor
+	Grade     string  `json:"grade"`            // A (none) .. F (unusable)
+	Saturated bool    `json:"saturated"`        // delta past bloatSaturatedMS
+	Damped    bool    `json:"damped,omitempty"` // scoring damped this tick (see dampSaturation)
+}
+
+// bloatGrade maps a delta to the letter scale used by common bufferbloat
+// test sites, so users can compare against numbers they know.
+func bloatGrade(deltaMS float64) string {
+	switch {
+	case deltaMS < 30:
+		return "A"
+	case deltaMS < 75:
+		return "B"
+	case deltaMS < 200:
+		return "C"
+	case deltaMS < 400:
+		return "D"
+	default:
+		return "F"
+	}
+}
+
+// bloatPenalty scales from 0 at a 30ms delta to bloatMaxPenalty at 400ms.
+func bloatPenalty(b BloatInfo) float64 {
+	if b.Grade == "" || b.DeltaMS <= 30 {
+		return 0
+	}
+	f := (b.DeltaMS - 30) / (400 - 30)
+	if f > 1 {
+		f = 1
+	}
+	return f * bloatMaxPenalty
+}
+
+// updateBloat recomputes m.Bloat from the stored window plus the current
+// sample. Members without enough history keep a zero BloatInfo.
+func (e *Engine) updateBloat(m *Member, sample *metrics.Metrics) {
+	if sample.LatencyMS == nil {
+		return
+	}
+	var lats []float64
+	for _, s := range e.store.Samples(m.Name, 120) {
+		if s.Metrics != nil && s.Metrics.LatencyMS != nil {
+			lats = append(lats, float64(*s.Metrics.LatencyMS))
+		}
+	}
+	if len(lats) < bloatMinSamples {
+		m.Bloat = BloatInfo{}
+		return
+	}
+	sort.Float64s(lats)
+	floor := lats[len(lats)/20]
+	delta := float64(*sample.LatencyMS) - floor
+	if delta < 0 {
+		delta = 0
+	}
+	m.Bloat = BloatInfo{
+		FloorMS:   floor,
+		DeltaMS:   delta,
+		Grade:     bloatGrade(delta),
+		Saturated: delta >= bloatSaturatedMS,
+	}
+}
+
+// dampSaturation pulls a saturation-caused instant-score drop back
+// toward the smoothed score. The upload-saturation detector is the
+// discriminator: a big bloat delta with near-zero loss is the user's own
+// traffic filling the queue, not the WAN failing, and a few ticks of it
+// must not push the member through the fail threshold. Loss, an
+// improving score, or an unsaturated path leave the score untouched.
+func dampSaturation(b BloatInfo, sample *metrics.Metrics, instant, ewma float64) (float64, bool) {
+	if !b.Saturated || instant >= ewma {
+		return instant, false
+	}
+	if sample.LossPercent != nil && float64(*sample.LossPercent) > bloatDampMaxLossPct {
+		return instant, false
+	}
+	return instant + bloatDampFactor*(ewma-instant), true
+}
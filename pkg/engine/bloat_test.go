@@ -0,0 +1,109 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/collector"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/controller"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/metrics"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/telemetry"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/uci"
+)
+
+func TestBloatGrade(t *testing.T) {
+	cases := []struct {
+		deltaMS float64
+		want    string
+	}{
+		{5, "A"}, {50, "B"}, {150, "C"}, {300, "D"}, {600, "F"},
+	}
+	for _, tc := range cases {
+		if got := bloatGrade(tc.deltaMS); got != tc.want {
+			t.Errorf("bloatGrade(%.0f) = %s, want %s", tc.deltaMS, got, tc.want)
+		}
+	}
+}
+
+func TestUpdateBloatDetectsSaturation(t *testing.T) {
+	cfg := &uci.Config{
+		FailScore: 40, RestoreScore: 70,
+		Members: []uci.MemberConfig{{Name: "sl", Iface: "wan", Class: "starlink", Weight: 50}},
+	}
+	ctl := controller.New(nil)
+	ctl.Noop = true
+	e := New(cfg, collector.NewRegistry(), ctl, telemetry.NewStore(t.TempDir()), nil)
+	m := e.Members()[0]
+
+	// Twenty idle readings around 50ms establish the floor.
+	for i := 0; i < 20; i++ {
+		e.store.AddSample(telemetry.Sample{
+			Member:  "sl",
+			Metrics: &metrics.Metrics{LatencyMS: metrics.Milliseconds(50).Ptr()},
+		})
+	}
+	loaded := &metrics.Metrics{LatencyMS: metrics.Milliseconds(300).Ptr()}
+	e.updateBloat(m, loaded)
+
+	if m.Bloat.FloorMS < 45 || m.Bloat.FloorMS > 55 {
+		t.Errorf("floor = %.1fms, want ~50", m.Bloat.FloorMS)
+	}
+	if m.Bloat.DeltaMS < 240 || m.Bloat.DeltaMS > 260 {
+		t.Errorf("delta = %.1fms, want ~250", m.Bloat.DeltaMS)
+	}
+	if m.Bloat.Grade != "D" {
+		t.Errorf("grade = %s, want D", m.Bloat.Grade)
+	}
+	if !m.Bloat.Saturated {
+		t.Error("saturated = false past the threshold")
+	}
+	if p := bloatPenalty(m.Bloat); p <= 0 || p > bloatMaxPenalty {
+		t.Errorf("penalty = %.1f, want within (0, %.0f]", p, bloatMaxPenalty)
+	}
+}
+
+func TestUpdateBloatNeedsHistory(t *testing.T) {
+	cfg := &uci.Config{
+		FailScore: 40, RestoreScore: 70,
+		Members: []uci.MemberConfig{{Name: "sl", Iface: "wan", Class: "starlink", Weight: 50}},
+	}
+	ctl := controller.New(nil)
+	ctl.Noop = true
+	e := New(cfg, collector.NewRegistry(), ctl, telemetry.NewStore(t.TempDir()), nil)
+	m := e.Members()[0]
+
+	e.updateBloat(m, &metrics.Metrics{LatencyMS: metrics.Milliseconds(400).Ptr()})
+	if m.Bloat.Grade != "" {
+		t.Errorf("graded %q with no history", m.Bloat.Grade)
+	}
+}
+
+func TestDampSaturationPullsTowardEWMA(t *testing.T) {
+	b := BloatInfo{Saturated: true}
+	clean := &metrics.Metrics{LossPercent: metrics.Percent(0).Ptr()}
+
+	got, damped := dampSaturation(b, clean, 30, 80)
+	if !damped {
+		t.Fatal("not damped for a lossless saturated drop")
+	}
+	if got <= 30 || got >= 80 {
+		t.Errorf("damped score = %.1f, want between instant and EWMA", got)
+	}
+}
+
+func TestDampSaturationLeavesRealFailuresAlone(t *testing.T) {
+	clean := &metrics.Metrics{LossPercent: metrics.Percent(0).Ptr()}
+	lossy := &metrics.Metrics{LossPercent: metrics.Percent(20).Ptr()}
+
+	// Loss means the spike is not (only) local saturation.
+	if got, damped := dampSaturation(BloatInfo{Saturated: true}, lossy, 30, 80); damped || got != 30 {
+		t.Errorf("lossy sample damped: got %.1f, %v", got, damped)
+	}
+	// No saturation: nothing to discount.
+	if got, damped := dampSaturation(BloatInfo{}, clean, 30, 80); damped || got != 30 {
+		t.Errorf("unsaturated sample damped: got %.1f, %v", got, damped)
+	}
+	// Improving scores pass through.
+	if got, damped := dampSaturation(BloatInfo{Saturated: true}, clean, 90, 80); damped || got != 90 {
+		t.Errorf("improving score damped: got %.1f, %v", got, damped)
+	}
+}
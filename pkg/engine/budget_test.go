@@ -0,0 +1,37 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/collector"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/controller"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/telemetry"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/uci"
+)
+
+func TestCollectBudget(t *testing.T) {
+	cases := []struct {
+		name     string
+		interval int
+		members  int
+		want     time.Duration
+	}{
+		{"split evenly", 10, 3, 2 * time.Second},
+		{"capped at max", 60, 3, maxCollectTimeout},
+		{"floored at min", 5, 4, minCollectTimeout},
+		{"no members", 10, 0, 6 * time.Second},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &uci.Config{PollIntervalS: tc.interval}
+			ctl := controller.New(nil)
+			ctl.Noop = true
+			e := New(cfg, collector.NewRegistry(), ctl, telemetry.NewStore(t.TempDir()), nil)
+			if got := e.collectBudget(tc.members); got != tc.want {
+				t.Errorf("collectBudget(%d) with %ds interval = %v, want %v",
+					tc.members, tc.interval, got, tc.want)
+			}
+		})
+	}
+}
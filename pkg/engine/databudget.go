@@ -0,0 +1,233 @@
+package engine
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/notifications"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/telemetry"
+)
+
+// Starlink mobile-priority plans sell a fixed allowance of priority data
+// per billing period; once it runs out the dish drops to deprioritized
+// service that can be worse than a flat-rate SIM. The budget tracker
+// meters bytes through the Starlink interface while it carries traffic,
+// exposes the remaining allowance, and — when enabled — lets the decision
+// loop move traffic to a flat-rate member for the rest of the period.
+
+// DefaultBudgetPath is where the period's usage persists across daemon
+// restarts. Like the rest of the state it lives on tmpfs: a full reboot
+// starts the count fresh, which under-counts rather than over-counts.
+const DefaultBudgetPath = "/tmp/starfail/budget.json"
+
+// readIfaceBytes returns the interface's cumulative rx+tx byte counters;
+// a variable so tests can stub it.
+var readIfaceBytes = func(device string) (uint64, error) {
+	var total uint64
+	for _, name := range []string{"rx_bytes", "tx_bytes"} {
+		raw, err := os.ReadFile("/sys/class/net/" + device + "/statistics/" + name)
+		if err != nil {
+			return 0, err
+		}
+		v, err := strconv.ParseUint(strings.TrimSpace(string(raw)), 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		total += v
+	}
+	return total, nil
+}
+
+// budgetState is the persisted form.
+type budgetState struct {
+	PeriodStart time.Time `json:"period_start"`
+	UsedBytes   uint64    `json:"used_bytes"`
+}
+
+// budgetTracker accumulates usage against the configured allowance. It is
+// only touched from the engine tick, so it carries no lock of its own.
+type budgetTracker struct {
+	limitBytes uint64
+	resetDay   int
+	path       string
+
+	periodStart time.Time
+	usedBytes   uint64
+	lastTotal   uint64
+	haveLast    bool
+	exhausted   bool
+}
+
+// newBudgetTracker restores a tracker from path ("" for the default);
+// persisted usage from an earlier billing period is discarded.
+func newBudgetTracker(limitGB float64, resetDay int, path string) *budgetTracker {
+	if path == "" {
+		path = DefaultBudgetPath
+	}
+	if resetDay < 1 || resetDay > 28 {
+		resetDay = 1
+	}
+	b := &budgetTracker{
+		limitBytes: uint64(limitGB * 1e9),
+		resetDay:   resetDay,
+		path:       path,
+	}
+	now := time.Now()
+	b.periodStart = b.periodFor(now)
+	if raw, err := os.ReadFile(path); err == nil {
+		var s budgetState
+		if json.Unmarshal(raw, &s) == nil && !s.PeriodStart.Before(b.periodStart) {
+			b.usedBytes = s.UsedBytes
+		}
+	}
+	b.exhausted = b.usedBytes >= b.limitBytes
+	return b
+}
+
+// periodFor returns the start of the billing period containing now.
+func (b *budgetTracker) periodFor(now time.Time) time.Time {
+	start := time.Date(now.Year(), now.Month(), b.resetDay, 0, 0, 0, 0, now.Location())
+	if start.After(now) {
+		start = start.AddDate(0, -1, 0)
+	}
+	return start
+}
+
+// observe folds one counter reading into the period's usage and reports
+// whether the allowance was exhausted by this observation. active gates
+// accumulation: while Starlink is not carrying traffic its counters still
+// move (dish telemetry, background probes) but those bytes are not
+// priority data spent on user traffic, so the delta baseline resets.
+func (b *budgetTracker) observe(total uint64, active bool, now time.Time) bool {
+	if start := b.periodFor(now); start.After(b.periodStart) {
+		b.periodStart = start
+		b.usedBytes = 0
+		b.exhausted = false
+		b.persist()
+	}
+	if !active {
+		b.haveLast = false
+		return false
+	}
+	switch {
+	case !b.haveLast:
+		b.haveLast = true
+	case total < b.lastTotal:
+		// Counter reset (interface bounce, reboot): restart the baseline.
+	default:
+		b.usedBytes += total - b.lastTotal
+		b.persist()
+	}
+	b.lastTotal = total
+	if !b.exhausted && b.usedBytes >= b.limitBytes {
+		b.exhausted = true
+		return true
+	}
+	return false
+}
+
+// persist writes the period's usage atomically; errors are logged, never
+// fatal.
+func (b *budgetTracker) persist() {
+	if err := os.MkdirAll(filepath.Dir(b.path), 0o755); err != nil {
+		log.Printf("engine: persist budget: %v", err)
+		return
+	}
+	raw, err := json.Marshal(budgetState{PeriodStart: b.periodStart, UsedBytes: b.usedBytes})
+	if err != nil {
+		return
+	}
+	tmp := b.path + ".tmp"
+	if os.WriteFile(tmp, raw, 0o644) == nil {
+		_ = os.Rename(tmp, b.path)
+	}
+}
+
+// BudgetStatus is the allowance view exposed over ubus.
+type BudgetStatus struct {
+	LimitMB     float64   `json:"limit_mb"`
+	UsedMB      float64   `json:"used_mb"`
+	RemainingMB float64   `json:"remaining_mb"`
+	PeriodStart time.Time `json:"period_start"`
+	Exhausted   bool      `json:"exhausted"`
+}
+
+// Budget returns the current allowance state, or nil when no budget is
+// configured.
+func (e *Engine) Budget() *BudgetStatus {
+	if e.dataBudget == nil {
+		return nil
+	}
+	b := e.dataBudget
+	remaining := float64(0)
+	if b.limitBytes > b.usedBytes {
+		remaining = float64(b.limitBytes-b.usedBytes) / 1e6
+	}
+	return &BudgetStatus{
+		LimitMB:     float64(b.limitBytes) / 1e6,
+		UsedMB:      float64(b.usedBytes) / 1e6,
+		RemainingMB: remaining,
+		PeriodStart: b.periodStart,
+		Exhausted:   b.exhausted,
+	}
+}
+
+// trackBudget meters the active Starlink member's counters; called once
+// per tick before the decision.
+func (e *Engine) trackBudget(now time.Time) {
+	if e.dataBudget == nil {
+		return
+	}
+	active := e.member(e.active)
+	starlinkActive := active != nil && active.Class == "starlink"
+	var total uint64
+	if starlinkActive {
+		var err error
+		if total, err = readIfaceBytes(active.Iface); err != nil {
+			log.Printf("engine: budget counters for %s: %v", active.Iface, err)
+			e.dataBudget.haveLast = false
+			return
+		}
+	}
+	if e.dataBudget.observe(total, starlinkActive, now) {
+		e.store.AddEvent(telemetry.Event{
+			Timestamp: now, Type: "budget_exhausted", Member: active.Name,
+			Reason:  "priority data allowance exhausted",
+			Details: map[string]any{"used_mb": float64(e.dataBudget.usedBytes) / 1e6},
+		})
+		if e.notifier != nil {
+			e.notifier.Notify("budget_exhausted", notifications.PriorityNormal, notifications.EventData{
+				Member: active.Name,
+				Reason: "priority data allowance exhausted",
+			})
+		}
+	}
+}
+
+// dataCapAlternative returns the member traffic should move to when the
+// candidate is a Starlink member with an exhausted budget: the best
+// eligible flat-rate member, or nil when de-prioritization is off, the
+// budget holds, or no flat-rate member can take over.
+func (e *Engine) dataCapAlternative(best *Member) *Member {
+	if best == nil || e.dataBudget == nil || !e.cfg.BudgetDeprioritize {
+		return nil
+	}
+	if best.Class != "starlink" || !e.dataBudget.exhausted {
+		return nil
+	}
+	var alt *Member
+	for _, m := range e.roster.All() {
+		if m.State != StateEligible || !m.FlatRate || m == best {
+			continue
+		}
+		if alt == nil || m.Final > alt.Final {
+			alt = m
+		}
+	}
+	return alt
+}
@@ -0,0 +1,137 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/collector"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/controller"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/telemetry"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/uci"
+)
+
+func newBudgetTracker100MB(t *testing.T) *budgetTracker {
+	t.Helper()
+	return newBudgetTracker(0.1, 1, t.TempDir()+"/budget.json") // 100 MB
+}
+
+func TestBudgetObserveAccumulates(t *testing.T) {
+	b := newBudgetTracker100MB(t)
+	now := time.Now()
+	b.observe(1000, true, now) // baseline
+	b.observe(5000, true, now)
+	if b.usedBytes != 4000 {
+		t.Errorf("usedBytes = %d, want 4000", b.usedBytes)
+	}
+	// An inactive stretch resets the baseline: bytes moved while another
+	// member carried traffic are not counted.
+	b.observe(0, false, now)
+	b.observe(90000, true, now) // new baseline, no delta yet
+	b.observe(91000, true, now)
+	if b.usedBytes != 5000 {
+		t.Errorf("usedBytes = %d, want 5000", b.usedBytes)
+	}
+	// A counter reset (interface bounce) must not count backwards.
+	b.observe(200, true, now)
+	b.observe(300, true, now)
+	if b.usedBytes != 5100 {
+		t.Errorf("usedBytes after reset = %d, want 5100", b.usedBytes)
+	}
+}
+
+func TestBudgetExhaustionFiresOnce(t *testing.T) {
+	b := newBudgetTracker100MB(t)
+	now := time.Now()
+	b.observe(0, true, now)
+	if b.observe(99e6, true, now) {
+		t.Error("exhausted below the limit")
+	}
+	if !b.observe(101e6, true, now) {
+		t.Error("crossing the limit did not report exhaustion")
+	}
+	if b.observe(102e6, true, now) {
+		t.Error("exhaustion reported twice")
+	}
+}
+
+func TestBudgetPeriodRoll(t *testing.T) {
+	b := newBudgetTracker100MB(t)
+	b.usedBytes = 101e6
+	b.exhausted = true
+	b.periodStart = b.periodStart.AddDate(0, -1, 0)
+	b.observe(500, true, time.Now())
+	if b.usedBytes != 0 || b.exhausted {
+		t.Errorf("period roll kept used=%d exhausted=%v", b.usedBytes, b.exhausted)
+	}
+}
+
+func TestBudgetPersistence(t *testing.T) {
+	path := t.TempDir() + "/budget.json"
+	b := newBudgetTracker(0.1, 1, path)
+	now := time.Now()
+	b.observe(0, true, now)
+	b.observe(7000, true, now)
+	b2 := newBudgetTracker(0.1, 1, path)
+	if b2.usedBytes != 7000 {
+		t.Errorf("restored usedBytes = %d, want 7000", b2.usedBytes)
+	}
+}
+
+func newBudgetTestEngine(t *testing.T) *Engine {
+	t.Helper()
+	cfg := &uci.Config{
+		FailScore: 40, RestoreScore: 70, RestoreWindowS: 300,
+		CooldownS: 0, WarmupS: 30, PollIntervalS: 5,
+		BudgetDeprioritize: true,
+		Members: []uci.MemberConfig{
+			{Name: "starlink", Iface: "wan", Class: "starlink", Weight: 50},
+			{Name: "cellular", Iface: "mob1s1a1", Class: "cellular", Weight: 50, FlatRate: true},
+		},
+	}
+	ctl := controller.New(nil)
+	ctl.Noop = true
+	e := New(cfg, collector.NewRegistry(), ctl, telemetry.NewStore(t.TempDir()), nil)
+	e.SetStatePath(t.TempDir() + "/state.json")
+	e.dataBudget = newBudgetTracker(0.1, 1, t.TempDir()+"/budget.json")
+	e.member("starlink").Final = 90
+	e.member("cellular").Final = 60
+	return e
+}
+
+func TestDataCapHandsOffToFlatRate(t *testing.T) {
+	e := newBudgetTestEngine(t)
+	e.makeDecision()
+	if e.Active() != "starlink" {
+		t.Fatalf("active = %q, want starlink", e.Active())
+	}
+	e.dataBudget.exhausted = true
+	e.makeDecision()
+	if e.Active() != "cellular" {
+		t.Fatalf("active = %q after exhaustion, want cellular", e.Active())
+	}
+	var found bool
+	for _, ev := range e.store.Events(10) {
+		if ev.Type == "failover" && ev.Details["reason_code"] == string(ReasonDataCap) {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("no failover event with reason_code data_cap")
+	}
+	// The budget stays exhausted: traffic must not flap back to Starlink.
+	e.makeDecision()
+	if e.Active() != "cellular" {
+		t.Errorf("active = %q on next tick, want cellular", e.Active())
+	}
+}
+
+func TestDataCapNeedsFlatRateMember(t *testing.T) {
+	e := newBudgetTestEngine(t)
+	e.member("cellular").FlatRate = false
+	e.makeDecision()
+	e.dataBudget.exhausted = true
+	e.makeDecision()
+	if e.Active() != "starlink" {
+		t.Errorf("active = %q, want starlink (no flat-rate member to take over)", e.Active())
+	}
+}
@@ -0,0 +1,85 @@
+package engine
+
+// Soft-threshold pre-notifications. A member whose score sinks below the
+// warn threshold but stays above the fail threshold is degrading, not yet
+// failing: no routing changes, but the operator gets a heads-up with the
+// current trend so the eventual failover (or a quiet recovery) is not a
+// surprise. The warning clears with hysteresis to keep a score hovering
+// around the threshold from spamming.
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/notifications"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/telemetry"
+)
+
+// degradeClearMargin is how far above the warn threshold the score must
+// recover before the warning state clears.
+const degradeClearMargin = 5.0
+
+// noteDegradation tracks soft-threshold crossings for one member; called
+// once per tick after scoring.
+func (e *Engine) noteDegradation(m *Member) {
+	warn := e.cfg.WarnScore
+	if warn <= 0 || warn <= e.cfg.FailScore {
+		return // disabled, or misconfigured below the hard threshold
+	}
+	switch {
+	case !m.degraded && m.State == StateEligible && m.Final < warn && m.Final >= e.cfg.FailScore:
+		m.degraded = true
+		trend := e.scoreTrend(m.Name)
+		reason := fmt.Sprintf("score %.1f below warning threshold %.0f, %s", m.Final, warn, trendPhrase(trend))
+		e.store.AddEvent(telemetry.Event{
+			Timestamp: time.Now(), Type: "member_degraded", Member: m.Name, Reason: reason,
+			Details: map[string]any{"score": m.Final, "warn_score": warn, "trend_per_min": trend},
+		})
+		if e.notifier != nil {
+			e.notifier.Notify("member_degraded", notifications.PriorityNormal, notifications.EventData{
+				Member: m.Name, Reason: trendPhrase(trend), Score: m.Final, Metrics: m.LastSample,
+			})
+		}
+	case m.degraded && (m.Final >= warn+degradeClearMargin || m.State != StateEligible):
+		// Either the member recovered or it went on to fail outright; the
+		// latter already produces member_failed, so only a recovery gets
+		// its own event.
+		m.degraded = false
+		if m.State == StateEligible {
+			e.store.AddEvent(telemetry.Event{
+				Timestamp: time.Now(), Type: "member_degradation_cleared", Member: m.Name,
+				Details: map[string]any{"score": m.Final},
+			})
+		}
+	}
+}
+
+// scoreTrend is the score change per minute over the recent sample window,
+// negative when sinking.
+func (e *Engine) scoreTrend(name string) float64 {
+	samples := e.store.Samples(name, 12)
+	if len(samples) < 2 {
+		return 0
+	}
+	first, last := samples[0], samples[len(samples)-1]
+	if first.Metrics == nil || last.Metrics == nil {
+		return 0
+	}
+	dt := last.Metrics.Timestamp.Sub(first.Metrics.Timestamp).Minutes()
+	if dt <= 0 {
+		return 0
+	}
+	return (last.Score - first.Score) / dt
+}
+
+// trendPhrase renders a trend for humans.
+func trendPhrase(perMin float64) string {
+	switch {
+	case perMin < -0.5:
+		return fmt.Sprintf("falling %.1f points/min", -perMin)
+	case perMin > 0.5:
+		return fmt.Sprintf("recovering %.1f points/min", perMin)
+	default:
+		return "holding steady"
+	}
+}
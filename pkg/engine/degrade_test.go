@@ -0,0 +1,104 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/metrics"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/telemetry"
+)
+
+func degradeEvents(e *Engine, typ string) int {
+	n := 0
+	for _, ev := range e.store.Events(0) {
+		if ev.Type == typ {
+			n++
+		}
+	}
+	return n
+}
+
+func TestDegradationWarnsOnceWithHysteresis(t *testing.T) {
+	e := newForceTestEngine(t)
+	e.cfg.WarnScore = 55
+	m := e.member("starlink")
+
+	// Healthy: nothing fires.
+	m.Final = 80
+	e.noteDegradation(m)
+	if degradeEvents(e, "member_degraded") != 0 {
+		t.Fatal("warning fired while healthy")
+	}
+	// Crosses the soft threshold: one warning, repeated ticks stay quiet.
+	m.Final = 50
+	e.noteDegradation(m)
+	e.noteDegradation(m)
+	if got := degradeEvents(e, "member_degraded"); got != 1 {
+		t.Fatalf("member_degraded events = %d, want 1", got)
+	}
+	// Hovering just above the threshold must not clear yet.
+	m.Final = 57
+	e.noteDegradation(m)
+	if degradeEvents(e, "member_degradation_cleared") != 0 {
+		t.Fatal("warning cleared inside the hysteresis band")
+	}
+	// Clear recovery: cleared event, and a later dip warns again.
+	m.Final = 70
+	e.noteDegradation(m)
+	if degradeEvents(e, "member_degradation_cleared") != 1 {
+		t.Fatal("no cleared event after recovery")
+	}
+	m.Final = 50
+	e.noteDegradation(m)
+	if got := degradeEvents(e, "member_degraded"); got != 2 {
+		t.Fatalf("member_degraded events after re-dip = %d, want 2", got)
+	}
+}
+
+func TestDegradationBelowFailScoreIsNotAWarning(t *testing.T) {
+	e := newForceTestEngine(t)
+	e.cfg.WarnScore = 55
+	m := e.member("starlink")
+	m.Final = 20 // below FailScore: the lifecycle handles this, not the warning
+	e.noteDegradation(m)
+	if degradeEvents(e, "member_degraded") != 0 {
+		t.Fatal("warning fired below the fail threshold")
+	}
+}
+
+func TestDegradationDisabledOrMisconfigured(t *testing.T) {
+	e := newForceTestEngine(t)
+	m := e.member("starlink")
+	m.Final = 50
+	for _, warn := range []float64{0, 30} { // disabled; below FailScore 40
+		e.cfg.WarnScore = warn
+		e.noteDegradation(m)
+	}
+	if degradeEvents(e, "member_degraded") != 0 {
+		t.Fatal("warning fired with warn_score disabled or misconfigured")
+	}
+}
+
+func TestScoreTrend(t *testing.T) {
+	e := newForceTestEngine(t)
+	now := time.Now()
+	for i, score := range []float64{80, 70, 60} {
+		e.store.AddSample(telemetry.Sample{
+			Member:  "starlink",
+			Metrics: &metrics.Metrics{Timestamp: now.Add(time.Duration(i) * time.Minute)},
+			Score:   score,
+		})
+	}
+	if got := e.scoreTrend("starlink"); got != -10 {
+		t.Fatalf("scoreTrend = %v, want -10 points/min", got)
+	}
+	if got := e.scoreTrend("cellular"); got != 0 {
+		t.Fatalf("trend without samples = %v, want 0", got)
+	}
+	if p := trendPhrase(-10); p != "falling 10.0 points/min" {
+		t.Fatalf("trendPhrase = %q", p)
+	}
+	if p := trendPhrase(0.2); p != "holding steady" {
+		t.Fatalf("trendPhrase near zero = %q", p)
+	}
+}
@@ -0,0 +1,40 @@
+package engine
+
+import "testing"
+
+func TestDryRunRecordsWithoutSwitching(t *testing.T) {
+	e := newForceTestEngine(t)
+	e.cfg.DryRun = true
+
+	e.makeDecision()
+	if e.Active() != "starlink" {
+		t.Fatalf("shadow active = %q, want starlink", e.Active())
+	}
+	var would, real int
+	for _, ev := range e.store.Events(0) {
+		switch ev.Type {
+		case "would_failover":
+			would++
+			if ev.Details["reason_code"] != string(ReasonInitialSelection) {
+				t.Errorf("reason_code = %v", ev.Details["reason_code"])
+			}
+			if ev.Snapshot == nil {
+				t.Error("would_failover event carries no snapshot")
+			}
+		case "failover":
+			real++
+		}
+	}
+	if would != 1 || real != 0 {
+		t.Fatalf("events: %d would_failover, %d failover; want 1 and 0", would, real)
+	}
+
+	// The shadow state keeps decisions stable: a second round with nothing
+	// changed must not repeat the event.
+	e.makeDecision()
+	for _, ev := range e.store.Events(0)[1:] {
+		if ev.Type == "would_failover" {
+			t.Fatal("steady state produced another would_failover")
+		}
+	}
+}
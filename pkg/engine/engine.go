@@ -0,0 +1,683 @@
+// Package engine runs the decision loop: collect samples for every member,
+// score them, and switch the active mwan3 member when the scores say so,
+// with hysteresis so marginal differences never cause flapping.
+package engine
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/collector"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/controller"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/metrics"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/notifications"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/predictive"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/probe"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/sla"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/telemetry"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/uci"
+)
+
+// Collector timeouts are derived from the decision interval so a slow
+// member can never push a tick past the next one: collectShare of the
+// interval is split evenly among the members being polled, clamped to
+// [minCollectTimeout, maxCollectTimeout].
+const (
+	collectShare      = 0.6
+	minCollectTimeout = 2 * time.Second
+	maxCollectTimeout = 10 * time.Second
+)
+
+// collectFailureLimit is how many consecutive collection failures one member
+// accumulates before a problem report goes out.
+const collectFailureLimit = 5
+
+// switchMargin is how many points better a candidate must score before we
+// switch away from a healthy active member.
+const switchMargin = 10.0
+
+// Member is the engine's runtime view of one WAN member.
+type Member struct {
+	Name   string
+	Iface  string
+	Class  string
+	Weight int
+	// Priority ranks the member for the rules strategy; lower wins, 0 is
+	// unranked (see strategy.go).
+	Priority int
+	Group    string // explicit failure-domain override; see group.go
+	VRF    string // VRF device to bind probes to instead of Iface
+	// FlatRate marks a member with unlimited data; budget de-prioritization
+	// only moves traffic onto flat-rate members (see budget.go).
+	FlatRate bool
+
+	// Lifecycle holds the member's state machine; see state.go for the
+	// states and transition table.
+	Lifecycle
+
+	// Scoring state.
+	Instant    float64
+	EWMA       float64
+	WindowAvg  float64
+	Final      float64
+	Predictive predictive.Assessment
+	LastSample *metrics.Metrics
+	// Bloat is the member's bufferbloat assessment; see bloat.go.
+	Bloat BloatInfo
+
+	// TLSFiltered marks a member whose TLS probe found an intercepting
+	// certificate (captive filtering, corporate MITM); sensitive traffic
+	// profiles avoid such members. TLSIssuer names who signed what the
+	// middlebox presented.
+	TLSFiltered bool
+	TLSIssuer   string
+
+	// Stowed marks a dish parked flat: administratively out of rotation,
+	// not failed. See stow.go.
+	Stowed bool
+
+	haveEWMA        bool
+	degraded        bool // soft-threshold warning state; see degrade.go
+	cleanStreak     int  // consecutive loss-free samples; see failback.go
+	lastCollect     time.Time
+	lastPrewarm     time.Time // see establish.go
+	collectFailures int
+	lastAlerts      map[string]bool
+
+	// Warm-up scoring accumulators, reset when the member enters warm-up;
+	// see WarmupScore.
+	warmupSum   float64
+	warmupCount int
+}
+
+// WarmupScore is the dedicated warm-up average: every score observed since
+// the member entered warm-up counts equally, so one early stumble keeps
+// dragging the average down until enough clean samples bury it. No samples
+// yet means zero — a member cannot graduate before it has been measured.
+func (m *Member) WarmupScore() float64 {
+	if m.warmupCount == 0 {
+		return 0
+	}
+	return m.warmupSum / float64(m.warmupCount)
+}
+
+// BindIface is the device collectors and probes should bind sockets to: the
+// VRF device when one is configured (binding to it makes the kernel route by
+// the VRF's table), otherwise the member's interface.
+func (m *Member) BindIface() string {
+	if m.VRF != "" {
+		return m.VRF
+	}
+	return m.Iface
+}
+
+// Engine owns the decision loop.
+type Engine struct {
+	cfg        *uci.Config
+	registry   *collector.Registry
+	controller *controller.Controller
+	store      *telemetry.Store
+	notifier   *notifications.Notifier
+
+	roster     *Roster
+	active     string
+	lastSwitch time.Time
+	sla        *sla.Tracker
+	assessor   predictive.Assessor
+	statePath  string
+
+	// dataBudget meters priority data while Starlink is active; nil when
+	// no budget is configured. See budget.go.
+	dataBudget *budgetTracker
+
+	// Cellular session establishment timing; see establish.go.
+	establish    *establishTracker
+	establishing *establishRun
+
+	// failoverHook, when set, runs as part of each switch with the member
+	// being left (post-mortem packet capture); the returned reference is
+	// attached to the failover event.
+	failoverHook func(member, iface, correlationID string) string
+
+	// onSwitch hooks run in the background after each completed switch
+	// (e.g. re-kicking WireGuard tunnels onto the new WAN).
+	onSwitch []func(member string)
+
+	// verifier and its bookkeeping gate failback; see verify.go.
+	verifier Verifier
+	verify   verifyState
+
+	// profile is the deployment's traffic profile; see profile.go.
+	profile trafficProfile
+
+	// strategy chooses switch candidates; see strategy.go.
+	strategy Strategy
+
+	// Manual failover pin; see force.go.
+	forced      string
+	forcedUntil time.Time
+	forceWarned bool
+
+	// Maintenance pause; see pause.go.
+	paused      bool
+	pauseReason string
+	pauseUntil  time.Time
+
+	// problem, when set, receives structured problem reports (currently
+	// repeated collector failures); main wires it to the outbound webhook.
+	problem func(check, subject, severity, detail string)
+
+	// poke wakes Run for an immediate out-of-schedule tick (passive
+	// dead-gateway detection). Buffered so Poke never blocks.
+	poke chan struct{}
+}
+
+// SetSLA attaches an SLA tracker fed one observation per member per tick.
+func (e *Engine) SetSLA(t *sla.Tracker) { e.sla = t }
+
+// OnSwitch adds a hook run after each completed switch.
+func (e *Engine) OnSwitch(h func(member string)) {
+	e.onSwitch = append(e.onSwitch, h)
+}
+
+// SetProblemReporter installs the problem-report hook; see the field comment.
+func (e *Engine) SetProblemReporter(f func(check, subject, severity, detail string)) {
+	e.problem = f
+}
+
+// SetFailoverHook installs the per-switch hook; see the field comment.
+func (e *Engine) SetFailoverHook(h func(member, iface, correlationID string) string) {
+	e.failoverHook = h
+}
+
+// SetAssessor replaces the predictive assessor (default: the built-in
+// heuristics); main wires the UCI-configured one here.
+func (e *Engine) SetAssessor(a predictive.Assessor) {
+	if a != nil {
+		e.assessor = a
+	}
+}
+
+// New wires an Engine from its dependencies.
+func New(cfg *uci.Config, reg *collector.Registry, ctl *controller.Controller, store *telemetry.Store, notifier *notifications.Notifier) *Engine {
+	e := &Engine{
+		cfg:        cfg,
+		registry:   reg,
+		controller: ctl,
+		store:      store,
+		notifier:   notifier,
+		assessor:   predictive.Heuristic{},
+		statePath:  DefaultStatePath,
+		profile:    profileByName(cfg.Profile),
+		strategy:   strategyByName(cfg.Strategy),
+		establish:  newEstablishTracker(""),
+		poke:       make(chan struct{}, 1),
+	}
+	var members []*Member
+	for _, mc := range cfg.Members {
+		members = append(members, &Member{
+			Name:      mc.Name,
+			Iface:     mc.Iface,
+			Class:     mc.Class,
+			Weight:    mc.Weight,
+			Priority:  mc.Priority,
+			Group:     mc.Group,
+			VRF:       mc.VRF,
+			FlatRate:  mc.FlatRate,
+			Lifecycle: Lifecycle{State: StateEligible},
+		})
+	}
+	e.roster = NewRoster(members...)
+	// A roster change should be acted on now, not at the next poll.
+	e.roster.OnChange(e.Poke)
+	if cfg.BudgetGB > 0 {
+		e.dataBudget = newBudgetTracker(cfg.BudgetGB, cfg.BudgetResetDay, "")
+	}
+	return e
+}
+
+// Roster returns the shared member registry; see roster.go.
+func (e *Engine) Roster() *Roster { return e.roster }
+
+// SetMembers replaces the engine's member list (used by main on reload).
+func (e *Engine) SetMembers(members []*Member) { e.roster.Set(members) }
+
+// AddMember appends a member at runtime.
+func (e *Engine) AddMember(m *Member) { e.roster.Add(m) }
+
+// RemoveMember drops a member at runtime (e.g. wifi-as-WAN out of range).
+func (e *Engine) RemoveMember(name string) {
+	e.roster.Remove(name)
+	if e.active == name {
+		e.active = ""
+	}
+}
+
+// Members returns the live member list.
+func (e *Engine) Members() []*Member { return e.roster.All() }
+
+// Active returns the name of the currently preferred member.
+func (e *Engine) Active() string { return e.active }
+
+// DryRun reports whether the engine is in observation-only mode.
+func (e *Engine) DryRun() bool { return e.cfg.DryRun }
+
+// Run executes the decision loop until ctx is cancelled.
+func (e *Engine) Run(ctx context.Context) {
+	ticker := time.NewTicker(time.Duration(e.cfg.PollIntervalS) * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			e.tick(ctx)
+		case <-e.poke:
+			e.tick(ctx)
+		}
+	}
+}
+
+// Poke requests an immediate tick from Run without waiting for the next
+// poll interval; used by passive failure detectors that see trouble
+// between probes. Never blocks, and coalesces while a tick is pending.
+func (e *Engine) Poke() {
+	select {
+	case e.poke <- struct{}{}:
+	default:
+	}
+}
+
+// meteredActive reports whether the active member is a metered link.
+func (e *Engine) meteredActive() bool {
+	a := e.member(e.active)
+	return a != nil && a.Class == "cellular"
+}
+
+// collectBudget returns the per-collector timeout for a tick polling n
+// members; see the constants above for the derivation.
+func (e *Engine) collectBudget(n int) time.Duration {
+	if n < 1 {
+		n = 1
+	}
+	interval := time.Duration(e.cfg.PollIntervalS) * time.Second
+	d := time.Duration(collectShare * float64(interval) / float64(n))
+	if d < minCollectTimeout {
+		return minCollectTimeout
+	}
+	if d > maxCollectTimeout {
+		return maxCollectTimeout
+	}
+	return d
+}
+
+// tick runs one collect+score+decide round.
+func (e *Engine) tick(ctx context.Context) {
+	metered := e.meteredActive()
+	probe.SetMetered(metered, e.cfg.MeteredProbeCount)
+	// One roster snapshot per tick: concurrent additions join next round.
+	members := e.roster.All()
+	var warming []string
+	for _, m := range members {
+		if m.State == StateWarmup {
+			warming = append(warming, m.BindIface())
+		}
+	}
+	probe.SetWarming(warming)
+	backoff := time.Duration(e.cfg.BackupPollIntervalS) * time.Second
+	budget := e.collectBudget(len(members))
+	for _, m := range members {
+		c, ok := e.registry.ForClass(m.Class)
+		if !ok {
+			continue
+		}
+		if metered && m.State == StateFailed && m.Name != e.active &&
+			time.Since(m.lastCollect) < backoff {
+			// Conserve metered data: re-poll failed members at the backup
+			// rate; recovery is still detected within BackupPollIntervalS.
+			continue
+		}
+		m.lastCollect = time.Now()
+		cctx, cancel := context.WithTimeout(ctx, budget)
+		sample, err := c.Collect(cctx, m.BindIface())
+		cancel()
+		if err != nil {
+			log.Printf("engine: collect %s: %v", m.Name, err)
+			m.collectFailures++
+			if m.collectFailures == collectFailureLimit && e.problem != nil {
+				e.problem("collect", m.Name, "warning",
+					fmt.Sprintf("%d consecutive collection failures, last: %v", m.collectFailures, err))
+			}
+			continue
+		}
+		m.collectFailures = 0
+		if err := sample.Validate(); err != nil {
+			log.Printf("engine: %s sample rejected: %v", m.Name, err)
+			e.store.AddEvent(telemetry.Event{
+				Timestamp: time.Now(), Type: "invalid_sample", Member: m.Name,
+				Reason: err.Error(),
+			})
+			continue
+		}
+		if sample.DataSource == metrics.SourceMock && !e.cfg.AllowMockMetrics {
+			// Treat mock data as a failed collection: fabricated values
+			// must not reach scoring, the sample window, or prediction.
+			log.Printf("engine: %s returned mock data, not scoring", m.Name)
+			e.store.AddEvent(telemetry.Event{
+				Timestamp: time.Now(), Type: "mock_data_rejected", Member: m.Name,
+			})
+			continue
+		}
+		e.noteStow(m, sample.Stowed)
+		if m.Stowed {
+			continue // administratively out; don't score a parked dish
+		}
+		e.scoreMember(ctx, m, sample)
+		e.noteAlerts(m, sample)
+		e.noteProbeStreak(m, sample)
+		e.store.AddSample(telemetry.Sample{Member: m.Name, Metrics: sample, Score: m.Final})
+		e.noteDegradation(m)
+		e.noteEstablish(m, sample)
+	}
+	if e.sla != nil {
+		now := time.Now()
+		anyUp := false
+		for _, m := range members {
+			up := m.Final >= e.cfg.FailScore
+			e.sla.Observe(m.Name, up, now)
+			anyUp = anyUp || up
+		}
+		e.sla.Observe(sla.Overall, anyUp, now)
+	}
+	e.trackBudget(time.Now())
+	e.prewarmStandbys(ctx, members)
+	e.makeDecision()
+	e.SaveState()
+}
+
+// noteAlerts turns newly-raised dish alerts into one telemetry event and
+// notification each; alerts that clear simply drop out of the tracked set.
+func (e *Engine) noteAlerts(m *Member, sample *metrics.Metrics) {
+	if len(sample.ActiveAlerts) == 0 && len(m.lastAlerts) == 0 {
+		return
+	}
+	current := make(map[string]bool, len(sample.ActiveAlerts))
+	for _, a := range sample.ActiveAlerts {
+		current[a] = true
+		if m.lastAlerts[a] {
+			continue
+		}
+		e.store.AddEvent(telemetry.Event{
+			Timestamp: time.Now(), Type: "starlink_alert", Member: m.Name, Reason: a,
+		})
+		if e.notifier != nil {
+			e.notifier.Notify("starlink_alert", notifications.PriorityHigh, notifications.EventData{
+				Member: m.Name, Reason: a, Metrics: sample,
+			})
+		}
+	}
+	m.lastAlerts = current
+}
+
+// scoreMember folds one sample into the member's scoring state.
+func (e *Engine) scoreMember(ctx context.Context, m *Member, sample *metrics.Metrics) {
+	m.LastSample = sample
+	m.Instant = e.instantScore(sample)
+	e.updateBloat(m, sample)
+	if p := bloatPenalty(m.Bloat); p > 0 {
+		m.Instant -= p
+		if m.Instant < 0 {
+			m.Instant = 0
+		}
+	}
+	if m.haveEWMA {
+		m.Instant, m.Bloat.Damped = dampSaturation(m.Bloat, sample, m.Instant, m.EWMA)
+	}
+	if !m.haveEWMA {
+		m.EWMA = m.Instant
+		m.haveEWMA = true
+	} else {
+		m.EWMA = e.cfg.EWMAAlpha*m.Instant + (1-e.cfg.EWMAAlpha)*m.EWMA
+	}
+	window := e.store.Samples(m.Name, 12)
+	if len(window) > 0 {
+		var sum float64
+		for _, s := range window {
+			sum += s.Score
+		}
+		m.WindowAvg = sum / float64(len(window))
+	} else {
+		m.WindowAvg = m.Instant
+	}
+	m.Predictive, _ = e.assessor.Assess(ctx, m.Name, window)
+	m.Final = e.cfg.BlendInstant*m.Instant + e.cfg.BlendEWMA*m.EWMA + e.cfg.BlendWindow*m.WindowAvg +
+		weightBias(m.Weight) + e.profile.predictiveScale*m.Predictive.Adjustment
+	if m.Final < 0 {
+		m.Final = 0
+	}
+	if m.Final > 100 {
+		m.Final = 100
+	}
+	if m.State == StateWarmup {
+		m.warmupSum += m.Final
+		m.warmupCount++
+	}
+}
+
+// makeDecision advances each member's state machine and switches the
+// active member when warranted. This is intentionally the only place
+// routing changes happen.
+func (e *Engine) makeDecision() {
+	now := time.Now()
+	if e.pauseHold(now) {
+		return // maintenance pause; see pause.go
+	}
+	if e.forcedHold(now) {
+		return // operator pinned a member; see force.go
+	}
+	lcfg := lifecycleConfig{
+		FailScore:     e.cfg.FailScore,
+		RestoreScore:  e.cfg.RestoreScore,
+		RestoreWindow: time.Duration(e.profile.restoreWindowScale * float64(e.cfg.RestoreWindowS) * float64(time.Second)),
+		Warmup:        time.Duration(e.cfg.WarmupS) * time.Second,
+		WarmupScore:   e.cfg.WarmupScore,
+	}
+
+	for _, m := range e.roster.All() {
+		if m.Stowed {
+			continue // administratively out; see stow.go
+		}
+		score := m.Final
+		if m.State == StateWarmup {
+			score = m.WarmupScore()
+		}
+		mcfg := lcfg
+		if m.State == StateCooldown {
+			mcfg.FailbackConfidence = e.failbackConfidence(m, lcfg.RestoreWindow, now).Total
+		}
+		from, to := m.step(score, mcfg, now)
+		if from == to {
+			continue
+		}
+		if to == StateWarmup {
+			m.warmupSum, m.warmupCount = 0, 0
+		}
+		e.store.AddEvent(telemetry.Event{
+			Timestamp: now, Type: "state_transition", Member: m.Name,
+			Reason:  transitionReason(from, to),
+			Details: map[string]any{"score": m.Final},
+		})
+		// Keep the coarser event types downstream consumers already
+		// watch for.
+		switch to {
+		case StateFailed:
+			if from == StateEligible {
+				e.store.AddEvent(telemetry.Event{
+					Timestamp: now, Type: "member_failed", Member: m.Name,
+					Details:  map[string]any{"score": m.Final},
+					Snapshot: e.eventSnapshot(m.Name),
+				})
+			}
+		case StateWarmup:
+			e.store.AddEvent(telemetry.Event{
+				Timestamp: now, Type: "member_recovering", Member: m.Name,
+			})
+		}
+	}
+
+	// The strategy (score-based by default, see strategy.go) chooses the
+	// switch candidate from the stepped lifecycle states.
+	if best, reason := e.strategy.Decide(e, now); best != nil {
+		e.switchTo(best, reason)
+	}
+}
+
+// pickBest returns the highest-scoring eligible member, skipping
+// TLS-filtered members when avoidFiltered is set.
+func (e *Engine) pickBest(avoidFiltered bool) *Member {
+	var best *Member
+	for _, m := range e.roster.All() {
+		if m.State != StateEligible || m.Stowed {
+			continue
+		}
+		if avoidFiltered && m.TLSFiltered {
+			continue
+		}
+		if best == nil || m.Final > best.Final {
+			best = m
+		}
+	}
+	return best
+}
+
+// SetTLSFiltered records a TLS probe verdict for a member; see the
+// Member.TLSFiltered comment.
+func (e *Engine) SetTLSFiltered(name string, filtered bool, issuer string) {
+	if m := e.member(name); m != nil {
+		m.TLSFiltered = filtered
+		m.TLSIssuer = issuer
+	}
+}
+
+// switchTo makes m the active member and records/notifies the event. The
+// reason code travels machine-readable in the event details while its
+// human text fills the reason fields consumers display.
+func (e *Engine) switchTo(m *Member, code ReasonCode) {
+	if e.cfg.DryRun {
+		e.recordWouldSwitch(m, code)
+		return
+	}
+	if err := e.controller.Switch(m.Name); err != nil {
+		log.Printf("engine: switch to %s: %v", m.Name, err)
+		return
+	}
+	prev := e.active
+	e.active = m.Name
+	e.lastSwitch = time.Now()
+	e.startEstablish(m)
+	// Keep heavy probes (bandwidth tests) off the wire while the new
+	// routing settles; anything measured now would be self-inflicted.
+	probe.FreezeHeavy(time.Duration(e.cfg.CooldownS) * time.Second)
+	corr := notifications.NewCorrelationID()
+	details := map[string]any{
+		"from": prev, "score": m.Final, "correlation_id": corr,
+		"reason_code": string(code),
+	}
+	if e.failoverHook != nil && prev != "" {
+		if pm := e.member(prev); pm != nil {
+			if ref := e.failoverHook(prev, pm.Iface, corr); ref != "" {
+				details["pcap"] = ref
+			}
+		}
+	}
+	e.store.AddEvent(telemetry.Event{
+		Timestamp: time.Now(), Type: "failover", Member: m.Name, Reason: code.Text(),
+		Details:  details,
+		Snapshot: e.eventSnapshot(m.Name, prev),
+	})
+	if e.notifier != nil {
+		e.notifier.Notify("failover", notifications.PriorityHigh, notifications.EventData{
+			Member:        m.Name,
+			From:          prev,
+			Reason:        code.Text(),
+			Score:         m.Final,
+			Metrics:       m.LastSample,
+			CorrelationID: corr,
+		})
+	}
+	for _, h := range e.onSwitch {
+		go h(m.Name)
+	}
+}
+
+// recordWouldSwitch is switchTo's dry-run path: the decision is recorded
+// with the same data a real failover event carries — including the shadow
+// active member moving, so the week's event log reads as the sequence of
+// switches the engine would have made rather than one repeat per tick —
+// but mwan3 is never touched and nothing is notified or captured.
+func (e *Engine) recordWouldSwitch(m *Member, code ReasonCode) {
+	prev := e.active
+	e.active = m.Name
+	e.lastSwitch = time.Now()
+	log.Printf("engine: dry-run: would switch %s -> %s (%s)", prev, m.Name, code.Text())
+	e.store.AddEvent(telemetry.Event{
+		Timestamp: time.Now(), Type: "would_failover", Member: m.Name, Reason: code.Text(),
+		Details: map[string]any{
+			"from": prev, "score": m.Final, "reason_code": string(code),
+		},
+		Snapshot: e.eventSnapshot(m.Name, prev),
+	})
+}
+
+// snapshotWindow is how much sample history rides along on an event.
+const snapshotWindow = time.Minute
+
+// eventSnapshot freezes the involved members' scores, lifecycle states and
+// last minute of samples plus the engine's override state, so inspecting a
+// failover or member-failure event later is self-contained.
+func (e *Engine) eventSnapshot(involved ...string) *telemetry.EventSnapshot {
+	snap := &telemetry.EventSnapshot{
+		Active:  e.active,
+		Forced:  e.forced,
+		Paused:  e.paused,
+		Members: make(map[string]telemetry.MemberSnapshot, len(involved)),
+	}
+	for _, name := range involved {
+		m := e.member(name)
+		if m == nil {
+			continue
+		}
+		snap.Members[name] = telemetry.MemberSnapshot{
+			Score:   m.Final,
+			State:   string(m.State),
+			Samples: e.store.RecentSamples(name, snapshotWindow),
+		}
+	}
+	return snap
+}
+
+// bestOutsideDomain returns the highest-scoring eligible member whose
+// failure domain differs from domain, or nil when there is none.
+func (e *Engine) bestOutsideDomain(domain string) *Member {
+	var best *Member
+	for _, m := range e.roster.All() {
+		if m.State != StateEligible || m.Stowed || m.failureDomain() == domain {
+			continue
+		}
+		if best == nil || m.Final > best.Final {
+			best = m
+		}
+	}
+	return best
+}
+
+func (e *Engine) member(name string) *Member {
+	for _, m := range e.roster.All() {
+		if m.Name == name {
+			return m
+		}
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package engine
+
+// Cellular session establishment. A standby modem that looks healthy on
+// signal metrics can still take a long time to actually pass traffic once
+// promoted — the data session has to be (re)established, and some
+// SIM/operator combinations are far slower at it than others. The engine
+// times every promotion of a cellular member until its first clean sample,
+// keeps a per-operator history of those times, and uses the history to
+// pre-warm slow sessions with a probe burst as soon as the active member
+// starts degrading, so the session is already up when the failover lands.
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/metrics"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/probe"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/telemetry"
+)
+
+// DefaultEstablishPath is where the per-operator history persists.
+const DefaultEstablishPath = "/tmp/starfail/establish.json"
+
+const (
+	// establishTimeout abandons a measurement that never completes (the
+	// member failed right back out, or the switch was reverted).
+	establishTimeout = 2 * time.Minute
+	// establishAlpha is the history EWMA weight for the newest measurement.
+	establishAlpha = 0.3
+	// prewarmWorthwhile is the expected establishment time above which a
+	// standby session is worth pre-warming; faster sessions come up on
+	// their own within a tick anyway. Unknown operators are always warmed.
+	prewarmWorthwhile = 3.0 // seconds
+	// prewarmInterval rate-limits warming per member.
+	prewarmInterval = 30 * time.Second
+	// prewarmCount is the probe burst size; enough to force the modem to
+	// bring the data session up, small enough to not matter on metered SIMs.
+	prewarmCount = 3
+)
+
+// prewarmProbe sends the warming burst; a variable so tests can observe
+// warming without pinging anything. The default implementation backgrounds
+// the burst itself so the tick never waits on it, while test stubs stay
+// synchronous.
+var prewarmProbe = func(ctx context.Context, iface, class string) {
+	go func() {
+		pctx, cancel := context.WithTimeout(ctx, maxCollectTimeout)
+		defer cancel()
+		probe.Run(pctx, iface, probe.ProfileFor(class), prewarmCount)
+	}()
+}
+
+// establishRun is one in-flight measurement.
+type establishRun struct {
+	member  string
+	started time.Time
+}
+
+// establishStats is the per-operator history entry.
+type establishStats struct {
+	EWMASeconds float64 `json:"ewma_seconds"`
+	LastSeconds float64 `json:"last_seconds"`
+	Count       int     `json:"count"`
+}
+
+// establishTracker keeps the per-operator history. Like the budget
+// tracker it is only touched from the engine tick, so no lock.
+type establishTracker struct {
+	path  string
+	stats map[string]*establishStats
+}
+
+// newEstablishTracker restores the history from path ("" for the default).
+func newEstablishTracker(path string) *establishTracker {
+	if path == "" {
+		path = DefaultEstablishPath
+	}
+	t := &establishTracker{path: path, stats: map[string]*establishStats{}}
+	if raw, err := os.ReadFile(path); err == nil {
+		_ = json.Unmarshal(raw, &t.stats)
+	}
+	return t
+}
+
+// record folds one measurement into the operator's history and persists.
+func (t *establishTracker) record(key string, seconds float64) *establishStats {
+	s := t.stats[key]
+	if s == nil {
+		s = &establishStats{EWMASeconds: seconds}
+		t.stats[key] = s
+	} else {
+		s.EWMASeconds = establishAlpha*seconds + (1-establishAlpha)*s.EWMASeconds
+	}
+	s.LastSeconds = seconds
+	s.Count++
+	t.persist()
+	return s
+}
+
+// expected returns the operator's expected establishment time; ok is
+// false when there is no history yet.
+func (t *establishTracker) expected(key string) (float64, bool) {
+	s := t.stats[key]
+	if s == nil {
+		return 0, false
+	}
+	return s.EWMASeconds, true
+}
+
+// persist writes the history atomically; errors are logged, never fatal.
+func (t *establishTracker) persist() {
+	if err := os.MkdirAll(filepath.Dir(t.path), 0o755); err != nil {
+		log.Printf("engine: persist establish history: %v", err)
+		return
+	}
+	raw, err := json.Marshal(t.stats)
+	if err != nil {
+		return
+	}
+	tmp := t.path + ".tmp"
+	if os.WriteFile(tmp, raw, 0o644) == nil {
+		_ = os.Rename(tmp, t.path)
+	}
+}
+
+// establishKey buckets history by operator — the SIM's current network is
+// what determines session behaviour — falling back to the member name
+// before the first sample names one.
+func establishKey(m *Member) string {
+	if m.LastSample != nil && m.LastSample.Operator != "" {
+		return m.LastSample.Operator
+	}
+	return m.Name
+}
+
+// startEstablish begins timing a cellular promotion; called from switchTo.
+func (e *Engine) startEstablish(m *Member) {
+	if m.Class != "cellular" {
+		return
+	}
+	e.establishing = &establishRun{member: m.Name, started: time.Now()}
+}
+
+// noteEstablish closes an in-flight measurement when the promoted member's
+// sample shows traffic passing; called once per tick after sampling.
+func (e *Engine) noteEstablish(m *Member, sample *metrics.Metrics) {
+	run := e.establishing
+	if run == nil || run.member != m.Name {
+		return
+	}
+	if run.member != e.active || time.Since(run.started) > establishTimeout {
+		e.establishing = nil // reverted or hopeless; nothing to learn
+		return
+	}
+	if sample.LatencyMS == nil || sample.LossPercent == nil || *sample.LossPercent >= 100 {
+		return // not passing traffic yet
+	}
+	e.establishing = nil
+	seconds := time.Since(run.started).Seconds()
+	key := establishKey(m)
+	stats := e.establish.record(key, seconds)
+	e.store.AddEvent(telemetry.Event{
+		Timestamp: time.Now(), Type: "session_established", Member: m.Name,
+		Reason: fmt.Sprintf("passing traffic %.1fs after promotion", seconds),
+		Details: map[string]any{
+			"duration_s": seconds, "operator": key, "ewma_s": stats.EWMASeconds,
+		},
+	})
+}
+
+// prewarmStandbys warms slow cellular sessions while the active member is
+// degrading; called once per tick before the decision.
+func (e *Engine) prewarmStandbys(ctx context.Context, members []*Member) {
+	active := e.member(e.active)
+	if active == nil || !active.degraded || active.Class == "cellular" {
+		return
+	}
+	now := time.Now()
+	for _, m := range members {
+		if m.Class != "cellular" || m.Name == e.active || m.State != StateEligible || m.Stowed {
+			continue
+		}
+		if est, known := e.establish.expected(establishKey(m)); known && est < prewarmWorthwhile {
+			continue
+		}
+		if now.Sub(m.lastPrewarm) < prewarmInterval {
+			continue
+		}
+		m.lastPrewarm = now
+		log.Printf("engine: pre-warming %s session while %s degrades", m.Name, e.active)
+		e.store.AddEvent(telemetry.Event{
+			Timestamp: now, Type: "session_prewarm", Member: m.Name,
+			Reason: fmt.Sprintf("%s degrading; warming standby session", e.active),
+		})
+		prewarmProbe(ctx, m.BindIface(), m.Class)
+	}
+}
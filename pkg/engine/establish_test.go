@@ -0,0 +1,142 @@
+package engine
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/metrics"
+)
+
+func newEstablishTestEngine(t *testing.T) *Engine {
+	t.Helper()
+	e := newForceTestEngine(t)
+	e.establish = newEstablishTracker(filepath.Join(t.TempDir(), "establish.json"))
+	return e
+}
+
+func passingSample(operator string) *metrics.Metrics {
+	return &metrics.Metrics{
+		Timestamp:   time.Now(),
+		LatencyMS:   metrics.Milliseconds(40).Ptr(),
+		LossPercent: metrics.Percent(0).Ptr(),
+		Operator:    operator,
+	}
+}
+
+func TestEstablishMeasuredOnCellularPromotion(t *testing.T) {
+	e := newEstablishTestEngine(t)
+	cell := e.member("cellular")
+	e.switchTo(cell, ReasonManual)
+	if e.establishing == nil {
+		t.Fatal("promotion did not start a measurement")
+	}
+	e.establishing.started = time.Now().Add(-8 * time.Second)
+
+	// First sample still shows total loss: session not up yet.
+	lossy := passingSample("Telia SE")
+	lossy.LossPercent = metrics.Percent(100).Ptr()
+	cell.LastSample = lossy
+	e.noteEstablish(cell, lossy)
+	if e.establishing == nil {
+		t.Fatal("measurement closed on a 100%-loss sample")
+	}
+
+	sample := passingSample("Telia SE")
+	cell.LastSample = sample
+	e.noteEstablish(cell, sample)
+	if e.establishing != nil {
+		t.Fatal("measurement still open after a clean sample")
+	}
+	est, ok := e.establish.expected("Telia SE")
+	if !ok || est < 7 || est > 10 {
+		t.Errorf("expected establish = (%.1f, %v), want ~8s", est, ok)
+	}
+	found := false
+	for _, ev := range e.store.Events(0) {
+		if ev.Type == "session_established" && ev.Member == "cellular" {
+			found = true
+			if ev.Details["operator"] != "Telia SE" {
+				t.Errorf("operator detail = %v", ev.Details["operator"])
+			}
+		}
+	}
+	if !found {
+		t.Error("no session_established event recorded")
+	}
+}
+
+func TestEstablishAbandonedWhenReverted(t *testing.T) {
+	e := newEstablishTestEngine(t)
+	cell := e.member("cellular")
+	e.switchTo(cell, ReasonManual)
+	e.switchTo(e.member("starlink"), ReasonManual) // switched right back
+
+	sample := passingSample("Telia SE")
+	e.noteEstablish(cell, sample)
+	if e.establishing != nil {
+		t.Fatal("reverted promotion left a measurement open")
+	}
+	if _, ok := e.establish.expected("Telia SE"); ok {
+		t.Error("reverted promotion recorded history")
+	}
+}
+
+func TestEstablishTrackerHistoryRoundTrips(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "establish.json")
+	tr := newEstablishTracker(path)
+	tr.record("Telia SE", 10)
+	tr.record("Telia SE", 20) // EWMA: 0.3*20 + 0.7*10 = 13
+	if est, ok := tr.expected("Telia SE"); !ok || est < 12.9 || est > 13.1 {
+		t.Fatalf("ewma = (%.2f, %v), want 13", est, ok)
+	}
+	tr2 := newEstablishTracker(path)
+	est, ok := tr2.expected("Telia SE")
+	if !ok || est < 12.9 || est > 13.1 {
+		t.Errorf("reloaded ewma = (%.2f, %v), want 13", est, ok)
+	}
+	if tr2.stats["Telia SE"].Count != 2 {
+		t.Errorf("reloaded count = %d, want 2", tr2.stats["Telia SE"].Count)
+	}
+}
+
+func TestPrewarmOnlySlowStandbysWhileDegrading(t *testing.T) {
+	e := newEstablishTestEngine(t)
+	var warmed []string
+	orig := prewarmProbe
+	prewarmProbe = func(_ context.Context, iface, _ string) { warmed = append(warmed, iface) }
+	t.Cleanup(func() { prewarmProbe = orig })
+
+	e.switchTo(e.member("starlink"), ReasonManual)
+	members := e.roster.All()
+
+	// Active healthy: no warming.
+	e.prewarmStandbys(context.Background(), members)
+	if len(warmed) != 0 {
+		t.Fatalf("warmed %v with a healthy active member", warmed)
+	}
+
+	// Degrading active, no history for the standby: warm it.
+	e.member("starlink").degraded = true
+	e.prewarmStandbys(context.Background(), members)
+	if len(warmed) != 1 || warmed[0] != "mob1s1a1" {
+		t.Fatalf("warmed = %v, want the cellular iface once", warmed)
+	}
+
+	// Rate limit holds within prewarmInterval.
+	e.prewarmStandbys(context.Background(), members)
+	if len(warmed) != 1 {
+		t.Fatalf("rate limit did not hold: %v", warmed)
+	}
+
+	// A known-fast operator is not worth warming.
+	cell := e.member("cellular")
+	cell.lastPrewarm = time.Time{}
+	cell.LastSample = passingSample("Telia SE")
+	e.establish.record("Telia SE", 1)
+	e.prewarmStandbys(context.Background(), members)
+	if len(warmed) != 1 {
+		t.Fatalf("warmed a fast-establishing operator: %v", warmed)
+	}
+}
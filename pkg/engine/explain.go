@@ -0,0 +1,74 @@
+package engine
+
+import (
+	"fmt"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/metrics"
+)
+
+// Explanation breaks a member's current score into its components so users
+// can see exactly why a member scores the way it does. All penalty values
+// are points subtracted from 100; bias and adjustment are signed.
+type Explanation struct {
+	Member  string `json:"member"`
+	Status  State  `json:"status"`
+	Profile string `json:"profile"`
+
+	// Raw inputs from the last sample.
+	Inputs *metrics.Metrics `json:"inputs"`
+
+	// Penalty components of the instant score.
+	LatencyPenalty     float64   `json:"latency_penalty"`
+	LossPenalty        float64   `json:"loss_penalty"`
+	ObstructionPenalty float64   `json:"obstruction_penalty"`
+	BloatPenalty       float64   `json:"bloat_penalty"`
+	Bloat              BloatInfo `json:"bloat"`
+	Instant            float64   `json:"instant"`
+
+	// Smoothing terms and their blend weights.
+	EWMA         float64 `json:"ewma"`
+	WindowAvg    float64 `json:"window_avg"`
+	BlendInstant float64 `json:"blend_instant"`
+	BlendEWMA    float64 `json:"blend_ewma"`
+	BlendWindow  float64 `json:"blend_window"`
+
+	// Signed modifiers applied on top of the blend.
+	WeightBias           float64 `json:"weight_bias"`
+	PredictiveAdjustment float64 `json:"predictive_adjustment"`
+	PredictiveReason     string  `json:"predictive_reason,omitempty"`
+
+	Final float64 `json:"final"`
+}
+
+// Explain returns the score breakdown for the named member, or an error if
+// the member is unknown or has not been sampled yet.
+func (e *Engine) Explain(name string) (*Explanation, error) {
+	m := e.member(name)
+	if m == nil {
+		return nil, fmt.Errorf("unknown member %q", name)
+	}
+	if m.LastSample == nil {
+		return nil, fmt.Errorf("member %q has no samples yet", name)
+	}
+	return &Explanation{
+		Member:               m.Name,
+		Status:               m.State,
+		Profile:              e.profile.name,
+		Inputs:               m.LastSample,
+		LatencyPenalty:       latencyPenalty(m.LastSample, e.profile.jitterWeight),
+		LossPenalty:          lossPenalty(m.LastSample),
+		ObstructionPenalty:   obstructionPenalty(m.LastSample),
+		BloatPenalty:         bloatPenalty(m.Bloat),
+		Bloat:                m.Bloat,
+		Instant:              m.Instant,
+		EWMA:                 m.EWMA,
+		WindowAvg:            m.WindowAvg,
+		BlendInstant:         e.cfg.BlendInstant,
+		BlendEWMA:            e.cfg.BlendEWMA,
+		BlendWindow:          e.cfg.BlendWindow,
+		WeightBias:           weightBias(m.Weight),
+		PredictiveAdjustment: m.Predictive.Adjustment,
+		PredictiveReason:     m.Predictive.Reason,
+		Final:                m.Final,
+	}, nil
+}
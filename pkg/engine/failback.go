@@ -0,0 +1,97 @@
+package engine
+
+// Failback confidence. Graduating a failed member out of cooldown used to
+// be a bare timer — healthy for RestoreWindow, done. That single factor
+// says nothing about *how* healthy the member has been, so the timer is
+// now the largest input to a weighted composite: time spent clean, the
+// consecutive-clean-probe streak, whether a real throughput sample backs
+// the recovery up, and the score trend. The composite is exposed per
+// member over ubus so an operator can see exactly how close a failed
+// member is to coming back, and which factor is holding it.
+
+import (
+	"time"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/metrics"
+)
+
+// Factor weights (summing to 100) and their saturation points.
+const (
+	confDurationWeight   = 40.0
+	confStreakWeight     = 30.0
+	confThroughputWeight = 15.0
+	confTrendWeight      = 15.0
+
+	// confStreakTarget is how many consecutive loss-free samples earn the
+	// full streak weight.
+	confStreakTarget = 6
+	// confThroughputFloorMbps is the least measured downlink that counts
+	// as a usable throughput sample.
+	confThroughputFloorMbps = 1.0
+
+	// failbackConfidenceThreshold is what the composite must reach before
+	// cooldown graduates to warmup. The duration and streak factors alone
+	// (70) cannot clear it: at least one corroborating factor must agree.
+	failbackConfidenceThreshold = 85.0
+)
+
+// FailbackConfidence is the composite and its factor breakdown, each
+// factor already scaled to its weight.
+type FailbackConfidence struct {
+	Total        float64 `json:"total"`
+	DurationGood float64 `json:"duration_good"`
+	ProbeStreak  float64 `json:"probe_streak"`
+	Throughput   float64 `json:"throughput"`
+	Trend        float64 `json:"trend"`
+}
+
+// failbackConfidence computes the composite for one member; window is the
+// profile-scaled restore window feeding the duration factor.
+func (e *Engine) failbackConfidence(m *Member, window time.Duration, now time.Time) FailbackConfidence {
+	var c FailbackConfidence
+	if m.State == StateCooldown && window > 0 {
+		frac := now.Sub(m.Since).Seconds() / window.Seconds()
+		if frac > 1 {
+			frac = 1
+		}
+		c.DurationGood = confDurationWeight * frac
+	}
+	streak := float64(m.cleanStreak) / confStreakTarget
+	if streak > 1 {
+		streak = 1
+	}
+	c.ProbeStreak = confStreakWeight * streak
+	if ls := m.LastSample; ls != nil && ls.DownlinkMbps != nil && float64(*ls.DownlinkMbps) >= confThroughputFloorMbps {
+		c.Throughput = confThroughputWeight
+	}
+	// A flat or rising score corroborates; a falling one withholds the
+	// weight even if everything else looks served.
+	if e.scoreTrend(m.Name) > -0.5 {
+		c.Trend = confTrendWeight
+	}
+	c.Total = c.DurationGood + c.ProbeStreak + c.Throughput + c.Trend
+	return c
+}
+
+// FailbackConfidence reports how close the named member is to being
+// restored; nil for unknown members and for members that are not on the
+// failback path at all.
+func (e *Engine) FailbackConfidence(name string) *FailbackConfidence {
+	m := e.member(name)
+	if m == nil || (m.State != StateFailed && m.State != StateCooldown) {
+		return nil
+	}
+	window := time.Duration(e.profile.restoreWindowScale * float64(e.cfg.RestoreWindowS) * float64(time.Second))
+	c := e.failbackConfidence(m, window, time.Now())
+	return &c
+}
+
+// noteProbeStreak maintains the consecutive-clean-sample counter; called
+// once per tick for every sampled member.
+func (e *Engine) noteProbeStreak(m *Member, sample *metrics.Metrics) {
+	if sample.LossPercent != nil && *sample.LossPercent == 0 {
+		m.cleanStreak++
+	} else {
+		m.cleanStreak = 0
+	}
+}
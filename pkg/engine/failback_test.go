@@ -0,0 +1,98 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/metrics"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/telemetry"
+)
+
+func TestFailbackConfidenceFactors(t *testing.T) {
+	e := newForceTestEngine(t)
+	m := e.member("cellular")
+	now := time.Now()
+	window := 300 * time.Second
+
+	// Fresh cooldown, no streak, no throughput, no trend data (flat counts
+	// as corroborating): only the trend weight.
+	m.Lifecycle = Lifecycle{State: StateCooldown, Since: now}
+	c := e.failbackConfidence(m, window, now)
+	if c.Total != confTrendWeight {
+		t.Fatalf("fresh cooldown total = %.1f, want %.1f", c.Total, confTrendWeight)
+	}
+
+	// Everything served: full composite.
+	m.Lifecycle = Lifecycle{State: StateCooldown, Since: now.Add(-window)}
+	m.cleanStreak = confStreakTarget
+	m.LastSample = &metrics.Metrics{DownlinkMbps: metrics.Mbps(25).Ptr()}
+	c = e.failbackConfidence(m, window, now)
+	if c.Total != 100 {
+		t.Fatalf("full composite = %.1f (%+v), want 100", c.Total, c)
+	}
+	if c.Total < failbackConfidenceThreshold {
+		t.Fatal("full composite does not clear the graduation threshold")
+	}
+
+	// Half the window served, half the streak: duration and streak scale.
+	m.Lifecycle = Lifecycle{State: StateCooldown, Since: now.Add(-window / 2)}
+	m.cleanStreak = confStreakTarget / 2
+	c = e.failbackConfidence(m, window, now)
+	if c.DurationGood != confDurationWeight/2 || c.ProbeStreak != confStreakWeight/2 {
+		t.Errorf("scaled factors = %+v", c)
+	}
+}
+
+func TestFailbackConfidenceFallingTrendWithholds(t *testing.T) {
+	e := newForceTestEngine(t)
+	m := e.member("cellular")
+	now := time.Now()
+	for i, score := range []float64{85, 65} {
+		e.store.AddSample(telemetry.Sample{
+			Member:  "cellular",
+			Score:   score,
+			Metrics: &metrics.Metrics{Timestamp: now.Add(time.Duration(i) * time.Minute)},
+		})
+	}
+	m.Lifecycle = Lifecycle{State: StateCooldown, Since: now.Add(-time.Hour)}
+	c := e.failbackConfidence(m, 300*time.Second, now)
+	if c.Trend != 0 {
+		t.Errorf("falling trend granted %.1f, want 0", c.Trend)
+	}
+}
+
+func TestFailbackConfidenceExposedOnlyWhenRelevant(t *testing.T) {
+	e := newForceTestEngine(t)
+	if fc := e.FailbackConfidence("starlink"); fc != nil {
+		t.Errorf("eligible member exposed confidence %+v", fc)
+	}
+	e.member("starlink").Lifecycle = Lifecycle{State: StateCooldown, Since: time.Now()}
+	if fc := e.FailbackConfidence("starlink"); fc == nil {
+		t.Error("cooldown member exposed no confidence")
+	}
+	if fc := e.FailbackConfidence("nope"); fc != nil {
+		t.Error("unknown member exposed confidence")
+	}
+}
+
+func TestNoteProbeStreak(t *testing.T) {
+	e := newForceTestEngine(t)
+	m := e.member("cellular")
+	clean := &metrics.Metrics{LossPercent: metrics.Percent(0).Ptr()}
+	lossy := &metrics.Metrics{LossPercent: metrics.Percent(10).Ptr()}
+	for i := 0; i < 3; i++ {
+		e.noteProbeStreak(m, clean)
+	}
+	if m.cleanStreak != 3 {
+		t.Fatalf("streak = %d, want 3", m.cleanStreak)
+	}
+	e.noteProbeStreak(m, lossy)
+	if m.cleanStreak != 0 {
+		t.Fatalf("streak = %d after loss, want 0", m.cleanStreak)
+	}
+	// No loss reading at all is not a clean probe.
+	e.noteProbeStreak(m, &metrics.Metrics{})
+	if m.cleanStreak != 0 {
+		t.Fatalf("streak = %d without a loss reading, want 0", m.cleanStreak)
+	}
+}
@@ -0,0 +1,87 @@
+package engine
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/metrics"
+)
+
+// fastStartBudget bounds the whole boot election: one parallel collection
+// round, then a pick. Long enough for a probe burst, far short of the
+// several minutes the smoothing windows need.
+const fastStartBudget = 10 * time.Second
+
+// FastStart elects an active member right after boot from a single burst
+// of parallel collections, scored on instant values only (no EWMA or
+// window history exists yet), so the router is not stuck on a bad default
+// route while normal hysteresis warms up. It is a no-op when a restored
+// snapshot already names an active member. Members that fail to produce a
+// valid sample inside the budget simply do not stand for election; the
+// regular loop takes over afterwards either way.
+func (e *Engine) FastStart(ctx context.Context) {
+	if e.active != "" {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, fastStartBudget)
+	defer cancel()
+
+	type candidate struct {
+		member *Member
+		sample *metrics.Metrics
+		score  float64
+	}
+	var (
+		mu         sync.Mutex
+		candidates []candidate
+		wg         sync.WaitGroup
+	)
+	for _, m := range e.roster.All() {
+		c, ok := e.registry.ForClass(m.Class)
+		if !ok {
+			continue
+		}
+		wg.Add(1)
+		go func(m *Member) {
+			defer wg.Done()
+			sample, err := c.Collect(ctx, m.BindIface())
+			if err != nil {
+				log.Printf("engine: fast-start collect %s: %v", m.Name, err)
+				return
+			}
+			if err := sample.Validate(); err != nil {
+				log.Printf("engine: fast-start %s sample rejected: %v", m.Name, err)
+				return
+			}
+			if sample.DataSource == metrics.SourceMock && !e.cfg.AllowMockMetrics {
+				return
+			}
+			mu.Lock()
+			candidates = append(candidates, candidate{
+				member: m,
+				sample: sample,
+				score:  e.instantScore(sample) + weightBias(m.Weight),
+			})
+			mu.Unlock()
+		}(m)
+	}
+	wg.Wait()
+
+	var best *candidate
+	for i := range candidates {
+		if best == nil || candidates[i].score > best.score {
+			best = &candidates[i]
+		}
+	}
+	if best == nil {
+		log.Println("engine: fast-start found no usable member, waiting for the regular loop")
+		return
+	}
+	// Seed the member's scoring state from the burst so the first regular
+	// ticks continue from it rather than from zero.
+	e.scoreMember(ctx, best.member, best.sample)
+	log.Printf("engine: fast-start electing %s (score %.1f)", best.member.Name, best.member.Final)
+	e.switchTo(best.member, ReasonFastStart)
+}
@@ -0,0 +1,98 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/collector"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/controller"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/metrics"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/telemetry"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/uci"
+)
+
+// fakeCollector serves canned latencies per interface.
+type fakeCollector struct {
+	class     string
+	latencies map[string]float64 // iface -> ms; missing iface errors
+}
+
+func (f *fakeCollector) Class() string { return f.class }
+
+func (f *fakeCollector) Collect(_ context.Context, iface string) (*metrics.Metrics, error) {
+	lat, ok := f.latencies[iface]
+	if !ok {
+		return nil, fmt.Errorf("iface %s down", iface)
+	}
+	return &metrics.Metrics{
+		Timestamp:   time.Now(),
+		DataSource:  metrics.SourceProbe,
+		LatencyMS:   metrics.Milliseconds(lat).Ptr(),
+		LossPercent: metrics.Percent(0).Ptr(),
+	}, nil
+}
+
+func fastStartEngine(t *testing.T, fakes ...*fakeCollector) *Engine {
+	t.Helper()
+	cfg := &uci.Config{
+		FailScore: 40, RestoreScore: 70, RestoreWindowS: 300,
+		CooldownS: 60, WarmupS: 30, PollIntervalS: 5,
+		Members: []uci.MemberConfig{
+			{Name: "starlink", Iface: "wan", Class: "starlink", Weight: 50},
+			{Name: "cellular", Iface: "mob1s1a1", Class: "cellular", Weight: 50},
+		},
+	}
+	reg := collector.NewRegistry()
+	for _, f := range fakes {
+		reg.Register(f)
+	}
+	ctl := controller.New(func() []string { return []string{"starlink", "cellular"} })
+	ctl.Noop = true
+	e := New(cfg, reg, ctl, telemetry.NewStore(t.TempDir()), nil)
+	e.SetStatePath(filepath.Join(t.TempDir(), "state.json"))
+	return e
+}
+
+func TestFastStartPicksBest(t *testing.T) {
+	e := fastStartEngine(t,
+		&fakeCollector{class: "starlink", latencies: map[string]float64{"wan": 450}},
+		&fakeCollector{class: "cellular", latencies: map[string]float64{"mob1s1a1": 60}},
+	)
+	e.FastStart(context.Background())
+	if e.Active() != "cellular" {
+		t.Fatalf("active = %q, want cellular (lower latency burst)", e.Active())
+	}
+}
+
+func TestFastStartSkipsFailedCollectors(t *testing.T) {
+	e := fastStartEngine(t,
+		&fakeCollector{class: "starlink", latencies: nil}, // dish unreachable
+		&fakeCollector{class: "cellular", latencies: map[string]float64{"mob1s1a1": 80}},
+	)
+	e.FastStart(context.Background())
+	if e.Active() != "cellular" {
+		t.Fatalf("active = %q, want cellular", e.Active())
+	}
+}
+
+func TestFastStartNoCandidates(t *testing.T) {
+	e := fastStartEngine(t)
+	e.FastStart(context.Background())
+	if e.Active() != "" {
+		t.Fatalf("active = %q, want no election without candidates", e.Active())
+	}
+}
+
+func TestFastStartSkippedWithRestoredActive(t *testing.T) {
+	e := fastStartEngine(t,
+		&fakeCollector{class: "cellular", latencies: map[string]float64{"mob1s1a1": 10}},
+	)
+	e.active = "starlink" // as restored from a snapshot
+	e.FastStart(context.Background())
+	if e.Active() != "starlink" {
+		t.Fatalf("active = %q, want restored starlink untouched", e.Active())
+	}
+}
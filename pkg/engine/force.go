@@ -0,0 +1,88 @@
+package engine
+
+// Manual failover pinning. A forced member suspends automatic decisions so
+// the operator's choice sticks; an optional TTL reverts to automatic mode
+// when it expires, because "I forced cellular last month and forgot" is a
+// real failure mode on set-and-forget installs.
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/notifications"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/telemetry"
+)
+
+// revertWarning is how far ahead of a TTL expiry the pre-revert
+// notification goes out.
+const revertWarning = time.Minute
+
+// Force pins member as the active choice and switches to it. A ttl of zero
+// pins until Unforce; otherwise automatic decisions resume when it expires.
+func (e *Engine) Force(name string, ttl time.Duration) error {
+	m := e.member(name)
+	if m == nil {
+		return fmt.Errorf("unknown member %q", name)
+	}
+	e.forced = name
+	e.forcedUntil = time.Time{}
+	if ttl > 0 {
+		e.forcedUntil = time.Now().Add(ttl)
+	}
+	e.forceWarned = false
+	e.switchTo(m, ReasonManual)
+	return nil
+}
+
+// Unforce drops a manual pin; the next tick decides automatically again.
+func (e *Engine) Unforce() {
+	e.forced = ""
+	e.forcedUntil = time.Time{}
+}
+
+// Forced returns the pinned member ("" when none) and the pin's expiry
+// (zero when indefinite).
+func (e *Engine) Forced() (string, time.Time) { return e.forced, e.forcedUntil }
+
+// forcedHold reports whether a manual pin is holding automatic decisions
+// off. It emits the pre-revert warning as the TTL approaches and, once it
+// expires, clears the pin with an event and notification.
+func (e *Engine) forcedHold(now time.Time) bool {
+	if e.forced == "" {
+		return false
+	}
+	if e.forcedUntil.IsZero() {
+		return true
+	}
+	remaining := e.forcedUntil.Sub(now)
+	if remaining > 0 {
+		if remaining <= revertWarning && !e.forceWarned {
+			e.forceWarned = true
+			e.store.AddEvent(telemetry.Event{
+				Timestamp: now, Type: "manual_revert_pending", Member: e.forced,
+				Reason: fmt.Sprintf("reverting to automatic failover in %s", remaining.Round(time.Second)),
+			})
+			if e.notifier != nil {
+				e.notifier.Notify("manual_revert", notifications.PriorityNormal, notifications.EventData{
+					Member: e.forced,
+					Reason: fmt.Sprintf("manual failover expires in %s", remaining.Round(time.Second)),
+				})
+			}
+		}
+		return true
+	}
+	member := e.forced
+	e.Unforce()
+	e.store.AddEvent(telemetry.Event{
+		Timestamp: now, Type: "manual_revert", Member: member,
+		Reason:  ReasonManualRevert.Text(),
+		Details: map[string]any{"reason_code": string(ReasonManualRevert)},
+	})
+	if e.notifier != nil {
+		e.notifier.Notify("manual_revert", notifications.PriorityNormal, notifications.EventData{
+			Member: member,
+			Reason: "manual failover TTL expired; automatic decisions resumed",
+		})
+	}
+	return false
+}
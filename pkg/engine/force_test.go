@@ -0,0 +1,88 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/collector"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/controller"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/telemetry"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/uci"
+)
+
+func newForceTestEngine(t *testing.T) *Engine {
+	t.Helper()
+	cfg := &uci.Config{
+		FailScore: 40, RestoreScore: 70, RestoreWindowS: 300,
+		CooldownS: 0, WarmupS: 30, PollIntervalS: 5,
+		Members: []uci.MemberConfig{
+			{Name: "starlink", Iface: "wan", Class: "starlink", Weight: 50},
+			{Name: "cellular", Iface: "mob1s1a1", Class: "cellular", Weight: 50},
+		},
+	}
+	ctl := controller.New(nil)
+	ctl.Noop = true
+	e := New(cfg, collector.NewRegistry(), ctl, telemetry.NewStore(t.TempDir()), nil)
+	e.SetStatePath(t.TempDir() + "/state.json")
+	// Both members healthy, starlink clearly better.
+	for _, m := range e.Members() {
+		m.Final = 90
+	}
+	e.member("cellular").Final = 60
+	return e
+}
+
+func TestForceHoldsDecisions(t *testing.T) {
+	e := newForceTestEngine(t)
+	if err := e.Force("cellular", 0); err != nil {
+		t.Fatalf("force: %v", err)
+	}
+	if e.Active() != "cellular" {
+		t.Fatalf("active = %q after force", e.Active())
+	}
+	// Automatic decisions would prefer starlink; the pin must hold.
+	e.makeDecision()
+	if e.Active() != "cellular" {
+		t.Errorf("pin did not hold: active = %q", e.Active())
+	}
+	e.Unforce()
+	e.makeDecision()
+	if e.Active() != "starlink" {
+		t.Errorf("after unforce active = %q, want starlink", e.Active())
+	}
+}
+
+func TestForceUnknownMember(t *testing.T) {
+	e := newForceTestEngine(t)
+	if err := e.Force("nope", 0); err == nil {
+		t.Error("forcing an unknown member did not error")
+	}
+}
+
+func TestForceTTLExpiryReverts(t *testing.T) {
+	e := newForceTestEngine(t)
+	if err := e.Force("cellular", time.Hour); err != nil {
+		t.Fatalf("force: %v", err)
+	}
+	// Mid-TTL the pin holds.
+	if !e.forcedHold(time.Now()) {
+		t.Fatal("pin released before TTL")
+	}
+	// Inside the warning window an advance notice goes out once.
+	warnAt := e.forcedUntil.Add(-30 * time.Second)
+	e.forcedHold(warnAt)
+	if !e.forceWarned {
+		t.Error("no pre-revert warning inside the warning window")
+	}
+	// Past the TTL the pin clears and automatic decisions resume.
+	if e.forcedHold(e.forcedUntil.Add(time.Second)) {
+		t.Fatal("pin still holding after TTL expiry")
+	}
+	if forced, _ := e.Forced(); forced != "" {
+		t.Errorf("forced = %q after expiry", forced)
+	}
+	e.makeDecision()
+	if e.Active() != "starlink" {
+		t.Errorf("after revert active = %q, want starlink", e.Active())
+	}
+}
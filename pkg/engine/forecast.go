@@ -0,0 +1,22 @@
+package engine
+
+import (
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/predictive"
+)
+
+// Forecast returns the expected quality over the next horizonMin minutes
+// for every member, keyed by name, or nil when the installed assessor
+// cannot project forward (predictive disabled, or a purely external
+// model). Served over ubus and HTTP for LAN applications that want to
+// schedule transfers into good windows.
+func (e *Engine) Forecast(horizonMin int) map[string][]predictive.ForecastSlot {
+	f, ok := e.assessor.(predictive.Forecaster)
+	if !ok {
+		return nil
+	}
+	out := make(map[string][]predictive.ForecastSlot, e.roster.Len())
+	for _, m := range e.roster.All() {
+		out[m.Name] = f.Forecast(m.Final, e.scoreTrend(m.Name), horizonMin)
+	}
+	return out
+}
@@ -0,0 +1,25 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/predictive"
+)
+
+func TestEngineForecastPerMember(t *testing.T) {
+	e := newForceTestEngine(t)
+	out := e.Forecast(60)
+	if out == nil {
+		t.Fatal("forecast unavailable with the built-in heuristic")
+	}
+	for _, m := range e.Members() {
+		if len(out[m.Name]) != 6 {
+			t.Errorf("%s: %d slots, want 6", m.Name, len(out[m.Name]))
+		}
+	}
+
+	e.SetAssessor(predictive.Disabled{})
+	if out := e.Forecast(60); out != nil {
+		t.Error("forecast served while predictive is disabled")
+	}
+}
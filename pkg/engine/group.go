@@ -0,0 +1,33 @@
+package engine
+
+import "regexp"
+
+// Two mwan3 members often share one physical modem — an IPv4 and an IPv6
+// interface, or two APNs on the same SIM. Those die together, so failing
+// over between them just burns the cooldown on a switch that cannot help.
+// failureDomain collapses such members into one domain the decision logic
+// can reason about.
+
+// cellularIfacePattern matches RUTOS mobile interface names such as
+// mob1s1a1 or mob1s1a1_6 and captures the modem part ("mob1").
+var cellularIfacePattern = regexp.MustCompile(`^(mob\d+)s\d+a\d+`)
+
+// failureDomain returns the name of the member's physical failure domain.
+// An explicit starfail.<member>.group option wins; cellular interfaces are
+// collapsed to their modem; everything else is its own domain.
+func (m *Member) failureDomain() string {
+	if m.Group != "" {
+		return m.Group
+	}
+	if m.Class == "cellular" {
+		if sub := cellularIfacePattern.FindStringSubmatch(m.Iface); sub != nil {
+			return sub[1]
+		}
+	}
+	return m.Name
+}
+
+// sameDomain reports whether two members share a physical failure domain.
+func sameDomain(a, b *Member) bool {
+	return a != nil && b != nil && a.failureDomain() == b.failureDomain()
+}
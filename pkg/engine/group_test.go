@@ -0,0 +1,85 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/collector"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/controller"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/telemetry"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/uci"
+)
+
+func TestFailureDomain(t *testing.T) {
+	tests := []struct {
+		name   string
+		member Member
+		want   string
+	}{
+		{"explicit group wins", Member{Name: "a", Iface: "mob1s1a1", Class: "cellular", Group: "modem-a"}, "modem-a"},
+		{"cellular ipv4", Member{Name: "cell4", Iface: "mob1s1a1", Class: "cellular"}, "mob1"},
+		{"cellular ipv6 suffix", Member{Name: "cell6", Iface: "mob1s1a1_6", Class: "cellular"}, "mob1"},
+		{"second apn same modem", Member{Name: "cell_apn2", Iface: "mob1s1a2", Class: "cellular"}, "mob1"},
+		{"second modem", Member{Name: "cell2", Iface: "mob2s1a1", Class: "cellular"}, "mob2"},
+		{"non-cellular", Member{Name: "starlink", Iface: "wan", Class: "starlink"}, "starlink"},
+		{"cellular odd iface", Member{Name: "odd", Iface: "wwan0", Class: "cellular"}, "odd"},
+	}
+	for _, tt := range tests {
+		if got := tt.member.failureDomain(); got != tt.want {
+			t.Errorf("%s: failureDomain() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFailoverSkipsSharedModem(t *testing.T) {
+	cfg := &uci.Config{
+		FailScore: 40, RestoreScore: 70, RestoreWindowS: 300,
+		CooldownS: 0, WarmupS: 30, PollIntervalS: 5,
+		Members: []uci.MemberConfig{
+			{Name: "cell4", Iface: "mob1s1a1", Class: "cellular", Weight: 50},
+			{Name: "cell6", Iface: "mob1s1a1_6", Class: "cellular", Weight: 50},
+			{Name: "starlink", Iface: "wan", Class: "starlink", Weight: 50},
+		},
+	}
+	ctl := controller.New(nil)
+	ctl.Noop = true
+	e := New(cfg, collector.NewRegistry(), ctl, telemetry.NewStore(t.TempDir()), nil)
+	e.active = "cell4"
+
+	// The active member just died; its IPv6 sibling still scores highest
+	// (it has not failed yet), but starlink is the usable choice.
+	e.Members()[0].Final = 10
+	e.Members()[0].Lifecycle = Lifecycle{State: StateFailed}
+	e.Members()[1].Final = 90
+	e.Members()[2].Final = 80
+	e.makeDecision()
+
+	if e.active != "starlink" {
+		t.Errorf("active = %q, want starlink (cell6 shares cell4's modem)", e.active)
+	}
+}
+
+func TestFailoverUsesSiblingWhenAlone(t *testing.T) {
+	cfg := &uci.Config{
+		FailScore: 40, RestoreScore: 70, RestoreWindowS: 300,
+		CooldownS: 0, WarmupS: 30, PollIntervalS: 5,
+		Members: []uci.MemberConfig{
+			{Name: "cell4", Iface: "mob1s1a1", Class: "cellular", Weight: 50},
+			{Name: "cell6", Iface: "mob1s1a1_6", Class: "cellular", Weight: 50},
+		},
+	}
+	ctl := controller.New(nil)
+	ctl.Noop = true
+	e := New(cfg, collector.NewRegistry(), ctl, telemetry.NewStore(t.TempDir()), nil)
+	e.active = "cell4"
+
+	e.Members()[0].Final = 10
+	e.Members()[0].Lifecycle = Lifecycle{State: StateFailed}
+	e.Members()[1].Final = 90
+	e.makeDecision()
+
+	// With nothing outside the domain, the sibling is still better than
+	// staying on a dead member.
+	if e.active != "cell6" {
+		t.Errorf("active = %q, want cell6", e.active)
+	}
+}
@@ -0,0 +1,116 @@
+package engine
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/collector"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/controller"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/metrics"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/telemetry"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/uci"
+)
+
+// countingCollector records how often each interface is collected.
+type countingCollector struct {
+	class string
+	lat   float64
+	loss  float64
+
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+func (c *countingCollector) Class() string { return c.class }
+
+func (c *countingCollector) Collect(_ context.Context, iface string) (*metrics.Metrics, error) {
+	c.mu.Lock()
+	if c.calls == nil {
+		c.calls = make(map[string]int)
+	}
+	c.calls[iface]++
+	c.mu.Unlock()
+	return &metrics.Metrics{
+		Timestamp:   time.Now(),
+		DataSource:  metrics.SourceProbe,
+		LatencyMS:   metrics.Milliseconds(c.lat).Ptr(),
+		LossPercent: metrics.Percent(c.loss).Ptr(),
+	}, nil
+}
+
+func (c *countingCollector) count(iface string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.calls[iface]
+}
+
+func TestMeteredThrottlesFailedMembers(t *testing.T) {
+	cfg := &uci.Config{
+		FailScore: 40, RestoreScore: 70, RestoreWindowS: 300,
+		CooldownS: 60, WarmupS: 30, PollIntervalS: 5,
+		BackupPollIntervalS: 3600, MeteredProbeCount: 1,
+		Members: []uci.MemberConfig{
+			{Name: "starlink", Iface: "wan", Class: "starlink", Weight: 50},
+			{Name: "cellular", Iface: "mob1s1a1", Class: "cellular", Weight: 50},
+		},
+	}
+	// Starlink still looks terrible when polled, so it stays failed.
+	sl := &countingCollector{class: "starlink", lat: 2000, loss: 90}
+	cell := &countingCollector{class: "cellular", lat: 60}
+	reg := collector.NewRegistry()
+	reg.Register(sl)
+	reg.Register(cell)
+	ctl := controller.New(nil)
+	ctl.Noop = true
+	e := New(cfg, reg, ctl, telemetry.NewStore(t.TempDir()), nil)
+	e.SetStatePath(t.TempDir() + "/state.json")
+
+	// Starlink is down, cellular carries the traffic.
+	e.active = "cellular"
+	e.Members()[0].Lifecycle = Lifecycle{State: StateFailed, Since: time.Now()}
+
+	for i := 0; i < 3; i++ {
+		e.tick(context.Background())
+	}
+	if got := sl.count("wan"); got != 1 {
+		t.Errorf("starlink collected %d times on metered backup, want 1", got)
+	}
+	if got := cell.count("mob1s1a1"); got != 3 {
+		t.Errorf("cellular collected %d times, want every tick", got)
+	}
+}
+
+func TestUnmeteredPollsEveryTick(t *testing.T) {
+	cfg := &uci.Config{
+		FailScore: 40, RestoreScore: 70, RestoreWindowS: 300,
+		CooldownS: 60, WarmupS: 30, PollIntervalS: 5,
+		BackupPollIntervalS: 3600, MeteredProbeCount: 1,
+		Members: []uci.MemberConfig{
+			{Name: "starlink", Iface: "wan", Class: "starlink", Weight: 50},
+			{Name: "generic", Iface: "wan2", Class: "generic", Weight: 50},
+		},
+	}
+	sl := &countingCollector{class: "starlink", lat: 2000, loss: 90}
+	gen := &countingCollector{class: "generic", lat: 60}
+	reg := collector.NewRegistry()
+	reg.Register(sl)
+	reg.Register(gen)
+	ctl := controller.New(nil)
+	ctl.Noop = true
+	e := New(cfg, reg, ctl, telemetry.NewStore(t.TempDir()), nil)
+	e.SetStatePath(t.TempDir() + "/state.json")
+
+	e.active = "generic"
+	e.Members()[0].Lifecycle = Lifecycle{State: StateFailed, Since: time.Now()}
+
+	for i := 0; i < 3; i++ {
+		e.tick(context.Background())
+	}
+	// The active member is not metered, so the failed member is re-polled
+	// at the normal rate.
+	if got := sl.count("wan"); got != 3 {
+		t.Errorf("starlink collected %d times on unmetered active, want 3", got)
+	}
+}
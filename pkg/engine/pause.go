@@ -0,0 +1,66 @@
+package engine
+
+// Pause/resume of automatic decisions. During maintenance — re-cabling the
+// dish, swapping a modem — every collector reading is garbage and acting on
+// it would flap traffic for no reason. A pause freezes the current member;
+// collection and scoring continue so the operator can watch readings settle.
+// A hard cap bounds forgotten pauses.
+
+import (
+	"time"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/telemetry"
+)
+
+// maxPause caps any pause; a pause requested without a duration (or with a
+// longer one) expires after this long.
+const maxPause = 4 * time.Hour
+
+// Pause suspends automatic decisions and returns when they resume on their
+// own. A non-positive d asks for the maximum; longer requests are clamped.
+func (e *Engine) Pause(reason string, d time.Duration) time.Time {
+	if d <= 0 || d > maxPause {
+		d = maxPause
+	}
+	e.paused = true
+	e.pauseReason = reason
+	e.pauseUntil = time.Now().Add(d)
+	e.store.AddEvent(telemetry.Event{
+		Timestamp: time.Now(), Type: "decisions_paused", Member: e.active,
+		Reason:  reason,
+		Details: map[string]any{"until": e.pauseUntil.Format(time.RFC3339)},
+	})
+	return e.pauseUntil
+}
+
+// Resume re-enables automatic decisions.
+func (e *Engine) Resume(reason string) {
+	if !e.paused {
+		return
+	}
+	e.paused = false
+	e.pauseUntil = time.Time{}
+	e.pauseReason = ""
+	e.store.AddEvent(telemetry.Event{
+		Timestamp: time.Now(), Type: "decisions_resumed", Member: e.active,
+		Reason: reason,
+	})
+}
+
+// Paused returns whether decisions are paused, why, and until when.
+func (e *Engine) Paused() (bool, string, time.Time) {
+	return e.paused, e.pauseReason, e.pauseUntil
+}
+
+// pauseHold reports whether a pause is holding decisions off, auto-resuming
+// once the cap or requested duration passes.
+func (e *Engine) pauseHold(now time.Time) bool {
+	if !e.paused {
+		return false
+	}
+	if now.Before(e.pauseUntil) {
+		return true
+	}
+	e.Resume("pause duration elapsed")
+	return false
+}
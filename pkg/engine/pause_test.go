@@ -0,0 +1,60 @@
+package engine
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPauseFreezesDecisions(t *testing.T) {
+	e := newForceTestEngine(t)
+	e.makeDecision()
+	if e.Active() != "starlink" {
+		t.Fatalf("active = %q before pause", e.Active())
+	}
+	e.Pause("re-cabling the dish", time.Hour)
+	// Starlink collapsing would normally trigger a switch.
+	e.member("starlink").Final = 10
+	e.makeDecision()
+	if e.Active() != "starlink" {
+		t.Errorf("decision made while paused: active = %q", e.Active())
+	}
+	e.Resume("done")
+	e.makeDecision()
+	if e.Active() != "cellular" {
+		t.Errorf("after resume active = %q, want cellular", e.Active())
+	}
+}
+
+func TestPauseDurationIsCapped(t *testing.T) {
+	e := newForceTestEngine(t)
+	until := e.Pause("forgot about it", 0)
+	if d := time.Until(until); d > maxPause+time.Minute {
+		t.Errorf("uncapped pause: %v", d)
+	}
+	if !e.pauseHold(time.Now()) {
+		t.Error("pause not holding")
+	}
+	if e.pauseHold(until.Add(time.Second)) {
+		t.Error("pause still holding past its cap")
+	}
+	if paused, _, _ := e.Paused(); paused {
+		t.Error("still paused after expiry")
+	}
+}
+
+func TestPauseSurvivesRestart(t *testing.T) {
+	e := newForceTestEngine(t)
+	until := e.Pause("maintenance", time.Hour)
+	e.SaveState()
+
+	e2 := newForceTestEngine(t)
+	e2.statePath = e.statePath
+	e2.RestoreState()
+	paused, reason, got := e2.Paused()
+	if !paused || reason != "maintenance" {
+		t.Fatalf("restored pause = %v %q", paused, reason)
+	}
+	if !got.Equal(until) {
+		t.Errorf("restored until = %v, want %v", got, until)
+	}
+}
@@ -0,0 +1,120 @@
+package engine
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// A daemon restart (upgrade, crash, watchdog kick) must not look like a
+// fresh boot to the decision loop: without memory of the last switch and
+// the members' lifecycle states it would re-elect from scratch and
+// possibly flap within seconds. The state file keeps that memory across
+// restarts; it lives on tmpfs, so a full reboot genuinely starts fresh.
+
+// DefaultStatePath is where the decision state persists.
+const DefaultStatePath = "/tmp/starfail/state.json"
+
+// maxStateAge is how old a snapshot may be before restore discards it:
+// after this long offline the world has moved on and replaying stale
+// hysteresis would delay legitimate decisions.
+const maxStateAge = 15 * time.Minute
+
+// memberState is the persisted slice of one member.
+type memberState struct {
+	State State     `json:"state"`
+	Since time.Time `json:"since"`
+}
+
+// decisionState is the snapshot written to disk.
+type decisionState struct {
+	SavedAt    time.Time              `json:"saved_at"`
+	Active     string                 `json:"active"`
+	LastSwitch time.Time              `json:"last_switch"`
+	Members    map[string]memberState `json:"members"`
+
+	// A maintenance pause survives daemon restarts: a watchdog kick
+	// mid-re-cabling must not unfreeze decisions. See pause.go.
+	Paused      bool      `json:"paused,omitempty"`
+	PauseReason string    `json:"pause_reason,omitempty"`
+	PauseUntil  time.Time `json:"pause_until,omitempty"`
+}
+
+// SetStatePath overrides the state file location (tests).
+func (e *Engine) SetStatePath(path string) { e.statePath = path }
+
+// SaveState snapshots the decision state atomically. Called after every
+// tick and on shutdown; errors are logged, never fatal.
+func (e *Engine) SaveState() {
+	s := decisionState{
+		SavedAt:    time.Now(),
+		Active:     e.active,
+		LastSwitch: e.lastSwitch,
+		Members:    make(map[string]memberState, e.roster.Len()),
+	}
+	for _, m := range e.roster.All() {
+		s.Members[m.Name] = memberState{State: m.State, Since: m.Since}
+	}
+	s.Paused = e.paused
+	s.PauseReason = e.pauseReason
+	s.PauseUntil = e.pauseUntil
+	if err := os.MkdirAll(filepath.Dir(e.statePath), 0o755); err != nil {
+		log.Printf("engine: save state: %v", err)
+		return
+	}
+	b, err := json.Marshal(&s)
+	if err != nil {
+		log.Printf("engine: save state: %v", err)
+		return
+	}
+	tmp := e.statePath + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		log.Printf("engine: save state: %v", err)
+		return
+	}
+	if err := os.Rename(tmp, e.statePath); err != nil {
+		log.Printf("engine: save state: %v", err)
+	}
+}
+
+// RestoreState loads the snapshot if it exists and is fresh enough,
+// reinstating the active member, the switch cooldown clock, and each
+// member's lifecycle state. Members that joined or left while the daemon
+// was down simply keep their defaults. Called by main before the decision
+// loop starts; the restored active member is not re-actuated — mwan3
+// still holds whatever metrics the previous instance wrote.
+func (e *Engine) RestoreState() {
+	b, err := os.ReadFile(e.statePath)
+	if err != nil {
+		return // first start, nothing to restore
+	}
+	var s decisionState
+	if err := json.Unmarshal(b, &s); err != nil {
+		log.Printf("engine: restore state: %v", err)
+		return
+	}
+	if age := time.Since(s.SavedAt); age > maxStateAge {
+		log.Printf("engine: discarding decision state from %v ago", age.Round(time.Second))
+		return
+	}
+	for _, m := range e.roster.All() {
+		if ms, ok := s.Members[m.Name]; ok && ms.State != "" {
+			m.Lifecycle = Lifecycle{State: ms.State, Since: ms.Since}
+		}
+	}
+	if s.Active != "" && e.member(s.Active) != nil {
+		e.active = s.Active
+	}
+	e.lastSwitch = s.LastSwitch
+	if s.Paused && time.Now().Before(s.PauseUntil) {
+		e.paused = true
+		e.pauseReason = s.PauseReason
+		e.pauseUntil = s.PauseUntil
+		log.Printf("engine: restored maintenance pause until %v (%s)",
+			s.PauseUntil.Format(time.RFC3339), s.PauseReason)
+	}
+	log.Printf("engine: restored decision state (active %q, saved %v ago)",
+		s.Active, time.Since(s.SavedAt).Round(time.Second))
+}
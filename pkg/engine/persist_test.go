@@ -0,0 +1,96 @@
+package engine
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/collector"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/controller"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/telemetry"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/uci"
+)
+
+func testEngine(t *testing.T, statePath string) *Engine {
+	t.Helper()
+	cfg := &uci.Config{
+		FailScore: 40, RestoreScore: 70, RestoreWindowS: 300,
+		CooldownS: 60, WarmupS: 30, PollIntervalS: 5,
+		Members: []uci.MemberConfig{
+			{Name: "starlink", Iface: "wan", Class: "starlink", Weight: 60},
+			{Name: "cellular", Iface: "mob1s1a1", Class: "cellular", Weight: 40},
+		},
+	}
+	e := New(cfg, collector.NewRegistry(), controller.New(nil), telemetry.NewStore(t.TempDir()), nil)
+	e.SetStatePath(statePath)
+	return e
+}
+
+func TestSaveRestoreState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	e := testEngine(t, path)
+	e.active = "starlink"
+	e.lastSwitch = time.Now().Add(-30 * time.Second)
+	e.Members()[1].Lifecycle = Lifecycle{State: StateCooldown, Since: time.Now().Add(-2 * time.Minute)}
+	e.SaveState()
+
+	e2 := testEngine(t, path)
+	e2.RestoreState()
+	if e2.active != "starlink" {
+		t.Errorf("active = %q, want starlink", e2.active)
+	}
+	if !e2.lastSwitch.Equal(e.lastSwitch) {
+		t.Errorf("lastSwitch = %v, want %v", e2.lastSwitch, e.lastSwitch)
+	}
+	if e2.Members()[1].State != StateCooldown {
+		t.Errorf("cellular state = %s, want cooldown", e2.Members()[1].State)
+	}
+	if !e2.Members()[1].Since.Equal(e.Members()[1].Since) {
+		t.Errorf("cellular since = %v, want %v", e2.Members()[1].Since, e.Members()[1].Since)
+	}
+}
+
+func TestRestoreStateStale(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	e := testEngine(t, path)
+	e.active = "cellular"
+	e.SaveState()
+
+	// Rewrite the snapshot with an old timestamp.
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var s decisionState
+	if err := json.Unmarshal(b, &s); err != nil {
+		t.Fatal(err)
+	}
+	s.SavedAt = time.Now().Add(-time.Hour)
+	b, _ = json.Marshal(&s)
+	if err := os.WriteFile(path, b, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	e2 := testEngine(t, path)
+	e2.RestoreState()
+	if e2.active != "" {
+		t.Errorf("active = %q after stale restore, want empty", e2.active)
+	}
+}
+
+func TestRestoreStateUnknownMember(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	e := testEngine(t, path)
+	e.active = "starlink"
+	e.SaveState()
+
+	e2 := testEngine(t, path)
+	// Simulate the active member disappearing from config while down.
+	e2.SetMembers(e2.Members()[1:])
+	e2.RestoreState()
+	if e2.active != "" {
+		t.Errorf("active = %q, want empty for a removed member", e2.active)
+	}
+}
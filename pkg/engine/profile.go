@@ -0,0 +1,62 @@
+package engine
+
+// Traffic profiles retune the engine for what the deployment cares about
+// most: a gamer wants switches before lag spikes land, a data-capped van
+// wants as few switches (and probes) as possible. One UCI option
+// (starfail.main.profile) moves all the related knobs together; explicit
+// starfail.scoring options still win where they overlap.
+
+// trafficProfile bundles the per-profile tuning values.
+type trafficProfile struct {
+	name string
+
+	// jitterWeight scales the jitter penalty in instant scoring.
+	jitterWeight float64
+
+	// predictiveScale scales the (negative) predictive adjustment:
+	// above 1 switches earlier on predicted trouble.
+	predictiveScale float64
+
+	// switchMargin replaces the default margin a candidate must clear.
+	switchMargin float64
+
+	// cooldownScale and restoreWindowScale stretch or shrink the
+	// configured hysteresis windows.
+	cooldownScale      float64
+	restoreWindowScale float64
+
+	// sensitive profiles carry traffic users would not want through an
+	// intercepting middlebox; they avoid TLS-filtered members while any
+	// clean one is eligible.
+	sensitive bool
+}
+
+var trafficProfiles = map[string]trafficProfile{
+	"web": {
+		name: "web", jitterWeight: 1, predictiveScale: 1,
+		switchMargin: switchMargin, cooldownScale: 1, restoreWindowScale: 1,
+	},
+	"voip": {
+		name: "voip", jitterWeight: 2, predictiveScale: 1.5,
+		switchMargin: 8, cooldownScale: 0.5, restoreWindowScale: 1,
+		sensitive: true,
+	},
+	"gaming": {
+		name: "gaming", jitterWeight: 2.5, predictiveScale: 2,
+		switchMargin: 6, cooldownScale: 0.5, restoreWindowScale: 0.5,
+		sensitive: true,
+	},
+	"backup": {
+		name: "backup", jitterWeight: 0.5, predictiveScale: 0.5,
+		switchMargin: 15, cooldownScale: 2, restoreWindowScale: 2,
+	},
+}
+
+// profileByName resolves a configured profile name, falling back to web
+// (the pre-profile behaviour) for empty or unknown names.
+func profileByName(name string) trafficProfile {
+	if p, ok := trafficProfiles[name]; ok {
+		return p
+	}
+	return trafficProfiles["web"]
+}
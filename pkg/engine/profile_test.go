@@ -0,0 +1,49 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/collector"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/controller"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/metrics"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/telemetry"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/uci"
+)
+
+func TestProfileByName(t *testing.T) {
+	if p := profileByName(""); p.name != "web" {
+		t.Errorf("empty name resolved to %q, want web", p.name)
+	}
+	if p := profileByName("nonsense"); p.name != "web" {
+		t.Errorf("unknown name resolved to %q, want web", p.name)
+	}
+	if p := profileByName("gaming"); p.name != "gaming" {
+		t.Errorf("gaming resolved to %q", p.name)
+	}
+}
+
+func TestProfileJitterSensitivity(t *testing.T) {
+	sample := &metrics.Metrics{
+		LatencyMS:   metrics.Milliseconds(40).Ptr(),
+		JitterMS:    metrics.Milliseconds(60).Ptr(),
+		LossPercent: metrics.Percent(0).Ptr(),
+	}
+	mkEngine := func(profile string) *Engine {
+		cfg := &uci.Config{
+			FailScore: 40, RestoreScore: 70, Profile: profile,
+			Members: []uci.MemberConfig{{Name: "m", Iface: "wan", Class: "generic", Weight: 50}},
+		}
+		ctl := controller.New(nil)
+		ctl.Noop = true
+		return New(cfg, collector.NewRegistry(), ctl, telemetry.NewStore(t.TempDir()), nil)
+	}
+	web := mkEngine("web").instantScore(sample)
+	gaming := mkEngine("gaming").instantScore(sample)
+	backup := mkEngine("backup").instantScore(sample)
+	if gaming >= web {
+		t.Errorf("gaming score %.1f not below web %.1f for jittery link", gaming, web)
+	}
+	if backup <= web {
+		t.Errorf("backup score %.1f not above web %.1f for jittery link", backup, web)
+	}
+}
@@ -0,0 +1,84 @@
+package engine
+
+import "sort"
+
+// ReasonCode is the machine-readable cause attached to a routing decision.
+// Codes form a stable public vocabulary: failover events carry them in
+// Details["reason_code"], and from there they reach logs, notifications,
+// the telemetry tap, and MQTT consumers that match on them. An existing
+// code must therefore never be renamed or repurposed — only new codes may
+// be added, with their human text registered in reasonText below.
+type ReasonCode string
+
+const (
+	// ReasonScoreMargin: a standby member beat the active one by more
+	// than the profile's switch margin.
+	ReasonScoreMargin ReasonCode = "score_margin"
+	// ReasonLinkDown: the active member left the eligible state.
+	ReasonLinkDown ReasonCode = "link_down"
+	// ReasonInitialSelection: first pick after startup, before any member
+	// was active.
+	ReasonInitialSelection ReasonCode = "initial_selection"
+	// ReasonFastStart: the boot-time election that runs before the
+	// regular scoring loop has history.
+	ReasonFastStart ReasonCode = "fast_start"
+	// ReasonManual: an operator pinned the member via ubus or
+	// starfailctl.
+	ReasonManual ReasonCode = "manual"
+	// ReasonManualRevert: a manual pin's TTL expired and automatic
+	// decisions resumed.
+	ReasonManualRevert ReasonCode = "manual_revert"
+	// ReasonPredictiveObstruction: reserved for switches driven directly
+	// by an obstruction forecast; today predictive penalties lower the
+	// score and surface as score_margin.
+	ReasonPredictiveObstruction ReasonCode = "predictive_obstruction"
+	// ReasonDataCap: reserved for switches that move traffic off a
+	// member approaching its metered data cap.
+	ReasonDataCap ReasonCode = "data_cap"
+	// ReasonMaintenanceWindow: reserved for switches performed on entry
+	// to or exit from a maintenance pause.
+	ReasonMaintenanceWindow ReasonCode = "maintenance_window"
+	// ReasonRulePriority: the rules strategy found a healthy member ranked
+	// above the active one (see strategy.go).
+	ReasonRulePriority ReasonCode = "rule_priority"
+	// ReasonStowed: the active member's dish was stowed, taking it out of
+	// rotation administratively (see stow.go).
+	ReasonStowed ReasonCode = "dish_stowed"
+)
+
+// reasonText maps every code to the human-readable phrase used in logs
+// and notification templates. Every declared code must have an entry;
+// TestReasonCodesAreStable enforces both directions.
+var reasonText = map[ReasonCode]string{
+	ReasonScoreMargin:           "better score",
+	ReasonLinkDown:              "active member failed",
+	ReasonInitialSelection:      "initial selection",
+	ReasonFastStart:             "fast-start election",
+	ReasonManual:                "manual failover",
+	ReasonManualRevert:          "manual failover TTL expired",
+	ReasonPredictiveObstruction: "predicted obstruction",
+	ReasonDataCap:               "metered data cap",
+	ReasonMaintenanceWindow:     "maintenance window",
+	ReasonRulePriority:          "higher-priority member healthy",
+	ReasonStowed:                "dish stowed",
+}
+
+// Text returns the human-readable phrase for a code; unknown codes render
+// as themselves so a forgotten map entry degrades visibly, not silently.
+func (c ReasonCode) Text() string {
+	if t, ok := reasonText[c]; ok {
+		return t
+	}
+	return string(c)
+}
+
+// KnownReasonCodes returns the full vocabulary, sorted, for the stability
+// test and for consumers that want to validate incoming codes.
+func KnownReasonCodes() []ReasonCode {
+	codes := make([]ReasonCode, 0, len(reasonText))
+	for c := range reasonText {
+		codes = append(codes, c)
+	}
+	sort.Slice(codes, func(i, j int) bool { return codes[i] < codes[j] })
+	return codes
+}
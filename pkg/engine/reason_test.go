@@ -0,0 +1,45 @@
+package engine
+
+import "testing"
+
+// TestReasonCodesAreStable pins the reason-code vocabulary. External
+// consumers (tap clients, MQTT subscribers, notification filters) match
+// on these strings, so a failing diff here means a code was renamed or
+// dropped — which is a breaking change, not a refactor. Adding a code is
+// fine: extend this list in the same commit.
+func TestReasonCodesAreStable(t *testing.T) {
+	want := []ReasonCode{
+		"data_cap",
+		"dish_stowed",
+		"fast_start",
+		"initial_selection",
+		"link_down",
+		"maintenance_window",
+		"manual",
+		"manual_revert",
+		"predictive_obstruction",
+		"rule_priority",
+		"score_margin",
+	}
+	got := KnownReasonCodes()
+	if len(got) != len(want) {
+		t.Fatalf("KnownReasonCodes() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("code[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestReasonCodeText(t *testing.T) {
+	for _, c := range KnownReasonCodes() {
+		if c.Text() == "" || c.Text() == string(c) {
+			t.Errorf("code %q has no registered human text", c)
+		}
+	}
+	// Unknown codes render as themselves rather than vanishing.
+	if got := ReasonCode("weird").Text(); got != "weird" {
+		t.Errorf("unknown code text = %q, want %q", got, "weird")
+	}
+}
@@ -0,0 +1,109 @@
+package engine
+
+import "sync"
+
+// Roster is the single shared member registry. The engine's decision
+// loop, the controller's membership check, the ubus handlers and the
+// monitoring server all read the same list, so runtime additions (wifi
+// onboarding) and SIGHUP reloads are visible everywhere at once instead
+// of being re-pushed into each component. Mutations come from goroutines
+// other than the engine's (the wifi onboarder, the signal handler), hence
+// the lock; the Member structs themselves are still owned by the engine
+// tick as before.
+type Roster struct {
+	mu   sync.RWMutex
+	list []*Member
+	subs []func()
+}
+
+// NewRoster returns a Roster holding the given members.
+func NewRoster(members ...*Member) *Roster {
+	return &Roster{list: members}
+}
+
+// All returns a copy of the member list; the pointed-to Members are
+// shared, the slice is the caller's.
+func (r *Roster) All() []*Member {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]*Member, len(r.list))
+	copy(out, r.list)
+	return out
+}
+
+// Len returns the member count.
+func (r *Roster) Len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.list)
+}
+
+// Names returns the member names, in roster order.
+func (r *Roster) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]string, len(r.list))
+	for i, m := range r.list {
+		out[i] = m.Name
+	}
+	return out
+}
+
+// Get returns the named member, nil when absent.
+func (r *Roster) Get(name string) *Member {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, m := range r.list {
+		if m.Name == name {
+			return m
+		}
+	}
+	return nil
+}
+
+// Set replaces the whole list (SIGHUP reload).
+func (r *Roster) Set(members []*Member) {
+	r.mu.Lock()
+	r.list = members
+	r.mu.Unlock()
+	r.notify()
+}
+
+// Add appends a member at runtime.
+func (r *Roster) Add(m *Member) {
+	r.mu.Lock()
+	r.list = append(r.list, m)
+	r.mu.Unlock()
+	r.notify()
+}
+
+// Remove drops the named member; reports whether it was present.
+func (r *Roster) Remove(name string) bool {
+	r.mu.Lock()
+	found := false
+	for i, m := range r.list {
+		if m.Name == name {
+			r.list = append(r.list[:i], r.list[i+1:]...)
+			found = true
+			break
+		}
+	}
+	r.mu.Unlock()
+	if found {
+		r.notify()
+	}
+	return found
+}
+
+// OnChange registers fn to run after every mutation. Subscribers are
+// expected to be registered during startup, before mutations begin.
+func (r *Roster) OnChange(fn func()) {
+	r.subs = append(r.subs, fn)
+}
+
+// notify runs outside the lock so subscribers may read the roster.
+func (r *Roster) notify() {
+	for _, fn := range r.subs {
+		fn()
+	}
+}
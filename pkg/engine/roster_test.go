@@ -0,0 +1,51 @@
+package engine
+
+import "testing"
+
+func TestRosterMutationsNotify(t *testing.T) {
+	r := NewRoster(&Member{Name: "starlink"})
+	notified := 0
+	r.OnChange(func() { notified++ })
+
+	r.Add(&Member{Name: "cellular"})
+	if got := r.Names(); len(got) != 2 || got[1] != "cellular" {
+		t.Fatalf("names after add = %v", got)
+	}
+	if !r.Remove("starlink") {
+		t.Fatal("Remove did not find starlink")
+	}
+	if r.Remove("starlink") {
+		t.Error("second Remove reported a hit")
+	}
+	r.Set([]*Member{{Name: "wifi"}})
+	if m := r.Get("wifi"); m == nil {
+		t.Error("Get after Set returned nil")
+	}
+	if notified != 3 {
+		t.Errorf("notified %d times, want 3 (add, first remove, set)", notified)
+	}
+}
+
+func TestRosterAllReturnsACopy(t *testing.T) {
+	r := NewRoster(&Member{Name: "a"}, &Member{Name: "b"})
+	all := r.All()
+	all[0] = nil // caller mangling its slice must not touch the roster
+	if r.Len() != 2 || r.Get("a") == nil {
+		t.Error("roster shares its backing slice with callers")
+	}
+}
+
+func TestEngineRosterChangePokes(t *testing.T) {
+	e := newForceTestEngine(t)
+	// Drain any pending poke, then confirm a roster change queues one.
+	select {
+	case <-e.poke:
+	default:
+	}
+	e.AddMember(&Member{Name: "wifi", Class: "wifi", Lifecycle: Lifecycle{State: StateEligible}})
+	select {
+	case <-e.poke:
+	default:
+		t.Error("roster change did not poke the engine")
+	}
+}
@@ -0,0 +1,91 @@
+package engine
+
+import "github.com/markus-lassfolk/rutos-starlink-failover/pkg/metrics"
+
+// Scoring maps a sample to a 0-100 quality score. 100 is a perfect link.
+// The final score blends the instantaneous value with an EWMA and a window
+// average so one bad probe round cannot trigger a switch on its own.
+
+// The EWMA alpha and the blend weights live in uci.Config
+// (starfail.scoring) so deployments can tune responsiveness vs stability;
+// see Config.validateScoring for the accepted bounds.
+
+// Thresholds where penalties start and saturate.
+const (
+	latencyGoodMS = 50.0
+	latencyBadMS  = 500.0
+	lossBadPct    = 20.0
+	obstructionBadPct = 10.0
+)
+
+// instantScore computes the penalty-based score for one sample under the
+// engine's traffic profile.
+func (e *Engine) instantScore(m *metrics.Metrics) float64 {
+	score := 100.0
+	score -= latencyPenalty(m, e.profile.jitterWeight)
+	score -= lossPenalty(m)
+	score -= obstructionPenalty(m)
+	if score < 0 {
+		score = 0
+	}
+	return score
+}
+
+// latencyPenalty scales linearly from 0 at latencyGoodMS to 40 at
+// latencyBadMS. Jitter adds up to 10 more at neutral jitterWeight;
+// latency-sensitive profiles weight it higher.
+func latencyPenalty(m *metrics.Metrics, jitterWeight float64) float64 {
+	var p float64
+	lat := m.LatencyMS
+	if lat == nil {
+		// Probe volume can be metered down to nothing useful; the
+		// passive kernel-tracked TCP RTT stands in when present.
+		lat = m.PassiveRTTMS
+	}
+	if lat != nil && float64(*lat) > latencyGoodMS {
+		f := (float64(*lat) - latencyGoodMS) / (latencyBadMS - latencyGoodMS)
+		if f > 1 {
+			f = 1
+		}
+		p += f * 40
+	}
+	if m.JitterMS != nil && float64(*m.JitterMS) > 10 {
+		f := (float64(*m.JitterMS) - 10) / 100
+		if f > 1 {
+			f = 1
+		}
+		p += f * 10 * jitterWeight
+	}
+	return p
+}
+
+// lossPenalty scales from 0 to 50 as loss goes from 0% to lossBadPct.
+func lossPenalty(m *metrics.Metrics) float64 {
+	if m.LossPercent == nil {
+		return 0
+	}
+	f := float64(*m.LossPercent) / lossBadPct
+	if f > 1 {
+		f = 1
+	}
+	return f * 50
+}
+
+// obstructionPenalty scales from 0 to 30 as obstruction goes from 0% to
+// obstructionBadPct; ignored when the dish says the estimate is invalid.
+func obstructionPenalty(m *metrics.Metrics) float64 {
+	if m.ObstructionPct == nil || !m.ObstructionValid {
+		return 0
+	}
+	f := float64(*m.ObstructionPct) / obstructionBadPct
+	if f > 1 {
+		f = 1
+	}
+	return f * 30
+}
+
+// weightBias converts the configured member weight (1-100, 50 neutral) into
+// a small additive preference so equal links resolve deterministically.
+func weightBias(weight int) float64 {
+	return float64(weight-50) / 10
+}
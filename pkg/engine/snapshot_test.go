@@ -0,0 +1,47 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/metrics"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/telemetry"
+)
+
+func TestFailoverEventCarriesSnapshot(t *testing.T) {
+	e := newForceTestEngine(t)
+	for i := 0; i < 3; i++ {
+		e.store.AddSample(telemetry.Sample{
+			Member:  "cellular",
+			Metrics: &metrics.Metrics{Timestamp: time.Now()},
+			Score:   60,
+		})
+	}
+	if err := e.Force("cellular", 0); err != nil {
+		t.Fatalf("force: %v", err)
+	}
+	var failover *telemetry.Event
+	for _, ev := range e.store.Events(0) {
+		if ev.Type == "failover" {
+			ev := ev
+			failover = &ev
+		}
+	}
+	if failover == nil {
+		t.Fatal("no failover event recorded")
+	}
+	snap := failover.Snapshot
+	if snap == nil {
+		t.Fatal("failover event has no snapshot")
+	}
+	if snap.Active != "cellular" || snap.Forced != "cellular" {
+		t.Fatalf("snapshot state = %+v", snap)
+	}
+	ms, ok := snap.Members["cellular"]
+	if !ok {
+		t.Fatalf("snapshot missing switched-to member: %+v", snap.Members)
+	}
+	if ms.Score != 60 || len(ms.Samples) != 3 {
+		t.Fatalf("member snapshot = %+v", ms)
+	}
+}
@@ -0,0 +1,113 @@
+package engine
+
+import (
+	"fmt"
+	"time"
+)
+
+// State is one point in a member's lifecycle. The underlying type is
+// string so the values appearing in ubus replies and telemetry events are
+// unchanged from the old ad-hoc status strings.
+type State string
+
+const (
+	// StateEligible members score normally and may carry traffic.
+	StateEligible State = "eligible"
+	// StateFailed members scored below FailScore and are excluded from
+	// selection until they prove themselves again.
+	StateFailed State = "failed"
+	// StateCooldown members are failed but currently healthy, waiting
+	// out the restore window; one bad score sends them back to failed.
+	StateCooldown State = "cooldown"
+	// StateWarmup members passed the restore window and are soaking for
+	// WarmupS before becoming eligible again.
+	StateWarmup State = "warmup"
+)
+
+// Lifecycle is the per-member state machine. It is embedded in Member so
+// existing accesses to m.State keep working; Since records when the
+// current state was entered and drives the time-based guards.
+type Lifecycle struct {
+	State State     `json:"state"`
+	Since time.Time `json:"since"`
+}
+
+// lifecycleConfig are the thresholds the transition guards evaluate,
+// snapshot from uci.Config each tick so a SIGHUP reload takes effect
+// immediately.
+type lifecycleConfig struct {
+	FailScore     float64
+	RestoreScore  float64
+	RestoreWindow time.Duration
+	Warmup        time.Duration
+	// WarmupScore is the dedicated warm-up average that must be reached —
+	// in addition to the Warmup soak time — before eligibility; zero keeps
+	// warm-up purely time-based.
+	WarmupScore float64
+	// FailbackConfidence is the member's current composite failback
+	// confidence (see failback.go), filled in per member by the caller;
+	// cooldown graduates when it clears the threshold.
+	FailbackConfidence float64
+}
+
+// step advances the machine one evaluation and returns the transition
+// taken (from == to when none fired). The full transition table:
+//
+//	eligible --score < FailScore--------------> failed
+//	failed   --score >= RestoreScore----------> cooldown
+//	cooldown --score < RestoreScore-----------> failed
+//	cooldown --failback confidence over
+//	           threshold (see failback.go)----> warmup
+//	warmup   --score < FailScore--------------> failed
+//	warmup   --Warmup elapsed and
+//	           score >= WarmupScore-----------> eligible
+//
+// During warm-up the caller passes the dedicated warm-up average (see
+// Member.WarmupScore) rather than the blended score, so a link that is up
+// but still unstable keeps soaking instead of graduating on elapsed time.
+//
+// At most one transition fires per step, so a member can never skip the
+// cooldown or warmup soak periods however good its score looks.
+func (l *Lifecycle) step(score float64, cfg lifecycleConfig, now time.Time) (from, to State) {
+	from = l.State
+	switch l.State {
+	case StateEligible:
+		if score < cfg.FailScore {
+			l.enter(StateFailed, now)
+		}
+	case StateFailed:
+		if score >= cfg.RestoreScore {
+			l.enter(StateCooldown, now)
+		}
+	case StateCooldown:
+		switch {
+		case score < cfg.RestoreScore:
+			l.enter(StateFailed, now)
+		case cfg.FailbackConfidence >= failbackConfidenceThreshold:
+			l.enter(StateWarmup, now)
+		}
+	case StateWarmup:
+		switch {
+		case score < cfg.FailScore:
+			l.enter(StateFailed, now)
+		case now.Sub(l.Since) >= cfg.Warmup && score >= cfg.WarmupScore:
+			l.enter(StateEligible, now)
+		}
+	default:
+		// Unknown state (e.g. hand-edited persisted snapshot): recover
+		// conservatively by treating the member as freshly failed.
+		l.enter(StateFailed, now)
+	}
+	return from, l.State
+}
+
+// enter is the single entry action: record the state and its start time.
+func (l *Lifecycle) enter(s State, now time.Time) {
+	l.State = s
+	l.Since = now
+}
+
+// transitionReason renders a transition for telemetry events.
+func transitionReason(from, to State) string {
+	return fmt.Sprintf("%s -> %s", from, to)
+}
@@ -0,0 +1,107 @@
+package engine
+
+import (
+	"testing"
+	"time"
+)
+
+var testLifecycleCfg = lifecycleConfig{
+	FailScore:     40,
+	RestoreScore:  70,
+	RestoreWindow: 300 * time.Second,
+	Warmup:        30 * time.Second,
+}
+
+func TestLifecycleFullRecovery(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := Lifecycle{State: StateEligible, Since: now}
+
+	// Healthy scores keep the member eligible.
+	if from, to := l.step(85, testLifecycleCfg, now); from != to {
+		t.Fatalf("unexpected transition %s -> %s on a healthy score", from, to)
+	}
+
+	// Collapse below FailScore.
+	if _, to := l.step(20, testLifecycleCfg, now); to != StateFailed {
+		t.Fatalf("state = %s, want failed", to)
+	}
+
+	// Still bad: stays failed.
+	now = now.Add(time.Minute)
+	if from, to := l.step(35, testLifecycleCfg, now); from != to {
+		t.Fatalf("unexpected transition %s -> %s while still bad", from, to)
+	}
+
+	// Healthy again: enters cooldown, not straight back to eligible.
+	if _, to := l.step(80, testLifecycleCfg, now); to != StateCooldown {
+		t.Fatalf("state = %s, want cooldown", to)
+	}
+
+	// Failback confidence short of the threshold: stays in cooldown.
+	now = now.Add(testLifecycleCfg.RestoreWindow)
+	cfg := testLifecycleCfg
+	cfg.FailbackConfidence = failbackConfidenceThreshold - 1
+	if from, to := l.step(80, cfg, now); from != to {
+		t.Fatalf("left cooldown below the confidence threshold: %s -> %s", from, to)
+	}
+
+	// Confidence cleared: warmup.
+	cfg.FailbackConfidence = failbackConfidenceThreshold
+	if _, to := l.step(80, cfg, now); to != StateWarmup {
+		t.Fatalf("state = %s, want warmup", to)
+	}
+
+	// Warmup served: eligible, but never in the same step.
+	now = now.Add(testLifecycleCfg.Warmup)
+	if _, to := l.step(80, testLifecycleCfg, now); to != StateEligible {
+		t.Fatalf("state = %s, want eligible", to)
+	}
+}
+
+func TestLifecycleCooldownRelapse(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := Lifecycle{State: StateCooldown, Since: now}
+
+	if _, to := l.step(50, testLifecycleCfg, now.Add(time.Minute)); to != StateFailed {
+		t.Fatalf("state = %s, want failed after a relapse during cooldown", to)
+	}
+	// The restore clock restarted: a good score only reaches cooldown.
+	if _, to := l.step(90, testLifecycleCfg, now.Add(2*time.Minute)); to != StateCooldown {
+		t.Fatalf("state = %s, want cooldown", to)
+	}
+}
+
+func TestLifecycleUnknownStateRecovers(t *testing.T) {
+	now := time.Now()
+	l := Lifecycle{State: "bogus"}
+	if _, to := l.step(90, testLifecycleCfg, now); to != StateFailed {
+		t.Fatalf("state = %s, want failed for an unknown state", to)
+	}
+}
+
+func TestLifecycleWarmupScoreGate(t *testing.T) {
+	cfg := testLifecycleCfg
+	cfg.WarmupScore = 70
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := Lifecycle{State: StateWarmup, Since: now}
+
+	// Soak time served, but the warm-up average has not cleared the bar:
+	// keep soaking instead of graduating on elapsed time alone.
+	if from, to := l.step(60, cfg, now.Add(cfg.Warmup)); from != to {
+		t.Fatalf("unexpected transition %s -> %s below the warm-up score", from, to)
+	}
+	// Once the average clears the bar, eligibility follows.
+	if _, to := l.step(80, cfg, now.Add(cfg.Warmup)); to != StateEligible {
+		t.Fatalf("state = %s, want eligible", to)
+	}
+}
+
+func TestLifecycleWarmupRelapse(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := Lifecycle{State: StateWarmup, Since: now}
+
+	// A collapse during warm-up goes straight back to failed.
+	if _, to := l.step(20, testLifecycleCfg, now.Add(time.Second)); to != StateFailed {
+		t.Fatalf("state = %s, want failed after a warm-up collapse", to)
+	}
+}
@@ -0,0 +1,53 @@
+package engine
+
+// Stowed-dish handling. A stowed dish has no link by design, so a stowed
+// member is administratively out of rotation: no lifecycle stepping on the
+// garbage readings a parked dish produces, no member_failed events, and no
+// candidacy in either strategy. The flag follows the dish's own
+// stow_requested state (users stow from the Starlink app too) and the ubus
+// stow action, which marks the member first so traffic moves away before
+// the link drops. On deploy the member re-proves itself through warm-up.
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/telemetry"
+)
+
+// noteStow reconciles a member's stow flag with the dish-reported state;
+// called once per tick and from SetStowed.
+func (e *Engine) noteStow(m *Member, stowed bool) {
+	if stowed == m.Stowed {
+		return
+	}
+	m.Stowed = stowed
+	if stowed {
+		e.store.AddEvent(telemetry.Event{
+			Timestamp: time.Now(), Type: "member_stowed", Member: m.Name,
+			Reason: "dish stow requested",
+		})
+		return
+	}
+	// Deployed again: rejoin through warm-up rather than instantly, the
+	// dish needs time to acquire satellites.
+	m.State = StateWarmup
+	m.Since = time.Now()
+	m.warmupSum, m.warmupCount = 0, 0
+	e.store.AddEvent(telemetry.Event{
+		Timestamp: time.Now(), Type: "member_unstowed", Member: m.Name,
+		Reason: "dish deployed, warming up",
+	})
+}
+
+// SetStowed marks a member stowed or deployed on behalf of the ubus stow
+// action and pokes the decision loop so traffic moves promptly.
+func (e *Engine) SetStowed(name string, stowed bool) error {
+	m := e.member(name)
+	if m == nil {
+		return fmt.Errorf("unknown member %q", name)
+	}
+	e.noteStow(m, stowed)
+	e.Poke()
+	return nil
+}
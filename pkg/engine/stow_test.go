@@ -0,0 +1,77 @@
+package engine
+
+import "testing"
+
+func TestStowMovesTrafficWithoutFailure(t *testing.T) {
+	e := newForceTestEngine(t)
+	e.makeDecision()
+	if e.Active() != "starlink" {
+		t.Fatalf("initial active = %q", e.Active())
+	}
+	if err := e.SetStowed("starlink", true); err != nil {
+		t.Fatalf("SetStowed: %v", err)
+	}
+	e.makeDecision()
+	if e.Active() != "cellular" {
+		t.Fatalf("active = %q after stow, want cellular", e.Active())
+	}
+	var sawStowEvent bool
+	var lastFailoverCode any
+	for _, ev := range e.store.Events(0) {
+		switch ev.Type {
+		case "member_failed", "state_transition":
+			t.Errorf("stow produced a %s event; it must be administrative", ev.Type)
+		case "member_stowed":
+			sawStowEvent = true
+		case "failover":
+			lastFailoverCode = ev.Details["reason_code"]
+		}
+	}
+	if !sawStowEvent {
+		t.Error("no member_stowed event recorded")
+	}
+	if lastFailoverCode != string(ReasonStowed) {
+		t.Errorf("failover reason_code = %v, want %q", lastFailoverCode, ReasonStowed)
+	}
+}
+
+func TestUnstowReentersThroughWarmup(t *testing.T) {
+	e := newForceTestEngine(t)
+	e.makeDecision()
+	_ = e.SetStowed("starlink", true)
+	e.makeDecision()
+	if err := e.SetStowed("starlink", false); err != nil {
+		t.Fatalf("SetStowed: %v", err)
+	}
+	m := e.member("starlink")
+	if m.Stowed {
+		t.Fatal("member still flagged stowed after deploy")
+	}
+	if m.State != StateWarmup {
+		t.Fatalf("state after unstow = %q, want warmup", m.State)
+	}
+	// Warm-up members are not candidates yet, so traffic stays put.
+	e.makeDecision()
+	if e.Active() != "cellular" {
+		t.Fatalf("active = %q right after unstow, want cellular", e.Active())
+	}
+}
+
+func TestStowedMemberNeverACandidate(t *testing.T) {
+	e := newForceTestEngine(t)
+	_ = e.SetStowed("starlink", true)
+	if best := e.pickBest(false); best == nil || best.Name != "cellular" {
+		t.Fatalf("pickBest = %+v, want cellular", best)
+	}
+	_ = e.SetStowed("cellular", true)
+	if best := e.pickBest(false); best != nil {
+		t.Fatalf("pickBest = %+v with everything stowed, want nil", best)
+	}
+}
+
+func TestSetStowedUnknownMember(t *testing.T) {
+	e := newForceTestEngine(t)
+	if err := e.SetStowed("nope", true); err == nil {
+		t.Error("stowing an unknown member did not error")
+	}
+}
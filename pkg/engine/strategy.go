@@ -0,0 +1,170 @@
+package engine
+
+// Decision strategies. The default score strategy compares blended quality
+// scores with hysteresis (switch margin, cooldown, shadow verification);
+// the rules strategy walks an explicit per-member priority order for
+// deployments that distrust scoring and want deterministic failover. Both
+// run on the same collectors, lifecycle state machine and controller — a
+// strategy only chooses the switch candidate, and lifecycle eligibility
+// remains the health gate either way.
+
+import (
+	"log"
+	"time"
+)
+
+// Strategy picks the switch candidate on each tick, after lifecycle
+// stepping. Decide returns the member to switch to and why, or nil to
+// leave routing alone.
+type Strategy interface {
+	Name() string
+	Decide(e *Engine, now time.Time) (*Member, ReasonCode)
+}
+
+// strategyByName maps the starfail.main.strategy value; unknown names fall
+// back to the score strategy so a typo degrades to the default, loudly.
+func strategyByName(name string) Strategy {
+	switch name {
+	case "", "score":
+		return scoreStrategy{}
+	case "rules":
+		return rulesStrategy{}
+	default:
+		log.Printf("engine: unknown strategy %q, using score", name)
+		return scoreStrategy{}
+	}
+}
+
+// scoreStrategy is the historical behaviour: highest blended score wins,
+// guarded by switch margin, cooldown, failure-domain awareness and shadow
+// verification.
+type scoreStrategy struct{}
+
+func (scoreStrategy) Name() string { return "score" }
+
+func (scoreStrategy) Decide(e *Engine, now time.Time) (*Member, ReasonCode) {
+	// Pick the best eligible member. Sensitive profiles skip TLS-filtered
+	// members first, but fall back to them rather than running dark.
+	best := e.pickBest(e.profile.sensitive)
+	if best == nil && e.profile.sensitive {
+		best = e.pickBest(false)
+	}
+	if best == nil {
+		return nil, "" // nothing usable; leave routing alone
+	}
+	// An exhausted Starlink priority-data budget hands off to a flat-rate
+	// member when one is eligible; the handoff ignores the score margin
+	// since the point is to stop spending, not to chase a better link.
+	var capped ReasonCode
+	if alt := e.dataCapAlternative(best); alt != nil {
+		best = alt
+		capped = ReasonDataCap
+	}
+	if e.active == "" {
+		return best, ReasonInitialSelection
+	}
+	if best.Name == e.active {
+		return nil, ""
+	}
+	current := e.member(e.active)
+	if capped == "" && current != nil && current.State == StateEligible && !current.Stowed &&
+		best.Final < current.Final+e.profile.switchMargin {
+		return nil, "" // not enough margin to justify a switch
+	}
+	if e.inCooldown(now) {
+		return nil, ""
+	}
+	reason := ReasonScoreMargin
+	if capped != "" {
+		reason = capped
+	}
+	if current == nil || current.State != StateEligible || current.Stowed {
+		reason = ReasonLinkDown
+		if current != nil && current.Stowed {
+			reason = ReasonStowed
+		}
+		// Members on the same physical modem die together: switching to
+		// the failed member's sibling cannot help, so prefer the best
+		// candidate from another failure domain when one exists.
+		if sameDomain(best, current) {
+			if alt := e.bestOutsideDomain(current.failureDomain()); alt != nil {
+				best = alt
+			} else {
+				log.Printf("engine: only candidate %s shares %s's failure domain, switching anyway",
+					best.Name, current.Name)
+			}
+		}
+	}
+	if reason == ReasonScoreMargin && !e.readyForFailback(best) {
+		// The active member is healthy; hold traffic until the candidate
+		// passes shadow verification (see verify.go).
+		return nil, ""
+	}
+	return best, reason
+}
+
+// rulesStrategy switches to the highest-priority eligible member, full
+// stop. No score margins: a member is either healthy (lifecycle eligible)
+// or it is not, and among healthy members the configured priority decides.
+type rulesStrategy struct{}
+
+func (rulesStrategy) Name() string { return "rules" }
+
+func (rulesStrategy) Decide(e *Engine, now time.Time) (*Member, ReasonCode) {
+	best := e.rulesCandidate()
+	if best == nil {
+		return nil, ""
+	}
+	if e.active == "" {
+		return best, ReasonInitialSelection
+	}
+	if best.Name == e.active {
+		return nil, ""
+	}
+	current := e.member(e.active)
+	if e.inCooldown(now) {
+		return nil, ""
+	}
+	if current == nil || current.State != StateEligible || current.Stowed {
+		if current != nil && current.Stowed {
+			return best, ReasonStowed
+		}
+		return best, ReasonLinkDown
+	}
+	// The active member is healthy but a higher-priority one came back:
+	// deterministic failback, still gated by shadow verification.
+	if !e.readyForFailback(best) {
+		return nil, ""
+	}
+	return best, ReasonRulePriority
+}
+
+// rulesCandidate returns the eligible member with the lowest non-zero
+// priority; members without a priority rank after every ranked one, in
+// config order.
+func (e *Engine) rulesCandidate() *Member {
+	var best *Member
+	for _, m := range e.roster.All() {
+		if m.State != StateEligible || m.Stowed || m.Priority == 0 {
+			continue
+		}
+		if best == nil || m.Priority < best.Priority {
+			best = m
+		}
+	}
+	if best != nil {
+		return best
+	}
+	for _, m := range e.roster.All() {
+		if m.State == StateEligible && !m.Stowed {
+			return m
+		}
+	}
+	return nil
+}
+
+// inCooldown reports whether the profile-scaled switch cooldown is still
+// running.
+func (e *Engine) inCooldown(now time.Time) bool {
+	return now.Sub(e.lastSwitch) < time.Duration(e.profile.cooldownScale*float64(e.cfg.CooldownS)*float64(time.Second))
+}
@@ -0,0 +1,94 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/collector"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/controller"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/telemetry"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/uci"
+)
+
+func newRulesTestEngine(t *testing.T) *Engine {
+	t.Helper()
+	cfg := &uci.Config{
+		Strategy:  "rules",
+		FailScore: 40, RestoreScore: 70, RestoreWindowS: 0,
+		CooldownS: 0, WarmupS: 0, PollIntervalS: 5,
+		Members: []uci.MemberConfig{
+			{Name: "starlink", Iface: "wan", Class: "starlink", Weight: 50, Priority: 1},
+			{Name: "cellular", Iface: "mob1s1a1", Class: "cellular", Weight: 50, Priority: 2},
+			{Name: "backup", Iface: "wwan", Class: "wifi", Weight: 50},
+		},
+	}
+	ctl := controller.New(nil)
+	ctl.Noop = true
+	e := New(cfg, collector.NewRegistry(), ctl, telemetry.NewStore(t.TempDir()), nil)
+	e.SetStatePath(t.TempDir() + "/state.json")
+	for _, m := range e.Members() {
+		m.Final = 80
+	}
+	return e
+}
+
+func TestRulesStrategyFollowsPriority(t *testing.T) {
+	e := newRulesTestEngine(t)
+	if e.strategy.Name() != "rules" {
+		t.Fatalf("strategy = %q, want rules", e.strategy.Name())
+	}
+	// Even with a better-scoring lower-priority member, priority 1 wins.
+	e.member("cellular").Final = 99
+	e.makeDecision()
+	if e.Active() != "starlink" {
+		t.Fatalf("active = %q, want starlink (priority 1)", e.Active())
+	}
+	// A score advantage alone must never move traffic under rules.
+	e.makeDecision()
+	if e.Active() != "starlink" {
+		t.Fatalf("score margin caused a switch under the rules strategy")
+	}
+}
+
+func TestRulesStrategyFailoverAndFailback(t *testing.T) {
+	e := newRulesTestEngine(t)
+	e.makeDecision()
+	if e.Active() != "starlink" {
+		t.Fatalf("initial active = %q", e.Active())
+	}
+	// Priority 1 fails: traffic moves to priority 2, not the unranked one.
+	e.member("starlink").State = StateFailed
+	e.makeDecision()
+	if e.Active() != "cellular" {
+		t.Fatalf("after failure active = %q, want cellular", e.Active())
+	}
+	// Priority 1 back: deterministic failback.
+	e.member("starlink").State = StateEligible
+	e.makeDecision()
+	if e.Active() != "starlink" {
+		t.Fatalf("after recovery active = %q, want starlink", e.Active())
+	}
+}
+
+func TestRulesCandidateUnrankedFallback(t *testing.T) {
+	e := newRulesTestEngine(t)
+	e.member("starlink").State = StateFailed
+	e.member("cellular").State = StateFailed
+	if c := e.rulesCandidate(); c == nil || c.Name != "backup" {
+		t.Fatalf("candidate = %+v, want unranked backup", c)
+	}
+	e.member("backup").State = StateFailed
+	if c := e.rulesCandidate(); c != nil {
+		t.Fatalf("candidate = %+v with every member failed", c)
+	}
+}
+
+func TestStrategyByNameFallsBackToScore(t *testing.T) {
+	for _, name := range []string{"", "score", "typo"} {
+		if got := strategyByName(name).Name(); got != "score" {
+			t.Errorf("strategyByName(%q) = %q, want score", name, got)
+		}
+	}
+	if got := strategyByName("rules").Name(); got != "rules" {
+		t.Errorf("strategyByName(rules) = %q", got)
+	}
+}
@@ -0,0 +1,97 @@
+package engine
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/telemetry"
+)
+
+// Failback is the one switch with no urgency: user traffic is flowing and
+// the only reason to move is that the recovered member looks better. A
+// Verifier lets the engine prove the candidate end-to-end — shadow-routing
+// only the daemon's probe traffic through it — before regular traffic
+// moves, so a member that merely looks recovered never causes a blip.
+
+// Verifier checks one candidate member before failback.
+type Verifier interface {
+	// Verify returns true when member (via iface) carried probe traffic
+	// acceptably for the whole verification window.
+	Verify(ctx context.Context, member, iface string) bool
+}
+
+// How long a passed verification stays valid, and how long to wait before
+// re-trying a member that failed one.
+const (
+	verifiedTTL      = 5 * time.Minute
+	verifyRetryAfter = 2 * time.Minute
+)
+
+// verifyState tracks in-flight and completed verifications; it has its
+// own lock because verifications run outside the engine goroutine.
+type verifyState struct {
+	mu      sync.Mutex
+	running bool
+	passed  map[string]time.Time
+	failed  map[string]time.Time
+}
+
+// SetVerifier installs a failback verifier; without one, failback
+// proceeds on scores alone as before.
+func (e *Engine) SetVerifier(v Verifier) { e.verifier = v }
+
+// readyForFailback reports whether m may take traffic now. With a
+// verifier configured it returns false until a verification has passed,
+// kicking one off in the background when none is running.
+func (e *Engine) readyForFailback(m *Member) bool {
+	if e.verifier == nil {
+		return true
+	}
+	vs := &e.verify
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+	now := time.Now()
+	if t, ok := vs.passed[m.Name]; ok && now.Sub(t) < verifiedTTL {
+		return true
+	}
+	if t, ok := vs.failed[m.Name]; ok && now.Sub(t) < verifyRetryAfter {
+		return false
+	}
+	if !vs.running {
+		vs.running = true
+		go e.runVerification(m.Name, m.BindIface())
+	}
+	return false
+}
+
+// runVerification performs one verification and records the outcome.
+func (e *Engine) runVerification(name, iface string) {
+	ok := e.verifier.Verify(context.Background(), name, iface)
+	vs := &e.verify
+	vs.mu.Lock()
+	vs.running = false
+	if vs.passed == nil {
+		vs.passed = make(map[string]time.Time)
+		vs.failed = make(map[string]time.Time)
+	}
+	if ok {
+		vs.passed[name] = time.Now()
+		delete(vs.failed, name)
+	} else {
+		vs.failed[name] = time.Now()
+		delete(vs.passed, name)
+	}
+	vs.mu.Unlock()
+	if ok {
+		e.store.AddEvent(telemetry.Event{
+			Timestamp: time.Now(), Type: "verification_passed", Member: name,
+		})
+	} else {
+		log.Printf("engine: %s failed failback verification, holding traffic", name)
+		e.store.AddEvent(telemetry.Event{
+			Timestamp: time.Now(), Type: "verification_failed", Member: name,
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package engine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/collector"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/controller"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/telemetry"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/uci"
+)
+
+// fakeVerifier returns a fixed verdict and signals each completed run.
+type fakeVerifier struct {
+	ok   bool
+	done chan string
+}
+
+func (f *fakeVerifier) Verify(_ context.Context, member, _ string) bool {
+	defer func() { f.done <- member }()
+	return f.ok
+}
+
+func verifyEngine(t *testing.T) *Engine {
+	t.Helper()
+	cfg := &uci.Config{
+		FailScore: 40, RestoreScore: 70, RestoreWindowS: 300,
+		CooldownS: 0, WarmupS: 30, PollIntervalS: 5,
+		Members: []uci.MemberConfig{
+			{Name: "cellular", Iface: "mob1s1a1", Class: "cellular", Weight: 50},
+			{Name: "starlink", Iface: "wan", Class: "starlink", Weight: 50},
+		},
+	}
+	ctl := controller.New(nil)
+	ctl.Noop = true
+	e := New(cfg, collector.NewRegistry(), ctl, telemetry.NewStore(t.TempDir()), nil)
+	e.SetStatePath(t.TempDir() + "/state.json")
+	e.active = "cellular"
+	e.Members()[0].Final = 50
+	e.Members()[1].Final = 90 // recovered, clears the switch margin
+	return e
+}
+
+func TestFailbackWaitsForVerification(t *testing.T) {
+	e := verifyEngine(t)
+	v := &fakeVerifier{ok: true, done: make(chan string, 1)}
+	e.SetVerifier(v)
+
+	e.makeDecision()
+	if e.active != "cellular" {
+		t.Fatalf("switched before verification completed (active=%s)", e.active)
+	}
+	select {
+	case m := <-v.done:
+		if m != "starlink" {
+			t.Fatalf("verified %q, want starlink", m)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("verification never ran")
+	}
+
+	// The verdict is recorded just after Verify returns; poll briefly.
+	deadline := time.Now().Add(2 * time.Second)
+	for e.active != "starlink" && time.Now().Before(deadline) {
+		e.makeDecision()
+		time.Sleep(10 * time.Millisecond)
+	}
+	if e.active != "starlink" {
+		t.Errorf("active = %q after passed verification, want starlink", e.active)
+	}
+}
+
+func TestFailbackHeldAfterFailedVerification(t *testing.T) {
+	e := verifyEngine(t)
+	v := &fakeVerifier{ok: false, done: make(chan string, 1)}
+	e.SetVerifier(v)
+
+	e.makeDecision()
+	<-v.done
+	e.makeDecision()
+	if e.active != "cellular" {
+		t.Errorf("active = %q after failed verification, want cellular", e.active)
+	}
+
+	// The event lands just after Verify returns; poll briefly.
+	found := false
+	deadline := time.Now().Add(2 * time.Second)
+	for !found && time.Now().Before(deadline) {
+		for _, ev := range e.store.Events(0) {
+			if ev.Type == "verification_failed" && ev.Member == "starlink" {
+				found = true
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !found {
+		t.Error("no verification_failed event recorded")
+	}
+}
+
+func TestFailoverSkipsVerification(t *testing.T) {
+	e := verifyEngine(t)
+	// A verifier that would never pass must not delay emergency failover.
+	v := &fakeVerifier{ok: false, done: make(chan string, 1)}
+	e.SetVerifier(v)
+
+	e.Members()[0].Final = 10
+	e.Members()[0].Lifecycle = Lifecycle{State: StateFailed}
+	e.makeDecision()
+	if e.active != "starlink" {
+		t.Errorf("active = %q, want immediate failover to starlink", e.active)
+	}
+}
@@ -0,0 +1,44 @@
+// Package features records which optional subsystems a binary was built
+// with. Everything is on by default; low-flash devices (RUT240 class)
+// strip the heavy parts at build time, e.g.
+//
+//	go build -tags "starfail_no_predictive starfail_no_monitoring starfail_no_gps" ./cmd/starfaild
+//
+// main consults these constants when wiring subsystems, so a disabled
+// feature costs neither RAM nor background work at runtime, and the
+// matrix is visible via starfaild -version -features.
+package features
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Matrix returns feature name -> enabled for this binary.
+func Matrix() map[string]bool {
+	return map[string]bool{
+		"predictive": Predictive,
+		"monitoring": Monitoring,
+		"gps":        GPS,
+	}
+}
+
+// String renders the matrix as "feature=on feature=off ...", sorted.
+func String() string {
+	m := Matrix()
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		state := "off"
+		if m[name] {
+			state = "on"
+		}
+		parts = append(parts, fmt.Sprintf("%s=%s", name, state))
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,6 @@
+//go:build starfail_no_gps
+
+package features
+
+// GPS is stripped from this build; alignment works without live heading.
+const GPS = false
@@ -0,0 +1,7 @@
+//go:build !starfail_no_gps
+
+package features
+
+// GPS enables position/heading fusion (baseline anchoring, alignment
+// heading suggestions).
+const GPS = true
@@ -0,0 +1,6 @@
+//go:build starfail_no_monitoring
+
+package features
+
+// Monitoring is stripped from this build; no HTTP listener is started.
+const Monitoring = false
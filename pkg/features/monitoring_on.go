@@ -0,0 +1,6 @@
+//go:build !starfail_no_monitoring
+
+package features
+
+// Monitoring enables the HTTP metrics/health listener.
+const Monitoring = true
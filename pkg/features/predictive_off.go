@@ -0,0 +1,7 @@
+//go:build starfail_no_predictive
+
+package features
+
+// Predictive is stripped from this build; scoring runs without
+// predictive adjustments.
+const Predictive = false
@@ -0,0 +1,7 @@
+//go:build !starfail_no_predictive
+
+package features
+
+// Predictive enables the predictive assessor (obstruction baseline,
+// external model socket).
+const Predictive = true
@@ -0,0 +1,90 @@
+package features
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Runtime tracks subsystems that can be started and stopped while the
+// daemon runs, complementing the build-time matrix: a feature compiled in
+// can still be switched off on a live router (ubus set_feature) to isolate
+// a problem without editing config and restarting. Build-time-stripped
+// features never register here, so they cannot be switched on.
+type Runtime struct {
+	mu      sync.Mutex
+	systems map[string]*system
+}
+
+type system struct {
+	enabled bool
+	start   func()
+	stop    func()
+}
+
+// NewRuntime returns an empty toggle registry.
+func NewRuntime() *Runtime {
+	return &Runtime{systems: make(map[string]*system)}
+}
+
+// Register adds a toggleable subsystem in the stopped state; start and
+// stop must be idempotent-safe in the sense that they are only ever called
+// alternately, starting with start.
+func (r *Runtime) Register(name string, start, stop func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.systems[name] = &system{start: start, stop: stop}
+}
+
+// Set switches a subsystem on or off, invoking its start/stop hook on an
+// actual state change; unknown names error so typos are visible.
+func (r *Runtime) Set(name string, on bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s, ok := r.systems[name]
+	if !ok {
+		return fmt.Errorf("unknown feature %q (not registered or stripped at build time)", name)
+	}
+	if s.enabled == on {
+		return nil
+	}
+	s.enabled = on
+	if on {
+		s.start()
+	} else {
+		s.stop()
+	}
+	return nil
+}
+
+// Enabled reports whether a subsystem is currently on; unregistered names
+// are off.
+func (r *Runtime) Enabled(name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s, ok := r.systems[name]
+	return ok && s.enabled
+}
+
+// States returns name -> enabled for every registered subsystem.
+func (r *Runtime) States() map[string]bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make(map[string]bool, len(r.systems))
+	for name, s := range r.systems {
+		out[name] = s.enabled
+	}
+	return out
+}
+
+// Names returns the registered subsystem names, sorted.
+func (r *Runtime) Names() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	names := make([]string, 0, len(r.systems))
+	for name := range r.systems {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,55 @@
+package features
+
+import "testing"
+
+func TestRuntimeToggle(t *testing.T) {
+	r := NewRuntime()
+	starts, stops := 0, 0
+	r.Register("mqtt", func() { starts++ }, func() { stops++ })
+
+	if r.Enabled("mqtt") {
+		t.Error("registered feature starts enabled")
+	}
+	if err := r.Set("mqtt", true); err != nil {
+		t.Fatalf("Set on: %v", err)
+	}
+	if starts != 1 || !r.Enabled("mqtt") {
+		t.Errorf("starts = %d, enabled = %v", starts, r.Enabled("mqtt"))
+	}
+	// Setting the same state again must not re-invoke the hook.
+	_ = r.Set("mqtt", true)
+	if starts != 1 {
+		t.Errorf("redundant Set invoked start (%d)", starts)
+	}
+	if err := r.Set("mqtt", false); err != nil || stops != 1 {
+		t.Errorf("Set off: err=%v stops=%d", err, stops)
+	}
+	_ = r.Set("mqtt", false)
+	if stops != 1 {
+		t.Errorf("redundant Set invoked stop (%d)", stops)
+	}
+}
+
+func TestRuntimeUnknownFeature(t *testing.T) {
+	r := NewRuntime()
+	if err := r.Set("nope", true); err == nil {
+		t.Error("Set on unknown feature did not error")
+	}
+	if r.Enabled("nope") {
+		t.Error("unknown feature reports enabled")
+	}
+}
+
+func TestRuntimeStates(t *testing.T) {
+	r := NewRuntime()
+	r.Register("a", func() {}, func() {})
+	r.Register("b", func() {}, func() {})
+	_ = r.Set("b", true)
+	st := r.States()
+	if st["a"] || !st["b"] {
+		t.Errorf("States() = %v", st)
+	}
+	if names := r.Names(); len(names) != 2 || names[0] != "a" || names[1] != "b" {
+		t.Errorf("Names() = %v", names)
+	}
+}
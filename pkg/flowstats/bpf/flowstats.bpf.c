@@ -0,0 +1,281 @@
+// SPDX-License-Identifier: GPL-2.0
+/*
+ * flowstats: passive per-WAN-device TCP quality counters.
+ *
+ * One tc classifier, attached to both directions of each WAN device
+ * (clsact ingress + egress), maintains per-ifindex totals of TCP
+ * retransmissions and passively measured flow RTTs. The daemon reads
+ * the flowstats_map totals via `bpftool map dump` (see flowstats.go for
+ * the value layout it expects: three little-endian u64s).
+ *
+ * Retransmits are detected on egress: a data segment whose sequence
+ * range was already covered by an earlier segment of the same flow is a
+ * local retransmission, i.e. uplink loss. RTT comes from the TCP
+ * timestamp option: egress records when each TSval left the box, and
+ * the first ingress segment echoing that value in its TSecr closes the
+ * measurement. Both state tables are LRU maps, so idle flows age out on
+ * their own and the program never needs a userspace janitor.
+ *
+ * The helper and map-definition macros below are the standard libbpf
+ * ones, declared inline so building needs only clang and the kernel
+ * uapi headers (see the Makefile); IPv6 and VLAN-tagged frames are
+ * passed through uncounted.
+ */
+
+#include <linux/types.h>
+#include <linux/bpf.h>
+#include <linux/if_ether.h>
+#include <linux/ip.h>
+#include <linux/tcp.h>
+#include <linux/pkt_cls.h>
+
+#define SEC(name) __attribute__((section(name), used))
+#define __uint(name, val) int (*name)[val]
+#define __type(name, val) typeof(val) *name
+
+static void *(*bpf_map_lookup_elem)(void *map, const void *key) = (void *)1;
+static long (*bpf_map_update_elem)(void *map, const void *key,
+				   const void *value, __u64 flags) = (void *)2;
+static long (*bpf_map_delete_elem)(void *map, const void *key) = (void *)3;
+static __u64 (*bpf_ktime_get_ns)(void) = (void *)5;
+static long (*bpf_skb_load_bytes)(const void *skb, __u32 offset, void *to,
+				  __u32 len) = (void *)26;
+
+#if __BYTE_ORDER__ == __ORDER_LITTLE_ENDIAN__
+#define bpf_ntohs(x) __builtin_bswap16(x)
+#define bpf_ntohl(x) __builtin_bswap32(x)
+#else
+#define bpf_ntohs(x) (x)
+#define bpf_ntohl(x) (x)
+#endif
+
+char LICENSE[] SEC("license") = "GPL";
+
+/* Per-device totals, read by the daemon. Keep the layout in step with
+ * flowstats.go: it decodes exactly these three u64s. */
+struct devstats {
+	__u64 retransmits;
+	__u64 rtt_sum_us;
+	__u64 rtt_samples;
+};
+
+struct {
+	__uint(type, BPF_MAP_TYPE_HASH);
+	__uint(max_entries, 64);
+	__type(key, __u32); /* ifindex */
+	__type(value, struct devstats);
+} flowstats_map SEC(".maps");
+
+/* Flow keys are oriented local->remote so both directions of a
+ * connection share one entry. */
+struct flowkey {
+	__u32 laddr;
+	__u32 raddr;
+	__u16 lport;
+	__u16 rport;
+};
+
+struct flowstate {
+	__u32 end_seq; /* highest sequence end sent so far */
+};
+
+struct {
+	__uint(type, BPF_MAP_TYPE_LRU_HASH);
+	__uint(max_entries, 4096);
+	__type(key, struct flowkey);
+	__type(value, struct flowstate);
+} flows SEC(".maps");
+
+/* Outstanding timestamp echoes: when TSval left, per flow. */
+struct tskey {
+	struct flowkey flow;
+	__u32 tsval;
+};
+
+struct {
+	__uint(type, BPF_MAP_TYPE_LRU_HASH);
+	__uint(max_entries, 4096);
+	__type(key, struct tskey);
+	__type(value, __u64); /* ktime ns at send */
+} tsvals SEC(".maps");
+
+/* seq_before is a wraparound-safe a < b. */
+static __always_inline int seq_before(__u32 a, __u32 b)
+{
+	return (__s32)(a - b) < 0;
+}
+
+static __always_inline struct devstats *dev_entry(__u32 ifindex)
+{
+	struct devstats *st = bpf_map_lookup_elem(&flowstats_map, &ifindex);
+
+	if (st)
+		return st;
+	struct devstats zero = {};
+
+	bpf_map_update_elem(&flowstats_map, &ifindex, &zero, BPF_NOEXIST);
+	return bpf_map_lookup_elem(&flowstats_map, &ifindex);
+}
+
+/* tcp_timestamps pulls TSval/TSecr out of the option block. Bounded at
+ * ten options, which comfortably covers the usual nop,nop,timestamp
+ * layouts; returns 0 when found. */
+static __always_inline int tcp_timestamps(struct __sk_buff *skb, __u32 tcp_off,
+					  __u32 hdr_len, __u32 *tsval,
+					  __u32 *tsecr)
+{
+	__u32 i = sizeof(struct tcphdr);
+
+#pragma unroll
+	for (int n = 0; n < 10; n++) {
+		__u8 kind, len;
+
+		if (i + 1 > hdr_len)
+			return -1;
+		if (bpf_skb_load_bytes(skb, tcp_off + i, &kind, 1))
+			return -1;
+		if (kind == 0) /* end of options */
+			return -1;
+		if (kind == 1) { /* nop */
+			i++;
+			continue;
+		}
+		if (i + 2 > hdr_len)
+			return -1;
+		if (bpf_skb_load_bytes(skb, tcp_off + i + 1, &len, 1))
+			return -1;
+		if (len < 2)
+			return -1;
+		if (kind == 8 && len == 10 && i + 10 <= hdr_len) {
+			__u32 v[2];
+
+			if (bpf_skb_load_bytes(skb, tcp_off + i + 2, v, 8))
+				return -1;
+			*tsval = bpf_ntohl(v[0]);
+			*tsecr = bpf_ntohl(v[1]);
+			return 0;
+		}
+		i += len;
+	}
+	return -1;
+}
+
+/* "classifier" rather than the newer "tc" section name: every libbpf
+ * that RUTOS bpftool builds ship understands it. */
+SEC("classifier")
+int flowstats(struct __sk_buff *skb)
+{
+	void *data = (void *)(long)skb->data;
+	void *data_end = (void *)(long)skb->data_end;
+
+	struct ethhdr *eth = data;
+
+	if ((void *)(eth + 1) > data_end)
+		return TC_ACT_OK;
+	if (eth->h_proto != bpf_ntohs(ETH_P_IP))
+		return TC_ACT_OK;
+
+	struct iphdr *ip = (void *)(eth + 1);
+
+	if ((void *)(ip + 1) > data_end)
+		return TC_ACT_OK;
+	if (ip->protocol != IPPROTO_TCP)
+		return TC_ACT_OK;
+
+	__u32 ihl = ip->ihl * 4;
+
+	if (ihl < sizeof(*ip) || ihl > 60)
+		return TC_ACT_OK;
+
+	struct tcphdr *tcp = (void *)ip + ihl;
+
+	if ((void *)(tcp + 1) > data_end)
+		return TC_ACT_OK;
+
+	__u32 hdr_len = tcp->doff * 4;
+
+	if (hdr_len < sizeof(*tcp))
+		return TC_ACT_OK;
+
+	__u32 tcp_off = sizeof(*eth) + ihl;
+	__u32 payload = bpf_ntohs(ip->tot_len) - ihl - hdr_len;
+
+	/* At clsact ingress the skb carries the receiving ifindex; on
+	 * egress it is zero, which is how one program serves both
+	 * directions. */
+	int ingress = skb->ingress_ifindex != 0;
+
+	struct flowkey key;
+
+	__builtin_memset(&key, 0, sizeof(key));
+	if (ingress) {
+		key.laddr = ip->daddr;
+		key.raddr = ip->saddr;
+		key.lport = tcp->dest;
+		key.rport = tcp->source;
+	} else {
+		key.laddr = ip->saddr;
+		key.raddr = ip->daddr;
+		key.lport = tcp->source;
+		key.rport = tcp->dest;
+	}
+
+	__u32 tsval = 0, tsecr = 0;
+	int have_ts = tcp_timestamps(skb, tcp_off, hdr_len, &tsval, &tsecr) == 0;
+
+	if (ingress) {
+		/* The remote echoed one of our timestamps: close the RTT
+		 * measurement it opened on egress. */
+		if (!have_ts || tsecr == 0)
+			return TC_ACT_OK;
+		struct tskey tk = { .flow = key, .tsval = tsecr };
+		__u64 *sent = bpf_map_lookup_elem(&tsvals, &tk);
+
+		if (!sent)
+			return TC_ACT_OK;
+		__u64 rtt_us = (bpf_ktime_get_ns() - *sent) / 1000;
+
+		bpf_map_delete_elem(&tsvals, &tk);
+		struct devstats *st = dev_entry(skb->ifindex);
+
+		if (!st)
+			return TC_ACT_OK;
+		__sync_fetch_and_add(&st->rtt_sum_us, rtt_us);
+		__sync_fetch_and_add(&st->rtt_samples, 1);
+		return TC_ACT_OK;
+	}
+
+	/* Egress: stamp the outgoing TSval once (a retransmit must not
+	 * restart the clock, or the RTT would shrink). */
+	if (have_ts && tsval != 0) {
+		struct tskey tk = { .flow = key, .tsval = tsval };
+		__u64 now = bpf_ktime_get_ns();
+
+		bpf_map_update_elem(&tsvals, &tk, &now, BPF_NOEXIST);
+	}
+
+	if (payload == 0 || payload > 0xffff)
+		return TC_ACT_OK;
+
+	__u32 seq = bpf_ntohl(tcp->seq);
+	__u32 end_seq = seq + payload;
+	struct flowstate *fs = bpf_map_lookup_elem(&flows, &key);
+
+	if (!fs) {
+		struct flowstate init = { .end_seq = end_seq };
+
+		bpf_map_update_elem(&flows, &key, &init, BPF_NOEXIST);
+		return TC_ACT_OK;
+	}
+	if (seq_before(seq, fs->end_seq)) {
+		/* Sending data below the highest end we have seen: a
+		 * retransmission (uplink loss). */
+		struct devstats *st = dev_entry(skb->ifindex);
+
+		if (st)
+			__sync_fetch_and_add(&st->retransmits, 1);
+		return TC_ACT_OK;
+	}
+	fs->end_seq = end_seq;
+	return TC_ACT_OK;
+}
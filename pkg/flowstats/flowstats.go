@@ -0,0 +1,210 @@
+// Package flowstats exposes per-WAN-device TCP flow statistics gathered
+// by a small eBPF program: retransmissions and smoothed flow RTTs, the
+// two numbers that show a link degrading while pings still look fine.
+//
+// The program source lives in bpf/flowstats.bpf.c and is compiled on a
+// development machine with clang -target bpf (see bpf/Makefile), then
+// shipped as the pre-compiled object DefaultObjectPath points at. It is
+// loaded and pinned with bpftool and attached to both directions of
+// each WAN device with tc — the same shell-out approach the rest of the
+// daemon uses, so there is no libbpf or CGo dependency. Kernels or
+// firmwares without eBPF (or without bpftool) simply report unsupported
+// and the daemon carries on with active probing only.
+package flowstats
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/uci"
+)
+
+// DefaultObjectPath is the shipped eBPF object.
+const DefaultObjectPath = "/usr/share/starfail/flowstats.o"
+
+// pinDir is where the program and its map are pinned; a variable for
+// tests.
+var pinDir = "/sys/fs/bpf/starfail"
+
+// sysNetDir resolves device names to ifindexes; a variable for tests.
+var sysNetDir = "/sys/class/net"
+
+// bpftoolExec runs bpftool; a variable so tests can stub it.
+var bpftoolExec = func(args ...string) (string, error) {
+	out, err := exec.Command("bpftool", args...).Output()
+	if err != nil {
+		return "", fmt.Errorf("bpftool %s: %w", strings.Join(args, " "), err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// tcExec runs tc; a variable so tests can stub it.
+var tcExec = func(args ...string) error {
+	out, err := exec.Command("tc", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("tc %s: %v: %s", strings.Join(args, " "), err, out)
+	}
+	return nil
+}
+
+// Tracker owns the loaded program and the devices it is attached to.
+type Tracker struct {
+	objPath  string
+	attached []string
+}
+
+// NewFromUCI returns a Tracker when starfail.flowstats.enabled is on and
+// the system can run it, nil otherwise (the clean fallback path).
+func NewFromUCI() *Tracker {
+	if !uci.GetBool("starfail.flowstats.enabled", false) {
+		return nil
+	}
+	obj := DefaultObjectPath
+	if v, ok := uci.Get("starfail.flowstats.object"); ok && v != "" {
+		obj = v
+	}
+	t := &Tracker{objPath: obj}
+	if !t.supported() {
+		log.Println("flowstats: eBPF not available on this system; falling back to active probing only")
+		return nil
+	}
+	return t
+}
+
+// supported checks for bpftool, a bpf filesystem and the shipped object.
+func (t *Tracker) supported() bool {
+	if _, err := os.Stat(t.objPath); err != nil {
+		return false
+	}
+	if _, err := os.Stat(filepath.Dir(pinDir)); err != nil {
+		return false // /sys/fs/bpf not mounted: kernel without BPF fs
+	}
+	_, err := bpftoolExec("version")
+	return err == nil
+}
+
+// Attach loads and pins the program once (pinning its maps alongside so
+// Stats can dump them), then attaches it to both directions of each WAN
+// device via a clsact qdisc — the program needs egress for retransmit
+// detection and timestamp stamping, ingress for the echo that closes an
+// RTT measurement. Per-device failures are logged and skipped so one
+// exotic interface does not lose stats for the rest.
+func (t *Tracker) Attach(devices ...string) error {
+	if _, err := os.Stat(filepath.Join(pinDir, "flowstats")); err != nil {
+		if _, err := bpftoolExec("prog", "load", t.objPath, filepath.Join(pinDir, "flowstats"),
+			"pinmaps", pinDir); err != nil {
+			return err
+		}
+	}
+	for _, dev := range devices {
+		if dev == "" {
+			continue
+		}
+		// clsact may already exist; tc errors on that and it is fine.
+		_ = tcExec("qdisc", "add", "dev", dev, "clsact")
+		if err := t.attachDirections(dev); err != nil {
+			log.Printf("flowstats: attach %s: %v", dev, err)
+			continue
+		}
+		t.attached = append(t.attached, dev)
+	}
+	return nil
+}
+
+// attachDirections hooks the pinned program to one device's ingress and
+// egress.
+func (t *Tracker) attachDirections(dev string) error {
+	for _, dir := range []string{"ingress", "egress"} {
+		if err := tcExec("filter", "replace", "dev", dev, dir,
+			"bpf", "da", "object-pinned", filepath.Join(pinDir, "flowstats")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeviceStats are the per-device counters the program maintains.
+type DeviceStats struct {
+	Retransmits uint64  `json:"retransmits"`
+	Flows       uint64  `json:"flows"`
+	AvgRTTMS    float64 `json:"avg_rtt_ms"`
+}
+
+// mapEntry is one element of `bpftool map dump -j`: key and value as hex
+// byte arrays. The key is the u32 ifindex; the value is three
+// little-endian u64s: retransmits, rtt sum (µs), rtt samples.
+type mapEntry struct {
+	Key   []string `json:"key"`
+	Value []string `json:"value"`
+}
+
+// Stats dumps the pinned map and returns counters per attached device.
+func (t *Tracker) Stats() map[string]DeviceStats {
+	out, err := bpftoolExec("map", "dump", "pinned", filepath.Join(pinDir, "flowstats_map"), "-j")
+	if err != nil {
+		return nil
+	}
+	var entries []mapEntry
+	if err := json.Unmarshal([]byte(out), &entries); err != nil {
+		return nil
+	}
+	byIndex := make(map[uint64]DeviceStats)
+	for _, e := range entries {
+		key, ok := hexLE(e.Key)
+		if !ok || len(e.Value) < 24 {
+			continue
+		}
+		retrans, ok1 := hexLE(e.Value[0:8])
+		rttSum, ok2 := hexLE(e.Value[8:16])
+		samples, ok3 := hexLE(e.Value[16:24])
+		if !ok1 || !ok2 || !ok3 {
+			continue
+		}
+		st := DeviceStats{Retransmits: retrans, Flows: samples}
+		if samples > 0 {
+			st.AvgRTTMS = float64(rttSum) / float64(samples) / 1000
+		}
+		byIndex[key] = st
+	}
+	stats := make(map[string]DeviceStats)
+	for _, dev := range t.attached {
+		if st, ok := byIndex[ifindex(dev)]; ok {
+			stats[dev] = st
+		}
+	}
+	return stats
+}
+
+// hexLE decodes a bpftool hex byte array ("0x2a", ...) as a
+// little-endian unsigned integer of up to eight bytes.
+func hexLE(bytes []string) (uint64, bool) {
+	if len(bytes) == 0 || len(bytes) > 8 {
+		return 0, false
+	}
+	buf := make([]byte, 8)
+	for i, s := range bytes {
+		v, err := strconv.ParseUint(strings.TrimPrefix(s, "0x"), 16, 8)
+		if err != nil {
+			return 0, false
+		}
+		buf[i] = byte(v)
+	}
+	return binary.LittleEndian.Uint64(buf), true
+}
+
+// ifindex resolves a device name; 0 when unknown.
+func ifindex(dev string) uint64 {
+	raw, err := os.ReadFile(filepath.Join(sysNetDir, dev, "ifindex"))
+	if err != nil {
+		return 0
+	}
+	v, _ := strconv.ParseUint(strings.TrimSpace(string(raw)), 10, 64)
+	return v
+}
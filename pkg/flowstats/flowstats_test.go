@@ -0,0 +1,121 @@
+package flowstats
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// hexBytes renders v as a bpftool-style little-endian hex byte array.
+func hexBytes(v uint64, n int) []string {
+	out := make([]string, n)
+	for i := 0; i < n; i++ {
+		out[i] = fmt.Sprintf("0x%02x", byte(v>>(8*i)))
+	}
+	return out
+}
+
+func installFlowStubs(t *testing.T, dump string) (bpfCalls, tcCalls *[]string) {
+	t.Helper()
+	dir := t.TempDir()
+	var bpf, tc []string
+	origBpf, origTc, origPin, origSys := bpftoolExec, tcExec, pinDir, sysNetDir
+	pinDir = filepath.Join(dir, "bpf", "starfail")
+	sysNetDir = filepath.Join(dir, "net")
+	bpftoolExec = func(args ...string) (string, error) {
+		bpf = append(bpf, strings.Join(args, " "))
+		if args[0] == "map" {
+			return dump, nil
+		}
+		return "", nil
+	}
+	tcExec = func(args ...string) error {
+		tc = append(tc, strings.Join(args, " "))
+		return nil
+	}
+	t.Cleanup(func() { bpftoolExec, tcExec, pinDir, sysNetDir = origBpf, origTc, origPin, origSys })
+	return &bpf, &tc
+}
+
+func writeIfindex(t *testing.T, dev string, idx int) {
+	t.Helper()
+	d := filepath.Join(sysNetDir, dev)
+	if err := os.MkdirAll(d, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(d, "ifindex"), []byte(fmt.Sprintf("%d\n", idx)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAttachAndStats(t *testing.T) {
+	entries := []mapEntry{
+		{Key: hexBytes(3, 4), Value: append(append(hexBytes(42, 8), hexBytes(90_000, 8)...), hexBytes(3, 8)...)},
+		{Key: hexBytes(7, 4), Value: append(append(hexBytes(0, 8), hexBytes(0, 8)...), hexBytes(0, 8)...)},
+	}
+	dump, _ := json.Marshal(entries)
+	bpfCalls, tcCalls := installFlowStubs(t, string(dump))
+	writeIfindex(t, "eth1", 3)
+	writeIfindex(t, "wwan0", 7)
+
+	tr := &Tracker{objPath: "/tmp/flowstats.o"}
+	if err := tr.Attach("eth1", "wwan0", ""); err != nil {
+		t.Fatalf("Attach: %v", err)
+	}
+	joined := strings.Join(*tcCalls, "\n")
+	for _, dev := range []string{"eth1", "wwan0"} {
+		if !strings.Contains(joined, "qdisc add dev "+dev+" clsact") ||
+			!strings.Contains(joined, "filter replace dev "+dev+" ingress") ||
+			!strings.Contains(joined, "filter replace dev "+dev+" egress") {
+			t.Errorf("missing tc setup for %s:\n%s", dev, joined)
+		}
+	}
+	if !strings.Contains(strings.Join(*bpfCalls, "\n"), "prog load") {
+		t.Errorf("program never loaded: %v", *bpfCalls)
+	}
+
+	stats := tr.Stats()
+	if len(stats) != 2 {
+		t.Fatalf("stats = %v, want both devices", stats)
+	}
+	if st := stats["eth1"]; st.Retransmits != 42 || st.Flows != 3 || st.AvgRTTMS != 30 {
+		t.Errorf("eth1 = %+v", st)
+	}
+	if st := stats["wwan0"]; st.Retransmits != 0 || st.AvgRTTMS != 0 {
+		t.Errorf("wwan0 = %+v", st)
+	}
+}
+
+func TestStatsDumpFailure(t *testing.T) {
+	installFlowStubs(t, "")
+	origBpf := bpftoolExec
+	bpftoolExec = func(args ...string) (string, error) { return "", fmt.Errorf("no such map") }
+	t.Cleanup(func() { bpftoolExec = origBpf })
+	tr := &Tracker{attached: []string{"eth1"}}
+	if stats := tr.Stats(); stats != nil {
+		t.Errorf("stats = %v, want nil on dump failure", stats)
+	}
+}
+
+func TestSupportedMissingObject(t *testing.T) {
+	installFlowStubs(t, "")
+	tr := &Tracker{objPath: filepath.Join(t.TempDir(), "missing.o")}
+	if tr.supported() {
+		t.Error("supported without the eBPF object")
+	}
+}
+
+func TestHexLE(t *testing.T) {
+	if v, ok := hexLE([]string{"0x2a", "0x00", "0x00", "0x00"}); !ok || v != 42 {
+		t.Errorf("hexLE = %d/%v", v, ok)
+	}
+	if _, ok := hexLE([]string{"0xzz"}); ok {
+		t.Error("garbage hex accepted")
+	}
+	if _, ok := hexLE(nil); ok {
+		t.Error("empty accepted")
+	}
+}
@@ -0,0 +1,107 @@
+// Package gps reads position fixes from the RUTOS gpsd ubus object, falling
+// back to parsing raw NMEA from gpsctl when ubus has no fix.
+package gps
+
+import (
+	"context"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/ubus"
+)
+
+// Fix is one position sample.
+type Fix struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Altitude  float64 `json:"altitude"`
+	SpeedKmh  float64 `json:"speed_kmh"`
+	// HeadingDeg is the course over ground; only meaningful when
+	// HeadingValid is set (a stationary receiver reports no course).
+	HeadingDeg   float64 `json:"heading_deg"`
+	HeadingValid bool    `json:"heading_valid"`
+	Satellites int    `json:"satellites"`
+	Valid     bool    `json:"valid"`
+}
+
+// Current returns the best available fix.
+func Current(ctx context.Context) (*Fix, error) {
+	var pos struct {
+		Latitude   float64 `json:"latitude"`
+		Longitude  float64 `json:"longitude"`
+		Altitude   float64 `json:"altitude"`
+		Speed      float64 `json:"speed"`
+		Satellites int     `json:"satellites"`
+		FixStatus  string  `json:"fix_status"`
+	}
+	if err := ubus.Call("gpsd", "position", nil, &pos); err == nil && pos.FixStatus != "0" {
+		return &Fix{
+			Latitude:   pos.Latitude,
+			Longitude:  pos.Longitude,
+			Altitude:   pos.Altitude,
+			SpeedKmh:   pos.Speed,
+			Satellites: pos.Satellites,
+			Valid:      true,
+		}, nil
+	}
+	// Fallback: raw NMEA via gpsctl.
+	out, err := exec.CommandContext(ctx, "gpsctl", "-x").Output()
+	if err != nil {
+		return &Fix{}, err
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		if fix, ok := ParseRMC(strings.TrimSpace(line)); ok {
+			return fix, nil
+		}
+	}
+	return &Fix{}, nil
+}
+
+// ParseRMC parses a $..RMC NMEA sentence into a Fix. ok is false for other
+// sentence types or unparseable input.
+func ParseRMC(line string) (*Fix, bool) {
+	if !strings.HasPrefix(line, "$") {
+		return nil, false
+	}
+	// Strip checksum.
+	if i := strings.IndexByte(line, '*'); i >= 0 {
+		line = line[:i]
+	}
+	f := strings.Split(line, ",")
+	if len(f) < 10 || !strings.HasSuffix(f[0], "RMC") {
+		return nil, false
+	}
+	if f[2] != "A" { // void fix
+		return &Fix{}, true
+	}
+	lat, ok1 := parseCoord(f[3], f[4])
+	lon, ok2 := parseCoord(f[5], f[6])
+	if !ok1 || !ok2 {
+		return nil, false
+	}
+	fix := &Fix{Latitude: lat, Longitude: lon, Valid: true}
+	if kn, err := strconv.ParseFloat(f[7], 64); err == nil {
+		fix.SpeedKmh = kn * 1.852
+	}
+	if deg, err := strconv.ParseFloat(f[8], 64); err == nil {
+		fix.HeadingDeg = deg
+		fix.HeadingValid = true
+	}
+	return fix, true
+}
+
+// parseCoord converts NMEA ddmm.mmmm + hemisphere into decimal degrees.
+func parseCoord(val, hemi string) (float64, bool) {
+	v, err := strconv.ParseFloat(val, 64)
+	if err != nil {
+		return 0, false
+	}
+	deg := float64(int(v / 100))
+	min := v - deg*100
+	d := deg + min/60
+	if hemi == "S" || hemi == "W" {
+		d = -d
+	}
+	return d, true
+}
@@ -0,0 +1,56 @@
+package gps
+
+import "testing"
+
+func TestParseRMC(t *testing.T) {
+	fix, ok := ParseRMC("$GPRMC,123519,A,4807.038,N,01131.000,E,022.4,084.4,230394,003.1,W*6A")
+	if !ok {
+		t.Fatal("valid RMC sentence rejected")
+	}
+	if !fix.Valid {
+		t.Fatal("fix not marked valid")
+	}
+	if fix.Latitude < 48.11 || fix.Latitude > 48.12 {
+		t.Errorf("latitude = %v, want ~48.117", fix.Latitude)
+	}
+	if fix.Longitude < 11.51 || fix.Longitude > 11.52 {
+		t.Errorf("longitude = %v, want ~11.516", fix.Longitude)
+	}
+	if fix.SpeedKmh < 41 || fix.SpeedKmh > 42 {
+		t.Errorf("speed = %v km/h, want ~41.5", fix.SpeedKmh)
+	}
+}
+
+func TestParseRMCVoidFix(t *testing.T) {
+	fix, ok := ParseRMC("$GPRMC,123519,V,,,,,,,230394,,*00")
+	if !ok {
+		t.Fatal("void RMC sentence rejected entirely")
+	}
+	if fix.Valid {
+		t.Error("void fix marked valid")
+	}
+}
+
+func TestParseRMCRejects(t *testing.T) {
+	for _, line := range []string{
+		"",
+		"GPRMC,no,dollar",
+		"$GPGGA,123519,4807.038,N,01131.000,E,1,08,0.9,545.4,M,46.9,M,,*47",
+		"$GPRMC,short",
+		"$GPRMC,123519,A,bogus,N,01131.000,E,022.4,084.4,230394,,*6A",
+	} {
+		if fix, ok := ParseRMC(line); ok && fix.Valid {
+			t.Errorf("ParseRMC(%q) returned a valid fix", line)
+		}
+	}
+}
+
+func FuzzParseRMC(f *testing.F) {
+	f.Add("$GPRMC,123519,A,4807.038,N,01131.000,E,022.4,084.4,230394,003.1,W*6A")
+	f.Add("$GPRMC,123519,V,,,,,,,230394,,*00")
+	f.Add("$GNRMC,,,,,,,,,,,,")
+	f.Fuzz(func(t *testing.T, line string) {
+		// Must never panic on arbitrary serial garbage.
+		_, _ = ParseRMC(line)
+	})
+}
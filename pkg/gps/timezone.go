@@ -0,0 +1,99 @@
+package gps
+
+// Timezone from position. A vehicle that crosses a border keeps its
+// maintenance windows, quiet hours and report schedule on the configured
+// zone unless something re-derives local time; this does that from the
+// GPS fix. The table below is a coarse set of bounding boxes over the
+// regions a RUTOS router plausibly drives through, first match wins —
+// a few hundred bytes instead of a shapefile, at the cost of fuzzy
+// borders in the far north. Anywhere the table does not cover falls back
+// to solar time (15 degrees of longitude per hour), which nails the
+// offset and only misses DST rules.
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// tzRefresh bounds how often LocalLocation re-reads the fix; zone
+// changes are measured in hours of driving.
+const tzRefresh = 15 * time.Minute
+
+// tzCurrent reads the fix; a variable so tests can stub it.
+var tzCurrent = Current
+
+// tzBox maps a lat/lon bounding box to an IANA zone.
+type tzBox struct {
+	latMin, latMax float64
+	lonMin, lonMax float64
+	zone           string
+}
+
+// zoneTable is ordered most-specific-first; the wide central-European
+// box at the end catches everything the earlier boxes carve out.
+var zoneTable = []tzBox{
+	{49, 61.5, -11, 2, "Europe/London"},
+	{36.8, 42.2, -9.6, -6.2, "Europe/Lisbon"},
+	// Northern Sweden reaches ~24°E, past the wide central-European box;
+	// carve it out ahead of the Helsinki entries so Kiruna stays on CET.
+	{65.5, 69.1, 19, 23.8, "Europe/Stockholm"},
+	{59.5, 65.5, 19.5, 31.6, "Europe/Helsinki"},
+	{65.5, 69.1, 23.8, 31.6, "Europe/Helsinki"},
+	{68, 71.5, 18, 32, "Europe/Oslo"},
+	{53.9, 59.9, 20.9, 28.2, "Europe/Tallinn"},
+	{34.8, 41.8, 19.5, 28.3, "Europe/Athens"},
+	{36, 42, 26, 45, "Europe/Istanbul"},
+	{43, 70, 31.6, 60, "Europe/Moscow"},
+	{36, 71.5, -9.9, 19, "Europe/Stockholm"},
+	{24, 50, -82, -67, "America/New_York"},
+	{25, 50, -102, -82, "America/Chicago"},
+	{25, 50, -115, -102, "America/Denver"},
+	{32, 49, -125, -115, "America/Los_Angeles"},
+	{51, 72, -170, -130, "America/Anchorage"},
+}
+
+// ZoneFor returns the local timezone for a position and its name. A
+// position outside the table, or a router without tzdata for the matched
+// zone, gets a fixed solar-time zone instead.
+func ZoneFor(lat, lon float64) (*time.Location, string) {
+	for _, b := range zoneTable {
+		if lat < b.latMin || lat > b.latMax || lon < b.lonMin || lon > b.lonMax {
+			continue
+		}
+		if loc, err := time.LoadLocation(b.zone); err == nil {
+			return loc, b.zone
+		}
+		break
+	}
+	off := int(math.Round(lon / 15))
+	name := fmt.Sprintf("UTC%+d", off)
+	return time.FixedZone(name, off*3600), name
+}
+
+var tzMu sync.Mutex
+var tzLoc *time.Location
+var tzChecked time.Time
+
+// LocalLocation returns the timezone at the router's current position,
+// cached between refreshes. Without a fix it returns the last derived
+// zone, or the system zone when none has ever been derived — a parked
+// indoor router behaves exactly as before.
+func LocalLocation() *time.Location {
+	tzMu.Lock()
+	defer tzMu.Unlock()
+	if now := time.Now(); now.Sub(tzChecked) >= tzRefresh {
+		tzChecked = now
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		if fix, err := tzCurrent(ctx); err == nil && fix.Valid {
+			tzLoc, _ = ZoneFor(fix.Latitude, fix.Longitude)
+		}
+	}
+	if tzLoc == nil {
+		return time.Local
+	}
+	return tzLoc
+}
@@ -0,0 +1,76 @@
+package gps
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestZoneForTable(t *testing.T) {
+	cases := []struct {
+		name     string
+		lat, lon float64
+		zone     string
+	}{
+		{"Stockholm", 59.33, 18.07, "Europe/Stockholm"},
+		{"London", 51.5, -0.12, "Europe/London"},
+		{"Helsinki", 60.17, 24.94, "Europe/Helsinki"},
+		{"Rovaniemi", 66.5, 25.7, "Europe/Helsinki"},
+		{"Kiruna keeps CET", 67.85, 20.22, "Europe/Stockholm"},
+		{"Tromso", 69.65, 18.95, "Europe/Oslo"},
+		{"Lisbon", 38.72, -9.14, "Europe/Lisbon"},
+		{"Vilnius", 54.69, 25.28, "Europe/Tallinn"},
+		{"Denver", 39.74, -104.99, "America/Denver"},
+	}
+	for _, tc := range cases {
+		if _, zone := ZoneFor(tc.lat, tc.lon); zone != tc.zone {
+			t.Errorf("%s: zone = %s, want %s", tc.name, zone, tc.zone)
+		}
+	}
+}
+
+func TestZoneForSolarFallback(t *testing.T) {
+	loc, zone := ZoneFor(0, -150) // mid-Pacific, nowhere near the table
+	if zone != "UTC-10" {
+		t.Fatalf("zone = %s, want UTC-10", zone)
+	}
+	_, off := time.Now().In(loc).Zone()
+	if off != -10*3600 {
+		t.Errorf("offset = %d, want %d", off, -10*3600)
+	}
+	if _, zone := ZoneFor(0, 0); zone != "UTC+0" {
+		t.Errorf("Gulf of Guinea zone = %s, want UTC+0", zone)
+	}
+}
+
+func installTzFix(t *testing.T, fix *Fix, err error) {
+	t.Helper()
+	oldCur, oldLoc, oldChecked := tzCurrent, tzLoc, tzChecked
+	tzCurrent = func(context.Context) (*Fix, error) { return fix, err }
+	tzLoc, tzChecked = nil, time.Time{}
+	t.Cleanup(func() { tzCurrent, tzLoc, tzChecked = oldCur, oldLoc, oldChecked })
+}
+
+func TestLocalLocationFollowsFix(t *testing.T) {
+	installTzFix(t, &Fix{Latitude: 60.17, Longitude: 24.94, Valid: true}, nil)
+	if loc := LocalLocation(); loc.String() != "Europe/Helsinki" {
+		t.Fatalf("location = %s, want Europe/Helsinki", loc)
+	}
+	// Inside the refresh interval the cached zone is served without
+	// another fix read.
+	tzCurrent = func(context.Context) (*Fix, error) {
+		t.Error("fix read inside the refresh interval")
+		return nil, nil
+	}
+	if loc := LocalLocation(); loc.String() != "Europe/Helsinki" {
+		t.Errorf("cached location = %s, want Europe/Helsinki", loc)
+	}
+}
+
+func TestLocalLocationWithoutFix(t *testing.T) {
+	installTzFix(t, nil, errors.New("no gps"))
+	if loc := LocalLocation(); loc != time.Local {
+		t.Errorf("location without any fix = %s, want the system zone", loc)
+	}
+}
@@ -0,0 +1,37 @@
+// Package heartbeat gives the two daemons a cheap mutual liveness channel:
+// each periodically touches a file under /tmp/starfail, and the peer judges
+// health from the file's age. A pidfile only proves a process exists; a
+// hung daemon keeps its pid but stops beating.
+package heartbeat
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Dir is where heartbeat files live; a var so tests can redirect it.
+var Dir = "/tmp/starfail"
+
+// Path returns the heartbeat file for a daemon name.
+func Path(name string) string {
+	return filepath.Join(Dir, name+".heartbeat")
+}
+
+// Beat records that the named daemon is alive right now.
+func Beat(name string) error {
+	if err := os.MkdirAll(Dir, 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(Path(name), []byte(time.Now().Format(time.RFC3339)+"\n"), 0o644)
+}
+
+// Age returns how long ago the named daemon last beat; ok is false when it
+// never has (fresh boot, or the daemon is intentionally not running).
+func Age(name string) (age time.Duration, ok bool) {
+	st, err := os.Stat(Path(name))
+	if err != nil {
+		return 0, false
+	}
+	return time.Since(st.ModTime()), true
+}
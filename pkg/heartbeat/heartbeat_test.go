@@ -0,0 +1,26 @@
+package heartbeat
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBeatAndAge(t *testing.T) {
+	orig := Dir
+	Dir = t.TempDir()
+	t.Cleanup(func() { Dir = orig })
+
+	if _, ok := Age("starfaild"); ok {
+		t.Fatal("age reported before any beat")
+	}
+	if err := Beat("starfaild"); err != nil {
+		t.Fatalf("beat: %v", err)
+	}
+	age, ok := Age("starfaild")
+	if !ok {
+		t.Fatal("no age after beat")
+	}
+	if age > time.Minute {
+		t.Errorf("age = %v immediately after beat", age)
+	}
+}
@@ -0,0 +1,91 @@
+// Package identity derives a stable device identity for telemetry
+// tagging. A fleet of routers publishing to one broker or webhook
+// endpoint is indistinguishable by hostname alone — RUTOS ships every
+// box as "Teltonika-RUTX50" — so remote consumers need an identifier
+// that survives reboots, firmware upgrades and hostname changes. The ID
+// is a short hash of the board serial (from ubus system board), falling
+// back to the first ethernet MAC and finally the hostname; an optional
+// friendly name (starfail.main.device_name) rides along for dashboards.
+package identity
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/ubus"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/uci"
+)
+
+// boardSerial asks ubus for the board's serial number; a variable so
+// tests can stub the binary.
+var boardSerial = func() (string, error) {
+	var b struct {
+		Serial   string `json:"serial"`
+		Hostname string `json:"hostname"`
+	}
+	if err := ubus.Call("system", "board", nil, &b); err != nil {
+		return "", err
+	}
+	return b.Serial, nil
+}
+
+// firstMAC reads the first ethernet device's hardware address; a
+// variable for tests.
+var firstMAC = func() (string, error) {
+	b, err := os.ReadFile("/sys/class/net/eth0/address")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
+var (
+	mu       sync.Mutex
+	cachedID string
+)
+
+// ID returns the stable device identifier: twelve hex characters hashed
+// from the most durable hardware identity available. The value is
+// computed once and cached; all fallbacks exhausted, it hashes the
+// hostname so the function never returns empty.
+func ID() string {
+	mu.Lock()
+	defer mu.Unlock()
+	if cachedID != "" {
+		return cachedID
+	}
+	material := ""
+	if s, err := boardSerial(); err == nil && s != "" {
+		material = "serial:" + s
+	} else if m, err := firstMAC(); err == nil && m != "" {
+		material = "mac:" + m
+	} else {
+		host, _ := os.Hostname()
+		material = "host:" + host
+	}
+	sum := sha1.Sum([]byte(material))
+	cachedID = hex.EncodeToString(sum[:6])
+	return cachedID
+}
+
+// Name returns the operator-facing device name: the configured friendly
+// name when set, else the hostname, else the device ID.
+func Name() string {
+	if n, ok := uci.Get("starfail.main.device_name"); ok && n != "" {
+		return n
+	}
+	if host, err := os.Hostname(); err == nil && host != "" {
+		return host
+	}
+	return ID()
+}
+
+// resetCache clears the memoized ID; tests stub the sources and re-derive.
+func resetCache() {
+	mu.Lock()
+	cachedID = ""
+	mu.Unlock()
+}
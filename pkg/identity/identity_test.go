@@ -0,0 +1,70 @@
+package identity
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/uci"
+)
+
+func installIdentityStubs(t *testing.T, serial, mac string) {
+	t.Helper()
+	origSerial, origMAC, origExec := boardSerial, firstMAC, uci.Exec
+	boardSerial = func() (string, error) {
+		if serial == "" {
+			return "", fmt.Errorf("no serial")
+		}
+		return serial, nil
+	}
+	firstMAC = func() (string, error) {
+		if mac == "" {
+			return "", fmt.Errorf("no eth0")
+		}
+		return mac, nil
+	}
+	uci.Exec = func(...string) (string, error) { return "", fmt.Errorf("not found") }
+	resetCache()
+	t.Cleanup(func() {
+		boardSerial, firstMAC, uci.Exec = origSerial, origMAC, origExec
+		resetCache()
+	})
+}
+
+func TestIDStableAndFromSerial(t *testing.T) {
+	installIdentityStubs(t, "1102334455", "aa:bb:cc:dd:ee:ff")
+	id := ID()
+	if len(id) != 12 {
+		t.Fatalf("ID length = %d, want 12", len(id))
+	}
+	if ID() != id {
+		t.Error("ID changed between calls")
+	}
+	resetCache()
+	if ID() != id {
+		t.Error("ID not reproducible from the same serial")
+	}
+}
+
+func TestIDFallsBackToMAC(t *testing.T) {
+	installIdentityStubs(t, "1102334455", "aa:bb:cc:dd:ee:ff")
+	withSerial := ID()
+
+	installIdentityStubs(t, "", "aa:bb:cc:dd:ee:ff")
+	withMAC := ID()
+	if withMAC == withSerial {
+		t.Error("MAC fallback produced the serial-derived ID")
+	}
+	if len(withMAC) != 12 {
+		t.Errorf("fallback ID length = %d, want 12", len(withMAC))
+	}
+}
+
+func TestNamePrefersUCI(t *testing.T) {
+	installIdentityStubs(t, "1102334455", "")
+	uci.Exec = func(args ...string) (string, error) {
+		return "garage-router", nil
+	}
+	if got := Name(); got != "garage-router" {
+		t.Errorf("Name() = %q, want the configured friendly name", got)
+	}
+}
@@ -0,0 +1,93 @@
+// Package indicator drives a local status output: a router LED blinked
+// through sysfs, or a small I2C SSD1306 OLED showing a status line. Both
+// answer the same field question — "is this box failed over right now?" —
+// without SSH, an app, or cell coverage, which is exactly when the
+// question comes up.
+package indicator
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/uci"
+)
+
+// Status is the summary a driver renders each round.
+type Status struct {
+	Active   string  // active member name, empty when none
+	Score    float64 // active member's final score
+	Failover bool    // true when the active member is not the preferred one
+	Healthy  int     // members currently eligible
+	Total    int     // members configured
+}
+
+// driver is one output backend. apply may be called with the same status
+// repeatedly; backends are expected to skip redundant hardware writes.
+type driver interface {
+	apply(s Status) error
+	close()
+}
+
+// Indicator refreshes one configured driver on an interval.
+type Indicator struct {
+	IntervalS int
+
+	drv driver
+}
+
+// NewFromUCI builds an Indicator from starfail.indicator.mode ("led" or
+// "oled") and its driver options. Returns nil when no mode is configured
+// or the configured hardware is absent — a missing LED name or display
+// should not keep the daemon from starting.
+func NewFromUCI() *Indicator {
+	mode, _ := uci.Get("starfail.indicator.mode")
+	var drv driver
+	var err error
+	switch mode {
+	case "":
+		return nil
+	case "led":
+		name, _ := uci.Get("starfail.indicator.led")
+		drv, err = newLEDDriver(name)
+	case "oled":
+		dev, ok := uci.Get("starfail.indicator.oled_dev")
+		if !ok || dev == "" {
+			dev = "/dev/i2c-0"
+		}
+		drv, err = newOLEDDriver(dev, uci.GetInt("starfail.indicator.oled_addr", 0x3c))
+	default:
+		log.Printf("indicator: unknown mode %q", mode)
+		return nil
+	}
+	if err != nil {
+		log.Printf("indicator: %v, disabled", err)
+		return nil
+	}
+	return &Indicator{
+		IntervalS: uci.GetInt("starfail.indicator.interval_s", 5),
+		drv:       drv,
+	}
+}
+
+// Run refreshes the output every IntervalS until ctx is cancelled, then
+// releases the hardware.
+func (ind *Indicator) Run(ctx context.Context, snapshot func() Status) {
+	interval := time.Duration(ind.IntervalS) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	defer ind.drv.close()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := ind.drv.apply(snapshot()); err != nil {
+				log.Printf("indicator: %v", err)
+			}
+		}
+	}
+}
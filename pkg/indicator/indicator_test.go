@@ -0,0 +1,166 @@
+package indicator
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStatusPattern(t *testing.T) {
+	healthy := Status{Active: "starlink", Healthy: 2, Total: 2}
+	if p := statusPattern(healthy); p.delayOn != 0 || !p.on {
+		t.Errorf("healthy pattern = %+v, want steady on", p)
+	}
+	failover := Status{Active: "mob1s1a1", Failover: true, Healthy: 1, Total: 2}
+	if p := statusPattern(failover); p.delayOn != 500 {
+		t.Errorf("failover pattern = %+v, want slow blink", p)
+	}
+	dark := Status{Total: 2}
+	if p := statusPattern(dark); p.delayOn != 100 {
+		t.Errorf("no-link pattern = %+v, want fast blink", p)
+	}
+}
+
+func installFakeLED(t *testing.T, name string) string {
+	t.Helper()
+	old := ledDir
+	ledDir = t.TempDir()
+	t.Cleanup(func() { ledDir = old })
+	dir := filepath.Join(ledDir, name)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "max_brightness"), []byte("255\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestLEDDriverWritesSysfs(t *testing.T) {
+	dir := installFakeLED(t, "wan")
+	d, err := newLEDDriver("wan")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := d.apply(Status{Active: "starlink", Healthy: 2, Total: 2}); err != nil {
+		t.Fatal(err)
+	}
+	read := func(file string) string {
+		t.Helper()
+		raw, err := os.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(raw)
+	}
+	if read("trigger") != "none" || read("brightness") != "255" {
+		t.Errorf("healthy: trigger=%q brightness=%q", read("trigger"), read("brightness"))
+	}
+
+	if err := d.apply(Status{Active: "mob1s1a1", Failover: true, Healthy: 1, Total: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if read("trigger") != "timer" || read("delay_on") != "500" || read("delay_off") != "500" {
+		t.Errorf("failover: trigger=%q delays=%q/%q", read("trigger"), read("delay_on"), read("delay_off"))
+	}
+
+	// Same status again: no hardware churn.
+	if err := os.Remove(filepath.Join(dir, "trigger")); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.apply(Status{Active: "mob1s1a1", Failover: true, Healthy: 1, Total: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "trigger")); !os.IsNotExist(err) {
+		t.Error("redundant apply rewrote the trigger")
+	}
+}
+
+func TestLEDDriverMissingHardware(t *testing.T) {
+	installFakeLED(t, "wan")
+	if _, err := newLEDDriver("nope"); err == nil {
+		t.Error("missing LED accepted")
+	}
+	if _, err := newLEDDriver(""); err == nil {
+		t.Error("empty LED name accepted")
+	}
+}
+
+func TestStatusLines(t *testing.T) {
+	lines := statusLines(Status{Active: "starlink", Score: 87.5, Healthy: 2, Total: 2})
+	if lines[0] != "STARFAIL OK" || lines[1] != "STARLINK 88 2/2" {
+		t.Errorf("healthy lines = %q", lines)
+	}
+	lines = statusLines(Status{Active: "mob1s1a1", Score: 61, Failover: true, Healthy: 1, Total: 2})
+	if lines[0] != "STARFAIL: FAILOVER" {
+		t.Errorf("failover head = %q", lines[0])
+	}
+	lines = statusLines(Status{Total: 2})
+	if lines[0] != "STARFAIL: NO LINK" || lines[1] != "NO ACTIVE MEMBER" {
+		t.Errorf("dark lines = %q", lines)
+	}
+}
+
+type fakeBus struct {
+	writes [][]byte
+}
+
+func (b *fakeBus) Write(p []byte) (int, error) {
+	b.writes = append(b.writes, append([]byte(nil), p...))
+	return len(p), nil
+}
+
+func (b *fakeBus) Close() error { return nil }
+
+func TestOLEDDriverRendersFramebuffer(t *testing.T) {
+	bus := &fakeBus{}
+	old := openI2C
+	openI2C = func(dev string, addr int) (io.WriteCloser, error) { return bus, nil }
+	t.Cleanup(func() { openI2C = old })
+
+	d, err := newOLEDDriver("/dev/i2c-0", 0x3c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bus.writes) != 1 || bus.writes[0][0] != 0x00 {
+		t.Fatalf("init wrote %d messages, want one command sequence", len(bus.writes))
+	}
+
+	if err := d.apply(Status{Active: "starlink", Score: 87.5, Healthy: 2, Total: 2}); err != nil {
+		t.Fatal(err)
+	}
+	data := bus.writes[len(bus.writes)-1]
+	if data[0] != 0x40 || len(data) != 1+oledWidth*oledPages {
+		t.Fatalf("data write: control=%#x len=%d", data[0], len(data))
+	}
+	// Page 0 starts with the glyph for 'S'.
+	s := font5x7['S']
+	if !bytes.Equal(data[1:6], s[:]) {
+		t.Errorf("framebuffer starts % x, want 'S' glyph % x", data[1:6], s)
+	}
+
+	// Unchanged status: no redraw.
+	n := len(bus.writes)
+	if err := d.apply(Status{Active: "starlink", Score: 87.5, Healthy: 2, Total: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if len(bus.writes) != n {
+		t.Error("redundant apply redrew the display")
+	}
+}
+
+func TestDrawTextTruncatesAtRightEdge(t *testing.T) {
+	d := &oledDriver{}
+	d.drawText(0, "WWWWWWWWWWWWWWWWWWWWWWWWWW") // wider than 128 columns
+	if d.fb[oledWidth-1] == 0 && d.fb[oledWidth-2] == 0 {
+		t.Error("long line did not reach the right edge")
+	}
+	for i := oledWidth; i < 2*oledWidth; i++ {
+		if d.fb[i] != 0 {
+			t.Fatalf("long line bled into page 1 at column %d", i-oledWidth)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package indicator
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// ledDir is the sysfs LED class root; a variable for tests.
+var ledDir = "/sys/class/leds"
+
+// ledPattern is what the LED shows: steady at a brightness, or a timer
+// blink with the given phase lengths.
+type ledPattern struct {
+	on                bool
+	delayOn, delayOff int // milliseconds; zero means steady
+}
+
+// statusPattern maps health to a pattern a passer-by can read without
+// documentation: solid is good, slow blink means running on a backup,
+// fast blink means trouble right now.
+func statusPattern(s Status) ledPattern {
+	switch {
+	case s.Active == "" || s.Healthy == 0:
+		return ledPattern{on: true, delayOn: 100, delayOff: 100}
+	case s.Failover:
+		return ledPattern{on: true, delayOn: 500, delayOff: 500}
+	default:
+		return ledPattern{on: true}
+	}
+}
+
+// ledDriver blinks one sysfs LED.
+type ledDriver struct {
+	dir  string
+	max  string
+	last ledPattern
+}
+
+func newLEDDriver(name string) (*ledDriver, error) {
+	if name == "" {
+		return nil, fmt.Errorf("led: no led name configured")
+	}
+	dir := filepath.Join(ledDir, name)
+	if _, err := os.Stat(dir); err != nil {
+		return nil, fmt.Errorf("led %s: %w", name, err)
+	}
+	max := "255"
+	if raw, err := os.ReadFile(filepath.Join(dir, "max_brightness")); err == nil {
+		max = string(bytes.TrimSpace(raw))
+	}
+	return &ledDriver{dir: dir, max: max, last: ledPattern{delayOn: -1}}, nil
+}
+
+func (d *ledDriver) apply(s Status) error {
+	p := statusPattern(s)
+	if p == d.last {
+		return nil
+	}
+	if p.delayOn > 0 {
+		// Order matters: the timer trigger creates the delay files.
+		if err := d.write("trigger", "timer"); err != nil {
+			return err
+		}
+		if err := d.write("delay_on", strconv.Itoa(p.delayOn)); err != nil {
+			return err
+		}
+		if err := d.write("delay_off", strconv.Itoa(p.delayOff)); err != nil {
+			return err
+		}
+	} else {
+		if err := d.write("trigger", "none"); err != nil {
+			return err
+		}
+		b := "0"
+		if p.on {
+			b = d.max
+		}
+		if err := d.write("brightness", b); err != nil {
+			return err
+		}
+	}
+	d.last = p
+	return nil
+}
+
+// close hands the LED back to the firmware default instead of leaving a
+// stale pattern behind.
+func (d *ledDriver) close() {
+	_ = d.write("trigger", "none")
+	_ = d.write("brightness", "0")
+}
+
+func (d *ledDriver) write(file, value string) error {
+	if err := os.WriteFile(filepath.Join(d.dir, file), []byte(value), 0o644); err != nil {
+		return fmt.Errorf("led: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,217 @@
+package indicator
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"syscall"
+)
+
+// SSD1306 driver over the Linux i2c-dev interface. The display protocol
+// is a handful of command bytes and a raw framebuffer write, so it is
+// hand-rolled here rather than pulling in a graphics dependency — the
+// same trade the MQTT client makes.
+
+const (
+	i2cSlaveIoctl = 0x0703 // I2C_SLAVE from linux/i2c-dev.h
+
+	oledWidth  = 128
+	oledHeight = 32
+	oledPages  = oledHeight / 8
+)
+
+// openI2C opens the bus and selects the device address; a variable so
+// tests can substitute an in-memory transport.
+var openI2C = func(dev string, addr int) (io.WriteCloser, error) {
+	f, err := os.OpenFile(dev, os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), i2cSlaveIoctl, uintptr(addr)); errno != 0 {
+		_ = f.Close()
+		return nil, fmt.Errorf("select address %#x: %v", addr, errno)
+	}
+	return f, nil
+}
+
+// oledDriver renders two status lines into a framebuffer and ships it
+// whole; at 128x32 that is 512 bytes, cheap enough to redraw per round.
+type oledDriver struct {
+	bus  io.WriteCloser
+	fb   [oledWidth * oledPages]byte
+	last [2]string
+}
+
+func newOLEDDriver(dev string, addr int) (*oledDriver, error) {
+	bus, err := openI2C(dev, addr)
+	if err != nil {
+		return nil, fmt.Errorf("oled %s: %w", dev, err)
+	}
+	d := &oledDriver{bus: bus}
+	if err := d.init(); err != nil {
+		_ = bus.Close()
+		return nil, fmt.Errorf("oled %s: %w", dev, err)
+	}
+	return d, nil
+}
+
+// init is the SSD1306 power-up sequence for a 128x32 panel in horizontal
+// addressing mode.
+func (d *oledDriver) init() error {
+	return d.commands(
+		0xae,       // display off
+		0xd5, 0x80, // clock divide
+		0xa8, oledHeight-1, // multiplex
+		0xd3, 0x00, // no display offset
+		0x40,       // start line 0
+		0x8d, 0x14, // charge pump on
+		0x20, 0x00, // horizontal addressing
+		0xa1, 0xc8, // flip both axes: pins at the top of most mounts
+		0xda, 0x02, // COM pins for 128x32
+		0x81, 0x8f, // contrast
+		0xd9, 0xf1, // precharge
+		0xdb, 0x40, // VCOM deselect
+		0xa4, // resume from RAM
+		0xa6, // normal (not inverted)
+		0xaf, // display on
+	)
+}
+
+func (d *oledDriver) apply(s Status) error {
+	lines := statusLines(s)
+	if lines == d.last {
+		return nil
+	}
+	for i := range d.fb {
+		d.fb[i] = 0
+	}
+	d.drawText(0, lines[0])
+	d.drawText(2, lines[1])
+	if err := d.flush(); err != nil {
+		return err
+	}
+	d.last = lines
+	return nil
+}
+
+func (d *oledDriver) close() {
+	_ = d.commands(0xae)
+	_ = d.bus.Close()
+}
+
+// statusLines summarises a Status for a 21-character display. Text is
+// upper-cased because the built-in font only carries the glyphs the
+// status line needs.
+func statusLines(s Status) [2]string {
+	head := "STARFAIL OK"
+	switch {
+	case s.Active == "" || s.Healthy == 0:
+		head = "STARFAIL: NO LINK"
+	case s.Failover:
+		head = "STARFAIL: FAILOVER"
+	}
+	detail := "NO ACTIVE MEMBER"
+	if s.Active != "" {
+		detail = fmt.Sprintf("%s %.0f %d/%d", strings.ToUpper(s.Active), s.Score, s.Healthy, s.Total)
+	}
+	return [2]string{head, detail}
+}
+
+// drawText renders one line of 5x7 glyphs onto the given page pair,
+// truncating at the right edge.
+func (d *oledDriver) drawText(page int, text string) {
+	x := 0
+	for _, r := range text {
+		glyph, ok := font5x7[r]
+		if !ok {
+			glyph = font5x7['?']
+		}
+		for _, col := range glyph {
+			if x >= oledWidth {
+				return
+			}
+			d.fb[page*oledWidth+x] = col
+			x++
+		}
+		if x >= oledWidth {
+			return
+		}
+		x++ // one blank column between glyphs
+	}
+}
+
+// flush resets the RAM window and writes the whole framebuffer.
+func (d *oledDriver) flush() error {
+	if err := d.commands(
+		0x21, 0x00, oledWidth-1, // column range
+		0x22, 0x00, oledPages-1, // page range
+	); err != nil {
+		return err
+	}
+	// Control byte 0x40: everything that follows is display data.
+	buf := make([]byte, 1, 1+len(d.fb))
+	buf[0] = 0x40
+	buf = append(buf, d.fb[:]...)
+	_, err := d.bus.Write(buf)
+	return err
+}
+
+// commands sends a command sequence (control byte 0x00).
+func (d *oledDriver) commands(cmds ...byte) error {
+	buf := make([]byte, 1, 1+len(cmds))
+	buf[0] = 0x00
+	buf = append(buf, cmds...)
+	_, err := d.bus.Write(buf)
+	return err
+}
+
+// font5x7 carries the glyphs a status line can contain: upper-case
+// letters, digits and a little punctuation, column-major with the LSB at
+// the top row.
+var font5x7 = map[rune][5]byte{
+	' ': {0x00, 0x00, 0x00, 0x00, 0x00},
+	'%': {0x23, 0x13, 0x08, 0x64, 0x62},
+	'-': {0x08, 0x08, 0x08, 0x08, 0x08},
+	'.': {0x00, 0x60, 0x60, 0x00, 0x00},
+	'/': {0x20, 0x10, 0x08, 0x04, 0x02},
+	':': {0x00, 0x36, 0x36, 0x00, 0x00},
+	'?': {0x02, 0x01, 0x51, 0x09, 0x06},
+	'_': {0x40, 0x40, 0x40, 0x40, 0x40},
+	'0': {0x3e, 0x51, 0x49, 0x45, 0x3e},
+	'1': {0x00, 0x42, 0x7f, 0x40, 0x00},
+	'2': {0x42, 0x61, 0x51, 0x49, 0x46},
+	'3': {0x21, 0x41, 0x45, 0x4b, 0x31},
+	'4': {0x18, 0x14, 0x12, 0x7f, 0x10},
+	'5': {0x27, 0x45, 0x45, 0x45, 0x39},
+	'6': {0x3c, 0x4a, 0x49, 0x49, 0x30},
+	'7': {0x01, 0x71, 0x09, 0x05, 0x03},
+	'8': {0x36, 0x49, 0x49, 0x49, 0x36},
+	'9': {0x06, 0x49, 0x49, 0x29, 0x1e},
+	'A': {0x7e, 0x11, 0x11, 0x11, 0x7e},
+	'B': {0x7f, 0x49, 0x49, 0x49, 0x36},
+	'C': {0x3e, 0x41, 0x41, 0x41, 0x22},
+	'D': {0x7f, 0x41, 0x41, 0x22, 0x1c},
+	'E': {0x7f, 0x49, 0x49, 0x49, 0x41},
+	'F': {0x7f, 0x09, 0x09, 0x09, 0x01},
+	'G': {0x3e, 0x41, 0x49, 0x49, 0x7a},
+	'H': {0x7f, 0x08, 0x08, 0x08, 0x7f},
+	'I': {0x00, 0x41, 0x7f, 0x41, 0x00},
+	'J': {0x20, 0x40, 0x41, 0x3f, 0x01},
+	'K': {0x7f, 0x08, 0x14, 0x22, 0x41},
+	'L': {0x7f, 0x40, 0x40, 0x40, 0x40},
+	'M': {0x7f, 0x02, 0x0c, 0x02, 0x7f},
+	'N': {0x7f, 0x04, 0x08, 0x10, 0x7f},
+	'O': {0x3e, 0x41, 0x41, 0x41, 0x3e},
+	'P': {0x7f, 0x09, 0x09, 0x09, 0x06},
+	'Q': {0x3e, 0x41, 0x51, 0x21, 0x5e},
+	'R': {0x7f, 0x09, 0x19, 0x29, 0x46},
+	'S': {0x46, 0x49, 0x49, 0x49, 0x31},
+	'T': {0x01, 0x01, 0x7f, 0x01, 0x01},
+	'U': {0x3f, 0x40, 0x40, 0x40, 0x3f},
+	'V': {0x1f, 0x20, 0x40, 0x20, 0x1f},
+	'W': {0x3f, 0x40, 0x38, 0x40, 0x3f},
+	'X': {0x63, 0x14, 0x08, 0x14, 0x63},
+	'Y': {0x07, 0x08, 0x70, 0x08, 0x07},
+	'Z': {0x61, 0x51, 0x49, 0x45, 0x43},
+}
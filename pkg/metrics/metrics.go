@@ -0,0 +1,223 @@
+// Package metrics defines the common sample type shared by all collectors.
+//
+// A Metrics value is one point-in-time observation of a WAN member. Fields
+// that a collector cannot provide are left nil so the scoring engine can
+// distinguish "not measured" from "measured as zero".
+package metrics
+
+import (
+	"fmt"
+	"time"
+)
+
+// Data sources a sample can originate from. Scoring treats SourceMock as
+// untrustworthy by default; everything downstream (ubus, Prometheus,
+// telemetry JSON) carries the source so mock data can never masquerade as
+// a real measurement.
+const (
+	SourceGRPC      = "grpc"      // dish gRPC API
+	SourceHTTP      = "http"      // HTTP scrape fallback
+	SourceProbe     = "probe"     // active ping probing
+	SourceMock      = "mock"      // fabricated fallback values
+	SourceCache     = "cache"     // replayed from a recent real sample
+	SourceSimulated = "simulated" // development simulator
+)
+
+// Unit types for measured quantities. They are plain float64 underneath
+// and marshal as numbers, so the JSON schema is unchanged for existing
+// consumers; within Go they keep a latency from being assigned to a
+// signal-strength field without an explicit conversion.
+type (
+	// Milliseconds holds latency and jitter values.
+	Milliseconds float64
+	// Percent holds ratios on a 0-100 scale (loss, obstruction).
+	Percent float64
+	// DB holds relative signal quality in decibels (SNR, RSRQ, SINR).
+	DB float64
+	// DBm holds absolute signal power (RSRP, RSSI).
+	DBm float64
+	// Mbps holds throughput in megabits per second.
+	Mbps float64
+)
+
+// Ptr returns a pointer to v; convenience for building samples.
+func (v Milliseconds) Ptr() *Milliseconds { return &v }
+
+// Ptr returns a pointer to v.
+func (v Percent) Ptr() *Percent { return &v }
+
+// Ptr returns a pointer to v.
+func (v DB) Ptr() *DB { return &v }
+
+// Ptr returns a pointer to v.
+func (v DBm) Ptr() *DBm { return &v }
+
+// Ptr returns a pointer to v.
+func (v Mbps) Ptr() *Mbps { return &v }
+
+// Metrics is a single sample for one member. Every measured field carries
+// its unit in its type; remaining *float64 fields state the unit in the
+// field name (degrees, km/h).
+type Metrics struct {
+	Timestamp time.Time `json:"timestamp"`
+
+	// DataSource records where this sample came from (Source* constants).
+	DataSource string `json:"data_source,omitempty"`
+
+	// Generic reachability metrics, populated by the probe layer.
+	LatencyMS   *Milliseconds `json:"latency_ms,omitempty"`
+	LossPercent *Percent      `json:"loss_percent,omitempty"`
+	JitterMS    *Milliseconds `json:"jitter_ms,omitempty"`
+
+	// PassiveRTTMS is the median kernel-tracked TCP RTT across existing
+	// connections, attached while probe volume is metered down.
+	PassiveRTTMS *Milliseconds `json:"passive_rtt_ms,omitempty"`
+
+	// Starlink-specific metrics from the dish API.
+	ObstructionPct     *Percent      `json:"obstruction_pct,omitempty"`
+	ObstructionValid   bool          `json:"obstruction_valid,omitempty"`
+	SNR                *DB           `json:"snr,omitempty"`
+	DownlinkMbps       *Mbps         `json:"downlink_mbps,omitempty"`
+	UplinkMbps         *Mbps         `json:"uplink_mbps,omitempty"`
+	PopPingLatencyMS   *Milliseconds `json:"pop_ping_latency_ms,omitempty"`
+	SystemAlertsActive bool          `json:"system_alerts_active,omitempty"`
+	// ActiveAlerts are the decoded dish alert names behind
+	// SystemAlertsActive (e.g. "thermal_shutdown", "motors_stuck").
+	ActiveAlerts []string `json:"active_alerts,omitempty"`
+	// Stowed is set while the dish is parked flat (stow requested); a
+	// stowed member is administratively out of rotation, not failed.
+	Stowed  bool   `json:"stowed,omitempty"`
+	UptimeS *int64 `json:"uptime_s,omitempty"`
+	// OutageProbPct is the history-derived probability (0-100) of a
+	// micro-outage within the next ten minutes; see the starlink
+	// collector's outage forecast.
+	OutageProbPct *Percent `json:"outage_prob_pct,omitempty"`
+
+	// Cellular-specific metrics from gsmctl.
+	RSRP     *DBm   `json:"rsrp,omitempty"`
+	RSRQ     *DB    `json:"rsrq,omitempty"`
+	SINR     *DB    `json:"sinr,omitempty"`
+	RSSI     *DBm   `json:"rssi,omitempty"`
+	Network  string `json:"network,omitempty"` // e.g. "LTE", "5G-NSA"
+	Operator string `json:"operator,omitempty"`
+	Roaming  bool   `json:"roaming,omitempty"`
+
+	// GPS fix attached to the sample when available.
+	Latitude  *float64 `json:"latitude,omitempty"`  // decimal degrees
+	Longitude *float64 `json:"longitude,omitempty"` // decimal degrees
+	SpeedKmh  *float64 `json:"speed_kmh,omitempty"`
+
+	// Per-target probe results behind the aggregate latency/loss values,
+	// retained for diagnostics.
+	ProbeResults []ProbeResult `json:"probe_results,omitempty"`
+
+	// Collector bookkeeping.
+	CollectDurationMS int64 `json:"collect_duration_ms,omitempty"`
+	// HeuristicFloats marks samples whose values were recovered by
+	// heuristic byte scanning rather than known protobuf fields.
+	HeuristicFloats bool `json:"heuristic_floats,omitempty"`
+}
+
+// Plausibility bounds enforced on ingest. These are deliberately loose —
+// they reject values a real link cannot produce (negative latency, 300%
+// loss, a -300 dBm RSRP from a parser bug), not merely bad ones.
+const (
+	maxLatencyMS = 60_000
+	maxJitterMS  = 10_000
+	maxMbps      = 10_000
+	minDBm       = -160
+	maxDBm       = 0
+	minDB        = -50
+	maxDB        = 50
+)
+
+// Validate reports whether every populated field is physically plausible.
+// The engine calls it on ingest and discards samples that fail, so one
+// buggy collector or garbled CLI output cannot poison scoring history.
+func (m *Metrics) Validate() error {
+	if err := checkRange("latency_ms", (*float64)(m.LatencyMS), 0, maxLatencyMS); err != nil {
+		return err
+	}
+	if err := checkRange("jitter_ms", (*float64)(m.JitterMS), 0, maxJitterMS); err != nil {
+		return err
+	}
+	if err := checkRange("passive_rtt_ms", (*float64)(m.PassiveRTTMS), 0, maxLatencyMS); err != nil {
+		return err
+	}
+	if err := checkRange("pop_ping_latency_ms", (*float64)(m.PopPingLatencyMS), 0, maxLatencyMS); err != nil {
+		return err
+	}
+	if err := checkRange("loss_percent", (*float64)(m.LossPercent), 0, 100); err != nil {
+		return err
+	}
+	if err := checkRange("outage_prob_pct", (*float64)(m.OutageProbPct), 0, 100); err != nil {
+		return err
+	}
+	if err := checkRange("obstruction_pct", (*float64)(m.ObstructionPct), 0, 100); err != nil {
+		return err
+	}
+	if err := checkRange("downlink_mbps", (*float64)(m.DownlinkMbps), 0, maxMbps); err != nil {
+		return err
+	}
+	if err := checkRange("uplink_mbps", (*float64)(m.UplinkMbps), 0, maxMbps); err != nil {
+		return err
+	}
+	if err := checkRange("snr", (*float64)(m.SNR), minDB, maxDB); err != nil {
+		return err
+	}
+	if err := checkRange("rsrq", (*float64)(m.RSRQ), minDB, maxDB); err != nil {
+		return err
+	}
+	if err := checkRange("sinr", (*float64)(m.SINR), minDB, maxDB); err != nil {
+		return err
+	}
+	if err := checkRange("rsrp", (*float64)(m.RSRP), minDBm, maxDBm); err != nil {
+		return err
+	}
+	if err := checkRange("rssi", (*float64)(m.RSSI), minDBm, maxDBm); err != nil {
+		return err
+	}
+	if err := checkRange("latitude", m.Latitude, -90, 90); err != nil {
+		return err
+	}
+	if err := checkRange("longitude", m.Longitude, -180, 180); err != nil {
+		return err
+	}
+	return nil
+}
+
+// checkRange accepts nil (not measured) and values in [lo, hi]; NaN is
+// rejected by both comparisons failing.
+func checkRange(name string, v *float64, lo, hi float64) error {
+	if v == nil {
+		return nil
+	}
+	if !(*v >= lo && *v <= hi) {
+		return fmt.Errorf("%s %v outside [%v, %v]", name, *v, lo, hi)
+	}
+	return nil
+}
+
+// ProbeResult mirrors probe.Result without importing it, keeping this
+// package dependency-free for external consumers of the JSON schema.
+type ProbeResult struct {
+	Target      string  `json:"target"`
+	Sent        int     `json:"sent"`
+	Received    int     `json:"received"`
+	LatencyMS   float64 `json:"latency_ms"`
+	JitterMS    float64 `json:"jitter_ms"`
+	LossPercent float64 `json:"loss_percent"`
+
+	// One-way estimates from reflector rounds; zero for plain echo and
+	// ping probes.
+	UplinkMS      float64 `json:"uplink_ms,omitempty"`
+	DownlinkMS    float64 `json:"downlink_ms,omitempty"`
+	ClockOffsetMS float64 `json:"clock_offset_ms,omitempty"`
+}
+
+// Float returns a pointer to v; convenience for the remaining plain
+// float64 fields (coordinates, speed).
+func Float(v float64) *float64 { return &v }
+
+// Int64 returns a pointer to v.
+func Int64(v int64) *int64 { return &v }
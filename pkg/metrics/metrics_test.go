@@ -0,0 +1,84 @@
+package metrics
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		name string
+		m    Metrics
+		ok   bool
+	}{
+		{"empty", Metrics{}, true},
+		{"typical probe", Metrics{
+			LatencyMS:   Milliseconds(47).Ptr(),
+			LossPercent: Percent(0.5).Ptr(),
+			JitterMS:    Milliseconds(3).Ptr(),
+		}, true},
+		{"typical cellular", Metrics{
+			RSRP: DBm(-98).Ptr(),
+			RSRQ: DB(-11).Ptr(),
+			SINR: DB(14).Ptr(),
+			RSSI: DBm(-65).Ptr(),
+		}, true},
+		{"negative latency", Metrics{LatencyMS: Milliseconds(-1).Ptr()}, false},
+		{"absurd latency", Metrics{LatencyMS: Milliseconds(1e7).Ptr()}, false},
+		{"loss over 100", Metrics{LossPercent: Percent(300).Ptr()}, false},
+		{"NaN loss", Metrics{LossPercent: Percent(math.NaN()).Ptr()}, false},
+		{"positive rsrp", Metrics{RSRP: DBm(30).Ptr()}, false},
+		{"impossible rsrp", Metrics{RSRP: DBm(-300).Ptr()}, false},
+		{"obstruction over 100", Metrics{ObstructionPct: Percent(250).Ptr()}, false},
+		{"latitude out of range", Metrics{Latitude: Float(91)}, false},
+	}
+	for _, tc := range cases {
+		err := tc.m.Validate()
+		if tc.ok && err != nil {
+			t.Errorf("%s: unexpected error %v", tc.name, err)
+		}
+		if !tc.ok && err == nil {
+			t.Errorf("%s: expected a validation error", tc.name)
+		}
+	}
+}
+
+// TestJSONCompat pins the wire format: typed unit fields must marshal as
+// plain numbers under the same keys older consumers already parse.
+func TestJSONCompat(t *testing.T) {
+	m := Metrics{
+		Timestamp:   time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		DataSource:  SourceProbe,
+		LatencyMS:   Milliseconds(47.5).Ptr(),
+		LossPercent: Percent(1).Ptr(),
+		RSRP:        DBm(-98).Ptr(),
+	}
+	b, err := json.Marshal(&m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var raw map[string]any
+	if err := json.Unmarshal(b, &raw); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := raw["latency_ms"].(float64); !ok || v != 47.5 {
+		t.Errorf("latency_ms = %v (%T), want plain number 47.5", raw["latency_ms"], raw["latency_ms"])
+	}
+	if v, ok := raw["rsrp"].(float64); !ok || v != -98 {
+		t.Errorf("rsrp = %v, want plain number -98", raw["rsrp"])
+	}
+
+	// Round trip from legacy-style JSON.
+	var back Metrics
+	if err := json.Unmarshal([]byte(`{"latency_ms":12,"loss_percent":0.5,"rsrp":-101}`), &back); err != nil {
+		t.Fatal(err)
+	}
+	if back.LatencyMS == nil || *back.LatencyMS != 12 {
+		t.Errorf("round-trip latency = %v, want 12", back.LatencyMS)
+	}
+	if back.RSRP == nil || *back.RSRP != -101 {
+		t.Errorf("round-trip rsrp = %v, want -101", back.RSRP)
+	}
+}
@@ -0,0 +1,148 @@
+package monitoring
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/subtle"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"log"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/secrets"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/uci"
+)
+
+// The listener binds loopback-only by default; operators who point
+// starfail.monitoring.bind at a LAN or VPN address can turn on TLS (with a
+// locally generated certificate) and token or basic auth so the metrics
+// and health endpoints are safe to expose.
+
+// defaultBind keeps the endpoints off the network unless asked for.
+const defaultBind = "127.0.0.1:9101"
+
+// certDir holds the generated certificate pair; a variable for tests.
+var certDir = "/etc/starfail"
+
+// listenerConfig is the starfail.monitoring section.
+type listenerConfig struct {
+	bind     string
+	useTLS   bool
+	token    string // bearer/X-Auth-Token value
+	user     string // basic auth, used together with password
+	password string
+}
+
+func listenerFromUCI() listenerConfig {
+	lc := listenerConfig{
+		bind:   defaultBind,
+		useTLS: uci.GetBool("starfail.monitoring.tls", false),
+	}
+	if v, ok := uci.Get("starfail.monitoring.bind"); ok && v != "" {
+		lc.bind = v
+	}
+	lc.token, _ = secrets.Get("starfail.monitoring.token")
+	lc.user, _ = uci.Get("starfail.monitoring.user")
+	lc.password, _ = secrets.Get("starfail.monitoring.password")
+	return lc
+}
+
+// withAuth enforces the configured credentials; with none configured the
+// handler passes through (the default bind is loopback-only).
+func (lc listenerConfig) withAuth(next http.Handler) http.Handler {
+	if lc.token == "" && lc.user == "" {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if lc.token != "" {
+			got := r.Header.Get("X-Auth-Token")
+			if got == "" {
+				const prefix = "Bearer "
+				if h := r.Header.Get("Authorization"); len(h) > len(prefix) && h[:len(prefix)] == prefix {
+					got = h[len(prefix):]
+				}
+			}
+			if subtle.ConstantTimeCompare([]byte(got), []byte(lc.token)) == 1 {
+				next.ServeHTTP(w, r)
+				return
+			}
+		}
+		if lc.user != "" {
+			u, p, ok := r.BasicAuth()
+			if ok && subtle.ConstantTimeCompare([]byte(u), []byte(lc.user)) == 1 &&
+				subtle.ConstantTimeCompare([]byte(p), []byte(lc.password)) == 1 {
+				next.ServeHTTP(w, r)
+				return
+			}
+			w.Header().Set("WWW-Authenticate", `Basic realm="starfail"`)
+		}
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+	})
+}
+
+// serve runs the listener, generating a certificate on first TLS use.
+func (lc listenerConfig) serve(hs *http.Server) error {
+	if !lc.useTLS {
+		return hs.ListenAndServe()
+	}
+	certFile := filepath.Join(certDir, "monitoring.crt")
+	keyFile := filepath.Join(certDir, "monitoring.key")
+	if err := ensureCert(certFile, keyFile); err != nil {
+		return err
+	}
+	return hs.ListenAndServeTLS(certFile, keyFile)
+}
+
+// ensureCert generates a self-signed certificate pair if none exists yet.
+// Routers have no CA-issued names; a pinned self-signed cert still gets
+// operators encryption on LAN/VPN exposure.
+func ensureCert(certFile, keyFile string) error {
+	if _, err := os.Stat(certFile); err == nil {
+		if _, err := os.Stat(keyFile); err == nil {
+			return nil
+		}
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return err
+	}
+	tmpl := x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: "starfail-monitoring"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().AddDate(10, 0, 0),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &tmpl, &tmpl, &key.PublicKey, key)
+	if err != nil {
+		return err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(certFile), 0o755); err != nil {
+		return err
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	if err := os.WriteFile(certFile, certPEM, 0o644); err != nil {
+		return err
+	}
+	if err := os.WriteFile(keyFile, keyPEM, 0o600); err != nil {
+		return err
+	}
+	log.Printf("monitoring: generated self-signed certificate at %s", certFile)
+	return nil
+}
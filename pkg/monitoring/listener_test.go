@@ -0,0 +1,94 @@
+package monitoring
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func okHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	})
+}
+
+func TestWithAuthToken(t *testing.T) {
+	lc := listenerConfig{token: "sekret-token"}
+	h := lc.withAuth(okHandler())
+
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("no credentials: status = %d, want 401", rec.Code)
+	}
+
+	req = httptest.NewRequest("GET", "/metrics", nil)
+	req.Header.Set("Authorization", "Bearer sekret-token")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("bearer token: status = %d, want 200", rec.Code)
+	}
+
+	req = httptest.NewRequest("GET", "/metrics", nil)
+	req.Header.Set("X-Auth-Token", "sekret-token")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("header token: status = %d, want 200", rec.Code)
+	}
+}
+
+func TestWithAuthBasic(t *testing.T) {
+	lc := listenerConfig{user: "admin", password: "hunter2"}
+	h := lc.withAuth(okHandler())
+
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	req.SetBasicAuth("admin", "wrong")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("wrong password: status = %d, want 401", rec.Code)
+	}
+
+	req = httptest.NewRequest("GET", "/metrics", nil)
+	req.SetBasicAuth("admin", "hunter2")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("valid credentials: status = %d, want 200", rec.Code)
+	}
+}
+
+func TestWithAuthUnconfiguredPassesThrough(t *testing.T) {
+	h := listenerConfig{}.withAuth(okHandler())
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d, want 200", rec.Code)
+	}
+}
+
+func TestEnsureCert(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "monitoring.crt")
+	keyFile := filepath.Join(dir, "monitoring.key")
+	if err := ensureCert(certFile, keyFile); err != nil {
+		t.Fatalf("ensureCert: %v", err)
+	}
+	first, err := os.ReadFile(certFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A second run must keep the existing pair (clients may pin it).
+	if err := ensureCert(certFile, keyFile); err != nil {
+		t.Fatalf("ensureCert (second): %v", err)
+	}
+	second, _ := os.ReadFile(certFile)
+	if string(first) != string(second) {
+		t.Error("certificate regenerated on second run")
+	}
+}
@@ -0,0 +1,123 @@
+package monitoring
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// The /map page is deliberately self-contained: no Leaflet, no tile server,
+// no internet. Debugging a mobile install usually happens on the router's
+// LAN with the WAN down, so the track is drawn on a plain canvas with an
+// equirectangular projection instead of fetched map tiles.
+
+// trackPoint is one GPS-bearing sample in /map.json.
+type trackPoint struct {
+	Timestamp time.Time `json:"timestamp"`
+	Member    string    `json:"member"`
+	Latitude  float64   `json:"latitude"`
+	Longitude float64   `json:"longitude"`
+	Score     float64   `json:"score"`
+	Active    bool      `json:"active"`
+}
+
+// handleMapData serves the recent GPS track as JSON, oldest first.
+func (s *Server) handleMapData(w http.ResponseWriter, _ *http.Request) {
+	if s.Store == nil {
+		http.Error(w, "no telemetry store", http.StatusNotFound)
+		return
+	}
+	active := s.engine.Active()
+	var points []trackPoint
+	for _, m := range s.engine.Members() {
+		for _, sm := range s.Store.Samples(m.Name, 0) {
+			if sm.Metrics == nil || sm.Metrics.Latitude == nil || sm.Metrics.Longitude == nil {
+				continue
+			}
+			points = append(points, trackPoint{
+				Timestamp: sm.Metrics.Timestamp,
+				Member:    sm.Member,
+				Latitude:  *sm.Metrics.Latitude,
+				Longitude: *sm.Metrics.Longitude,
+				Score:     sm.Score,
+				Active:    sm.Member == active,
+			})
+		}
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i].Timestamp.Before(points[j].Timestamp) })
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{"points": points})
+}
+
+// handleMap serves the self-contained track page.
+func (s *Server) handleMap(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(mapHTML))
+}
+
+const mapHTML = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>starfail GPS track</title>
+<style>
+body { font-family: sans-serif; background: #111; color: #ddd; margin: 1em; }
+canvas { background: #1a1a2a; border: 1px solid #444; }
+.legend span { display: inline-block; margin-right: 1.2em; }
+.dot { display: inline-block; width: 10px; height: 10px; border-radius: 5px; margin-right: 4px; }
+</style>
+</head>
+<body>
+<h2>GPS track (recent samples)</h2>
+<div class="legend" id="legend"></div>
+<canvas id="map" width="900" height="600"></canvas>
+<p>Point brightness tracks the member's quality score; the heavy line marks
+samples taken while that member was active. Rendered locally &mdash; no
+internet needed.</p>
+<script>
+const palette = ["#4fc3f7","#81c784","#ffb74d","#e57373","#ba68c8","#fff176"];
+fetch("map.json").then(r => r.json()).then(d => draw(d.points || []));
+function draw(pts) {
+  const c = document.getElementById("map"), g = c.getContext("2d");
+  if (!pts.length) {
+    g.fillStyle = "#888";
+    g.fillText("No GPS-bearing samples yet.", 20, 30);
+    return;
+  }
+  let minLat = 90, maxLat = -90, minLon = 180, maxLon = -180;
+  for (const p of pts) {
+    minLat = Math.min(minLat, p.latitude); maxLat = Math.max(maxLat, p.latitude);
+    minLon = Math.min(minLon, p.longitude); maxLon = Math.max(maxLon, p.longitude);
+  }
+  const pad = 30;
+  const spanLat = Math.max(maxLat - minLat, 1e-4), spanLon = Math.max(maxLon - minLon, 1e-4);
+  const sx = (c.width - 2*pad) / spanLon, sy = (c.height - 2*pad) / spanLat;
+  const X = p => pad + (p.longitude - minLon) * sx;
+  const Y = p => c.height - pad - (p.latitude - minLat) * sy;
+  const members = [...new Set(pts.map(p => p.member))];
+  const color = m => palette[members.indexOf(m) % palette.length];
+  document.getElementById("legend").innerHTML = members.map(m =>
+    '<span><i class="dot" style="background:' + color(m) + '"></i>' + m + '</span>').join("");
+  // faint graticule so motion is readable without tiles
+  g.strokeStyle = "#2a2a3a";
+  for (let i = 1; i < 10; i++) {
+    g.beginPath(); g.moveTo(i*c.width/10, 0); g.lineTo(i*c.width/10, c.height); g.stroke();
+    g.beginPath(); g.moveTo(0, i*c.height/10); g.lineTo(c.width, i*c.height/10); g.stroke();
+  }
+  for (let i = 1; i < pts.length; i++) {
+    const a = pts[i-1], b = pts[i];
+    g.strokeStyle = color(b.member);
+    g.lineWidth = b.active ? 3 : 1;
+    g.globalAlpha = 0.3 + 0.7 * Math.min(Math.max(b.score, 0), 100) / 100;
+    g.beginPath(); g.moveTo(X(a), Y(a)); g.lineTo(X(b), Y(b)); g.stroke();
+  }
+  g.globalAlpha = 1;
+  const last = pts[pts.length-1];
+  g.fillStyle = "#fff";
+  g.beginPath(); g.arc(X(last), Y(last), 4, 0, 2*Math.PI); g.fill();
+}
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,31 @@
+package monitoring
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMapPageIsSelfContained(t *testing.T) {
+	s := &Server{}
+	rec := httptest.NewRecorder()
+	s.handleMap(rec, httptest.NewRequest("GET", "/map", nil))
+	body := rec.Body.String()
+	if !strings.Contains(body, "<canvas") {
+		t.Error("map page has no canvas")
+	}
+	for _, external := range []string{"http://", "https://", "unpkg", "tile.openstreetmap"} {
+		if strings.Contains(body, external) {
+			t.Errorf("map page references external resource %q; must work offline", external)
+		}
+	}
+}
+
+func TestMapDataWithoutStore(t *testing.T) {
+	s := &Server{}
+	rec := httptest.NewRecorder()
+	s.handleMapData(rec, httptest.NewRequest("GET", "/map.json", nil))
+	if rec.Code != 404 {
+		t.Errorf("status = %d, want 404 without a store", rec.Code)
+	}
+}
@@ -0,0 +1,212 @@
+// Package monitoring exposes the daemon's state over HTTP: a Prometheus
+// text-format /metrics endpoint and a /healthz liveness endpoint.
+package monitoring
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"time"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/collector/starlink"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/engine"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/flowstats"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/sla"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/telemetry"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/ubus"
+)
+
+// Server serves metrics and health for one engine.
+type Server struct {
+	engine *engine.Engine
+
+	// Starlink, when set, contributes dish API cache counters.
+	Starlink *starlink.Collector
+
+	// SLA, when set, contributes availability and burn-rate gauges.
+	SLA *sla.Tracker
+
+	// Ubus, when set, contributes bus connectivity gauges.
+	Ubus *ubus.Monitor
+
+	// Store, when set, enables the /heatmap JSON endpoint.
+	Store *telemetry.Store
+
+	// Flow, when set, contributes per-device eBPF flow counters.
+	Flow *flowstats.Tracker
+
+	// srv is the running listener, kept so Stop can shut it down.
+	srv *http.Server
+}
+
+// NewServer returns a monitoring Server for e.
+func NewServer(e *engine.Engine) *Server {
+	return &Server{engine: e}
+}
+
+// Start listens in the background on the configured bind address
+// (starfail.monitoring.bind, loopback by default), with optional TLS and
+// auth; see listener.go.
+func (s *Server) Start() {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", s.handleMetrics)
+	mux.HandleFunc("/healthz", s.handleHealthz)
+	mux.HandleFunc("/heatmap", s.handleHeatmap)
+	mux.HandleFunc("/forecast", s.handleForecast)
+	mux.HandleFunc("/map", s.handleMap)
+	mux.HandleFunc("/map.json", s.handleMapData)
+	lc := listenerFromUCI()
+	hs := &http.Server{Addr: lc.bind, Handler: lc.withAuth(mux)}
+	s.srv = hs
+	go func() {
+		if err := lc.serve(hs); err != nil && err != http.ErrServerClosed {
+			log.Printf("monitoring: listener: %v", err)
+		}
+	}()
+}
+
+// Stop shuts the listener down; Start may be called again afterwards
+// (runtime feature toggling).
+func (s *Server) Stop() {
+	if s.srv == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	_ = s.srv.Shutdown(ctx)
+	s.srv = nil
+}
+
+func (s *Server) handleMetrics(w http.ResponseWriter, _ *http.Request) {
+	var b strings.Builder
+	b.WriteString("# HELP starfail_member_score Blended quality score per member (0-100).\n")
+	b.WriteString("# TYPE starfail_member_score gauge\n")
+	for _, m := range s.engine.Members() {
+		fmt.Fprintf(&b, "starfail_member_score{member=%q,class=%q} %.2f\n", m.Name, m.Class, m.Final)
+	}
+	b.WriteString("# HELP starfail_member_sample_source One-hot data source of the latest sample.\n")
+	b.WriteString("# TYPE starfail_member_sample_source gauge\n")
+	for _, m := range s.engine.Members() {
+		if m.LastSample == nil || m.LastSample.DataSource == "" {
+			continue
+		}
+		fmt.Fprintf(&b, "starfail_member_sample_source{member=%q,source=%q} 1\n", m.Name, m.LastSample.DataSource)
+	}
+	b.WriteString("# HELP starfail_member_active 1 for the active member.\n")
+	b.WriteString("# TYPE starfail_member_active gauge\n")
+	for _, m := range s.engine.Members() {
+		active := 0
+		if m.Name == s.engine.Active() {
+			active = 1
+		}
+		fmt.Fprintf(&b, "starfail_member_active{member=%q} %d\n", m.Name, active)
+	}
+	if s.SLA != nil {
+		b.WriteString("# HELP starfail_sla_availability_percent Month-to-date availability per tracked name.\n")
+		b.WriteString("# TYPE starfail_sla_availability_percent gauge\n")
+		compliance := s.SLA.Compliance(time.Now())
+		for name, st := range compliance {
+			fmt.Fprintf(&b, "starfail_sla_availability_percent{name=%q} %.4f\n", name, st.Availability)
+		}
+		b.WriteString("# HELP starfail_sla_burn_rate Error budget burn rate (>1 is too fast).\n")
+		b.WriteString("# TYPE starfail_sla_burn_rate gauge\n")
+		for name, st := range compliance {
+			if st.Objective > 0 {
+				fmt.Fprintf(&b, "starfail_sla_burn_rate{name=%q} %.4f\n", name, st.BurnRate)
+			}
+		}
+	}
+	if s.Ubus != nil {
+		st := s.Ubus.Status()
+		connected := 0
+		if st.Connected {
+			connected = 1
+		}
+		b.WriteString("# HELP starfail_ubus_connected 1 when ubusd is reachable.\n")
+		b.WriteString("# TYPE starfail_ubus_connected gauge\n")
+		fmt.Fprintf(&b, "starfail_ubus_connected %d\n", connected)
+		b.WriteString("# HELP starfail_ubus_reconnects_total Times the bus came back after an outage.\n")
+		b.WriteString("# TYPE starfail_ubus_reconnects_total counter\n")
+		fmt.Fprintf(&b, "starfail_ubus_reconnects_total %d\n", st.Reconnects)
+	}
+	if s.Starlink != nil {
+		st := s.Starlink.Stats()
+		b.WriteString("# HELP starfail_starlink_cache_hits_total Dish status requests served from cache.\n")
+		b.WriteString("# TYPE starfail_starlink_cache_hits_total counter\n")
+		fmt.Fprintf(&b, "starfail_starlink_cache_hits_total %d\n", st.Hits)
+		b.WriteString("# HELP starfail_starlink_cache_misses_total Dish status requests that hit the API.\n")
+		b.WriteString("# TYPE starfail_starlink_cache_misses_total counter\n")
+		fmt.Fprintf(&b, "starfail_starlink_cache_misses_total %d\n", st.Misses)
+		ps := s.Starlink.PoolStats()
+		b.WriteString("# HELP starfail_starlink_dials_total Dish connections dialed.\n")
+		b.WriteString("# TYPE starfail_starlink_dials_total counter\n")
+		fmt.Fprintf(&b, "starfail_starlink_dials_total %d\n", ps.Dials)
+		b.WriteString("# HELP starfail_starlink_dial_failures_total Dish dial attempts that failed.\n")
+		b.WriteString("# TYPE starfail_starlink_dial_failures_total counter\n")
+		fmt.Fprintf(&b, "starfail_starlink_dial_failures_total %d\n", ps.DialFailures)
+		b.WriteString("# HELP starfail_starlink_conn_reuses_total Dish calls served over a pooled connection.\n")
+		b.WriteString("# TYPE starfail_starlink_conn_reuses_total counter\n")
+		fmt.Fprintf(&b, "starfail_starlink_conn_reuses_total %d\n", ps.Reuses)
+	}
+	if s.Flow != nil {
+		if stats := s.Flow.Stats(); len(stats) > 0 {
+			b.WriteString("# HELP starfail_flow_retransmits_total TCP retransmissions seen per WAN device (eBPF).\n")
+			b.WriteString("# TYPE starfail_flow_retransmits_total counter\n")
+			for dev, st := range stats {
+				fmt.Fprintf(&b, "starfail_flow_retransmits_total{device=%q} %d\n", dev, st.Retransmits)
+			}
+			b.WriteString("# HELP starfail_flow_rtt_ms Mean flow RTT per WAN device (eBPF).\n")
+			b.WriteString("# TYPE starfail_flow_rtt_ms gauge\n")
+			for dev, st := range stats {
+				if st.Flows > 0 {
+					fmt.Fprintf(&b, "starfail_flow_rtt_ms{device=%q} %.2f\n", dev, st.AvgRTTMS)
+				}
+			}
+		}
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	_, _ = w.Write([]byte(b.String()))
+}
+
+// handleHeatmap serves the per-member weekday x hour latency/loss grid.
+func (s *Server) handleHeatmap(w http.ResponseWriter, _ *http.Request) {
+	if s.Store == nil {
+		http.Error(w, "no telemetry store", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{"members": s.Store.Heatmap()})
+}
+
+// handleForecast serves the per-member quality forecast so LAN
+// applications can schedule transfers into predicted good windows;
+// ?minutes= selects the horizon (default 60).
+func (s *Server) handleForecast(w http.ResponseWriter, r *http.Request) {
+	minutes, _ := strconv.Atoi(r.URL.Query().Get("minutes"))
+	if minutes <= 0 {
+		minutes = 60
+	}
+	members := s.engine.Forecast(minutes)
+	if members == nil {
+		http.Error(w, "predictive engine disabled", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"horizon_min": minutes,
+		"members":     members,
+	})
+}
+
+func (s *Server) handleHealthz(w http.ResponseWriter, _ *http.Request) {
+	if s.engine.Active() == "" {
+		http.Error(w, "no active member", http.StatusServiceUnavailable)
+		return
+	}
+	_, _ = w.Write([]byte("ok\n"))
+}
@@ -0,0 +1,182 @@
+package mqtt
+
+// Compact wire encodings for fleet ingestion. The payload profiles in
+// profile.go exist for home-automation consumers and spread a round over
+// many small topics; a fleet backend pulling telemetry from hundreds of
+// routers over cellular wants the opposite — one snapshot message per
+// round, as small as possible. The gzip encoding compresses the JSON
+// snapshot; cbor encodes it directly in CBOR (RFC 8949), hand-rolled for
+// the handful of types the snapshot uses, in the same no-dependency
+// spirit as the MQTT client itself. Every snapshot carries a schema
+// version so the backend can evolve decoding without guessing.
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/identity"
+)
+
+// SchemaVersion identifies the snapshot layout; bump on breaking changes.
+const SchemaVersion = 1
+
+// snapshotTopic is the suffix under the configured prefix the encoded
+// snapshot publishes to.
+const snapshotTopic = "/snapshot"
+
+// snapshotPayload is the one-message-per-round form.
+func snapshotPayload(members []MemberState, now time.Time) map[string]any {
+	active := ""
+	ms := make([]any, 0, len(members))
+	for _, m := range members {
+		if m.Active {
+			active = m.Name
+		}
+		ms = append(ms, map[string]any{
+			"name":       m.Name,
+			"class":      m.Class,
+			"state":      m.State,
+			"score":      m.Score,
+			"active":     m.Active,
+			"latency_ms": m.LatencyMS,
+			"loss_pct":   m.LossPct,
+		})
+	}
+	return map[string]any{
+		"schema":  SchemaVersion,
+		"ts":      now.Unix(),
+		"device":  identity.ID(),
+		"name":    identity.Name(),
+		"active":  active,
+		"members": ms,
+	}
+}
+
+// EncodeSnapshot renders one round in the named encoding. Supported:
+// "gzip" (gzipped JSON) and "cbor".
+func EncodeSnapshot(encoding string, members []MemberState, now time.Time) ([]byte, error) {
+	snap := snapshotPayload(members, now)
+	switch encoding {
+	case "gzip":
+		raw, err := json.Marshal(snap)
+		if err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		if _, err := zw.Write(raw); err != nil {
+			return nil, err
+		}
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case "cbor":
+		var buf bytes.Buffer
+		if err := cborEncode(&buf, snap); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	default:
+		return nil, fmt.Errorf("unknown encoding %q", encoding)
+	}
+}
+
+// cborEncode writes v in CBOR. Only the types the snapshot produces are
+// supported; map keys are emitted sorted so payloads are deterministic.
+func cborEncode(buf *bytes.Buffer, v any) error {
+	switch x := v.(type) {
+	case nil:
+		buf.WriteByte(0xf6)
+	case bool:
+		if x {
+			buf.WriteByte(0xf5)
+		} else {
+			buf.WriteByte(0xf4)
+		}
+	case int:
+		cborInt(buf, int64(x))
+	case int64:
+		cborInt(buf, x)
+	case float64:
+		// Whole numbers fit the integer major types and save bytes —
+		// scores and latencies are often round values.
+		if x == math.Trunc(x) && math.Abs(x) < 1<<53 {
+			cborInt(buf, int64(x))
+			return nil
+		}
+		buf.WriteByte(0xfb)
+		bits := math.Float64bits(x)
+		for shift := 56; shift >= 0; shift -= 8 {
+			buf.WriteByte(byte(bits >> shift))
+		}
+	case string:
+		cborHead(buf, 3, uint64(len(x)))
+		buf.WriteString(x)
+	case []any:
+		cborHead(buf, 4, uint64(len(x)))
+		for _, e := range x {
+			if err := cborEncode(buf, e); err != nil {
+				return err
+			}
+		}
+	case map[string]any:
+		cborHead(buf, 5, uint64(len(x)))
+		keys := make([]string, 0, len(x))
+		for k := range x {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if err := cborEncode(buf, k); err != nil {
+				return err
+			}
+			if err := cborEncode(buf, x[k]); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("cbor: unsupported type %T", v)
+	}
+	return nil
+}
+
+// cborInt writes a major type 0/1 integer.
+func cborInt(buf *bytes.Buffer, v int64) {
+	if v >= 0 {
+		cborHead(buf, 0, uint64(v))
+	} else {
+		cborHead(buf, 1, uint64(-1-v))
+	}
+}
+
+// cborHead writes the major type and length/value header.
+func cborHead(buf *bytes.Buffer, major byte, n uint64) {
+	mt := major << 5
+	switch {
+	case n < 24:
+		buf.WriteByte(mt | byte(n))
+	case n < 1<<8:
+		buf.WriteByte(mt | 24)
+		buf.WriteByte(byte(n))
+	case n < 1<<16:
+		buf.WriteByte(mt | 25)
+		buf.WriteByte(byte(n >> 8))
+		buf.WriteByte(byte(n))
+	case n < 1<<32:
+		buf.WriteByte(mt | 26)
+		for shift := 24; shift >= 0; shift -= 8 {
+			buf.WriteByte(byte(n >> shift))
+		}
+	default:
+		buf.WriteByte(mt | 27)
+		for shift := 56; shift >= 0; shift -= 8 {
+			buf.WriteByte(byte(n >> shift))
+		}
+	}
+}
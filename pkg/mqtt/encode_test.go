@@ -0,0 +1,150 @@
+package mqtt
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEncodeSnapshotGzipRoundTrips(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	payload, err := EncodeSnapshot("gzip", testMembers, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(payload))
+	if err != nil {
+		t.Fatalf("payload is not gzip: %v", err)
+	}
+	raw, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var snap struct {
+		Schema  int    `json:"schema"`
+		TS      int64  `json:"ts"`
+		Active  string `json:"active"`
+		Members []struct {
+			Name      string  `json:"name"`
+			Score     float64 `json:"score"`
+			LatencyMS float64 `json:"latency_ms"`
+		} `json:"members"`
+	}
+	if err := json.Unmarshal(raw, &snap); err != nil {
+		t.Fatal(err)
+	}
+	if snap.Schema != SchemaVersion {
+		t.Errorf("schema = %d, want %d", snap.Schema, SchemaVersion)
+	}
+	if snap.TS != now.Unix() {
+		t.Errorf("ts = %d, want %d", snap.TS, now.Unix())
+	}
+	if snap.Active != "starlink" {
+		t.Errorf("active = %q, want starlink", snap.Active)
+	}
+	if len(snap.Members) != 2 || snap.Members[0].Name != "starlink" || snap.Members[0].Score != 87.5 {
+		t.Errorf("members = %+v", snap.Members)
+	}
+}
+
+func TestEncodeSnapshotUnknownEncoding(t *testing.T) {
+	if _, err := EncodeSnapshot("brotli", testMembers, time.Now()); err == nil {
+		t.Fatal("unknown encoding accepted")
+	}
+}
+
+func TestCBOREncodeGoldenBytes(t *testing.T) {
+	cases := []struct {
+		name string
+		v    any
+		want []byte
+	}{
+		{"small int", 10, []byte{0x0a}},
+		{"one-byte int", 100, []byte{0x18, 0x64}},
+		{"two-byte int", 1000, []byte{0x19, 0x03, 0xe8}},
+		{"negative int", int64(-42), []byte{0x38, 0x29}},
+		{"whole float collapses", float64(80), []byte{0x18, 0x50}},
+		{"fractional float", 1.5, []byte{0xfb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0}},
+		{"bool", true, []byte{0xf5}},
+		{"nil", nil, []byte{0xf6}},
+		{"string", "ok", []byte{0x62, 'o', 'k'}},
+		{"array", []any{1, "a"}, []byte{0x82, 0x01, 0x61, 'a'}},
+		// Map keys come out sorted regardless of insertion order.
+		{"map sorted", map[string]any{"b": 2, "a": 1},
+			[]byte{0xa2, 0x61, 'a', 0x01, 0x61, 'b', 0x02}},
+	}
+	for _, tc := range cases {
+		var buf bytes.Buffer
+		if err := cborEncode(&buf, tc.v); err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if !bytes.Equal(buf.Bytes(), tc.want) {
+			t.Errorf("%s = % x, want % x", tc.name, buf.Bytes(), tc.want)
+		}
+	}
+	var buf bytes.Buffer
+	if err := cborEncode(&buf, struct{}{}); err == nil {
+		t.Error("unsupported type accepted")
+	}
+}
+
+func TestCBORHeadBoundaries(t *testing.T) {
+	cases := []struct {
+		n    uint64
+		want []byte
+	}{
+		{23, []byte{0x17}},
+		{24, []byte{0x18, 0x18}},
+		{255, []byte{0x18, 0xff}},
+		{256, []byte{0x19, 0x01, 0x00}},
+		{65536, []byte{0x1a, 0x00, 0x01, 0x00, 0x00}},
+		{1 << 32, []byte{0x1b, 0, 0, 0, 0x01, 0, 0, 0, 0}},
+	}
+	for _, tc := range cases {
+		var buf bytes.Buffer
+		cborHead(&buf, 0, tc.n)
+		if !bytes.Equal(buf.Bytes(), tc.want) {
+			t.Errorf("head(%d) = % x, want % x", tc.n, buf.Bytes(), tc.want)
+		}
+	}
+}
+
+func TestRenderUsesSnapshotTopicWhenEncoded(t *testing.T) {
+	p := &Publisher{Encoding: "cbor"}
+	msgs := p.render(testMembers)
+	if len(msgs) != 1 {
+		t.Fatalf("got %d messages, want 1 snapshot", len(msgs))
+	}
+	if msgs[0].Topic != "starfail/snapshot" {
+		t.Errorf("topic = %q, want starfail/snapshot", msgs[0].Topic)
+	}
+
+	// A misconfigured encoding falls back to the profile topics.
+	p = &Publisher{Encoding: "brotli"}
+	if msgs := p.render(testMembers); len(msgs) < 2 {
+		t.Errorf("bad encoding rendered %d messages, want profile topics", len(msgs))
+	}
+}
+
+func TestRenderAppendsDeviceTopic(t *testing.T) {
+	p := &Publisher{Prefix: "fleet/r1"}
+	msgs := p.render(testMembers)
+	if len(msgs) < 2 {
+		t.Fatalf("got %d messages", len(msgs))
+	}
+	last := msgs[len(msgs)-1]
+	if last.Topic != "fleet/r1/device" {
+		t.Errorf("device topic = %q, want fleet/r1/device", last.Topic)
+	}
+	if !last.Retain {
+		t.Error("device message not retained")
+	}
+	if !strings.Contains(last.Payload, `"id"`) || !strings.Contains(last.Payload, `"name"`) {
+		t.Errorf("device payload missing fields: %s", last.Payload)
+	}
+}
@@ -0,0 +1,132 @@
+// Package mqtt publishes member state to a broker for home-automation
+// consumers (node-red, openHAB, Home Assistant). Like the dish client, it
+// speaks just enough of the protocol itself — MQTT 3.1.1 CONNECT and QoS 0
+// PUBLISH over one TCP connection — instead of pulling in a client library;
+// the daemon only ever publishes.
+package mqtt
+
+import (
+	"fmt"
+	"net"
+	"time"
+)
+
+// Packet types (MQTT 3.1.1, high nibble of the first byte).
+const (
+	pktConnect = 0x10
+	pktConnack = 0x20
+	pktPublish = 0x30
+
+	// retainFlag in the PUBLISH fixed header makes the broker keep the
+	// last value per topic, which the Homie convention requires.
+	retainFlag = 0x01
+)
+
+// Client is one broker connection. Methods are not safe for concurrent
+// use; the publisher loop is the only caller.
+type Client struct {
+	conn net.Conn
+}
+
+// Dial connects and authenticates. user/pass may be empty for open brokers.
+func Dial(addr, clientID, user, pass string) (*Client, error) {
+	conn, err := net.DialTimeout("tcp", addr, 10*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	c := &Client{conn: conn}
+	if err := c.connect(clientID, user, pass); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// connect sends CONNECT and waits for a successful CONNACK.
+func (c *Client) connect(clientID, user, pass string) error {
+	var flags byte = 0x02 // clean session
+	if user != "" {
+		flags |= 0x80
+	}
+	if pass != "" {
+		flags |= 0x40
+	}
+	var body []byte
+	body = appendString(body, "MQTT")
+	body = append(body, 4, flags) // protocol level 4 = 3.1.1
+	body = append(body, 0, 60)    // keepalive seconds
+	body = appendString(body, clientID)
+	if user != "" {
+		body = appendString(body, user)
+	}
+	if pass != "" {
+		body = appendString(body, pass)
+	}
+	_ = c.conn.SetDeadline(time.Now().Add(10 * time.Second))
+	if _, err := c.conn.Write(appendPacket(nil, pktConnect, body)); err != nil {
+		return err
+	}
+	ack := make([]byte, 4)
+	if _, err := readFull(c.conn, ack); err != nil {
+		return fmt.Errorf("read CONNACK: %w", err)
+	}
+	if ack[0] != pktConnack || ack[3] != 0 {
+		return fmt.Errorf("broker refused connection (code %d)", ack[3])
+	}
+	return nil
+}
+
+// Publish sends one QoS 0 message.
+func (c *Client) Publish(topic, payload string, retain bool) error {
+	var body []byte
+	body = appendString(body, topic)
+	body = append(body, payload...)
+	hdr := byte(pktPublish)
+	if retain {
+		hdr |= retainFlag
+	}
+	_ = c.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	_, err := c.conn.Write(appendPacket(nil, hdr, body))
+	return err
+}
+
+// Close hangs up; QoS 0 has nothing in flight to drain.
+func (c *Client) Close() error { return c.conn.Close() }
+
+// appendString appends a length-prefixed UTF-8 string.
+func appendString(b []byte, s string) []byte {
+	b = append(b, byte(len(s)>>8), byte(len(s)))
+	return append(b, s...)
+}
+
+// appendPacket frames a control packet: fixed header byte, remaining
+// length as a varint, then the body.
+func appendPacket(b []byte, hdr byte, body []byte) []byte {
+	b = append(b, hdr)
+	n := len(body)
+	for {
+		d := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			d |= 0x80
+		}
+		b = append(b, d)
+		if n == 0 {
+			break
+		}
+	}
+	return append(b, body...)
+}
+
+func readFull(conn net.Conn, buf []byte) (int, error) {
+	_ = conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+	total := 0
+	for total < len(buf) {
+		n, err := conn.Read(buf[total:])
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	return total, nil
+}
@@ -0,0 +1,83 @@
+package mqtt
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+)
+
+// startFakeBroker accepts one connection, answers the CONNECT, and sends
+// every received PUBLISH topic/payload pair down the channel.
+func startFakeBroker(t *testing.T) (addr string, published <-chan [2]string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	ch := make(chan [2]string, 16)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			hdr := make([]byte, 1)
+			if _, err := io.ReadFull(conn, hdr); err != nil {
+				return
+			}
+			length := 0
+			for shift := 0; ; shift += 7 {
+				b := make([]byte, 1)
+				if _, err := io.ReadFull(conn, b); err != nil {
+					return
+				}
+				length |= int(b[0]&0x7f) << shift
+				if b[0]&0x80 == 0 {
+					break
+				}
+			}
+			body := make([]byte, length)
+			if _, err := io.ReadFull(conn, body); err != nil {
+				return
+			}
+			switch hdr[0] & 0xf0 {
+			case pktConnect:
+				if _, err := conn.Write([]byte{pktConnack, 2, 0, 0}); err != nil {
+					return
+				}
+			case pktPublish:
+				tlen := int(binary.BigEndian.Uint16(body[0:2]))
+				ch <- [2]string{string(body[2 : 2+tlen]), string(body[2+tlen:])}
+			}
+		}
+	}()
+	return ln.Addr().String(), ch
+}
+
+func TestClientPublishes(t *testing.T) {
+	addr, published := startFakeBroker(t)
+	c, err := Dial(addr, "test", "", "")
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer c.Close()
+	if err := c.Publish("starfail/starlink/score", "87.5", false); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	got := <-published
+	if got[0] != "starfail/starlink/score" || got[1] != "87.5" {
+		t.Errorf("broker saw %v", got)
+	}
+}
+
+func TestDialAuthenticates(t *testing.T) {
+	addr, _ := startFakeBroker(t)
+	c, err := Dial(addr, "test", "user", "secret")
+	if err != nil {
+		t.Fatalf("dial with credentials: %v", err)
+	}
+	_ = c.Close()
+}
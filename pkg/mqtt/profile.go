@@ -0,0 +1,158 @@
+package mqtt
+
+// Payload profiles shape what goes onto the wire so existing automation
+// setups consume the topics without transform functions. "flat" is one
+// plain value per topic (node-red, Tasmota-style rules), "homie" follows
+// the Homie 4.0 convention (auto-discovery in openHAB and others), and
+// "openhab" publishes one flat JSON object per member for JSONPATH-based
+// channel linking.
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// MemberState is the per-member snapshot the profiles render.
+type MemberState struct {
+	Name      string
+	Class     string
+	State     string
+	Score     float64
+	Active    bool
+	LatencyMS float64
+	LossPct   float64
+}
+
+// Message is one rendered topic/payload pair.
+type Message struct {
+	Topic   string
+	Payload string
+	Retain  bool
+}
+
+// Render produces the messages for one publish round under the given
+// profile. prefix is the configured topic prefix ("starfail" by default);
+// the homie profile ignores it in value topics per the convention but uses
+// it as the device id. Unknown profile names fall back to flat.
+func Render(profile, prefix string, members []MemberState) []Message {
+	if prefix == "" {
+		prefix = "starfail"
+	}
+	switch profile {
+	case "homie":
+		return renderHomie(prefix, members)
+	case "openhab":
+		return renderOpenHAB(prefix, members)
+	default:
+		return renderFlat(prefix, members)
+	}
+}
+
+// renderFlat publishes one plain value per topic:
+// <prefix>/<member>/score = "87.5".
+func renderFlat(prefix string, members []MemberState) []Message {
+	var out []Message
+	active := ""
+	for _, m := range members {
+		base := prefix + "/" + m.Name
+		out = append(out,
+			Message{Topic: base + "/score", Payload: fmt.Sprintf("%.1f", m.Score)},
+			Message{Topic: base + "/state", Payload: m.State},
+			Message{Topic: base + "/active", Payload: boolPayload(m.Active)},
+			Message{Topic: base + "/latency_ms", Payload: fmt.Sprintf("%.0f", m.LatencyMS)},
+			Message{Topic: base + "/loss_pct", Payload: fmt.Sprintf("%.1f", m.LossPct)},
+		)
+		if m.Active {
+			active = m.Name
+		}
+	}
+	out = append(out, Message{Topic: prefix + "/active", Payload: active})
+	return out
+}
+
+// renderHomie publishes under homie/<device> per the Homie 4.0 convention:
+// retained attributes describing the device and nodes, then retained
+// property values. Property ids are lowercase with dashes, as required.
+func renderHomie(device string, members []MemberState) []Message {
+	root := "homie/" + device
+	names := make([]string, 0, len(members))
+	for _, m := range members {
+		names = append(names, homieID(m.Name))
+	}
+	sort.Strings(names)
+	out := []Message{
+		{Topic: root + "/$homie", Payload: "4.0", Retain: true},
+		{Topic: root + "/$name", Payload: "starfail", Retain: true},
+		{Topic: root + "/$state", Payload: "ready", Retain: true},
+		{Topic: root + "/$nodes", Payload: strings.Join(names, ","), Retain: true},
+	}
+	for _, m := range members {
+		node := root + "/" + homieID(m.Name)
+		out = append(out,
+			Message{Topic: node + "/$name", Payload: m.Name, Retain: true},
+			Message{Topic: node + "/$type", Payload: m.Class, Retain: true},
+			Message{Topic: node + "/$properties", Payload: "score,state,active,latency-ms,loss-pct", Retain: true},
+			Message{Topic: node + "/score", Payload: fmt.Sprintf("%.1f", m.Score), Retain: true},
+			Message{Topic: node + "/state", Payload: m.State, Retain: true},
+			Message{Topic: node + "/active", Payload: homieBool(m.Active), Retain: true},
+			Message{Topic: node + "/latency-ms", Payload: fmt.Sprintf("%.0f", m.LatencyMS), Retain: true},
+			Message{Topic: node + "/loss-pct", Payload: fmt.Sprintf("%.1f", m.LossPct), Retain: true},
+		)
+	}
+	return out
+}
+
+// renderOpenHAB publishes one flat JSON object per member, reachable with
+// a single JSONPATH transform per channel, plus the active member name.
+func renderOpenHAB(prefix string, members []MemberState) []Message {
+	var out []Message
+	active := ""
+	for _, m := range members {
+		b, _ := json.Marshal(map[string]any{
+			"score":      m.Score,
+			"state":      m.State,
+			"active":     m.Active,
+			"latency_ms": m.LatencyMS,
+			"loss_pct":   m.LossPct,
+			"class":      m.Class,
+		})
+		out = append(out, Message{Topic: prefix + "/" + m.Name + "/state", Payload: string(b)})
+		if m.Active {
+			active = m.Name
+		}
+	}
+	out = append(out, Message{Topic: prefix + "/active", Payload: active})
+	return out
+}
+
+func boolPayload(v bool) string {
+	if v {
+		return "1"
+	}
+	return "0"
+}
+
+// homieBool renders per the convention's boolean payloads.
+func homieBool(v bool) string {
+	if v {
+		return "true"
+	}
+	return "false"
+}
+
+// homieID lowercases a member name into a valid Homie topic id (lowercase
+// letters, numbers, hyphens).
+func homieID(name string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-':
+			sb.WriteRune(r)
+		default:
+			sb.WriteRune('-')
+		}
+	}
+	return sb.String()
+}
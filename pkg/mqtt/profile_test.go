@@ -0,0 +1,90 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+var testMembers = []MemberState{
+	{Name: "starlink", Class: "starlink", State: "eligible", Score: 87.5, Active: true, LatencyMS: 42, LossPct: 0.5},
+	{Name: "mob1s1a1", Class: "cellular", State: "eligible", Score: 61.2, LatencyMS: 80, LossPct: 1},
+}
+
+func findTopic(msgs []Message, topic string) (Message, bool) {
+	for _, m := range msgs {
+		if m.Topic == topic {
+			return m, true
+		}
+	}
+	return Message{}, false
+}
+
+func TestRenderFlat(t *testing.T) {
+	msgs := Render("flat", "starfail", testMembers)
+	checks := map[string]string{
+		"starfail/starlink/score":      "87.5",
+		"starfail/starlink/active":     "1",
+		"starfail/mob1s1a1/active":     "0",
+		"starfail/mob1s1a1/latency_ms": "80",
+		"starfail/active":              "starlink",
+	}
+	for topic, want := range checks {
+		msg, ok := findTopic(msgs, topic)
+		if !ok {
+			t.Errorf("topic %s missing", topic)
+			continue
+		}
+		if msg.Payload != want {
+			t.Errorf("%s = %q, want %q", topic, msg.Payload, want)
+		}
+		if msg.Retain {
+			t.Errorf("%s retained; flat profile should not retain", topic)
+		}
+	}
+}
+
+func TestRenderHomie(t *testing.T) {
+	msgs := Render("homie", "starfail", testMembers)
+	if msg, ok := findTopic(msgs, "homie/starfail/$homie"); !ok || msg.Payload != "4.0" {
+		t.Errorf("$homie = %+v", msg)
+	}
+	nodes, ok := findTopic(msgs, "homie/starfail/$nodes")
+	if !ok || !strings.Contains(nodes.Payload, "mob1s1a1") {
+		t.Errorf("$nodes = %+v", nodes)
+	}
+	if msg, ok := findTopic(msgs, "homie/starfail/starlink/active"); !ok || msg.Payload != "true" {
+		t.Errorf("homie active = %+v, want \"true\"", msg)
+	}
+	// Homie requires every message retained and dashed property ids.
+	for _, msg := range msgs {
+		if !msg.Retain {
+			t.Errorf("%s not retained", msg.Topic)
+		}
+		if strings.Contains(msg.Topic, "_") {
+			t.Errorf("topic %s contains an underscore", msg.Topic)
+		}
+	}
+}
+
+func TestRenderOpenHAB(t *testing.T) {
+	msgs := Render("openhab", "starfail", testMembers)
+	msg, ok := findTopic(msgs, "starfail/starlink/state")
+	if !ok {
+		t.Fatal("member state topic missing")
+	}
+	var payload map[string]any
+	if err := json.Unmarshal([]byte(msg.Payload), &payload); err != nil {
+		t.Fatalf("payload not JSON: %v", err)
+	}
+	if payload["score"] != 87.5 || payload["active"] != true {
+		t.Errorf("payload = %v", payload)
+	}
+}
+
+func TestRenderUnknownProfileFallsBackToFlat(t *testing.T) {
+	msgs := Render("", "", testMembers)
+	if _, ok := findTopic(msgs, "starfail/starlink/score"); !ok {
+		t.Errorf("default profile/prefix did not render flat topics: %v", msgs)
+	}
+}
@@ -0,0 +1,143 @@
+package mqtt
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/identity"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/secrets"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/uci"
+)
+
+// Publisher periodically renders member state through a payload profile
+// and publishes it. The connection is dialed lazily and re-dialed after any
+// publish error; a broker outage costs rounds, never the daemon.
+type Publisher struct {
+	Addr      string
+	ClientID  string
+	User      string
+	Pass      string
+	Profile   string
+	Prefix    string
+	IntervalS int
+	// Encoding selects the wire format: "" or "json" renders the
+	// configured payload profile topics; "gzip" and "cbor" publish one
+	// compact versioned snapshot per round instead (see encode.go).
+	Encoding string
+
+	client       *Client
+	warnedEncode bool
+}
+
+// NewFromUCI builds a Publisher from starfail.mqtt.host / port / username /
+// profile / prefix / interval (password decrypted via secrets). Returns nil
+// when no broker host is configured.
+func NewFromUCI() *Publisher {
+	host, ok := uci.Get("starfail.mqtt.host")
+	if !ok || host == "" {
+		return nil
+	}
+	port := uci.GetInt("starfail.mqtt.port", 1883)
+	user, _ := uci.Get("starfail.mqtt.username")
+	pass, _ := secrets.Get("starfail.mqtt.password")
+	prefix, _ := uci.Get("starfail.mqtt.prefix")
+	profile, _ := uci.Get("starfail.mqtt.profile")
+	encoding, _ := uci.Get("starfail.mqtt.encoding")
+	return &Publisher{
+		Addr:      net.JoinHostPort(host, strconv.Itoa(port)),
+		ClientID:  "starfaild",
+		User:      user,
+		Pass:      pass,
+		Profile:   profile,
+		Prefix:    prefix,
+		Encoding:  encoding,
+		IntervalS: uci.GetInt("starfail.mqtt.interval", 30),
+	}
+}
+
+// Run publishes a snapshot every IntervalS until ctx is cancelled.
+func (p *Publisher) Run(ctx context.Context, snapshot func() []MemberState) {
+	interval := time.Duration(p.IntervalS) * time.Second
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			if p.client != nil {
+				_ = p.client.Close()
+			}
+			return
+		case <-ticker.C:
+			p.publishRound(snapshot())
+		}
+	}
+}
+
+// publishRound sends one round, dialing on demand and dropping the
+// connection on the first error so the next round starts fresh.
+func (p *Publisher) publishRound(members []MemberState) {
+	if len(members) == 0 {
+		return
+	}
+	if p.client == nil {
+		c, err := Dial(p.Addr, p.ClientID, p.User, p.Pass)
+		if err != nil {
+			log.Printf("mqtt: dial %s: %v", p.Addr, err)
+			return
+		}
+		p.client = c
+	}
+	for _, msg := range p.render(members) {
+		if err := p.client.Publish(msg.Topic, msg.Payload, msg.Retain); err != nil {
+			log.Printf("mqtt: publish %s: %v", msg.Topic, err)
+			_ = p.client.Close()
+			p.client = nil
+			return
+		}
+	}
+}
+
+// render produces the round's messages: the compact snapshot when an
+// encoding is configured, the payload profile topics otherwise. A bad
+// encoding name degrades to the profile path, loudly but only once.
+func (p *Publisher) render(members []MemberState) []Message {
+	switch p.Encoding {
+	case "", "json":
+	default:
+		prefix := p.Prefix
+		if prefix == "" {
+			prefix = "starfail"
+		}
+		payload, err := EncodeSnapshot(p.Encoding, members, time.Now())
+		if err != nil {
+			if !p.warnedEncode {
+				log.Printf("mqtt: %v, publishing profile topics instead", err)
+				p.warnedEncode = true
+			}
+			break
+		}
+		return []Message{{Topic: prefix + snapshotTopic, Payload: string(payload)}}
+	}
+	return append(Render(p.Profile, p.Prefix, members), deviceMessage(p.Prefix))
+}
+
+// deviceMessage is the retained identity topic published with every
+// profile round, so multi-device dashboards can tell routers apart even
+// when their topic prefixes collide.
+func deviceMessage(prefix string) Message {
+	if prefix == "" {
+		prefix = "starfail"
+	}
+	payload, _ := json.Marshal(map[string]string{
+		"id":   identity.ID(),
+		"name": identity.Name(),
+	})
+	return Message{Topic: prefix + "/device", Payload: string(payload), Retain: true}
+}
@@ -0,0 +1,210 @@
+package notifications
+
+// Escalation chains. A push notification at 03:00 is easy to sleep
+// through; a failover that is still unacknowledged half an hour later
+// deserves a louder channel. Each severity can configure a chain of
+// stages — "push sms@10 email@30" sends push immediately, SMS through
+// the modem after ten unacknowledged minutes, email after thirty — and
+// an acknowledgment (starfailctl ack <id>, using the correlation ID
+// every notification carries) stops the chain. The whole mechanism sits
+// behind starfail.notify.ack_tracking; with the flag off, Notify
+// delivers exactly as before.
+
+import (
+	"fmt"
+	"log"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/uci"
+)
+
+// escalateCheckInterval is how often due stages are looked for; shrunk
+// in tests.
+var escalateCheckInterval = 30 * time.Second
+
+// emailSend hands one message to the system sendmail; a variable for
+// tests.
+var emailSend = func(to, subject, body string) error {
+	cmd := exec.Command("sendmail", to)
+	cmd.Stdin = strings.NewReader("Subject: " + subject + "\n\n" + body + "\n")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("sendmail: %v: %s", err, out)
+	}
+	return nil
+}
+
+// stage is one step of an escalation chain.
+type stage struct {
+	channel  string // push|sms|email
+	afterMin int    // minutes unacknowledged before this stage fires
+}
+
+// incident is one notification working through its chain.
+type incident struct {
+	title    string
+	body     string
+	priority int
+	chain    []stage
+	started  time.Time
+	next     int // index of the next stage to fire
+}
+
+// escalator tracks unacknowledged incidents per correlation ID.
+type escalator struct {
+	chains    map[int][]stage // priority -> chain
+	smsNumber string
+	email     string
+
+	mu        sync.Mutex
+	incidents map[string]*incident
+}
+
+// parseStage parses the UCI list entry form "channel" or
+// "channel@minutes", mirroring the probe target syntax.
+func parseStage(s string) (stage, bool) {
+	st := stage{channel: s}
+	if i := strings.LastIndexByte(s, '@'); i > 0 {
+		m, err := strconv.Atoi(s[i+1:])
+		if err != nil || m < 0 {
+			return stage{}, false
+		}
+		st.channel = s[:i]
+		st.afterMin = m
+	}
+	switch st.channel {
+	case "push", "sms", "email":
+		return st, true
+	}
+	return stage{}, false
+}
+
+// newEscalatorFromUCI returns the configured escalator, or nil when
+// acknowledgment tracking is off or no chain is configured. Chains are
+// per severity: starfail.notify.escalate_critical for high-priority
+// events, escalate_warning for normal, escalate_info for low.
+func newEscalatorFromUCI() *escalator {
+	if !uci.GetBool("starfail.notify.ack_tracking", false) {
+		return nil
+	}
+	e := &escalator{
+		chains:    make(map[int][]stage),
+		incidents: make(map[string]*incident),
+	}
+	e.smsNumber, _ = uci.Get("starfail.notify.sms_number")
+	e.email, _ = uci.Get("starfail.notify.email")
+	for priority, key := range map[int]string{
+		PriorityHigh:   "starfail.notify.escalate_critical",
+		PriorityNormal: "starfail.notify.escalate_warning",
+		PriorityLow:    "starfail.notify.escalate_info",
+	} {
+		var chain []stage
+		for _, entry := range uci.GetList(key) {
+			st, ok := parseStage(entry)
+			if !ok {
+				log.Printf("notify: bad escalation stage %q in %s", entry, key)
+				continue
+			}
+			chain = append(chain, st)
+		}
+		if len(chain) > 0 {
+			e.chains[priority] = chain
+		}
+	}
+	if len(e.chains) == 0 {
+		return nil
+	}
+	return e
+}
+
+// open starts an incident and fires every stage already due (normally
+// just the immediate ones). Returns false when no chain covers the
+// priority, leaving delivery to the ordinary path.
+func (e *escalator) open(n *Notifier, id, title, body string, priority int) bool {
+	chain, ok := e.chains[priority]
+	if !ok || id == "" {
+		return false
+	}
+	inc := &incident{title: title, body: body, priority: priority, chain: chain, started: time.Now()}
+	e.mu.Lock()
+	e.incidents[id] = inc
+	e.mu.Unlock()
+	e.advance(n, id, inc)
+	return true
+}
+
+// ack stops an incident's remaining stages; returns whether the ID was
+// known.
+func (e *escalator) ack(id string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if _, ok := e.incidents[id]; !ok {
+		return false
+	}
+	delete(e.incidents, id)
+	return true
+}
+
+// sweep fires every due stage of every open incident; incidents whose
+// chain is exhausted are dropped — there is nothing left to escalate to.
+func (e *escalator) sweep(n *Notifier) {
+	e.mu.Lock()
+	ids := make([]string, 0, len(e.incidents))
+	for id := range e.incidents {
+		ids = append(ids, id)
+	}
+	e.mu.Unlock()
+	for _, id := range ids {
+		e.mu.Lock()
+		inc := e.incidents[id]
+		e.mu.Unlock()
+		if inc != nil {
+			e.advance(n, id, inc)
+		}
+	}
+}
+
+// advance fires inc's due stages in order and retires the incident once
+// the chain is done.
+func (e *escalator) advance(n *Notifier, id string, inc *incident) {
+	elapsed := int(time.Since(inc.started).Minutes())
+	for inc.next < len(inc.chain) && inc.chain[inc.next].afterMin <= elapsed {
+		st := inc.chain[inc.next]
+		inc.next++
+		e.fire(n, st.channel, id, inc)
+	}
+	if inc.next >= len(inc.chain) {
+		e.mu.Lock()
+		delete(e.incidents, id)
+		e.mu.Unlock()
+	}
+}
+
+// fire delivers one stage; failures are logged and the chain continues —
+// a dead SMS modem must not block the email stage.
+func (e *escalator) fire(n *Notifier, channel, id string, inc *incident) {
+	tagged := inc.body + " (ack: starfailctl ack " + id + ")"
+	switch channel {
+	case "push":
+		n.SendNotification(inc.title, tagged, inc.priority)
+	case "sms":
+		if e.smsNumber == "" {
+			log.Printf("notify: sms stage with no starfail.notify.sms_number")
+			return
+		}
+		if err := smsSend(e.smsNumber, inc.title+": "+tagged); err != nil {
+			log.Printf("notify: %v", err)
+		}
+	case "email":
+		if e.email == "" {
+			log.Printf("notify: email stage with no starfail.notify.email")
+			return
+		}
+		if err := emailSend(e.email, inc.title, tagged); err != nil {
+			log.Printf("notify: %v", err)
+		}
+	}
+}
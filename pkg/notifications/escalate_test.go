@@ -0,0 +1,146 @@
+package notifications
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// escalateTestNotifier captures push stages in its queue instead of
+// talking to Pushover.
+func escalateTestNotifier(t *testing.T) *Notifier {
+	t.Helper()
+	return &Notifier{
+		token: "tok", user: "usr",
+		queue: newQueue(filepath.Join(t.TempDir(), "queue.json")),
+		wake:  make(chan struct{}, 1),
+	}
+}
+
+// installEscalateStubs captures SMS and email deliveries.
+func installEscalateStubs(t *testing.T) (sms, mails *[]string) {
+	t.Helper()
+	var s, m []string
+	origSMS, origMail := smsSend, emailSend
+	smsSend = func(number, text string) error {
+		s = append(s, number+": "+text)
+		return nil
+	}
+	emailSend = func(to, subject, body string) error {
+		m = append(m, to+": "+subject)
+		return nil
+	}
+	t.Cleanup(func() { smsSend, emailSend = origSMS, origMail })
+	return &s, &m
+}
+
+func TestParseStage(t *testing.T) {
+	cases := []struct {
+		in      string
+		channel string
+		after   int
+		ok      bool
+	}{
+		{"push", "push", 0, true},
+		{"sms@10", "sms", 10, true},
+		{"email@30", "email", 30, true},
+		{"pager@5", "", 0, false},
+		{"sms@ten", "", 0, false},
+	}
+	for _, tc := range cases {
+		st, ok := parseStage(tc.in)
+		if ok != tc.ok || st.channel != tc.channel || st.afterMin != tc.after {
+			t.Errorf("parseStage(%q) = %+v, %v", tc.in, st, ok)
+		}
+	}
+}
+
+func TestEscalatorFiresStagesAsTheyComeDue(t *testing.T) {
+	sms, mails := installEscalateStubs(t)
+	n := escalateTestNotifier(t)
+	e := &escalator{
+		chains:    map[int][]stage{PriorityHigh: {{channel: "push"}, {channel: "sms", afterMin: 10}, {channel: "email", afterMin: 30}}},
+		smsNumber: "+46700000000",
+		email:     "ops@example.com",
+		incidents: make(map[string]*incident),
+	}
+	if !e.open(n, "abc123", "Failover", "wan -> mob1s1a1", PriorityHigh) {
+		t.Fatal("open did not take the notification")
+	}
+	if n.queue.len() != 1 {
+		t.Fatalf("push stage sent %d messages, want 1", n.queue.len())
+	}
+	if len(*sms) != 0 || len(*mails) != 0 {
+		t.Fatal("later stages fired immediately")
+	}
+
+	// Eleven unacknowledged minutes: the SMS stage is due.
+	e.mu.Lock()
+	e.incidents["abc123"].started = time.Now().Add(-11 * time.Minute)
+	e.mu.Unlock()
+	e.sweep(n)
+	if len(*sms) != 1 || len(*mails) != 0 {
+		t.Fatalf("after 11 min: sms=%d mails=%d", len(*sms), len(*mails))
+	}
+
+	// Thirty-one minutes: email fires and the exhausted chain retires.
+	e.mu.Lock()
+	e.incidents["abc123"].started = time.Now().Add(-31 * time.Minute)
+	e.mu.Unlock()
+	e.sweep(n)
+	if len(*mails) != 1 {
+		t.Fatalf("email stage fired %d times", len(*mails))
+	}
+	if e.ack("abc123") {
+		t.Error("exhausted incident still tracked")
+	}
+}
+
+func TestEscalatorAckStopsChain(t *testing.T) {
+	sms, _ := installEscalateStubs(t)
+	n := escalateTestNotifier(t)
+	e := &escalator{
+		chains:    map[int][]stage{PriorityHigh: {{channel: "push"}, {channel: "sms", afterMin: 10}}},
+		smsNumber: "+46700000000",
+		incidents: make(map[string]*incident),
+	}
+	e.open(n, "abc123", "Failover", "body", PriorityHigh)
+	if !e.ack("abc123") {
+		t.Fatal("ack did not find the incident")
+	}
+	e.sweep(n)
+	if len(*sms) != 0 {
+		t.Error("acknowledged incident escalated anyway")
+	}
+	if e.ack("abc123") {
+		t.Error("double ack succeeded")
+	}
+}
+
+func TestEscalatorSkipsUncoveredPriority(t *testing.T) {
+	n := escalateTestNotifier(t)
+	e := &escalator{
+		chains:    map[int][]stage{PriorityHigh: {{channel: "push"}}},
+		incidents: make(map[string]*incident),
+	}
+	if e.open(n, "abc123", "FYI", "body", PriorityLow) {
+		t.Error("open took a priority with no chain")
+	}
+}
+
+func TestEscalatorStageFailureContinuesChain(t *testing.T) {
+	_, mails := installEscalateStubs(t)
+	smsSend = func(string, string) error { return fmt.Errorf("modem gone") }
+	n := escalateTestNotifier(t)
+	e := &escalator{
+		chains:    map[int][]stage{PriorityHigh: {{channel: "sms"}, {channel: "email"}}},
+		smsNumber: "+46700000000",
+		email:     "ops@example.com",
+		incidents: make(map[string]*incident),
+	}
+	e.open(n, "abc123", "Failover", "body", PriorityHigh)
+	if len(*mails) != 1 {
+		t.Errorf("email stage fired %d times after sms failure", len(*mails))
+	}
+}
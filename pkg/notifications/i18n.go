@@ -0,0 +1,152 @@
+package notifications
+
+import (
+	"sync"
+	"time"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/gps"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/uci"
+)
+
+// Notification language and timezone come from starfail.notify.language
+// ("en", "sv", "de") and starfail.notify.timezone (IANA name, e.g.
+// "Europe/Stockholm"). Unknown values fall back to English. With no
+// timezone configured, timestamps follow the GPS-derived local zone, so
+// a moving install reads in local wall-clock time; without a fix that is
+// simply the router's zone, as before.
+
+var localeMu sync.Mutex
+var localeLang = "en"
+
+// localeLoc is the explicitly configured zone; nil means follow
+// gps.LocalLocation.
+var localeLoc *time.Location
+
+// SetLocale applies the configured language and timezone; called from
+// NewNotifier and on config reload. Bad values are ignored, keeping the
+// previous (or default) locale.
+func SetLocale(lang, timezone string) {
+	localeMu.Lock()
+	defer localeMu.Unlock()
+	if _, ok := builtinTemplates[lang]; ok {
+		localeLang = lang
+	}
+	if timezone == "" {
+		localeLoc = nil
+	} else if loc, err := time.LoadLocation(timezone); err == nil {
+		localeLoc = loc
+	}
+}
+
+// loadLocale reads the UCI locale options and applies them.
+func loadLocale() {
+	lang, _ := uci.Get("starfail.notify.language")
+	tz, _ := uci.Get("starfail.notify.timezone")
+	SetLocale(lang, tz)
+}
+
+// ReloadLocale re-reads the locale options; called on config reload.
+func ReloadLocale() { loadLocale() }
+
+func currentLocale() (string, *time.Location) {
+	localeMu.Lock()
+	defer localeMu.Unlock()
+	if localeLoc == nil {
+		return localeLang, gps.LocalLocation()
+	}
+	return localeLang, localeLoc
+}
+
+// timestampLayouts hold the per-language date order; times are always
+// 24-hour, which is what EN users of RUTOS gear see everywhere else in
+// the UI too.
+var timestampLayouts = map[string]string{
+	"en": "2006-01-02 15:04",
+	"sv": "2006-01-02 15:04",
+	"de": "02.01.2006 15:04",
+}
+
+// formatTimestamp renders t in the configured timezone and date order;
+// exposed to templates as the "ts" function.
+func formatTimestamp(t time.Time) string {
+	lang, loc := currentLocale()
+	layout, ok := timestampLayouts[lang]
+	if !ok {
+		layout = timestampLayouts["en"]
+	}
+	return t.In(loc).Format(layout)
+}
+
+// genericFallbacks render unknown event types per language.
+var genericFallbacks = map[string]string{
+	"en": "{{.Event}} on {{.Member}}\n{{.Reason}}\n{{ts .Timestamp}} [{{.CorrelationID}}]",
+	"sv": "{{.Event}} på {{.Member}}\n{{.Reason}}\n{{ts .Timestamp}} [{{.CorrelationID}}]",
+	"de": "{{.Event}} auf {{.Member}}\n{{.Reason}}\n{{ts .Timestamp}} [{{.CorrelationID}}]",
+}
+
+// Swedish and German builtins mirror the English set in template.go; the
+// correlation id and timestamp line is kept identical across languages so
+// log cross-referencing instructions work regardless of locale.
+var svTemplates = map[string]string{
+	"failover": "Växlade till {{.Member}}\n" +
+		"Bytte från {{if .From}}{{.From}}{{else}}(ingen){{end}} till {{.Member}}: {{.Reason}}.\n" +
+		"Poäng {{printf \"%.1f\" .Score}}{{with .Metrics}}{{with .LatencyMS}}, latens {{printf \"%.0f\" (ms .)}} ms{{end}}{{end}}.\n" +
+		"{{ts .Timestamp}} [{{.CorrelationID}}]",
+	"member_failed": "{{.Member}} har fallerat\n" +
+		"Medlem {{.Member}} föll till poäng {{printf \"%.1f\" .Score}}: {{.Reason}}.\n" +
+		"{{ts .Timestamp}} [{{.CorrelationID}}]",
+	"member_degraded": "{{.Member}} under varningströskeln\n" +
+		"Medlem {{.Member}} sjönk till poäng {{printf \"%.1f\" .Score}}: {{.Reason}}.\n" +
+		"Ingen failover ännu; trafiken ligger kvar.\n" +
+		"{{ts .Timestamp}} [{{.CorrelationID}}]",
+	"member_recovering": "{{.Member}} återhämtar sig\n" +
+		"Medlem {{.Member}} har varit frisk länge nog för att återgå i drift.\n" +
+		"{{ts .Timestamp}} [{{.CorrelationID}}]",
+	"predictive": "{{.Member}} försämras\n" +
+		"Prediktiv motor flaggar {{.Member}}: {{.Reason}}.\n" +
+		"{{ts .Timestamp}} [{{.CorrelationID}}]",
+	"data_balance": "Datasaldo lågt\n" +
+		"{{.Member}}: {{.Reason}}.\n" +
+		"{{ts .Timestamp}} [{{.CorrelationID}}]",
+	"starlink_alert": "Starlink-larm: {{.Reason}}\n" +
+		"Antennen på {{.Member}} rapporterar {{.Reason}}.\n" +
+		"{{ts .Timestamp}} [{{.CorrelationID}}]",
+	"manual_revert": "Manuell failover: {{.Member}}\n" +
+		"{{.Reason}}.\n" +
+		"{{ts .Timestamp}} [{{.CorrelationID}}]",
+	"budget_exhausted": "Prioritetsdata slut\n" +
+		"{{.Member}}: {{.Reason}}.\n" +
+		"{{ts .Timestamp}} [{{.CorrelationID}}]",
+}
+
+var deTemplates = map[string]string{
+	"failover": "Failover zu {{.Member}}\n" +
+		"Gewechselt von {{if .From}}{{.From}}{{else}}(keinem){{end}} zu {{.Member}}: {{.Reason}}.\n" +
+		"Punktzahl {{printf \"%.1f\" .Score}}{{with .Metrics}}{{with .LatencyMS}}, Latenz {{printf \"%.0f\" (ms .)}} ms{{end}}{{end}}.\n" +
+		"{{ts .Timestamp}} [{{.CorrelationID}}]",
+	"member_failed": "{{.Member}} ausgefallen\n" +
+		"Mitglied {{.Member}} fiel auf Punktzahl {{printf \"%.1f\" .Score}}: {{.Reason}}.\n" +
+		"{{ts .Timestamp}} [{{.CorrelationID}}]",
+	"member_degraded": "{{.Member}} unter der Warnschwelle\n" +
+		"Mitglied {{.Member}} sank auf Punktzahl {{printf \"%.1f\" .Score}}: {{.Reason}}.\n" +
+		"Noch kein Failover; der Verkehr bleibt, wo er ist.\n" +
+		"{{ts .Timestamp}} [{{.CorrelationID}}]",
+	"member_recovering": "{{.Member}} erholt sich\n" +
+		"Mitglied {{.Member}} war lange genug gesund und kehrt in die Rotation zurück.\n" +
+		"{{ts .Timestamp}} [{{.CorrelationID}}]",
+	"predictive": "{{.Member}} verschlechtert sich\n" +
+		"Prädiktive Engine meldet {{.Member}}: {{.Reason}}.\n" +
+		"{{ts .Timestamp}} [{{.CorrelationID}}]",
+	"data_balance": "Datenvolumen niedrig\n" +
+		"{{.Member}}: {{.Reason}}.\n" +
+		"{{ts .Timestamp}} [{{.CorrelationID}}]",
+	"starlink_alert": "Starlink-Alarm: {{.Reason}}\n" +
+		"Die Antenne an {{.Member}} meldet {{.Reason}}.\n" +
+		"{{ts .Timestamp}} [{{.CorrelationID}}]",
+	"manual_revert": "Manuelles Failover: {{.Member}}\n" +
+		"{{.Reason}}.\n" +
+		"{{ts .Timestamp}} [{{.CorrelationID}}]",
+	"budget_exhausted": "Prioritätsdaten aufgebraucht\n" +
+		"{{.Member}}: {{.Reason}}.\n" +
+		"{{ts .Timestamp}} [{{.CorrelationID}}]",
+}
@@ -0,0 +1,73 @@
+package notifications
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenderTemplateSwedish(t *testing.T) {
+	SetLocale("sv", "UTC")
+	t.Cleanup(func() { SetLocale("en", "UTC") })
+
+	title, body, err := RenderTemplate("pushover", "failover", EventData{
+		Member: "cellular", From: "starlink", Reason: "active member failed",
+		Score: 70, CorrelationID: "abcd1234",
+		Timestamp: time.Date(2026, 3, 1, 14, 30, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("RenderTemplate: %v", err)
+	}
+	if title != "Växlade till cellular" {
+		t.Errorf("title = %q", title)
+	}
+	for _, want := range []string{"starlink", "Poäng", "2026-03-01 14:30", "abcd1234"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("body missing %q: %q", want, body)
+		}
+	}
+}
+
+func TestRenderTemplateGermanDateOrder(t *testing.T) {
+	SetLocale("de", "UTC")
+	t.Cleanup(func() { SetLocale("en", "UTC") })
+
+	_, body, err := RenderTemplate("pushover", "member_failed", EventData{
+		Member: "wan", Reason: "score collapsed", Score: 12,
+		CorrelationID: "x", Timestamp: time.Date(2026, 3, 1, 14, 30, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("RenderTemplate: %v", err)
+	}
+	if !strings.Contains(body, "01.03.2026 14:30") {
+		t.Errorf("body missing German-order timestamp: %q", body)
+	}
+	if !strings.Contains(body, "Punktzahl") {
+		t.Errorf("body not in German: %q", body)
+	}
+}
+
+func TestSetLocaleRejectsUnknown(t *testing.T) {
+	SetLocale("en", "UTC")
+	SetLocale("xx", "Not/AZone")
+	lang, loc := currentLocale()
+	if lang != "en" || loc != time.UTC {
+		t.Errorf("locale = %s/%v, want unchanged en/UTC", lang, loc)
+	}
+}
+
+func TestFallbackLocalized(t *testing.T) {
+	SetLocale("de", "UTC")
+	t.Cleanup(func() { SetLocale("en", "UTC") })
+
+	_, body, err := RenderTemplate("pushover", "something_new", EventData{
+		Event: "something_new", Member: "wan", Reason: "details",
+		CorrelationID: "id1", Timestamp: time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("RenderTemplate fallback: %v", err)
+	}
+	if !strings.Contains(body, "details") {
+		t.Errorf("fallback body = %q", body)
+	}
+}
@@ -0,0 +1,165 @@
+// Package notifications delivers user alerts, currently via Pushover — the
+// channel the shell scripts in Starlink-RUTOS-Failover have used since the
+// first release.
+//
+// Delivery is asynchronous: SendNotification only enqueues, and a worker
+// goroutine drains the queue with backoff. That matters here because the
+// moment notifications fire is exactly when the active link is dying, and
+// failover handling must never wait on an unreachable Pushover API; queued
+// alerts go out once connectivity resumes.
+package notifications
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/secrets"
+)
+
+// Priorities map to Pushover priority values.
+const (
+	PriorityLow    = -1
+	PriorityNormal = 0
+	PriorityHigh   = 1
+)
+
+const pushoverURL = "https://api.pushover.net/1/messages.json"
+
+// Notifier sends alerts using credentials from UCI
+// (starfail.notify.pushover_token / pushover_user).
+type Notifier struct {
+	token  string
+	user   string
+	client *http.Client
+	queue  *queue
+	wake   chan struct{}
+	esc    *escalator
+	sms    *SMSSender
+}
+
+// NewNotifier loads credentials; a Notifier with no credentials logs
+// messages instead of sending them. Messages queued by a previous run are
+// restored and delivered once Start runs.
+func NewNotifier() *Notifier {
+	token, _ := secrets.Get("starfail.notify.pushover_token")
+	user, _ := secrets.Get("starfail.notify.pushover_user")
+	loadLocale()
+	n := &Notifier{
+		token:  token,
+		user:   user,
+		client: &http.Client{Timeout: 15 * time.Second},
+		queue:  newQueue(""),
+		wake:   make(chan struct{}, 1),
+		esc:    newEscalatorFromUCI(),
+		sms:    newSMSFromUCI(),
+	}
+	if err := n.queue.load(); err != nil {
+		log.Printf("notify: restore queue: %v", err)
+	}
+	return n
+}
+
+// SendNotification enqueues one message for asynchronous delivery. It never
+// blocks; when the queue is full the oldest message is dropped.
+func (n *Notifier) SendNotification(title, message string, priority int) {
+	// SMS goes out through the modem regardless of Pushover state — its
+	// whole point is working when the data path (or its config) does not.
+	if n.sms != nil {
+		n.sms.Notify(title, message, priority)
+	}
+	if n.token == "" || n.user == "" {
+		log.Printf("notify (unconfigured): %s: %s", title, message)
+		return
+	}
+	n.queue.push(Message{Title: title, Body: message, Priority: priority, QueuedAt: time.Now()})
+	select {
+	case n.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Start runs the delivery worker until ctx is cancelled. The queue is
+// persisted on every change so a daemon restart (or crash) loses nothing.
+func (n *Notifier) Start(ctx context.Context) {
+	go n.worker(ctx)
+	if n.esc != nil {
+		go func() {
+			t := time.NewTicker(escalateCheckInterval)
+			defer t.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-t.C:
+					n.esc.sweep(n)
+				}
+			}
+		}()
+	}
+}
+
+// Ack acknowledges an escalating notification by its correlation ID,
+// stopping the remaining stages; returns whether the ID was pending.
+func (n *Notifier) Ack(id string) bool {
+	return n.esc != nil && n.esc.ack(id)
+}
+
+func (n *Notifier) worker(ctx context.Context) {
+	backoff := time.Second
+	for {
+		msg, ok := n.queue.peek()
+		if !ok {
+			select {
+			case <-ctx.Done():
+				return
+			case <-n.wake:
+			}
+			continue
+		}
+		if err := n.deliver(msg); err != nil {
+			log.Printf("notify: %v (retrying in %s, %d queued)", err, backoff, n.queue.len())
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			if backoff < 5*time.Minute {
+				backoff *= 2
+			}
+			continue
+		}
+		backoff = time.Second
+		n.queue.pop()
+	}
+}
+
+// deliver makes one delivery attempt.
+func (n *Notifier) deliver(msg Message) error {
+	form := url.Values{
+		"token":    {n.token},
+		"user":     {n.user},
+		"title":    {msg.Title},
+		"message":  {msg.Body},
+		"priority": {strconv.Itoa(msg.Priority)},
+	}
+	resp, err := n.client.PostForm(pushoverURL, form)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		return nil
+	case resp.StatusCode >= 400 && resp.StatusCode < 500:
+		// The API rejected the message itself; retrying cannot help.
+		log.Printf("notify: dropping %q: pushover returned %s", msg.Title, resp.Status)
+		return nil
+	default:
+		return fmt.Errorf("pushover returned %s", resp.Status)
+	}
+}
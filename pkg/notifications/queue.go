@@ -0,0 +1,104 @@
+package notifications
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// maxQueued bounds the persistent queue; beyond it the oldest messages are
+// dropped, since a flood of stale alerts after a long outage helps nobody.
+const maxQueued = 100
+
+// DefaultQueuePath is where pending messages are persisted across restarts.
+const DefaultQueuePath = "/tmp/starfail/notify-queue.json"
+
+// Message is one queued notification.
+type Message struct {
+	Title    string    `json:"title"`
+	Body     string    `json:"body"`
+	Priority int       `json:"priority"`
+	QueuedAt time.Time `json:"queued_at"`
+}
+
+// queue is a bounded FIFO persisted to disk on every change.
+type queue struct {
+	mu   sync.Mutex
+	path string
+	msgs []Message
+}
+
+func newQueue(path string) *queue {
+	if path == "" {
+		path = DefaultQueuePath
+	}
+	return &queue{path: path}
+}
+
+func (q *queue) push(m Message) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.msgs = append(q.msgs, m)
+	if len(q.msgs) > maxQueued {
+		q.msgs = q.msgs[len(q.msgs)-maxQueued:]
+	}
+	q.persistLocked()
+}
+
+// peek returns the head without removing it, so a failed delivery attempt
+// keeps the message.
+func (q *queue) peek() (Message, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.msgs) == 0 {
+		return Message{}, false
+	}
+	return q.msgs[0], true
+}
+
+func (q *queue) pop() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.msgs) == 0 {
+		return
+	}
+	q.msgs = q.msgs[1:]
+	q.persistLocked()
+}
+
+func (q *queue) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.msgs)
+}
+
+// load restores persisted messages; a missing file is not an error.
+func (q *queue) load() error {
+	b, err := os.ReadFile(q.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return json.Unmarshal(b, &q.msgs)
+}
+
+// persistLocked writes the queue atomically; callers hold q.mu.
+func (q *queue) persistLocked() {
+	if err := os.MkdirAll(filepath.Dir(q.path), 0o755); err != nil {
+		return
+	}
+	b, err := json.Marshal(q.msgs)
+	if err != nil {
+		return
+	}
+	tmp := q.path + ".tmp"
+	if os.WriteFile(tmp, b, 0o600) == nil {
+		_ = os.Rename(tmp, q.path)
+	}
+}
@@ -0,0 +1,58 @@
+package notifications
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestQueuePushPopPersist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.json")
+	q := newQueue(path)
+	q.push(Message{Title: "a", QueuedAt: time.Now()})
+	q.push(Message{Title: "b", QueuedAt: time.Now()})
+	if q.len() != 2 {
+		t.Fatalf("len = %d, want 2", q.len())
+	}
+	if m, ok := q.peek(); !ok || m.Title != "a" {
+		t.Fatalf("peek = %+v, %v", m, ok)
+	}
+	// peek must not remove.
+	if q.len() != 2 {
+		t.Fatal("peek removed a message")
+	}
+	q.pop()
+
+	// A fresh queue restores the remaining message from disk.
+	q2 := newQueue(path)
+	if err := q2.load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if q2.len() != 1 {
+		t.Fatalf("restored len = %d, want 1", q2.len())
+	}
+	if m, _ := q2.peek(); m.Title != "b" {
+		t.Errorf("restored head = %q, want b", m.Title)
+	}
+}
+
+func TestQueueBounded(t *testing.T) {
+	q := newQueue(filepath.Join(t.TempDir(), "queue.json"))
+	for i := 0; i < maxQueued+10; i++ {
+		q.push(Message{Title: "m", Priority: i})
+	}
+	if q.len() != maxQueued {
+		t.Fatalf("len = %d, want %d", q.len(), maxQueued)
+	}
+	// Oldest were dropped: head priority is 10.
+	if m, _ := q.peek(); m.Priority != 10 {
+		t.Errorf("head priority = %d, want 10", m.Priority)
+	}
+}
+
+func TestQueueLoadMissingFile(t *testing.T) {
+	q := newQueue(filepath.Join(t.TempDir(), "nope.json"))
+	if err := q.load(); err != nil {
+		t.Fatalf("load missing file: %v", err)
+	}
+}
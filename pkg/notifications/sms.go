@@ -0,0 +1,157 @@
+package notifications
+
+// SMS channel. Push notifications ride the very data WANs whose failure
+// they report; an SMS through the router's own modem still leaves the
+// box when every data bearer is down, which makes it the channel of last
+// resort for critical alerts. Routing is per number — each configured
+// number names the lowest severity it wants — deliveries are rate
+// limited so an alert storm cannot drain a prepaid SIM, and text is
+// squeezed into the GSM 7-bit default alphabet so one alert stays one
+// message.
+
+import (
+	"fmt"
+	"log"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/uci"
+)
+
+// smsMaxLen is the single-message GSM-7 limit; longer bodies are
+// truncated rather than split into a paid multipart message.
+const smsMaxLen = 160
+
+// smsSend delivers one SMS through the modem; a variable for tests. The
+// escalation chains (escalate.go) send through it too.
+var smsSend = func(number, text string) error {
+	out, err := exec.Command("gsmctl", "-S", "-s", number+" "+text).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("gsmctl send: %v: %s", err, out)
+	}
+	return nil
+}
+
+// smsRoute is one destination and the lowest priority it receives.
+type smsRoute struct {
+	number      string
+	minPriority int
+}
+
+// SMSSender texts alerts through the local modem.
+type SMSSender struct {
+	routes    []smsRoute
+	maxHourly int
+
+	mu   sync.Mutex
+	sent []time.Time
+}
+
+// newSMSFromUCI builds the sender from starfail.notify.sms_numbers, list
+// entries of the form "number" or "number@severity" where severity is
+// critical|warning|info (plain numbers get critical only — SMS cost
+// makes opt-down the safe default). Returns nil when no numbers are
+// configured.
+func newSMSFromUCI() *SMSSender {
+	var routes []smsRoute
+	for _, entry := range uci.GetList("starfail.notify.sms_numbers") {
+		number, severity := entry, "critical"
+		if i := strings.LastIndexByte(entry, '@'); i > 0 {
+			number, severity = entry[:i], entry[i+1:]
+		}
+		min, ok := map[string]int{
+			"critical": PriorityHigh,
+			"warning":  PriorityNormal,
+			"info":     PriorityLow,
+		}[severity]
+		if !ok {
+			log.Printf("notify: bad sms severity %q in %q", severity, entry)
+			continue
+		}
+		routes = append(routes, smsRoute{number: number, minPriority: min})
+	}
+	if len(routes) == 0 {
+		return nil
+	}
+	return &SMSSender{
+		routes:    routes,
+		maxHourly: uci.GetInt("starfail.notify.sms_max_hourly", 10),
+	}
+}
+
+// Notify texts the alert to every number routed for its priority, within
+// the hourly budget. Delivery failures are logged; there is no retry —
+// if the modem cannot send, nothing in this process will fix that.
+func (s *SMSSender) Notify(title, body string, priority int) {
+	text := gsm7Sanitize(title + ": " + body)
+	if runes := []rune(text); len(runes) > smsMaxLen {
+		text = string(runes[:smsMaxLen-3]) + "..."
+	}
+	for _, r := range s.routes {
+		if priority < r.minPriority {
+			continue
+		}
+		if !s.takeToken() {
+			log.Printf("notify: sms rate limit (%d/h) reached, dropping %q", s.maxHourly, title)
+			return
+		}
+		if err := smsSend(r.number, text); err != nil {
+			log.Printf("notify: %v", err)
+		}
+	}
+}
+
+// takeToken admits one send within the rolling hourly budget.
+func (s *SMSSender) takeToken() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cutoff := time.Now().Add(-time.Hour)
+	kept := s.sent[:0]
+	for _, t := range s.sent {
+		if t.After(cutoff) {
+			kept = append(kept, t)
+		}
+	}
+	s.sent = kept
+	if s.maxHourly > 0 && len(s.sent) >= s.maxHourly {
+		return false
+	}
+	s.sent = append(s.sent, time.Now())
+	return true
+}
+
+// gsm7Replacements transliterates characters outside the GSM 7-bit
+// default alphabet that alerts commonly contain; anything else non-ASCII
+// becomes '?' so the modem never silently switches to UCS-2 and quarters
+// the message capacity.
+var gsm7Replacements = map[rune]string{
+	'‘': "'", '’': "'", '“': `"`, '”': `"`,
+	'–': "-", '—': "-", '…': "...", ' ': " ",
+	'→': "->",
+}
+
+// gsm7Extra are non-ASCII runes that are part of the GSM 7-bit default
+// alphabet (or its extension) and pass through unchanged.
+const gsm7Extra = "£¥èéùìòÇØøÅåÆæßÉÄÖÑÜäöñüà€"
+
+// gsm7Sanitize squeezes s into the GSM 7-bit repertoire.
+func gsm7Sanitize(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r >= 0x20 && r < 0x7f, r == '\n':
+			b.WriteRune(r)
+		case strings.ContainsRune(gsm7Extra, r):
+			b.WriteRune(r)
+		default:
+			if rep, ok := gsm7Replacements[r]; ok {
+				b.WriteString(rep)
+			} else {
+				b.WriteByte('?')
+			}
+		}
+	}
+	return b.String()
+}
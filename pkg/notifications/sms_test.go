@@ -0,0 +1,94 @@
+package notifications
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func installSMSStub(t *testing.T) *[]string {
+	t.Helper()
+	var sent []string
+	orig := smsSend
+	smsSend = func(number, text string) error {
+		sent = append(sent, number+"|"+text)
+		return nil
+	}
+	t.Cleanup(func() { smsSend = orig })
+	return &sent
+}
+
+func TestSMSRoutesByPriority(t *testing.T) {
+	sent := installSMSStub(t)
+	s := &SMSSender{
+		routes: []smsRoute{
+			{number: "+46700000001", minPriority: PriorityHigh},
+			{number: "+46700000002", minPriority: PriorityLow},
+		},
+		maxHourly: 10,
+	}
+	s.Notify("Failover", "wan down", PriorityHigh)
+	if len(*sent) != 2 {
+		t.Fatalf("critical alert reached %d numbers, want 2", len(*sent))
+	}
+	*sent = nil
+	s.Notify("FYI", "budget low", PriorityLow)
+	if len(*sent) != 1 || !strings.HasPrefix((*sent)[0], "+46700000002|") {
+		t.Errorf("info alert routing = %v", *sent)
+	}
+}
+
+func TestSMSRateLimit(t *testing.T) {
+	sent := installSMSStub(t)
+	s := &SMSSender{
+		routes:    []smsRoute{{number: "+46700000001", minPriority: PriorityLow}},
+		maxHourly: 2,
+	}
+	for i := 0; i < 5; i++ {
+		s.Notify("Alert", "storm", PriorityHigh)
+	}
+	if len(*sent) != 2 {
+		t.Errorf("sent %d messages under a 2/h limit", len(*sent))
+	}
+	// Tokens outside the rolling hour are reclaimed.
+	s.mu.Lock()
+	for i := range s.sent {
+		s.sent[i] = time.Now().Add(-2 * time.Hour)
+	}
+	s.mu.Unlock()
+	s.Notify("Alert", "later", PriorityHigh)
+	if len(*sent) != 3 {
+		t.Errorf("rolling window did not reopen: %d sent", len(*sent))
+	}
+}
+
+func TestSMSTruncates(t *testing.T) {
+	sent := installSMSStub(t)
+	s := &SMSSender{
+		routes:    []smsRoute{{number: "+46700000001", minPriority: PriorityLow}},
+		maxHourly: 10,
+	}
+	s.Notify("Long", strings.Repeat("x", 400), PriorityHigh)
+	text := strings.SplitN((*sent)[0], "|", 2)[1]
+	if len([]rune(text)) != smsMaxLen {
+		t.Errorf("truncated length = %d, want %d", len([]rune(text)), smsMaxLen)
+	}
+	if !strings.HasSuffix(text, "...") {
+		t.Errorf("no ellipsis on truncated text: ...%s", text[len(text)-10:])
+	}
+}
+
+func TestGSM7Sanitize(t *testing.T) {
+	cases := []struct{ in, want string }{
+		{"plain ASCII", "plain ASCII"},
+		{"wan → mob1s1a1", "wan -> mob1s1a1"},
+		{"“smart” – quotes…", `"smart" - quotes...`},
+		{"åäö ÅÄÖ é", "åäö ÅÄÖ é"}, // GSM-7 default alphabet passes through
+		{"雨が降る", "????"},
+	}
+	for _, tc := range cases {
+		if got := gsm7Sanitize(tc.in); got != tc.want {
+			t.Errorf("gsm7Sanitize(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
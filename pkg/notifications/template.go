@@ -0,0 +1,162 @@
+package notifications
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/gps"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/metrics"
+)
+
+// TemplateDir is searched for user overrides: <dir>/<channel>/<event>.tmpl.
+// The first line of a rendered template is the notification title, the
+// rest is the body.
+const TemplateDir = "/etc/starfail/templates"
+
+// EventData is the variable set available inside message templates.
+type EventData struct {
+	Event         string           // event type, e.g. "failover"
+	Member        string           // member the event concerns
+	From          string           // previous member (switch events)
+	Reason        string           // human-readable cause
+	Score         float64          // member score at event time
+	Metrics       *metrics.Metrics // last sample, may be nil
+	GPS           *gps.Fix         // current fix, may be nil
+	CorrelationID string           // ties notification to telemetry events
+	Timestamp     time.Time
+}
+
+// NewCorrelationID returns a short random id used to tie a notification to
+// its telemetry event.
+func NewCorrelationID() string {
+	var b [4]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return fmt.Sprintf("%08x", time.Now().UnixNano()&0xffffffff)
+	}
+	return hex.EncodeToString(b[:])
+}
+
+// builtinTemplates are the defaults per language and event type, used when
+// no override file exists for the channel. The Swedish and German sets
+// live in i18n.go.
+var builtinTemplates = map[string]map[string]string{
+	"en": {
+		"failover": "Failover to {{.Member}}\n" +
+			"Switched from {{if .From}}{{.From}}{{else}}(none){{end}} to {{.Member}}: {{.Reason}}.\n" +
+			"Score {{printf \"%.1f\" .Score}}{{with .Metrics}}{{with .LatencyMS}}, latency {{printf \"%.0f\" (ms .)}} ms{{end}}{{end}}.\n" +
+			"{{ts .Timestamp}} [{{.CorrelationID}}]",
+		"member_failed": "{{.Member}} failed\n" +
+			"Member {{.Member}} dropped to score {{printf \"%.1f\" .Score}}: {{.Reason}}.\n" +
+			"{{ts .Timestamp}} [{{.CorrelationID}}]",
+		"member_degraded": "{{.Member}} below warning threshold\n" +
+			"Member {{.Member}} slipped to score {{printf \"%.1f\" .Score}}: {{.Reason}}.\n" +
+			"No failover yet; traffic stays where it is.\n" +
+			"{{ts .Timestamp}} [{{.CorrelationID}}]",
+		"member_recovering": "{{.Member}} recovering\n" +
+			"Member {{.Member}} has been healthy long enough to re-enter rotation.\n" +
+			"{{ts .Timestamp}} [{{.CorrelationID}}]",
+		"predictive": "{{.Member}} degrading\n" +
+			"Predictive engine flags {{.Member}}: {{.Reason}}.\n" +
+			"{{ts .Timestamp}} [{{.CorrelationID}}]",
+		"data_balance": "Data balance low\n" +
+			"{{.Member}}: {{.Reason}}.\n" +
+			"{{ts .Timestamp}} [{{.CorrelationID}}]",
+		"starlink_alert": "Starlink alert: {{.Reason}}\n" +
+			"The dish on {{.Member}} reports {{.Reason}}.\n" +
+			"{{ts .Timestamp}} [{{.CorrelationID}}]",
+		"manual_revert": "Manual failover: {{.Member}}\n" +
+			"{{.Reason}}.\n" +
+			"{{ts .Timestamp}} [{{.CorrelationID}}]",
+		"budget_exhausted": "Priority data exhausted\n" +
+			"{{.Member}}: {{.Reason}}.\n" +
+			"{{ts .Timestamp}} [{{.CorrelationID}}]",
+	},
+	"sv": svTemplates,
+	"de": deTemplates,
+}
+
+// templateFuncs are available inside all message templates.
+var templateFuncs = template.FuncMap{
+	"ts": formatTimestamp,
+	// ms dereferences an optional latency for printf; metrics fields are
+	// pointers (absent vs zero) and printf would format the pointer itself.
+	"ms": func(v *metrics.Milliseconds) float64 {
+		if v == nil {
+			return 0
+		}
+		return float64(*v)
+	},
+}
+
+// RenderTemplate produces the title and body for an event on a channel in
+// the configured language. Lookup order: per-language user override file,
+// plain override file, built-in default for the language, English
+// built-in, then a generic fallback so an unknown event type still
+// produces something readable.
+func RenderTemplate(channel, event string, data EventData) (title, body string, err error) {
+	src, err := templateSource(channel, event)
+	if err != nil {
+		return "", "", err
+	}
+	tmpl, err := template.New(event).Funcs(templateFuncs).Parse(src)
+	if err != nil {
+		return "", "", fmt.Errorf("template %s/%s: %w", channel, event, err)
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", "", fmt.Errorf("render %s/%s: %w", channel, event, err)
+	}
+	out := sb.String()
+	if i := strings.IndexByte(out, '\n'); i >= 0 {
+		return strings.TrimSpace(out[:i]), strings.TrimSpace(out[i+1:]), nil
+	}
+	return strings.TrimSpace(out), "", nil
+}
+
+func templateSource(channel, event string) (string, error) {
+	lang, _ := currentLocale()
+	for _, path := range []string{
+		filepath.Join(TemplateDir, channel, lang, event+".tmpl"),
+		filepath.Join(TemplateDir, channel, event+".tmpl"),
+	} {
+		if b, err := os.ReadFile(path); err == nil {
+			return string(b), nil
+		}
+	}
+	if src, ok := builtinTemplates[lang][event]; ok {
+		return src, nil
+	}
+	if src, ok := builtinTemplates["en"][event]; ok {
+		return src, nil
+	}
+	if src, ok := genericFallbacks[lang]; ok {
+		return src, nil
+	}
+	return genericFallbacks["en"], nil
+}
+
+// Notify renders the event for this notifier's channel and enqueues it.
+func (n *Notifier) Notify(event string, priority int, data EventData) {
+	if data.CorrelationID == "" {
+		data.CorrelationID = NewCorrelationID()
+	}
+	if data.Timestamp.IsZero() {
+		data.Timestamp = time.Now()
+	}
+	data.Event = event
+	title, body, err := RenderTemplate("pushover", event, data)
+	if err != nil {
+		// A broken user template must not swallow the alert.
+		title, body = event, data.Reason
+	}
+	if n.esc != nil && n.esc.open(n, data.CorrelationID, title, body, priority) {
+		return
+	}
+	n.SendNotification(title, body, priority)
+}
@@ -0,0 +1,52 @@
+package notifications
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/metrics"
+)
+
+func TestRenderTemplateFailover(t *testing.T) {
+	title, body, err := RenderTemplate("pushover", "failover", EventData{
+		Event: "failover", Member: "cellular", From: "starlink",
+		Reason: "active member failed", Score: 71.2,
+		Metrics:       &metrics.Metrics{LatencyMS: metrics.Milliseconds(88).Ptr()},
+		CorrelationID: "abcd1234",
+	})
+	if err != nil {
+		t.Fatalf("RenderTemplate: %v", err)
+	}
+	if title != "Failover to cellular" {
+		t.Errorf("title = %q", title)
+	}
+	for _, want := range []string{"starlink", "cellular", "active member failed", "71.2", "88 ms", "abcd1234"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("body missing %q: %q", want, body)
+		}
+	}
+}
+
+func TestRenderTemplateNilMetrics(t *testing.T) {
+	_, body, err := RenderTemplate("pushover", "failover", EventData{
+		Member: "wan", Reason: "better score", CorrelationID: "x",
+	})
+	if err != nil {
+		t.Fatalf("RenderTemplate with nil metrics: %v", err)
+	}
+	if strings.Contains(body, "latency") {
+		t.Errorf("body mentions latency without a sample: %q", body)
+	}
+}
+
+func TestRenderTemplateUnknownEvent(t *testing.T) {
+	title, body, err := RenderTemplate("pushover", "something_new", EventData{
+		Event: "something_new", Member: "wan", Reason: "details here", CorrelationID: "id1",
+	})
+	if err != nil {
+		t.Fatalf("RenderTemplate fallback: %v", err)
+	}
+	if !strings.Contains(title, "something_new") || !strings.Contains(body, "details here") {
+		t.Errorf("fallback render = %q / %q", title, body)
+	}
+}
@@ -0,0 +1,168 @@
+package predictive
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net"
+	"time"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/gps"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/telemetry"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/uci"
+)
+
+// Assessor produces a predictive assessment for one member from its
+// recent telemetry window. Implementations are the built-in Heuristic and
+// External, which delegates to a user-supplied model process.
+type Assessor interface {
+	Assess(ctx context.Context, member string, window []telemetry.Sample) (Assessment, error)
+}
+
+// Heuristic wraps the built-in trend heuristics as an Assessor. With a
+// Baseline attached it also learns the weekly degradation pattern and
+// adjusts its output: expected dips are down-weighted, unusual ones are
+// called out.
+type Heuristic struct {
+	Baseline *Baseline
+}
+
+// Assess implements Assessor; it never fails.
+func (h Heuristic) Assess(_ context.Context, _ string, window []telemetry.Sample) (Assessment, error) {
+	a := Assess(window)
+	if h.Baseline == nil {
+		return a, nil
+	}
+	// Baseline slots are hour-of-day in local time, GPS-derived so the
+	// learned pattern stays aligned with the sun across zone crossings.
+	now := time.Now().In(gps.LocalLocation())
+	if len(window) > 0 && window[len(window)-1].Metrics != nil {
+		last := window[len(window)-1].Metrics
+		if last.Latitude != nil && last.Longitude != nil {
+			h.Baseline.Anchor(*last.Latitude, *last.Longitude)
+		}
+		// Only links that report obstruction feed the sky baseline.
+		if last.ObstructionPct != nil {
+			h.Baseline.Observe(a.Adjustment < 0, now)
+		}
+	}
+	if a.Adjustment < 0 {
+		switch rate := h.Baseline.ExpectedRate(now); {
+		case rate >= 0.5:
+			// This slot is degraded more often than not: expected
+			// behaviour, not a reason to pre-emptively fail over.
+			a.Adjustment *= 1 - rate
+			a.Reason += " (expected at this hour)"
+		case rate > 0 && rate <= 0.1:
+			a.Reason += " (unusual for this hour)"
+		}
+	}
+	return a, nil
+}
+
+// Disabled is the assessor installed when the predictive feature is
+// switched off at runtime: every member scores neutral.
+type Disabled struct{}
+
+// Assess implements Assessor.
+func (Disabled) Assess(context.Context, string, []telemetry.Sample) (Assessment, error) {
+	return Assessment{}, nil
+}
+
+// DefaultExternalTimeout bounds one sidecar round trip; the tick budget
+// has to absorb this on every member, so it stays small.
+const DefaultExternalTimeout = 2 * time.Second
+
+// External queries a model sidecar (ONNX runner, Python process, ...)
+// over a unix socket. The protocol matches the daemon's own control
+// socket: one JSON request line, one JSON response line.
+//
+//	-> {"member":"starlink","samples":[...]}
+//	<- {"adjustment":-8.5,"reason":"obstruction forecast"}
+//
+// Responses are clamped to [-MaxPenalty, 0] so a misbehaving model can
+// bias a score but never invert or inflate it.
+type External struct {
+	Path    string
+	Timeout time.Duration
+}
+
+// Assess implements Assessor.
+func (e *External) Assess(ctx context.Context, member string, window []telemetry.Sample) (Assessment, error) {
+	timeout := e.Timeout
+	if timeout <= 0 {
+		timeout = DefaultExternalTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "unix", e.Path)
+	if err != nil {
+		return Assessment{}, fmt.Errorf("dial model socket: %w", err)
+	}
+	defer conn.Close()
+	if dl, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(dl)
+	}
+
+	req := struct {
+		Member  string             `json:"member"`
+		Samples []telemetry.Sample `json:"samples"`
+	}{Member: member, Samples: window}
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return Assessment{}, fmt.Errorf("send to model: %w", err)
+	}
+	var a Assessment
+	if err := json.NewDecoder(conn).Decode(&a); err != nil {
+		return Assessment{}, fmt.Errorf("read model reply: %w", err)
+	}
+	return clamp(a), nil
+}
+
+// clamp forces an assessment into the contract the engine relies on.
+func clamp(a Assessment) Assessment {
+	if math.IsNaN(a.Adjustment) || a.Adjustment > 0 {
+		a.Adjustment = 0
+	}
+	if a.Adjustment < -MaxPenalty {
+		a.Adjustment = -MaxPenalty
+	}
+	return a
+}
+
+// fallback tries the primary assessor and silently degrades to the
+// built-in heuristics when it errors, so a dead sidecar never stalls
+// scoring.
+type fallback struct {
+	primary   Assessor
+	heuristic Heuristic
+}
+
+func (f fallback) Assess(ctx context.Context, member string, window []telemetry.Sample) (Assessment, error) {
+	a, err := f.primary.Assess(ctx, member, window)
+	if err == nil {
+		return a, nil
+	}
+	log.Printf("predictive: external assessor: %v (using heuristics)", err)
+	return f.heuristic.Assess(ctx, member, window)
+}
+
+// FromUCI builds the configured assessor: an External (with heuristic
+// fallback) when starfail.predictive.socket is set, the baseline-backed
+// Heuristic otherwise. starfail.predictive.timeout_ms overrides the
+// round-trip budget.
+func FromUCI() Assessor {
+	h := Heuristic{Baseline: NewBaseline("")}
+	path, ok := uci.Get("starfail.predictive.socket")
+	if !ok || path == "" {
+		return h
+	}
+	ext := &External{Path: path}
+	if ms := uci.GetInt("starfail.predictive.timeout_ms", 0); ms > 0 {
+		ext.Timeout = time.Duration(ms) * time.Millisecond
+	}
+	return fallback{primary: ext, heuristic: h}
+}
@@ -0,0 +1,87 @@
+package predictive
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// serveModel answers one connection with the given raw JSON line.
+func serveModel(t *testing.T, reply string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "model.sock")
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		var req map[string]any
+		_ = json.NewDecoder(conn).Decode(&req)
+		_, _ = conn.Write([]byte(reply + "\n"))
+	}()
+	return path
+}
+
+func TestExternalAssess(t *testing.T) {
+	path := serveModel(t, `{"adjustment":-8.5,"reason":"obstruction forecast"}`)
+	ext := &External{Path: path}
+	a, err := ext.Assess(context.Background(), "starlink", nil)
+	if err != nil {
+		t.Fatalf("Assess: %v", err)
+	}
+	if a.Adjustment != -8.5 || a.Reason != "obstruction forecast" {
+		t.Errorf("assessment = %+v", a)
+	}
+}
+
+func TestExternalClampsReply(t *testing.T) {
+	cases := []struct {
+		reply string
+		want  float64
+	}{
+		{`{"adjustment":-9000}`, -MaxPenalty},
+		{`{"adjustment":50}`, 0},
+	}
+	for _, tc := range cases {
+		path := serveModel(t, tc.reply)
+		ext := &External{Path: path}
+		a, err := ext.Assess(context.Background(), "m", nil)
+		if err != nil {
+			t.Fatalf("Assess(%s): %v", tc.reply, err)
+		}
+		if a.Adjustment != tc.want {
+			t.Errorf("reply %s: adjustment = %v, want %v", tc.reply, a.Adjustment, tc.want)
+		}
+	}
+}
+
+func TestClampNaN(t *testing.T) {
+	if a := clamp(Assessment{Adjustment: math.NaN()}); a.Adjustment != 0 {
+		t.Errorf("NaN clamp = %v, want 0", a.Adjustment)
+	}
+}
+
+func TestFallbackOnDeadSocket(t *testing.T) {
+	f := fallback{primary: &External{
+		Path:    filepath.Join(t.TempDir(), "absent.sock"),
+		Timeout: 100 * time.Millisecond,
+	}}
+	a, err := f.Assess(context.Background(), "m", nil)
+	if err != nil {
+		t.Fatalf("fallback must not surface errors, got %v", err)
+	}
+	// Too few samples for the heuristics: neutral assessment.
+	if a.Adjustment != 0 {
+		t.Errorf("adjustment = %v, want 0", a.Adjustment)
+	}
+}
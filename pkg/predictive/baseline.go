@@ -0,0 +1,133 @@
+package predictive
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Baseline learns how often a link is degraded in each hour-of-day /
+// day-of-week bucket at the current location. Starlink degradations often
+// recur on a schedule (nightly maintenance windows, the sun crossing a
+// tree line), and the predictor uses the learned rate to down-weight
+// penalties for expected dips and to call out true anomalies.
+
+// DefaultBaselinePath is where the learned buckets persist across
+// restarts.
+const DefaultBaselinePath = "/tmp/starfail/baseline.json"
+
+// baselineMinSamples is how many observations a bucket needs before its
+// rate is trusted; below this ExpectedRate reports no signal.
+const baselineMinSamples = 20
+
+// baselineMoveKM resets learning when the router moves further than this
+// from the anchor position: another spot has another sky.
+const baselineMoveKM = 5.0
+
+// bucket counts observations for one weekday/hour slot.
+type bucket struct {
+	Samples  int64 `json:"samples"`
+	Degraded int64 `json:"degraded"`
+}
+
+// baselineState is the persisted form.
+type baselineState struct {
+	Lat        float64       `json:"lat"`
+	Lon        float64       `json:"lon"`
+	HaveAnchor bool          `json:"have_anchor"`
+	Buckets    [7][24]bucket `json:"buckets"`
+}
+
+// Baseline accumulates the per-slot degradation history.
+type Baseline struct {
+	mu          sync.Mutex
+	state       baselineState
+	path        string
+	lastPersist time.Time
+}
+
+// NewBaseline restores a baseline from path ("" for the default).
+func NewBaseline(path string) *Baseline {
+	if path == "" {
+		path = DefaultBaselinePath
+	}
+	b := &Baseline{path: path}
+	if raw, err := os.ReadFile(path); err == nil {
+		_ = json.Unmarshal(raw, &b.state)
+	}
+	return b
+}
+
+// Observe records one tick for the current slot. Persistence is
+// throttled to once a minute; losing sub-minute counts on a crash is
+// irrelevant at this sample density.
+func (b *Baseline) Observe(degraded bool, now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	bk := &b.state.Buckets[int(now.Weekday())][now.Hour()]
+	bk.Samples++
+	if degraded {
+		bk.Degraded++
+	}
+	if now.Sub(b.lastPersist) >= time.Minute {
+		b.persistLocked()
+		b.lastPersist = now
+	}
+}
+
+// ExpectedRate returns the learned degradation probability for the slot
+// containing now, or 0 when the bucket has too little history to trust.
+func (b *Baseline) ExpectedRate(now time.Time) float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	bk := b.state.Buckets[int(now.Weekday())][now.Hour()]
+	if bk.Samples < baselineMinSamples {
+		return 0
+	}
+	return float64(bk.Degraded) / float64(bk.Samples)
+}
+
+// Anchor ties the baseline to a position; moving more than baselineMoveKM
+// away discards everything learned, since the obstruction pattern there
+// no longer applies.
+func (b *Baseline) Anchor(lat, lon float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state.HaveAnchor && distanceKM(b.state.Lat, b.state.Lon, lat, lon) <= baselineMoveKM {
+		return
+	}
+	if b.state.HaveAnchor {
+		b.state.Buckets = [7][24]bucket{}
+	}
+	b.state.Lat, b.state.Lon, b.state.HaveAnchor = lat, lon, true
+	b.persistLocked()
+}
+
+// distanceKM is the haversine great-circle distance.
+func distanceKM(lat1, lon1, lat2, lon2 float64) float64 {
+	const r = 6371.0
+	toRad := func(d float64) float64 { return d * math.Pi / 180 }
+	dLat := toRad(lat2 - lat1)
+	dLon := toRad(lon2 - lon1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * r * math.Asin(math.Sqrt(a))
+}
+
+// persistLocked writes the state atomically; callers hold b.mu.
+func (b *Baseline) persistLocked() {
+	if err := os.MkdirAll(filepath.Dir(b.path), 0o755); err != nil {
+		return
+	}
+	raw, err := json.Marshal(&b.state)
+	if err != nil {
+		return
+	}
+	tmp := b.path + ".tmp"
+	if os.WriteFile(tmp, raw, 0o644) == nil {
+		_ = os.Rename(tmp, b.path)
+	}
+}
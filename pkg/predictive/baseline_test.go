@@ -0,0 +1,72 @@
+package predictive
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBaselineExpectedRate(t *testing.T) {
+	b := NewBaseline(filepath.Join(t.TempDir(), "baseline.json"))
+	slot := time.Date(2026, 3, 2, 23, 15, 0, 0, time.UTC) // Monday 23:xx
+
+	// Below the sample floor: no signal yet.
+	for i := 0; i < baselineMinSamples-1; i++ {
+		b.Observe(true, slot)
+	}
+	if r := b.ExpectedRate(slot); r != 0 {
+		t.Fatalf("rate with thin history = %v, want 0", r)
+	}
+
+	b.Observe(true, slot)
+	if r := b.ExpectedRate(slot); r != 1 {
+		t.Fatalf("rate = %v, want 1", r)
+	}
+
+	// A different slot has its own bucket.
+	other := slot.Add(2 * time.Hour) // Tuesday 01:xx
+	if r := b.ExpectedRate(other); r != 0 {
+		t.Fatalf("other slot rate = %v, want 0", r)
+	}
+}
+
+func TestBaselinePersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	b := NewBaseline(path)
+	slot := time.Date(2026, 3, 2, 23, 0, 0, 0, time.UTC)
+	for i := 0; i < baselineMinSamples; i++ {
+		b.Observe(true, slot.Add(time.Duration(i)*2*time.Minute)) // spans >1m, forces persist
+	}
+	if r := NewBaseline(path).ExpectedRate(slot); r != 1 {
+		t.Fatalf("restored rate = %v, want 1", r)
+	}
+}
+
+func TestBaselineAnchorReset(t *testing.T) {
+	b := NewBaseline(filepath.Join(t.TempDir(), "baseline.json"))
+	slot := time.Date(2026, 3, 2, 23, 0, 0, 0, time.UTC)
+	b.Anchor(59.33, 18.07) // Stockholm
+	for i := 0; i < baselineMinSamples; i++ {
+		b.Observe(true, slot)
+	}
+
+	// A short drive around the spot keeps the history.
+	b.Anchor(59.34, 18.08)
+	if r := b.ExpectedRate(slot); r != 1 {
+		t.Fatalf("rate after small move = %v, want 1", r)
+	}
+
+	// Relocating discards it: another spot, another sky.
+	b.Anchor(57.71, 11.97) // Gothenburg
+	if r := b.ExpectedRate(slot); r != 0 {
+		t.Fatalf("rate after relocation = %v, want 0", r)
+	}
+}
+
+func TestDistanceKM(t *testing.T) {
+	// Stockholm to Gothenburg is just under 400 km.
+	d := distanceKM(59.33, 18.07, 57.71, 11.97)
+	if d < 350 || d > 450 {
+		t.Fatalf("distance = %v km, want ~400", d)
+	}
+}
@@ -0,0 +1,90 @@
+package predictive
+
+// Quality forecast for LAN applications. Backup jobs and video uploaders
+// on the LAN can do much better than "try and see" if the router tells
+// them when the link is expected to be good: project the current score
+// along its short-horizon trend, then shade each upcoming slot by the
+// learned weekly degradation pattern. This is a scheduling hint, not a
+// promise — the slots carry a coarse good/fair/poor verdict on purpose.
+
+import (
+	"time"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/gps"
+)
+
+const (
+	// forecastSlotMin is the slot width; finer than ten minutes would
+	// suggest precision the heuristics do not have.
+	forecastSlotMin = 10
+
+	// forecastMaxMin caps the horizon; beyond three hours only the
+	// weekly baseline carries any signal.
+	forecastMaxMin = 180
+
+	// trendHorizonMin is how far the current trend is extrapolated
+	// before it is assumed to have played out.
+	trendHorizonMin = 15.0
+)
+
+// ForecastSlot is the expected quality for one future slot.
+type ForecastSlot struct {
+	OffsetMin int     `json:"offset_min"`
+	Score     float64 `json:"score"`
+	Quality   string  `json:"quality"` // good | fair | poor
+}
+
+// Forecaster is implemented by assessors that can project quality
+// forward; the engine probes for it when serving the forecast API.
+type Forecaster interface {
+	Forecast(current, trendPerMin float64, horizonMin int) []ForecastSlot
+}
+
+// Forecast projects a member's score over the next horizonMin minutes in
+// forecastSlotMin slices. The trend is damped after trendHorizonMin;
+// further out the learned weekly baseline dominates.
+func (h Heuristic) Forecast(current, trendPerMin float64, horizonMin int) []ForecastSlot {
+	if horizonMin <= 0 {
+		horizonMin = 60
+	}
+	if horizonMin > forecastMaxMin {
+		horizonMin = forecastMaxMin
+	}
+	now := time.Now().In(gps.LocalLocation())
+	var out []ForecastSlot
+	for off := 0; off < horizonMin; off += forecastSlotMin {
+		mid := float64(off) + forecastSlotMin/2
+		score := current + trendPerMin*min(mid, trendHorizonMin)
+		if h.Baseline != nil {
+			// A slot that is degraded 40% of the time historically
+			// costs 40% of the predictor's maximum penalty.
+			score -= h.Baseline.ExpectedRate(now.Add(time.Duration(mid)*time.Minute)) * MaxPenalty
+		}
+		score = max(0, min(score, 100))
+		out = append(out, ForecastSlot{
+			OffsetMin: off,
+			Score:     score,
+			Quality:   qualityFor(score),
+		})
+	}
+	return out
+}
+
+// Forecast delegates to the heuristic side: an external model only
+// answers Assess, and the baseline lives with the heuristic anyway.
+func (f fallback) Forecast(current, trendPerMin float64, horizonMin int) []ForecastSlot {
+	return f.heuristic.Forecast(current, trendPerMin, horizonMin)
+}
+
+// qualityFor buckets a projected score the way the lifecycle does:
+// comfortably usable, usable with care, or not worth scheduling on.
+func qualityFor(score float64) string {
+	switch {
+	case score >= 70:
+		return "good"
+	case score >= 45:
+		return "fair"
+	default:
+		return "poor"
+	}
+}
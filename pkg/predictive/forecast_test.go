@@ -0,0 +1,75 @@
+package predictive
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestForecastProjectsTrend(t *testing.T) {
+	h := Heuristic{}
+	slots := h.Forecast(80, -2, 60)
+	if len(slots) != 6 {
+		t.Fatalf("got %d slots for a 60 minute horizon, want 6", len(slots))
+	}
+	// The first slot midpoint is 5 minutes out: 80 - 2*5 = 70.
+	if slots[0].OffsetMin != 0 || slots[0].Score != 70 || slots[0].Quality != "good" {
+		t.Errorf("first slot = %+v", slots[0])
+	}
+	// Beyond trendHorizonMin the extrapolation stops: 80 - 2*15 = 50.
+	last := slots[len(slots)-1]
+	if last.OffsetMin != 50 || last.Score != 50 || last.Quality != "fair" {
+		t.Errorf("last slot = %+v", last)
+	}
+}
+
+func TestForecastScoreStaysInRange(t *testing.T) {
+	h := Heuristic{}
+	for _, s := range h.Forecast(5, -10, 60) {
+		if s.Score < 0 || s.Quality != "poor" {
+			t.Errorf("collapsing link slot = %+v", s)
+		}
+	}
+	for _, s := range h.Forecast(99, 10, 60) {
+		if s.Score > 100 {
+			t.Errorf("rising link slot = %+v", s)
+		}
+	}
+}
+
+func TestForecastAppliesBaselinePenalty(t *testing.T) {
+	b := NewBaseline(filepath.Join(t.TempDir(), "baseline.json"))
+	// Mark every weekday/hour slot fully degraded so the penalty applies
+	// whatever wall-clock hour the test runs at.
+	day := time.Date(2026, 3, 2, 0, 30, 0, 0, time.UTC)
+	for slot := 0; slot < 7*24; slot++ {
+		ts := day.Add(time.Duration(slot) * time.Hour)
+		for i := 0; i < baselineMinSamples; i++ {
+			b.Observe(true, ts)
+		}
+	}
+	with := Heuristic{Baseline: b}.Forecast(80, 0, 30)
+	without := Heuristic{}.Forecast(80, 0, 30)
+	for i := range with {
+		if want := without[i].Score - MaxPenalty; with[i].Score != want {
+			t.Errorf("slot %d score = %v, want %v", i, with[i].Score, want)
+		}
+	}
+}
+
+func TestForecastHorizonClamps(t *testing.T) {
+	h := Heuristic{}
+	if got := len(h.Forecast(80, 0, 0)); got != 6 {
+		t.Errorf("default horizon gave %d slots, want 6", got)
+	}
+	if got := len(h.Forecast(80, 0, 100000)); got != forecastMaxMin/forecastSlotMin {
+		t.Errorf("clamped horizon gave %d slots, want %d", got, forecastMaxMin/forecastSlotMin)
+	}
+}
+
+func TestFallbackForecastUsesHeuristic(t *testing.T) {
+	f := fallback{primary: Disabled{}, heuristic: Heuristic{}}
+	if slots := f.Forecast(80, 0, 30); len(slots) != 3 {
+		t.Errorf("fallback forecast gave %d slots, want 3", len(slots))
+	}
+}
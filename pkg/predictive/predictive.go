@@ -0,0 +1,129 @@
+// Package predictive estimates whether a member is about to fail before
+// hard thresholds are crossed, using short-horizon trend heuristics over
+// the telemetry window.
+package predictive
+
+import (
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/telemetry"
+)
+
+// Assessment is the engine-facing output: a score adjustment in
+// [-MaxPenalty, 0] plus a human-readable reason when non-zero.
+type Assessment struct {
+	Adjustment float64 `json:"adjustment"`
+	Reason     string  `json:"reason,omitempty"`
+}
+
+// MaxPenalty bounds how much the predictor may subtract from a score.
+const MaxPenalty = 25.0
+
+// Assess looks at the recent samples for one member. The heuristics are
+// deliberately simple: rising obstruction, rising latency slope, and
+// climbing loss each contribute a penalty proportional to the trend.
+func Assess(samples []telemetry.Sample) Assessment {
+	var a Assessment
+	if len(samples) == 0 {
+		return a
+	}
+
+	// Outage forecast: the starlink collector precomputes the probability
+	// of a micro-outage within the next ten minutes from dish history, so
+	// the latest sample alone is enough to act on.
+	if last := samples[len(samples)-1].Metrics; last != nil && last.OutageProbPct != nil {
+		if p := float64(*last.OutageProbPct); p >= 30 {
+			a.Adjustment -= min(p/5, 15)
+			a.Reason = "high chance of Starlink micro-outages"
+		}
+	}
+
+	if len(samples) < 6 {
+		return clampAssessment(a)
+	}
+	recent := samples[len(samples)-6:]
+
+	// Obstruction ramp: compare first and second half of the window.
+	if d := delta(recent, func(s telemetry.Sample) (float64, bool) {
+		if s.Metrics.ObstructionPct == nil {
+			return 0, false
+		}
+		return float64(*s.Metrics.ObstructionPct), true
+	}); d > 0.5 {
+		a.Adjustment -= min(d*4, 12)
+		if a.Reason != "" {
+			a.Reason += ", "
+		}
+		a.Reason += "obstruction rising"
+	}
+
+	// Latency slope.
+	if d := delta(recent, func(s telemetry.Sample) (float64, bool) {
+		if s.Metrics.LatencyMS == nil {
+			return 0, false
+		}
+		return float64(*s.Metrics.LatencyMS), true
+	}); d > 20 {
+		a.Adjustment -= min(d/10, 8)
+		if a.Reason != "" {
+			a.Reason += ", "
+		}
+		a.Reason += "latency trending up"
+	}
+
+	// Loss climbing.
+	if d := delta(recent, func(s telemetry.Sample) (float64, bool) {
+		if s.Metrics.LossPercent == nil {
+			return 0, false
+		}
+		return float64(*s.Metrics.LossPercent), true
+	}); d > 2 {
+		a.Adjustment -= min(d, 10)
+		if a.Reason != "" {
+			a.Reason += ", "
+		}
+		a.Reason += "loss climbing"
+	}
+
+	return clampAssessment(a)
+}
+
+// clampAssessment bounds the accumulated penalty at MaxPenalty.
+func clampAssessment(a Assessment) Assessment {
+	if a.Adjustment < -MaxPenalty {
+		a.Adjustment = -MaxPenalty
+	}
+	return a
+}
+
+// delta returns mean(second half) - mean(first half) of the extracted
+// values; 0 when too few values are present.
+func delta(samples []telemetry.Sample, get func(telemetry.Sample) (float64, bool)) float64 {
+	half := len(samples) / 2
+	m1, n1 := mean(samples[:half], get)
+	m2, n2 := mean(samples[half:], get)
+	if n1 == 0 || n2 == 0 {
+		return 0
+	}
+	return m2 - m1
+}
+
+func mean(samples []telemetry.Sample, get func(telemetry.Sample) (float64, bool)) (float64, int) {
+	var sum float64
+	n := 0
+	for _, s := range samples {
+		if v, ok := get(s); ok {
+			sum += v
+			n++
+		}
+	}
+	if n == 0 {
+		return 0, 0
+	}
+	return sum / float64(n), n
+}
+
+func min(a, b float64) float64 {
+	if a < b {
+		return a
+	}
+	return b
+}
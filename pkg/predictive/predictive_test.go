@@ -0,0 +1,41 @@
+package predictive
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/metrics"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/telemetry"
+)
+
+func TestAssessOutageForecast(t *testing.T) {
+	// A high outage probability acts on the latest sample alone, even
+	// before the trend heuristics have a full window.
+	samples := []telemetry.Sample{{
+		Member:  "starlink",
+		Metrics: &metrics.Metrics{OutageProbPct: metrics.Percent(80).Ptr()},
+	}}
+	a := Assess(samples)
+	if a.Adjustment >= 0 {
+		t.Fatalf("adjustment = %v, want negative", a.Adjustment)
+	}
+	if !strings.Contains(a.Reason, "micro-outages") {
+		t.Errorf("reason = %q, want micro-outage mention", a.Reason)
+	}
+}
+
+func TestAssessOutageForecastBelowThreshold(t *testing.T) {
+	samples := []telemetry.Sample{{
+		Member:  "starlink",
+		Metrics: &metrics.Metrics{OutageProbPct: metrics.Percent(10).Ptr()},
+	}}
+	if a := Assess(samples); a.Adjustment != 0 || a.Reason != "" {
+		t.Errorf("low forecast produced %+v, want neutral", a)
+	}
+}
+
+func TestAssessEmpty(t *testing.T) {
+	if a := Assess(nil); a.Adjustment != 0 {
+		t.Errorf("empty window adjustment = %v, want 0", a.Adjustment)
+	}
+}
@@ -0,0 +1,121 @@
+package probe
+
+// Probe marking and volume budgeting. Two problems with monitoring
+// traffic on real WANs: upstream QoS may deprioritize unmarked ICMP and
+// make the link look worse than user traffic sees it, and on metered
+// plans a month of probing is real money. DSCP marking fixes the first;
+// a per-member daily byte budget bounds the second. An exhausted budget
+// does not silence a member — one ping per target keeps the liveness
+// signal — it just stops the bulk of the volume.
+
+import (
+	"sync"
+	"time"
+)
+
+// bytesPerPing is the on-the-wire cost of one echo exchange: 84 bytes of
+// IP+ICMP+payload in each direction. Close enough for budgeting.
+const bytesPerPing = 168
+
+var (
+	budgetMu sync.Mutex
+	// probeTOS is the ToS byte passed to ping (-Q); zero leaves packets
+	// unmarked.
+	probeTOS int
+	// budgetBytes is the per-member daily allowance; zero disables.
+	budgetBytes int64
+	budgetDay   string
+	budgetUsed  map[string]int64
+)
+
+// SetDSCP installs the DSCP codepoint for probe packets (0-63; 0
+// unmarks). The ToS byte is the codepoint shifted into the upper six
+// bits.
+func SetDSCP(dscp int) {
+	if dscp < 0 || dscp > 63 {
+		return
+	}
+	budgetMu.Lock()
+	probeTOS = dscp << 2
+	budgetMu.Unlock()
+}
+
+// SetDailyBudget installs the per-member probe byte allowance per day;
+// zero or negative disables budgeting.
+func SetDailyBudget(bytes int64) {
+	budgetMu.Lock()
+	if bytes < 0 {
+		bytes = 0
+	}
+	budgetBytes = bytes
+	budgetMu.Unlock()
+}
+
+// tosValue returns the configured ToS byte.
+func tosValue() int {
+	budgetMu.Lock()
+	defer budgetMu.Unlock()
+	return probeTOS
+}
+
+// budgetCount clamps a probe's ping count for iface: full count while
+// the member is under budget, one once it is exhausted.
+func budgetCount(iface string, count int) int {
+	budgetMu.Lock()
+	defer budgetMu.Unlock()
+	if budgetBytes <= 0 || iface == "" {
+		return count
+	}
+	rolloverLocked()
+	if budgetUsed[iface] >= budgetBytes && count > 1 {
+		return 1
+	}
+	return count
+}
+
+// recordProbe charges sent pings against iface's budget.
+func recordProbe(iface string, sent int) {
+	if iface == "" || sent <= 0 {
+		return
+	}
+	budgetMu.Lock()
+	defer budgetMu.Unlock()
+	rolloverLocked()
+	if budgetUsed == nil {
+		budgetUsed = make(map[string]int64)
+	}
+	budgetUsed[iface] += int64(sent) * bytesPerPing
+}
+
+// rolloverLocked resets the counters at the local day boundary.
+func rolloverLocked() {
+	day := time.Now().Format("2006-01-02")
+	if day != budgetDay {
+		budgetDay = day
+		budgetUsed = make(map[string]int64)
+	}
+}
+
+// BudgetCounter is one member's probe volume for today.
+type BudgetCounter struct {
+	BytesToday int64 `json:"bytes_today"`
+	LimitBytes int64 `json:"limit_bytes,omitempty"`
+	Exhausted  bool  `json:"exhausted,omitempty"`
+}
+
+// BudgetCounters returns today's probe volume per interface; exposed
+// over ubus for plan accounting.
+func BudgetCounters() map[string]BudgetCounter {
+	budgetMu.Lock()
+	defer budgetMu.Unlock()
+	rolloverLocked()
+	out := make(map[string]BudgetCounter, len(budgetUsed))
+	for iface, used := range budgetUsed {
+		out[iface] = BudgetCounter{
+			BytesToday: used,
+			LimitBytes: budgetBytes,
+			Exhausted:  budgetBytes > 0 && used >= budgetBytes,
+		}
+	}
+	return out
+}
@@ -0,0 +1,103 @@
+package probe
+
+import "testing"
+
+// resetBudget restores the budgeting globals after a test.
+func resetBudget(t *testing.T) {
+	t.Helper()
+	budgetMu.Lock()
+	tos, limit, day, used := probeTOS, budgetBytes, budgetDay, budgetUsed
+	budgetMu.Unlock()
+	t.Cleanup(func() {
+		budgetMu.Lock()
+		probeTOS, budgetBytes, budgetDay, budgetUsed = tos, limit, day, used
+		budgetMu.Unlock()
+	})
+}
+
+func TestSetDSCP(t *testing.T) {
+	resetBudget(t)
+	SetDSCP(46) // EF
+	if got := tosValue(); got != 46<<2 {
+		t.Errorf("tosValue() = %d, want %d", got, 46<<2)
+	}
+	SetDSCP(0)
+	if got := tosValue(); got != 0 {
+		t.Errorf("tosValue() after SetDSCP(0) = %d, want 0", got)
+	}
+	SetDSCP(46)
+	SetDSCP(64) // out of range, ignored
+	SetDSCP(-1)
+	if got := tosValue(); got != 46<<2 {
+		t.Errorf("out-of-range SetDSCP changed value: got %d", got)
+	}
+}
+
+func TestBudgetCountClampsWhenExhausted(t *testing.T) {
+	resetBudget(t)
+	SetDailyBudget(10 * bytesPerPing)
+	if got := budgetCount("wan", 5); got != 5 {
+		t.Errorf("under budget: budgetCount = %d, want 5", got)
+	}
+	recordProbe("wan", 10)
+	if got := budgetCount("wan", 5); got != 1 {
+		t.Errorf("exhausted: budgetCount = %d, want 1", got)
+	}
+	// Other members keep their own allowance.
+	if got := budgetCount("mob1s1a1", 5); got != 5 {
+		t.Errorf("other member: budgetCount = %d, want 5", got)
+	}
+}
+
+func TestBudgetDisabledPassesThrough(t *testing.T) {
+	resetBudget(t)
+	SetDailyBudget(0)
+	recordProbe("wan", 1000)
+	if got := budgetCount("wan", 5); got != 5 {
+		t.Errorf("disabled budget: budgetCount = %d, want 5", got)
+	}
+	SetDailyBudget(-1)
+	if got := budgetCount("wan", 5); got != 5 {
+		t.Errorf("negative budget: budgetCount = %d, want 5", got)
+	}
+}
+
+func TestBudgetCounters(t *testing.T) {
+	resetBudget(t)
+	SetDailyBudget(3 * bytesPerPing)
+	budgetMu.Lock()
+	budgetUsed = nil
+	budgetMu.Unlock()
+	recordProbe("wan", 2)
+	recordProbe("wan", 2)
+	recordProbe("mob1s1a1", 1)
+	c := BudgetCounters()
+	if got := c["wan"].BytesToday; got != 4*bytesPerPing {
+		t.Errorf("wan bytes_today = %d, want %d", got, 4*bytesPerPing)
+	}
+	if !c["wan"].Exhausted {
+		t.Error("wan not marked exhausted at 4 of 3 pings")
+	}
+	if c["mob1s1a1"].Exhausted {
+		t.Error("mob1s1a1 marked exhausted under budget")
+	}
+	if got := c["mob1s1a1"].LimitBytes; got != 3*bytesPerPing {
+		t.Errorf("limit_bytes = %d, want %d", got, 3*bytesPerPing)
+	}
+}
+
+func TestBudgetRollover(t *testing.T) {
+	resetBudget(t)
+	SetDailyBudget(bytesPerPing)
+	recordProbe("wan", 5)
+	if got := budgetCount("wan", 5); got != 1 {
+		t.Fatalf("exhausted: budgetCount = %d, want 1", got)
+	}
+	// Pretend the counters were charged yesterday; the next check resets.
+	budgetMu.Lock()
+	budgetDay = "1970-01-01"
+	budgetMu.Unlock()
+	if got := budgetCount("wan", 5); got != 5 {
+		t.Errorf("after rollover: budgetCount = %d, want 5", got)
+	}
+}
@@ -0,0 +1,114 @@
+package probe
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Heavyweight probes — bandwidth tests and anything else that loads the
+// link instead of sampling it — skew every other member's scores when they
+// overlap: two simultaneous speed tests congest the shared router and each
+// reads the other's traffic as path degradation. The heavy scheduler
+// serializes them across members, lets urgent work pre-empt background
+// runs, and freezes everything around a failover so the settling window
+// measures the network, not our own tests.
+
+// HeavyPriority orders heavy probes; higher pre-empts lower.
+type HeavyPriority int
+
+const (
+	// HeavyBackground is for scheduled, repeatable work (periodic
+	// bandwidth probes); it yields to everything.
+	HeavyBackground HeavyPriority = iota
+	// HeavyDiagnostic is for operator-requested tests that should run
+	// now, pre-empting background work.
+	HeavyDiagnostic
+)
+
+// HeavyScheduler is the single slot heavy probes run in.
+type HeavyScheduler struct {
+	mu          sync.Mutex
+	holder      string
+	priority    HeavyPriority
+	cancel      context.CancelFunc
+	gen         uint64 // bumped per grant, so a stale release is a no-op
+	frozenUntil time.Time
+}
+
+// NewHeavyScheduler returns an empty scheduler.
+func NewHeavyScheduler() *HeavyScheduler { return &HeavyScheduler{} }
+
+// Freeze blocks new heavy probes until d from now and pre-empts one
+// already running; called around failovers so heavy traffic never skews
+// the settling window.
+func (s *HeavyScheduler) Freeze(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if until := time.Now().Add(d); until.After(s.frozenUntil) {
+		s.frozenUntil = until
+	}
+	if s.cancel != nil {
+		s.cancel()
+		s.cancel = nil
+		s.holder = ""
+		s.gen++
+	}
+}
+
+// Acquire claims the heavy slot for member. On success it returns a run
+// context — cancelled if the probe is later pre-empted — and a release
+// function the caller must invoke when done. It fails when the scheduler
+// is frozen or the slot is held at an equal or higher priority; a
+// higher-priority caller pre-empts the current holder instead.
+func (s *HeavyScheduler) Acquire(ctx context.Context, member string, prio HeavyPriority) (context.Context, func(), bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if time.Now().Before(s.frozenUntil) {
+		return nil, nil, false
+	}
+	if s.cancel != nil {
+		if prio <= s.priority {
+			return nil, nil, false
+		}
+		s.cancel() // pre-empt the lower-priority holder
+	}
+	runCtx, cancel := context.WithCancel(ctx)
+	s.holder = member
+	s.priority = prio
+	s.cancel = cancel
+	s.gen++
+	gen := s.gen
+	release := func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		// Only clear the slot if this grant still owns it: a pre-empting
+		// caller may have taken it before the release ran.
+		if s.gen == gen {
+			s.cancel()
+			s.cancel = nil
+			s.holder = ""
+		}
+	}
+	return runCtx, release, true
+}
+
+// Holder reports who currently owns the heavy slot ("" when idle).
+func (s *HeavyScheduler) Holder() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.holder
+}
+
+// The package-level scheduler mirrors SetMetered/SetWarming: collectors
+// and the engine share one instance without threading it through every
+// constructor.
+var heavy = NewHeavyScheduler()
+
+// AcquireHeavy claims the shared heavy slot; see HeavyScheduler.Acquire.
+func AcquireHeavy(ctx context.Context, member string, prio HeavyPriority) (context.Context, func(), bool) {
+	return heavy.Acquire(ctx, member, prio)
+}
+
+// FreezeHeavy freezes the shared scheduler; see HeavyScheduler.Freeze.
+func FreezeHeavy(d time.Duration) { heavy.Freeze(d) }
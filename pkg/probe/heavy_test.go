@@ -0,0 +1,78 @@
+package probe
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestHeavySlotIsExclusive(t *testing.T) {
+	s := NewHeavyScheduler()
+	_, release, ok := s.Acquire(context.Background(), "starlink", HeavyBackground)
+	if !ok {
+		t.Fatal("first acquire failed")
+	}
+	if _, _, ok := s.Acquire(context.Background(), "cellular", HeavyBackground); ok {
+		t.Error("second background acquire succeeded while slot held")
+	}
+	release()
+	if _, release2, ok := s.Acquire(context.Background(), "cellular", HeavyBackground); !ok {
+		t.Error("acquire after release failed")
+	} else {
+		release2()
+	}
+}
+
+func TestHeavyPreemption(t *testing.T) {
+	s := NewHeavyScheduler()
+	bgCtx, bgRelease, ok := s.Acquire(context.Background(), "starlink", HeavyBackground)
+	if !ok {
+		t.Fatal("background acquire failed")
+	}
+	_, diagRelease, ok := s.Acquire(context.Background(), "cellular", HeavyDiagnostic)
+	if !ok {
+		t.Fatal("diagnostic did not pre-empt background")
+	}
+	select {
+	case <-bgCtx.Done():
+	default:
+		t.Error("pre-empted background context not cancelled")
+	}
+	if got := s.Holder(); got != "cellular" {
+		t.Errorf("holder = %q, want cellular", got)
+	}
+	// The pre-empted probe's release must not free the diagnostic's slot.
+	bgRelease()
+	if got := s.Holder(); got != "cellular" {
+		t.Errorf("stale release cleared the slot (holder %q)", got)
+	}
+	// Equal priority does not pre-empt.
+	if _, _, ok := s.Acquire(context.Background(), "wifi", HeavyDiagnostic); ok {
+		t.Error("equal-priority acquire pre-empted the holder")
+	}
+	diagRelease()
+}
+
+func TestHeavyFreeze(t *testing.T) {
+	s := NewHeavyScheduler()
+	runCtx, release, ok := s.Acquire(context.Background(), "starlink", HeavyDiagnostic)
+	if !ok {
+		t.Fatal("acquire failed")
+	}
+	s.Freeze(time.Minute)
+	select {
+	case <-runCtx.Done():
+	default:
+		t.Error("freeze did not pre-empt the running probe")
+	}
+	release() // stale; must not panic or corrupt state
+	if _, _, ok := s.Acquire(context.Background(), "cellular", HeavyDiagnostic); ok {
+		t.Error("acquire succeeded during freeze")
+	}
+	s.frozenUntil = time.Now().Add(-time.Second)
+	if _, release2, ok := s.Acquire(context.Background(), "cellular", HeavyBackground); !ok {
+		t.Error("acquire failed after freeze expiry")
+	} else {
+		release2()
+	}
+}
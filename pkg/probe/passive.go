@@ -0,0 +1,140 @@
+// Passive dead-gateway detection. When the active member's gateway dies,
+// existing connections start failing well before the next scheduled probe
+// round notices: the kernel counts ICMP destination-unreachables, failed
+// connect attempts and resets of established connections. Sampling those
+// counters every second and flagging bursts gives the engine a trigger to
+// re-check immediately, cutting worst-case detection from a full poll
+// interval to about a second — without sending a single extra packet.
+
+package probe
+
+import (
+	"context"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/uci"
+)
+
+// Counter file paths; variables so tests can point at fixtures.
+var (
+	procSNMPPath    = "/proc/net/snmp"
+	procNetstatPath = "/proc/net/netstat"
+)
+
+// passiveHoldoff rate-limits burst triggers so a sustained failure pokes
+// the engine once, not every second while it plays out.
+const passiveHoldoff = 30 * time.Second
+
+// PassiveMonitor watches kernel failure counters for bursts.
+type PassiveMonitor struct {
+	// Threshold is the failure-event count within one sample interval
+	// that counts as a burst.
+	Threshold int
+	IntervalS int
+
+	onBurst     func(count int)
+	lastSum     uint64
+	haveLast    bool
+	lastTrigger time.Time
+}
+
+// NewPassiveFromUCI builds the monitor when starfail.passive.enabled is
+// on; starfail.passive.threshold tunes the burst size.
+func NewPassiveFromUCI() *PassiveMonitor {
+	if !uci.GetBool("starfail.passive.enabled", false) {
+		return nil
+	}
+	return &PassiveMonitor{
+		Threshold: uci.GetInt("starfail.passive.threshold", 10),
+		IntervalS: uci.GetInt("starfail.passive.interval", 1),
+	}
+}
+
+// OnBurst sets the callback invoked (rate-limited) when a burst is seen.
+func (p *PassiveMonitor) OnBurst(fn func(count int)) { p.onBurst = fn }
+
+// Run samples the counters until ctx is cancelled.
+func (p *PassiveMonitor) Run(ctx context.Context) {
+	interval := time.Duration(p.IntervalS) * time.Second
+	if interval <= 0 {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.sample(time.Now())
+		}
+	}
+}
+
+// sample takes one counter reading and fires onBurst when the delta since
+// the previous reading crosses the threshold.
+func (p *PassiveMonitor) sample(now time.Time) {
+	sum := failureSum()
+	if !p.haveLast || sum < p.lastSum { // first reading or counter reset
+		p.lastSum, p.haveLast = sum, true
+		return
+	}
+	delta := sum - p.lastSum
+	p.lastSum = sum
+	if delta < uint64(p.Threshold) {
+		return
+	}
+	if now.Sub(p.lastTrigger) < passiveHoldoff {
+		return
+	}
+	p.lastTrigger = now
+	log.Printf("probe: passive: %d connection failures in one interval", delta)
+	if p.onBurst != nil {
+		p.onBurst(int(delta))
+	}
+}
+
+// failureSum totals the kernel counters that spike when the default
+// gateway goes dark: ICMP destination unreachable, failed TCP connects,
+// resets of established connections, and aborts on retransmit timeout.
+func failureSum() uint64 {
+	var sum uint64
+	sum += procCounter(procSNMPPath, "Icmp:", "InDestUnreachs")
+	sum += procCounter(procSNMPPath, "Tcp:", "AttemptFails")
+	sum += procCounter(procSNMPPath, "Tcp:", "EstabResets")
+	sum += procCounter(procNetstatPath, "TcpExt:", "TCPAbortOnTimeout")
+	return sum
+}
+
+// procCounter extracts one named counter from a /proc/net/snmp-style
+// file, where a header line of field names is followed by a line of
+// values under the same prefix. Missing files or fields read as zero.
+func procCounter(path, prefix, field string) uint64 {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	lines := strings.Split(string(raw), "\n")
+	for i := 0; i+1 < len(lines); i++ {
+		if !strings.HasPrefix(lines[i], prefix) || !strings.HasPrefix(lines[i+1], prefix) {
+			continue
+		}
+		names := strings.Fields(lines[i])[1:]
+		values := strings.Fields(lines[i+1])[1:]
+		for j, name := range names {
+			if name != field || j >= len(values) {
+				continue
+			}
+			v, err := strconv.ParseUint(values[j], 10, 64)
+			if err != nil {
+				return 0
+			}
+			return v
+		}
+	}
+	return 0
+}
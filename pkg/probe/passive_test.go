@@ -0,0 +1,102 @@
+package probe
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeCounters lays down snmp/netstat fixtures with the given counter
+// values and points the monitor at them.
+func writeCounters(t *testing.T, dir string, unreach, attemptFails, estabResets, abortTimeout uint64) {
+	t.Helper()
+	snmp := fmt.Sprintf("Icmp: InMsgs InErrors InDestUnreachs\nIcmp: 100 0 %d\n"+
+		"Tcp: RtoAlgorithm AttemptFails EstabResets InSegs\nTcp: 1 %d %d 5000\n",
+		unreach, attemptFails, estabResets)
+	netstat := fmt.Sprintf("TcpExt: SyncookiesSent TCPAbortOnTimeout\nTcpExt: 0 %d\n", abortTimeout)
+	if err := os.WriteFile(filepath.Join(dir, "snmp"), []byte(snmp), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "netstat"), []byte(netstat), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func installPassiveFixtures(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	origSNMP, origNetstat := procSNMPPath, procNetstatPath
+	procSNMPPath = filepath.Join(dir, "snmp")
+	procNetstatPath = filepath.Join(dir, "netstat")
+	t.Cleanup(func() { procSNMPPath, procNetstatPath = origSNMP, origNetstat })
+	return dir
+}
+
+func TestPassiveBurstTriggers(t *testing.T) {
+	dir := installPassiveFixtures(t)
+	writeCounters(t, dir, 10, 5, 0, 0)
+
+	var bursts []int
+	p := &PassiveMonitor{Threshold: 10}
+	p.OnBurst(func(n int) { bursts = append(bursts, n) })
+
+	now := time.Now()
+	p.sample(now)                      // baseline
+	writeCounters(t, dir, 18, 9, 0, 0) // +12 failures
+	p.sample(now.Add(time.Second))
+	if len(bursts) != 1 || bursts[0] != 12 {
+		t.Fatalf("bursts = %v, want [12]", bursts)
+	}
+
+	// Another burst inside the holdoff is coalesced.
+	writeCounters(t, dir, 40, 20, 0, 0)
+	p.sample(now.Add(2 * time.Second))
+	if len(bursts) != 1 {
+		t.Errorf("burst inside holdoff fired: %v", bursts)
+	}
+
+	// After the holdoff it fires again.
+	writeCounters(t, dir, 60, 35, 0, 0)
+	p.sample(now.Add(2*time.Second + passiveHoldoff))
+	if len(bursts) != 2 {
+		t.Errorf("burst after holdoff did not fire: %v", bursts)
+	}
+}
+
+func TestPassiveBelowThreshold(t *testing.T) {
+	dir := installPassiveFixtures(t)
+	writeCounters(t, dir, 10, 0, 0, 0)
+	fired := 0
+	p := &PassiveMonitor{Threshold: 10}
+	p.OnBurst(func(int) { fired++ })
+	now := time.Now()
+	p.sample(now)
+	writeCounters(t, dir, 15, 2, 1, 1) // +9, below threshold
+	p.sample(now.Add(time.Second))
+	if fired != 0 {
+		t.Errorf("fired on a sub-threshold delta")
+	}
+}
+
+func TestPassiveCounterReset(t *testing.T) {
+	dir := installPassiveFixtures(t)
+	writeCounters(t, dir, 1000, 500, 0, 0)
+	fired := 0
+	p := &PassiveMonitor{Threshold: 10}
+	p.OnBurst(func(int) { fired++ })
+	now := time.Now()
+	p.sample(now)
+	writeCounters(t, dir, 5, 0, 0, 0) // counters went backwards (reboot)
+	p.sample(now.Add(time.Second))
+	if fired != 0 {
+		t.Errorf("fired on a counter reset")
+	}
+}
+
+func TestProcCounterMissingFile(t *testing.T) {
+	if v := procCounter("/nonexistent/snmp", "Tcp:", "AttemptFails"); v != 0 {
+		t.Errorf("missing file read %d, want 0", v)
+	}
+}
@@ -0,0 +1,75 @@
+package probe
+
+import (
+	"fmt"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Passive RTT estimation. While the active link is metered, probe volume
+// is cut to the bone (SetMetered) and a single ping per target is thin
+// evidence to score a member on. The kernel already maintains a smoothed
+// RTT for every established TCP connection, and `ss -ti` exposes it —
+// sampling those estimates rides on traffic the LAN is sending anyway,
+// so quality data keeps flowing without spending a byte of the data plan.
+
+// ssExec runs the ss binary for established TCP sockets with internal TCP
+// info; a variable so tests can feed canned output.
+var ssExec = func() (string, error) {
+	out, err := exec.Command("ss", "-H", "-t", "-i", "state", "established").Output()
+	if err != nil {
+		return "", fmt.Errorf("ss: %w", err)
+	}
+	return string(out), nil
+}
+
+// PassiveRTT returns the median smoothed RTT in milliseconds across the
+// established TCP connections the kernel is tracking, and how many
+// connections contributed. ok is false when ss fails or nothing usable
+// is connected (median of zero flows would be noise, not data).
+func PassiveRTT() (medianMS float64, samples int, ok bool) {
+	out, err := ssExec()
+	if err != nil {
+		return 0, 0, false
+	}
+	rtts := parseSSRTTs(out)
+	if len(rtts) == 0 {
+		return 0, 0, false
+	}
+	sort.Float64s(rtts)
+	mid := len(rtts) / 2
+	median := rtts[mid]
+	if len(rtts)%2 == 0 {
+		median = (rtts[mid-1] + rtts[mid]) / 2
+	}
+	return median, len(rtts), true
+}
+
+// parseSSRTTs pulls the smoothed RTT out of every "rtt:<srtt>/<var>"
+// token in ss -i output.
+func parseSSRTTs(out string) []float64 {
+	var rtts []float64
+	for _, field := range strings.Fields(out) {
+		if !strings.HasPrefix(field, "rtt:") {
+			continue
+		}
+		val := field[len("rtt:"):]
+		if i := strings.IndexByte(val, '/'); i >= 0 {
+			val = val[:i]
+		}
+		if v, err := strconv.ParseFloat(val, 64); err == nil && v > 0 {
+			rtts = append(rtts, v)
+		}
+	}
+	return rtts
+}
+
+// Metered reports whether probe conservation is currently on; collectors
+// use it to decide when a passive estimate is worth attaching.
+func Metered() bool {
+	profileMu.RLock()
+	defer profileMu.RUnlock()
+	return metered
+}
@@ -0,0 +1,61 @@
+package probe
+
+import (
+	"fmt"
+	"testing"
+)
+
+// ssOutput mimics `ss -H -t -i state established`: one socket line, one
+// info line with the tcp_info dump.
+func ssOutput(rtts ...float64) string {
+	out := ""
+	for i, rtt := range rtts {
+		out += fmt.Sprintf("0 0 192.168.1.%d:50000 203.0.113.9:443\n", i+2)
+		out += fmt.Sprintf("\t cubic wscale:7,7 rto:204 rtt:%g/4.5 ato:40 mss:1448 cwnd:10\n", rtt)
+	}
+	return out
+}
+
+func stubSS(t *testing.T, out string, err error) {
+	t.Helper()
+	orig := ssExec
+	ssExec = func() (string, error) { return out, err }
+	t.Cleanup(func() { ssExec = orig })
+}
+
+func TestPassiveRTTMedian(t *testing.T) {
+	stubSS(t, ssOutput(30, 120, 45), nil)
+	med, n, ok := PassiveRTT()
+	if !ok || n != 3 {
+		t.Fatalf("ok=%v n=%d", ok, n)
+	}
+	if med != 45 {
+		t.Errorf("median = %v, want 45", med)
+	}
+
+	// Even count: mean of the middle pair.
+	stubSS(t, ssOutput(30, 120, 40, 60), nil)
+	med, n, ok = PassiveRTT()
+	if !ok || n != 4 || med != 50 {
+		t.Errorf("even median = %v (n=%d ok=%v), want 50", med, n, ok)
+	}
+}
+
+func TestPassiveRTTNoFlows(t *testing.T) {
+	stubSS(t, "", nil)
+	if _, _, ok := PassiveRTT(); ok {
+		t.Error("ok with no established connections")
+	}
+	stubSS(t, "", fmt.Errorf("ss: not found"))
+	if _, _, ok := PassiveRTT(); ok {
+		t.Error("ok when ss fails")
+	}
+}
+
+func TestParseSSRTTsIgnoresGarbage(t *testing.T) {
+	out := "garbage rtt:abc/1 rtt:-5/1 rtt:12.5/3.2 minrtt:3\n"
+	rtts := parseSSRTTs(out)
+	if len(rtts) != 1 || rtts[0] != 12.5 {
+		t.Errorf("rtts = %v, want [12.5]", rtts)
+	}
+}
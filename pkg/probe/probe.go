@@ -0,0 +1,101 @@
+// Package probe measures reachability of a WAN member by pinging well-known
+// anycast targets out of a specific interface.
+package probe
+
+import (
+	"context"
+	"math"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DefaultTargets are probed when a member has no explicit targets configured.
+var DefaultTargets = []string{"1.1.1.1", "8.8.8.8"}
+
+// Result is the aggregate outcome of one probe round against one target.
+type Result struct {
+	Target      string  `json:"target"`
+	Sent        int     `json:"sent"`
+	Received    int     `json:"received"`
+	LatencyMS   float64 `json:"latency_ms"`
+	JitterMS    float64 `json:"jitter_ms"`
+	LossPercent float64 `json:"loss_percent"`
+
+	// One-way estimates, filled only by reflector rounds against a
+	// timestamping reflector (zero for plain echo and ping); see
+	// reflector.go for how the shared clock offset is removed.
+	UplinkMS      float64 `json:"uplink_ms,omitempty"`
+	DownlinkMS    float64 `json:"downlink_ms,omitempty"`
+	ClockOffsetMS float64 `json:"clock_offset_ms,omitempty"`
+}
+
+var rttRe = regexp.MustCompile(`time=([0-9.]+) ms`)
+
+// Ping sends count echo requests to target out of iface using the system
+// ping binary (busybox compatible flags only) and parses per-reply RTTs.
+func Ping(ctx context.Context, iface, target string, count int) (Result, error) {
+	count = budgetCount(iface, count)
+	res := Result{Target: target, Sent: count}
+	args := []string{"-c", strconv.Itoa(count), "-W", "2"}
+	if tos := tosValue(); tos > 0 {
+		args = append(args, "-Q", strconv.Itoa(tos))
+	}
+	if iface != "" {
+		args = append(args, "-I", iface)
+	}
+	args = append(args, target)
+	out, _ := exec.CommandContext(ctx, "ping", args...).Output()
+	recordProbe(iface, count)
+	// busybox ping exits non-zero on any loss; parse whatever we got.
+	var rtts []float64
+	for _, m := range rttRe.FindAllStringSubmatch(string(out), -1) {
+		if v, perr := strconv.ParseFloat(m[1], 64); perr == nil {
+			rtts = append(rtts, v)
+		}
+	}
+	return aggregate(res, rtts), nil
+}
+
+// aggregate fills a Result's latency/jitter/loss from per-reply RTTs.
+func aggregate(res Result, rtts []float64) Result {
+	res.Received = len(rtts)
+	if len(rtts) == 0 {
+		res.LossPercent = 100
+		return res
+	}
+	var sum float64
+	for _, v := range rtts {
+		sum += v
+	}
+	res.LatencyMS = sum / float64(len(rtts))
+	var dev float64
+	for _, v := range rtts {
+		dev += math.Abs(v - res.LatencyMS)
+	}
+	res.JitterMS = dev / float64(len(rtts))
+	res.LossPercent = 100 * float64(res.Sent-len(rtts)) / float64(res.Sent)
+	return res
+}
+
+// ParsePingSummary extracts "N packets transmitted, M received" from ping
+// output; exported for the shell-compat tooling.
+func ParsePingSummary(out string) (sent, recv int, ok bool) {
+	for _, line := range strings.Split(out, "\n") {
+		if !strings.Contains(line, "packets transmitted") {
+			continue
+		}
+		f := strings.Fields(line)
+		if len(f) < 4 {
+			return 0, 0, false
+		}
+		s, err1 := strconv.Atoi(f[0])
+		r, err2 := strconv.Atoi(f[3])
+		if err1 != nil || err2 != nil {
+			return 0, 0, false
+		}
+		return s, r, true
+	}
+	return 0, 0, false
+}
@@ -0,0 +1,137 @@
+package probe
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Target is one probe destination with a relative weight used when
+// aggregating results across a profile.
+type Target struct {
+	Host   string  `json:"host"`
+	Weight float64 `json:"weight"`
+}
+
+// Profile is the ordered target set probed for one member class.
+type Profile []Target
+
+// ParseTarget parses the UCI list entry form "host" or "host@weight".
+func ParseTarget(s string) Target {
+	t := Target{Host: s, Weight: 1}
+	if i := strings.LastIndexByte(s, '@'); i > 0 {
+		if w, err := strconv.ParseFloat(s[i+1:], 64); err == nil && w > 0 {
+			t.Host = s[:i]
+			t.Weight = w
+		}
+	}
+	return t
+}
+
+var (
+	profileMu    sync.RWMutex
+	profiles     map[string]Profile
+	metered      bool
+	meteredCount = 1
+	warming      map[string]bool
+)
+
+// warmupBoost multiplies pings per target for interfaces in warm-up: a
+// link that is up but still unstable shows its loss much sooner under a
+// denser schedule.
+const warmupBoost = 3
+
+// SetMetered switches probe volume conservation on or off: while on,
+// CountFor caps pings per target at count. The engine flips this when the
+// active member is a metered (cellular) link.
+func SetMetered(on bool, count int) {
+	if count < 1 {
+		count = 1
+	}
+	profileMu.Lock()
+	metered = on
+	meteredCount = count
+	profileMu.Unlock()
+}
+
+// SetWarming installs the interfaces currently in warm-up; the engine
+// refreshes the set each tick.
+func SetWarming(ifaces []string) {
+	set := make(map[string]bool, len(ifaces))
+	for _, i := range ifaces {
+		set[i] = true
+	}
+	profileMu.Lock()
+	warming = set
+	profileMu.Unlock()
+}
+
+// CountFor returns the pings per target for one interface given a
+// collector's normal count: reduced while the active link is metered
+// (conservation wins over warm-up boosting), tripled while the interface
+// itself is warming up.
+func CountFor(iface string, normal int) int {
+	profileMu.RLock()
+	defer profileMu.RUnlock()
+	if metered && meteredCount < normal {
+		return meteredCount
+	}
+	if warming[iface] {
+		return normal * warmupBoost
+	}
+	return normal
+}
+
+// SetProfiles installs the per-class profiles from configuration; called at
+// startup and on config reload.
+func SetProfiles(p map[string]Profile) {
+	profileMu.Lock()
+	profiles = p
+	profileMu.Unlock()
+}
+
+// ProfileFor returns the profile for a member class, falling back to the
+// default anycast targets with equal weight.
+func ProfileFor(class string) Profile {
+	profileMu.RLock()
+	p, ok := profiles[class]
+	profileMu.RUnlock()
+	if ok && len(p) > 0 {
+		return p
+	}
+	def := make(Profile, 0, len(DefaultTargets))
+	for _, h := range DefaultTargets {
+		def = append(def, Target{Host: h, Weight: 1})
+	}
+	return def
+}
+
+// Run probes every target in the profile through iface and returns the
+// weighted aggregate plus the per-target results for diagnostics.
+func Run(ctx context.Context, iface string, p Profile, count int) (Result, []Result) {
+	agg := Result{Target: "aggregate"}
+	var perTarget []Result
+	var wsum, lat, jit, loss float64
+	for _, t := range p {
+		res, err := Ping(ctx, iface, t.Host, count)
+		if err != nil {
+			continue
+		}
+		perTarget = append(perTarget, res)
+		agg.Sent += res.Sent
+		agg.Received += res.Received
+		wsum += t.Weight
+		lat += t.Weight * res.LatencyMS
+		jit += t.Weight * res.JitterMS
+		loss += t.Weight * res.LossPercent
+	}
+	if wsum == 0 {
+		agg.LossPercent = 100
+		return agg, perTarget
+	}
+	agg.LatencyMS = lat / wsum
+	agg.JitterMS = jit / wsum
+	agg.LossPercent = loss / wsum
+	return agg, perTarget
+}
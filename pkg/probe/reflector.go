@@ -0,0 +1,253 @@
+package probe
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/uci"
+)
+
+// A reflector is a user-run UDP echo service (a few lines of socat or
+// netcat on a VPS). Probing one fixed endpoint over every member at the
+// same moment gives apples-to-apples latency/loss comparisons; anycast
+// targets like 1.1.1.1 resolve to different POPs per carrier and can make
+// a slower link look faster.
+//
+// Datagram layout (big endian): magic(4) seq(4) t1(8) t2(8) t3(8), times
+// in unix nanoseconds. A plain echo reflector returns t2/t3 as the zeros
+// we sent and only round-trip stats are produced. A timestamping reflector
+// fills t2 (its receive time) and t3 (its send time), NTP-style, which
+// lets Probe split the delay into uplink and downlink: the raw one-way
+// readings include the unknown offset between our clock and the VPS's, but
+// that offset is identical for every member probed in the same round, so
+// the per-round median asymmetry is taken as the clock offset and removed.
+// What remains makes an uplink dying ahead of the downlink (the usual
+// Starlink failure mode during video calls) directly visible.
+
+// reflectorMagic marks our datagrams so stray UDP traffic is ignored.
+const reflectorMagic = 0x53464c52 // "SFLR"
+
+// reflectorPktLen is the full timestamped datagram size.
+const reflectorPktLen = 32
+
+// Reflector probes one echo endpoint over each member in parallel.
+type Reflector struct {
+	Addr      string // host:port of the echo service
+	Count     int    // datagrams per member per round
+	IntervalS int    // seconds between rounds
+}
+
+// NewReflectorFromUCI builds a Reflector from starfail.reflector.host /
+// port / count / interval. Returns nil when no host is configured.
+func NewReflectorFromUCI() *Reflector {
+	host, ok := uci.Get("starfail.reflector.host")
+	if !ok || host == "" {
+		return nil
+	}
+	port := uci.GetInt("starfail.reflector.port", 19000)
+	return &Reflector{
+		Addr:      net.JoinHostPort(host, strconv.Itoa(port)),
+		Count:     uci.GetInt("starfail.reflector.count", 5),
+		IntervalS: uci.GetInt("starfail.reflector.interval", 60),
+	}
+}
+
+// Probe runs one round over every member simultaneously. ifaces maps member
+// name to its interface; the result map has one entry per member.
+func (r *Reflector) Probe(ctx context.Context, ifaces map[string]string) map[string]Result {
+	out := make(map[string]Result, len(ifaces))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for member, iface := range ifaces {
+		wg.Add(1)
+		go func(member, iface string) {
+			defer wg.Done()
+			res := reflectorExchange(ctx, iface, r.Addr, r.Count)
+			mu.Lock()
+			out[member] = res
+			mu.Unlock()
+		}(member, iface)
+	}
+	wg.Wait()
+	normalizeOneWay(out)
+	return out
+}
+
+// normalizeOneWay removes the shared client/reflector clock offset from the
+// raw one-way readings of one round. The offset is taken as the median
+// per-member asymmetry (up-down)/2 — every member shares the same clocks,
+// so the median is the offset unless most links are asymmetric the same
+// way. Cross-member differences survive untouched.
+func normalizeOneWay(results map[string]Result) {
+	var offsets []float64
+	for _, res := range results {
+		if res.UplinkMS == 0 && res.DownlinkMS == 0 {
+			continue
+		}
+		offsets = append(offsets, (res.UplinkMS-res.DownlinkMS)/2)
+	}
+	if len(offsets) == 0 {
+		return
+	}
+	sort.Float64s(offsets)
+	theta := offsets[len(offsets)/2]
+	for name, res := range results {
+		if res.UplinkMS == 0 && res.DownlinkMS == 0 {
+			continue
+		}
+		res.ClockOffsetMS = theta
+		res.UplinkMS -= theta
+		res.DownlinkMS += theta
+		if res.UplinkMS < 0 {
+			res.UplinkMS = 0
+		}
+		if res.DownlinkMS < 0 {
+			res.DownlinkMS = 0
+		}
+		results[name] = res
+	}
+}
+
+// Run probes every IntervalS until ctx is cancelled, passing each round's
+// results to report. members is re-evaluated per round so runtime member
+// changes are picked up.
+func (r *Reflector) Run(ctx context.Context, members func() map[string]string, report func(map[string]Result)) {
+	interval := time.Duration(r.IntervalS) * time.Second
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			ifaces := members()
+			if len(ifaces) == 0 {
+				continue
+			}
+			report(r.Probe(ctx, ifaces))
+		}
+	}
+}
+
+// reflectorExchange is a stub var for tests.
+var reflectorExchange = exchange
+
+// exchange sends count timestamped datagrams out of iface and measures the
+// echoes. Total loss is a result, not an error, matching Ping.
+func exchange(ctx context.Context, iface, addr string, count int) Result {
+	res := Result{Target: addr, Sent: count}
+	d := net.Dialer{Control: bindToDevice(iface)}
+	conn, err := d.DialContext(ctx, "udp", addr)
+	if err != nil {
+		res.LossPercent = 100
+		return res
+	}
+	defer conn.Close()
+	var rtts, ups, downs []float64
+	buf := make([]byte, 64)
+	for i := 0; i < count; i++ {
+		var pkt [reflectorPktLen]byte
+		binary.BigEndian.PutUint32(pkt[0:4], reflectorMagic)
+		binary.BigEndian.PutUint32(pkt[4:8], uint32(i))
+		start := time.Now()
+		binary.BigEndian.PutUint64(pkt[8:16], uint64(start.UnixNano()))
+		if _, err := conn.Write(pkt[:]); err != nil {
+			continue
+		}
+		_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		n, err := conn.Read(buf)
+		if err != nil || n < 16 || binary.BigEndian.Uint32(buf[0:4]) != reflectorMagic {
+			continue
+		}
+		t4 := time.Now()
+		rtts = append(rtts, float64(t4.Sub(start).Microseconds())/1000)
+		if n >= reflectorPktLen {
+			t2 := int64(binary.BigEndian.Uint64(buf[16:24]))
+			t3 := int64(binary.BigEndian.Uint64(buf[24:32]))
+			if t2 != 0 && t3 != 0 {
+				// Raw one-way readings, still containing the clock offset;
+				// Probe removes it once all members have reported.
+				ups = append(ups, float64(t2-start.UnixNano())/1e6)
+				downs = append(downs, float64(t4.UnixNano()-t3)/1e6)
+			}
+		}
+	}
+	res = aggregate(res, rtts)
+	if len(ups) > 0 {
+		res.UplinkMS = mean(ups)
+		res.DownlinkMS = mean(downs)
+	}
+	return res
+}
+
+// Failure-direction classification. Starlink in heavy rain often
+// degrades one direction first — the uplink, with its smaller antenna
+// and power budget, usually ahead of the downlink — and "the link is
+// bad" hides which half. The normalized one-way delays make the
+// direction visible: when one side's delay dwarfs the other's, that side
+// is the failing one.
+const (
+	// DirectionUplink and DirectionDownlink name the failing direction;
+	// an empty direction means symmetric or indeterminate.
+	DirectionUplink   = "uplink"
+	DirectionDownlink = "downlink"
+
+	// asymmetryFactor and asymmetryFloorMS bound the classification: the
+	// worse direction must exceed the better by the factor and by the
+	// floor in absolute terms, so jitter on a fast link never reads as a
+	// directional failure.
+	asymmetryFactor  = 3.0
+	asymmetryFloorMS = 50.0
+)
+
+// FailureDirection classifies one member's reflector result as an
+// uplink- or downlink-dominant degradation, "" when the delays are
+// symmetric or the result carries no one-way readings (plain echo
+// reflector, total loss).
+func FailureDirection(r Result) string {
+	if r.UplinkMS == 0 && r.DownlinkMS == 0 {
+		return ""
+	}
+	switch {
+	case r.UplinkMS > r.DownlinkMS*asymmetryFactor && r.UplinkMS-r.DownlinkMS > asymmetryFloorMS:
+		return DirectionUplink
+	case r.DownlinkMS > r.UplinkMS*asymmetryFactor && r.DownlinkMS-r.UplinkMS > asymmetryFloorMS:
+		return DirectionDownlink
+	}
+	return ""
+}
+
+func mean(vals []float64) float64 {
+	var sum float64
+	for _, v := range vals {
+		sum += v
+	}
+	return sum / float64(len(vals))
+}
+
+// bindToDevice returns a dialer Control binding the socket to iface via
+// SO_BINDTODEVICE; "" (tests) means no binding.
+func bindToDevice(iface string) func(network, address string, c syscall.RawConn) error {
+	if iface == "" {
+		return nil
+	}
+	return func(_, _ string, c syscall.RawConn) error {
+		var serr error
+		err := c.Control(func(fd uintptr) {
+			serr = syscall.SetsockoptString(int(fd), syscall.SOL_SOCKET, syscall.SO_BINDTODEVICE, iface)
+		})
+		if err != nil {
+			return err
+		}
+		return serr
+	}
+}
@@ -0,0 +1,137 @@
+package probe
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+)
+
+// startEchoReflector runs the reflector contract (echo every datagram back)
+// on loopback, like the socat one-liner a user would run on a VPS.
+func startEchoReflector(t *testing.T) string {
+	t.Helper()
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = pc.Close() })
+	go func() {
+		buf := make([]byte, 64)
+		for {
+			n, addr, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			_, _ = pc.WriteTo(buf[:n], addr)
+		}
+	}()
+	return pc.LocalAddr().String()
+}
+
+func TestReflectorProbeAllMembers(t *testing.T) {
+	addr := startEchoReflector(t)
+	r := &Reflector{Addr: addr, Count: 3}
+	results := r.Probe(context.Background(), map[string]string{
+		"starlink": "", // "" skips SO_BINDTODEVICE, which needs root
+		"cellular": "",
+	})
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2", len(results))
+	}
+	for member, res := range results {
+		if res.Received != 3 || res.LossPercent != 0 {
+			t.Errorf("%s: received %d, loss %.0f%%; want 3 and 0%%",
+				member, res.Received, res.LossPercent)
+		}
+		if res.LatencyMS <= 0 || res.LatencyMS > 100 {
+			t.Errorf("%s: latency %.2fms implausible for loopback", member, res.LatencyMS)
+		}
+	}
+}
+
+// startTimestampingReflector fills t2/t3 like the NTP-style reflector,
+// with its clock skewed ahead of ours to make offset removal observable.
+func startTimestampingReflector(t *testing.T, skew time.Duration) string {
+	t.Helper()
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = pc.Close() })
+	go func() {
+		buf := make([]byte, 64)
+		for {
+			n, addr, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if n >= reflectorPktLen {
+				now := uint64(time.Now().Add(skew).UnixNano())
+				binary.BigEndian.PutUint64(buf[16:24], now)
+				binary.BigEndian.PutUint64(buf[24:32], now)
+			}
+			_, _ = pc.WriteTo(buf[:n], addr)
+		}
+	}()
+	return pc.LocalAddr().String()
+}
+
+func TestReflectorOneWayEstimates(t *testing.T) {
+	skew := 40 * time.Millisecond
+	addr := startTimestampingReflector(t, skew)
+	r := &Reflector{Addr: addr, Count: 3}
+	results := r.Probe(context.Background(), map[string]string{
+		"starlink": "",
+		"cellular": "",
+	})
+	for member, res := range results {
+		if res.ClockOffsetMS < 35 || res.ClockOffsetMS > 45 {
+			t.Errorf("%s: clock offset %.2fms, want ~40", member, res.ClockOffsetMS)
+		}
+		// With the offset removed, loopback one-way delays are near zero.
+		if res.UplinkMS > 5 || res.DownlinkMS > 5 {
+			t.Errorf("%s: uplink %.2fms downlink %.2fms, want <5 on loopback",
+				member, res.UplinkMS, res.DownlinkMS)
+		}
+	}
+}
+
+func TestReflectorPlainEchoHasNoOneWay(t *testing.T) {
+	addr := startEchoReflector(t)
+	r := &Reflector{Addr: addr, Count: 2}
+	results := r.Probe(context.Background(), map[string]string{"m": ""})
+	res := results["m"]
+	if res.UplinkMS != 0 || res.DownlinkMS != 0 || res.ClockOffsetMS != 0 {
+		t.Errorf("plain echo produced one-way estimates: %+v", res)
+	}
+}
+
+func TestReflectorProbeUnreachable(t *testing.T) {
+	// A port nothing listens on: every datagram is lost.
+	r := &Reflector{Addr: "127.0.0.1:9", Count: 2}
+	results := r.Probe(context.Background(), map[string]string{"m": ""})
+	if res := results["m"]; res.LossPercent != 100 || res.Received != 0 {
+		t.Errorf("result = %+v, want total loss", res)
+	}
+}
+
+func TestFailureDirection(t *testing.T) {
+	cases := []struct {
+		name string
+		res  Result
+		want string
+	}{
+		{"uplink dominant", Result{UplinkMS: 400, DownlinkMS: 30}, DirectionUplink},
+		{"downlink dominant", Result{UplinkMS: 25, DownlinkMS: 300}, DirectionDownlink},
+		{"symmetric", Result{UplinkMS: 40, DownlinkMS: 35}, ""},
+		{"asymmetric but tiny", Result{UplinkMS: 9, DownlinkMS: 2}, ""},
+		{"no one-way data", Result{LatencyMS: 50}, ""},
+	}
+	for _, tc := range cases {
+		if got := FailureDirection(tc.res); got != tc.want {
+			t.Errorf("%s: FailureDirection = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+// TLS handshake probing. Corporate, campground and marina WiFi uplinks
+// frequently intercept HTTPS with a filtering middlebox; ICMP and even plain
+// TCP look healthy while every TLS session is terminated by someone else's
+// certificate. The probe completes a handshake against a well-known host per
+// member, records whether the presented chain verifies against the system
+// roots, and reports the leaf issuer so a MITM is visible by name.
+
+package probe
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/uci"
+)
+
+// TLSResult is the outcome of one handshake probe on one member.
+type TLSResult struct {
+	Host     string `json:"host"`
+	OK       bool   `json:"ok"`                 // handshake completed and chain verified
+	Filtered bool   `json:"filtered,omitempty"` // handshake completed but chain did not verify: MITM/filtering
+	Issuer   string `json:"issuer,omitempty"`   // leaf certificate issuer, whoever it turned out to be
+	Error    string `json:"error,omitempty"`
+}
+
+// TLSProbe performs periodic handshake probes against one host.
+type TLSProbe struct {
+	Host      string // hostname to handshake with (also the SNI and chain DNS name)
+	Port      int
+	IntervalS int
+
+	// roots overrides the system cert pool in tests.
+	roots *x509.CertPool
+}
+
+// NewTLSProbeFromUCI builds a TLSProbe from starfail.tlsprobe.host / port /
+// interval. Returns nil when no host is configured.
+func NewTLSProbeFromUCI() *TLSProbe {
+	host, ok := uci.Get("starfail.tlsprobe.host")
+	if !ok || host == "" {
+		return nil
+	}
+	return &TLSProbe{
+		Host:      host,
+		Port:      uci.GetInt("starfail.tlsprobe.port", 443),
+		IntervalS: uci.GetInt("starfail.tlsprobe.interval", 300),
+	}
+}
+
+// Probe runs one handshake out of iface. The handshake itself is done with
+// verification disabled so an intercepting certificate is captured rather
+// than discarded; the chain is then verified manually against the roots.
+func (p *TLSProbe) Probe(ctx context.Context, iface string) TLSResult {
+	res := TLSResult{Host: p.Host}
+	d := net.Dialer{Control: bindToDevice(iface), Timeout: 10 * time.Second}
+	raw, err := d.DialContext(ctx, "tcp", net.JoinHostPort(p.Host, strconv.Itoa(p.Port)))
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	defer raw.Close()
+	conn := tls.Client(raw, &tls.Config{
+		ServerName:         p.Host,
+		InsecureSkipVerify: true, // chain checked below so the issuer survives
+	})
+	if err := conn.HandshakeContext(ctx); err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	certs := conn.ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		res.Error = "no peer certificate"
+		return res
+	}
+	res.Issuer = certs[0].Issuer.CommonName
+	if res.Issuer == "" {
+		res.Issuer = certs[0].Issuer.String()
+	}
+	inter := x509.NewCertPool()
+	for _, c := range certs[1:] {
+		inter.AddCert(c)
+	}
+	_, err = certs[0].Verify(x509.VerifyOptions{
+		DNSName:       p.Host,
+		Roots:         p.roots,
+		Intermediates: inter,
+	})
+	if err != nil {
+		res.Filtered = true
+		res.Error = err.Error()
+		return res
+	}
+	res.OK = true
+	return res
+}
+
+// Run probes every member each IntervalS until ctx is cancelled, passing
+// each round's results to report. members is re-evaluated per round, same as
+// Reflector.Run.
+func (p *TLSProbe) Run(ctx context.Context, members func() map[string]string, report func(map[string]TLSResult)) {
+	interval := time.Duration(p.IntervalS) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			ifaces := members()
+			if len(ifaces) == 0 {
+				continue
+			}
+			out := make(map[string]TLSResult, len(ifaces))
+			for member, iface := range ifaces {
+				out[member] = p.Probe(ctx, iface)
+			}
+			report(out)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package probe
+
+import (
+	"context"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+// tlsProbeFor points a TLSProbe at a httptest TLS server.
+func tlsProbeFor(t *testing.T, srv *httptest.Server) *TLSProbe {
+	t.Helper()
+	host, portStr, err := net.SplitHostPort(srv.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("split addr: %v", err)
+	}
+	port, _ := strconv.Atoi(portStr)
+	return &TLSProbe{Host: host, Port: port}
+}
+
+func TestTLSProbeDetectsUntrustedChain(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	// The httptest certificate is self-signed: exactly what a filtering
+	// middlebox looks like to a client that trusts only the system roots.
+	res := tlsProbeFor(t, srv).Probe(context.Background(), "")
+	if res.OK {
+		t.Error("self-signed chain verified against system roots")
+	}
+	if !res.Filtered {
+		t.Errorf("completed handshake with untrusted chain not flagged: %+v", res)
+	}
+	if res.Issuer == "" {
+		t.Error("issuer not captured from intercepting certificate")
+	}
+}
+
+func TestTLSProbeTrustedChainIsOK(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	p := tlsProbeFor(t, srv)
+	p.roots = x509.NewCertPool()
+	p.roots.AddCert(srv.Certificate())
+	res := p.Probe(context.Background(), "")
+	if !res.OK || res.Filtered {
+		t.Errorf("trusted chain rejected: %+v", res)
+	}
+}
+
+func TestTLSProbeUnreachable(t *testing.T) {
+	p := &TLSProbe{Host: "127.0.0.1", Port: 9} // discard port, nothing listens
+	res := p.Probe(context.Background(), "")
+	if res.OK || res.Filtered {
+		t.Errorf("unreachable host produced a verdict: %+v", res)
+	}
+	if res.Error == "" {
+		t.Error("no error recorded for failed dial")
+	}
+}
@@ -0,0 +1,208 @@
+// Package report compiles daily and weekly operational summaries from
+// telemetry: per-member availability, switch counts, downtime, and the
+// reasons behind switches. Reports are stored as JSON for ubus retrieval
+// and condensed into a notification.
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/engine"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/notifications"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/telemetry"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/uci"
+)
+
+// MemberSummary aggregates one member over the report period.
+type MemberSummary struct {
+	Samples    int     `json:"samples"`
+	UpRatio    float64 `json:"up_ratio"` // fraction of samples at/above the fail score
+	AvgScore   float64 `json:"avg_score"`
+	AvgLatency float64 `json:"avg_latency_ms"`
+}
+
+// Report is one generated summary.
+type Report struct {
+	Period        string                   `json:"period"` // daily | weekly
+	GeneratedAt   time.Time                `json:"generated_at"`
+	Since         time.Time                `json:"since"`
+	Members       map[string]MemberSummary `json:"members"`
+	Switches      int                      `json:"switches"`
+	DowntimeS     int                      `json:"downtime_s"` // no member above fail score
+	SwitchReasons map[string]int           `json:"switch_reasons,omitempty"`
+}
+
+// Generator produces and schedules reports.
+type Generator struct {
+	cfg      *uci.Config
+	eng      *engine.Engine
+	store    *telemetry.Store
+	notifier *notifications.Notifier
+	dir      string
+}
+
+// NewGenerator returns a Generator writing reports under dir ("" for the
+// telemetry default).
+func NewGenerator(cfg *uci.Config, eng *engine.Engine, store *telemetry.Store, notifier *notifications.Notifier, dir string) *Generator {
+	if dir == "" {
+		dir = telemetry.DefaultDir
+	}
+	return &Generator{cfg: cfg, eng: eng, store: store, notifier: notifier, dir: dir}
+}
+
+// Generate builds a report covering the given period ("daily" = 24h,
+// "weekly" = 7d) from whatever history the store still holds.
+func (g *Generator) Generate(period string) *Report {
+	dur := 24 * time.Hour
+	if period == "weekly" {
+		dur = 7 * 24 * time.Hour
+	}
+	since := time.Now().Add(-dur)
+	r := &Report{
+		Period:        period,
+		GeneratedAt:   time.Now(),
+		Since:         since,
+		Members:       make(map[string]MemberSummary),
+		SwitchReasons: make(map[string]int),
+	}
+	for _, m := range g.eng.Members() {
+		samples := g.store.Samples(m.Name, 0)
+		var sum MemberSummary
+		var scoreSum, latSum float64
+		latN := 0
+		for _, s := range samples {
+			if s.Metrics.Timestamp.Before(since) {
+				continue
+			}
+			sum.Samples++
+			scoreSum += s.Score
+			if s.Score >= g.cfg.FailScore {
+				sum.UpRatio++ // counted; normalized below
+			}
+			if s.Metrics.LatencyMS != nil {
+				latSum += float64(*s.Metrics.LatencyMS)
+				latN++
+			}
+		}
+		if sum.Samples > 0 {
+			sum.AvgScore = scoreSum / float64(sum.Samples)
+			sum.UpRatio /= float64(sum.Samples)
+		}
+		if latN > 0 {
+			sum.AvgLatency = latSum / float64(latN)
+		}
+		r.Members[m.Name] = sum
+	}
+	for _, ev := range g.store.Events(0) {
+		if ev.Timestamp.Before(since) {
+			continue
+		}
+		switch ev.Type {
+		case "failover", "manual_failover":
+			r.Switches++
+			if ev.Reason != "" {
+				r.SwitchReasons[ev.Reason]++
+			}
+		}
+	}
+	r.DowntimeS = g.downtime(since)
+	return r
+}
+
+// downtime estimates seconds during which no member was above the fail
+// score, from the per-sample history.
+func (g *Generator) downtime(since time.Time) int {
+	// Bucket sample scores per tick timestamp (rounded to the poll
+	// interval) across members; a bucket where every member is below the
+	// fail score counts as one interval of downtime.
+	interval := g.cfg.PollIntervalS
+	if interval <= 0 {
+		interval = 5
+	}
+	type bucket struct{ any, up bool }
+	buckets := make(map[int64]*bucket)
+	for _, m := range g.eng.Members() {
+		for _, s := range g.store.Samples(m.Name, 0) {
+			if s.Metrics.Timestamp.Before(since) {
+				continue
+			}
+			key := s.Metrics.Timestamp.Unix() / int64(interval)
+			b := buckets[key]
+			if b == nil {
+				b = &bucket{}
+				buckets[key] = b
+			}
+			b.any = true
+			if s.Score >= g.cfg.FailScore {
+				b.up = true
+			}
+		}
+	}
+	down := 0
+	for _, b := range buckets {
+		if b.any && !b.up {
+			down += interval
+		}
+	}
+	return down
+}
+
+// Save writes the report JSON atomically and returns its path.
+func (g *Generator) Save(r *Report) (string, error) {
+	if err := os.MkdirAll(g.dir, 0o755); err != nil {
+		return "", err
+	}
+	b, err := json.Marshal(r)
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(g.dir, "report-"+r.Period+".json")
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return "", err
+	}
+	return path, os.Rename(tmp, path)
+}
+
+// Load returns the stored report for a period, if any.
+func (g *Generator) Load(period string) (*Report, error) {
+	b, err := os.ReadFile(filepath.Join(g.dir, "report-"+period+".json"))
+	if err != nil {
+		return nil, err
+	}
+	var r Report
+	if err := json.Unmarshal(b, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// Summary renders the one-paragraph notification text for a report.
+func Summary(r *Report) string {
+	var names []string
+	for name := range r.Members {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var parts []string
+	for _, name := range names {
+		m := r.Members[name]
+		if m.Samples == 0 {
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%s %.1f%% up", name, m.UpRatio*100))
+	}
+	period := r.Period
+	if period != "" {
+		period = strings.ToUpper(period[:1]) + period[1:]
+	}
+	return fmt.Sprintf("%s report: %s; %d switches, %s downtime.",
+		period, strings.Join(parts, ", "), r.Switches,
+		(time.Duration(r.DowntimeS) * time.Second).String())
+}
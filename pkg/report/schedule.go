@@ -0,0 +1,50 @@
+package report
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/gps"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/notifications"
+)
+
+// Run generates the daily report shortly after local midnight and the
+// weekly report on Monday mornings, sending each as a low-priority
+// notification, until ctx is cancelled. "Local" follows the GPS-derived
+// zone, so the day boundary moves with the vehicle.
+func (g *Generator) Run(ctx context.Context) {
+	for {
+		next := nextDaily(time.Now().In(gps.LocalLocation()))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Until(next)):
+		}
+		g.emit("daily")
+		if next.Weekday() == time.Monday {
+			g.emit("weekly")
+		}
+	}
+}
+
+// emit generates, stores, and notifies one report.
+func (g *Generator) emit(period string) {
+	r := g.Generate(period)
+	if _, err := g.Save(r); err != nil {
+		log.Printf("report: save %s: %v", period, err)
+	}
+	if g.notifier != nil {
+		g.notifier.SendNotification("Starfail "+period+" report", Summary(r),
+			notifications.PriorityLow)
+	}
+}
+
+// nextDaily returns the next 00:05 local time after now.
+func nextDaily(now time.Time) time.Time {
+	next := time.Date(now.Year(), now.Month(), now.Day(), 0, 5, 0, 0, now.Location())
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
@@ -0,0 +1,218 @@
+// Package roam shortens the cellular dead time after a border crossing.
+// A roaming SIM tends to cling to the old country's network until the
+// modem's own (slow) reselection gives up, and its preferred-PLMN list
+// often latches a partner carrier with poor coverage once it does. The
+// watcher polls the serving network and forces an operator rescan
+// (AT+COPS deregister + automatic reselect, via gsmctl like everything
+// else modem-related) in two situations: the modem has lost service and
+// GPS shows the router has travelled far since it last had any, or the
+// serving country (MCC) changed while roaming and a fresh scan should
+// pick the best network in the new country rather than whatever the
+// modem latched onto first.
+package roam
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/collector/cellular"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/gps"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/uci"
+)
+
+// minRescanGap rate-limits rescans: a scan itself drops the link for
+// 30-60 seconds, so a watcher gone wrong must not flap the modem.
+const minRescanGap = 5 * time.Minute
+
+// currentFix is a stub var for tests.
+var currentFix = gps.Current
+
+// Watcher polls modem registration and decides when to force a rescan.
+type Watcher struct {
+	interval  time.Duration
+	moveKm    float64
+	deadAfter time.Duration
+
+	// OnRescan, when set, is called after each triggered rescan so the
+	// daemon can record a telemetry event.
+	OnRescan func(reason string)
+
+	lastMCC    string
+	lastRegFix *gps.Fix
+	unregSince time.Time
+	lastRescan time.Time
+}
+
+// NewFromUCI returns a Watcher when starfail.roam.enabled is on, nil
+// otherwise.
+func NewFromUCI() *Watcher {
+	if !uci.GetBool("starfail.roam.enabled", false) {
+		return nil
+	}
+	return &Watcher{
+		interval:  time.Duration(uci.GetInt("starfail.roam.interval_s", 60)) * time.Second,
+		moveKm:    uci.GetFloat("starfail.roam.move_km", 30),
+		deadAfter: time.Duration(uci.GetInt("starfail.roam.dead_s", 180)) * time.Second,
+	}
+}
+
+// Run polls until ctx is cancelled.
+func (w *Watcher) Run(ctx context.Context) {
+	t := time.NewTicker(w.interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			w.poll(ctx, time.Now())
+		}
+	}
+}
+
+// registration is one snapshot of the modem's network state.
+type registration struct {
+	Registered bool
+	Roaming    bool
+	MCC        string
+}
+
+// poll takes one registration snapshot and applies the two triggers.
+func (w *Watcher) poll(ctx context.Context, now time.Time) {
+	reg := readRegistration(ctx)
+	if reg.Registered {
+		w.unregSince = time.Time{}
+		if fix, err := currentFix(ctx); err == nil && fix.Valid {
+			w.lastRegFix = fix
+		}
+		// MCC change while roaming: we crossed a border and the modem has
+		// already camped somewhere — rescan so it picks the best network
+		// in the new country, not the first partner it found.
+		if reg.Roaming && reg.MCC != "" && w.lastMCC != "" && reg.MCC != w.lastMCC {
+			w.rescan(ctx, now, fmt.Sprintf("serving country changed (MCC %s -> %s)", w.lastMCC, reg.MCC))
+		}
+		if reg.MCC != "" {
+			w.lastMCC = reg.MCC
+		}
+		return
+	}
+	if w.unregSince.IsZero() {
+		w.unregSince = now
+		return
+	}
+	// No service. GPS distance since the last registered fix is the fast
+	// path — far from where coverage ended means a new country's networks
+	// are worth scanning for now, not whenever the modem gets around to it.
+	if fix, err := currentFix(ctx); err == nil && fix.Valid && w.lastRegFix != nil {
+		if d := distanceKm(w.lastRegFix.Latitude, w.lastRegFix.Longitude, fix.Latitude, fix.Longitude); d >= w.moveKm {
+			w.rescan(ctx, now, fmt.Sprintf("no service after moving %.0f km", d))
+			return
+		}
+	}
+	if now.Sub(w.unregSince) >= w.deadAfter {
+		w.rescan(ctx, now, fmt.Sprintf("no service for %s", now.Sub(w.unregSince).Round(time.Second)))
+	}
+}
+
+// rescan forces operator reselection: deregister, then automatic mode,
+// which makes the modem run a full scan instead of walking its stale
+// preferred-PLMN list.
+func (w *Watcher) rescan(ctx context.Context, now time.Time, reason string) {
+	if !w.lastRescan.IsZero() && now.Sub(w.lastRescan) < minRescanGap {
+		return
+	}
+	w.lastRescan = now
+	w.unregSince = time.Time{} // the scan restarts the clock
+	log.Printf("roam: forcing operator rescan: %s", reason)
+	if _, err := cellular.Exec(ctx, "-A", "AT+COPS=2"); err != nil {
+		log.Printf("roam: deregister: %v", err)
+	}
+	if _, err := cellular.Exec(ctx, "-A", "AT+COPS=0"); err != nil {
+		log.Printf("roam: reselect: %v", err)
+	}
+	if w.OnRescan != nil {
+		w.OnRescan(reason)
+	}
+}
+
+// readRegistration queries CREG for registration/roaming and COPS (in
+// numeric format) for the serving PLMN.
+func readRegistration(ctx context.Context) registration {
+	var reg registration
+	out, err := cellular.Exec(ctx, "-A", "AT+CREG?")
+	if err != nil {
+		return reg
+	}
+	stat, ok := parseCREG(out)
+	if !ok || (stat != 1 && stat != 5) {
+		return reg
+	}
+	reg.Registered = true
+	reg.Roaming = stat == 5
+	// Numeric operator format, then query; the modem remembers the format
+	// so repeating the set each poll is harmless.
+	_, _ = cellular.Exec(ctx, "-A", "AT+COPS=3,2")
+	if out, err := cellular.Exec(ctx, "-A", "AT+COPS?"); err == nil {
+		if plmn, ok := parseCOPSPLMN(out); ok {
+			reg.MCC = plmn[:3]
+		}
+	}
+	return reg
+}
+
+// parseCREG extracts <stat> from a +CREG: <n>,<stat>[,...] reply.
+func parseCREG(out string) (int, bool) {
+	i := strings.Index(out, "+CREG:")
+	if i < 0 {
+		return 0, false
+	}
+	fields := strings.Split(out[i+len("+CREG:"):], ",")
+	if len(fields) < 2 {
+		return 0, false
+	}
+	stat, err := strconv.Atoi(strings.TrimSpace(strings.SplitN(fields[1], "\n", 2)[0]))
+	if err != nil {
+		return 0, false
+	}
+	return stat, true
+}
+
+// parseCOPSPLMN extracts the quoted numeric PLMN from a +COPS reply,
+// e.g. +COPS: 0,2,"24001",7. ok is false for alphanumeric operator names
+// (wrong format) or replies without an operator.
+func parseCOPSPLMN(out string) (string, bool) {
+	start := strings.IndexByte(out, '"')
+	if start < 0 {
+		return "", false
+	}
+	end := strings.IndexByte(out[start+1:], '"')
+	if end < 0 {
+		return "", false
+	}
+	plmn := out[start+1 : start+1+end]
+	if len(plmn) < 5 || len(plmn) > 6 {
+		return "", false
+	}
+	for _, r := range plmn {
+		if r < '0' || r > '9' {
+			return "", false
+		}
+	}
+	return plmn, true
+}
+
+// distanceKm is the haversine great-circle distance.
+func distanceKm(lat1, lon1, lat2, lon2 float64) float64 {
+	const earthRadiusKm = 6371
+	rad := math.Pi / 180
+	dLat := (lat2 - lat1) * rad
+	dLon := (lon2 - lon1) * rad
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*rad)*math.Cos(lat2*rad)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(a))
+}
@@ -0,0 +1,185 @@
+package roam
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/collector/cellular"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/gps"
+)
+
+// fakeModem answers the AT queries the watcher issues and records the
+// COPS commands it receives.
+type fakeModem struct {
+	creg     string // +CREG reply
+	cops     string // +COPS? reply
+	commands []string
+}
+
+func installFakeModem(t *testing.T, m *fakeModem) {
+	t.Helper()
+	orig := cellular.Exec
+	cellular.Exec = func(_ context.Context, args ...string) (string, error) {
+		if len(args) != 2 || args[0] != "-A" {
+			return "", errors.New("unexpected gsmctl invocation")
+		}
+		switch {
+		case args[1] == "AT+CREG?":
+			return m.creg, nil
+		case args[1] == "AT+COPS?":
+			return m.cops, nil
+		default:
+			m.commands = append(m.commands, args[1])
+			return "OK", nil
+		}
+	}
+	t.Cleanup(func() { cellular.Exec = orig })
+}
+
+func installFix(t *testing.T, fix *gps.Fix, err error) {
+	t.Helper()
+	orig := currentFix
+	currentFix = func(context.Context) (*gps.Fix, error) { return fix, err }
+	t.Cleanup(func() { currentFix = orig })
+}
+
+func newTestWatcher() *Watcher {
+	return &Watcher{interval: time.Minute, moveKm: 30, deadAfter: 3 * time.Minute}
+}
+
+func rescanCommands(m *fakeModem) []string {
+	var out []string
+	for _, c := range m.commands {
+		if c == "AT+COPS=2" || c == "AT+COPS=0" {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func TestBorderCrossingTriggersRescan(t *testing.T) {
+	m := &fakeModem{creg: `+CREG: 0,5`, cops: `+COPS: 0,2,"24001",7`}
+	installFakeModem(t, m)
+	installFix(t, &gps.Fix{Valid: true, Latitude: 59.3, Longitude: 18.1}, nil)
+
+	w := newTestWatcher()
+	var reasons []string
+	w.OnRescan = func(r string) { reasons = append(reasons, r) }
+
+	now := time.Now()
+	w.poll(context.Background(), now) // learns MCC 240, no rescan yet
+	if len(reasons) != 0 {
+		t.Fatalf("rescan before any MCC change: %v", reasons)
+	}
+	m.cops = `+COPS: 0,2,"24201",7` // Norwegian PLMN
+	w.poll(context.Background(), now.Add(time.Minute))
+	if len(reasons) != 1 || !strings.Contains(reasons[0], "240 -> 242") {
+		t.Fatalf("reasons = %v, want one MCC-change rescan", reasons)
+	}
+	if got := rescanCommands(m); len(got) != 2 || got[0] != "AT+COPS=2" || got[1] != "AT+COPS=0" {
+		t.Errorf("modem commands = %v, want deregister then automatic", m.commands)
+	}
+}
+
+func TestHomeNetworkMCCChangeDoesNotRescan(t *testing.T) {
+	// stat 1 = registered on the home network; an MCC change here is a
+	// SIM swap or modem quirk, not a border crossing to act on.
+	m := &fakeModem{creg: `+CREG: 0,1`, cops: `+COPS: 0,2,"24001",7`}
+	installFakeModem(t, m)
+	installFix(t, &gps.Fix{Valid: true}, nil)
+
+	w := newTestWatcher()
+	w.OnRescan = func(string) { t.Error("rescan on a home-network SIM") }
+	now := time.Now()
+	w.poll(context.Background(), now)
+	m.cops = `+COPS: 0,2,"24201",7`
+	w.poll(context.Background(), now.Add(time.Minute))
+}
+
+func TestMovedWithoutServiceTriggersRescan(t *testing.T) {
+	m := &fakeModem{creg: `+CREG: 0,5`, cops: `+COPS: 0,2,"24001",7`}
+	installFakeModem(t, m)
+	fix := &gps.Fix{Valid: true, Latitude: 59.0, Longitude: 18.0}
+	installFix(t, fix, nil)
+
+	w := newTestWatcher()
+	var reasons []string
+	w.OnRescan = func(r string) { reasons = append(reasons, r) }
+
+	now := time.Now()
+	w.poll(context.Background(), now) // registered: remembers the fix
+	m.creg = `+CREG: 0,2`             // searching
+	w.poll(context.Background(), now.Add(time.Minute))
+	if len(reasons) != 0 {
+		t.Fatalf("rescan without movement: %v", reasons)
+	}
+	// ~55 km north of the last registered position.
+	installFix(t, &gps.Fix{Valid: true, Latitude: 59.5, Longitude: 18.0}, nil)
+	w.poll(context.Background(), now.Add(2*time.Minute))
+	if len(reasons) != 1 || !strings.Contains(reasons[0], "no service after moving") {
+		t.Fatalf("reasons = %v, want one movement rescan", reasons)
+	}
+}
+
+func TestDeadTimerRescanIsRateLimited(t *testing.T) {
+	m := &fakeModem{creg: `+CREG: 0,2`}
+	installFakeModem(t, m)
+	installFix(t, nil, errors.New("no gps"))
+
+	w := newTestWatcher()
+	var reasons []string
+	w.OnRescan = func(r string) { reasons = append(reasons, r) }
+
+	now := time.Now()
+	w.poll(context.Background(), now) // starts the dead timer
+	w.poll(context.Background(), now.Add(3*time.Minute))
+	if len(reasons) != 1 {
+		t.Fatalf("reasons = %v, want one dead-timer rescan", reasons)
+	}
+	// Still dead: the timer restarts after the scan and its next expiry
+	// falls inside minRescanGap, so the rescan is held...
+	w.poll(context.Background(), now.Add(4*time.Minute))
+	w.poll(context.Background(), now.Add(7*time.Minute))
+	if len(reasons) != 1 {
+		t.Fatalf("rate limit did not hold: %v", reasons)
+	}
+	// ...until the gap has passed.
+	w.poll(context.Background(), now.Add(10*time.Minute))
+	if len(reasons) != 2 {
+		t.Fatalf("reasons = %v, want a second rescan after the gap", reasons)
+	}
+}
+
+func TestParseCREG(t *testing.T) {
+	cases := []struct {
+		in   string
+		stat int
+		ok   bool
+	}{
+		{`+CREG: 0,5`, 5, true},
+		{`AT+CREG?` + "\r\n" + `+CREG: 2,1,"00A1","01234567",7` + "\r\nOK", 1, true},
+		{`+CREG: 0`, 0, false},
+		{`ERROR`, 0, false},
+	}
+	for _, c := range cases {
+		stat, ok := parseCREG(c.in)
+		if ok != c.ok || (ok && stat != c.stat) {
+			t.Errorf("parseCREG(%q) = (%d, %v), want (%d, %v)", c.in, stat, ok, c.stat, c.ok)
+		}
+	}
+}
+
+func TestParseCOPSPLMN(t *testing.T) {
+	if plmn, ok := parseCOPSPLMN(`+COPS: 0,2,"24001",7`); !ok || plmn != "24001" {
+		t.Errorf("numeric PLMN = (%q, %v)", plmn, ok)
+	}
+	if _, ok := parseCOPSPLMN(`+COPS: 0,0,"Telia SE",7`); ok {
+		t.Error("alphanumeric operator parsed as a PLMN")
+	}
+	if _, ok := parseCOPSPLMN(`+COPS: 2`); ok {
+		t.Error("deregistered reply parsed as a PLMN")
+	}
+}
@@ -0,0 +1,161 @@
+// Package secrets centralizes credential handling: UCI-stored tokens and
+// passwords are read through it, optionally decrypted from an at-rest
+// encrypted form, and every value it has seen is scrubbed from log and
+// diagnostic output so credentials never leak off the router.
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/uci"
+)
+
+// encPrefix marks a UCI value as encrypted at rest; the remainder is
+// base64(nonce || AES-GCM ciphertext) under the device-derived key.
+const encPrefix = "enc:"
+
+// Redacted replaces secret values in scrubbed output.
+const Redacted = "[REDACTED]"
+
+// minRedactLen keeps trivial strings ("1", "on") out of the redaction
+// set, where they would mangle ordinary log lines.
+const minRedactLen = 6
+
+var (
+	mu    sync.RWMutex
+	known []string
+)
+
+// deviceIdentity returns stable per-device material for the at-rest key:
+// the RUTOS serial number when available, else the machine id. A variable
+// so tests can pin it.
+var deviceIdentity = func() string {
+	if out, err := exec.Command("mnf_info", "sn").Output(); err == nil {
+		if sn := strings.TrimSpace(string(out)); sn != "" {
+			return sn
+		}
+	}
+	if b, err := os.ReadFile("/etc/machine-id"); err == nil {
+		return strings.TrimSpace(string(b))
+	}
+	host, _ := os.Hostname()
+	return host
+}
+
+// Register adds plaintext secrets to the redaction set.
+func Register(values ...string) {
+	mu.Lock()
+	defer mu.Unlock()
+	for _, v := range values {
+		if len(v) < minRedactLen {
+			continue
+		}
+		known = append(known, v)
+	}
+}
+
+// Redact scrubs every registered secret from s.
+func Redact(s string) string {
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, v := range known {
+		s = strings.ReplaceAll(s, v, Redacted)
+	}
+	return s
+}
+
+// Get reads a UCI option through the secret layer: encrypted values are
+// decrypted with the device key, and whatever comes out is registered for
+// redaction. Use this instead of uci.Get for anything credential-shaped.
+func Get(key string) (string, bool) {
+	v, ok := uci.Get(key)
+	if !ok {
+		return "", false
+	}
+	if strings.HasPrefix(v, encPrefix) {
+		plain, err := decrypt(strings.TrimPrefix(v, encPrefix))
+		if err != nil {
+			// An undecryptable secret is unusable; treat it as unset
+			// rather than handing ciphertext to an API call.
+			return "", false
+		}
+		v = plain
+	}
+	Register(v)
+	return v, true
+}
+
+// Encrypt produces the at-rest form of a secret for storing in UCI
+// (starfailctl secret encrypt). Decryption only works on the same device.
+func Encrypt(plaintext string) (string, error) {
+	gcm, err := deviceCipher()
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return encPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func decrypt(encoded string) (string, error) {
+	gcm, err := deviceCipher()
+	if err != nil {
+		return "", err
+	}
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", err
+	}
+	if len(raw) < gcm.NonceSize() {
+		return "", fmt.Errorf("ciphertext too short")
+	}
+	plain, err := gcm.Open(nil, raw[:gcm.NonceSize()], raw[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
+
+// deviceCipher derives the AES-GCM cipher from the device identity. The
+// salt just separates this use from any other hash of the serial.
+func deviceCipher() (cipher.AEAD, error) {
+	key := sha256.Sum256([]byte("starfail-secrets-v1:" + deviceIdentity()))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// writer scrubs registered secrets from everything written through it.
+type writer struct {
+	dst io.Writer
+}
+
+// NewWriter wraps dst (typically the log output) with redaction.
+func NewWriter(dst io.Writer) io.Writer {
+	return &writer{dst: dst}
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	scrubbed := Redact(string(p))
+	if _, err := w.dst.Write([]byte(scrubbed)); err != nil {
+		return 0, err
+	}
+	// Report the original length: callers account for what they passed
+	// in, not for what redaction substituted.
+	return len(p), nil
+}
@@ -0,0 +1,83 @@
+package secrets
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/uci"
+)
+
+func pinIdentity(t *testing.T) {
+	t.Helper()
+	orig := deviceIdentity
+	deviceIdentity = func() string { return "TEST-SERIAL-0001" }
+	t.Cleanup(func() {
+		deviceIdentity = orig
+		mu.Lock()
+		known = nil
+		mu.Unlock()
+	})
+}
+
+func TestRedact(t *testing.T) {
+	pinIdentity(t)
+	Register("tok-supersecret", "x") // "x" is too short to register
+	if got := Redact("sending tok-supersecret to api"); !strings.Contains(got, Redacted) {
+		t.Errorf("Redact = %q", got)
+	}
+	if got := Redact("x marks the spot"); got != "x marks the spot" {
+		t.Errorf("short value was redacted: %q", got)
+	}
+}
+
+func TestEncryptRoundTrip(t *testing.T) {
+	pinIdentity(t)
+	enc, err := Encrypt("hunter2hunter2")
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	if !strings.HasPrefix(enc, encPrefix) {
+		t.Fatalf("missing prefix: %q", enc)
+	}
+
+	values := map[string]string{"starfail.notify.pushover_token": enc}
+	origExec := uci.Exec
+	uci.Exec = func(args ...string) (string, error) {
+		if len(args) == 3 && args[1] == "get" {
+			if v, ok := values[args[2]]; ok {
+				return v, nil
+			}
+		}
+		return "", fmt.Errorf("not found")
+	}
+	t.Cleanup(func() { uci.Exec = origExec })
+
+	got, ok := Get("starfail.notify.pushover_token")
+	if !ok || got != "hunter2hunter2" {
+		t.Fatalf("Get = %q, %v", got, ok)
+	}
+	// The decrypted value is now registered for redaction.
+	if Redact("token is hunter2hunter2") == "token is hunter2hunter2" {
+		t.Error("decrypted secret not redacted")
+	}
+
+	// Tampering or a different device key must fail closed.
+	deviceIdentity = func() string { return "OTHER-DEVICE" }
+	if _, ok := Get("starfail.notify.pushover_token"); ok {
+		t.Error("Get succeeded with the wrong device key")
+	}
+}
+
+func TestWriterScrubsLogs(t *testing.T) {
+	pinIdentity(t)
+	Register("tok-supersecret")
+	var buf bytes.Buffer
+	l := log.New(NewWriter(&buf), "", 0)
+	l.Printf("posting token=%s", "tok-supersecret")
+	if strings.Contains(buf.String(), "tok-supersecret") {
+		t.Errorf("log output leaked the secret: %q", buf.String())
+	}
+}
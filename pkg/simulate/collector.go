@@ -0,0 +1,83 @@
+package simulate
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/metrics"
+)
+
+// Collector serves samples from a scenario. One instance is registered per
+// member class present in the scenario; members are looked up by interface
+// name, which in simulation equals the member name.
+type Collector struct {
+	class   string
+	scripts map[string]*MemberScript // by member name
+	start   time.Time
+}
+
+// NewCollectors builds one simulated collector per class in the scenario,
+// all sharing the same start time.
+func NewCollectors(sc *Scenario) []*Collector {
+	start := time.Now()
+	byClass := make(map[string]*Collector)
+	for i := range sc.Members {
+		m := &sc.Members[i]
+		c, ok := byClass[m.Class]
+		if !ok {
+			c = &Collector{class: m.Class, scripts: make(map[string]*MemberScript), start: start}
+			byClass[m.Class] = c
+		}
+		c.scripts[m.Name] = m
+	}
+	out := make([]*Collector, 0, len(byClass))
+	for _, c := range byClass {
+		out = append(out, c)
+	}
+	return out
+}
+
+// Class implements collector.Collector.
+func (c *Collector) Class() string { return c.class }
+
+// Collect implements collector.Collector by sampling the member's timeline
+// at the current offset.
+func (c *Collector) Collect(_ context.Context, iface string) (*metrics.Metrics, error) {
+	script, ok := c.scripts[iface]
+	if !ok {
+		return nil, fmt.Errorf("no scripted member %q", iface)
+	}
+	step := stepAt(script.Timeline, int(time.Since(c.start).Seconds()))
+	m := &metrics.Metrics{Timestamp: time.Now(), DataSource: metrics.SourceSimulated}
+	if step.LatencyMS != nil {
+		m.LatencyMS = metrics.Milliseconds(*step.LatencyMS).Ptr()
+	}
+	if step.LossPercent != nil {
+		m.LossPercent = metrics.Percent(*step.LossPercent).Ptr()
+	}
+	if step.JitterMS != nil {
+		m.JitterMS = metrics.Milliseconds(*step.JitterMS).Ptr()
+	}
+	if step.ObstructionPct != nil {
+		m.ObstructionPct = metrics.Percent(*step.ObstructionPct).Ptr()
+		m.ObstructionValid = true
+	}
+	if step.RSRP != nil {
+		m.RSRP = metrics.DBm(*step.RSRP).Ptr()
+	}
+	return m, nil
+}
+
+// stepAt returns the last step whose offset has passed; before the first
+// step the first step's values apply.
+func stepAt(timeline []Step, offset int) Step {
+	cur := timeline[0]
+	for _, s := range timeline {
+		if s.AtS > offset {
+			break
+		}
+		cur = s
+	}
+	return cur
+}
@@ -0,0 +1,184 @@
+// Package simulate replaces the real collectors with scripted metric
+// timelines so the full daemon — engine, telemetry, notifications — can be
+// exercised on a development machine with no ubus, modem, or dish.
+//
+// Scenarios are YAML files; only the subset needed for the schema below is
+// parsed (two-space indentation, "- " list items, scalar values):
+//
+//	name: rain fade
+//	members:
+//	  - name: starlink
+//	    class: starlink
+//	    timeline:
+//	      - at: 0
+//	        latency_ms: 40
+//	        loss_percent: 0
+//	        obstruction_pct: 0.5
+//	      - at: 120
+//	        latency_ms: 350
+//	        loss_percent: 12
+//
+// Each timeline step takes effect at its "at" offset (seconds from daemon
+// start) and holds until the next step.
+package simulate
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Step is one point on a member's metric timeline.
+type Step struct {
+	AtS            int
+	LatencyMS      *float64
+	LossPercent    *float64
+	JitterMS       *float64
+	ObstructionPct *float64
+	RSRP           *float64
+}
+
+// MemberScript is the scripted behaviour of one member.
+type MemberScript struct {
+	Name     string
+	Class    string
+	Timeline []Step
+}
+
+// Scenario is a parsed scenario file.
+type Scenario struct {
+	Name    string
+	Members []MemberScript
+}
+
+// LoadScenario reads and parses a scenario file.
+func LoadScenario(path string) (*Scenario, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseScenario(string(b))
+}
+
+// ParseScenario parses scenario YAML (see package comment for the subset).
+func ParseScenario(src string) (*Scenario, error) {
+	sc := &Scenario{}
+	var member *MemberScript
+	var step *Step
+	inTimeline := false
+	for ln, raw := range strings.Split(src, "\n") {
+		line := strings.TrimRight(raw, " \t")
+		trimmed := strings.TrimLeft(line, " ")
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		indent := len(line) - len(trimmed)
+		isItem := strings.HasPrefix(trimmed, "- ")
+		if isItem {
+			trimmed = trimmed[2:]
+		}
+		key, val, ok := cutKV(trimmed)
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key: value", ln+1)
+		}
+		switch {
+		case indent == 0 && key == "name":
+			sc.Name = val
+		case indent == 0 && key == "members":
+			// header only
+		case isItem && indent == 2: // new member
+			sc.Members = append(sc.Members, MemberScript{})
+			member = &sc.Members[len(sc.Members)-1]
+			inTimeline = false
+			if err := setMemberField(member, key, val); err != nil {
+				return nil, fmt.Errorf("line %d: %w", ln+1, err)
+			}
+		case member != nil && !isItem && indent == 4 && key == "timeline":
+			inTimeline = true
+		case member != nil && !isItem && indent == 4:
+			if err := setMemberField(member, key, val); err != nil {
+				return nil, fmt.Errorf("line %d: %w", ln+1, err)
+			}
+		case member != nil && inTimeline && isItem && indent == 6: // new step
+			member.Timeline = append(member.Timeline, Step{})
+			step = &member.Timeline[len(member.Timeline)-1]
+			if err := setStepField(step, key, val); err != nil {
+				return nil, fmt.Errorf("line %d: %w", ln+1, err)
+			}
+		case step != nil && !isItem && indent == 8:
+			if err := setStepField(step, key, val); err != nil {
+				return nil, fmt.Errorf("line %d: %w", ln+1, err)
+			}
+		default:
+			return nil, fmt.Errorf("line %d: unexpected indentation", ln+1)
+		}
+	}
+	if len(sc.Members) == 0 {
+		return nil, fmt.Errorf("scenario has no members")
+	}
+	for i := range sc.Members {
+		m := &sc.Members[i]
+		if m.Name == "" {
+			return nil, fmt.Errorf("member %d has no name", i)
+		}
+		if len(m.Timeline) == 0 {
+			return nil, fmt.Errorf("member %s has no timeline", m.Name)
+		}
+		sort.SliceStable(m.Timeline, func(a, b int) bool {
+			return m.Timeline[a].AtS < m.Timeline[b].AtS
+		})
+	}
+	return sc, nil
+}
+
+func cutKV(s string) (key, val string, ok bool) {
+	i := strings.IndexByte(s, ':')
+	if i < 0 {
+		return "", "", false
+	}
+	return strings.TrimSpace(s[:i]), strings.TrimSpace(s[i+1:]), true
+}
+
+func setMemberField(m *MemberScript, key, val string) error {
+	switch key {
+	case "name":
+		m.Name = val
+	case "class":
+		m.Class = val
+	default:
+		return fmt.Errorf("unknown member field %q", key)
+	}
+	return nil
+}
+
+func setStepField(s *Step, key, val string) error {
+	if key == "at" {
+		v, err := strconv.Atoi(val)
+		if err != nil {
+			return fmt.Errorf("bad at value %q", val)
+		}
+		s.AtS = v
+		return nil
+	}
+	v, err := strconv.ParseFloat(val, 64)
+	if err != nil {
+		return fmt.Errorf("bad value %q for %s", val, key)
+	}
+	switch key {
+	case "latency_ms":
+		s.LatencyMS = &v
+	case "loss_percent":
+		s.LossPercent = &v
+	case "jitter_ms":
+		s.JitterMS = &v
+	case "obstruction_pct":
+		s.ObstructionPct = &v
+	case "rsrp":
+		s.RSRP = &v
+	default:
+		return fmt.Errorf("unknown step field %q", key)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package simulate
+
+import "testing"
+
+const sample = `# comment
+name: test scenario
+members:
+  - name: starlink
+    class: starlink
+    timeline:
+      - at: 0
+        latency_ms: 45
+        loss_percent: 0
+      - at: 60
+        latency_ms: 300
+        loss_percent: 10
+        obstruction_pct: 5
+  - name: cell
+    class: cellular
+    timeline:
+      - at: 0
+        latency_ms: 70
+        rsrp: -95
+`
+
+func TestParseScenario(t *testing.T) {
+	sc, err := ParseScenario(sample)
+	if err != nil {
+		t.Fatalf("ParseScenario: %v", err)
+	}
+	if sc.Name != "test scenario" {
+		t.Errorf("name = %q", sc.Name)
+	}
+	if len(sc.Members) != 2 {
+		t.Fatalf("members = %d, want 2", len(sc.Members))
+	}
+	sl := sc.Members[0]
+	if sl.Name != "starlink" || sl.Class != "starlink" || len(sl.Timeline) != 2 {
+		t.Fatalf("starlink member parsed wrong: %+v", sl)
+	}
+	if sl.Timeline[1].AtS != 60 || sl.Timeline[1].ObstructionPct == nil || *sl.Timeline[1].ObstructionPct != 5 {
+		t.Errorf("second step parsed wrong: %+v", sl.Timeline[1])
+	}
+	if sc.Members[1].Timeline[0].RSRP == nil || *sc.Members[1].Timeline[0].RSRP != -95 {
+		t.Errorf("rsrp not parsed: %+v", sc.Members[1].Timeline[0])
+	}
+}
+
+func TestStepAt(t *testing.T) {
+	sc, err := ParseScenario(sample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tl := sc.Members[0].Timeline
+	if s := stepAt(tl, 0); *s.LatencyMS != 45 {
+		t.Errorf("offset 0: latency %v", *s.LatencyMS)
+	}
+	if s := stepAt(tl, 59); *s.LatencyMS != 45 {
+		t.Errorf("offset 59: latency %v", *s.LatencyMS)
+	}
+	if s := stepAt(tl, 61); *s.LatencyMS != 300 {
+		t.Errorf("offset 61: latency %v", *s.LatencyMS)
+	}
+}
+
+func TestParseScenarioErrors(t *testing.T) {
+	for _, src := range []string{
+		"",
+		"name: empty\nmembers:\n",
+		"members:\n  - name: x\n    class: generic\n", // no timeline
+	} {
+		if _, err := ParseScenario(src); err == nil {
+			t.Errorf("ParseScenario(%q) succeeded, want error", src)
+		}
+	}
+}
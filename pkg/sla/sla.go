@@ -0,0 +1,180 @@
+// Package sla tracks availability objectives per member and for the WAN as
+// a whole over calendar months, the way users state them ("99.5%
+// monthly"), and raises an alert when the error budget is burning faster
+// than the month is passing.
+package sla
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/notifications"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/uci"
+)
+
+// Overall is the pseudo-member name for whole-WAN availability (at least
+// one member usable).
+const Overall = "overall"
+
+// burnAlertFactor: alert when the budget is consumed this many times
+// faster than elapsed calendar time would allow.
+const burnAlertFactor = 2.0
+
+// Objectives reads the configured SLA targets: per member from
+// starfail.<member>.sla_objective, overall from starfail.main.sla_objective.
+// Members without an objective are tracked but never alerted on.
+func Objectives(cfg *uci.Config) map[string]float64 {
+	out := make(map[string]float64)
+	if v := uci.GetFloat("starfail.main.sla_objective", 0); v > 0 && v <= 100 {
+		out[Overall] = v
+	}
+	for _, m := range cfg.Members {
+		if v := uci.GetFloat("starfail."+m.Name+".sla_objective", 0); v > 0 && v <= 100 {
+			out[m.Name] = v
+		}
+	}
+	return out
+}
+
+// counters accumulate one calendar month of observations.
+type counters struct {
+	Month string `json:"month"` // "2026-08"
+	Total int64  `json:"total"`
+	Up    int64  `json:"up"`
+}
+
+// Status is the exported compliance view for one tracked name.
+type Status struct {
+	Objective    float64 `json:"objective"`    // percent, 0 when untargeted
+	Availability float64 `json:"availability"` // percent so far this month
+	BurnRate     float64 `json:"burn_rate"`    // >1 consumes budget too fast
+	Total        int64   `json:"samples"`
+}
+
+// Tracker accumulates per-member up/down observations.
+type Tracker struct {
+	mu         sync.Mutex
+	objectives map[string]float64
+	counts     map[string]*counters
+	alerted    map[string]bool
+	path       string
+	notifier   *notifications.Notifier
+}
+
+// NewTracker restores persisted counters from path ("" for the default
+// under /tmp/starfail).
+func NewTracker(objectives map[string]float64, notifier *notifications.Notifier, path string) *Tracker {
+	if path == "" {
+		path = "/tmp/starfail/sla.json"
+	}
+	t := &Tracker{
+		objectives: objectives,
+		counts:     make(map[string]*counters),
+		alerted:    make(map[string]bool),
+		path:       path,
+		notifier:   notifier,
+	}
+	if b, err := os.ReadFile(path); err == nil {
+		_ = json.Unmarshal(b, &t.counts)
+	}
+	return t
+}
+
+// Observe records one tick for a name. Counters reset when the calendar
+// month changes.
+func (t *Tracker) Observe(name string, up bool, now time.Time) {
+	month := now.Format("2006-01")
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	c := t.counts[name]
+	if c == nil || c.Month != month {
+		c = &counters{Month: month}
+		t.counts[name] = c
+		t.alerted[name] = false
+	}
+	c.Total++
+	if up {
+		c.Up++
+	}
+	t.maybeAlertLocked(name, c, now)
+	t.persistLocked()
+}
+
+// maybeAlertLocked fires one alert per month per name when the error
+// budget burns faster than burnAlertFactor.
+func (t *Tracker) maybeAlertLocked(name string, c *counters, now time.Time) {
+	obj, ok := t.objectives[name]
+	if !ok || t.alerted[name] || c.Total < 100 {
+		return
+	}
+	st := status(obj, c, now)
+	if st.BurnRate <= burnAlertFactor {
+		return
+	}
+	t.alerted[name] = true
+	if t.notifier != nil {
+		t.notifier.SendNotification("SLA budget burning fast",
+			fmt.Sprintf("%s is at %.3f%% availability against a %.2f%% objective (burn rate %.1fx)",
+				name, st.Availability, obj, st.BurnRate),
+			notifications.PriorityNormal)
+	}
+}
+
+// Compliance returns current status for every tracked name.
+func (t *Tracker) Compliance(now time.Time) map[string]Status {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := make(map[string]Status, len(t.counts))
+	for name, c := range t.counts {
+		out[name] = status(t.objectives[name], c, now)
+	}
+	return out
+}
+
+// status computes availability and burn rate for one counter set.
+func status(objective float64, c *counters, now time.Time) Status {
+	st := Status{Objective: objective, Total: c.Total}
+	if c.Total == 0 {
+		st.Availability = 100
+		return st
+	}
+	st.Availability = 100 * float64(c.Up) / float64(c.Total)
+	if objective <= 0 || objective >= 100 {
+		return st
+	}
+	budget := 100 - objective            // allowed downtime percent
+	consumed := 100 - st.Availability    // downtime percent so far
+	elapsed := monthElapsedFraction(now) // fraction of month passed
+	if elapsed <= 0 {
+		return st
+	}
+	st.BurnRate = (consumed / budget) / elapsed
+	return st
+}
+
+// monthElapsedFraction returns how much of the current calendar month has
+// passed, in (0, 1].
+func monthElapsedFraction(now time.Time) float64 {
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	end := start.AddDate(0, 1, 0)
+	return float64(now.Sub(start)) / float64(end.Sub(start))
+}
+
+// persistLocked writes counters atomically; callers hold t.mu.
+func (t *Tracker) persistLocked() {
+	if err := os.MkdirAll(filepath.Dir(t.path), 0o755); err != nil {
+		return
+	}
+	b, err := json.Marshal(t.counts)
+	if err != nil {
+		return
+	}
+	tmp := t.path + ".tmp"
+	if os.WriteFile(tmp, b, 0o644) == nil {
+		_ = os.Rename(tmp, t.path)
+	}
+}
@@ -0,0 +1,64 @@
+package sla
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStatusBurnRate(t *testing.T) {
+	// Half the month gone, half the error budget gone: burn rate 1.0.
+	now := time.Date(2026, 4, 16, 0, 0, 0, 0, time.UTC) // April has 30 days
+	c := &counters{Month: "2026-04", Total: 10000, Up: 9975}
+	st := status(99.5, c, now)
+	if st.Availability < 99.74 || st.Availability > 99.76 {
+		t.Fatalf("availability = %v, want ~99.75", st.Availability)
+	}
+	if st.BurnRate < 0.99 || st.BurnRate > 1.01 {
+		t.Fatalf("burn rate = %v, want ~1.0", st.BurnRate)
+	}
+}
+
+func TestStatusNoObjective(t *testing.T) {
+	now := time.Date(2026, 4, 16, 0, 0, 0, 0, time.UTC)
+	st := status(0, &counters{Month: "2026-04", Total: 10, Up: 5}, now)
+	if st.Availability != 50 {
+		t.Fatalf("availability = %v, want 50", st.Availability)
+	}
+	if st.BurnRate != 0 {
+		t.Fatalf("burn rate = %v, want 0 without an objective", st.BurnRate)
+	}
+}
+
+func TestObserveMonthRollover(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sla.json")
+	tr := NewTracker(map[string]float64{"wan": 99.5}, nil, path)
+
+	jan := time.Date(2026, 1, 31, 12, 0, 0, 0, time.UTC)
+	tr.Observe("wan", false, jan)
+	tr.Observe("wan", false, jan)
+
+	feb := jan.AddDate(0, 0, 1)
+	tr.Observe("wan", true, feb)
+
+	st := tr.Compliance(feb)["wan"]
+	if st.Total != 1 {
+		t.Fatalf("total after rollover = %d, want 1", st.Total)
+	}
+	if st.Availability != 100 {
+		t.Fatalf("availability after rollover = %v, want 100", st.Availability)
+	}
+
+	// A fresh tracker must restore the persisted February counters.
+	tr2 := NewTracker(nil, nil, path)
+	if got := tr2.Compliance(feb)["wan"].Total; got != 1 {
+		t.Fatalf("restored total = %d, want 1", got)
+	}
+}
+
+func TestMonthElapsedFraction(t *testing.T) {
+	mid := time.Date(2026, 4, 16, 0, 0, 0, 0, time.UTC)
+	if f := monthElapsedFraction(mid); f < 0.49 || f > 0.51 {
+		t.Fatalf("elapsed fraction = %v, want ~0.5", f)
+	}
+}
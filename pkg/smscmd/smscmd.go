@@ -0,0 +1,204 @@
+// Package smscmd is the out-of-band control path: whitelisted,
+// PIN-protected SMS commands processed through the router's own modem.
+// A remote installation that loses every data WAN is exactly the one
+// that needs intervention — force a failover, reboot a wedged dish — and
+// SMS is the only channel that still reaches it. The handler polls the
+// modem inbox, executes commands from configured numbers that carry the
+// configured PIN, and texts the result back.
+//
+// Auth is deliberately two-factor-ish: sender spoofing is cheap, so the
+// whitelist alone is not enough, and a PIN alone would let anyone who
+// learns the number probe for it — both must match. Messages from
+// numbers outside the whitelist are left untouched in the inbox; mail
+// from a whitelisted control number is consumed (deleted) whether or not
+// the PIN checked out, so a typo cannot be replayed from the SIM later.
+package smscmd
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/collector/cellular"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/uci"
+)
+
+// Handler polls the modem inbox and executes commands.
+type Handler struct {
+	pin      string
+	allowed  map[string]bool
+	interval time.Duration
+
+	// Command implementations, wired by the daemon. A nil callback makes
+	// its command report as unavailable rather than crash.
+	Status     func() string
+	Failover   func(member string) error
+	Unforce    func()
+	RebootDish func(ctx context.Context) error
+
+	// OnCommand, when set, is called after each authenticated command so
+	// the daemon can record a telemetry event.
+	OnCommand func(from, command, result string)
+}
+
+// NewFromUCI returns a Handler when starfail.smscmd.enabled is on, nil
+// otherwise. Both a PIN and at least one allowed number are required —
+// an unauthenticated remote-control path is worse than none.
+func NewFromUCI() *Handler {
+	if !uci.GetBool("starfail.smscmd.enabled", false) {
+		return nil
+	}
+	pin, _ := uci.Get("starfail.smscmd.pin")
+	numbers := uci.GetList("starfail.smscmd.allowed")
+	if pin == "" || len(numbers) == 0 {
+		log.Printf("smscmd: enabled but starfail.smscmd.pin or allowed numbers missing; not starting")
+		return nil
+	}
+	h := &Handler{
+		pin:      pin,
+		allowed:  make(map[string]bool, len(numbers)),
+		interval: time.Duration(uci.GetInt("starfail.smscmd.interval_s", 30)) * time.Second,
+	}
+	for _, n := range numbers {
+		h.allowed[n] = true
+	}
+	return h
+}
+
+// Run polls the inbox until ctx is cancelled.
+func (h *Handler) Run(ctx context.Context) {
+	t := time.NewTicker(h.interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			h.poll(ctx)
+		}
+	}
+}
+
+// inbound is one stored SMS.
+type inbound struct {
+	index int
+	from  string
+	text  string
+}
+
+// poll reads the inbox once and processes every message from a control
+// number, deleting each after handling so it is not reprocessed.
+func (h *Handler) poll(ctx context.Context) {
+	out, err := cellular.Exec(ctx, "-S", "-l", "all")
+	if err != nil {
+		log.Printf("smscmd: list inbox: %v", err)
+		return
+	}
+	for _, m := range parseInbox(out) {
+		if !h.allowed[m.from] {
+			continue // not ours; leave it in the inbox
+		}
+		h.handle(ctx, m)
+		if _, err := cellular.Exec(ctx, "-S", "-d", strconv.Itoa(m.index)); err != nil {
+			log.Printf("smscmd: delete message %d: %v", m.index, err)
+		}
+	}
+}
+
+// handle authenticates one message and executes it. A bad PIN is logged
+// but never answered — a reply would confirm to a spoofer that the
+// interface exists and the number is whitelisted.
+func (h *Handler) handle(ctx context.Context, m inbound) {
+	pin, rest, _ := strings.Cut(strings.TrimSpace(m.text), " ")
+	if pin != h.pin {
+		log.Printf("smscmd: bad PIN from %s", m.from)
+		return
+	}
+	cmd := strings.TrimSpace(rest)
+	result := h.execute(ctx, cmd)
+	if h.OnCommand != nil {
+		h.OnCommand(m.from, cmd, result)
+	}
+	if _, err := cellular.Exec(ctx, "-S", "-s", m.from+" "+result); err != nil {
+		log.Printf("smscmd: reply to %s: %v", m.from, err)
+	}
+}
+
+// execute runs one authenticated command and returns the reply text.
+// Verbs are case-insensitive; member names are not.
+func (h *Handler) execute(ctx context.Context, cmd string) string {
+	verb, arg, _ := strings.Cut(cmd, " ")
+	arg = strings.TrimSpace(arg)
+	switch strings.ToUpper(verb) {
+	case "STATUS":
+		if h.Status == nil {
+			break
+		}
+		return h.Status()
+	case "FAILOVER":
+		switch {
+		case arg == "":
+			return "FAILOVER needs a member name (or AUTO)"
+		case strings.EqualFold(arg, "AUTO"):
+			if h.Unforce == nil {
+				break
+			}
+			h.Unforce()
+			return "automatic failover resumed"
+		case h.Failover != nil:
+			if err := h.Failover(arg); err != nil {
+				return "failover: " + err.Error()
+			}
+			return "failover to " + arg + " forced; send FAILOVER AUTO to revert"
+		}
+	case "REBOOT":
+		if !strings.EqualFold(arg, "DISH") {
+			return "only REBOOT DISH is supported"
+		}
+		if h.RebootDish == nil {
+			break
+		}
+		if err := h.RebootDish(ctx); err != nil {
+			return "dish reboot: " + err.Error()
+		}
+		return "dish rebooting; expect several minutes of downtime"
+	}
+	return "unknown command; try STATUS, FAILOVER <member>, REBOOT DISH"
+}
+
+// parseInbox parses gsmctl -S -l output: per-message blocks of
+// "Index:", "Sender:" and "Text:" lines, the text possibly spanning
+// lines until the next block. Unparseable blocks are skipped.
+func parseInbox(out string) []inbound {
+	var msgs []inbound
+	var cur *inbound
+	inText := false
+	for _, line := range strings.Split(out, "\n") {
+		switch {
+		case strings.HasPrefix(line, "Index:"):
+			if cur != nil && cur.from != "" {
+				msgs = append(msgs, *cur)
+			}
+			idx, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "Index:")))
+			cur, inText = nil, false
+			if err == nil {
+				cur = &inbound{index: idx}
+			}
+		case cur == nil:
+		case strings.HasPrefix(line, "Sender:"):
+			cur.from = strings.TrimSpace(strings.TrimPrefix(line, "Sender:"))
+			inText = false
+		case strings.HasPrefix(line, "Text:"):
+			cur.text = strings.TrimSpace(strings.TrimPrefix(line, "Text:"))
+			inText = true
+		case inText && strings.TrimSpace(line) != "":
+			cur.text += "\n" + strings.TrimSpace(line)
+		}
+	}
+	if cur != nil && cur.from != "" {
+		msgs = append(msgs, *cur)
+	}
+	return msgs
+}
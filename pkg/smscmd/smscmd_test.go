@@ -0,0 +1,179 @@
+package smscmd
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/collector/cellular"
+)
+
+// fakeInbox serves a canned gsmctl -S -l reply and records deletes and
+// replies.
+type fakeInbox struct {
+	listing string
+	deleted []string
+	replies []string
+}
+
+func installFakeInbox(t *testing.T, f *fakeInbox) {
+	t.Helper()
+	orig := cellular.Exec
+	cellular.Exec = func(_ context.Context, args ...string) (string, error) {
+		if len(args) < 2 || args[0] != "-S" {
+			return "", errors.New("unexpected gsmctl invocation")
+		}
+		switch args[1] {
+		case "-l":
+			return f.listing, nil
+		case "-d":
+			f.deleted = append(f.deleted, args[2])
+			return "OK", nil
+		case "-s":
+			f.replies = append(f.replies, args[2])
+			return "OK", nil
+		}
+		return "", errors.New("unexpected gsmctl invocation")
+	}
+	t.Cleanup(func() { cellular.Exec = orig })
+}
+
+func newTestHandler() *Handler {
+	return &Handler{
+		pin:     "4711",
+		allowed: map[string]bool{"+46700000001": true},
+	}
+}
+
+func listing(entries ...string) string {
+	return strings.Join(entries, "\n\n")
+}
+
+func message(index, sender, text string) string {
+	return "Index: " + index + "\nDate: 2026-08-31 12:00:00\nSender: " + sender + "\nText: " + text
+}
+
+func TestParseInbox(t *testing.T) {
+	msgs := parseInbox(listing(
+		message("3", "+46700000001", "4711 STATUS"),
+		message("7", "+46700000002", "hello\nsecond line"),
+	))
+	if len(msgs) != 2 {
+		t.Fatalf("parsed %d messages, want 2", len(msgs))
+	}
+	if msgs[0].index != 3 || msgs[0].from != "+46700000001" || msgs[0].text != "4711 STATUS" {
+		t.Errorf("first message = %+v", msgs[0])
+	}
+	if msgs[1].text != "hello\nsecond line" {
+		t.Errorf("multiline text = %q", msgs[1].text)
+	}
+}
+
+func TestStatusCommandRepliesAndDeletes(t *testing.T) {
+	f := &fakeInbox{listing: listing(message("3", "+46700000001", "4711 STATUS"))}
+	installFakeInbox(t, f)
+	h := newTestHandler()
+	h.Status = func() string { return "active: wan" }
+	h.poll(context.Background())
+	if len(f.replies) != 1 || !strings.Contains(f.replies[0], "active: wan") {
+		t.Errorf("replies = %v", f.replies)
+	}
+	if len(f.deleted) != 1 || f.deleted[0] != "3" {
+		t.Errorf("deleted = %v", f.deleted)
+	}
+}
+
+func TestIgnoresUnlistedSenders(t *testing.T) {
+	f := &fakeInbox{listing: listing(message("3", "+46700009999", "4711 STATUS"))}
+	installFakeInbox(t, f)
+	h := newTestHandler()
+	h.Status = func() string { return "active: wan" }
+	h.poll(context.Background())
+	if len(f.replies) != 0 || len(f.deleted) != 0 {
+		t.Errorf("unlisted sender got replies=%v deleted=%v", f.replies, f.deleted)
+	}
+}
+
+func TestBadPINConsumedSilently(t *testing.T) {
+	f := &fakeInbox{listing: listing(message("3", "+46700000001", "9999 STATUS"))}
+	installFakeInbox(t, f)
+	h := newTestHandler()
+	h.Status = func() string { return "active: wan" }
+	h.poll(context.Background())
+	if len(f.replies) != 0 {
+		t.Errorf("bad PIN was answered: %v", f.replies)
+	}
+	if len(f.deleted) != 1 {
+		t.Errorf("bad-PIN message left in inbox: deleted=%v", f.deleted)
+	}
+}
+
+func TestFailoverCommand(t *testing.T) {
+	f := &fakeInbox{listing: listing(
+		message("1", "+46700000001", "4711 failover mob1s1a1"),
+		message("2", "+46700000001", "4711 FAILOVER AUTO"),
+	)}
+	installFakeInbox(t, f)
+	h := newTestHandler()
+	var forced string
+	unforced := false
+	h.Failover = func(member string) error {
+		forced = member
+		return nil
+	}
+	h.Unforce = func() { unforced = true }
+	h.poll(context.Background())
+	if forced != "mob1s1a1" {
+		t.Errorf("forced member = %q", forced)
+	}
+	if !unforced {
+		t.Error("FAILOVER AUTO did not unforce")
+	}
+	if len(f.replies) != 2 {
+		t.Errorf("replies = %v", f.replies)
+	}
+}
+
+func TestFailoverErrorReported(t *testing.T) {
+	f := &fakeInbox{listing: listing(message("1", "+46700000001", "4711 FAILOVER nosuch"))}
+	installFakeInbox(t, f)
+	h := newTestHandler()
+	h.Failover = func(string) error { return errors.New(`unknown member "nosuch"`) }
+	h.poll(context.Background())
+	if len(f.replies) != 1 || !strings.Contains(f.replies[0], "unknown member") {
+		t.Errorf("replies = %v", f.replies)
+	}
+}
+
+func TestRebootDishCommand(t *testing.T) {
+	f := &fakeInbox{listing: listing(message("1", "+46700000001", "4711 REBOOT DISH"))}
+	installFakeInbox(t, f)
+	h := newTestHandler()
+	rebooted := false
+	h.RebootDish = func(context.Context) error {
+		rebooted = true
+		return nil
+	}
+	var events []string
+	h.OnCommand = func(from, command, result string) {
+		events = append(events, from+": "+command)
+	}
+	h.poll(context.Background())
+	if !rebooted {
+		t.Error("dish not rebooted")
+	}
+	if len(events) != 1 || events[0] != "+46700000001: REBOOT DISH" {
+		t.Errorf("events = %v", events)
+	}
+}
+
+func TestUnknownCommand(t *testing.T) {
+	f := &fakeInbox{listing: listing(message("1", "+46700000001", "4711 PING"))}
+	installFakeInbox(t, f)
+	h := newTestHandler()
+	h.poll(context.Background())
+	if len(f.replies) != 1 || !strings.Contains(f.replies[0], "unknown command") {
+		t.Errorf("replies = %v", f.replies)
+	}
+}
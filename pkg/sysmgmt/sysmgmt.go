@@ -0,0 +1,289 @@
+// Package sysmgmt implements the system-management daemon: periodic health
+// checks of the router itself (disk, memory, services, time sync) and
+// automated remediation, ported from system-maintenance-rutos.sh.
+package sysmgmt
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/heartbeat"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/ubus"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/uci"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/webhook"
+)
+
+// Config is the sysmgmt section, loaded through the shared pkg/uci reader
+// (uci.LoadSysmgmtConfig) so both daemons have one source of truth for
+// configuration and defaults.
+type Config = uci.SysmgmtConfig
+
+// LoadConfig reads the sysmgmt section via pkg/uci.
+func LoadConfig() (*Config, error) {
+	return uci.LoadSysmgmtConfig(), nil
+}
+
+// Issue is one detected problem.
+type Issue struct {
+	Check    string `json:"check"`
+	Severity string `json:"severity"` // warning|critical
+	Detail   string `json:"detail"`
+}
+
+// FixAuditPath records every automated fix as one JSON line; starfaild's
+// diag bundle and operators auditing unattended remediation read it.
+const FixAuditPath = "/tmp/starfail/sysmgmt-fixes.json"
+
+// failoverGrace is how long after a failover event service restarts stay
+// blocked: restarting mwan3 or networking mid-switch can strand the router
+// on a dead link.
+const failoverGrace = 2 * time.Minute
+
+// starfaildStaleAfter is how old starfaild's heartbeat may grow before the
+// process is considered hung; the daemon beats every 30 seconds.
+const starfaildStaleAfter = 5 * time.Minute
+
+// Manager runs the checks.
+type Manager struct {
+	cfg      *Config
+	reporter *webhook.Reporter
+
+	// pending tracks destructive fixes awaiting their second strike; see
+	// FixIssues.
+	pending map[string]time.Time
+}
+
+// NewManager returns a Manager for cfg.
+func NewManager(cfg *Config) *Manager {
+	return &Manager{cfg: cfg, pending: make(map[string]time.Time)}
+}
+
+// SetReporter attaches an outbound problem reporter; each detected issue is
+// posted (deduplicated) in addition to being fixed locally.
+func (m *Manager) SetReporter(r *webhook.Reporter) { m.reporter = r }
+
+// Run loops forever running checks each interval.
+func (m *Manager) Run() {
+	for {
+		if err := heartbeat.Beat("starfail-sysmgmt"); err != nil {
+			log.Printf("sysmgmt: heartbeat: %v", err)
+		}
+		issues := m.CheckHealth()
+		for _, is := range issues {
+			m.report(is)
+		}
+		if len(issues) > 0 && m.cfg.AutoFix {
+			m.FixIssues(issues)
+		}
+		time.Sleep(time.Duration(m.cfg.CheckIntervalS) * time.Second)
+	}
+}
+
+// report forwards one issue to the webhook; the subject is the service name
+// for service checks, else the check itself.
+func (m *Manager) report(is Issue) {
+	subject := is.Check
+	if is.Check == "service" || is.Check == "heartbeat" {
+		subject = strings.Fields(is.Detail)[0]
+	}
+	m.reporter.Report("sysmgmt", is.Check, subject, is.Severity, is.Detail)
+}
+
+// CheckHealth runs all checks and returns found issues.
+func (m *Manager) CheckHealth() []Issue {
+	var issues []Issue
+	if pct, err := diskUsagePct("/overlay"); err == nil && pct > m.cfg.MaxDiskUsagePct {
+		issues = append(issues, Issue{
+			Check: "disk", Severity: "critical",
+			Detail: fmt.Sprintf("/overlay at %d%% (limit %d%%)", pct, m.cfg.MaxDiskUsagePct),
+		})
+	}
+	if free, err := freeMemoryKB(); err == nil && free < m.cfg.MinFreeMemoryKB {
+		issues = append(issues, Issue{
+			Check: "memory", Severity: "warning",
+			Detail: fmt.Sprintf("only %d kB free", free),
+		})
+	}
+	for _, svc := range m.cfg.ManagedServices {
+		if !serviceRunning(svc) {
+			issues = append(issues, Issue{
+				Check: "service", Severity: "critical",
+				Detail: svc + " not running",
+			})
+		}
+	}
+	// A running starfaild that stopped beating is hung, which pgrep can
+	// never see; the heartbeat check catches it.
+	if age, ok := heartbeat.Age("starfaild"); ok && age > starfaildStaleAfter && serviceRunning("starfaild") {
+		issues = append(issues, Issue{
+			Check: "heartbeat", Severity: "critical",
+			Detail: fmt.Sprintf("starfaild heartbeat stale for %s", age.Round(time.Second)),
+		})
+	}
+	return issues
+}
+
+// FixIssues applies remediation for each issue: restart dead services,
+// clean logs on full disk, drop caches on low memory. Two interlocks guard
+// the destructive path (service restarts): the issue must persist across
+// two consecutive check rounds, and no restart runs within failoverGrace
+// of a failover event. Every applied fix is appended to FixAuditPath.
+func (m *Manager) FixIssues(issues []Issue) {
+	now := time.Now()
+	seen := make(map[string]bool, len(issues))
+	for _, is := range issues {
+		key := is.Check + ":" + is.Detail
+		seen[key] = true
+		if is.Check == "service" || is.Check == "heartbeat" {
+			if m.pending[key].IsZero() {
+				// First strike: a service can legitimately be mid-restart
+				// when we look; only act when it is still down next round.
+				m.pending[key] = now
+				log.Printf("sysmgmt: %s — deferring restart until confirmed next round", is.Detail)
+				continue
+			}
+			if m.failoverInProgress() {
+				log.Printf("sysmgmt: %s — holding restart, failover in progress", is.Detail)
+				continue
+			}
+		}
+		log.Printf("sysmgmt: fixing %s: %s", is.Check, is.Detail)
+		var action string
+		switch is.Check {
+		case "service", "heartbeat":
+			svc := strings.Fields(is.Detail)[0]
+			run("/etc/init.d/"+svc, "restart")
+			action = "restarted " + svc
+		case "disk":
+			run("sh", "-c", "rm -f /overlay/starfail/*.old /tmp/starfail/*.tmp")
+			action = "removed stale overlay and tmp files"
+		case "memory":
+			run("sh", "-c", "sync; echo 3 > /proc/sys/vm/drop_caches")
+			action = "dropped page caches"
+		}
+		m.audit(is, action)
+		delete(m.pending, key)
+	}
+	// Issues that cleared on their own lose their pending strike.
+	for key := range m.pending {
+		if !seen[key] {
+			delete(m.pending, key)
+		}
+	}
+}
+
+// failoverInProgress asks starfaild (over its control socket) whether a
+// failover event happened within failoverGrace. An unreachable daemon
+// counts as no failover — it may be exactly the service we need to restart.
+func (m *Manager) failoverInProgress() bool {
+	conn, err := dialStarfaild()
+	if err != nil {
+		return false
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte(`{"method":"events","args":{"limit":10}}` + "\n")); err != nil {
+		return false
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	if err != nil {
+		return false
+	}
+	var resp struct {
+		Result struct {
+			Events []struct {
+				Timestamp time.Time `json:"timestamp"`
+				Type      string    `json:"type"`
+			} `json:"events"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(line, &resp); err != nil {
+		return false
+	}
+	for _, ev := range resp.Result.Events {
+		if ev.Type == "failover" && time.Since(ev.Timestamp) < failoverGrace {
+			return true
+		}
+	}
+	return false
+}
+
+// dialStarfaild is a stub var for tests.
+var dialStarfaild = func() (net.Conn, error) {
+	return net.DialTimeout("unix", ubus.DefaultSocket, 2*time.Second)
+}
+
+// audit appends one JSON line per applied fix to FixAuditPath.
+func (m *Manager) audit(is Issue, action string) {
+	rec := map[string]any{
+		"timestamp": time.Now(),
+		"check":     is.Check,
+		"severity":  is.Severity,
+		"detail":    is.Detail,
+		"action":    action,
+	}
+	b, _ := json.Marshal(rec)
+	if err := os.MkdirAll(filepath.Dir(fixAuditPath), 0o755); err != nil {
+		return
+	}
+	f, err := os.OpenFile(fixAuditPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		log.Printf("sysmgmt: audit: %v", err)
+		return
+	}
+	defer f.Close()
+	_, _ = f.Write(append(b, '\n'))
+}
+
+// fixAuditPath is a var so tests can redirect the audit log.
+var fixAuditPath = FixAuditPath
+
+// run is a var so tests can capture commands instead of executing them.
+var run = func(name string, args ...string) {
+	if out, err := exec.Command(name, args...).CombinedOutput(); err != nil {
+		log.Printf("sysmgmt: %s %v: %v: %s", name, args, err, out)
+	}
+}
+
+func diskUsagePct(path string) (int, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, err
+	}
+	if st.Blocks == 0 {
+		return 0, fmt.Errorf("no blocks")
+	}
+	used := st.Blocks - st.Bfree
+	return int(used * 100 / st.Blocks), nil
+}
+
+func freeMemoryKB() (int, error) {
+	b, err := os.ReadFile("/proc/meminfo")
+	if err != nil {
+		return 0, err
+	}
+	for _, line := range strings.Split(string(b), "\n") {
+		if strings.HasPrefix(line, "MemAvailable:") {
+			f := strings.Fields(line)
+			if len(f) >= 2 {
+				return strconv.Atoi(f[1])
+			}
+		}
+	}
+	return 0, fmt.Errorf("MemAvailable not found")
+}
+
+func serviceRunning(name string) bool {
+	err := exec.Command("pgrep", "-f", name).Run()
+	return err == nil
+}
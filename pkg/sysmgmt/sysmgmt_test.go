@@ -0,0 +1,125 @@
+package sysmgmt
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func captureRuns(t *testing.T) *[]string {
+	t.Helper()
+	var cmds []string
+	orig := run
+	run = func(name string, args ...string) {
+		cmds = append(cmds, name+" "+strings.Join(args, " "))
+	}
+	t.Cleanup(func() { run = orig })
+	return &cmds
+}
+
+func noDaemon(t *testing.T) {
+	t.Helper()
+	orig := dialStarfaild
+	dialStarfaild = func() (net.Conn, error) { return nil, fmt.Errorf("no daemon") }
+	t.Cleanup(func() { dialStarfaild = orig })
+}
+
+func tempAudit(t *testing.T) string {
+	t.Helper()
+	orig := fixAuditPath
+	fixAuditPath = filepath.Join(t.TempDir(), "fixes.json")
+	t.Cleanup(func() { fixAuditPath = orig })
+	return fixAuditPath
+}
+
+func TestServiceRestartNeedsTwoStrikes(t *testing.T) {
+	cmds := captureRuns(t)
+	noDaemon(t)
+	audit := tempAudit(t)
+	mgr := NewManager(&Config{})
+	issue := Issue{Check: "service", Severity: "critical", Detail: "mwan3 not running"}
+
+	mgr.FixIssues([]Issue{issue})
+	if len(*cmds) != 0 {
+		t.Fatalf("restart ran on first strike: %v", *cmds)
+	}
+	mgr.FixIssues([]Issue{issue})
+	if len(*cmds) != 1 || !strings.Contains((*cmds)[0], "/etc/init.d/mwan3 restart") {
+		t.Fatalf("cmds = %v, want one mwan3 restart", *cmds)
+	}
+
+	b, err := os.ReadFile(audit)
+	if err != nil {
+		t.Fatalf("audit log: %v", err)
+	}
+	var rec map[string]any
+	if err := json.Unmarshal(b, &rec); err != nil {
+		t.Fatalf("audit line not JSON: %v", err)
+	}
+	if rec["action"] != "restarted mwan3" {
+		t.Errorf("audit action = %v", rec["action"])
+	}
+}
+
+func TestClearedIssueDropsStrike(t *testing.T) {
+	cmds := captureRuns(t)
+	noDaemon(t)
+	tempAudit(t)
+	mgr := NewManager(&Config{})
+	issue := Issue{Check: "service", Severity: "critical", Detail: "mwan3 not running"}
+
+	mgr.FixIssues([]Issue{issue})
+	mgr.FixIssues(nil) // recovered on its own
+	mgr.FixIssues([]Issue{issue})
+	if len(*cmds) != 0 {
+		t.Fatalf("restart ran without two consecutive strikes: %v", *cmds)
+	}
+}
+
+func TestNonDestructiveFixRunsImmediately(t *testing.T) {
+	cmds := captureRuns(t)
+	noDaemon(t)
+	tempAudit(t)
+	mgr := NewManager(&Config{})
+
+	mgr.FixIssues([]Issue{{Check: "disk", Severity: "critical", Detail: "/overlay at 95%"}})
+	if len(*cmds) != 1 {
+		t.Fatalf("cmds = %v, want immediate cleanup", *cmds)
+	}
+}
+
+func TestRestartHeldDuringFailover(t *testing.T) {
+	cmds := captureRuns(t)
+	tempAudit(t)
+	orig := dialStarfaild
+	dialStarfaild = func() (net.Conn, error) {
+		client, server := net.Pipe()
+		go func() {
+			defer server.Close()
+			if _, err := bufio.NewReader(server).ReadBytes('\n'); err != nil {
+				return
+			}
+			reply := map[string]any{"result": map[string]any{"events": []map[string]any{
+				{"timestamp": time.Now(), "type": "failover"},
+			}}}
+			b, _ := json.Marshal(reply)
+			_, _ = server.Write(append(b, '\n'))
+		}()
+		return client, nil
+	}
+	t.Cleanup(func() { dialStarfaild = orig })
+
+	mgr := NewManager(&Config{})
+	issue := Issue{Check: "service", Severity: "critical", Detail: "starfaild not running"}
+	mgr.FixIssues([]Issue{issue})
+	mgr.FixIssues([]Issue{issue})
+	if len(*cmds) != 0 {
+		t.Fatalf("restart ran during active failover: %v", *cmds)
+	}
+}
@@ -0,0 +1,229 @@
+package telemetry
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/identity"
+)
+
+// The blackbox is a small memory-mapped ring of recent sample and log
+// lines kept for crash forensics. Because writes land directly in the
+// mapped pages, whatever was recorded up to the instant of a panic or a
+// SIGKILL is still in the file afterwards — no buffered writer to lose.
+// On tmpfs the pages are the file, so nothing is ever synced to flash.
+// The next startup reads the previous ring back and, if the last run did
+// not shut down cleanly, turns it into a crash report.
+
+// DefaultBlackboxPath is the ring file on tmpfs.
+const DefaultBlackboxPath = DefaultDir + "/blackbox.bin"
+
+const (
+	// blackboxSize holds roughly a minute of per-tick sample lines plus
+	// interleaved log output for a handful of members.
+	blackboxSize = 64 * 1024
+
+	// bbMagic identifies the file and its layout version.
+	bbMagic = "SFBBOX1\x00"
+
+	// bbHeaderSize is magic[0:8], write offset uint32 LE [8:12],
+	// flags [12], padding [13:16].
+	bbHeaderSize = 16
+
+	bbFlagWrapped = 1 << 0 // the write offset has lapped the file
+	bbFlagClean   = 1 << 1 // Close ran; the previous run exited cleanly
+)
+
+// Blackbox appends newline-terminated records into the mapped ring.
+// The zero value is not usable; open one with OpenBlackbox. A nil
+// Blackbox ignores writes, so callers need no enabled-checks.
+type Blackbox struct {
+	mu      sync.Mutex
+	buf     []byte // the whole mapping, header included
+	off     int    // next write position
+	wrapped bool
+}
+
+// OpenBlackbox creates (or re-initializes) the ring file at path and maps
+// it. Any previous contents are discarded — recover them first with
+// RecoverBlackbox. The clean-shutdown flag stays unset until Close, so a
+// crash of any kind leaves the file marked dirty.
+func OpenBlackbox(path string) (*Blackbox, error) {
+	if path == "" {
+		path = DefaultBlackboxPath
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if err := f.Truncate(blackboxSize); err != nil {
+		return nil, err
+	}
+	buf, err := syscall.Mmap(int(f.Fd()), 0, blackboxSize,
+		syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, fmt.Errorf("mmap blackbox: %w", err)
+	}
+	for i := range buf {
+		buf[i] = 0
+	}
+	copy(buf, bbMagic)
+	b := &Blackbox{buf: buf, off: bbHeaderSize}
+	b.updateHeader()
+	return b, nil
+}
+
+// Append records one line in the ring, stamping it with the wall clock.
+func (b *Blackbox) Append(line string) {
+	if b == nil {
+		return
+	}
+	b.append(time.Now().Format("15:04:05.000") + " " + line)
+}
+
+// Write implements io.Writer so the blackbox can sit behind the standard
+// logger via io.MultiWriter; the logger already stamps its lines.
+func (b *Blackbox) Write(p []byte) (int, error) {
+	if b != nil {
+		b.append(strings.TrimRight(string(p), "\n"))
+	}
+	return len(p), nil
+}
+
+func (b *Blackbox) append(line string) {
+	rec := append([]byte(line), '\n')
+	const capacity = blackboxSize - bbHeaderSize
+	if len(rec) > capacity {
+		rec = rec[len(rec)-capacity:]
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.buf == nil { // closed; late log writes are harmless no-ops
+		return
+	}
+	for _, c := range rec {
+		b.buf[b.off] = c
+		b.off++
+		if b.off == blackboxSize {
+			b.off = bbHeaderSize
+			b.wrapped = true
+		}
+	}
+	b.updateHeader()
+}
+
+// updateHeader publishes the write offset and flags; called under mu
+// (or before the blackbox is shared).
+func (b *Blackbox) updateHeader() {
+	binary.LittleEndian.PutUint32(b.buf[8:12], uint32(b.off))
+	var flags byte
+	if b.wrapped {
+		flags |= bbFlagWrapped
+	}
+	b.buf[12] = flags
+}
+
+// Close marks the ring cleanly shut down and unmaps it. The file stays
+// behind for the next startup to inspect.
+func (b *Blackbox) Close() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.buf == nil {
+		return
+	}
+	b.buf[12] |= bbFlagClean
+	_ = syscall.Munmap(b.buf)
+	b.buf = nil
+}
+
+// RecoverBlackbox reads a ring left by a previous run and returns its
+// lines oldest-first, plus whether that run shut down cleanly. A missing,
+// truncated or foreign file yields no lines and clean=true.
+func RecoverBlackbox(path string) (lines []string, clean bool) {
+	if path == "" {
+		path = DefaultBlackboxPath
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil || len(raw) != blackboxSize || string(raw[:8]) != bbMagic {
+		return nil, true
+	}
+	off := int(binary.LittleEndian.Uint32(raw[8:12]))
+	if off < bbHeaderSize || off > blackboxSize {
+		return nil, true
+	}
+	flags := raw[12]
+	clean = flags&bbFlagClean != 0
+	var data []byte
+	if flags&bbFlagWrapped != 0 {
+		data = append(data, raw[off:]...)
+		data = append(data, raw[bbHeaderSize:off]...)
+	} else {
+		data = raw[bbHeaderSize:off]
+	}
+	parts := strings.Split(string(data), "\n")
+	if flags&bbFlagWrapped != 0 && len(parts) > 0 {
+		// The first piece after a wrap is almost always the tail of a
+		// record the write offset ran through; drop it.
+		parts = parts[1:]
+	}
+	for _, p := range parts {
+		if p != "" {
+			lines = append(lines, p)
+		}
+	}
+	return lines, clean
+}
+
+// blackboxSample renders one sample as a compact forensics line; only
+// the fields that matter in a post-mortem make the cut.
+func blackboxSample(sm Sample) string {
+	line := fmt.Sprintf("sample %s score=%.1f", sm.Member, sm.Score)
+	if m := sm.Metrics; m != nil {
+		if m.LatencyMS != nil {
+			line += fmt.Sprintf(" lat=%.0fms", float64(*m.LatencyMS))
+		}
+		if m.LossPercent != nil {
+			line += fmt.Sprintf(" loss=%.1f%%", float64(*m.LossPercent))
+		}
+		if m.ObstructionPct != nil {
+			line += fmt.Sprintf(" obstruction=%.1f%%", float64(*m.ObstructionPct))
+		}
+	}
+	return line
+}
+
+// WriteCrashReport saves recovered blackbox lines as a timestamped report
+// in dir ("" for DefaultDir) and returns its path. Older reports are left
+// in place; tmpfs clears them on reboot anyway.
+func WriteCrashReport(dir string, lines []string) (string, error) {
+	if dir == "" {
+		dir = DefaultDir
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, fmt.Sprintf("crash-%s.log", time.Now().Format("20060102-150405")))
+	var b strings.Builder
+	fmt.Fprintf(&b, "# starfaild crash report (device %s): last blackbox contents before unclean exit\n", identity.ID())
+	for _, l := range lines {
+		b.WriteString(l)
+		b.WriteByte('\n')
+	}
+	if err := os.WriteFile(path, []byte(b.String()), 0o644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
@@ -0,0 +1,119 @@
+package telemetry
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBlackboxRecoverAfterCrash(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blackbox.bin")
+	b, err := OpenBlackbox(path)
+	if err != nil {
+		t.Fatalf("OpenBlackbox: %v", err)
+	}
+	b.Append("first line")
+	b.Append("second line")
+	// No Close: simulate a crash. MAP_SHARED means the lines are in the
+	// file regardless.
+	lines, clean := RecoverBlackbox(path)
+	if clean {
+		t.Error("unclosed blackbox reported clean")
+	}
+	if len(lines) != 2 {
+		t.Fatalf("recovered %d lines, want 2: %v", len(lines), lines)
+	}
+	if !strings.HasSuffix(lines[0], "first line") || !strings.HasSuffix(lines[1], "second line") {
+		t.Errorf("lines out of order or mangled: %v", lines)
+	}
+}
+
+func TestBlackboxCleanShutdown(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blackbox.bin")
+	b, err := OpenBlackbox(path)
+	if err != nil {
+		t.Fatalf("OpenBlackbox: %v", err)
+	}
+	b.Append("shutting down")
+	b.Close()
+	if _, clean := RecoverBlackbox(path); !clean {
+		t.Error("closed blackbox reported unclean")
+	}
+	// Writes after Close must not panic.
+	b.Append("too late")
+}
+
+func TestBlackboxWraps(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blackbox.bin")
+	b, err := OpenBlackbox(path)
+	if err != nil {
+		t.Fatalf("OpenBlackbox: %v", err)
+	}
+	// Enough ~100-byte records to lap the 64 KiB ring twice.
+	n := 2 * blackboxSize / 100
+	for i := 0; i < n; i++ {
+		b.Append(fmt.Sprintf("record %05d %s", i, strings.Repeat("x", 70)))
+	}
+	lines, _ := RecoverBlackbox(path)
+	if len(lines) == 0 || len(lines) >= n {
+		t.Fatalf("recovered %d lines from a lapped ring of %d records", len(lines), n)
+	}
+	// Only the newest records survive, still oldest-first and intact.
+	if !strings.HasSuffix(lines[len(lines)-1], strings.Repeat("x", 70)) {
+		t.Errorf("last line mangled: %q", lines[len(lines)-1])
+	}
+	last := -1
+	for _, l := range lines {
+		var i int
+		if _, err := fmt.Sscanf(l[strings.Index(l, "record"):], "record %d", &i); err != nil {
+			t.Fatalf("unparseable recovered line %q: %v", l, err)
+		}
+		if i <= last {
+			t.Fatalf("records out of order: %d after %d", i, last)
+		}
+		last = i
+	}
+	if last != n-1 {
+		t.Errorf("newest record %d not recovered (want %d)", last, n-1)
+	}
+}
+
+func TestBlackboxRecoverMissingOrForeign(t *testing.T) {
+	dir := t.TempDir()
+	if lines, clean := RecoverBlackbox(filepath.Join(dir, "nope.bin")); lines != nil || !clean {
+		t.Errorf("missing file: lines=%v clean=%v", lines, clean)
+	}
+	foreign := filepath.Join(dir, "foreign.bin")
+	if err := os.WriteFile(foreign, []byte("not a blackbox"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if lines, clean := RecoverBlackbox(foreign); lines != nil || !clean {
+		t.Errorf("foreign file: lines=%v clean=%v", lines, clean)
+	}
+}
+
+func TestBlackboxNilReceiver(t *testing.T) {
+	var b *Blackbox
+	b.Append("ignored")
+	if n, err := b.Write([]byte("ignored\n")); n != 8 || err != nil {
+		t.Errorf("nil Write = (%d, %v)", n, err)
+	}
+	b.Close()
+}
+
+func TestWriteCrashReport(t *testing.T) {
+	dir := t.TempDir()
+	path, err := WriteCrashReport(dir, []string{"one", "two"})
+	if err != nil {
+		t.Fatalf("WriteCrashReport: %v", err)
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(raw), "one\ntwo\n") {
+		t.Errorf("report missing lines:\n%s", raw)
+	}
+}
@@ -0,0 +1,141 @@
+package telemetry
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// The heatmap aggregates latency and loss per member into weekday x hour
+// buckets so dashboards can show when each link is typically good at the
+// current site. Unlike the sample ring, which only covers the last hour,
+// the heatmap accumulates across restarts via Flush/LoadHeatmap.
+
+// heatmapMaxValues bounds the per-bucket reservoirs the medians are
+// computed from; oldest values are evicted first.
+const heatmapMaxValues = 100
+
+// heatCell is the stored form: raw value reservoirs per bucket.
+type heatCell struct {
+	Latency []float64 `json:"latency,omitempty"`
+	Loss    []float64 `json:"loss,omitempty"`
+}
+
+// HeatmapCell is one weekday x hour bucket as served over ubus and HTTP.
+type HeatmapCell struct {
+	MedianLatencyMS float64 `json:"median_latency_ms"`
+	MedianLossPct   float64 `json:"median_loss_pct"`
+	Samples         int     `json:"samples"`
+}
+
+// observeHeatmap folds one sample into the grid; the caller holds s.mu.
+func (s *Store) observeHeatmap(sm Sample) {
+	if sm.Metrics == nil {
+		return
+	}
+	ts := sm.Metrics.Timestamp
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+	if s.heat == nil {
+		s.heat = make(map[string]*[7][24]heatCell)
+	}
+	grid := s.heat[sm.Member]
+	if grid == nil {
+		grid = &[7][24]heatCell{}
+		s.heat[sm.Member] = grid
+	}
+	cell := &grid[int(ts.Weekday())][ts.Hour()]
+	if sm.Metrics.LatencyMS != nil {
+		cell.Latency = appendBounded(cell.Latency, float64(*sm.Metrics.LatencyMS))
+	}
+	if sm.Metrics.LossPercent != nil {
+		cell.Loss = appendBounded(cell.Loss, float64(*sm.Metrics.LossPercent))
+	}
+}
+
+func appendBounded(vals []float64, v float64) []float64 {
+	vals = append(vals, v)
+	if len(vals) > heatmapMaxValues {
+		vals = vals[len(vals)-heatmapMaxValues:]
+	}
+	return vals
+}
+
+// Heatmap returns the 7x24 median grid per member, weekday-major with
+// Sunday first (matching time.Weekday).
+func (s *Store) Heatmap() map[string][7][24]HeatmapCell {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make(map[string][7][24]HeatmapCell, len(s.heat))
+	for member, grid := range s.heat {
+		var g [7][24]HeatmapCell
+		for d := 0; d < 7; d++ {
+			for h := 0; h < 24; h++ {
+				cell := grid[d][h]
+				n := len(cell.Latency)
+				if len(cell.Loss) > n {
+					n = len(cell.Loss)
+				}
+				g[d][h] = HeatmapCell{
+					MedianLatencyMS: median(cell.Latency),
+					MedianLossPct:   median(cell.Loss),
+					Samples:         n,
+				}
+			}
+		}
+		out[member] = g
+	}
+	return out
+}
+
+// median returns the middle value (mean of the two middles for even
+// counts), or 0 for an empty slice.
+func median(vals []float64) float64 {
+	if len(vals) == 0 {
+		return 0
+	}
+	sorted := make([]float64, len(vals))
+	copy(sorted, vals)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+// flushHeatmap writes the stored grids next to events.json; the caller
+// holds at least a read lock.
+func (s *Store) flushHeatmap() error {
+	b, err := json.Marshal(s.heat)
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(s.dir, "heatmap.json.tmp")
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(s.dir, "heatmap.json"))
+}
+
+// LoadHeatmap restores flushed grids, ignoring a missing file.
+func (s *Store) LoadHeatmap() error {
+	b, err := os.ReadFile(filepath.Join(s.dir, "heatmap.json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	heat := make(map[string]*[7][24]heatCell)
+	if err := json.Unmarshal(b, &heat); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.heat = heat
+	s.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,65 @@
+package telemetry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/metrics"
+)
+
+func sampleAt(member string, ts time.Time, latency, loss float64) Sample {
+	return Sample{
+		Member: member,
+		Metrics: &metrics.Metrics{
+			Timestamp:   ts,
+			LatencyMS:   metrics.Milliseconds(latency).Ptr(),
+			LossPercent: metrics.Percent(loss).Ptr(),
+		},
+	}
+}
+
+func TestHeatmapMedians(t *testing.T) {
+	s := NewStore(t.TempDir())
+	// A Monday at 14:xx local time.
+	ts := time.Date(2026, 3, 2, 14, 5, 0, 0, time.Local)
+	for _, lat := range []float64{40, 60, 500} {
+		s.AddSample(sampleAt("starlink", ts, lat, 1))
+	}
+
+	grid, ok := s.Heatmap()["starlink"]
+	if !ok {
+		t.Fatal("no grid for starlink")
+	}
+	cell := grid[int(time.Monday)][14]
+	if cell.MedianLatencyMS != 60 {
+		t.Errorf("median latency = %v, want 60", cell.MedianLatencyMS)
+	}
+	if cell.MedianLossPct != 1 {
+		t.Errorf("median loss = %v, want 1", cell.MedianLossPct)
+	}
+	if cell.Samples != 3 {
+		t.Errorf("samples = %d, want 3", cell.Samples)
+	}
+	if other := grid[int(time.Tuesday)][14]; other.Samples != 0 {
+		t.Errorf("unexpected samples in adjacent bucket: %+v", other)
+	}
+}
+
+func TestHeatmapFlushRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	s := NewStore(dir)
+	ts := time.Date(2026, 3, 2, 14, 5, 0, 0, time.Local)
+	s.AddSample(sampleAt("wwan", ts, 80, 0))
+	if err := s.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	restored := NewStore(dir)
+	if err := restored.LoadHeatmap(); err != nil {
+		t.Fatalf("LoadHeatmap: %v", err)
+	}
+	cell := restored.Heatmap()["wwan"][int(time.Monday)][14]
+	if cell.MedianLatencyMS != 80 || cell.Samples != 1 {
+		t.Errorf("restored cell = %+v", cell)
+	}
+}
@@ -0,0 +1,40 @@
+package telemetry
+
+import "time"
+
+// EventSnapshot is a compact capture of recent state attached to failover
+// and member-failure events, so inspecting an event later does not require
+// correlating separate sample queries against the same time window.
+type EventSnapshot struct {
+	// Active is the active member as of the event.
+	Active string `json:"active,omitempty"`
+	// Forced and Paused mirror the engine's manual-override state.
+	Forced string `json:"forced,omitempty"`
+	Paused bool   `json:"paused,omitempty"`
+	// Members holds the involved members' scores, lifecycle states and
+	// recent sample windows.
+	Members map[string]MemberSnapshot `json:"members,omitempty"`
+}
+
+// MemberSnapshot is one member's contribution to an EventSnapshot.
+type MemberSnapshot struct {
+	Score   float64  `json:"score"`
+	State   string   `json:"state,omitempty"`
+	Samples []Sample `json:"samples,omitempty"` // oldest first
+}
+
+// RecentSamples returns member's samples from the last window, oldest
+// first, judged by each sample's metrics timestamp.
+func (s *Store) RecentSamples(member string, window time.Duration) []Sample {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	cutoff := time.Now().Add(-window)
+	buf := s.samples[member]
+	i := len(buf)
+	for i > 0 && buf[i-1].Metrics != nil && buf[i-1].Metrics.Timestamp.After(cutoff) {
+		i--
+	}
+	out := make([]Sample, len(buf)-i)
+	copy(out, buf[i:])
+	return out
+}
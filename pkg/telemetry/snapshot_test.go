@@ -0,0 +1,48 @@
+package telemetry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/metrics"
+)
+
+func TestRecentSamplesWindow(t *testing.T) {
+	store := NewStore(t.TempDir())
+	now := time.Now()
+	for _, age := range []time.Duration{5 * time.Minute, 90 * time.Second, 40 * time.Second, 5 * time.Second} {
+		store.AddSample(Sample{
+			Member:  "wan",
+			Metrics: &metrics.Metrics{Timestamp: now.Add(-age)},
+			Score:   age.Seconds(),
+		})
+	}
+	got := store.RecentSamples("wan", time.Minute)
+	if len(got) != 2 {
+		t.Fatalf("RecentSamples returned %d samples, want 2", len(got))
+	}
+	if got[0].Score != 40 || got[1].Score != 5 {
+		t.Fatalf("samples out of order or misfiltered: %+v", got)
+	}
+	if rest := store.RecentSamples("other", time.Minute); len(rest) != 0 {
+		t.Fatalf("unknown member returned %d samples", len(rest))
+	}
+}
+
+func TestEventSnapshotRoundTrips(t *testing.T) {
+	store := NewStore(t.TempDir())
+	store.AddEvent(Event{
+		Timestamp: time.Now(), Type: "failover", Member: "cellular",
+		Snapshot: &EventSnapshot{
+			Active:  "cellular",
+			Members: map[string]MemberSnapshot{"cellular": {Score: 72, State: "eligible"}},
+		},
+	})
+	events := store.Events(1)
+	if len(events) != 1 || events[0].Snapshot == nil {
+		t.Fatalf("snapshot lost on the way through the store: %+v", events)
+	}
+	if ms := events[0].Snapshot.Members["cellular"]; ms.Score != 72 {
+		t.Fatalf("member snapshot = %+v", ms)
+	}
+}
@@ -0,0 +1,175 @@
+package telemetry
+
+// The tap streams every new sample and event to local consumers as NDJSON
+// over a unix socket — one JSON object per line, no polling, no broker. A
+// shell script or node-red flow connects, optionally writes one filter
+// line, and reads until it hangs up. Slow readers are disconnected rather
+// than allowed to stall the daemon.
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultTapSocket is where the telemetry tap listens.
+const DefaultTapSocket = "/var/run/starfaild-tap.sock"
+
+// tapWriteTimeout bounds how long one client write may block before the
+// client is dropped.
+const tapWriteTimeout = time.Second
+
+// TapFilter is the optional first line a client sends; empty fields mean
+// "everything".
+type TapFilter struct {
+	Kinds   []string `json:"kinds,omitempty"`   // "sample" and/or "event"
+	Members []string `json:"members,omitempty"` // member names
+	Events  []string `json:"events,omitempty"`  // event types
+}
+
+func (f TapFilter) wants(kind, member, eventType string) bool {
+	if !contains(f.Kinds, kind) {
+		return false
+	}
+	if member != "" && !contains(f.Members, member) {
+		return false
+	}
+	if kind == "event" && !contains(f.Events, eventType) {
+		return false
+	}
+	return true
+}
+
+// contains treats an empty list as "match anything".
+func contains(list []string, v string) bool {
+	if len(list) == 0 {
+		return true
+	}
+	for _, s := range list {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+// tapLine is one published NDJSON object.
+type tapLine struct {
+	Kind   string  `json:"kind"` // "sample" | "event"
+	Sample *Sample `json:"sample,omitempty"`
+	Event  *Event  `json:"event,omitempty"`
+}
+
+// Tap fans samples and events out to connected unix-socket clients.
+type Tap struct {
+	mu      sync.Mutex
+	clients map[net.Conn]TapFilter
+}
+
+// NewTap returns a Tap with no clients; attach it with Store.SetTap and
+// start it with Serve.
+func NewTap() *Tap {
+	return &Tap{clients: make(map[net.Conn]TapFilter)}
+}
+
+// Serve listens on path until ctx is cancelled. A stale socket file from a
+// previous run is removed first.
+func (t *Tap) Serve(ctx context.Context, path string) error {
+	_ = os.Remove(path)
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return err
+	}
+	go func() {
+		<-ctx.Done()
+		_ = ln.Close()
+	}()
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return err // listener closed on shutdown
+		}
+		t.mu.Lock()
+		t.clients[conn] = TapFilter{}
+		t.mu.Unlock()
+		go t.readFilters(conn)
+	}
+}
+
+// readFilters applies each filter line the client sends and detects
+// hangups; most clients send one line or nothing at all. Each applied
+// filter is acknowledged with a {"kind":"filter_ack"} line so the client
+// knows from which point the stream is filtered.
+func (t *Tap) readFilters(conn net.Conn) {
+	sc := bufio.NewScanner(conn)
+	for sc.Scan() {
+		var f TapFilter
+		if err := json.Unmarshal(sc.Bytes(), &f); err != nil {
+			continue // garbage in, filter unchanged
+		}
+		t.mu.Lock()
+		if _, ok := t.clients[conn]; ok {
+			t.clients[conn] = f
+			_ = conn.SetWriteDeadline(time.Now().Add(tapWriteTimeout))
+			_, _ = conn.Write([]byte("{\"kind\":\"filter_ack\"}\n"))
+		}
+		t.mu.Unlock()
+	}
+	t.drop(conn)
+}
+
+func (t *Tap) drop(conn net.Conn) {
+	t.mu.Lock()
+	delete(t.clients, conn)
+	t.mu.Unlock()
+	_ = conn.Close()
+}
+
+// publish sends one line to every client whose filter matches.
+func (t *Tap) publish(line tapLine) {
+	t.mu.Lock()
+	if len(t.clients) == 0 {
+		t.mu.Unlock()
+		return
+	}
+	b, err := json.Marshal(line)
+	if err != nil {
+		t.mu.Unlock()
+		return
+	}
+	b = append(b, '\n')
+	var dead []net.Conn
+	member, eventType := "", ""
+	if line.Sample != nil {
+		member = line.Sample.Member
+	}
+	if line.Event != nil {
+		member = line.Event.Member
+		eventType = line.Event.Type
+	}
+	for conn, f := range t.clients {
+		if !f.wants(line.Kind, member, eventType) {
+			continue
+		}
+		_ = conn.SetWriteDeadline(time.Now().Add(tapWriteTimeout))
+		if _, err := conn.Write(b); err != nil {
+			dead = append(dead, conn)
+		}
+	}
+	t.mu.Unlock()
+	for _, conn := range dead {
+		t.drop(conn)
+	}
+}
+
+// SetTap attaches a tap; every subsequent AddSample/AddEvent is streamed to
+// its clients in addition to being stored.
+func (s *Store) SetTap(t *Tap) {
+	s.mu.Lock()
+	s.tap = t
+	s.mu.Unlock()
+}
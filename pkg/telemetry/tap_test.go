@@ -0,0 +1,102 @@
+package telemetry
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// startTap serves a tap on a temp socket and returns its path.
+func startTap(t *testing.T, store *Store) string {
+	t.Helper()
+	sock := filepath.Join(t.TempDir(), "tap.sock")
+	tap := NewTap()
+	store.SetTap(tap)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go func() { _ = tap.Serve(ctx, sock) }()
+	for i := 0; i < 100; i++ {
+		if _, err := os.Stat(sock); err == nil {
+			return sock
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("tap socket never appeared")
+	return ""
+}
+
+func dialTap(t *testing.T, sock string) (net.Conn, *bufio.Scanner) {
+	t.Helper()
+	conn, err := net.Dial("unix", sock)
+	if err != nil {
+		t.Fatalf("dial tap: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn, bufio.NewScanner(conn)
+}
+
+func TestTapStreamsSamplesAndEvents(t *testing.T) {
+	store := NewStore(t.TempDir())
+	sock := startTap(t, store)
+	conn, sc := dialTap(t, sock)
+	_ = conn // no filter: everything flows
+
+	// Give the accept loop a moment to register the client.
+	time.Sleep(50 * time.Millisecond)
+	store.AddSample(Sample{Member: "wan", Score: 88})
+	store.AddEvent(Event{Timestamp: time.Now(), Type: "failover", Member: "wan"})
+
+	var kinds []string
+	for len(kinds) < 2 && sc.Scan() {
+		var line struct {
+			Kind string `json:"kind"`
+		}
+		if err := json.Unmarshal(sc.Bytes(), &line); err != nil {
+			t.Fatalf("bad line %q: %v", sc.Text(), err)
+		}
+		kinds = append(kinds, line.Kind)
+	}
+	if len(kinds) != 2 || kinds[0] != "sample" || kinds[1] != "event" {
+		t.Errorf("kinds = %v", kinds)
+	}
+}
+
+func TestTapFilters(t *testing.T) {
+	store := NewStore(t.TempDir())
+	sock := startTap(t, store)
+	conn, sc := dialTap(t, sock)
+
+	if _, err := conn.Write([]byte(`{"kinds":["event"],"events":["failover"]}` + "\n")); err != nil {
+		t.Fatalf("write filter: %v", err)
+	}
+	// The ack marks the point from which the stream is filtered.
+	if !sc.Scan() {
+		t.Fatal("no filter ack")
+	}
+	if got := sc.Text(); got != `{"kind":"filter_ack"}` {
+		t.Fatalf("ack = %q", got)
+	}
+
+	store.AddSample(Sample{Member: "wan", Score: 88})
+	store.AddEvent(Event{Timestamp: time.Now(), Type: "member_failed", Member: "wan"})
+	store.AddEvent(Event{Timestamp: time.Now(), Type: "failover", Member: "wan"})
+
+	if !sc.Scan() {
+		t.Fatal("no line after filter")
+	}
+	var line struct {
+		Kind  string `json:"kind"`
+		Event *Event `json:"event"`
+	}
+	if err := json.Unmarshal(sc.Bytes(), &line); err != nil {
+		t.Fatalf("bad line %q: %v", sc.Text(), err)
+	}
+	if line.Kind != "event" || line.Event == nil || line.Event.Type != "failover" {
+		t.Errorf("first delivered line = %q, want the failover event", sc.Text())
+	}
+}
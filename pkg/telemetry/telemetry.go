@@ -0,0 +1,174 @@
+// Package telemetry keeps a bounded in-memory history of samples and events
+// per member, periodically flushed to persistent storage under /overlay (or
+// /tmp on RAM-constrained devices).
+package telemetry
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/metrics"
+)
+
+// DefaultDir is where snapshots are flushed.
+const DefaultDir = "/tmp/starfail"
+
+// Event records a notable occurrence (failover, restore, predictive alert).
+type Event struct {
+	Timestamp time.Time         `json:"timestamp"`
+	Type      string            `json:"type"`
+	Member    string            `json:"member,omitempty"`
+	Reason    string            `json:"reason,omitempty"`
+	Details   map[string]any    `json:"details,omitempty"`
+	// Snapshot, when present, freezes the state surrounding the event;
+	// see snapshot.go.
+	Snapshot *EventSnapshot `json:"snapshot,omitempty"`
+}
+
+// Sample couples a member name with one metrics observation and its score.
+type Sample struct {
+	Member  string           `json:"member"`
+	Metrics *metrics.Metrics `json:"metrics"`
+	Score   float64          `json:"score"`
+}
+
+// Store is a ring buffer of samples and events.
+type Store struct {
+	mu       sync.RWMutex
+	dir      string
+	maxSamples int
+	maxEvents  int
+	samples  map[string][]Sample // per member, oldest first
+	events   []Event
+	heat     map[string]*[7][24]heatCell // see heatmap.go
+	tap      *Tap                        // optional live stream; see tap.go
+	blackbox *Blackbox                   // optional crash ring; see blackbox.go
+}
+
+// SetBlackbox attaches a crash-forensics ring; every sample and event is
+// mirrored into it as a compact one-line record.
+func (s *Store) SetBlackbox(b *Blackbox) {
+	s.mu.Lock()
+	s.blackbox = b
+	s.mu.Unlock()
+}
+
+// NewStore creates a store flushing into dir ("" for DefaultDir).
+func NewStore(dir string) *Store {
+	if dir == "" {
+		dir = DefaultDir
+	}
+	return &Store{
+		dir:        dir,
+		maxSamples: 720, // one hour at 5s ticks
+		maxEvents:  200,
+		samples:    make(map[string][]Sample),
+	}
+}
+
+// AddSample appends a sample for a member, evicting the oldest past cap.
+func (s *Store) AddSample(sm Sample) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	buf := append(s.samples[sm.Member], sm)
+	if len(buf) > s.maxSamples {
+		buf = buf[len(buf)-s.maxSamples:]
+	}
+	s.samples[sm.Member] = buf
+	s.observeHeatmap(sm)
+	if s.tap != nil {
+		s.tap.publish(tapLine{Kind: "sample", Sample: &sm})
+	}
+	s.blackbox.Append(blackboxSample(sm))
+}
+
+// AddEvent appends an event.
+func (s *Store) AddEvent(e Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, e)
+	if len(s.events) > s.maxEvents {
+		s.events = s.events[len(s.events)-s.maxEvents:]
+	}
+	if s.tap != nil {
+		s.tap.publish(tapLine{Kind: "event", Event: &e})
+	}
+	line := "event " + e.Type
+	if e.Member != "" {
+		line += " member=" + e.Member
+	}
+	if e.Reason != "" {
+		line += " reason=" + e.Reason
+	}
+	s.blackbox.Append(line)
+}
+
+// Samples returns up to n most recent samples for a member, oldest first.
+func (s *Store) Samples(member string, n int) []Sample {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	buf := s.samples[member]
+	if n > 0 && len(buf) > n {
+		buf = buf[len(buf)-n:]
+	}
+	out := make([]Sample, len(buf))
+	copy(out, buf)
+	return out
+}
+
+// Events returns up to n most recent events, oldest first.
+func (s *Store) Events(n int) []Event {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	buf := s.events
+	if n > 0 && len(buf) > n {
+		buf = buf[len(buf)-n:]
+	}
+	out := make([]Event, len(buf))
+	copy(out, buf)
+	return out
+}
+
+// Flush writes the current events and heatmap to disk; sample history is
+// considered disposable across restarts.
+func (s *Store) Flush() error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return err
+	}
+	b, err := json.Marshal(s.events)
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(s.dir, "events.json.tmp")
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, filepath.Join(s.dir, "events.json")); err != nil {
+		return err
+	}
+	return s.flushHeatmap()
+}
+
+// LoadEvents restores flushed events, ignoring a missing file.
+func (s *Store) LoadEvents() error {
+	b, err := os.ReadFile(filepath.Join(s.dir, "events.json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	var events []Event
+	if err := json.Unmarshal(b, &events); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.events = events
+	s.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,131 @@
+package ubus
+
+import (
+	"context"
+	"log"
+	"math/rand"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Outgoing calls shell out to the ubus CLI per invocation, so they need no
+// connection state and survive a ubusd restart on their own. What does not
+// survive is the daemon's object registration: rpcd registers "starfail"
+// on behalf of the shim, and when ubusd restarts (firmware updates do
+// this) the object is gone until rpcd re-registers. Monitor watches for
+// that and nudges rpcd back into shape.
+
+// Poll cadences for the monitor: a slow check while healthy, jittered
+// exponential backoff while the bus is down.
+const (
+	monitorHealthyInterval = 30 * time.Second
+	monitorBackoffMin      = time.Second
+	monitorBackoffMax      = time.Minute
+)
+
+// busExec runs one ubus CLI invocation; a variable so tests can stub the
+// bus away.
+var busExec = func(args ...string) ([]byte, error) {
+	return exec.Command("ubus", args...).Output()
+}
+
+// reregister asks rpcd to reload, which re-registers all shim-backed
+// objects; a variable for the same reason.
+var reregister = func() error {
+	return exec.Command("/etc/init.d/rpcd", "reload").Run()
+}
+
+// MonitorStatus is the exported connectivity view.
+type MonitorStatus struct {
+	Connected  bool   `json:"connected"`
+	Registered bool   `json:"registered"`
+	Reconnects uint64 `json:"reconnects"`
+	LastError  string `json:"last_error,omitempty"`
+}
+
+// Monitor tracks ubus connectivity and keeps the daemon's object
+// registered across ubusd restarts.
+type Monitor struct {
+	object string
+
+	mu     sync.Mutex
+	status MonitorStatus
+}
+
+// NewMonitor returns a Monitor expecting the given object (normally
+// "starfail") to exist on the bus.
+func NewMonitor(object string) *Monitor {
+	return &Monitor{object: object}
+}
+
+// Status returns the current connectivity view.
+func (m *Monitor) Status() MonitorStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.status
+}
+
+// Run polls until ctx is cancelled: every 30s while healthy, backing off
+// from 1s to 60s with ±20% jitter while the bus is unreachable so a fleet
+// of daemons does not hammer a restarting ubusd in lockstep.
+func (m *Monitor) Run(ctx context.Context) {
+	backoff := monitorBackoffMin
+	for {
+		healthy := m.check()
+		var wait time.Duration
+		if healthy {
+			backoff = monitorBackoffMin
+			wait = monitorHealthyInterval
+		} else {
+			wait = backoff
+			backoff *= 2
+			if backoff > monitorBackoffMax {
+				backoff = monitorBackoffMax
+			}
+		}
+		wait += time.Duration(rand.Int63n(int64(wait) / 5)) // +0-20% jitter
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// check performs one probe and updates status. It returns false only when
+// the bus itself is unreachable; a missing object triggers
+// re-registration but counts as connected.
+func (m *Monitor) check() bool {
+	out, err := busExec("list", m.object)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err != nil {
+		if m.status.Connected {
+			log.Printf("ubus: bus unreachable: %v", err)
+		}
+		m.status.Connected = false
+		m.status.Registered = false
+		m.status.LastError = err.Error()
+		return false
+	}
+	if !m.status.Connected {
+		log.Println("ubus: bus reachable again")
+		m.status.Reconnects++
+	}
+	m.status.Connected = true
+	m.status.LastError = ""
+	if strings.Contains(string(out), m.object) {
+		m.status.Registered = true
+		return true
+	}
+	// Bus is up but our object is gone: ubusd restarted underneath rpcd.
+	m.status.Registered = false
+	log.Printf("ubus: object %q missing, reloading rpcd", m.object)
+	if err := reregister(); err != nil {
+		m.status.LastError = "rpcd reload: " + err.Error()
+		log.Printf("ubus: rpcd reload: %v", err)
+	}
+	return true
+}
@@ -0,0 +1,51 @@
+package ubus
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestMonitorCheck(t *testing.T) {
+	origExec, origRereg := busExec, reregister
+	t.Cleanup(func() { busExec, reregister = origExec, origRereg })
+
+	m := NewMonitor("starfail")
+	reloads := 0
+	reregister = func() error { reloads++; return nil }
+
+	// Bus down.
+	busExec = func(...string) ([]byte, error) { return nil, fmt.Errorf("connection refused") }
+	if m.check() {
+		t.Fatal("check reported healthy with the bus down")
+	}
+	st := m.Status()
+	if st.Connected || st.LastError == "" {
+		t.Fatalf("status = %+v, want disconnected with an error", st)
+	}
+
+	// Bus back, object missing: counts as connected, triggers reload.
+	busExec = func(...string) ([]byte, error) { return []byte(""), nil }
+	if !m.check() {
+		t.Fatal("check reported bus down while only the object was missing")
+	}
+	st = m.Status()
+	if !st.Connected || st.Registered || st.Reconnects != 1 {
+		t.Fatalf("status = %+v, want connected/unregistered after 1 reconnect", st)
+	}
+	if reloads != 1 {
+		t.Fatalf("rpcd reloads = %d, want 1", reloads)
+	}
+
+	// Object registered again.
+	busExec = func(...string) ([]byte, error) { return []byte("'starfail'\n"), nil }
+	if !m.check() {
+		t.Fatal("check reported unhealthy with everything up")
+	}
+	st = m.Status()
+	if !st.Registered || st.Reconnects != 1 {
+		t.Fatalf("status = %+v, want registered with 1 reconnect", st)
+	}
+	if reloads != 1 {
+		t.Fatalf("rpcd reloads = %d, want still 1", reloads)
+	}
+}
@@ -0,0 +1,172 @@
+// Package ubus connects the daemon to the OpenWrt ubus message bus.
+//
+// Outgoing calls shell out to the ubus CLI (always present on RUTOS).
+// Incoming calls are served over a local unix socket speaking line-delimited
+// JSON; a small executable shim installed in /usr/libexec/rpcd/starfail
+// forwards `ubus call starfail <method>` invocations to that socket, which
+// avoids linking libubus and keeps the daemon pure Go.
+package ubus
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"sync"
+)
+
+// DefaultSocket is where the rpcd shim expects to reach the daemon.
+const DefaultSocket = "/var/run/starfaild.sock"
+
+// Call invokes a method on another ubus object and unmarshals the reply
+// into out (which may be nil to discard the reply).
+func Call(object, method string, args any, out any) error {
+	cmd := []string{"call", object, method}
+	if args != nil {
+		b, err := json.Marshal(args)
+		if err != nil {
+			return err
+		}
+		cmd = append(cmd, string(b))
+	}
+	raw, err := exec.Command("ubus", cmd...).Output()
+	if err != nil {
+		return fmt.Errorf("ubus call %s %s: %w", object, method, err)
+	}
+	if out == nil || len(raw) == 0 {
+		return nil
+	}
+	return json.Unmarshal(raw, out)
+}
+
+// Handler serves one method. Args is the raw JSON argument object; the
+// returned value is marshalled as the reply.
+type Handler func(args json.RawMessage) (any, error)
+
+// StreamHandler serves one streaming method. Each emit writes a chunk as its
+// own response line with "more" set; when the handler returns, the server
+// writes a final line without "more" (carrying the error, if any) so clients
+// know the stream ended. emit fails when the client has gone away.
+type StreamHandler func(args json.RawMessage, emit func(chunk any) error) error
+
+// Server exposes the daemon's ubus object over the unix socket.
+type Server struct {
+	mu       sync.RWMutex
+	handlers map[string]Handler
+	streams  map[string]StreamHandler
+	ln       net.Listener
+}
+
+// NewServer returns an empty server; register methods before Serve.
+func NewServer() *Server {
+	return &Server{
+		handlers: make(map[string]Handler),
+		streams:  make(map[string]StreamHandler),
+	}
+}
+
+// Register adds a method handler. Registering an existing name replaces it.
+func (s *Server) Register(method string, h Handler) {
+	s.mu.Lock()
+	s.handlers[method] = h
+	s.mu.Unlock()
+}
+
+// RegisterStream adds a streaming method handler.
+func (s *Server) RegisterStream(method string, h StreamHandler) {
+	s.mu.Lock()
+	s.streams[method] = h
+	s.mu.Unlock()
+}
+
+// Methods returns the registered method names (for introspection).
+func (s *Server) Methods() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	names := make([]string, 0, len(s.handlers)+len(s.streams))
+	for n := range s.handlers {
+		names = append(names, n)
+	}
+	for n := range s.streams {
+		names = append(names, n)
+	}
+	return names
+}
+
+type request struct {
+	Method string          `json:"method"`
+	Args   json.RawMessage `json:"args"`
+}
+
+type response struct {
+	Result any    `json:"result,omitempty"`
+	Error  string `json:"error,omitempty"`
+	More   bool   `json:"more,omitempty"` // further stream chunks follow
+}
+
+// Serve listens on path until the listener is closed. Each connection may
+// issue multiple newline-delimited requests.
+func (s *Server) Serve(path string) error {
+	_ = os.Remove(path)
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return err
+	}
+	s.ln = ln
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return err
+		}
+		go s.handleConn(conn)
+	}
+}
+
+// Close stops the listener.
+func (s *Server) Close() error {
+	if s.ln != nil {
+		return s.ln.Close()
+	}
+	return nil
+}
+
+func (s *Server) handleConn(conn net.Conn) {
+	defer conn.Close()
+	sc := bufio.NewScanner(conn)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	enc := json.NewEncoder(conn)
+	for sc.Scan() {
+		var req request
+		if err := json.Unmarshal(sc.Bytes(), &req); err != nil {
+			_ = enc.Encode(response{Error: "bad request"})
+			continue
+		}
+		s.mu.RLock()
+		h, ok := s.handlers[req.Method]
+		sh, sok := s.streams[req.Method]
+		s.mu.RUnlock()
+		if sok {
+			err := sh(req.Args, func(chunk any) error {
+				return enc.Encode(response{Result: chunk, More: true})
+			})
+			if err != nil {
+				_ = enc.Encode(response{Error: err.Error()})
+			} else {
+				_ = enc.Encode(response{Result: map[string]bool{"done": true}})
+			}
+			continue
+		}
+		if !ok {
+			_ = enc.Encode(response{Error: "method not found: " + req.Method})
+			continue
+		}
+		res, err := h(req.Args)
+		if err != nil {
+			_ = enc.Encode(response{Error: err.Error()})
+			continue
+		}
+		_ = enc.Encode(response{Result: res})
+	}
+}
@@ -0,0 +1,193 @@
+package uci
+
+// Config is the parsed starfail UCI package. Defaults match the values the
+// shell monitor historically used so a bare config behaves identically.
+//
+// The uci/default/range/desc tags are the canonical option reference:
+// docs.go derives the documentation and the `starfailctl config` command
+// from them, and docs_test.go pins the tagged defaults to the loader's
+// actual behaviour. Keep the tags in step with LoadConfig.
+type Config struct {
+	// Main section (starfail.main).
+	Enabled        bool    `uci:"starfail.main.enabled" default:"1" desc:"master switch; when off the daemon idles"`
+	PollIntervalS  int     `uci:"starfail.main.poll_interval" default:"5" range:"1-3600" desc:"decision tick interval, seconds"`
+	LogLevel       string  `uci:"starfail.main.log_level" default:"info" desc:"log verbosity: debug|info|warn|error"`
+	FailScore      float64 `uci:"starfail.main.fail_score" default:"40" range:"0-100" desc:"score below which a member is considered failed"`
+	RestoreScore   float64 `uci:"starfail.main.restore_score" default:"70" range:"0-100" desc:"score above which a failed member may be restored"`
+	RestoreWindowS int     `uci:"starfail.main.restore_window" default:"300" range:"0-86400" desc:"seconds a member must stay good before failback"`
+	CooldownS      int     `uci:"starfail.main.cooldown" default:"60" range:"0-3600" desc:"minimum seconds between switches"`
+	WarmupS        int     `uci:"starfail.main.warmup" default:"30" range:"0-3600" desc:"settle time after a member comes up, seconds"`
+	WarmupScore    float64 `uci:"starfail.main.warmup_score" default:"70" range:"0-100" desc:"average score warm-up must reach before eligibility"`
+	WarnScore      float64 `uci:"starfail.main.warn_score" default:"55" range:"0-100" desc:"soft threshold; dropping below warns without failing over (0 disables)"`
+	Profile        string  `uci:"starfail.main.profile" default:"web" desc:"traffic profile: web|voip|gaming|backup"`
+
+	// Strategy selects the decision strategy: "score" compares blended
+	// quality scores, "rules" walks an explicit per-member priority order
+	// for deployments that want deterministic failover.
+	Strategy string `uci:"starfail.main.strategy" default:"score" desc:"decision strategy: score|rules"`
+
+	// AllowMockMetrics lets mock-sourced samples reach scoring; off by
+	// default so a dead dish API degrades the member instead of feeding
+	// fabricated "all is well" values into decisions.
+	AllowMockMetrics bool `uci:"starfail.main.allow_mock_metrics" default:"0" desc:"let mock-sourced samples reach scoring"`
+
+	// DryRun makes the engine observe only: it collects, scores and
+	// decides as usual but records would_failover events instead of ever
+	// touching mwan3, so a new deployment can be watched for a while
+	// before it is trusted with routing changes.
+	DryRun bool `uci:"starfail.main.dry_run" default:"0" desc:"observe only: record would_failover events, never change routing"`
+
+	// Scoring tunables (starfail.scoring). Defaults favour stability;
+	// deployments that want faster reaction can raise the alpha and the
+	// instant blend weight.
+	EWMAAlpha    float64 `uci:"starfail.scoring.ewma_alpha" default:"0.2" range:"0-1" desc:"EWMA smoothing factor, (0, 1]"`
+	BlendInstant float64 `uci:"starfail.scoring.blend_instant" default:"0.3" range:"0-" desc:"blend weight of the instantaneous score"`
+	BlendEWMA    float64 `uci:"starfail.scoring.blend_ewma" default:"0.5" range:"0-" desc:"blend weight of the EWMA"`
+	BlendWindow  float64 `uci:"starfail.scoring.blend_window" default:"0.2" range:"0-" desc:"blend weight of the window average"`
+
+	// Metered-conservation tunables (starfail.main). While the active
+	// member is cellular, failed members are re-polled only every
+	// BackupPollIntervalS seconds and probes shrink to MeteredProbeCount
+	// pings, so recovery detection stays bounded without burning data.
+	BackupPollIntervalS int `uci:"starfail.main.backup_poll_interval" default:"60" range:"5-3600" desc:"re-poll interval for failed members on metered links, seconds"`
+	MeteredProbeCount   int `uci:"starfail.main.metered_probe_count" default:"1" range:"1-10" desc:"pings per probe while on a metered link"`
+
+	// Probe marking and volume budgeting (starfail.main). ProbeDSCP
+	// marks probe packets so upstream QoS treats them like user traffic;
+	// ProbeBudgetMB bounds per-member probe volume per day (0 disables;
+	// an exhausted member still gets one ping per target).
+	ProbeDSCP     int     `uci:"starfail.main.probe_dscp" default:"0" range:"0-63" desc:"DSCP codepoint for probe packets (0 leaves them unmarked)"`
+	ProbeBudgetMB float64 `uci:"starfail.main.probe_budget_mb" default:"0" range:"0-" desc:"probe byte budget per member per day, MB (0 disables)"`
+
+	// Priority-data budget (starfail.budget). BudgetGB caps how much data
+	// may flow while Starlink is active per billing period (0 disables
+	// tracking); BudgetResetDay is the billing day of month (1-28); with
+	// BudgetDeprioritize set, an exhausted budget moves traffic to a
+	// flat-rate member when one is eligible.
+	BudgetGB           float64 `uci:"starfail.budget.starlink_gb" default:"0" range:"0-" desc:"priority data per billing period, GB (0 disables tracking)"`
+	BudgetResetDay     int     `uci:"starfail.budget.reset_day" default:"1" range:"1-28" desc:"billing day of month the budget resets on"`
+	BudgetDeprioritize bool    `uci:"starfail.budget.deprioritize" default:"0" desc:"move traffic to a flat-rate member when the budget is exhausted"`
+
+	// Members parsed from member sections.
+	Members []MemberConfig
+
+	// ProbeProfiles maps member class -> UCI target list entries of the
+	// form "host" or "host@weight", from probe_profile sections.
+	ProbeProfiles map[string][]string
+}
+
+// MemberConfig is one "config member" section of /etc/config/starfail.
+// Tags use <member> as the section-name placeholder.
+type MemberConfig struct {
+	Name   string // section name, also the mwan3 member name
+	Iface  string `uci:"starfail.<member>.iface" desc:"netifd logical interface, e.g. wan, mob1s1a1 (defaults to the member name)"`
+	Class  string `uci:"starfail.<member>.class" default:"generic" desc:"starlink|cellular|wifi|lan|vpn|generic"`
+	Weight int    `uci:"starfail.<member>.weight" default:"50" range:"1-100" desc:"preference weight, higher wins ties"`
+
+	// Priority ranks this member for the rules strategy; lower wins and 0
+	// (unset) sorts after every ranked member, in config order.
+	Priority int    `uci:"starfail.<member>.priority" default:"0" range:"0-100" desc:"rules strategy rank, lower wins; 0 = after ranked members, config order"`
+	Group    string `uci:"starfail.<member>.group" desc:"failure-domain override for members sharing hardware"`
+
+	// FlatRate marks a member whose data is flat-rate/unlimited; budget
+	// de-prioritization only moves traffic onto such members.
+	FlatRate bool `uci:"starfail.<member>.flat_rate" default:"0" desc:"data is flat-rate; eligible target for budget de-prioritization"`
+
+	// VRF names the VRF device whose routing table carries this member's
+	// traffic (management VRF setups). When set, probes and the dish client
+	// bind to the VRF device instead of Iface so the kernel routes them by
+	// the VRF's table; Iface still identifies the member to mwan3/netifd.
+	VRF string `uci:"starfail.<member>.vrf" desc:"VRF device carrying this member's traffic"`
+}
+
+// LoadConfig reads the whole starfail package. Missing options fall back to
+// defaults; a missing package yields a disabled config rather than an error
+// so the daemon can idle until the user configures it.
+func LoadConfig() (*Config, error) {
+	cfg := &Config{
+		Enabled:             GetBool("starfail.main.enabled", true),
+		PollIntervalS:       GetInt("starfail.main.poll_interval", 5),
+		LogLevel:            getString("starfail.main.log_level", "info"),
+		FailScore:           GetFloat("starfail.main.fail_score", 40),
+		RestoreScore:        GetFloat("starfail.main.restore_score", 70),
+		RestoreWindowS:      GetInt("starfail.main.restore_window", 300),
+		CooldownS:           GetInt("starfail.main.cooldown", 60),
+		WarmupS:             GetInt("starfail.main.warmup", 30),
+		WarmupScore:         GetFloat("starfail.main.warmup_score", 70),
+		WarnScore:           GetFloat("starfail.main.warn_score", 55),
+		Profile:             getString("starfail.main.profile", "web"),
+		Strategy:            getString("starfail.main.strategy", "score"),
+		AllowMockMetrics:    GetBool("starfail.main.allow_mock_metrics", false),
+		DryRun:              GetBool("starfail.main.dry_run", false),
+		EWMAAlpha:           GetFloat("starfail.scoring.ewma_alpha", 0.2),
+		BlendInstant:        GetFloat("starfail.scoring.blend_instant", 0.30),
+		BlendEWMA:           GetFloat("starfail.scoring.blend_ewma", 0.50),
+		BlendWindow:         GetFloat("starfail.scoring.blend_window", 0.20),
+		BackupPollIntervalS: GetInt("starfail.main.backup_poll_interval", 60),
+		MeteredProbeCount:   GetInt("starfail.main.metered_probe_count", 1),
+		ProbeDSCP:           GetInt("starfail.main.probe_dscp", 0),
+		ProbeBudgetMB:       GetFloat("starfail.main.probe_budget_mb", 0),
+		BudgetGB:            GetFloat("starfail.budget.starlink_gb", 0),
+		BudgetResetDay:      GetInt("starfail.budget.reset_day", 1),
+		BudgetDeprioritize:  GetBool("starfail.budget.deprioritize", false),
+	}
+	cfg.validateScoring()
+	for _, name := range Sections("starfail", "member") {
+		m := MemberConfig{
+			Name:     name,
+			Iface:    getString("starfail."+name+".iface", name),
+			Class:    getString("starfail."+name+".class", "generic"),
+			Weight:   GetInt("starfail."+name+".weight", 50),
+			Priority: GetInt("starfail."+name+".priority", 0),
+			Group:    getString("starfail."+name+".group", ""),
+			FlatRate: GetBool("starfail."+name+".flat_rate", false),
+			VRF:      getString("starfail."+name+".vrf", ""),
+		}
+		cfg.Members = append(cfg.Members, m)
+	}
+	cfg.ProbeProfiles = make(map[string][]string)
+	for _, name := range Sections("starfail", "probe_profile") {
+		class := getString("starfail."+name+".class", name)
+		if targets := GetList("starfail." + name + ".targets"); len(targets) > 0 {
+			cfg.ProbeProfiles[class] = targets
+		}
+	}
+	return cfg, nil
+}
+
+// validateScoring clamps the scoring tunables to safe bounds and
+// normalizes the blend weights to sum to 1, logging nothing: out-of-range
+// values are a configuration mistake, not a runtime fault, and the clamped
+// result is always usable.
+func (c *Config) validateScoring() {
+	if c.EWMAAlpha <= 0 || c.EWMAAlpha > 1 {
+		c.EWMAAlpha = 0.2
+	}
+	// Weights are relative, so any non-negative magnitude is fine — the
+	// normalization below gives them meaning; only negatives are nonsense
+	// and reset to their defaults.
+	nonneg := func(v, def float64) float64 {
+		if v < 0 {
+			return def
+		}
+		return v
+	}
+	c.BlendInstant = nonneg(c.BlendInstant, 0.30)
+	c.BlendEWMA = nonneg(c.BlendEWMA, 0.50)
+	c.BlendWindow = nonneg(c.BlendWindow, 0.20)
+	sum := c.BlendInstant + c.BlendEWMA + c.BlendWindow
+	if sum <= 0 {
+		c.BlendInstant, c.BlendEWMA, c.BlendWindow = 0.30, 0.50, 0.20
+		return
+	}
+	c.BlendInstant /= sum
+	c.BlendEWMA /= sum
+	c.BlendWindow /= sum
+}
+
+func getString(key, def string) string {
+	if s, ok := Get(key); ok {
+		return s
+	}
+	return def
+}
@@ -0,0 +1,37 @@
+package uci
+
+import "testing"
+
+func TestValidateScoring(t *testing.T) {
+	tests := []struct {
+		name                   string
+		alpha, bi, be, bw      float64
+		wantAlpha              float64
+		wantBI, wantBE, wantBW float64
+	}{
+		{"defaults pass through", 0.2, 0.30, 0.50, 0.20, 0.2, 0.30, 0.50, 0.20},
+		{"alpha out of range resets", 1.5, 0.30, 0.50, 0.20, 0.2, 0.30, 0.50, 0.20},
+		{"zero alpha resets", 0, 0.30, 0.50, 0.20, 0.2, 0.30, 0.50, 0.20},
+		{"weights normalized", 0.5, 1, 1, 2, 0.5, 0.25, 0.25, 0.50},
+		{"negative weight resets then normalizes", 0.5, -1, 0.50, 0.20, 0.5, 0.30, 0.50, 0.20},
+		{"all-zero weights reset", 0.5, 0, 0, 0, 0.5, 0.30, 0.50, 0.20},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Config{EWMAAlpha: tt.alpha, BlendInstant: tt.bi, BlendEWMA: tt.be, BlendWindow: tt.bw}
+			c.validateScoring()
+			if c.EWMAAlpha != tt.wantAlpha {
+				t.Errorf("alpha = %v, want %v", c.EWMAAlpha, tt.wantAlpha)
+			}
+			if !close3(c.BlendInstant, tt.wantBI) || !close3(c.BlendEWMA, tt.wantBE) || !close3(c.BlendWindow, tt.wantBW) {
+				t.Errorf("blend = %v/%v/%v, want %v/%v/%v",
+					c.BlendInstant, c.BlendEWMA, c.BlendWindow, tt.wantBI, tt.wantBE, tt.wantBW)
+			}
+		})
+	}
+}
+
+func close3(a, b float64) bool {
+	d := a - b
+	return d > -0.001 && d < 0.001
+}
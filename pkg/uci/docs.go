@@ -0,0 +1,301 @@
+package uci
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The canonical starfail option reference is derived from code: the
+// Config/MemberConfig struct tags in config.go plus the extraOptions
+// table below for sections that subsystem packages parse directly. The
+// same data renders the documentation (starfailctl config docs) and
+// drives live validation (starfailctl config check), so LuCI forms and
+// docs cannot drift from what the daemon actually reads.
+
+// OptionDoc describes one UCI option of the starfail package. Keys use
+// <type> placeholders for options of repeated sections, e.g.
+// "starfail.<member>.iface".
+type OptionDoc struct {
+	Key         string `json:"key"`
+	Type        string `json:"type"` // bool|int|float|string|list
+	Default     string `json:"default,omitempty"`
+	Range       string `json:"range,omitempty"` // "lo-hi"; either bound may be open
+	Description string `json:"description"`
+}
+
+// extraOptions documents options read by subsystem packages outside
+// LoadConfig (their FromUCI constructors). Keep entries in step with the
+// reads they describe; docs_test.go checks coverage for the core loader
+// only, so these are maintained by review.
+var extraOptions = []OptionDoc{
+	{Key: "starfail.main.mwan3_hygiene", Type: "bool", Default: "0", Description: "audit and repair mwan3 config drift at startup"},
+	{Key: "starfail.main.device_name", Type: "string", Description: "friendly device name attached to remote telemetry (defaults to the hostname)"},
+	{Key: "starfail.main.restart_peer", Type: "bool", Default: "1", Description: "restart a wedged sysmgmt peer via procd"},
+	{Key: "starfail.main.sla_objective", Type: "float", Range: "0-100", Description: "overall availability objective, percent"},
+	{Key: "starfail.<member>.sla_objective", Type: "float", Range: "0-100", Description: "per-member availability objective, percent"},
+	{Key: "starfail.<probe_profile>.class", Type: "string", Description: "member class this probe profile applies to (defaults to the section name)"},
+	{Key: "starfail.<probe_profile>.targets", Type: "list", Description: "probe targets, \"host\" or \"host@weight\""},
+	{Key: "starfail.starlink.addr", Type: "string", Default: "192.168.100.1:9200", Description: "dish gRPC API address"},
+	{Key: "starfail.starlink.allow_heuristic", Type: "bool", Default: "0", Description: "allow heuristic parsing of unknown dish API responses"},
+	{Key: "starfail.starlink.router_addr", Type: "string", Description: "Starlink router LAN address to scrape when the dish API is unreachable (no-bypass setups)"},
+	{Key: "starfail.monitoring.bind", Type: "string", Default: "127.0.0.1:9101", Description: "metrics/health listen address"},
+	{Key: "starfail.monitoring.tls", Type: "bool", Default: "0", Description: "serve metrics over TLS with a generated certificate"},
+	{Key: "starfail.monitoring.token", Type: "string", Description: "bearer/X-Auth-Token value (encryptable)"},
+	{Key: "starfail.monitoring.user", Type: "string", Description: "basic auth user"},
+	{Key: "starfail.monitoring.password", Type: "string", Description: "basic auth password (encryptable)"},
+	{Key: "starfail.predictive.socket", Type: "string", Description: "unix socket of an external assessor sidecar"},
+	{Key: "starfail.predictive.timeout_ms", Type: "int", Range: "1-", Description: "sidecar round-trip budget, milliseconds"},
+	{Key: "starfail.quarantine.enabled", Type: "bool", Default: "0", Description: "firewall-quarantine members whose TLS probe reports interception"},
+	{Key: "starfail.notify.pushover_token", Type: "string", Description: "Pushover application token (encryptable)"},
+	{Key: "starfail.notify.pushover_user", Type: "string", Description: "Pushover user key (encryptable)"},
+	{Key: "starfail.notify.language", Type: "string", Default: "en", Description: "notification language: en|sv|de"},
+	{Key: "starfail.notify.timezone", Type: "string", Description: "IANA timezone for notification timestamps"},
+	{Key: "starfail.notify.ack_tracking", Type: "bool", Default: "0", Description: "track acknowledgments and escalate unacknowledged notifications"},
+	{Key: "starfail.notify.escalate_critical", Type: "list", Description: "escalation chain for high-priority events, e.g. push sms@10 email@30"},
+	{Key: "starfail.notify.escalate_warning", Type: "list", Description: "escalation chain for normal-priority events"},
+	{Key: "starfail.notify.escalate_info", Type: "list", Description: "escalation chain for low-priority events"},
+	{Key: "starfail.notify.sms_number", Type: "string", Description: "destination number for sms escalation stages"},
+	{Key: "starfail.notify.email", Type: "string", Description: "destination address for email escalation stages"},
+	{Key: "starfail.notify.sms_numbers", Type: "list", Description: "SMS alert numbers, entries number or number@severity (critical|warning|info); plain numbers get critical only"},
+	{Key: "starfail.notify.sms_max_hourly", Type: "int", Default: "10", Range: "0-", Description: "SMS deliveries allowed per rolling hour (0 = unlimited)"},
+	{Key: "starfail.smscmd.enabled", Type: "bool", Default: "0", Description: "accept SMS control commands (STATUS, FAILOVER, REBOOT DISH)"},
+	{Key: "starfail.smscmd.pin", Type: "string", Description: "PIN that must prefix every SMS command; required"},
+	{Key: "starfail.smscmd.allowed", Type: "list", Description: "sender numbers allowed to issue commands; required"},
+	{Key: "starfail.smscmd.interval_s", Type: "int", Default: "30", Range: "1-", Description: "modem inbox poll interval, seconds"},
+	{Key: "starfail.webhook.url", Type: "string", Description: "HTTP endpoint events are POSTed to"},
+	{Key: "starfail.webhook.token", Type: "string", Description: "bearer token for the webhook endpoint (encryptable)"},
+	{Key: "starfail.mqtt.host", Type: "string", Description: "MQTT broker host"},
+	{Key: "starfail.mqtt.port", Type: "int", Default: "1883", Range: "1-65535", Description: "MQTT broker port"},
+	{Key: "starfail.mqtt.username", Type: "string", Description: "MQTT username"},
+	{Key: "starfail.mqtt.password", Type: "string", Description: "MQTT password (encryptable)"},
+	{Key: "starfail.mqtt.prefix", Type: "string", Default: "starfail", Description: "topic prefix"},
+	{Key: "starfail.mqtt.interval", Type: "int", Default: "30", Range: "1-3600", Description: "publish interval, seconds"},
+	{Key: "starfail.mqtt.profile", Type: "string", Description: "payload profile: full|lite"},
+	{Key: "starfail.mqtt.encoding", Type: "string", Description: "wire encoding: profile topics by default; gzip|cbor publish one versioned snapshot per round"},
+	{Key: "starfail.sysmgmt.enabled", Type: "bool", Default: "1", Description: "run system-management checks"},
+	{Key: "starfail.sysmgmt.check_interval", Type: "int", Default: "300", Range: "10-86400", Description: "seconds between system checks"},
+	{Key: "starfail.sysmgmt.max_disk_usage", Type: "int", Default: "90", Range: "1-100", Description: "overlay usage percent that triggers cleanup"},
+	{Key: "starfail.sysmgmt.min_free_memory", Type: "int", Default: "8192", Range: "0-", Description: "free memory floor, KB"},
+	{Key: "starfail.sysmgmt.services", Type: "list", Default: "starfaild mwan3", Description: "services kept running"},
+	{Key: "starfail.sysmgmt.auto_fix", Type: "bool", Default: "1", Description: "repair problems instead of only reporting them"},
+	{Key: "starfail.capture.enabled", Type: "bool", Default: "0", Description: "capture packets around failover events"},
+	{Key: "starfail.capture.dir", Type: "string", Description: "capture output directory"},
+	{Key: "starfail.capture.duration_s", Type: "int", Default: "10", Range: "1-300", Description: "capture length per event, seconds"},
+	{Key: "starfail.capture.max_files", Type: "int", Default: "5", Range: "1-100", Description: "capture files kept before rotation"},
+	{Key: "starfail.verify.enabled", Type: "bool", Default: "0", Description: "shadow-verify a failover target before committing"},
+	{Key: "starfail.verify.window_s", Type: "int", Default: "15", Range: "1-300", Description: "verification window, seconds"},
+	{Key: "starfail.verify.max_loss_pct", Type: "float", Default: "10", Range: "0-100", Description: "loss ceiling during verification, percent"},
+	{Key: "starfail.verify.max_latency_ms", Type: "float", Default: "800", Range: "1-", Description: "latency ceiling during verification, milliseconds"},
+	{Key: "starfail.tlsprobe.host", Type: "string", Description: "TLS interception probe target host"},
+	{Key: "starfail.tlsprobe.port", Type: "int", Default: "443", Range: "1-65535", Description: "TLS probe port"},
+	{Key: "starfail.tlsprobe.interval", Type: "int", Default: "300", Range: "10-86400", Description: "TLS probe interval, seconds"},
+	{Key: "starfail.flowstats.enabled", Type: "bool", Default: "0", Description: "per-device eBPF flow statistics (needs bpftool and a BPF-capable kernel)"},
+	{Key: "starfail.flowstats.object", Type: "string", Default: "/usr/share/starfail/flowstats.o", Description: "pre-compiled eBPF object to load"},
+	{Key: "starfail.passive.enabled", Type: "bool", Default: "0", Description: "watch kernel failure counters for dead-gateway bursts"},
+	{Key: "starfail.passive.threshold", Type: "int", Default: "10", Range: "1-", Description: "failures per interval that count as a burst"},
+	{Key: "starfail.passive.interval", Type: "int", Default: "1", Range: "1-60", Description: "counter sample interval, seconds"},
+	{Key: "starfail.archive.endpoint", Type: "string", Description: "S3-compatible endpoint for telemetry archival, e.g. https://minio.example:9000"},
+	{Key: "starfail.archive.bucket", Type: "string", Description: "archive bucket name"},
+	{Key: "starfail.archive.region", Type: "string", Default: "us-east-1", Description: "signing region"},
+	{Key: "starfail.archive.prefix", Type: "string", Description: "object key prefix, e.g. a router name"},
+	{Key: "starfail.archive.access_key", Type: "string", Description: "archive access key (encryptable)"},
+	{Key: "starfail.archive.secret_key", Type: "string", Description: "archive secret key (encryptable)"},
+	{Key: "starfail.archive.interval_h", Type: "int", Default: "6", Range: "1-168", Description: "hours between snapshot uploads"},
+	{Key: "starfail.archive.bandwidth_kbps", Type: "int", Default: "0", Range: "0-", Description: "upload rate cap, kbit/s; 0 = unthrottled"},
+	{Key: "starfail.roam.enabled", Type: "bool", Default: "0", Description: "force a cellular operator rescan after border crossings"},
+	{Key: "starfail.roam.interval_s", Type: "int", Default: "60", Range: "10-3600", Description: "modem registration poll interval, seconds"},
+	{Key: "starfail.roam.move_km", Type: "float", Default: "30", Range: "1-", Description: "rescan when moved this far with no service, km"},
+	{Key: "starfail.roam.dead_s", Type: "int", Default: "180", Range: "30-", Description: "rescan after this long with no service, seconds"},
+	{Key: "starfail.indicator.mode", Type: "string", Description: "local status output: led|oled"},
+	{Key: "starfail.indicator.led", Type: "string", Description: "sysfs LED name under /sys/class/leds"},
+	{Key: "starfail.indicator.oled_dev", Type: "string", Default: "/dev/i2c-0", Description: "I2C bus device for the SSD1306 display"},
+	{Key: "starfail.indicator.oled_addr", Type: "int", Default: "60", Range: "0-127", Description: "SSD1306 I2C address (decimal; 60 = 0x3c)"},
+	{Key: "starfail.indicator.interval_s", Type: "int", Default: "5", Range: "1-600", Description: "status output refresh interval, seconds"},
+	{Key: "starfail.reflector.host", Type: "string", Description: "UDP reflector host for one-way loss probing"},
+	{Key: "starfail.reflector.port", Type: "int", Default: "19000", Range: "1-65535", Description: "reflector port"},
+	{Key: "starfail.reflector.interval", Type: "int", Default: "60", Range: "1-3600", Description: "reflector probe interval, seconds"},
+	{Key: "starfail.reflector.count", Type: "int", Default: "5", Range: "1-100", Description: "packets per reflector probe"},
+	{Key: "starfail.wifi.device", Type: "string", Description: "wireless device used for WiFi-as-WAN roaming"},
+	{Key: "starfail.wifi.iface", Type: "string", Description: "wireless interface section used for roaming"},
+	{Key: "starfail.<wifi_network>.ssid", Type: "string", Description: "candidate upstream SSID"},
+	{Key: "starfail.<wifi_network>.key", Type: "string", Description: "candidate network key (encryptable)"},
+	{Key: "starfail.wireguard.ifaces", Type: "list", Description: "WireGuard interfaces re-resolved after failover"},
+	{Key: "starfail.inbound.redirects", Type: "list", Description: "firewall redirect sections re-pointed at the new WAN address after failover"},
+	{Key: "starfail.upnp.migrate_leases", Type: "bool", Default: "0", Description: "expire and re-announce UPnP/NAT-PMP leases after failover"},
+	{Key: "starfail.dns.enabled", Type: "bool", Default: "1", Description: "reorder dnsmasq upstreams to follow the active WAN; 0 leaves /etc/config/dhcp alone"},
+	{Key: "starfail.dns.fallbacks", Type: "list", Default: "1.1.1.1 8.8.8.8", Description: "public resolvers appended after the active member's own"},
+	{Key: "starfail.dns.interval_s", Type: "int", Default: "300", Range: "10-86400", Description: "re-probe interval for the current upstreams, seconds"},
+	{Key: "starfail.<member>.dns_servers", Type: "list", Description: "explicit resolvers for this member instead of the netifd-learned ones"},
+	{Key: "starfail.<ddns>.plugin", Type: "string", Default: "url", Description: "DDNS update plugin: url|script"},
+	{Key: "starfail.<ddns>.url", Type: "string", Description: "update URL template; {ip} and {hostname} are substituted"},
+	{Key: "starfail.<ddns>.hostname", Type: "string", Description: "hostname substituted into the update URL"},
+	{Key: "starfail.<ddns>.script", Type: "string", Description: "script plugin: run with the new address as argument"},
+	{Key: "starfail.voip.flush_conntrack", Type: "bool", Default: "0", Description: "flush conntrack entries on failover so calls re-home"},
+	{Key: "starfail.voip.script", Type: "string", Description: "hook script run after failover"},
+	{Key: "starfail.<sim>.plugin", Type: "string", Default: "ussd", Description: "balance query plugin: ussd|api"},
+	{Key: "starfail.<sim>.ussd_code", Type: "string", Description: "USSD balance query code, e.g. *123#"},
+	{Key: "starfail.<sim>.api_url", Type: "string", Description: "carrier REST endpoint returning the balance as JSON"},
+	{Key: "starfail.<sim>.interval", Type: "int", Default: "3600", Range: "60-", Description: "balance query interval, seconds"},
+	{Key: "starfail.<sim>.low_watermark_mb", Type: "float", Default: "500", Range: "0-", Description: "notify when the balance drops below this, MB"},
+}
+
+// Options returns every documented option sorted by key.
+func Options() []OptionDoc {
+	out := structOptions(reflect.TypeOf(Config{}))
+	out = append(out, structOptions(reflect.TypeOf(MemberConfig{}))...)
+	out = append(out, extraOptions...)
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out
+}
+
+// structOptions reads the option tags off one config struct.
+func structOptions(t reflect.Type) []OptionDoc {
+	var out []OptionDoc
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		key := f.Tag.Get("uci")
+		if key == "" {
+			continue
+		}
+		out = append(out, OptionDoc{
+			Key:         key,
+			Type:        optionType(f.Type),
+			Default:     f.Tag.Get("default"),
+			Range:       f.Tag.Get("range"),
+			Description: f.Tag.Get("desc"),
+		})
+	}
+	return out
+}
+
+func optionType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "bool"
+	case reflect.Int:
+		return "int"
+	case reflect.Float64:
+		return "float"
+	case reflect.Slice:
+		return "list"
+	default:
+		return "string"
+	}
+}
+
+// OptionsJSON renders the reference as JSON for machine consumers (LuCI
+// form generation, external tooling).
+func OptionsJSON() []byte {
+	b, _ := json.MarshalIndent(Options(), "", "  ")
+	return append(b, '\n')
+}
+
+// OptionsMarkdown renders the reference as a Markdown table.
+func OptionsMarkdown() string {
+	var b strings.Builder
+	b.WriteString("# starfail UCI option reference\n\n")
+	b.WriteString("Generated from the option tags in pkg/uci; do not edit by hand.\n")
+	b.WriteString("`<member>`, `<probe_profile>`, `<sim>` and `<wifi_network>` stand for\n")
+	b.WriteString("the name of any section of that type.\n\n")
+	b.WriteString("| Option | Type | Default | Range | Description |\n")
+	b.WriteString("|---|---|---|---|---|\n")
+	for _, o := range Options() {
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %s |\n", o.Key, o.Type, o.Default, o.Range, o.Description)
+	}
+	return b.String()
+}
+
+// CheckConfig validates the live starfail package against the reference:
+// options no component reads and numeric values outside their documented
+// range come back as problems. An unreadable package is an error, not a
+// problem list, so callers can tell "uci broken" from "config wrong".
+func CheckConfig() ([]string, error) {
+	out, err := Exec("show", "starfail")
+	if err != nil {
+		return nil, err
+	}
+	exact := make(map[string]OptionDoc)
+	perType := make(map[string]map[string]OptionDoc)
+	for _, o := range Options() {
+		open := strings.IndexByte(o.Key, '<')
+		if open < 0 {
+			exact[o.Key] = o
+			continue
+		}
+		end := strings.IndexByte(o.Key, '>')
+		sectype := o.Key[open+1 : end]
+		opt := o.Key[end+2:] // skip ">."
+		if perType[sectype] == nil {
+			perType[sectype] = make(map[string]OptionDoc)
+		}
+		perType[sectype][opt] = o
+	}
+
+	secType := make(map[string]string)
+	var problems []string
+	for _, line := range strings.Split(out, "\n") {
+		eq := strings.IndexByte(line, '=')
+		if eq < 0 {
+			continue
+		}
+		key, value := line[:eq], strings.Trim(line[eq+1:], "'")
+		parts := strings.Split(key, ".")
+		if len(parts) == 2 {
+			secType[parts[1]] = value
+			continue
+		}
+		if len(parts) != 3 {
+			continue
+		}
+		sec, opt := parts[1], parts[2]
+		doc, ok := exact[key]
+		if !ok {
+			doc, ok = perType[secType[sec]][opt]
+		}
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s: no starfail component reads this option", key))
+			continue
+		}
+		if p := checkValue(doc, value); p != "" {
+			problems = append(problems, fmt.Sprintf("%s: %s", key, p))
+		}
+	}
+	return problems, nil
+}
+
+// checkValue validates one option value against its documentation.
+func checkValue(doc OptionDoc, value string) string {
+	switch doc.Type {
+	case "bool":
+		switch strings.ToLower(value) {
+		case "0", "1", "on", "off", "true", "false", "yes", "no", "enabled", "disabled":
+			return ""
+		}
+		return fmt.Sprintf("%q is not a boolean", value)
+	case "int", "float":
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Sprintf("%q is not a number", value)
+		}
+		if doc.Range == "" {
+			return ""
+		}
+		dash := strings.IndexByte(doc.Range, '-')
+		lo, hi := doc.Range[:dash], doc.Range[dash+1:]
+		if lo != "" {
+			if min, err := strconv.ParseFloat(lo, 64); err == nil && v < min {
+				return fmt.Sprintf("%s is below the minimum %s", value, lo)
+			}
+		}
+		if hi != "" {
+			if max, err := strconv.ParseFloat(hi, 64); err == nil && v > max {
+				return fmt.Sprintf("%s is above the maximum %s", value, hi)
+			}
+		}
+	}
+	return ""
+}
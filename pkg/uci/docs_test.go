@@ -0,0 +1,155 @@
+package uci
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// TestOptionDefaultsMatchLoader pins the `default` tags to what LoadConfig
+// actually produces from an empty config, so the documentation cannot
+// drift from the code.
+func TestOptionDefaultsMatchLoader(t *testing.T) {
+	installFakeUCI(t, map[string]string{})
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	v := reflect.ValueOf(*cfg)
+	tp := reflect.TypeOf(*cfg)
+	for i := 0; i < tp.NumField(); i++ {
+		f := tp.Field(i)
+		def := f.Tag.Get("default")
+		if f.Tag.Get("uci") == "" || def == "" {
+			continue
+		}
+		if got := formatOption(v.Field(i)); got != def {
+			t.Errorf("%s: loader default %q, documented default %q", f.Tag.Get("uci"), got, def)
+		}
+	}
+}
+
+func formatOption(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			return "1"
+		}
+		return "0"
+	case reflect.Int:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, 64)
+	default:
+		return v.String()
+	}
+}
+
+// TestEveryParsedOptionDocumented runs the core loaders under a recording
+// Exec stub and fails on any option they read that the reference misses.
+func TestEveryParsedOptionDocumented(t *testing.T) {
+	f := installFakeUCI(t, map[string]string{})
+	f.show = strings.Join([]string{
+		"starfail.main=main",
+		"starfail.wan=member",
+		"starfail.web=probe_profile",
+	}, "\n")
+
+	var asked []string
+	inner := Exec
+	Exec = func(args ...string) (string, error) {
+		a := args
+		if len(a) > 0 && a[0] == "-q" {
+			a = a[1:]
+		}
+		if len(a) > 1 && a[0] == "get" {
+			asked = append(asked, a[1])
+		}
+		return inner(args...)
+	}
+	t.Cleanup(func() { Exec = inner })
+
+	if _, err := LoadConfig(); err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	LoadSysmgmtConfig()
+
+	exact := make(map[string]bool)
+	perType := make(map[string]map[string]bool)
+	for _, o := range Options() {
+		open := strings.IndexByte(o.Key, '<')
+		if open < 0 {
+			exact[o.Key] = true
+			continue
+		}
+		end := strings.IndexByte(o.Key, '>')
+		sectype := o.Key[open+1 : end]
+		if perType[sectype] == nil {
+			perType[sectype] = make(map[string]bool)
+		}
+		perType[sectype][o.Key[end+2:]] = true
+	}
+	secType := map[string]string{"main": "main", "wan": "member", "web": "probe_profile"}
+	for _, key := range asked {
+		parts := strings.Split(key, ".")
+		if len(parts) != 3 || exact[key] {
+			continue
+		}
+		if perType[secType[parts[1]]][parts[2]] {
+			continue
+		}
+		t.Errorf("%s is parsed but not documented (add a tag or extraOptions entry)", key)
+	}
+}
+
+func TestCheckConfig(t *testing.T) {
+	f := installFakeUCI(t, map[string]string{})
+	f.show = strings.Join([]string{
+		"starfail.main=main",
+		"starfail.main.enabled='1'",
+		"starfail.main.poll_interval='0'", // below documented minimum
+		"starfail.main.typo_option='1'",   // read by nothing
+		"starfail.wan=member",
+		"starfail.wan.iface='wan'",
+		"starfail.wan.weight='150'", // above documented maximum
+	}, "\n")
+	problems, err := CheckConfig()
+	if err != nil {
+		t.Fatalf("CheckConfig: %v", err)
+	}
+	if len(problems) != 3 {
+		t.Fatalf("problems = %v, want 3", problems)
+	}
+	for _, want := range []string{"poll_interval", "typo_option", "weight"} {
+		found := false
+		for _, p := range problems {
+			if strings.Contains(p, want) {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("no problem mentions %s: %v", want, problems)
+		}
+	}
+}
+
+func TestCheckConfigClean(t *testing.T) {
+	f := installFakeUCI(t, map[string]string{})
+	f.show = strings.Join([]string{
+		"starfail.main=main",
+		"starfail.main.poll_interval='5'",
+		"starfail.monitoring=monitoring",
+		"starfail.monitoring.bind='0.0.0.0:9101'",
+		"starfail.wan=member",
+		"starfail.wan.class='starlink'",
+		"starfail.wan.sla_objective='99.5'",
+	}, "\n")
+	problems, err := CheckConfig()
+	if err != nil {
+		t.Fatalf("CheckConfig: %v", err)
+	}
+	if len(problems) != 0 {
+		t.Errorf("clean config reported problems: %v", problems)
+	}
+}
@@ -0,0 +1,130 @@
+package uci
+
+import (
+	"fmt"
+	"log"
+	"os"
+)
+
+// The starfail UCI schema is versioned through starfail.main.config_version
+// so option renames between releases do not silently strand old configs on
+// defaults. Migrate runs at startup, applies every step between the
+// on-device version and SchemaVersion, and reports what it did; the
+// pre-migration config is exported to a backup file first.
+
+// SchemaVersion is the schema this build reads. Bump it together with a
+// new entry in migrations when renaming or moving options.
+const SchemaVersion = 3
+
+// BackupDir receives "starfail.vN.bak" exports before migrating. A
+// variable so tests can redirect it.
+var BackupDir = "/etc/starfail"
+
+// migration is one schema step; Apply stages changes, Migrate commits.
+type migration struct {
+	to          int
+	description string
+	apply       func() error
+}
+
+var migrations = []migration{
+	{
+		to:          2,
+		description: "rename main.check_interval/failover_score/recovery_score to poll_interval_s/fail_score/restore_score",
+		apply: func() error {
+			for _, r := range [][2]string{
+				{"starfail.main.check_interval", "starfail.main.poll_interval_s"},
+				{"starfail.main.failover_score", "starfail.main.fail_score"},
+				{"starfail.main.recovery_score", "starfail.main.restore_score"},
+			} {
+				if err := renameOption(r[0], r[1]); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		to:          3,
+		description: "move pushover credentials from main to the notify section",
+		apply: func() error {
+			_, hasToken := Get("starfail.main.pushover_token")
+			_, hasUser := Get("starfail.main.pushover_user")
+			if !hasToken && !hasUser {
+				return nil
+			}
+			if err := Set("starfail.notify", "notify"); err != nil {
+				return err
+			}
+			for _, r := range [][2]string{
+				{"starfail.main.pushover_token", "starfail.notify.pushover_token"},
+				{"starfail.main.pushover_user", "starfail.notify.pushover_user"},
+			} {
+				if err := renameOption(r[0], r[1]); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+}
+
+// renameOption moves a value to a new key, never clobbering an existing
+// value at the destination (the user may have set both during a manual
+// upgrade; the new name wins).
+func renameOption(old, new string) error {
+	v, ok := Get(old)
+	if !ok {
+		return nil
+	}
+	if _, exists := Get(new); !exists {
+		if err := Set(new, v); err != nil {
+			return err
+		}
+	}
+	return Delete(old)
+}
+
+// Migrate upgrades the on-device config to SchemaVersion and returns the
+// descriptions of the steps applied (empty when already current). A
+// failing step aborts before commit, leaving the config untouched.
+func Migrate() ([]string, error) {
+	cur := GetInt("starfail.main.config_version", 1)
+	if cur >= SchemaVersion {
+		return nil, nil
+	}
+	if err := backup(cur); err != nil {
+		// A missing backup is worth a warning, not a stranded upgrade.
+		log.Printf("uci: config backup failed: %v", err)
+	}
+	var applied []string
+	for _, m := range migrations {
+		if m.to <= cur {
+			continue
+		}
+		if err := m.apply(); err != nil {
+			return applied, fmt.Errorf("migrate to v%d: %w", m.to, err)
+		}
+		applied = append(applied, fmt.Sprintf("v%d: %s", m.to, m.description))
+	}
+	if err := Set("starfail.main.config_version", fmt.Sprintf("%d", SchemaVersion)); err != nil {
+		return applied, err
+	}
+	if err := Commit("starfail"); err != nil {
+		return applied, err
+	}
+	return applied, nil
+}
+
+// backup exports the pre-migration config next to the live one.
+func backup(fromVersion int) error {
+	out, err := Exec("export", "starfail")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(BackupDir, 0o755); err != nil {
+		return err
+	}
+	path := fmt.Sprintf("%s/starfail.v%d.bak", BackupDir, fromVersion)
+	return os.WriteFile(path, []byte(out+"\n"), 0o600)
+}
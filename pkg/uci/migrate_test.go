@@ -0,0 +1,140 @@
+package uci
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeUCI emulates the uci CLI over an in-memory key/value store.
+type fakeUCI struct {
+	values  map[string]string
+	commits int
+	show    string // canned `uci show starfail` output
+}
+
+func installFakeUCI(t *testing.T, initial map[string]string) *fakeUCI {
+	t.Helper()
+	f := &fakeUCI{values: initial}
+	orig := Exec
+	Exec = func(args ...string) (string, error) {
+		// Strip the -q flag Get passes.
+		if len(args) > 0 && args[0] == "-q" {
+			args = args[1:]
+		}
+		switch args[0] {
+		case "get":
+			if v, ok := f.values[args[1]]; ok {
+				return v, nil
+			}
+			return "", fmt.Errorf("uci: entry not found")
+		case "set":
+			kv := strings.SplitN(args[1], "=", 2)
+			f.values[kv[0]] = kv[1]
+			return "", nil
+		case "delete":
+			delete(f.values, args[1])
+			return "", nil
+		case "commit":
+			f.commits++
+			return "", nil
+		case "show":
+			return f.show, nil
+		case "export":
+			var sb strings.Builder
+			for k, v := range f.values {
+				fmt.Fprintf(&sb, "%s=%s\n", k, v)
+			}
+			return sb.String(), nil
+		}
+		return "", fmt.Errorf("fake uci: unhandled %v", args)
+	}
+	t.Cleanup(func() { Exec = orig })
+	return f
+}
+
+func TestMigrateFromV1(t *testing.T) {
+	origBackup := BackupDir
+	BackupDir = t.TempDir()
+	t.Cleanup(func() { BackupDir = origBackup })
+
+	f := installFakeUCI(t, map[string]string{
+		"starfail.main":                "starfail",
+		"starfail.main.check_interval": "10",
+		"starfail.main.failover_score": "35",
+		"starfail.main.pushover_token": "tok123",
+		"starfail.main.pushover_user":  "usr456",
+	})
+
+	applied, err := Migrate()
+	if err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	if len(applied) != 2 {
+		t.Fatalf("applied = %v, want 2 steps", applied)
+	}
+	checks := map[string]string{
+		"starfail.main.poll_interval_s":  "10",
+		"starfail.main.fail_score":       "35",
+		"starfail.notify.pushover_token": "tok123",
+		"starfail.notify.pushover_user":  "usr456",
+		"starfail.main.config_version":   fmt.Sprintf("%d", SchemaVersion),
+	}
+	for k, want := range checks {
+		if got := f.values[k]; got != want {
+			t.Errorf("%s = %q, want %q", k, got, want)
+		}
+	}
+	for _, gone := range []string{
+		"starfail.main.check_interval",
+		"starfail.main.failover_score",
+		"starfail.main.pushover_token",
+	} {
+		if _, ok := f.values[gone]; ok {
+			t.Errorf("%s still present after migration", gone)
+		}
+	}
+	if f.commits != 1 {
+		t.Errorf("commits = %d, want 1", f.commits)
+	}
+
+	// The pre-migration export landed in the backup dir.
+	b, err := os.ReadFile(filepath.Join(BackupDir, "starfail.v1.bak"))
+	if err != nil {
+		t.Fatalf("backup: %v", err)
+	}
+	if !strings.Contains(string(b), "check_interval") {
+		t.Errorf("backup does not contain the old option: %q", b)
+	}
+}
+
+func TestMigrateCurrentIsNoop(t *testing.T) {
+	f := installFakeUCI(t, map[string]string{
+		"starfail.main.config_version": fmt.Sprintf("%d", SchemaVersion),
+	})
+	applied, err := Migrate()
+	if err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	if len(applied) != 0 || f.commits != 0 {
+		t.Errorf("applied = %v, commits = %d; want none", applied, f.commits)
+	}
+}
+
+func TestRenameOptionKeepsExistingDestination(t *testing.T) {
+	f := installFakeUCI(t, map[string]string{
+		"starfail.main.check_interval":  "10",
+		"starfail.main.poll_interval_s": "30", // user already set the new name
+	})
+	if err := renameOption("starfail.main.check_interval", "starfail.main.poll_interval_s"); err != nil {
+		t.Fatal(err)
+	}
+	if f.values["starfail.main.poll_interval_s"] != "30" {
+		t.Errorf("destination overwritten: %v", f.values)
+	}
+	if _, ok := f.values["starfail.main.check_interval"]; ok {
+		t.Error("old option still present")
+	}
+}
@@ -0,0 +1,33 @@
+package uci
+
+import "strings"
+
+// SysmgmtConfig is the parsed starfail.sysmgmt section, shared by the
+// starfail-sysmgmt daemon. It lives here rather than in pkg/sysmgmt so both
+// daemons read configuration through one loader with one set of defaults.
+type SysmgmtConfig struct {
+	Enabled         bool
+	CheckIntervalS  int
+	MaxDiskUsagePct int
+	MinFreeMemoryKB int
+	ManagedServices []string // procd services that must be running
+	AutoFix         bool
+}
+
+// LoadSysmgmtConfig reads starfail.sysmgmt. Defaults match the historical
+// system-maintenance-rutos.sh values; a missing section yields a fully
+// defaulted, enabled config.
+func LoadSysmgmtConfig() *SysmgmtConfig {
+	cfg := &SysmgmtConfig{
+		Enabled:         GetBool("starfail.sysmgmt.enabled", true),
+		CheckIntervalS:  GetInt("starfail.sysmgmt.check_interval", 300),
+		MaxDiskUsagePct: GetInt("starfail.sysmgmt.max_disk_usage", 90),
+		MinFreeMemoryKB: GetInt("starfail.sysmgmt.min_free_memory", 8192),
+		ManagedServices: []string{"starfaild", "mwan3"},
+		AutoFix:         GetBool("starfail.sysmgmt.auto_fix", true),
+	}
+	if s, ok := Get("starfail.sysmgmt.services"); ok {
+		cfg.ManagedServices = strings.Fields(s)
+	}
+	return cfg
+}
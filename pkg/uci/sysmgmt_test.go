@@ -0,0 +1,38 @@
+package uci
+
+import "testing"
+
+func TestLoadSysmgmtConfigDefaults(t *testing.T) {
+	installFakeUCI(t, map[string]string{})
+	cfg := LoadSysmgmtConfig()
+	if !cfg.Enabled || !cfg.AutoFix {
+		t.Errorf("defaults: enabled=%v autofix=%v, want both true", cfg.Enabled, cfg.AutoFix)
+	}
+	if cfg.CheckIntervalS != 300 || cfg.MaxDiskUsagePct != 90 || cfg.MinFreeMemoryKB != 8192 {
+		t.Errorf("defaults: %+v", cfg)
+	}
+	if len(cfg.ManagedServices) != 2 || cfg.ManagedServices[0] != "starfaild" {
+		t.Errorf("default services = %v", cfg.ManagedServices)
+	}
+}
+
+func TestLoadSysmgmtConfigRoundTrip(t *testing.T) {
+	installFakeUCI(t, map[string]string{
+		"starfail.sysmgmt.enabled":         "0",
+		"starfail.sysmgmt.check_interval":  "60",
+		"starfail.sysmgmt.max_disk_usage":  "80",
+		"starfail.sysmgmt.min_free_memory": "4096",
+		"starfail.sysmgmt.services":        "starfaild mwan3 dnsmasq",
+		"starfail.sysmgmt.auto_fix":        "0",
+	})
+	cfg := LoadSysmgmtConfig()
+	if cfg.Enabled || cfg.AutoFix {
+		t.Errorf("enabled=%v autofix=%v, want both false", cfg.Enabled, cfg.AutoFix)
+	}
+	if cfg.CheckIntervalS != 60 || cfg.MaxDiskUsagePct != 80 || cfg.MinFreeMemoryKB != 4096 {
+		t.Errorf("loaded %+v", cfg)
+	}
+	if len(cfg.ManagedServices) != 3 || cfg.ManagedServices[2] != "dnsmasq" {
+		t.Errorf("services = %v", cfg.ManagedServices)
+	}
+}
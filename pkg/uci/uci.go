@@ -0,0 +1,125 @@
+// Package uci reads the starfail UCI configuration on RUTOS/OpenWrt.
+//
+// The package shells out to the uci binary rather than linking libuci, the
+// same approach the shell-based monitor scripts in this repository use, so
+// the daemon works unmodified on any RUTOS firmware.
+package uci
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// Exec runs a uci command and returns trimmed stdout. It is a variable so
+// tests can stub the binary.
+var Exec = func(args ...string) (string, error) {
+	out, err := exec.Command("uci", args...).Output()
+	if err != nil {
+		return "", fmt.Errorf("uci %s: %w", strings.Join(args, " "), err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// Get returns the value of a fully qualified option, e.g.
+// "starfail.main.poll_interval". A missing option returns ok=false.
+func Get(key string) (string, bool) {
+	out, err := Exec("-q", "get", key)
+	if err != nil || out == "" {
+		return "", false
+	}
+	return out, true
+}
+
+// GetInt returns an integer option or def when unset or malformed.
+func GetInt(key string, def int) int {
+	s, ok := Get(key)
+	if !ok {
+		return def
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+// GetFloat returns a float option or def when unset or malformed.
+func GetFloat(key string, def float64) float64 {
+	s, ok := Get(key)
+	if !ok {
+		return def
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+// GetBool interprets the usual UCI truthy spellings ("1", "on", "true",
+// "yes", "enabled").
+func GetBool(key string, def bool) bool {
+	s, ok := Get(key)
+	if !ok {
+		return def
+	}
+	switch strings.ToLower(s) {
+	case "1", "on", "true", "yes", "enabled":
+		return true
+	case "0", "off", "false", "no", "disabled":
+		return false
+	}
+	return def
+}
+
+// GetList returns a UCI list option split on whitespace.
+func GetList(key string) []string {
+	s, ok := Get(key)
+	if !ok {
+		return nil
+	}
+	return strings.Fields(s)
+}
+
+// Set writes a value; the caller is responsible for committing.
+func Set(key, value string) error {
+	_, err := Exec("set", fmt.Sprintf("%s=%s", key, value))
+	return err
+}
+
+// Delete removes an option or section; the caller commits.
+func Delete(key string) error {
+	_, err := Exec("delete", key)
+	return err
+}
+
+// Commit persists staged changes for the given package.
+func Commit(pkg string) error {
+	_, err := Exec("commit", pkg)
+	return err
+}
+
+// Sections returns the section names of a given type within a package, in
+// declaration order (uci show output order).
+func Sections(pkg, sectype string) []string {
+	out, err := Exec("show", pkg)
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, line := range strings.Split(out, "\n") {
+		// lines look like: starfail.@member[0]=member or starfail.wan=member
+		eq := strings.IndexByte(line, '=')
+		if eq < 0 || line[eq+1:] != sectype {
+			continue
+		}
+		dot := strings.IndexByte(line, '.')
+		if dot < 0 {
+			continue
+		}
+		names = append(names, line[dot+1:eq])
+	}
+	return names
+}
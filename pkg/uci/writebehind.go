@@ -0,0 +1,124 @@
+package uci
+
+// Write-behind committing. Every uci commit rewrites a file under
+// /etc/config, which on these routers is a flash write; a controller
+// that commits on every metric tweak and hygiene fix wears the chip for
+// no benefit, because uci readers (the CLI, libuci, init scripts) all
+// merge the staged deltas from the save directory anyway — staged values
+// are live immediately, the commit only makes them survive a reboot. So
+// callers whose changes do not need reboot durability this instant mark
+// the package dirty on the shared queue and the flash write happens
+// once, debounced. A journal written before each flush makes an
+// interrupted one detectable: startup re-commits whatever the surviving
+// journal names, which is idempotent.
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Commits is the daemon-wide queue. The journal lives on persistent
+// storage so a flush cut short by power loss is found at next start.
+var Commits = NewCommitQueue("/etc/starfail-commit.journal", 10*time.Second)
+
+// CommitQueue batches and debounces uci commits for one process.
+type CommitQueue struct {
+	journalPath string
+	delay       time.Duration
+
+	mu    sync.Mutex
+	dirty map[string]bool
+	timer *time.Timer
+}
+
+// commitJournal is the on-disk record of a flush in progress.
+type commitJournal struct {
+	Packages []string  `json:"packages"`
+	Started  time.Time `json:"started"`
+}
+
+// NewCommitQueue returns a queue flushing delay after the first request.
+func NewCommitQueue(journalPath string, delay time.Duration) *CommitQueue {
+	return &CommitQueue{
+		journalPath: journalPath,
+		delay:       delay,
+		dirty:       make(map[string]bool),
+	}
+}
+
+// Request marks pkg's staged changes for the next debounced flash
+// commit. The staged values are already visible to every uci reader;
+// only their reboot durability is deferred.
+func (q *CommitQueue) Request(pkg string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.dirty[pkg] = true
+	if q.timer == nil {
+		q.timer = time.AfterFunc(q.delay, q.Flush)
+	}
+}
+
+// Flush commits every dirty package now. Shutdown calls it so nothing
+// staged is lost to a clean exit; failed packages stay dirty and the
+// journal stays on disk for the next flush or startup recovery.
+func (q *CommitQueue) Flush() {
+	q.mu.Lock()
+	if q.timer != nil {
+		q.timer.Stop()
+		q.timer = nil
+	}
+	pkgs := make([]string, 0, len(q.dirty))
+	for p := range q.dirty {
+		pkgs = append(pkgs, p)
+	}
+	sort.Strings(pkgs)
+	q.mu.Unlock()
+	if len(pkgs) == 0 {
+		return
+	}
+	j, _ := json.Marshal(commitJournal{Packages: pkgs, Started: time.Now()})
+	if err := os.WriteFile(q.journalPath, j, 0o600); err != nil {
+		log.Printf("uci: write commit journal: %v", err)
+	}
+	failed := false
+	for _, p := range pkgs {
+		if err := Commit(p); err != nil {
+			log.Printf("uci: deferred commit %s: %v", p, err)
+			failed = true
+			continue
+		}
+		q.mu.Lock()
+		delete(q.dirty, p)
+		q.mu.Unlock()
+	}
+	if !failed {
+		_ = os.Remove(q.journalPath)
+	}
+}
+
+// Recover finishes a flush interrupted by a crash or power loss: it
+// re-commits the packages the surviving journal names (a no-op for any
+// that made it) and returns them, nil when the last run flushed cleanly.
+func (q *CommitQueue) Recover() []string {
+	raw, err := os.ReadFile(q.journalPath)
+	if err != nil {
+		return nil
+	}
+	var j commitJournal
+	if json.Unmarshal(raw, &j) != nil || len(j.Packages) == 0 {
+		_ = os.Remove(q.journalPath)
+		return nil
+	}
+	for _, p := range j.Packages {
+		if err := Commit(p); err != nil {
+			log.Printf("uci: recover commit %s: %v", p, err)
+			return j.Packages
+		}
+	}
+	_ = os.Remove(q.journalPath)
+	return j.Packages
+}
@@ -0,0 +1,140 @@
+package uci
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// commitLog records uci invocations behind a mutex — the debounce timer
+// flushes from its own goroutine.
+type commitLog struct {
+	mu  sync.Mutex
+	log []string
+}
+
+func (l *commitLog) add(op string) {
+	l.mu.Lock()
+	l.log = append(l.log, op)
+	l.mu.Unlock()
+}
+
+func (l *commitLog) ops() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.log...)
+}
+
+func (l *commitLog) reset() {
+	l.mu.Lock()
+	l.log = nil
+	l.mu.Unlock()
+}
+
+// installCommitStub records uci invocations; commit fails for packages
+// listed in failing.
+func installCommitStub(t *testing.T, failing ...string) *commitLog {
+	t.Helper()
+	l := &commitLog{}
+	fail := make(map[string]bool)
+	for _, p := range failing {
+		fail[p] = true
+	}
+	orig := Exec
+	Exec = func(args ...string) (string, error) {
+		l.add(strings.Join(args, " "))
+		if len(args) == 2 && args[0] == "commit" && fail[args[1]] {
+			return "", fmt.Errorf("flash error")
+		}
+		return "", nil
+	}
+	t.Cleanup(func() { Exec = orig })
+	return l
+}
+
+func TestCommitQueueBatches(t *testing.T) {
+	l := installCommitStub(t)
+	q := NewCommitQueue(filepath.Join(t.TempDir(), "journal.json"), time.Hour)
+	q.Request("mwan3")
+	q.Request("mwan3")
+	q.Request("dhcp")
+	if ops := l.ops(); len(ops) != 0 {
+		t.Fatalf("committed before flush: %v", ops)
+	}
+	q.Flush()
+	if got := strings.Join(l.ops(), "\n"); got != "commit dhcp\ncommit mwan3" {
+		t.Errorf("flush ops:\n%s", got)
+	}
+	// Nothing left dirty: a second flush is a no-op.
+	q.Flush()
+	if ops := l.ops(); len(ops) != 2 {
+		t.Errorf("re-flush committed again: %v", ops)
+	}
+}
+
+func TestCommitQueueDebounceFires(t *testing.T) {
+	l := installCommitStub(t)
+	q := NewCommitQueue(filepath.Join(t.TempDir(), "journal.json"), 10*time.Millisecond)
+	q.Request("mwan3")
+	deadline := time.Now().Add(2 * time.Second)
+	for len(l.ops()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if ops := l.ops(); len(ops) != 1 || ops[0] != "commit mwan3" {
+		t.Errorf("debounced flush ops = %v", ops)
+	}
+}
+
+func TestCommitQueueJournalClearedOnSuccess(t *testing.T) {
+	installCommitStub(t)
+	journal := filepath.Join(t.TempDir(), "journal.json")
+	q := NewCommitQueue(journal, time.Hour)
+	q.Request("mwan3")
+	q.Flush()
+	if _, err := os.Stat(journal); err == nil {
+		t.Error("journal survived a clean flush")
+	}
+}
+
+func TestCommitQueueKeepsJournalOnFailure(t *testing.T) {
+	l := installCommitStub(t, "mwan3")
+	journal := filepath.Join(t.TempDir(), "journal.json")
+	q := NewCommitQueue(journal, time.Hour)
+	q.Request("mwan3")
+	q.Flush()
+	if _, err := os.Stat(journal); err != nil {
+		t.Fatal("failed flush removed the journal")
+	}
+	// The package stays dirty and retries on the next flush.
+	l.reset()
+	q.Flush()
+	if ops := l.ops(); len(ops) != 1 || ops[0] != "commit mwan3" {
+		t.Errorf("retry ops = %v", ops)
+	}
+}
+
+func TestCommitQueueRecover(t *testing.T) {
+	l := installCommitStub(t)
+	journal := filepath.Join(t.TempDir(), "journal.json")
+	if err := os.WriteFile(journal, []byte(`{"packages":["dhcp","mwan3"]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	q := NewCommitQueue(journal, time.Hour)
+	pkgs := q.Recover()
+	if strings.Join(pkgs, " ") != "dhcp mwan3" {
+		t.Errorf("recovered packages = %v", pkgs)
+	}
+	if got := strings.Join(l.ops(), "\n"); got != "commit dhcp\ncommit mwan3" {
+		t.Errorf("recover ops:\n%s", got)
+	}
+	if _, err := os.Stat(journal); err == nil {
+		t.Error("journal survived recovery")
+	}
+	if q.Recover() != nil {
+		t.Error("second recovery found a journal")
+	}
+}
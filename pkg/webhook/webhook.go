@@ -0,0 +1,125 @@
+// Package webhook posts structured problem reports to an operator-configured
+// HTTP endpoint (starfail.webhook.url). The payload is one JSON object per
+// problem with a stable dedup key and a severity, the schema the autonomous
+// issue tooling around this repo consumes to open and update issues without
+// creating duplicates.
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/identity"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/secrets"
+	"github.com/markus-lassfolk/rutos-starlink-failover/pkg/uci"
+)
+
+// schema identifies the payload format to the receiver.
+const schema = "starfail-problem/v1"
+
+// resendAfter suppresses re-posting a report with the same dedup key, so a
+// check that stays broken (or flaps) files one report, not one per interval.
+const resendAfter = 6 * time.Hour
+
+// Report is one problem in the wire format.
+type Report struct {
+	Schema    string    `json:"schema"`
+	Timestamp time.Time `json:"timestamp"`
+	Host      string    `json:"host"`
+	Device    string    `json:"device"`   // stable device ID (see pkg/identity)
+	Source    string    `json:"source"`   // starfaild | sysmgmt
+	Check     string    `json:"check"`    // disk, memory, service, collect, ...
+	Subject   string    `json:"subject"`  // the member or service concerned
+	Severity  string    `json:"severity"` // warning | critical
+	Detail    string    `json:"detail"`
+	DedupKey  string    `json:"dedup_key"`
+}
+
+// Reporter posts Reports to one endpoint. A nil Reporter is valid and
+// discards everything, so callers need no configured-or-not branches.
+type Reporter struct {
+	url    string
+	token  string
+	client *http.Client
+
+	mu       sync.Mutex
+	lastSent map[string]time.Time
+}
+
+// NewFromUCI builds a Reporter from starfail.webhook.url (token optional,
+// decrypted via secrets). Returns nil when no endpoint is configured.
+func NewFromUCI() *Reporter {
+	url, ok := uci.Get("starfail.webhook.url")
+	if !ok || url == "" {
+		return nil
+	}
+	token, _ := secrets.Get("starfail.webhook.token")
+	return New(url, token)
+}
+
+// New returns a Reporter posting to url, authenticating with token when set.
+func New(url, token string) *Reporter {
+	return &Reporter{
+		url:      url,
+		token:    token,
+		client:   &http.Client{Timeout: 15 * time.Second},
+		lastSent: make(map[string]time.Time),
+	}
+}
+
+// Report posts one problem in the background. The dedup key is
+// source:check:subject; repeats within resendAfter are dropped. Delivery
+// failures are logged and not retried — the next occurrence of a persistent
+// problem re-posts naturally once the suppression window passes.
+func (r *Reporter) Report(source, check, subject, severity, detail string) {
+	if r == nil {
+		return
+	}
+	key := source + ":" + check + ":" + subject
+	r.mu.Lock()
+	if t, ok := r.lastSent[key]; ok && time.Since(t) < resendAfter {
+		r.mu.Unlock()
+		return
+	}
+	r.lastSent[key] = time.Now()
+	r.mu.Unlock()
+	host, _ := os.Hostname()
+	go r.post(Report{
+		Schema:    schema,
+		Timestamp: time.Now(),
+		Host:      host,
+		Device:    identity.ID(),
+		Source:    source,
+		Check:     check,
+		Subject:   subject,
+		Severity:  severity,
+		Detail:    detail,
+		DedupKey:  key,
+	})
+}
+
+func (r *Reporter) post(rep Report) {
+	b, _ := json.Marshal(rep)
+	req, err := http.NewRequest(http.MethodPost, r.url, bytes.NewReader(b))
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if r.token != "" {
+		req.Header.Set("Authorization", "Bearer "+r.token)
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		log.Printf("webhook: post %s: %v", rep.DedupKey, err)
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		log.Printf("webhook: post %s: endpoint returned %s", rep.DedupKey, resp.Status)
+	}
+}
@@ -0,0 +1,74 @@
+package webhook
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestReportPostsSchema(t *testing.T) {
+	got := make(chan Report, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth := r.Header.Get("Authorization"); auth != "Bearer tok" {
+			t.Errorf("Authorization = %q, want bearer token", auth)
+		}
+		var rep Report
+		if err := json.NewDecoder(r.Body).Decode(&rep); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+		got <- rep
+	}))
+	defer srv.Close()
+
+	r := New(srv.URL, "tok")
+	r.Report("sysmgmt", "service", "mwan3", "critical", "mwan3 not running")
+
+	select {
+	case rep := <-got:
+		if rep.Schema != schema {
+			t.Errorf("schema = %q, want %q", rep.Schema, schema)
+		}
+		if rep.DedupKey != "sysmgmt:service:mwan3" {
+			t.Errorf("dedup_key = %q", rep.DedupKey)
+		}
+		if rep.Severity != "critical" || rep.Check != "service" {
+			t.Errorf("unexpected report %+v", rep)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no report delivered")
+	}
+}
+
+func TestReportDedupsWithinWindow(t *testing.T) {
+	posts := make(chan struct{}, 4)
+	srv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		posts <- struct{}{}
+	}))
+	defer srv.Close()
+
+	r := New(srv.URL, "")
+	for i := 0; i < 3; i++ {
+		r.Report("starfaild", "collect", "starlink", "warning", "5 consecutive failures")
+	}
+	r.Report("starfaild", "collect", "cellular", "warning", "5 consecutive failures")
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-posts:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("got %d posts, want 2 (one per dedup key)", i)
+		}
+	}
+	select {
+	case <-posts:
+		t.Fatal("duplicate report was posted")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestNilReporterIsSafe(t *testing.T) {
+	var r *Reporter
+	r.Report("starfaild", "collect", "starlink", "warning", "ignored")
+}
@@ -0,0 +1,132 @@
+// Command dishsim simulates the Starlink dish gRPC endpoint for
+// integration runs: it speaks just enough h2c to answer the daemon's
+// hand-rolled get_status client with a fixed healthy status. The framing
+// mirrors pkg/collector/starlink/pool.go; keeping the two in sync is the
+// point — a drift there is exactly the kind of regression the
+// integration environment exists to catch.
+package main
+
+import (
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+)
+
+const (
+	frameData     = 0x0
+	frameHeaders  = 0x1
+	frameSettings = 0x4
+
+	flagEndStream  = 0x1
+	flagEndHeaders = 0x4
+)
+
+var h2Preface = []byte("PRI * HTTP/2.0\r\n\r\nSM\r\n\r\n")
+
+func main() {
+	addr := flag.String("addr", ":9200", "listen address")
+	flag.Parse()
+
+	ln, err := net.Listen("tcp", *addr)
+	if err != nil {
+		log.Fatalf("dishsim: %v", err)
+	}
+	// The listen line is machine-read by the integration tests.
+	fmt.Printf("dishsim: listening on %s\n", ln.Addr())
+	_ = os.Stdout.Sync()
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			log.Fatalf("dishsim: accept: %v", err)
+		}
+		go serve(conn)
+	}
+}
+
+// serve handles one pooled connection: preface, then request/response
+// pairs until the client hangs up.
+func serve(conn net.Conn) {
+	defer conn.Close()
+	preface := make([]byte, len(h2Preface))
+	if _, err := io.ReadFull(conn, preface); err != nil || string(preface) != string(h2Preface) {
+		return
+	}
+	// Our settings; the client acks them from its read loop.
+	if _, err := conn.Write(appendFrame(nil, frameSettings, 0, 0, nil)); err != nil {
+		return
+	}
+
+	hdr := make([]byte, 9)
+	stream := uint32(1)
+	for {
+		if _, err := io.ReadFull(conn, hdr); err != nil {
+			return
+		}
+		length := int(hdr[0])<<16 | int(hdr[1])<<8 | int(hdr[2])
+		ftype, flags := hdr[3], hdr[4]
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(conn, payload); err != nil {
+			return
+		}
+		switch ftype {
+		case frameHeaders:
+			stream = binary.BigEndian.Uint32(hdr[5:]) &^ (1 << 31)
+		case frameData:
+			if flags&flagEndStream == 0 {
+				continue
+			}
+			if err := respond(conn, stream); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// respond sends one gRPC reply: HEADERS (:status 200) then DATA with the
+// length-prefixed status message and END_STREAM.
+func respond(conn net.Conn, stream uint32) error {
+	var buf []byte
+	buf = appendFrame(buf, frameHeaders, flagEndHeaders, stream, []byte{0x88})
+	msg := statusResponse()
+	body := make([]byte, 5+len(msg))
+	binary.BigEndian.PutUint32(body[1:5], uint32(len(msg)))
+	copy(body[5:], msg)
+	buf = appendFrame(buf, frameData, flagEndStream, stream, body)
+	_, err := conn.Write(buf)
+	return err
+}
+
+// statusResponse is a healthy dish: ~33ms pop ping, 150/20 Mbps, SNR
+// 9.5, 2% validated obstruction, a few hours of uptime.
+func statusResponse() []byte {
+	inner := []byte{
+		// device_state(1){ uptime_s(1) }
+		0x0a, 0x04, 0x08, 0xa0, 0xb8, 0x01,
+		// pop_ping_latency_ms(9) float32 33.25
+		0x4d, 0x00, 0x00, 0x05, 0x42,
+		// downlink_throughput_bps(10) float32 1.5e8
+		0x55, 0x18, 0x0d, 0x0f, 0x4d,
+		// uplink_throughput_bps(11) float32 2e7
+		0x5d, 0x80, 0x96, 0x98, 0x4b,
+		// snr(12) double 9.5
+		0x61, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x23, 0x40,
+		// obstruction_stats(7){ fraction_obstructed(1)=0.02, valid_s(5)=600 }
+		0x3a, 0x0a, 0x0d, 0x0a, 0xd7, 0xa3, 0x3c, 0x2d, 0x00, 0x00, 0x16, 0x44,
+	}
+	out := []byte{0xe2, 0x3e, byte(len(inner))}
+	return append(out, inner...)
+}
+
+// appendFrame mirrors the client's framing: 24-bit length, type, flags,
+// 31-bit stream id.
+func appendFrame(buf []byte, ftype, flags byte, stream uint32, payload []byte) []byte {
+	buf = append(buf,
+		byte(len(payload)>>16), byte(len(payload)>>8), byte(len(payload)),
+		ftype, flags,
+		byte(stream>>24), byte(stream>>16), byte(stream>>8), byte(stream))
+	return append(buf, payload...)
+}
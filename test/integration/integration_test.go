@@ -0,0 +1,266 @@
+//go:build integration
+
+// End-to-end wiring tests: build the real starfaild, run it against the
+// dish simulator and the mock RUTOS CLI tools, and drive it through its
+// control socket and HTTP endpoints the way starfailctl and a LAN client
+// would. Run with:
+//
+//	go test -tags integration ./test/integration
+//
+// The docker-compose environment in this directory runs the same pieces
+// as long-lived services; these tests are the CI-shaped version.
+package integration
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// repoRoot is where go build runs from.
+func repoRoot(t *testing.T) string {
+	t.Helper()
+	root, err := filepath.Abs("../..")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+// buildBinaries compiles starfaild and dishsim into dir.
+func buildBinaries(t *testing.T, dir string) (starfaild, dishsim string) {
+	t.Helper()
+	root := repoRoot(t)
+	starfaild = filepath.Join(dir, "starfaild")
+	dishsim = filepath.Join(dir, "dishsim")
+	for bin, pkg := range map[string]string{
+		starfaild: "./cmd/starfaild",
+		dishsim:   "./test/integration/dishsim",
+	} {
+		cmd := exec.Command("go", "build", "-o", bin, pkg)
+		cmd.Dir = root
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("build %s: %v\n%s", pkg, err, out)
+		}
+	}
+	return starfaild, dishsim
+}
+
+// startDishsim runs the simulator on a kernel-picked port and returns
+// its address.
+func startDishsim(t *testing.T, bin string) string {
+	t.Helper()
+	cmd := exec.Command(bin, "-addr", "127.0.0.1:0")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = cmd.Process.Kill(); _ = cmd.Wait() })
+	sc := bufio.NewScanner(stdout)
+	for sc.Scan() {
+		if addr, ok := strings.CutPrefix(sc.Text(), "dishsim: listening on "); ok {
+			return addr
+		}
+	}
+	t.Fatal("dishsim never reported its address")
+	return ""
+}
+
+// freePort asks the kernel for an unused TCP port.
+func freePort(t *testing.T) int {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	return ln.Addr().(*net.TCPAddr).Port
+}
+
+// writeUCIState writes the daemon's configuration in mock-uci format.
+func writeUCIState(t *testing.T, dir, dishAddr, monBind string) string {
+	t.Helper()
+	state := filepath.Join(dir, "uci.state")
+	lines := []string{
+		"starfail.main=starfail",
+		"starfail.main.enabled=1",
+		"starfail.main.poll_interval=1",
+		"starfail.starlink.addr=" + dishAddr,
+		"starfail.monitoring.bind=" + monBind,
+		"starfail.wan=member",
+		"starfail.wan.iface=wan",
+		"starfail.wan.class=starlink",
+		"starfail.wan.weight=100",
+		"starfail.mob1s1a1=member",
+		"starfail.mob1s1a1.iface=mob1s1a1",
+		"starfail.mob1s1a1.class=cellular",
+		"starfail.mob1s1a1.weight=50",
+	}
+	if broker := os.Getenv("INTEGRATION_MQTT_ADDR"); broker != "" {
+		host, port, err := net.SplitHostPort(broker)
+		if err != nil {
+			t.Fatalf("INTEGRATION_MQTT_ADDR: %v", err)
+		}
+		lines = append(lines,
+			"starfail.mqtt.host="+host,
+			"starfail.mqtt.port="+port,
+			"starfail.mqtt.interval=2",
+		)
+	}
+	if err := os.WriteFile(state, []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return state
+}
+
+// callCtl issues one request on the daemon's control socket.
+func callCtl(socket, method string, args any) (map[string]any, error) {
+	conn, err := net.Dial("unix", socket)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	req := map[string]any{"method": method}
+	if args != nil {
+		req["args"] = args
+	}
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return nil, err
+	}
+	var resp struct {
+		Result map[string]any `json:"result"`
+		Error  string         `json:"error"`
+	}
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return nil, err
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("%s: %s", method, resp.Error)
+	}
+	return resp.Result, nil
+}
+
+// waitFor polls check until it succeeds or the deadline passes.
+func waitFor(t *testing.T, timeout time.Duration, what string, check func() error) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	var err error
+	for time.Now().Before(deadline) {
+		if err = check(); err == nil {
+			return
+		}
+		time.Sleep(250 * time.Millisecond)
+	}
+	t.Fatalf("%s: %v", what, err)
+}
+
+func TestDaemonEndToEnd(t *testing.T) {
+	dir := t.TempDir()
+	starfaild, dishsim := buildBinaries(t, dir)
+	dishAddr := startDishsim(t, dishsim)
+	monBind := fmt.Sprintf("127.0.0.1:%d", freePort(t))
+	state := writeUCIState(t, dir, dishAddr, monBind)
+	socket := filepath.Join(dir, "ctl.sock")
+
+	mocks, err := filepath.Abs("mocks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(starfaild, "-socket", socket)
+	cmd.Env = append(os.Environ(),
+		"PATH="+mocks+string(os.PathListSeparator)+os.Getenv("PATH"),
+		"UCI_STATE_FILE="+state,
+	)
+	var logBuf strings.Builder
+	cmd.Stdout = &logBuf
+	cmd.Stderr = &logBuf
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = cmd.Process.Signal(syscall.SIGTERM)
+		done := make(chan struct{})
+		go func() { _ = cmd.Wait(); close(done) }()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			_ = cmd.Process.Kill()
+			<-done
+		}
+		if t.Failed() {
+			t.Logf("starfaild output:\n%s", logBuf.String())
+		}
+	})
+
+	// The daemon comes up, picks an active member, and says so over the
+	// control socket.
+	waitFor(t, 20*time.Second, "active member", func() error {
+		out, err := callCtl(socket, "status", nil)
+		if err != nil {
+			return err
+		}
+		if active, _ := out["active"].(string); active == "" {
+			return fmt.Errorf("no active member yet: %v", out)
+		}
+		return nil
+	})
+
+	// The starlink member is fed by the dish simulator, not mock data:
+	// its samples must be tagged grpc.
+	waitFor(t, 20*time.Second, "starlink grpc sample", func() error {
+		out, err := callCtl(socket, "members", nil)
+		if err != nil {
+			return err
+		}
+		members, _ := out["members"].([]any)
+		for _, raw := range members {
+			m, _ := raw.(map[string]any)
+			if m["name"] != "wan" {
+				continue
+			}
+			if src, _ := m["data_source"].(string); src != "grpc" {
+				return fmt.Errorf("starlink data_source = %q, want grpc", src)
+			}
+			return nil
+		}
+		return fmt.Errorf("no starlink member in %v", out)
+	})
+
+	// The monitoring endpoints answer on the configured bind.
+	waitFor(t, 10*time.Second, "healthz", func() error {
+		resp, err := http.Get("http://" + monBind + "/healthz")
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("healthz status %d", resp.StatusCode)
+		}
+		return nil
+	})
+	waitFor(t, 10*time.Second, "metrics", func() error {
+		resp, err := http.Get("http://" + monBind + "/metrics")
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		buf := make([]byte, 64<<10)
+		n, _ := resp.Body.Read(buf)
+		if !strings.Contains(string(buf[:n]), "starfail_member_score") {
+			return fmt.Errorf("no member score gauge in /metrics")
+		}
+		return nil
+	})
+}